@@ -6,14 +6,19 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"ezra-clone/backend/internal/adapter"
 	"ezra-clone/backend/internal/agent"
 	"ezra-clone/backend/internal/discord"
 	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/moderation"
+	"ezra-clone/backend/internal/ratelimit"
 	"ezra-clone/backend/internal/tools"
+	"ezra-clone/backend/internal/webhook"
 	"ezra-clone/backend/pkg/config"
 	"ezra-clone/backend/pkg/logger"
+	"ezra-clone/backend/pkg/tracing"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
@@ -40,6 +45,13 @@ func main() {
 		log.Fatal("DISCORD_BOT_TOKEN is required")
 	}
 
+	// Initialize tracing (no-op unless an OTLP endpoint is configured)
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.TracingEndpoint, cfg.TracingServiceName)
+	if err != nil {
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer shutdownTracing(context.Background())
+
 	// Initialize Neo4j driver
 	driver, err := neo4j.NewDriverWithContext(
 		cfg.Neo4jURI,
@@ -58,11 +70,74 @@ func main() {
 
 	// Initialize dependencies
 	graphRepo := graph.NewRepository(driver)
+	graphRepo.SetMaxPersonalityMemories(cfg.PersonalityMemoryMax)
+	memoryPolicy, _ := graph.ParseMemoryLimitPolicy(cfg.MemoryBlockLimitPolicy) // validated by config.Load
+	graphRepo.SetMemoryBlockLimit(cfg.MemoryBlockMaxChars, memoryPolicy)
+	graphRepo.SetFactConfidenceHalfLife(cfg.FactHalfLife)
+	graphRepo.SetArchivalScoring(cfg.ArchivalAutoScore, cfg.ArchivalDefaultRelevance)
+	if cfg.ArchivalAutoScore && cfg.ArchivalRescoreInterval > 0 {
+		defer graphRepo.StartArchivalRescoring(cfg.ArchivalRescoreInterval)()
+	}
+	var notifier *webhook.Notifier
+	if cfg.WebhookURL != "" {
+		notifier = webhook.NewNotifier(cfg.WebhookURL, cfg.WebhookSecret)
+		notifier.SetEventFilter(cfg.WebhookEvents)
+		defer notifier.Close()
+		graphRepo.SetMemoryEventListener(notifier)
+	}
+	if err := graphRepo.EnsureConstraints(ctx); err != nil {
+		log.Warn("Failed to ensure graph constraints", zap.Error(err))
+	}
 	llmAdapter := adapter.NewLLMAdapter(cfg.LiteLLMURL, cfg.OpenRouterAPIKey, cfg.ModelID)
+	if cfg.OpenAIAPIKey != "" {
+		llmAdapter.AddProvider(adapter.NewOpenAIProvider(adapter.ProviderOpenAI, cfg.OpenAIBaseURL, cfg.OpenAIAPIKey))
+	}
+	if cfg.AnthropicAPIKey != "" {
+		llmAdapter.AddProvider(adapter.NewAnthropicProvider(cfg.AnthropicBaseURL, cfg.AnthropicAPIKey, cfg.AnthropicMaxTokens))
+	}
+	if cfg.OllamaURL != "" {
+		llmAdapter.AddProvider(adapter.NewOllamaProvider(cfg.OllamaURL))
+	}
+	if err := llmAdapter.SetDefaultProvider(cfg.LLMProvider); err != nil {
+		log.Fatal("Invalid LLM provider", zap.Error(err))
+	}
+	llmAdapter.SetVisionModels(cfg.VisionModels)
+	llmAdapter.SetCaptionModel(cfg.CaptionModel)
+	if cfg.LLMDebugTrace {
+		llmAdapter.EnableDebugTrace(cfg.LLMTraceMaxTurns)
+		log.Warn("LLM debug tracing is on; prompts and responses are logged and kept in memory",
+			zap.Int("max_turns", cfg.LLMTraceMaxTurns),
+		)
+	}
 	agentOrch := agent.NewOrchestrator(graphRepo, llmAdapter)
+	agentOrch.SetRequireAgentModel(cfg.RequireAgentModel)
+	agentOrch.SetMemoryEvaluationLimits(cfg.MemoryEvalMinLength, cfg.MemoryEvalCooldown)
+	agentOrch.SetErrorAlertThreshold(cfg.ErrorAlertCount, cfg.ErrorAlertWindow)
+	if notifier != nil {
+		agentOrch.SetEventNotifier(notifier)
+	}
 
 	// Set LLM adapter for website summarization (uses LiteLLM)
 	agentOrch.SetLLMAdapterForTools(llmAdapter)
+	agentOrch.SetWebFetchLimits(cfg.WebFetchMaxBytes, cfg.WebFetchTimeout)
+	agentOrch.SetWebSearchFallback(cfg.WebSearchRelax, cfg.WebSearchFallbackURLs)
+	agentOrch.SetArticleLimits(cfg.ArticleContentChars, cfg.ArticleDigestChars)
+	agentOrch.SetWebCache(cfg.WebCacheMaxEntries, cfg.WebCacheTTL)
+	agentOrch.SetToolTimeouts(cfg.ToolTimeout, cfg.ToolTimeouts)
+	agentOrch.SetMaxToolCallsPerTurn(cfg.MaxToolCallsPerTurn)
+	var contentPolicy *moderation.Policy
+	if cfg.ContentFilterPatternsFile != "" {
+		contentPolicy, err = moderation.LoadPolicy(cfg.ContentFilterPatternsFile)
+		if err != nil {
+			log.Fatal("Failed to load content filter patterns", zap.Error(err))
+		}
+	}
+	agentOrch.SetContentFilter(moderation.NewFilter(contentPolicy, llmAdapter, cfg.ModerationModel, cfg.ContentFilterFallback, log))
+	agentOrch.SetPromptBudget(agent.PromptBudget{
+		ContextTokens:      cfg.PromptContextTokens,
+		ModelContextTokens: cfg.ModelContextTokens,
+		ReplyReserveTokens: cfg.PromptReplyReserveTokens,
+	})
 
 	// Create Discord session
 	dg, err := discordgo.New("Bot " + cfg.DiscordBotToken)
@@ -78,6 +153,8 @@ func main() {
 	// Initialize ComfyUI executor (always initialize for prompt enhancement, RunPod optional for image generation)
 	comfyExecutor := tools.NewComfyExecutor(llmAdapter, cfg)
 	agentOrch.SetComfyExecutor(comfyExecutor)
+	stopImageSweeper := comfyExecutor.StartImageSweeper()
+	defer stopImageSweeper()
 	if cfg.RunPodAPIKey != "" && cfg.RunPodEndpointID != "" {
 		log.Info("ComfyUI executor initialized with RunPod", zap.String("endpoint_id", cfg.RunPodEndpointID))
 	} else {
@@ -87,8 +164,16 @@ func main() {
 	// Initialize Music executor
 	musicExecutor := tools.NewMusicExecutor(dg, log, llmAdapter)
 	agentOrch.SetMusicExecutor(musicExecutor)
+	musicExecutor.SetIdleDisconnectGrace(cfg.VoiceIdleGrace)
 	log.Info("Music executor initialized")
 
+	// Initialize Voice executor for reference audio management
+	if cfg.VoiceReferenceDir != "" {
+		voiceExecutor := tools.NewVoiceExecutor(tools.NewReferenceAudioManager(cfg.VoiceReferenceDir), log)
+		agentOrch.SetVoiceExecutor(voiceExecutor)
+		log.Info("Voice executor initialized", zap.String("reference_dir", cfg.VoiceReferenceDir))
+	}
+
 	// Initialize Mimic background task
 	mimicTask := tools.NewMimicBackgroundTask(
 		agentOrch.GetToolExecutor(),
@@ -97,10 +182,37 @@ func main() {
 		cfg,
 		log,
 	)
+	if location, err := time.LoadLocation(cfg.MimicTimezone); err == nil {
+		mimicTask.SetSchedule(tools.NewMimicSchedule(cfg.MimicActiveFromHour, cfg.MimicActiveUntilHour, location, cfg.MimicDailyPostCap))
+	}
 	agentOrch.SetMimicBackgroundTask(mimicTask)
 	log.Info("Mimic background task initialized",
 		zap.String("mimic_channel_id", cfg.MimicChannelID),
+		zap.Int("active_from_hour", cfg.MimicActiveFromHour),
+		zap.Int("active_until_hour", cfg.MimicActiveUntilHour),
+		zap.String("timezone", cfg.MimicTimezone),
+		zap.Int("daily_post_cap", cfg.MimicDailyPostCap),
 	)
+	if restored, err := agentOrch.GetToolExecutor().RestoreMimicStates(ctx); err != nil {
+		log.Warn("Failed to restore mimic mode", zap.Error(err))
+	} else if restored > 0 {
+		log.Info("Restored mimic mode from before restart", zap.Int("agents", restored))
+	}
+
+	// Initialize the scheduler for scheduled messages and reminders
+	scheduler := tools.NewMessageScheduler(graphRepo, dg, log)
+	if err := scheduler.SetMissedPolicy(cfg.ScheduledMissed); err != nil {
+		log.Fatal("Invalid scheduled message policy", zap.Error(err))
+	}
+	if pending, err := scheduler.Load(ctx); err != nil {
+		log.Warn("Failed to load scheduled messages", zap.Error(err))
+	} else {
+		log.Info("Scheduled messages loaded",
+			zap.Int("pending", pending),
+			zap.String("missed_policy", cfg.ScheduledMissed),
+		)
+	}
+	agentOrch.SetMessageScheduler(scheduler)
 
 	// Create shutdown channel for programmatic shutdown
 	shutdownChan := make(chan os.Signal, 1)
@@ -114,11 +226,60 @@ func main() {
 
 	// Create message handler
 	messageHandler := discord.NewHandler(agentOrch, graphRepo, log)
+	messageHandler.SetAgentID(cfg.DefaultAgentID)
+	if cfg.VoiceAutoJoin {
+		messageHandler.SetVoiceJoiner(musicExecutor)
+		log.Info("Voice auto-join enabled")
+	}
+	if cfg.CommandPrefix != "" || len(cfg.GuildCommandPrefixes) > 0 {
+		messageHandler.SetCommandPrefixes(discord.NewCommandPrefixes(cfg.CommandPrefix, cfg.GuildCommandPrefixes))
+		log.Info("Prefix commands enabled", zap.String("prefix", cfg.CommandPrefix))
+	}
+	if limiter := ratelimit.New(float64(cfg.RateLimitPerMinute), cfg.RateLimitBurst, cfg.RateLimitExempt); limiter != nil {
+		messageHandler.SetRateLimiter(limiter)
+		defer limiter.Start(ratelimit.SweepInterval)()
+		log.Info("Per-user rate limit enabled",
+			zap.Int("per_minute", cfg.RateLimitPerMinute),
+			zap.Int("burst", cfg.RateLimitBurst),
+		)
+	}
+	if cfg.DailyMessageQuota > 0 {
+		messageHandler.SetDailyMessageQuota(cfg.DailyMessageQuota)
+		log.Info("Daily message quota enabled", zap.Int("limit", cfg.DailyMessageQuota))
+	}
+	if pace := discord.NewTypingPace(float64(cfg.TypingCharsPerSecond), float64(cfg.TypingJitterPercent)/100, cfg.TypingMaxDelay); pace != nil {
+		messageHandler.SetTypingPace(pace)
+		log.Info("Typing delays enabled",
+			zap.Int("chars_per_second", cfg.TypingCharsPerSecond),
+			zap.Duration("max_delay", cfg.TypingMaxDelay),
+		)
+	}
 
 	// Add message handler
 	dg.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
 		messageHandler.HandleMessage(s, m)
 	})
+	// Continue buttons under replies cut off by the length limit
+	dg.AddHandler(messageHandler.HandleInteraction)
+
+	// Keep stored history in sync when users edit or delete messages
+	messageHandler.SetHardDeleteMessages(cfg.HardDeleteMessages)
+	dg.AddHandler(messageHandler.HandleMessageUpdate)
+	dg.AddHandler(messageHandler.HandleMessageDelete)
+	dg.AddHandler(messageHandler.HandleMessageDeleteBulk)
+
+	// Re-apply the stored presence on every (re)connect and pick up API changes
+	presenceSync := discord.NewPresenceSync(graphRepo, dg, log)
+	dg.AddHandler(presenceSync.HandleReady)
+	if cfg.PresenceSyncInterval > 0 {
+		stopPresenceSync := presenceSync.Start(cfg.PresenceSyncInterval)
+		defer stopPresenceSync()
+	}
+
+	// Leave voice channels that have emptied out
+	dg.AddHandler(func(s *discordgo.Session, vsu *discordgo.VoiceStateUpdate) {
+		musicExecutor.HandleVoiceStateUpdate(s, vsu)
+	})
 
 	// Set intents (including voice state for music bot)
 	// Required intents:
@@ -142,6 +303,10 @@ func main() {
 	}
 	defer dg.Close()
 
+	// Send scheduled messages once the connection is up
+	stopScheduler := scheduler.Start(tools.ScheduledMessageTick)
+	defer stopScheduler()
+
 	log.Info("Discord bot is running. Press CTRL-C to exit.")
 
 	// Wait for interrupt signal (from CTRL-C or programmatic shutdown)
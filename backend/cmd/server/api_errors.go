@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"ezra-clone/backend/internal/graph"
+	apperrors "ezra-clone/backend/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Stable, machine-readable error codes returned in API error responses
+const (
+	codeInvalidRequest         = "invalid_request"
+	codeUnauthorized           = "unauthorized"
+	codeEndpointDisabled       = "endpoint_disabled"
+	codeNotFound               = "not_found"
+	codeAgentNotFound          = "agent_not_found"
+	codeArchivalMemoryNotFound = "archival_memory_not_found"
+	codeFactNotFound           = "fact_not_found"
+	codeTopicNotFound          = "topic_not_found"
+	codeChannelNotFound        = "channel_not_found"
+	codeUserNotFound           = "user_not_found"
+	codeGuildNotFound          = "guild_not_found"
+	codeToolNotFound           = "tool_not_found"
+	codeAgentExists            = "agent_exists"
+	codeAmbiguousUser          = "ambiguous_user"
+	codeQuotaExceeded          = "quota_exceeded"
+	codeRateLimited            = "rate_limited"
+	codeReindexRunning         = "reindex_running"
+	codeMemoryBlockTooLarge    = "memory_block_too_large"
+	codeMaxRecursion           = "max_recursion_exceeded"
+	codeLLMFailed              = "llm_failed"
+	codeLLMNoResponse          = "llm_no_response"
+	codeUpstreamFailed         = "upstream_failed"
+	codeDiscordUnavailable     = "discord_unavailable"
+	codeDatabaseUnavailable    = "database_unavailable"
+	codeTimeout                = "timeout"
+	codeInternal               = "internal_error"
+)
+
+// errorStatuses maps each error code to its HTTP status; codes not listed are 500s
+var errorStatuses = map[string]int{
+	codeInvalidRequest:         http.StatusBadRequest,
+	codeUnauthorized:           http.StatusUnauthorized,
+	codeEndpointDisabled:       http.StatusForbidden,
+	codeNotFound:               http.StatusNotFound,
+	codeAgentNotFound:          http.StatusNotFound,
+	codeArchivalMemoryNotFound: http.StatusNotFound,
+	codeFactNotFound:           http.StatusNotFound,
+	codeTopicNotFound:          http.StatusNotFound,
+	codeChannelNotFound:        http.StatusNotFound,
+	codeUserNotFound:           http.StatusNotFound,
+	codeGuildNotFound:          http.StatusNotFound,
+	codeToolNotFound:           http.StatusNotFound,
+	codeAgentExists:            http.StatusConflict,
+	codeAmbiguousUser:          http.StatusConflict,
+	codeQuotaExceeded:          http.StatusTooManyRequests,
+	codeRateLimited:            http.StatusTooManyRequests,
+	codeReindexRunning:         http.StatusConflict,
+	codeMemoryBlockTooLarge:    http.StatusBadRequest,
+	codeLLMFailed:              http.StatusBadGateway,
+	codeLLMNoResponse:          http.StatusBadGateway,
+	codeUpstreamFailed:         http.StatusBadGateway,
+	codeDiscordUnavailable:     http.StatusServiceUnavailable,
+	codeDatabaseUnavailable:    http.StatusServiceUnavailable,
+	codeTimeout:                http.StatusGatewayTimeout,
+}
+
+// APIError is the body of every error response, sent as {"error": APIError}
+type APIError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// newAPIError creates an API error; its HTTP status follows from code
+func newAPIError(code, message string) *APIError {
+	return &APIError{Code: code, Message: message}
+}
+
+// invalidRequest is a 400 for a malformed or invalid request
+func invalidRequest(message string) *APIError {
+	return newAPIError(codeInvalidRequest, message)
+}
+
+// WithDetails attaches extra machine-readable context
+func (e *APIError) WithDetails(details interface{}) *APIError {
+	e.Details = details
+	return e
+}
+
+// Status is the HTTP status for the error's code
+func (e *APIError) Status() int {
+	if status, ok := errorStatuses[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func (e *APIError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// toAPIError maps an internal error to an API error. Errors it doesn't
+// recognize become internal errors with the fallback message, so internal
+// details aren't leaked to clients.
+func toAPIError(err error, fallback string) *APIError {
+	var (
+		apiErr         *APIError
+		agentNotFound  graph.ErrAgentNotFound
+		agentExists    graph.ErrAgentExists
+		userNotFound   graph.ErrUserNotFound
+		memoryNotFound graph.ErrArchivalMemoryNotFound
+		factNotFound   graph.ErrFactNotFound
+		topicNotFound  graph.ErrTopicNotFound
+		tooLarge       graph.ErrMemoryBlockTooLarge
+		channelErr     *apperrors.ErrDiscordChannelNotFound
+		userErr        *apperrors.ErrDiscordUserNotFound
+		guildErr       *apperrors.ErrDiscordGuildNotFound
+		toolErr        *apperrors.ErrToolNotFound
+		llmErr         *apperrors.ErrAgentLLMFailed
+		graphErr       *apperrors.ErrGraphConnectionFailed
+		timeoutErr     *apperrors.ErrContextTimeout
+	)
+
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.As(err, &agentNotFound):
+		return newAPIError(codeAgentNotFound, agentNotFound.Error())
+	case errors.As(err, &agentExists):
+		return newAPIError(codeAgentExists, agentExists.Error())
+	case errors.As(err, &userNotFound):
+		return newAPIError(codeUserNotFound, userNotFound.Error())
+	case errors.As(err, &memoryNotFound):
+		return newAPIError(codeArchivalMemoryNotFound, memoryNotFound.Error())
+	case errors.As(err, &factNotFound):
+		return newAPIError(codeFactNotFound, factNotFound.Error())
+	case errors.As(err, &topicNotFound):
+		return newAPIError(codeTopicNotFound, topicNotFound.Error())
+	case errors.As(err, &tooLarge):
+		return newAPIError(codeMemoryBlockTooLarge, tooLarge.Error()).WithDetails(gin.H{
+			"block_name": tooLarge.BlockName,
+			"size":       tooLarge.Size,
+			"limit":      tooLarge.Limit,
+		})
+	case errors.As(err, &channelErr):
+		return newAPIError(codeChannelNotFound, channelErr.Message)
+	case errors.As(err, &userErr):
+		return newAPIError(codeUserNotFound, userErr.Message)
+	case errors.As(err, &guildErr):
+		return newAPIError(codeGuildNotFound, guildErr.Message)
+	case errors.As(err, &toolErr):
+		return newAPIError(codeToolNotFound, toolErr.Message)
+	case errors.As(err, &llmErr):
+		return newAPIError(codeLLMFailed, "The language model request failed").WithDetails(gin.H{
+			"model":     llmErr.Model,
+			"attempts":  llmErr.Attempts,
+			"retryable": llmErr.Retryable,
+		})
+	case errors.Is(err, apperrors.ErrAgentNoResponse):
+		return newAPIError(codeLLMNoResponse, "The language model returned no response")
+	case errors.Is(err, apperrors.ErrDiscordSessionUnavailable):
+		return newAPIError(codeDiscordUnavailable, "Discord is not available")
+	case errors.As(err, &graphErr):
+		return newAPIError(codeDatabaseUnavailable, "The database is not available")
+	case errors.As(err, &timeoutErr), errors.Is(err, context.DeadlineExceeded):
+		return newAPIError(codeTimeout, "The request timed out")
+	}
+	return newAPIError(codeInternal, fallback)
+}
+
+// writeError sends an API error response and stops the handler chain
+func writeError(c *gin.Context, apiErr *APIError) {
+	c.AbortWithStatusJSON(apiErr.Status(), gin.H{"error": apiErr})
+}
+
+// respondError maps err to an API error and sends it. Server-side failures
+// are logged with the fallback message, which is also what the client sees
+// for unrecognized errors.
+func respondError(c *gin.Context, log *zap.Logger, err error, fallback string) {
+	apiErr := toAPIError(err, fallback)
+	if apiErr.Status() >= http.StatusInternalServerError {
+		log.Error(fallback,
+			zap.String("code", apiErr.Code),
+			zap.String("path", c.FullPath()),
+			zap.Error(err),
+		)
+	}
+	writeError(c, apiErr)
+}
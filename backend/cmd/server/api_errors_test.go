@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ezra-clone/backend/internal/graph"
+	apperrors "ezra-clone/backend/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestToAPIError_MapsInternalErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   string
+		status int
+	}{
+		{"agent not found", graph.ErrAgentNotFound{AgentID: "Ezra"}, codeAgentNotFound, http.StatusNotFound},
+		{"wrapped agent not found", fmt.Errorf("failed to fetch state: %w", graph.ErrAgentNotFound{AgentID: "Ezra"}), codeAgentNotFound, http.StatusNotFound},
+		{"agent exists", graph.ErrAgentExists{AgentID: "Ezra"}, codeAgentExists, http.StatusConflict},
+		{"fact not found", graph.ErrFactNotFound{FactID: "f1"}, codeFactNotFound, http.StatusNotFound},
+		{"block too large", graph.ErrMemoryBlockTooLarge{BlockName: "persona", Size: 10, Limit: 5}, codeMemoryBlockTooLarge, http.StatusBadRequest},
+		{"discord channel", apperrors.NewDiscordChannelNotFound("123"), codeChannelNotFound, http.StatusNotFound},
+		{"llm failure", apperrors.NewAgentLLMFailed("gpt-4o", 3, true, errors.New("rate limited")), codeLLMFailed, http.StatusBadGateway},
+		{"no llm response", apperrors.ErrAgentNoResponse, codeLLMNoResponse, http.StatusBadGateway},
+		{"unknown", errors.New("neo4j: connection reset"), codeInternal, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := toAPIError(tt.err, "Something failed")
+			assert.Equal(t, tt.code, apiErr.Code)
+			assert.Equal(t, tt.status, apiErr.Status())
+		})
+	}
+
+	// Unrecognized errors don't leak internals
+	assert.Equal(t, "Something failed", toAPIError(errors.New("neo4j: connection reset"), "Something failed").Message)
+}
+
+func TestRespondError_WritesStructuredBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/agent/:id/state", func(c *gin.Context) {
+		respondError(c, zap.NewNop(), graph.ErrAgentNotFound{AgentID: c.Param("id")}, "Failed to fetch state")
+	})
+	router.PUT("/memory", func(c *gin.Context) {
+		respondError(c, zap.NewNop(), graph.ErrMemoryBlockTooLarge{BlockName: "persona", Size: 120, Limit: 100}, "Failed to update memory")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/agent/Ezra/state", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var body struct {
+		Error APIError `json:"error"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "agent_not_found", body.Error.Code)
+	assert.Equal(t, "agent not found: Ezra", body.Error.Message)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/memory", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error": {"code": "memory_block_too_large", "message": "memory block \"persona\" is 120 characters, over the 100 character limit", "details": {"block_name": "persona", "size": 120, "limit": 100}}}`, w.Body.String())
+}
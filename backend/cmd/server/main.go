@@ -2,22 +2,43 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/gin-gonic/gin"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"ezra-clone/backend/internal/adapter"
 	"ezra-clone/backend/internal/agent"
+	"ezra-clone/backend/internal/cache"
+	"ezra-clone/backend/internal/constants"
+	"ezra-clone/backend/internal/discord"
 	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/moderation"
+	"ezra-clone/backend/internal/ratelimit"
 	"ezra-clone/backend/internal/state"
 	"ezra-clone/backend/internal/tools"
+	"ezra-clone/backend/internal/webhook"
 	"ezra-clone/backend/pkg/config"
 	"ezra-clone/backend/pkg/logger"
+	"ezra-clone/backend/pkg/tracing"
 	"go.uber.org/zap"
 )
 
@@ -37,6 +58,13 @@ func main() {
 		log.Fatal("Failed to load configuration", zap.Error(err))
 	}
 
+	// Initialize tracing (no-op unless an OTLP endpoint is configured)
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.TracingEndpoint, cfg.TracingServiceName)
+	if err != nil {
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer shutdownTracing(context.Background())
+
 	// Initialize Neo4j driver
 	driver, err := neo4j.NewDriverWithContext(
 		cfg.Neo4jURI,
@@ -55,21 +83,104 @@ func main() {
 
 	// Initialize dependencies
 	graphRepo := graph.NewRepository(driver)
+	memoryPolicy, _ := graph.ParseMemoryLimitPolicy(cfg.MemoryBlockLimitPolicy) // validated by config.Load
+	graphRepo.SetMemoryBlockLimit(cfg.MemoryBlockMaxChars, memoryPolicy)
+	graphRepo.SetFactConfidenceHalfLife(cfg.FactHalfLife)
+	graphRepo.SetArchivalScoring(cfg.ArchivalAutoScore, cfg.ArchivalDefaultRelevance)
+	if cfg.ArchivalAutoScore && cfg.ArchivalRescoreInterval > 0 {
+		defer graphRepo.StartArchivalRescoring(cfg.ArchivalRescoreInterval)()
+	}
+	var notifier *webhook.Notifier
+	if cfg.WebhookURL != "" {
+		notifier = webhook.NewNotifier(cfg.WebhookURL, cfg.WebhookSecret)
+		notifier.SetEventFilter(cfg.WebhookEvents)
+		defer notifier.Close()
+		graphRepo.SetMemoryEventListener(notifier)
+	}
+	if err := graphRepo.EnsureConstraints(ctx); err != nil {
+		log.Warn("Failed to ensure graph constraints", zap.Error(err))
+	}
 	llmAdapter := adapter.NewLLMAdapter(cfg.LiteLLMURL, cfg.OpenRouterAPIKey, cfg.ModelID)
+	if cfg.OpenAIAPIKey != "" {
+		llmAdapter.AddProvider(adapter.NewOpenAIProvider(adapter.ProviderOpenAI, cfg.OpenAIBaseURL, cfg.OpenAIAPIKey))
+	}
+	if cfg.AnthropicAPIKey != "" {
+		llmAdapter.AddProvider(adapter.NewAnthropicProvider(cfg.AnthropicBaseURL, cfg.AnthropicAPIKey, cfg.AnthropicMaxTokens))
+	}
+	if cfg.OllamaURL != "" {
+		llmAdapter.AddProvider(adapter.NewOllamaProvider(cfg.OllamaURL))
+	}
+	if err := llmAdapter.SetDefaultProvider(cfg.LLMProvider); err != nil {
+		log.Fatal("Invalid LLM provider", zap.Error(err))
+	}
+	llmAdapter.SetVisionModels(cfg.VisionModels)
+	llmAdapter.SetCaptionModel(cfg.CaptionModel)
+	if cfg.LLMDebugTrace {
+		llmAdapter.EnableDebugTrace(cfg.LLMTraceMaxTurns)
+		log.Warn("LLM debug tracing is on; prompts and responses are logged and kept in memory",
+			zap.Int("max_turns", cfg.LLMTraceMaxTurns),
+		)
+	}
+	var reindexer *graph.EmbeddingReindexer
+	if cfg.EmbeddingModel != "" {
+		reindexer = graph.NewEmbeddingReindexer(graphRepo, llmAdapter, cfg.EmbeddingModel, cfg.EmbeddingBatchSize, log)
+	}
 	agentOrch := agent.NewOrchestrator(graphRepo, llmAdapter)
+	agentOrch.SetRequireAgentModel(cfg.RequireAgentModel)
+	agentOrch.SetMemoryEvaluationLimits(cfg.MemoryEvalMinLength, cfg.MemoryEvalCooldown)
+	agentOrch.SetErrorAlertThreshold(cfg.ErrorAlertCount, cfg.ErrorAlertWindow)
+	if notifier != nil {
+		agentOrch.SetEventNotifier(notifier)
+	}
 	
 	// Set LLM adapter for website summarization (uses LiteLLM)
 	agentOrch.SetLLMAdapterForTools(llmAdapter)
+	agentOrch.SetWebFetchLimits(cfg.WebFetchMaxBytes, cfg.WebFetchTimeout)
+	agentOrch.SetWebSearchFallback(cfg.WebSearchRelax, cfg.WebSearchFallbackURLs)
+	agentOrch.SetArticleLimits(cfg.ArticleContentChars, cfg.ArticleDigestChars)
+	agentOrch.SetWebCache(cfg.WebCacheMaxEntries, cfg.WebCacheTTL)
+	agentOrch.SetToolTimeouts(cfg.ToolTimeout, cfg.ToolTimeouts)
+	agentOrch.SetMaxToolCallsPerTurn(cfg.MaxToolCallsPerTurn)
+	var contentPolicy *moderation.Policy
+	if cfg.ContentFilterPatternsFile != "" {
+		contentPolicy, err = moderation.LoadPolicy(cfg.ContentFilterPatternsFile)
+		if err != nil {
+			log.Fatal("Failed to load content filter patterns", zap.Error(err))
+		}
+	}
+	agentOrch.SetContentFilter(moderation.NewFilter(contentPolicy, llmAdapter, cfg.ModerationModel, cfg.ContentFilterFallback, log))
+	agentOrch.SetPromptBudget(agent.PromptBudget{
+		ContextTokens:      cfg.PromptContextTokens,
+		ModelContextTokens: cfg.ModelContextTokens,
+		ReplyReserveTokens: cfg.PromptReplyReserveTokens,
+	})
 	
 	// Initialize ComfyUI executor (always initialize for prompt enhancement, RunPod optional for image generation)
 	comfyExecutor := tools.NewComfyExecutor(llmAdapter, cfg)
 	agentOrch.SetComfyExecutor(comfyExecutor)
+	stopImageSweeper := comfyExecutor.StartImageSweeper()
+	defer stopImageSweeper()
 	if cfg.RunPodAPIKey != "" && cfg.RunPodEndpointID != "" {
 		log.Info("ComfyUI executor initialized with RunPod", zap.String("endpoint_id", cfg.RunPodEndpointID))
 	} else {
 		log.Info("ComfyUI executor initialized (prompt enhancement only, RunPod not configured)")
 	}
 
+	// Discord REST access for personality analysis. The server never opens a
+	// gateway connection, so this doesn't compete with the bot.
+	var discordExecutor *tools.DiscordExecutor
+	var discordSession *discordgo.Session
+	if cfg.DiscordBotToken != "" {
+		dg, err := discordgo.New("Bot " + cfg.DiscordBotToken)
+		if err != nil {
+			log.Warn("Failed to create Discord session, personality analysis disabled", zap.Error(err))
+		} else {
+			discordSession = dg
+			discordExecutor = tools.NewDiscordExecutor(dg, log)
+			discordExecutor.SetRepository(graphRepo)
+		}
+	}
+
 	// Setup Gin router
 	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
@@ -93,26 +204,124 @@ func main() {
 		c.Next()
 	})
 
-	// Health check
+	// Liveness: the process is up
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Readiness: Neo4j and the configured downstream services are reachable
+	readinessChecks := buildReadinessChecks(driver, cfg)
+	router.GET("/ready", func(c *gin.Context) {
+		status, statuses := runReadinessChecks(c.Request.Context(), readinessChecks, cfg.ReadyTimeout)
+		code := http.StatusOK
+		if status == readinessNotReady {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{"status": status, "dependencies": statuses})
+	})
+
+	// Admin routes
+	admin := router.Group("/admin", requireAPIToken(cfg.APIAuthToken))
+	{
+		// Re-embed facts and archival memories that have no embedding from
+		// EMBEDDING_MODEL, in the background
+		admin.POST("/reindex-embeddings", func(c *gin.Context) {
+			if reindexer == nil {
+				writeError(c, newAPIError(codeEndpointDisabled, "Embeddings are disabled; set EMBEDDING_MODEL to enable them"))
+				return
+			}
+			progress, err := reindexer.Start()
+			if errors.Is(err, graph.ErrReindexRunning) {
+				writeError(c, newAPIError(codeReindexRunning, err.Error()).WithDetails(progress))
+				return
+			}
+			c.JSON(http.StatusAccepted, progress)
+		})
+
+		// Progress of the current or most recent reindex
+		admin.GET("/reindex-embeddings", func(c *gin.Context) {
+			if reindexer == nil {
+				writeError(c, newAPIError(codeEndpointDisabled, "Embeddings are disabled; set EMBEDDING_MODEL to enable them"))
+				return
+			}
+			c.JSON(http.StatusOK, reindexer.Progress())
+		})
+
+		// In-memory caches by name: tool results, image captions and stored images
+		caches := func() map[string]cache.Clearable {
+			all := agentOrch.Caches()
+			all["captions"] = llmAdapter.CaptionCache()
+			return all
+		}
+
+		// Size and hit rate of every cache
+		admin.GET("/caches", func(c *gin.Context) {
+			stats := make(map[string]cache.Stats)
+			for name, cached := range caches() {
+				stats[name] = cached.Stats()
+			}
+			c.JSON(http.StatusOK, gin.H{"caches": stats})
+		})
+
+		// Clear one cache, e.g. to force pages to be refetched
+		admin.DELETE("/caches/:name", func(c *gin.Context) {
+			name := c.Param("name")
+			all := caches()
+			cached, ok := all[name]
+			if !ok {
+				names := make([]string, 0, len(all))
+				for n := range all {
+					names = append(names, n)
+				}
+				sort.Strings(names)
+				writeError(c, newAPIError(codeNotFound, fmt.Sprintf("Unknown cache %q", name)).WithDetails(gin.H{"available": names}))
+				return
+			}
+			if err := cached.Clear(); err != nil {
+				respondError(c, log, err, "Failed to clear cache")
+				return
+			}
+			log.Info("Cache cleared", zap.String("cache", name))
+			c.JSON(http.StatusOK, gin.H{"cache": name, "stats": cached.Stats()})
+		})
+	}
+
+	// Per-user rate limit on chat, on top of the daily quota
+	chatLimiter := ratelimit.New(float64(cfg.RateLimitPerMinute), cfg.RateLimitBurst, cfg.RateLimitExempt)
+	defer chatLimiter.Start(ratelimit.SweepInterval)()
+	// Chat requests already charged, so a retry with the same idempotency key isn't charged again
+	chargedChats := ratelimit.NewRecentKeys(ratelimit.DuplicateWindow)
+
 	// API routes
-	api := router.Group("/api")
+	api := router.Group("/api", resolveDefaultAgent(cfg.DefaultAgentID))
 	{
-		// List all agents
+		// List agents, a page at a time, optionally filtered by name
 		api.GET("/agents", func(c *gin.Context) {
 			ctx := c.Request.Context()
 
-			agents, err := graphRepo.ListAgents(ctx)
+			limit := constants.DefaultAgentPageSize
+			if limitStr := c.Query("limit"); limitStr != "" {
+				if parsed, err := fmt.Sscanf(limitStr, "%d", &limit); err != nil || parsed != 1 || limit < 1 {
+					limit = constants.DefaultAgentPageSize
+				}
+			}
+			if limit > constants.MaxAgentPageSize {
+				limit = constants.MaxAgentPageSize
+			}
+			offset := 0
+			if offsetStr := c.Query("offset"); offsetStr != "" {
+				if parsed, err := fmt.Sscanf(offsetStr, "%d", &offset); err != nil || parsed != 1 || offset < 0 {
+					offset = 0
+				}
+			}
+
+			page, err := graphRepo.ListAgents(ctx, strings.TrimSpace(c.Query("q")), limit, offset)
 			if err != nil {
-				log.Error("Failed to list agents", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list agents"})
+				respondError(c, log, err, "Failed to list agents")
 				return
 			}
 
-			c.JSON(http.StatusOK, agents)
+			c.JSON(http.StatusOK, page)
 		})
 
 		// Get agent state
@@ -122,12 +331,7 @@ func main() {
 
 			state, err := graphRepo.FetchState(ctx, agentID)
 			if err != nil {
-				if _, ok := err.(graph.ErrAgentNotFound); ok {
-					c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
-					return
-				}
-				log.Error("Failed to fetch agent state", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch state"})
+				respondError(c, log, err, "Failed to fetch state")
 				return
 			}
 
@@ -141,12 +345,7 @@ func main() {
 
 			config, err := graphRepo.GetAgentConfig(ctx, agentID)
 			if err != nil {
-				if _, ok := err.(graph.ErrAgentNotFound); ok {
-					c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
-					return
-				}
-				log.Error("Failed to get agent config", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get config"})
+				respondError(c, log, err, "Failed to get config")
 				return
 			}
 
@@ -158,35 +357,87 @@ func main() {
 			c.JSON(http.StatusOK, config)
 		})
 
-		// Update agent configuration
-		api.PUT("/agent/:id/config", func(c *gin.Context) {
+		// Get the configuration actually in effect for an agent, with defaults applied
+		api.GET("/agent/:id/effective-config", func(c *gin.Context) {
 			agentID := c.Param("id")
 			ctx := c.Request.Context()
 
-			var req graph.AgentConfig
-			if err := c.ShouldBindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			agentConfig, err := graphRepo.GetAgentConfig(ctx, agentID)
+			if err != nil {
+				respondError(c, log, err, "Failed to get config")
 				return
 			}
 
-			if err := graphRepo.UpdateAgentConfig(ctx, agentID, req); err != nil {
-				if _, ok := err.(graph.ErrAgentNotFound); ok {
-					c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
+			c.JSON(http.StatusOK, buildEffectiveConfig(agentID, agentConfig, cfg))
+		})
+
+		// Update agent configuration. The body is merged into the stored
+		// config: fields it leaves out keep their current values.
+		api.PUT("/agent/:id/config", func(c *gin.Context) {
+			agentID := c.Param("id")
+			ctx := c.Request.Context()
+
+			stored, err := graphRepo.GetStoredAgentConfig(ctx, agentID)
+			if err != nil {
+				respondError(c, log, err, "Failed to get config")
+				return
+			}
+			if err := c.ShouldBindJSON(stored); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+			req := *stored
+			if req.MaxRecursionDepth < 0 || req.MaxRecursionDepth > constants.MaxRecursionDepthLimit {
+				writeError(c, invalidRequest(fmt.Sprintf("max_recursion_depth must be between 0 and %d", constants.MaxRecursionDepthLimit)))
+				return
+			}
+			if req.MaxPromptFacts < 0 {
+				writeError(c, invalidRequest("max_prompt_facts must not be negative"))
+				return
+			}
+			if h := req.MaxPromptHistory; h != nil && (*h < 0 || *h > constants.MaxPromptHistoryLimit) {
+				writeError(c, invalidRequest(fmt.Sprintf("max_prompt_history must be between 0 and %d", constants.MaxPromptHistoryLimit)))
+				return
+			}
+			if req.MaxTokens < 0 {
+				writeError(c, invalidRequest("max_tokens must not be negative"))
+				return
+			}
+			for _, provider := range []string{req.Provider, req.AuxiliaryProvider, req.EmbeddingProvider, req.ModerationProvider} {
+				if provider != "" && !llmAdapter.HasProvider(provider) {
+					writeError(c, invalidRequest(fmt.Sprintf("LLM provider %q is not configured", provider)).WithDetails(gin.H{"providers": llmAdapter.Providers()}))
 					return
 				}
-				log.Error("Failed to update agent config", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update config"})
+			}
+			if unknown := tools.UnknownToolNames(append(append([]string{}, req.AllowedTools...), req.DeniedTools...)); len(unknown) > 0 {
+				writeError(c, invalidRequest("unknown tools or capabilities: "+strings.Join(unknown, ", ")).WithDetails(gin.H{"unknown": unknown}))
+				return
+			}
+			if err := tools.ValidateStylePreset(req.ImageStylePreset); err != nil {
+				writeError(c, invalidRequest(err.Error()).WithDetails(gin.H{"style_presets": tools.StylePresetNames()}))
+				return
+			}
+			if err := (moderation.Settings{Mode: req.ContentFilter, Action: req.ContentFilterAction}).Validate(); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+
+			if err := graphRepo.UpdateAgentConfig(ctx, agentID, req); err != nil {
+				respondError(c, log, err, "Failed to update config")
 				return
 			}
 
 			c.JSON(http.StatusOK, gin.H{"status": "updated"})
 		})
 
-		// Get available tools for agent
+		// Get the tools the agent may use, after its allow and deny lists
 		api.GET("/agent/:id/tools", func(c *gin.Context) {
-			// Tools are the same for all agents, return all available tools
-			allTools := tools.GetAllTools()
-			c.JSON(http.StatusOK, allTools)
+			agentConfig, err := graphRepo.GetAgentConfig(c.Request.Context(), c.Param("id"))
+			if err != nil {
+				respondError(c, log, err, "Failed to get config")
+				return
+			}
+			c.JSON(http.StatusOK, tools.FilterTools(tools.GetAllTools(), tools.AgentToolAccess(agentConfig, nil)))
 		})
 
 		// Get context window statistics
@@ -196,12 +447,7 @@ func main() {
 
 			stats, err := graphRepo.GetContextStats(ctx, agentID)
 			if err != nil {
-				if _, ok := err.(graph.ErrAgentNotFound); ok {
-					c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
-					return
-				}
-				log.Error("Failed to get context stats", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get context stats"})
+				respondError(c, log, err, "Failed to get context stats")
 				return
 			}
 
@@ -215,12 +461,7 @@ func main() {
 
 			memories, err := graphRepo.GetArchivalMemories(ctx, agentID)
 			if err != nil {
-				if _, ok := err.(graph.ErrAgentNotFound); ok {
-					c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
-					return
-				}
-				log.Error("Failed to get archival memories", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get archival memories"})
+				respondError(c, log, err, "Failed to get archival memories")
 				return
 			}
 
@@ -232,9 +473,12 @@ func main() {
 			agentID := c.Param("id")
 			ctx := c.Request.Context()
 
-			var req graph.ArchivalMemory
+			var req struct {
+				graph.ArchivalMemory
+				Force bool `json:"force"` // Store as a separate entry even if a similar one exists
+			}
 			if err := c.ShouldBindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				writeError(c, invalidRequest(err.Error()))
 				return
 			}
 
@@ -243,17 +487,113 @@ func main() {
 				req.Timestamp = time.Now()
 			}
 
-			if err := graphRepo.CreateArchivalMemory(ctx, agentID, req); err != nil {
-				if _, ok := err.(graph.ErrAgentNotFound); ok {
-					c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
-					return
+			memoryID, merged, err := graphRepo.CreateArchivalMemory(ctx, agentID, req.ArchivalMemory, req.Force)
+			if err != nil {
+				respondError(c, log, err, "Failed to create archival memory")
+				return
+			}
+
+			status := "created"
+			if merged {
+				status = "merged"
+			}
+			c.JSON(http.StatusOK, gin.H{"status": status, "id": memoryID})
+		})
+
+		// Import archival memories in bulk, reporting a status per item
+		api.POST("/agent/:id/archival-memories/import", func(c *gin.Context) {
+			agentID := c.Param("id")
+			ctx := c.Request.Context()
+
+			var req struct {
+				Memories []graph.ArchivalImportItem `json:"memories" binding:"required"`
+				Atomic   bool                       `json:"atomic"` // Store all memories or none
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+			if len(req.Memories) == 0 {
+				writeError(c, invalidRequest("memories must not be empty"))
+				return
+			}
+
+			if _, err := graphRepo.GetAgentConfig(ctx, agentID); err != nil {
+				respondError(c, log, err, "Failed to import archival memories")
+				return
+			}
+
+			for i := range req.Memories {
+				if req.Memories[i].Timestamp.IsZero() {
+					req.Memories[i].Timestamp = time.Now()
 				}
-				log.Error("Failed to create archival memory", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create archival memory"})
+			}
+
+			resp, status := runBulk(bulkOps{
+				count: len(req.Memories),
+				validate: func(i int) error {
+					return validateArchivalImportItem(req.Memories[i])
+				},
+				writeOne: func(i int) (string, string, error) {
+					item := req.Memories[i]
+					memoryID, merged, err := graphRepo.CreateArchivalMemory(ctx, agentID, item.ArchivalMemory, item.Force)
+					if err != nil {
+						log.Error("Failed to import archival memory", zap.Int("index", i), zap.Error(err))
+						return "", "", fmt.Errorf("failed to store archival memory")
+					}
+					if merged {
+						return memoryID, bulkStatusMerged, nil
+					}
+					return memoryID, bulkStatusCreated, nil
+				},
+				writeAll: func() ([]string, []string, error) {
+					results, err := graphRepo.ImportArchivalMemories(ctx, agentID, req.Memories)
+					if err != nil {
+						log.Error("Failed to import archival memories", zap.Error(err))
+						return nil, nil, fmt.Errorf("import failed; no archival memories were stored")
+					}
+					ids := make([]string, len(results))
+					statuses := make([]string, len(results))
+					for i, result := range results {
+						ids[i] = result.ID
+						statuses[i] = bulkStatusCreated
+						if result.Merged {
+							statuses[i] = bulkStatusMerged
+						}
+					}
+					return ids, statuses, nil
+				},
+			}, req.Atomic)
+
+			c.JSON(status, resp)
+		})
+
+		// Update archival memory
+		api.PUT("/agent/:id/archival-memories/:memoryId", func(c *gin.Context) {
+			agentID := c.Param("id")
+			memoryID := c.Param("memoryId")
+			ctx := c.Request.Context()
+
+			var req graph.ArchivalMemoryUpdate
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+			if req.Summary == nil && req.Content == nil && req.RelevanceScore == nil {
+				writeError(c, invalidRequest("Nothing to update: set summary, content or relevance_score"))
+				return
+			}
+			if req.RelevanceScore != nil && (*req.RelevanceScore < 0 || *req.RelevanceScore > 1) {
+				writeError(c, invalidRequest("relevance_score must be between 0 and 1"))
+				return
+			}
+
+			if err := graphRepo.UpdateArchivalMemory(ctx, agentID, memoryID, req); err != nil {
+				respondError(c, log, err, "Failed to update archival memory")
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{"status": "created"})
+			c.JSON(http.StatusOK, gin.H{"status": "updated", "id": memoryID})
 		})
 
 		// Delete archival memory
@@ -263,12 +603,7 @@ func main() {
 			ctx := c.Request.Context()
 
 			if err := graphRepo.DeleteArchivalMemory(ctx, agentID, memoryID); err != nil {
-				if _, ok := err.(graph.ErrAgentNotFound); ok {
-					c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
-					return
-				}
-				log.Error("Failed to delete archival memory", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete archival memory"})
+				respondError(c, log, err, "Failed to delete archival memory")
 				return
 			}
 
@@ -282,14 +617,91 @@ func main() {
 
 			facts, err := graphRepo.GetAllFacts(ctx, agentID)
 			if err != nil {
-				log.Error("Failed to get facts", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facts"})
+				respondError(c, log, err, "Failed to get facts")
 				return
 			}
 
 			c.JSON(http.StatusOK, facts)
 		})
 
+		// Create facts in bulk, reporting a status per item
+		api.POST("/agent/:id/facts/batch", func(c *gin.Context) {
+			agentID := c.Param("id")
+			ctx := c.Request.Context()
+
+			var req struct {
+				Facts     []graph.FactInput `json:"facts" binding:"required"`
+				Atomic    bool              `json:"atomic"`     // Store all facts or none
+				SkipDedup bool              `json:"skip_dedup"` // Trusted import: only merge exact duplicates
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+			if len(req.Facts) == 0 {
+				writeError(c, invalidRequest("facts must not be empty"))
+				return
+			}
+
+			if _, err := graphRepo.GetAgentConfig(ctx, agentID); err != nil {
+				respondError(c, log, err, "Failed to create facts")
+				return
+			}
+
+			resp, status := runBulk(bulkOps{
+				count: len(req.Facts),
+				validate: func(i int) error {
+					return validateFactInput(req.Facts[i])
+				},
+				writeOne: func(i int) (string, string, error) {
+					results, err := graphRepo.CreateFactsBatch(ctx, agentID, req.Facts[i:i+1], req.SkipDedup)
+					if err != nil {
+						log.Error("Failed to create fact", zap.Int("index", i), zap.Error(err))
+						return "", "", fmt.Errorf("failed to store fact")
+					}
+					return results[0].ID, results[0].Status, nil
+				},
+				writeAll: func() ([]string, []string, error) {
+					results, err := graphRepo.CreateFactsBatch(ctx, agentID, req.Facts, req.SkipDedup)
+					if err != nil {
+						log.Error("Failed to create facts", zap.Error(err))
+						return nil, nil, fmt.Errorf("batch failed; no facts were stored")
+					}
+					ids := make([]string, len(results))
+					statuses := make([]string, len(results))
+					for i, result := range results {
+						ids[i] = result.ID
+						statuses[i] = result.Status
+					}
+					return ids, statuses, nil
+				},
+			}, req.Atomic)
+
+			c.JSON(status, resp)
+		})
+
+		// Pin or unpin a fact so memory cleanup never removes it
+		api.PUT("/agent/:id/facts/:factId/pin", func(c *gin.Context) {
+			agentID := c.Param("id")
+			factID := c.Param("factId")
+			ctx := c.Request.Context()
+
+			var req struct {
+				Pinned *bool `json:"pinned" binding:"required"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+
+			if err := graphRepo.SetFactPinned(ctx, agentID, factID, *req.Pinned); err != nil {
+				respondError(c, log, err, "Failed to pin fact")
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"id": factID, "pinned": *req.Pinned})
+		})
+
 		// Get all topics for an agent
 		api.GET("/agent/:id/topics", func(c *gin.Context) {
 			agentID := c.Param("id")
@@ -297,14 +709,85 @@ func main() {
 
 			topics, err := graphRepo.GetAllTopics(ctx, agentID)
 			if err != nil {
-				log.Error("Failed to get topics", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get topics"})
+				respondError(c, log, err, "Failed to get topics")
 				return
 			}
 
 			c.JSON(http.StatusOK, topics)
 		})
 
+		// Get a topic with its facts, subtopics and related topics
+		api.GET("/agent/:id/topics/:topic/facts", func(c *gin.Context) {
+			agentID := c.Param("id")
+			topicName := c.Param("topic")
+			limit := 50
+			if limitStr := c.Query("limit"); limitStr != "" {
+				if parsed, err := fmt.Sscanf(limitStr, "%d", &limit); err != nil || parsed != 1 || limit < 1 {
+					limit = 50
+				}
+			}
+			if limit > 200 {
+				limit = 200
+			}
+
+			ctx := c.Request.Context()
+			topic, err := graphRepo.GetTopic(ctx, topicName)
+			if err != nil {
+				respondError(c, log, err, "Failed to get topic")
+				return
+			}
+
+			facts, err := graphRepo.GetFactsByTopic(ctx, agentID, topicName, limit)
+			if err != nil {
+				respondError(c, log, err, "Failed to get facts")
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{
+				"topic": topic,
+				"facts": facts,
+			})
+		})
+
+		// Search an agent's facts, messages and topics with the full-text indexes
+		api.GET("/agent/:id/search", func(c *gin.Context) {
+			query := strings.TrimSpace(c.Query("q"))
+			if query == "" {
+				writeError(c, invalidRequest("q is required"))
+				return
+			}
+			var types []string
+			if typesStr := c.Query("types"); typesStr != "" {
+				for _, t := range strings.Split(typesStr, ",") {
+					t = strings.TrimSpace(t)
+					if !slices.Contains(graph.SearchTypes, t) {
+						writeError(c, invalidRequest(fmt.Sprintf("unknown type %q, expected %s", t, strings.Join(graph.SearchTypes, ", "))))
+						return
+					}
+					types = append(types, t)
+				}
+			}
+			limit := 20
+			if limitStr := c.Query("limit"); limitStr != "" {
+				parsed, err := strconv.Atoi(limitStr)
+				if err != nil || parsed < 1 {
+					writeError(c, invalidRequest("limit must be a positive integer"))
+					return
+				}
+				limit = min(parsed, 100)
+			}
+
+			results, err := graphRepo.FullTextSearch(c.Request.Context(), c.Param("id"), query, types, limit)
+			if err != nil {
+				respondError(c, log, err, "Failed to search")
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"query":   query,
+				"results": results,
+			})
+		})
+
 		// Get all messages for an agent
 		api.GET("/agent/:id/messages", func(c *gin.Context) {
 			agentID := c.Param("id")
@@ -318,33 +801,31 @@ func main() {
 
 			messages, err := graphRepo.GetAllMessages(ctx, agentID, limit)
 			if err != nil {
-				log.Error("Failed to get messages", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get messages"})
+				respondError(c, log, err, "Failed to get messages")
 				return
 			}
 
 			c.JSON(http.StatusOK, messages)
 		})
 
-		// Get all conversations for an agent
+		// List an agent's conversations a page at a time, optionally by platform and start time
 		api.GET("/agent/:id/conversations", func(c *gin.Context) {
 			agentID := c.Param("id")
 			ctx := c.Request.Context()
-			limit := 50
-			if limitStr := c.Query("limit"); limitStr != "" {
-				if parsed, err := fmt.Sscanf(limitStr, "%d", &limit); err != nil || parsed != 1 {
-					limit = 50
-				}
+
+			filter, err := parseConversationFilter(c.Request.URL.Query())
+			if err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
 			}
 
-			conversations, err := graphRepo.GetAllConversations(ctx, agentID, limit)
+			page, err := graphRepo.GetAllConversations(ctx, agentID, filter)
 			if err != nil {
-				log.Error("Failed to get conversations", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get conversations"})
+				respondError(c, log, err, "Failed to get conversations")
 				return
 			}
 
-			c.JSON(http.StatusOK, conversations)
+			c.JSON(http.StatusOK, page)
 		})
 
 		// Get all users for an agent
@@ -354,51 +835,137 @@ func main() {
 
 			users, err := graphRepo.GetAllUsers(ctx, agentID)
 			if err != nil {
-				log.Error("Failed to get users", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get users"})
+				respondError(c, log, err, "Failed to get users")
 				return
 			}
 
 			c.JSON(http.StatusOK, users)
 		})
 
-		// Create new agent
-		api.POST("/agents", func(c *gin.Context) {
-			ctx := c.Request.Context()
-
+		// Merge two user identities, keeping primary_id and folding secondary_id into it
+		api.POST("/users/merge", requireAPIToken(cfg.APIAuthToken), func(c *gin.Context) {
 			var req struct {
-				Name              string `json:"name" binding:"required"`
-				Model             string `json:"model"`
-				SystemInstructions string `json:"system_instructions"`
+				PrimaryID   string `json:"primary_id" binding:"required"`
+				SecondaryID string `json:"secondary_id" binding:"required"`
 			}
-
 			if err := c.ShouldBindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+			if req.PrimaryID == req.SecondaryID {
+				writeError(c, invalidRequest("primary_id and secondary_id must differ"))
 				return
 			}
 
-			// Generate agent ID from name (or use UUID)
-			agentID := req.Name
-			if err := graphRepo.CreateAgent(ctx, agentID, req.Name); err != nil {
-				log.Error("Failed to create agent", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create agent"})
+			merge, err := graphRepo.MergeUsers(c.Request.Context(), req.PrimaryID, req.SecondaryID)
+			if err != nil {
+				respondError(c, log, err, "Failed to merge users")
 				return
 			}
+			c.JSON(http.StatusOK, merge)
+		})
 
-			// Set initial config if provided
-			if req.Model != "" || req.SystemInstructions != "" {
-				config := graph.AgentConfig{
-					Model:              req.Model,
-					SystemInstructions: req.SystemInstructions,
-				}
-				if err := graphRepo.UpdateAgentConfig(ctx, agentID, config); err != nil {
-					log.Warn("Failed to set initial config", zap.Error(err))
-				}
+		// Link a web user to their Discord user, by Discord ID or username
+		api.POST("/user/:id/link", requireAPIToken(cfg.APIAuthToken), func(c *gin.Context) {
+			webUserID := c.Param("id")
+			var req struct {
+				DiscordID       string `json:"discord_id"`
+				DiscordUsername string `json:"discord_username"` // Used when discord_id isn't known
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
 			}
+			ctx := c.Request.Context()
 
-			// Create default identity
-			identity := state.AgentIdentity{
-				Name:        req.Name,
+			discordID := req.DiscordID
+			if discordID == "" {
+				if req.DiscordUsername == "" {
+					writeError(c, invalidRequest("discord_id or discord_username is required"))
+					return
+				}
+				candidates, err := graphRepo.FindDiscordUsers(ctx, req.DiscordUsername)
+				if err != nil {
+					respondError(c, log, err, "Failed to find Discord user")
+					return
+				}
+				switch len(candidates) {
+				case 0:
+					writeError(c, newAPIError(codeUserNotFound, "No Discord user with that username"))
+					return
+				case 1:
+					discordID = candidates[0].ID
+				default:
+					writeError(c, newAPIError(codeAmbiguousUser, "Several Discord users have that username; link by discord_id").WithDetails(gin.H{
+						"candidates": candidates,
+					}))
+					return
+				}
+			}
+			if discordID == webUserID {
+				writeError(c, invalidRequest("the web user is already that Discord user"))
+				return
+			}
+
+			merge, err := graphRepo.MergeUsers(ctx, discordID, webUserID)
+			if err != nil {
+				respondError(c, log, err, "Failed to link users")
+				return
+			}
+			c.JSON(http.StatusOK, merge)
+		})
+
+		// Delete everything stored about a user, across all agents (privacy requests)
+		api.DELETE("/user/:id/data", requireAPIToken(cfg.APIAuthToken), func(c *gin.Context) {
+			deletion, err := graphRepo.DeleteUserData(c.Request.Context(), c.Param("id"))
+			if err != nil {
+				respondError(c, log, err, "Failed to delete user data")
+				return
+			}
+
+			c.JSON(http.StatusOK, deletion)
+		})
+
+		// Create new agent
+		api.POST("/agents", func(c *gin.Context) {
+			ctx := c.Request.Context()
+
+			var req struct {
+				Name              string `json:"name" binding:"required"`
+				Model             string `json:"model"`
+				SystemInstructions string `json:"system_instructions"`
+			}
+
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+
+			// Generate agent ID from name (or use UUID)
+			agentID := req.Name
+			if agentID == constants.DefaultAgentAlias {
+				writeError(c, invalidRequest(fmt.Sprintf("%q is reserved for the default agent", constants.DefaultAgentAlias)))
+				return
+			}
+			if err := graphRepo.CreateAgent(ctx, agentID, req.Name); err != nil {
+				respondError(c, log, err, "Failed to create agent")
+				return
+			}
+
+			// Set initial config if provided
+			if req.Model != "" || req.SystemInstructions != "" {
+				config := graph.AgentConfig{
+					Model:              req.Model,
+					SystemInstructions: req.SystemInstructions,
+				}
+				if err := graphRepo.UpdateAgentConfig(ctx, agentID, config); err != nil {
+					log.Warn("Failed to set initial config", zap.Error(err))
+				}
+			}
+
+			// Create default identity
+			identity := state.AgentIdentity{
+				Name:        req.Name,
 				Personality: req.SystemInstructions,
 				Capabilities: []string{
 					"chat",
@@ -417,22 +984,124 @@ func main() {
 			})
 		})
 
+		// Clone an agent under a new ID
+		api.POST("/agents/:id/clone", func(c *gin.Context) {
+			sourceID := c.Param("id")
+			ctx := c.Request.Context()
+
+			var req struct {
+				NewID           string `json:"new_id" binding:"required"`
+				Name            string `json:"name"`
+				IncludeFacts    bool   `json:"include_facts"`
+				IncludeArchival bool   `json:"include_archival"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+
+			if req.NewID == constants.DefaultAgentAlias {
+				writeError(c, invalidRequest(fmt.Sprintf("%q is reserved for the default agent", constants.DefaultAgentAlias)))
+				return
+			}
+
+			opts := graph.CloneOptions{
+				Name:            req.Name,
+				IncludeFacts:    req.IncludeFacts,
+				IncludeArchival: req.IncludeArchival,
+			}
+			if err := graphRepo.CloneAgent(ctx, sourceID, req.NewID, opts); err != nil {
+				respondError(c, log, err, "Failed to clone agent")
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{
+				"id":          req.NewID,
+				"cloned_from": sourceID,
+			})
+		})
+
 		// Chat with agent
 		api.POST("/agent/:id/chat", func(c *gin.Context) {
 			agentID := c.Param("id")
 			ctx := c.Request.Context()
 
 			var req struct {
-				Message string `json:"message" binding:"required"`
-				UserID  string `json:"user_id" binding:"required"`
+				Message        string             `json:"message"` // Required unless attachments are sent
+				UserID         string             `json:"user_id" binding:"required"`
+				IdempotencyKey string             `json:"idempotency_key"` // Optional: makes retried requests log once
+				SessionID      string             `json:"session_id"`      // Optional: scopes history to a session
+				ChannelID      string             `json:"channel_id"`      // Optional: explicit channel, overrides session_id
+				Attachments    []tools.Attachment `json:"attachments"`     // Optional: files shared with the message
 			}
 
 			if err := c.ShouldBindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				writeError(c, invalidRequest(err.Error()))
 				return
 			}
+			if strings.TrimSpace(req.Message) == "" && len(req.Attachments) == 0 {
+				writeError(c, invalidRequest("message or attachments is required"))
+				return
+			}
+			for _, attachment := range req.Attachments {
+				if u, err := url.Parse(attachment.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					writeError(c, invalidRequest("attachment url must be an absolute http(s) URL"))
+					return
+				}
+			}
+
+			// Web users linked to a Discord user chat as that user
+			if userID, err := graphRepo.ResolveUserID(ctx, req.UserID); err != nil {
+				log.Warn("Failed to resolve user", zap.String("user_id", req.UserID), zap.Error(err))
+			} else {
+				req.UserID = userID
+			}
+
+			// Check for a retry first: a request already let through is
+			// answered again without being rate limited or counted twice
+			chargeKey := chatChargeKey(agentID, req.UserID, req.IdempotencyKey, req.Message)
+			if !chargedChats.Seen(chargeKey) {
+				if decision := chatLimiter.Allow(req.UserID); !decision.Allowed {
+					retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+					c.Header("Retry-After", strconv.Itoa(retryAfter))
+					writeError(c, newAPIError(codeRateLimited, "Too many messages; slow down").WithDetails(gin.H{
+						"retry_after_seconds": retryAfter,
+					}))
+					return
+				}
 
-			result, err := agentOrch.RunTurn(ctx, agentID, req.UserID, req.Message)
+				if cfg.DailyMessageQuota > 0 {
+					now := time.Now()
+					allowed, usage, err := graphRepo.ConsumeMessageQuota(ctx, req.UserID, cfg.DailyMessageQuota, now)
+					if err != nil {
+						// Don't lock users out because the count couldn't be read
+						log.Warn("Failed to check message quota", zap.String("user_id", req.UserID), zap.Error(err))
+					} else if !allowed {
+						writeError(c, newAPIError(codeQuotaExceeded, graph.QuotaExceededMessage(cfg.DailyMessageQuota)).WithDetails(gin.H{
+							"limit":     cfg.DailyMessageQuota,
+							"used":      usage.Count,
+							"resets_at": now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour),
+						}))
+						return
+					}
+				}
+				chargedChats.Add(chargeKey)
+			}
+
+			channelID := req.ChannelID
+			if channelID == "" {
+				channelID = resolveWebChannelID(cfg.WebChannelPattern, agentID, req.SessionID)
+			}
+
+			execCtx := &tools.ExecutionContext{
+				AgentID:        agentID,
+				UserID:         req.UserID,
+				ChannelID:      channelID,
+				Platform:       "web",
+				IdempotencyKey: req.IdempotencyKey,
+				Attachments:    req.Attachments,
+			}
+			result, err := agentOrch.RunTurnWithExecutionContext(ctx, execCtx, req.Message)
 			if err != nil {
 				if err == agent.ErrIgnored {
 					c.JSON(http.StatusOK, gin.H{
@@ -441,15 +1110,28 @@ func main() {
 					})
 					return
 				}
-				log.Error("Failed to run agent turn", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process message"})
+				if err == agent.ErrMaxRecursion {
+					log.Warn("Agent turn hit max recursion depth", zap.String("agent_id", agentID), zap.Int("depth", result.Depth))
+					writeError(c, newAPIError(codeMaxRecursion, "Agent exceeded its maximum recursion depth").WithDetails(gin.H{
+						"depth":             result.Depth,
+						"max_depth_reached": true,
+					}))
+					return
+				}
+				respondError(c, log, err, "Failed to process message")
 				return
 			}
 
 			c.JSON(http.StatusOK, gin.H{
-				"content":    result.Content,
-				"tool_calls": result.ToolCalls,
-				"ignored":    result.Ignored,
+				"content":           result.Content,
+				"tool_calls":        result.ToolCalls,
+				"ignored":           result.Ignored,
+				"channel_id":        channelID,
+				"depth":             result.Depth,
+				"max_depth_reached": result.MaxDepthReached,
+				"truncated":         result.Truncated,
+				"turn_id":           execCtx.IdempotencyKey,
+				"usage":             result.Usage,
 			})
 		})
 
@@ -461,16 +1143,16 @@ func main() {
 			var req struct {
 				BlockName string `json:"block_name" binding:"required"`
 				Content   string `json:"content" binding:"required"`
+				Order     *int   `json:"order"` // Position in the prompt; omitted keeps the current one
 			}
 
 			if err := c.ShouldBindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				writeError(c, invalidRequest(err.Error()))
 				return
 			}
 
-			if err := graphRepo.UpdateMemory(ctx, agentID, req.BlockName, req.Content); err != nil {
-				log.Error("Failed to update memory", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update memory"})
+			if err := graphRepo.UpdateMemoryWithOrder(ctx, agentID, req.BlockName, req.Content, req.Order); err != nil {
+				respondError(c, log, err, "Failed to update memory")
 				return
 			}
 
@@ -484,44 +1166,224 @@ func main() {
 			ctx := c.Request.Context()
 
 			if err := graphRepo.DeleteMemory(ctx, agentID, blockName); err != nil {
-				if _, ok := err.(graph.ErrAgentNotFound); ok {
-					c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
-					return
-				}
-				log.Error("Failed to delete memory", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete memory"})
+				respondError(c, log, err, "Failed to delete memory")
 				return
 			}
 
 			c.JSON(http.StatusOK, gin.H{"status": "deleted"})
 		})
 
-		// Get conversation history for a specific channel
-		api.GET("/agent/:id/conversation-history", func(c *gin.Context) {
+		// Show what the memory evaluator would save from a message, without saving it
+		api.POST("/agent/:id/memory/evaluate", func(c *gin.Context) {
 			agentID := c.Param("id")
+
+			var req struct {
+				Message string `json:"message" binding:"required"`
+				UserID  string `json:"user_id" binding:"required"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+
+			ctx := c.Request.Context()
+			evaluation, err := agentOrch.GetMemoryEvaluator().DryRun(ctx, agentID, req.UserID, req.Message)
+			if err != nil {
+				respondError(c, log, err, "Failed to evaluate message")
+				return
+			}
+
+			c.JSON(http.StatusOK, evaluation)
+		})
+
+		// Analyze a Discord user's writing style (reads their message history)
+		api.POST("/agent/:id/analyze-personality", requireAPIToken(cfg.APIAuthToken), func(c *gin.Context) {
+			var req struct {
+				ChannelID    string `json:"channel_id" binding:"required"`
+				UserID       string `json:"user_id" binding:"required"`
+				MessageCount int    `json:"message_count"`
+				ForceUpdate  bool   `json:"force_update"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+			if discordExecutor == nil {
+				writeError(c, newAPIError(codeDiscordUnavailable, "Personality analysis needs Discord access; set DISCORD_BOT_TOKEN"))
+				return
+			}
+
+			ctx := c.Request.Context()
+			profile, err := discordExecutor.AnalyzeUserPersonality(ctx, req.ChannelID, req.UserID, req.MessageCount, req.ForceUpdate)
+			if err != nil {
+				respondError(c, log, err, "Failed to analyze personality")
+				return
+			}
+
+			c.JSON(http.StatusOK, profile)
+		})
+
+		// Get a user's cached personality profile without re-analyzing
+		api.GET("/agent/:id/analyze-personality", requireAPIToken(cfg.APIAuthToken), func(c *gin.Context) {
+			userID := c.Query("user_id")
+			guildID := c.Query("guild_id")
 			channelID := c.Query("channel_id")
-			if channelID == "" {
-				channelID = "web-" + agentID
+			if userID == "" || (guildID == "" && channelID == "") {
+				writeError(c, invalidRequest("user_id and either guild_id or channel_id are required"))
+				return
 			}
-			limit := 20
-			if limitStr := c.Query("limit"); limitStr != "" {
-				if parsed, err := fmt.Sscanf(limitStr, "%d", &limit); err != nil || parsed != 1 {
-					limit = 20
-				}
+			if discordExecutor == nil {
+				writeError(c, newAPIError(codeDiscordUnavailable, "Personality analysis needs Discord access; set DISCORD_BOT_TOKEN"))
+				return
 			}
 
 			ctx := c.Request.Context()
-			messages, err := graphRepo.GetConversationHistory(ctx, channelID, limit)
+			if guildID == "" {
+				resolved, err := discordExecutor.PersonalityGuildID(ctx, channelID)
+				if err != nil {
+					writeError(c, newAPIError(codeChannelNotFound, err.Error()))
+					return
+				}
+				guildID = resolved
+			}
+
+			profile, err := discordExecutor.CachedPersonalityProfile(ctx, userID, guildID)
 			if err != nil {
-				log.Error("Failed to get conversation history", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get conversation history"})
+				respondError(c, log, err, "Failed to get personality profile")
+				return
+			}
+			if profile == nil {
+				writeError(c, newAPIError(codeNotFound, "No personality profile cached for this user"))
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"messages":   messages,
-				"channel_id": channelID,
-			})
+			c.JSON(http.StatusOK, profile)
+		})
+
+		// Get the Discord presence the bot applies
+		api.GET("/bot/status", requireAPIToken(cfg.APIAuthToken), func(c *gin.Context) {
+			presence, err := graphRepo.GetBotPresence(c.Request.Context())
+			if err != nil {
+				respondError(c, log, err, "Failed to get bot status")
+				return
+			}
+			if presence == nil {
+				presence = &graph.BotPresence{Status: string(discordgo.StatusOnline)}
+			}
+			c.JSON(http.StatusOK, presence)
+		})
+
+		// Set the bot's Discord presence. It is stored so the bot applies it
+		// within DISCORD_PRESENCE_SYNC_SECONDS and again on every reconnect.
+		api.PUT("/bot/status", requireAPIToken(cfg.APIAuthToken), func(c *gin.Context) {
+			var req graph.BotPresence
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+			presence, err := discord.NormalizePresence(req)
+			if err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+
+			stored, err := graphRepo.SetBotPresence(c.Request.Context(), presence)
+			if err != nil {
+				respondError(c, log, err, "Failed to set bot status")
+				return
+			}
+			c.JSON(http.StatusOK, stored)
+		})
+
+		// Replace the bot account's avatar from an image URL
+		api.PUT("/bot/avatar", requireAPIToken(cfg.APIAuthToken), func(c *gin.Context) {
+			var req struct {
+				ImageURL string `json:"image_url" binding:"required"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+			if u, err := url.Parse(req.ImageURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				writeError(c, invalidRequest("image_url must be an absolute http(s) URL"))
+				return
+			}
+			if discordSession == nil {
+				writeError(c, newAPIError(codeDiscordUnavailable, "Updating the avatar needs Discord access; set DISCORD_BOT_TOKEN"))
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+			defer cancel()
+			image, contentType, err := discord.FetchAvatar(ctx, http.DefaultClient, req.ImageURL)
+			if err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+			user, err := discord.SetAvatar(discordSession, image, contentType)
+			if err != nil {
+				log.Error("Failed to update bot avatar", zap.Error(err))
+				writeError(c, newAPIError(codeUpstreamFailed, err.Error()))
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"id": user.ID, "avatar": user.Avatar, "avatar_url": user.AvatarURL("")})
+		})
+
+		// Read, export and clear a channel's conversation history
+		registerConversationHistoryRoutes(api, graphRepo, cfg.WebChannelPattern, log)
+
+		// Token usage and estimated cost over a range of UTC days (default: the last 30)
+		api.GET("/agent/:id/usage", func(c *gin.Context) {
+			agentID := c.Param("id")
+			from, to, err := parseUsageRange(c.Query("from"), c.Query("to"), time.Now())
+			if err != nil {
+				writeError(c, invalidRequest(err.Error()))
+				return
+			}
+
+			report, err := graphRepo.GetTokenUsage(c.Request.Context(), agentID, from, to)
+			if err != nil {
+				respondError(c, log, err, "Failed to get token usage")
+				return
+			}
+			c.JSON(http.StatusOK, usageResponse(agentID, report, cfg.ModelPrices))
+		})
+
+		// Get the LLM calls made during a turn, when LLM_DEBUG_TRACE is on
+		api.GET("/agent/:id/turns/:turn_id/trace", requireAPIToken(cfg.APIAuthToken), func(c *gin.Context) {
+			if !llmAdapter.DebugTraceEnabled() {
+				writeError(c, newAPIError(codeEndpointDisabled, "LLM tracing is disabled; set LLM_DEBUG_TRACE=true to enable it"))
+				return
+			}
+			trace, ok := llmAdapter.Trace(c.Param("id"), c.Param("turn_id"))
+			if !ok {
+				writeError(c, newAPIError(codeNotFound, "No trace for this turn; it may be older than the retained turns"))
+				return
+			}
+			c.JSON(http.StatusOK, trace)
+		})
+
+		// Compact the agent's conversations: all but each channel's keep_recent
+		// latest messages become archival summaries
+		api.POST("/agent/:id/compact", func(c *gin.Context) {
+			agentID := c.Param("id")
+			keepRecent := graph.DefaultCompactKeepRecent
+			if keepStr := c.Query("keep_recent"); keepStr != "" {
+				parsed, err := strconv.Atoi(keepStr)
+				if err != nil || parsed < 0 {
+					writeError(c, invalidRequest("keep_recent must be a non-negative integer"))
+					return
+				}
+				keepRecent = parsed
+			}
+
+			compaction, err := graphRepo.CompactConversations(c.Request.Context(), agentID, keepRecent)
+			if err != nil {
+				respondError(c, log, err, "Failed to compact conversations")
+				return
+			}
+
+			c.JSON(http.StatusOK, compaction)
 		})
 	}
 
@@ -557,6 +1419,717 @@ func main() {
 	log.Info("Server exited")
 }
 
+// effectiveConfig is an agent's config merged with server defaults and feature flags
+type effectiveConfig struct {
+	AgentID                string          `json:"agent_id"`
+	Model                  string          `json:"model"`
+	ModelSource            string          `json:"model_source"` // "agent" or "default"
+	Provider               string          `json:"provider"`
+	AuxiliaryProvider      string          `json:"auxiliary_provider"`
+	AuxiliaryModel         string          `json:"auxiliary_model"`
+	EmbeddingProvider      string          `json:"embedding_provider"`
+	EmbeddingModel         string          `json:"embedding_model"`
+	ModerationProvider     string          `json:"moderation_provider"`
+	ModerationModel        string          `json:"moderation_model"`
+	MaxTokens              int             `json:"max_tokens"` // 0 leaves the limit to the provider
+	SystemInstructions     string          `json:"system_instructions"`
+	PersonaCheck           bool            `json:"persona_check"`
+	ContentFilter          string          `json:"content_filter"`
+	ContentFilterAction    string          `json:"content_filter_action"`
+	AllowedTools           []string        `json:"allowed_tools"`
+	DeniedTools            []string        `json:"denied_tools"`
+	ImageStylePreset       string          `json:"image_style_preset"`
+	MaxRecursionDepth      int             `json:"max_recursion_depth"`
+	MaxPromptFacts         int             `json:"max_prompt_facts"`
+	MaxPromptHistory       int             `json:"max_prompt_history"`
+	Env                    string          `json:"env"`
+	DefaultWebChannel      string          `json:"default_web_channel"`
+	WebFetchMaxBytes       int64           `json:"web_fetch_max_bytes"`
+	WebFetchTimeoutSeconds float64         `json:"web_fetch_timeout_seconds"`
+	Features               map[string]bool `json:"features"`
+}
+
+// buildEffectiveConfig applies the same fallbacks the chat path uses, so the
+// result reflects what a turn would actually run with. Secrets are never included.
+func buildEffectiveConfig(agentID string, agentConfig *graph.AgentConfig, cfg *config.Config) effectiveConfig {
+	effective := effectiveConfig{
+		AgentID:                agentID,
+		Model:                  agentConfig.Model,
+		ModelSource:            "agent",
+		Provider:               agentConfig.Provider,
+		AuxiliaryProvider:      agentConfig.AuxiliaryProvider,
+		AuxiliaryModel:         agentConfig.AuxiliaryModel,
+		EmbeddingProvider:      agentConfig.EmbeddingProvider,
+		EmbeddingModel:         agentConfig.EmbeddingModel,
+		ModerationProvider:     agentConfig.ModerationProvider,
+		ModerationModel:        agentConfig.ModerationModel,
+		MaxTokens:              agentConfig.MaxTokens,
+		SystemInstructions:     agentConfig.SystemInstructions,
+		PersonaCheck:           agentConfig.PersonaCheck,
+		ContentFilter:          agentConfig.ContentFilter,
+		ContentFilterAction:    agentConfig.ContentFilterAction,
+		AllowedTools:           agentConfig.AllowedTools,
+		DeniedTools:            agentConfig.DeniedTools,
+		ImageStylePreset:       agentConfig.ImageStylePreset,
+		MaxRecursionDepth:      agentConfig.MaxRecursionDepth,
+		MaxPromptFacts:         agentConfig.MaxPromptFacts,
+		MaxPromptHistory:       constants.DefaultPromptHistoryLimit,
+		Env:                    cfg.Env,
+		DefaultWebChannel:      resolveWebChannelID(cfg.WebChannelPattern, agentID, ""),
+		WebFetchMaxBytes:       cfg.WebFetchMaxBytes,
+		WebFetchTimeoutSeconds: cfg.WebFetchTimeout.Seconds(),
+		Features: map[string]bool{
+			"discord":          cfg.DiscordBotToken != "",
+			"image_generation": cfg.RunPodAPIKey != "" && cfg.RunPodEndpointID != "",
+			"openrouter":       cfg.OpenRouterAPIKey != "",
+			"voice_auto_join":  cfg.VoiceAutoJoin,
+		},
+	}
+	if effective.Provider == "" {
+		effective.Provider = cfg.LLMProvider
+	}
+	if effective.AuxiliaryProvider == "" {
+		effective.AuxiliaryProvider = cfg.LLMProvider
+	}
+	if effective.AuxiliaryModel == "" {
+		effective.AuxiliaryModel = cfg.ModelID
+	}
+	if effective.EmbeddingProvider == "" {
+		effective.EmbeddingProvider = cfg.LLMProvider
+	}
+	if effective.EmbeddingModel == "" {
+		effective.EmbeddingModel = cfg.EmbeddingModel
+	}
+	if effective.ModerationProvider == "" {
+		effective.ModerationProvider = cfg.LLMProvider
+	}
+	if effective.ModerationModel == "" {
+		effective.ModerationModel = cfg.ModerationModel
+	}
+	if effective.ModerationModel == "" {
+		effective.ModerationModel = adapter.DefaultModerationModel
+	}
+	if effective.MaxRecursionDepth == 0 {
+		effective.MaxRecursionDepth = constants.MaxRecursionDepth
+	}
+	if effective.MaxPromptFacts == 0 {
+		effective.MaxPromptFacts = constants.DefaultPromptFactLimit
+	}
+	if agentConfig.MaxPromptHistory != nil {
+		effective.MaxPromptHistory = *agentConfig.MaxPromptHistory
+	}
+	if effective.ContentFilter != moderation.ModeOff && effective.ContentFilterAction == "" {
+		effective.ContentFilterAction = moderation.ActionReplace
+	}
+	if effective.Model == "" && cfg.RequireAgentModel {
+		effective.ModelSource = "missing" // Turns fail until the agent gets a model
+	} else if effective.Model == "" {
+		effective.Model = cfg.ModelID
+		effective.ModelSource = "default"
+	}
+	return effective
+}
+
+// chatChargeKey identifies a chat request for retries: the same user
+// sending the same message to the same agent with the same idempotency key.
+// It is empty, matching nothing, without an idempotency key.
+func chatChargeKey(agentID, userID, idempotencyKey, message string) string {
+	if idempotencyKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(message))
+	return strings.Join([]string{agentID, userID, idempotencyKey, hex.EncodeToString(sum[:])}, "|")
+}
+
+// resolveWebChannelID builds the channel used to log and look up web chat.
+// The pattern's {agent_id} placeholder is filled in, and a session ID, if
+// given, is appended so each session gets its own history.
+func resolveWebChannelID(pattern, agentID, sessionID string) string {
+	if pattern == "" {
+		pattern = "web-{agent_id}"
+	}
+	channelID := strings.ReplaceAll(pattern, "{agent_id}", agentID)
+	if sessionID != "" {
+		channelID += "-" + sessionID
+	}
+	return channelID
+}
+
+// Overall /ready statuses
+const (
+	readinessReady    = "ready"
+	readinessDegraded = "degraded"  // Only optional dependencies are down
+	readinessNotReady = "not_ready" // A critical dependency is down
+)
+
+// readinessCheck probes one dependency for /ready
+type readinessCheck struct {
+	name     string
+	critical bool
+	probe    func(ctx context.Context) error
+}
+
+// dependencyStatus is one dependency's entry in the /ready response
+type dependencyStatus struct {
+	Status    string `json:"status"` // "ok" or "unavailable"
+	Critical  bool   `json:"critical"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// buildReadinessChecks lists the dependencies /ready probes: Neo4j always,
+// LiteLLM when it is the default LLM provider, and voice services only when
+// their URL is configured
+func buildReadinessChecks(driver neo4j.DriverWithContext, cfg *config.Config) []readinessCheck {
+	critical := make(map[string]bool, len(cfg.ReadyCriticalDeps))
+	for _, name := range cfg.ReadyCriticalDeps {
+		critical[name] = true
+	}
+
+	client := &http.Client{}
+	checks := []readinessCheck{
+		{name: "neo4j", critical: critical["neo4j"], probe: driver.VerifyConnectivity},
+	}
+	if cfg.LLMProvider == adapter.ProviderLiteLLM {
+		checks = append(checks, readinessCheck{name: "litellm", critical: critical["litellm"], probe: httpReachable(client, cfg.LiteLLMURL)})
+	}
+	if cfg.STTURL != "" {
+		checks = append(checks, readinessCheck{name: "stt", critical: critical["stt"], probe: httpReachable(client, cfg.STTURL)})
+	}
+	if cfg.TTSURL != "" {
+		checks = append(checks, readinessCheck{name: "tts", critical: critical["tts"], probe: httpReachable(client, cfg.TTSURL)})
+	}
+	return checks
+}
+
+// httpReachable probes a service by requesting its URL. Any response below
+// 500 counts as reachable; a service only needs to be up, not to serve "/".
+func httpReachable(client *http.Client, rawURL string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return fmt.Errorf("invalid URL: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// runReadinessChecks probes every dependency concurrently, each under timeout,
+// and returns the overall status with a status per dependency
+func runReadinessChecks(ctx context.Context, checks []readinessCheck, timeout time.Duration) (string, map[string]dependencyStatus) {
+	results := make([]dependencyStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check readinessCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.probe(checkCtx)
+			results[i] = dependencyStatus{Status: "ok", Critical: check.critical, LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				results[i].Status = "unavailable"
+				results[i].Error = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	status := readinessReady
+	statuses := make(map[string]dependencyStatus, len(checks))
+	for i, check := range checks {
+		statuses[check.name] = results[i]
+		if results[i].Status == "ok" {
+			continue
+		}
+		if check.critical {
+			status = readinessNotReady
+		} else if status == readinessReady {
+			status = readinessDegraded
+		}
+	}
+	return status, statuses
+}
+
+// exportBatchSize is how many messages a conversation export reads at a time
+const exportBatchSize = 500
+
+// conversationStore is what the conversation history endpoints read and
+// clear; *graph.Repository implements it
+type conversationStore interface {
+	conversationPager
+	GetConversationHistory(ctx context.Context, channelID string, limit int) ([]graph.Message, error)
+	ResetConversation(ctx context.Context, agentID, channelID string, archive bool) (*graph.ConversationReset, error)
+}
+
+// registerConversationHistoryRoutes adds the endpoints that read, export and
+// clear a conversation. Each picks its channel from channel_id, or else from
+// the agent's web channel for session_id.
+func registerConversationHistoryRoutes(api *gin.RouterGroup, store conversationStore, webChannelPattern string, log *zap.Logger) {
+	// Get conversation history for a specific channel
+	api.GET("/agent/:id/conversation-history", func(c *gin.Context) {
+		agentID := c.Param("id")
+		channelID := c.Query("channel_id")
+		if channelID == "" {
+			channelID = resolveWebChannelID(webChannelPattern, agentID, c.Query("session_id"))
+		}
+		limit := 20
+		if limitStr := c.Query("limit"); limitStr != "" {
+			if parsed, err := fmt.Sscanf(limitStr, "%d", &limit); err != nil || parsed != 1 {
+				limit = 20
+			}
+		}
+
+		ctx := c.Request.Context()
+		messages, err := store.GetConversationHistory(ctx, channelID, limit)
+		if err != nil {
+			respondError(c, log, err, "Failed to get conversation history")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"messages":   messages,
+			"channel_id": channelID,
+		})
+	})
+
+	// Export the agent's side of a channel's conversation as JSON or Markdown,
+	// streamed a batch at a time
+	api.GET("/agent/:id/conversation-history/export", func(c *gin.Context) {
+		agentID := c.Param("id")
+		channelID := c.Query("channel_id")
+		if channelID == "" {
+			channelID = resolveWebChannelID(webChannelPattern, agentID, c.Query("session_id"))
+		}
+		format := strings.ToLower(c.DefaultQuery("format", "json"))
+		if format == "md" {
+			format = "markdown"
+		}
+		if format != "json" && format != "markdown" {
+			writeError(c, invalidRequest("format must be json or markdown"))
+			return
+		}
+
+		contentType, extension := "application/json; charset=utf-8", "json"
+		if format == "markdown" {
+			contentType, extension = "text/markdown; charset=utf-8", "md"
+		}
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(channelID, extension)))
+
+		err := exportConversation(c.Request.Context(), c.Writer, store, agentID, channelID, format, time.Now().UTC())
+		if err != nil {
+			if c.Writer.Written() {
+				// Too late for an error response; the client sees a truncated export
+				log.Error("Failed to export conversation", zap.String("channel_id", channelID), zap.Error(err))
+				return
+			}
+			c.Writer.Header().Del("Content-Disposition")
+			c.Writer.Header().Del("Content-Type")
+			respondError(c, log, err, "Failed to export conversation")
+		}
+	})
+
+	// Clear a channel's conversation history, archiving it first unless archive=false
+	api.DELETE("/agent/:id/conversation-history", func(c *gin.Context) {
+		agentID := c.Param("id")
+		channelID := c.Query("channel_id")
+		if channelID == "" {
+			channelID = resolveWebChannelID(webChannelPattern, agentID, c.Query("session_id"))
+		}
+		archive := c.DefaultQuery("archive", "true") != "false"
+
+		ctx := c.Request.Context()
+		reset, err := store.ResetConversation(ctx, agentID, channelID, archive)
+		if err != nil {
+			respondError(c, log, err, "Failed to reset conversation")
+			return
+		}
+
+		c.JSON(http.StatusOK, reset)
+	})
+}
+
+// conversationPager reads a conversation a page at a time; *graph.Repository implements it
+type conversationPager interface {
+	GetConversationPage(ctx context.Context, agentID, channelID string, after *graph.MessageCursor, limit int) ([]graph.Message, error)
+}
+
+// defaultUsageDays is how many days GET /agent/:id/usage covers without a from date
+const defaultUsageDays = 30
+
+// parseUsageRange validates the from and to dates (YYYY-MM-DD, UTC) of a
+// usage query. to defaults to today and from to defaultUsageDays days
+// ending on to.
+func parseUsageRange(fromStr, toStr string, now time.Time) (string, string, error) {
+	to := now.UTC()
+	if toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return "", "", fmt.Errorf("to must be a date like 2026-01-31")
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -(defaultUsageDays - 1))
+	if fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return "", "", fmt.Errorf("from must be a date like 2026-01-01")
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		return "", "", fmt.Errorf("from must not be after to")
+	}
+	return graph.UsageDay(from), graph.UsageDay(to), nil
+}
+
+// parseConversationFilter reads the platform, since, until, limit and offset
+// query parameters of a conversation listing. since and until must be
+// RFC3339 times; a malformed limit or offset falls back to its default, as
+// for other listings.
+func parseConversationFilter(query url.Values) (graph.ConversationFilter, error) {
+	filter := graph.ConversationFilter{
+		Platform: strings.TrimSpace(query.Get("platform")),
+		Limit:    constants.DefaultConversationPageSize,
+	}
+	for _, bound := range []struct {
+		name string
+		dest *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		value := query.Get(bound.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return graph.ConversationFilter{}, fmt.Errorf("%s must be an RFC3339 time like 2026-01-31T00:00:00Z", bound.name)
+		}
+		*bound.dest = parsed
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return graph.ConversationFilter{}, fmt.Errorf("since must be before until")
+	}
+
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 {
+		filter.Limit = min(limit, constants.MaxConversationPageSize)
+	}
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+	return filter, nil
+}
+
+// usageResponse adds estimated costs to a token usage report. Models missing
+// from prices are listed under unpriced_models and left out of the cost.
+func usageResponse(agentID string, report *graph.TokenUsageReport, prices map[string]config.ModelPrice) gin.H {
+	models := make([]gin.H, 0, len(report.Models))
+	unpriced := []string{}
+	var prompt, completion int64
+	var cost float64
+	for _, usage := range report.Models {
+		entry := gin.H{
+			"model":             usage.Model,
+			"prompt_tokens":     usage.PromptTokens,
+			"completion_tokens": usage.CompletionTokens,
+			"total_tokens":      usage.PromptTokens + usage.CompletionTokens,
+			"calls":             usage.Calls,
+		}
+		if price, ok := prices[usage.Model]; ok {
+			modelCost := price.Cost(usage.PromptTokens, usage.CompletionTokens)
+			entry["estimated_cost_usd"] = modelCost
+			cost += modelCost
+		} else {
+			unpriced = append(unpriced, usage.Model)
+		}
+		prompt += usage.PromptTokens
+		completion += usage.CompletionTokens
+		models = append(models, entry)
+	}
+
+	return gin.H{
+		"agent_id":           agentID,
+		"from":               report.From,
+		"to":                 report.To,
+		"prompt_tokens":      prompt,
+		"completion_tokens":  completion,
+		"total_tokens":       prompt + completion,
+		"estimated_cost_usd": cost,
+		"unpriced_models":    unpriced,
+		"models":             models,
+		"lifetime": gin.H{
+			"prompt_tokens":     report.TotalPromptTokens,
+			"completion_tokens": report.TotalCompletionTokens,
+			"total_tokens":      report.TotalPromptTokens + report.TotalCompletionTokens,
+			"turns":             report.TotalTurns,
+		},
+	}
+}
+
+// exportFilename builds a download filename from a channel ID
+func exportFilename(channelID, extension string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, channelID)
+	return "conversation-" + name + "." + extension
+}
+
+// exportConversation writes an agent's whole conversation in a channel to w
+// as "json" or "markdown", paging through it so only one batch is held in
+// memory. The output is flushed after each batch when w supports it.
+func exportConversation(ctx context.Context, w io.Writer, pager conversationPager, agentID, channelID, format string, exportedAt time.Time) error {
+	// Read the first batch before writing so a failed lookup can still be reported
+	batch, err := pager.GetConversationPage(ctx, agentID, channelID, nil, exportBatchSize)
+	if err != nil {
+		return err
+	}
+
+	markdown := format == "markdown"
+	if markdown {
+		fmt.Fprintf(w, "# Conversation %s\n\nExported %s\n", channelID, exportedAt.Format(time.RFC3339))
+	} else {
+		agent, _ := json.Marshal(agentID)
+		channel, _ := json.Marshal(channelID)
+		fmt.Fprintf(w, `{"agent_id":%s,"channel_id":%s,"exported_at":%q,"messages":[`, agent, channel, exportedAt.Format(time.RFC3339))
+	}
+
+	first := true
+	for len(batch) > 0 {
+		for _, msg := range batch {
+			if markdown {
+				writeMarkdownMessage(w, msg)
+				continue
+			}
+			data, err := json.Marshal(msg)
+			if err != nil {
+				return fmt.Errorf("failed to encode message %s: %w", msg.ID, err)
+			}
+			if !first {
+				io.WriteString(w, ",")
+			}
+			first = false
+			w.Write(data)
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if len(batch) < exportBatchSize {
+			break
+		}
+
+		last := batch[len(batch)-1]
+		batch, err = pager.GetConversationPage(ctx, agentID, channelID, &graph.MessageCursor{Timestamp: last.Timestamp, ID: last.ID}, exportBatchSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !markdown {
+		_, err = io.WriteString(w, "]}\n")
+	}
+	return err
+}
+
+// writeMarkdownMessage writes one message as a role-prefixed Markdown section.
+// The content is kept verbatim; an unclosed code fence is closed so it can't
+// swallow the messages after it.
+func writeMarkdownMessage(w io.Writer, msg graph.Message) {
+	heading := msg.Role
+	if msg.SenderName != "" {
+		heading += " (" + msg.SenderName + ")"
+	}
+	fmt.Fprintf(w, "\n---\n\n**%s** · %s\n\n", heading, msg.Timestamp.UTC().Format("2006-01-02 15:04:05 UTC"))
+
+	content := strings.TrimRight(msg.Content, "\n")
+	io.WriteString(w, content+"\n")
+	if strings.Count(content, "```")%2 == 1 {
+		io.WriteString(w, "```\n")
+	}
+	for _, link := range msg.Attachments {
+		fmt.Fprintf(w, "\nAttachment: <%s>\n", link)
+	}
+}
+
+// Per-item statuses reported by bulk endpoints
+const (
+	bulkStatusCreated = "created"
+	bulkStatusMerged  = "merged"
+	bulkStatusFailed  = "failed"
+	bulkStatusSkipped = "skipped" // Not stored: a near-duplicate, or (with an error) the atomic batch was rejected
+)
+
+// bulkItemResult is the outcome of one item in a bulk request
+type bulkItemResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// bulkResponse is the body returned by bulk endpoints
+type bulkResponse struct {
+	Atomic    bool             `json:"atomic"`
+	Succeeded int              `json:"succeeded"`
+	Skipped   int              `json:"skipped"` // Deliberately not stored, e.g. duplicates
+	Failed    int              `json:"failed"`
+	Items     []bulkItemResult `json:"items"`
+}
+
+// bulkOps describes how to validate and store the items of a bulk request.
+// Errors returned by the write funcs are shown to the client as-is.
+type bulkOps struct {
+	count    int
+	validate func(i int) error
+	writeOne func(i int) (id, status string, err error) // Non-atomic mode
+	writeAll func() (ids, statuses []string, err error) // Atomic mode, in a single transaction
+}
+
+// runBulk validates and stores every item, reporting a status per item.
+// Non-atomic batches store each valid item independently. Atomic batches
+// store nothing unless every item is valid and the whole write succeeds.
+// The HTTP status is 201 if all items succeeded, 207 if only some did, 422
+// if none did because of invalid items and 500 if an atomic write failed.
+func runBulk(ops bulkOps, atomic bool) (bulkResponse, int) {
+	resp := bulkResponse{Atomic: atomic, Items: make([]bulkItemResult, ops.count)}
+	valid := make([]bool, ops.count)
+	invalid := 0
+	for i := 0; i < ops.count; i++ {
+		resp.Items[i].Index = i
+		if err := ops.validate(i); err != nil {
+			resp.Items[i].Status = bulkStatusFailed
+			resp.Items[i].Error = err.Error()
+			invalid++
+			continue
+		}
+		valid[i] = true
+	}
+
+	writeFailed := false
+	switch {
+	case atomic && invalid > 0:
+		for i := range resp.Items {
+			if valid[i] {
+				resp.Items[i].Status = bulkStatusSkipped
+				resp.Items[i].Error = "batch rejected: other items are invalid"
+			}
+		}
+	case atomic:
+		ids, statuses, err := ops.writeAll()
+		for i := range resp.Items {
+			if err != nil {
+				resp.Items[i].Status = bulkStatusFailed
+				resp.Items[i].Error = err.Error()
+				continue
+			}
+			resp.Items[i].ID = ids[i]
+			resp.Items[i].Status = statuses[i]
+		}
+		writeFailed = err != nil
+	default:
+		for i := range resp.Items {
+			if !valid[i] {
+				continue
+			}
+			id, status, err := ops.writeOne(i)
+			if err != nil {
+				resp.Items[i].Status = bulkStatusFailed
+				resp.Items[i].Error = err.Error()
+				continue
+			}
+			resp.Items[i].ID = id
+			resp.Items[i].Status = status
+		}
+	}
+
+	for _, item := range resp.Items {
+		switch {
+		case item.Status == bulkStatusCreated || item.Status == bulkStatusMerged:
+			resp.Succeeded++
+		case item.Status == bulkStatusSkipped && item.Error == "":
+			resp.Skipped++
+		default:
+			resp.Failed++
+		}
+	}
+
+	switch {
+	case writeFailed:
+		return resp, http.StatusInternalServerError
+	case resp.Failed == 0:
+		return resp, http.StatusCreated
+	case resp.Succeeded+resp.Skipped > 0:
+		return resp, http.StatusMultiStatus
+	default:
+		return resp, http.StatusUnprocessableEntity
+	}
+}
+
+// validateArchivalImportItem checks one archival import entry
+func validateArchivalImportItem(item graph.ArchivalImportItem) error {
+	if strings.TrimSpace(item.Summary) == "" && strings.TrimSpace(item.Content) == "" {
+		return fmt.Errorf("summary or content is required")
+	}
+	if item.RelevanceScore < 0 || item.RelevanceScore > 1 {
+		return fmt.Errorf("relevance_score must be between 0 and 1")
+	}
+	return nil
+}
+
+// validateFactInput checks one batch fact entry
+func validateFactInput(input graph.FactInput) error {
+	if strings.TrimSpace(input.Content) == "" {
+		return fmt.Errorf("content is required")
+	}
+	if input.Confidence != nil && (*input.Confidence < 0 || *input.Confidence > 1) {
+		return fmt.Errorf("confidence must be between 0 and 1")
+	}
+	return nil
+}
+
+// requireAPIToken rejects requests without "Authorization: Bearer <token>".
+// With no token configured the endpoint is closed rather than left open.
+func requireAPIToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			writeError(c, newAPIError(codeEndpointDisabled, "This endpoint is disabled; set API_AUTH_TOKEN to enable it"))
+			return
+		}
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			writeError(c, newAPIError(codeUnauthorized, "Invalid or missing API token"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// resolveDefaultAgent replaces the "default" alias in an agent ID path
+// parameter with the configured default agent, so single-agent deployments
+// don't need to know its ID
+func resolveDefaultAgent(defaultAgentID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for i, param := range c.Params {
+			if param.Key == "id" && param.Value == constants.DefaultAgentAlias {
+				c.Params[i].Value = defaultAgentID
+			}
+		}
+		c.Next()
+	}
+}
+
 // ginLogger is a custom logger middleware for Gin
 func ginLogger(log *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -2,13 +2,22 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
+
+	"ezra-clone/backend/internal/constants"
+	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/pkg/config"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 )
 
 func TestHealthEndpoint(t *testing.T) {
@@ -80,3 +89,424 @@ func TestMemoryUpdateEndpoint_InvalidRequest(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestResolveWebChannelID(t *testing.T) {
+	assert.Equal(t, "web-Ezra", resolveWebChannelID("web-{agent_id}", "Ezra", ""))
+	assert.Equal(t, "web-Ezra-abc", resolveWebChannelID("web-{agent_id}", "Ezra", "abc"))
+	assert.Equal(t, "chat/Ezra", resolveWebChannelID("chat/{agent_id}", "Ezra", ""))
+	assert.Equal(t, "web-Ezra", resolveWebChannelID("", "Ezra", ""))
+}
+
+func TestChatChargeKey(t *testing.T) {
+	key := chatChargeKey("Ezra", "user-1", "msg-42", "hello")
+	assert.Equal(t, key, chatChargeKey("Ezra", "user-1", "msg-42", "hello"), "a retry has the same key")
+	assert.NotEqual(t, key, chatChargeKey("Ezra", "user-1", "msg-42", "something else"), "a reused key with a new message is a new request")
+	assert.NotEqual(t, key, chatChargeKey("Ezra", "user-2", "msg-42", "hello"))
+	assert.NotEqual(t, key, chatChargeKey("Other", "user-1", "msg-42", "hello"))
+	assert.Empty(t, chatChargeKey("Ezra", "user-1", "", "hello"), "requests without a key are never retries")
+}
+
+// fakeConversationStore keeps messages by channel the way the graph does
+type fakeConversationStore struct {
+	fakePager
+	history map[string][]graph.Message
+	resets  []string
+}
+
+func (s *fakeConversationStore) GetConversationHistory(ctx context.Context, channelID string, limit int) ([]graph.Message, error) {
+	return s.history[channelID], nil
+}
+
+func (s *fakeConversationStore) ResetConversation(ctx context.Context, agentID, channelID string, archive bool) (*graph.ConversationReset, error) {
+	s.resets = append(s.resets, channelID)
+	delete(s.history, channelID)
+	return &graph.ConversationReset{}, nil
+}
+
+func TestConversationHistory_SessionScoping(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &fakeConversationStore{history: map[string][]graph.Message{
+		"web-Ezra":    {{ID: "1", Content: "default hello"}},
+		"web-Ezra-s1": {{ID: "2", Content: "session hello"}},
+		"discord-42":  {{ID: "3", Content: "discord hello"}},
+	}}
+	router := gin.New()
+	api := router.Group("/api", resolveDefaultAgent("Ezra"))
+	registerConversationHistoryRoutes(api, store, "web-{agent_id}", zap.NewNop())
+
+	get := func(path string) (string, []string) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			ChannelID string          `json:"channel_id"`
+			Messages  []graph.Message `json:"messages"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var contents []string
+		for _, msg := range response.Messages {
+			contents = append(contents, msg.Content)
+		}
+		return response.ChannelID, contents
+	}
+
+	channelID, messages := get("/api/agent/Ezra/conversation-history")
+	assert.Equal(t, "web-Ezra", channelID)
+	assert.Equal(t, []string{"default hello"}, messages)
+
+	channelID, messages = get("/api/agent/default/conversation-history?session_id=s1")
+	assert.Equal(t, "web-Ezra-s1", channelID)
+	assert.Equal(t, []string{"session hello"}, messages)
+
+	_, messages = get("/api/agent/Ezra/conversation-history?session_id=s2")
+	assert.Empty(t, messages)
+
+	// An explicit channel overrides the session
+	channelID, messages = get("/api/agent/Ezra/conversation-history?session_id=s1&channel_id=discord-42")
+	assert.Equal(t, "discord-42", channelID)
+	assert.Equal(t, []string{"discord hello"}, messages)
+
+	// Clearing a session leaves the default conversation alone
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/api/agent/Ezra/conversation-history?session_id=s1", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"web-Ezra-s1"}, store.resets)
+	_, messages = get("/api/agent/Ezra/conversation-history")
+	assert.Equal(t, []string{"default hello"}, messages)
+}
+
+func TestBuildEffectiveConfig_DefaultModel(t *testing.T) {
+	cfg := &config.Config{
+		Env:               "development",
+		LLMProvider:       "litellm",
+		ModelID:           "openrouter/default-model",
+		WebChannelPattern: "web-{agent_id}",
+		WebFetchMaxBytes:  500000,
+		WebFetchTimeout:   30 * time.Second,
+	}
+
+	effective := buildEffectiveConfig("Ezra", &graph.AgentConfig{SystemInstructions: "Be helpful"}, cfg)
+
+	assert.Equal(t, "openrouter/default-model", effective.Model)
+	assert.Equal(t, "default", effective.ModelSource)
+	assert.Equal(t, "litellm", effective.Provider)
+	assert.Equal(t, "Be helpful", effective.SystemInstructions)
+	assert.Equal(t, "web-Ezra", effective.DefaultWebChannel)
+	assert.Equal(t, float64(30), effective.WebFetchTimeoutSeconds)
+	assert.Equal(t, constants.MaxRecursionDepth, effective.MaxRecursionDepth)
+	assert.Equal(t, constants.DefaultPromptFactLimit, effective.MaxPromptFacts)
+	assert.Equal(t, constants.DefaultPromptHistoryLimit, effective.MaxPromptHistory)
+	assert.Equal(t, "", effective.ContentFilterAction)
+	assert.Equal(t, "litellm", effective.AuxiliaryProvider)
+	assert.Equal(t, "openrouter/default-model", effective.AuxiliaryModel)
+	assert.Equal(t, "omni-moderation-latest", effective.ModerationModel)
+	assert.Equal(t, 0, effective.MaxTokens)
+	assert.False(t, effective.Features["discord"])
+
+	noHistory := 0
+	effective = buildEffectiveConfig("Ezra", &graph.AgentConfig{Model: "custom-model", MaxRecursionDepth: 8, MaxPromptHistory: &noHistory, ContentFilter: "local", AuxiliaryModel: "small-model", ModerationProvider: "openai", MaxTokens: 1024}, cfg)
+	assert.Equal(t, "custom-model", effective.Model)
+	assert.Equal(t, "small-model", effective.AuxiliaryModel)
+	assert.Equal(t, "openai", effective.ModerationProvider)
+	assert.Equal(t, 1024, effective.MaxTokens)
+	assert.Equal(t, "agent", effective.ModelSource)
+	assert.Equal(t, 8, effective.MaxRecursionDepth)
+	assert.Equal(t, 0, effective.MaxPromptHistory)
+	assert.Equal(t, "replace", effective.ContentFilterAction)
+}
+
+func TestRunBulk_PartialFailure(t *testing.T) {
+	facts := []graph.FactInput{
+		{Content: "Likes pizza"},
+		{Content: "   "},
+		{Content: "Plays guitar"},
+	}
+	var stored []string
+
+	ops := bulkOps{
+		count: len(facts),
+		validate: func(i int) error {
+			return validateFactInput(facts[i])
+		},
+		writeOne: func(i int) (string, string, error) {
+			stored = append(stored, facts[i].Content)
+			return fmt.Sprintf("fact-%d", i), bulkStatusCreated, nil
+		},
+		writeAll: func() ([]string, []string, error) {
+			t.Fatal("writeAll must not be called for a rejected atomic batch")
+			return nil, nil, nil
+		},
+	}
+
+	resp, status := runBulk(ops, false)
+	assert.Equal(t, http.StatusMultiStatus, status)
+	assert.Equal(t, 2, resp.Succeeded)
+	assert.Equal(t, 1, resp.Failed)
+	assert.Equal(t, []string{"Likes pizza", "Plays guitar"}, stored)
+	assert.Equal(t, bulkItemResult{Index: 0, Status: bulkStatusCreated, ID: "fact-0"}, resp.Items[0])
+	assert.Equal(t, bulkItemResult{Index: 1, Status: bulkStatusFailed, Error: "content is required"}, resp.Items[1])
+	assert.Equal(t, bulkItemResult{Index: 2, Status: bulkStatusCreated, ID: "fact-2"}, resp.Items[2])
+
+	// In atomic mode the invalid item rejects the whole batch
+	stored = nil
+	resp, status = runBulk(ops, true)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Empty(t, stored)
+	assert.Equal(t, 0, resp.Succeeded)
+	assert.Equal(t, bulkStatusSkipped, resp.Items[0].Status)
+	assert.Equal(t, bulkStatusFailed, resp.Items[1].Status)
+}
+
+func TestRunBulk_SkippedDuplicatesAreNotFailures(t *testing.T) {
+	ops := bulkOps{
+		count:    2,
+		validate: func(i int) error { return nil },
+		writeOne: func(i int) (string, string, error) { return "", "", nil },
+		writeAll: func() ([]string, []string, error) {
+			return []string{"fact-0", ""}, []string{bulkStatusCreated, bulkStatusSkipped}, nil
+		},
+	}
+
+	resp, status := runBulk(ops, true)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, 1, resp.Succeeded)
+	assert.Equal(t, 1, resp.Skipped)
+	assert.Equal(t, 0, resp.Failed)
+}
+
+func TestRequireAPIToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(token string) *gin.Engine {
+		router := gin.New()
+		router.GET("/protected", requireAPIToken(token), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+		return router
+	}
+	request := func(router *gin.Engine, authHeader string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		if authHeader != "" {
+			req.Header.Set("Authorization", authHeader)
+		}
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	router := newRouter("s3cret")
+	assert.Equal(t, http.StatusOK, request(router, "Bearer s3cret"))
+	assert.Equal(t, http.StatusUnauthorized, request(router, "Bearer wrong"))
+	assert.Equal(t, http.StatusUnauthorized, request(router, "s3cret"))
+	assert.Equal(t, http.StatusUnauthorized, request(router, ""))
+
+	// Without a configured token the endpoint stays closed
+	assert.Equal(t, http.StatusForbidden, request(newRouter(""), "Bearer "))
+}
+
+// fakePager pages through in-memory messages the way GetConversationPage does
+type fakePager struct {
+	messages []graph.Message
+	limits   []int
+	agentIDs []string
+}
+
+func (p *fakePager) GetConversationPage(ctx context.Context, agentID, channelID string, after *graph.MessageCursor, limit int) ([]graph.Message, error) {
+	p.limits = append(p.limits, limit)
+	p.agentIDs = append(p.agentIDs, agentID)
+	start := 0
+	if after != nil {
+		for i, msg := range p.messages {
+			if msg.ID == after.ID {
+				start = i + 1
+			}
+		}
+	}
+	end := start + limit
+	if end > len(p.messages) {
+		end = len(p.messages)
+	}
+	return p.messages[start:end], nil
+}
+
+func TestResolveDefaultAgent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api", resolveDefaultAgent("Sage"))
+	api.GET("/agent/:id/state", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Param("id"))
+	})
+
+	for path, want := range map[string]string{
+		"/api/agent/default/state": "Sage",
+		"/api/agent/Ezra/state":    "Ezra",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Body.String(), path)
+	}
+}
+
+func TestExportConversation_JSONPagesThroughEverything(t *testing.T) {
+	pager := &fakePager{}
+	base := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	for i := 0; i < 1200; i++ {
+		pager.messages = append(pager.messages, graph.Message{
+			ID:        fmt.Sprintf("m%04d", i),
+			Content:   fmt.Sprintf("message %d", i),
+			Role:      "user",
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	var buf bytes.Buffer
+	err := exportConversation(context.Background(), &buf, pager, "Ezra", "web-Ezra", "json", base)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{exportBatchSize, exportBatchSize, exportBatchSize}, pager.limits)
+	assert.Equal(t, []string{"Ezra", "Ezra", "Ezra"}, pager.agentIDs, "every page is read for the agent in the path")
+
+	var export struct {
+		AgentID   string          `json:"agent_id"`
+		ChannelID string          `json:"channel_id"`
+		Messages  []graph.Message `json:"messages"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &export))
+	assert.Equal(t, "Ezra", export.AgentID)
+	assert.Equal(t, "web-Ezra", export.ChannelID)
+	assert.Len(t, export.Messages, 1200)
+	assert.Equal(t, "m0000", export.Messages[0].ID)
+	assert.Equal(t, "m1199", export.Messages[1199].ID)
+}
+
+func TestExportConversation_Markdown(t *testing.T) {
+	at := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	pager := &fakePager{messages: []graph.Message{
+		{ID: "1", Role: "user", SenderName: "alice", Content: "How do I loop?", Timestamp: at},
+		{ID: "2", Role: "agent", SenderName: "Ezra", Content: "Like this:\n```go\nfor i := range n {}\n```", Timestamp: at.Add(time.Second)},
+		{ID: "3", Role: "user", Content: "```broken", Timestamp: at.Add(2 * time.Second)},
+	}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, exportConversation(context.Background(), &buf, pager, "Ezra", "123", "markdown", at))
+	out := buf.String()
+
+	assert.Contains(t, out, "# Conversation 123")
+	assert.Contains(t, out, "**user (alice)** · 2026-01-02 15:04:05 UTC\n\nHow do I loop?\n")
+	assert.Contains(t, out, "**agent (Ezra)** · 2026-01-02 15:04:06 UTC\n\nLike this:\n```go\nfor i := range n {}\n```\n")
+	assert.Contains(t, out, "**user** · 2026-01-02 15:04:07 UTC\n\n```broken\n```\n")
+}
+
+func TestExportFilename(t *testing.T) {
+	assert.Equal(t, "conversation-web-Ezra-s1.md", exportFilename("web-Ezra-s1", "md"))
+	assert.Equal(t, "conversation-chat_Ezra.json", exportFilename("chat/Ezra", "json"))
+}
+
+func TestParseUsageRange(t *testing.T) {
+	now := time.Date(2026, 3, 15, 18, 0, 0, 0, time.UTC)
+
+	from, to, err := parseUsageRange("", "", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-02-14", from)
+	assert.Equal(t, "2026-03-15", to)
+
+	from, to, err = parseUsageRange("2026-03-01", "2026-03-10", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-03-01", from)
+	assert.Equal(t, "2026-03-10", to)
+
+	_, _, err = parseUsageRange("2026-03-10", "2026-03-01", now)
+	assert.Error(t, err)
+	_, _, err = parseUsageRange("last week", "", now)
+	assert.Error(t, err)
+}
+
+func TestUsageResponse_EstimatesCost(t *testing.T) {
+	report := &graph.TokenUsageReport{
+		From: "2026-03-01",
+		To:   "2026-03-31",
+		Models: []graph.ModelTokenUsage{
+			{Model: "priced", PromptTokens: 2_000_000, CompletionTokens: 500_000, Calls: 10},
+			{Model: "local", PromptTokens: 1000, CompletionTokens: 100, Calls: 1},
+		},
+		TotalPromptTokens:     5_000_000,
+		TotalCompletionTokens: 1_000_000,
+		TotalTurns:            42,
+	}
+	prices := map[string]config.ModelPrice{"priced": {Prompt: 3, Completion: 15}}
+
+	body := usageResponse("Ezra", report, prices)
+	assert.InDelta(t, 13.5, body["estimated_cost_usd"], 1e-9)
+	assert.Equal(t, int64(2_001_000), body["prompt_tokens"])
+	assert.Equal(t, int64(500_100), body["completion_tokens"])
+	assert.Equal(t, []string{"local"}, body["unpriced_models"])
+	models := body["models"].([]gin.H)
+	assert.NotContains(t, models[1], "estimated_cost_usd")
+	assert.Equal(t, int64(6_000_000), body["lifetime"].(gin.H)["total_tokens"])
+}
+
+func TestRunReadinessChecks(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return fmt.Errorf("connection refused") }
+	hang := func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }
+
+	status, deps := runReadinessChecks(context.Background(), []readinessCheck{
+		{name: "neo4j", critical: true, probe: ok},
+		{name: "tts", critical: false, probe: down},
+	}, time.Second)
+	assert.Equal(t, readinessDegraded, status)
+	assert.Equal(t, "ok", deps["neo4j"].Status)
+	assert.Equal(t, "unavailable", deps["tts"].Status)
+	assert.Equal(t, "connection refused", deps["tts"].Error)
+
+	status, deps = runReadinessChecks(context.Background(), []readinessCheck{
+		{name: "neo4j", critical: true, probe: hang},
+		{name: "litellm", critical: true, probe: ok},
+	}, 10*time.Millisecond)
+	assert.Equal(t, readinessNotReady, status)
+	assert.Equal(t, "unavailable", deps["neo4j"].Status)
+	assert.True(t, deps["neo4j"].Critical)
+}
+
+func TestHTTPReachable(t *testing.T) {
+	code := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	probe := httpReachable(server.Client(), server.URL)
+	assert.NoError(t, probe(context.Background()), "a 404 still means the service is up")
+
+	code = http.StatusBadGateway
+	assert.Error(t, probe(context.Background()))
+}
+
+func TestParseConversationFilter(t *testing.T) {
+	filter, err := parseConversationFilter(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, graph.ConversationFilter{Limit: constants.DefaultConversationPageSize}, filter)
+
+	filter, err = parseConversationFilter(url.Values{
+		"platform": {"web"},
+		"since":    {"2026-03-02T00:00:00Z"},
+		"until":    {"2026-03-09T00:00:00+01:00"},
+		"limit":    {"1000"},
+		"offset":   {"40"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "web", filter.Platform)
+	assert.True(t, filter.Since.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, filter.Until.Equal(time.Date(2026, 3, 8, 23, 0, 0, 0, time.UTC)))
+	assert.Equal(t, constants.MaxConversationPageSize, filter.Limit)
+	assert.Equal(t, 40, filter.Offset)
+
+	for _, query := range []url.Values{
+		{"since": {"last week"}},
+		{"until": {"2026-03-09"}},
+		{"since": {"2026-03-09T00:00:00Z"}, "until": {"2026-03-02T00:00:00Z"}},
+	} {
+		_, err := parseConversationFilter(query)
+		assert.Error(t, err, "query %v", query)
+	}
+}
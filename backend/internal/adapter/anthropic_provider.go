@@ -0,0 +1,350 @@
+package adapter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultAnthropicBaseURL is the Anthropic API used when no base URL is configured
+	DefaultAnthropicBaseURL = "https://api.anthropic.com"
+	// anthropicVersion is the Messages API version requested
+	anthropicVersion = "2023-06-01"
+	// DefaultAnthropicMaxTokens caps a reply when neither the call nor the
+	// provider sets a limit; the Messages API requires one
+	DefaultAnthropicMaxTokens = 4096
+)
+
+// anthropicProvider talks to the Anthropic Messages API
+type anthropicProvider struct {
+	baseURL   string
+	apiKey    string
+	maxTokens int // Reply limit for calls that don't set one
+	client    *http.Client
+}
+
+// NewAnthropicProvider returns a provider for the Anthropic Messages API at
+// baseURL, or the public API when baseURL is empty. maxTokens caps replies
+// for calls that don't set their own limit; 0 uses DefaultAnthropicMaxTokens.
+func NewAnthropicProvider(baseURL, apiKey string, maxTokens int) Provider {
+	if baseURL == "" {
+		baseURL = DefaultAnthropicBaseURL
+	}
+	if maxTokens <= 0 {
+		maxTokens = DefaultAnthropicMaxTokens
+	}
+	return &anthropicProvider{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey, maxTokens: maxTokens, client: &http.Client{}}
+}
+
+func (p *anthropicProvider) Name() string {
+	return ProviderAnthropic
+}
+
+func (p *anthropicProvider) secrets() []string {
+	if p.apiKey == "" {
+		return nil
+	}
+	return []string{p.apiKey}
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	Temperature float32            `json:"temperature"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string             `json:"role"`
+	Content []anthropicContent `json:"content"`
+}
+
+type anthropicContent struct {
+	Type   string                 `json:"type"`
+	Text   string                 `json:"text,omitempty"`
+	Source *anthropicImageSource  `json:"source,omitempty"`
+	ID     string                 `json:"id,omitempty"`
+	Name   string                 `json:"name,omitempty"`
+	Input  map[string]interface{} `json:"input,omitempty"`
+}
+
+type anthropicImageSource struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// newRequest builds the Messages API request for req, asking for the reply to
+// be streamed when stream is set
+func (p *anthropicProvider) newRequest(ctx context.Context, req ChatRequest, stream bool) (*http.Request, error) {
+	content := []anthropicContent{{Type: "text", Text: req.UserMessage}}
+	for _, url := range req.ImageURLs {
+		content = append(content, anthropicContent{Type: "image", Source: &anthropicImageSource{Type: "url", URL: url}})
+	}
+
+	tools := make([]anthropicTool, 0, len(req.Tools))
+	for _, tool := range req.Tools {
+		schema := tool.Function.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		tools = append(tools, anthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	// The Messages API accepts temperatures from 0 to 1
+	temperature := min(req.Temperature, 1)
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = p.maxTokens
+	}
+
+	body, err := json.Marshal(anthropicRequest{
+		Model:       req.Model,
+		MaxTokens:   maxTokens,
+		System:      req.SystemPrompt,
+		Messages:    []anthropicMessage{{Role: "user", Content: content}},
+		Tools:       tools,
+		Temperature: temperature,
+		Stream:      stream,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+	return httpReq, nil
+}
+
+// anthropicAPIError describes a failed Messages API call from its error body
+func anthropicAPIError(status int, statusText string, body []byte) error {
+	var apiErr anthropicError
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+		return fmt.Errorf("anthropic API error (%d %s): %s", status, apiErr.Error.Type, apiErr.Error.Message)
+	}
+	return fmt.Errorf("anthropic API error: %s", statusText)
+}
+
+func (p *anthropicProvider) Complete(ctx context.Context, req ChatRequest) (*Completion, error) {
+	httpReq, err := p.newRequest(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, anthropicAPIError(resp.StatusCode, resp.Status, respBody)
+	}
+
+	var parsed anthropicResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	completion := &Completion{
+		FinishReason: parsed.StopReason,
+		Usage: TokenUsage{
+			PromptTokens:     parsed.Usage.InputTokens,
+			CompletionTokens: parsed.Usage.OutputTokens,
+			TotalTokens:      parsed.Usage.InputTokens + parsed.Usage.OutputTokens,
+		},
+	}
+	var text []string
+	for _, block := range parsed.Content {
+		switch block.Type {
+		case "text":
+			text = append(text, block.Text)
+		case "tool_use":
+			args := []byte("{}")
+			if block.Input != nil {
+				if args, err = json.Marshal(block.Input); err != nil {
+					return nil, fmt.Errorf("failed to encode tool input: %w", err)
+				}
+			}
+			completion.ToolCalls = append(completion.ToolCalls, CompletionToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: string(args),
+			})
+		}
+	}
+	completion.Content = strings.Join(text, "")
+	return completion, nil
+}
+
+// anthropicStreamEvent is one server-sent event of a streamed reply. Which
+// fields are set depends on Type.
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message struct {
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	ContentBlock anthropicContent `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// maxAnthropicEventSize is the longest server-sent event line read
+const maxAnthropicEventSize = 1 << 20
+
+func (p *anthropicProvider) Stream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (*Completion, error) {
+	httpReq, err := p.newRequest(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return nil, anthropicAPIError(resp.StatusCode, resp.Status, respBody)
+	}
+
+	// Content blocks arrive in pieces, matched up by their index; tool input
+	// comes as fragments of JSON
+	type block struct {
+		content anthropicContent
+		text    strings.Builder
+	}
+	blocks := make(map[int]*block)
+	var order []int
+	completion := &Completion{}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAnthropicEventSize)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			return nil, fmt.Errorf("failed to decode stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			completion.Usage.PromptTokens = event.Message.Usage.InputTokens
+		case "content_block_start":
+			if _, ok := blocks[event.Index]; !ok {
+				order = append(order, event.Index)
+			}
+			blocks[event.Index] = &block{content: event.ContentBlock}
+			blocks[event.Index].text.WriteString(event.ContentBlock.Text)
+		case "content_block_delta":
+			b, ok := blocks[event.Index]
+			if !ok {
+				return nil, fmt.Errorf("stream event for unknown content block %d", event.Index)
+			}
+			switch event.Delta.Type {
+			case "text_delta":
+				b.text.WriteString(event.Delta.Text)
+				if err := onDelta(event.Delta.Text); err != nil {
+					return nil, err
+				}
+			case "input_json_delta":
+				b.text.WriteString(event.Delta.PartialJSON)
+			}
+		case "message_delta":
+			if event.Delta.StopReason != "" {
+				completion.FinishReason = event.Delta.StopReason
+			}
+			completion.Usage.CompletionTokens = event.Usage.OutputTokens
+		case "error":
+			return nil, fmt.Errorf("anthropic API error (%s): %s", event.Error.Type, event.Error.Message)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+	completion.Usage.TotalTokens = completion.Usage.PromptTokens + completion.Usage.CompletionTokens
+
+	var text []string
+	for _, index := range order {
+		b := blocks[index]
+		switch b.content.Type {
+		case "text":
+			text = append(text, b.text.String())
+		case "tool_use":
+			args := b.text.String()
+			if args == "" {
+				args = "{}"
+			}
+			completion.ToolCalls = append(completion.ToolCalls, CompletionToolCall{
+				ID:        b.content.ID,
+				Name:      b.content.Name,
+				Arguments: args,
+			})
+		}
+	}
+	completion.Content = strings.Join(text, "")
+	return completion, nil
+}
@@ -0,0 +1,40 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"ezra-clone/backend/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Embed returns an embedding vector for each of texts, in order, using the
+// embedding model served by the named provider, or the default provider when
+// providerName is empty
+func (a *LLMAdapter) Embed(ctx context.Context, providerName, model string, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	ctx, span := tracing.Start(ctx, "llm.embed",
+		attribute.String("llm.provider", providerName),
+		attribute.String("llm.model", model),
+		attribute.Int("llm.inputs", len(texts)),
+	)
+	vectors, err := a.embed(ctx, providerName, model, texts)
+	tracing.End(span, err)
+	return vectors, err
+}
+
+func (a *LLMAdapter) embed(ctx context.Context, providerName, model string, texts []string) ([][]float32, error) {
+	provider, err := a.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := provider.(Embedder)
+	if !ok {
+		return nil, fmt.Errorf("LLM provider %q does not serve embeddings", provider.Name())
+	}
+	return embedder.Embed(ctx, model, texts)
+}
@@ -5,54 +5,104 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/sashabaranov/go-openai"
+	"ezra-clone/backend/internal/cache"
 	"ezra-clone/backend/pkg/logger"
+	"ezra-clone/backend/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
-// LLMAdapter handles communication with the LLM via LiteLLM
+// defaultTemperature is the sampling temperature used when a call doesn't set one
+const defaultTemperature = 0.7
+
+// LLMAdapter handles communication with the LLM through its providers,
+// LiteLLM by default. It holds no per-request state, so it is safe to share
+// across concurrent turns.
 type LLMAdapter struct {
-	client *openai.Client
-	model  string
-	mu     sync.RWMutex // Protects model field for concurrent access
-	logger *zap.Logger
+	providers       map[string]Provider // Registered providers by name
+	defaultProvider string              // Used when a call doesn't name a provider
+	defaultModel    string              // Used when a call doesn't specify a model; never changes after construction
+	logger          *zap.Logger
+
+	visionModels []string           // Model name patterns that accept image inputs
+	captionModel string             // Vision model used to describe images for text-only models
+	captions     *cache.TTL[string] // Image descriptions by URL, shared across turns
+
+	traces  *TraceStore // Recent turns' LLM calls; nil when debug tracing is off
+	secrets []string    // Redacted from traces and debug logs
 }
 
-// SetModel updates the model used by this adapter
-func (a *LLMAdapter) SetModel(model string) {
-	if model != "" {
-		a.mu.Lock()
-		a.model = model
-		a.mu.Unlock()
-		a.logger.Debug("LLM adapter model updated", zap.String("model", model))
-	}
+// GenerateParams are per-call request settings. Zero values fall back to the
+// adapter defaults.
+type GenerateParams struct {
+	Provider    string   // Provider name; empty uses the adapter's default provider
+	Model       string   // Model ID; empty uses the adapter's default model
+	Temperature float32  // Sampling temperature; 0 uses defaultTemperature
+	MaxTokens   int      // Reply length limit; 0 uses the provider's default
+	ImageURLs   []string // Images attached to the user message
+	Auxiliary   bool     // Background work rather than a reply; unset fields come from the context's AuxiliaryModel
 }
 
-// GetModel returns the current model
-func (a *LLMAdapter) GetModel() string {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-	return a.model
+// AuxiliaryModel is an agent's choice of provider, model and reply limit for
+// the calls made for it outside its replies, such as memory evaluation and
+// summaries. Empty fields fall back to the adapter defaults.
+type AuxiliaryModel struct {
+	Provider  string
+	Model     string
+	MaxTokens int
 }
 
-// NewLLMAdapter creates a new LLM adapter
-func NewLLMAdapter(baseURL, apiKey, modelID string) *LLMAdapter {
-	// For LiteLLM, we can use a dummy API key if not provided
-	if apiKey == "" {
-		apiKey = "dummy-key"
+// auxiliaryKey is the context key for the AuxiliaryModel of auxiliary calls
+type auxiliaryKey struct{}
+
+// WithAuxiliaryModel returns a context whose auxiliary calls use m
+func WithAuxiliaryModel(ctx context.Context, m AuxiliaryModel) context.Context {
+	return context.WithValue(ctx, auxiliaryKey{}, m)
+}
+
+// AuxiliaryModelFrom returns ctx's AuxiliaryModel, the zero value (the
+// defaults) when it has none. Work that outlives a turn keeps it this way.
+func AuxiliaryModelFrom(ctx context.Context) AuxiliaryModel {
+	m, _ := ctx.Value(auxiliaryKey{}).(AuxiliaryModel)
+	return m
+}
+
+// auxiliaryParams fills in the fields of an auxiliary call's params that it
+// leaves unset from ctx's AuxiliaryModel
+func auxiliaryParams(ctx context.Context, params GenerateParams) GenerateParams {
+	m, ok := ctx.Value(auxiliaryKey{}).(AuxiliaryModel)
+	if !ok || !params.Auxiliary {
+		return params
 	}
-	
-	config := openai.DefaultConfig(apiKey)
-	config.BaseURL = baseURL + "/v1"
+	if params.Provider == "" && params.Model == "" {
+		params.Provider, params.Model = m.Provider, m.Model
+	}
+	if params.MaxTokens == 0 {
+		params.MaxTokens = m.MaxTokens
+	}
+	return params
+}
 
-	return &LLMAdapter{
-		client: openai.NewClientWithConfig(config),
-		model:  modelID,
-		logger: logger.Get(),
+// DefaultModel returns the model used when a call doesn't specify one
+func (a *LLMAdapter) DefaultModel() string {
+	return a.defaultModel
+}
+
+// NewLLMAdapter creates a new LLM adapter that sends calls to the LiteLLM
+// gateway at baseURL. Other providers can be added with AddProvider.
+func NewLLMAdapter(baseURL, apiKey, modelID string) *LLMAdapter {
+	a := &LLMAdapter{
+		providers:       make(map[string]Provider),
+		defaultProvider: ProviderLiteLLM,
+		defaultModel:    modelID,
+		logger:          logger.Get(),
+		visionModels:    defaultVisionModels,
+		captions:        cache.NewTTL[string](maxCachedCaptions, 0),
 	}
+	a.AddProvider(NewLiteLLMProvider(baseURL, apiKey))
+	return a
 }
 
 // Tool represents a function that can be called by the LLM
@@ -72,6 +122,15 @@ type FunctionDefinition struct {
 type Response struct {
 	Content   string
 	ToolCalls []ToolCall
+	Usage     TokenUsage // Zero when the provider doesn't report usage
+	Truncated bool       // The reply was cut off by the model's output length limit
+}
+
+// lengthFinishReasons are the finish reasons providers report when a reply
+// ran into the output token limit
+var lengthFinishReasons = map[string]bool{
+	"length":     true, // OpenAI-compatible APIs
+	"max_tokens": true, // Anthropic
 }
 
 // ToolCall represents a function call from the LLM
@@ -81,47 +140,93 @@ type ToolCall struct {
 	Arguments map[string]interface{}
 }
 
-// Generate sends a request to the LLM and returns the response
-func (a *LLMAdapter) Generate(ctx context.Context, systemPrompt, userMsg string, tools []Tool) (*Response, error) {
-	messages := []openai.ChatCompletionMessage{
-		{
-			Role:    openai.ChatMessageRoleSystem,
-			Content: systemPrompt,
-		},
-		{
-			Role:    openai.ChatMessageRoleUser,
-			Content: userMsg,
-		},
-	}
-
-	// Convert tools to OpenAI format
-	openaiTools := make([]openai.Tool, 0, len(tools))
-	for _, tool := range tools {
-		openaiTools = append(openaiTools, openai.Tool{
-			Type: openai.ToolTypeFunction,
-			Function: &openai.FunctionDefinition{
-				Name:        tool.Function.Name,
-				Description: tool.Function.Description,
-				Parameters:  tool.Function.Parameters,
-			},
-		})
-	}
-
-	a.mu.RLock()
-	currentModel := a.model
-	a.mu.RUnlock()
-
-	req := openai.ChatCompletionRequest{
-		Model:       currentModel,
-		Messages:    messages,
-		Tools:       openaiTools,
-		// ToolChoice defaults to "auto" when tools are provided
-		Temperature: 0.7,
+// Generate sends a request to the LLM and returns the response. The model and
+// other settings in params only apply to this call, so concurrent turns for
+// agents with different models don't interfere.
+func (a *LLMAdapter) Generate(ctx context.Context, params GenerateParams, systemPrompt, userMsg string, tools []Tool) (*Response, error) {
+	return a.generateTraced(ctx, "llm.generate", params, systemPrompt, userMsg, tools, nil)
+}
+
+// GenerateStream is Generate for a reply shown as it is written: onDelta is
+// called with each piece of the reply's text in order, and an error from it
+// stops the call. Providers that can't stream deliver the whole text at once.
+// A call that fails after text was delivered is not retried.
+func (a *LLMAdapter) GenerateStream(ctx context.Context, params GenerateParams, systemPrompt, userMsg string, tools []Tool, onDelta func(delta string) error) (*Response, error) {
+	return a.generateTraced(ctx, "llm.stream", params, systemPrompt, userMsg, tools, onDelta)
+}
+
+// generateTraced fills in params' defaults and makes the call inside a span
+// named spanName, streaming it when onDelta isn't nil
+func (a *LLMAdapter) generateTraced(ctx context.Context, spanName string, params GenerateParams, systemPrompt, userMsg string, tools []Tool, onDelta func(delta string) error) (*Response, error) {
+	params = auxiliaryParams(ctx, params)
+	if params.Model == "" {
+		params.Model = a.defaultModel
+	}
+
+	if params.Provider == "" {
+		params.Provider = a.defaultProvider
+	}
+
+	ctx, span := tracing.Start(ctx, spanName,
+		attribute.String("llm.provider", params.Provider),
+		attribute.String("llm.model", params.Model),
+		attribute.Int("llm.tools", len(tools)),
+	)
+	call := a.startCallTrace(params, systemPrompt, userMsg, tools)
+	response, err := a.generate(ctx, params, systemPrompt, userMsg, tools, onDelta, call)
+	a.finishCallTrace(ctx, call, err)
+	if response != nil {
+		span.SetAttributes(
+			attribute.Int("llm.tool_calls", len(response.ToolCalls)),
+			attribute.Int("llm.total_tokens", response.Usage.TotalTokens),
+		)
+		meterUsage(ctx, params.Model, response.Usage)
+	}
+	tracing.End(span, err)
+	return response, err
+}
+
+// generate sends the chat completion request, streamed to onDelta when it
+// isn't nil, retrying transient failures. The raw reply is recorded on call
+// when it isn't nil.
+func (a *LLMAdapter) generate(ctx context.Context, params GenerateParams, systemPrompt, userMsg string, tools []Tool, onDelta func(delta string) error, call *LLMCallTrace) (*Response, error) {
+	provider, err := a.provider(params.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	currentModel := params.Model
+	temperature := params.Temperature
+	if temperature == 0 {
+		temperature = defaultTemperature
+	}
+
+	userText, imageURLs := a.userContent(ctx, params, userMsg)
+	req := ChatRequest{
+		Model:        currentModel,
+		SystemPrompt: systemPrompt,
+		UserMessage:  userText,
+		ImageURLs:    imageURLs,
+		Tools:        tools,
+		Temperature:  temperature,
+		MaxTokens:    params.MaxTokens,
+	}
+
+	send := func() (*Completion, error) {
+		return provider.Complete(ctx, req)
+	}
+	delivered := false // Streamed text has reached onDelta, so a retry would repeat it
+	if onDelta != nil {
+		send = func() (*Completion, error) {
+			return streamCompletion(ctx, provider, req, func(delta string) error {
+				delivered = true
+				return onDelta(delta)
+			})
+		}
 	}
 
 	// Retry logic with exponential backoff
-	var resp openai.ChatCompletionResponse
-	var err error
+	var completion *Completion
 	maxRetries := 3
 	for attempt := 0; attempt < maxRetries; attempt++ {
 		if attempt > 0 {
@@ -133,8 +238,8 @@ func (a *LLMAdapter) Generate(ctx context.Context, systemPrompt, userMsg string,
 			time.Sleep(backoff)
 		}
 
-		resp, err = a.client.CreateChatCompletion(ctx, req)
-		if err == nil {
+		completion, err = send()
+		if err == nil || delivered {
 			break
 		}
 
@@ -143,6 +248,7 @@ func (a *LLMAdapter) Generate(ctx context.Context, systemPrompt, userMsg string,
 		a.logger.Error("LLM request failed",
 			zap.Error(err),
 			zap.Int("attempt", attempt+1),
+			zap.String("provider", provider.Name()),
 			zap.String("model", currentModel),
 			zap.String("error_message", errMsg),
 		)
@@ -158,53 +264,40 @@ func (a *LLMAdapter) Generate(ctx context.Context, systemPrompt, userMsg string,
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate response after %d attempts: %w", maxRetries, err)
 	}
+	call.recordResponse(completion)
 
 	// Parse response
 	response := &Response{
-		Content:   "",
+		Content:   completion.Content,
 		ToolCalls: []ToolCall{},
-	}
-
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no choices in LLM response")
-	}
-
-	choice := resp.Choices[0]
-	
-	// Extract content
-	if choice.Message.Content != "" {
-		response.Content = choice.Message.Content
+		Usage:     completion.Usage,
+		Truncated: lengthFinishReasons[completion.FinishReason],
 	}
 
 	// Extract tool calls
-	if len(choice.Message.ToolCalls) > 0 {
-		for _, tc := range choice.Message.ToolCalls {
-			toolCall := ToolCall{
-				ID:   tc.ID,
-				Name: tc.Function.Name,
-			}
-
-			// Parse arguments JSON
-			args, err := parseJSONArguments(tc.Function.Arguments)
-			if err != nil {
-				a.logger.Warn("Failed to parse tool call arguments",
-					zap.String("tool_id", tc.ID),
-					zap.Error(err),
-				)
-				args = make(map[string]interface{})
-			}
-			toolCall.Arguments = args
-
-			response.ToolCalls = append(response.ToolCalls, toolCall)
+	for _, tc := range completion.ToolCalls {
+		toolCall := ToolCall{
+			ID:   tc.ID,
+			Name: tc.Name,
 		}
-	}
 
-	a.mu.RLock()
-	modelUsed := a.model
-	a.mu.RUnlock()
+		// Parse arguments JSON
+		args, err := parseJSONArguments(tc.Arguments)
+		if err != nil {
+			a.logger.Warn("Failed to parse tool call arguments",
+				zap.String("tool_id", tc.ID),
+				zap.Error(err),
+			)
+			args = make(map[string]interface{})
+		}
+		toolCall.Arguments = args
+
+		response.ToolCalls = append(response.ToolCalls, toolCall)
+	}
 
 	a.logger.Debug("LLM response generated",
-		zap.String("model", modelUsed),
+		zap.String("provider", provider.Name()),
+		zap.String("model", currentModel),
 		zap.Int("tool_calls", len(response.ToolCalls)),
 		zap.Bool("has_content", response.Content != ""),
 	)
@@ -212,6 +305,24 @@ func (a *LLMAdapter) Generate(ctx context.Context, systemPrompt, userMsg string,
 	return response, nil
 }
 
+// streamCompletion streams req through provider, or completes it and passes
+// the whole reply to onDelta when the provider can't stream
+func streamCompletion(ctx context.Context, provider Provider, req ChatRequest, onDelta func(delta string) error) (*Completion, error) {
+	if streamer, ok := provider.(Streamer); ok {
+		return streamer.Stream(ctx, req, onDelta)
+	}
+	completion, err := provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if completion.Content != "" {
+		if err := onDelta(completion.Content); err != nil {
+			return nil, err
+		}
+	}
+	return completion, nil
+}
+
 // parseJSONArguments parses the JSON string arguments into a map
 func parseJSONArguments(jsonStr string) (map[string]interface{}, error) {
 	var args map[string]interface{}
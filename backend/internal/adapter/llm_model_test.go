@@ -0,0 +1,61 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// newEchoModelServer answers chat completions with the requested model name
+func newEchoModelServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":%q}}]}`, req.Model)
+	}))
+}
+
+func TestLLMAdapter_Generate_ConcurrentModels(t *testing.T) {
+	server := newEchoModelServer(t)
+	defer server.Close()
+
+	llm := NewLLMAdapter(server.URL, "", "default-model")
+	ctx := context.Background()
+
+	models := []string{"agent-a-model", "agent-b-model", ""}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, model := range models {
+			wg.Add(1)
+			go func(model string) {
+				defer wg.Done()
+				resp, err := llm.Generate(ctx, GenerateParams{Model: model}, "system", "hi", nil)
+				if err != nil {
+					t.Errorf("Generate failed: %v", err)
+					return
+				}
+				expected := model
+				if expected == "" {
+					expected = "default-model"
+				}
+				if resp.Content != expected {
+					t.Errorf("Expected model %s, got %s", expected, resp.Content)
+				}
+			}(model)
+		}
+	}
+	wg.Wait()
+
+	if got := llm.DefaultModel(); got != "default-model" {
+		t.Errorf("Expected default model to be untouched, got %s", got)
+	}
+}
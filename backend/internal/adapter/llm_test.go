@@ -18,7 +18,7 @@ func TestLLMAdapter_Generate(t *testing.T) {
 	systemPrompt := "You are a helpful assistant."
 	userMsg := "Say hello in one sentence."
 
-	response, err := adapter.Generate(ctx, systemPrompt, userMsg, []Tool{})
+	response, err := adapter.Generate(ctx, GenerateParams{}, systemPrompt, userMsg, []Tool{})
 	if err != nil {
 		t.Fatalf("Generate failed: %v", err)
 	}
@@ -61,7 +61,7 @@ func TestLLMAdapter_Generate_WithTools(t *testing.T) {
 		},
 	}
 
-	response, err := adapter.Generate(ctx, systemPrompt, userMsg, tools)
+	response, err := adapter.Generate(ctx, GenerateParams{}, systemPrompt, userMsg, tools)
 	if err != nil {
 		t.Fatalf("Generate failed: %v", err)
 	}
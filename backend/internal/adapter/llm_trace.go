@@ -0,0 +1,262 @@
+package adapter
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultTraceMaxTurns is how many turns' traces are kept when
+// LLM_TRACE_MAX_TURNS isn't set
+const DefaultTraceMaxTurns = 100
+
+// maxTraceCallsPerTurn bounds one turn's trace; later calls are dropped
+const maxTraceCallsPerTurn = 50
+
+// redactedText replaces secrets in traces and debug logs
+const redactedText = "[REDACTED]"
+
+// LLMCallTrace is one Generate call: what was sent and what came back
+type LLMCallTrace struct {
+	Time         time.Time      `json:"time"`
+	Provider     string         `json:"provider"`
+	Model        string         `json:"model"`
+	SystemPrompt string         `json:"system_prompt"`
+	UserMessage  string         `json:"user_message"`
+	ImageURLs    []string       `json:"image_urls,omitempty"`
+	Tools        []Tool         `json:"tools,omitempty"`
+	Response     *TraceResponse `json:"response,omitempty"`
+	Usage        *TokenUsage    `json:"usage,omitempty"` // Only when the provider reports it
+	Error        string         `json:"error,omitempty"`
+	DurationMs   int64          `json:"duration_ms"`
+}
+
+// TraceResponse is the model's raw reply, before tool arguments are parsed
+type TraceResponse struct {
+	Content      string          `json:"content"`
+	ToolCalls    []TraceToolCall `json:"tool_calls,omitempty"`
+	FinishReason string          `json:"finish_reason,omitempty"`
+}
+
+// TraceToolCall is a tool call as the model sent it
+type TraceToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// TurnTrace is every LLM call made during one agent turn
+type TurnTrace struct {
+	AgentID   string         `json:"agent_id"`
+	TurnID    string         `json:"turn_id"`
+	StartedAt time.Time      `json:"started_at"`
+	Calls     []LLMCallTrace `json:"calls"`
+	Truncated bool           `json:"truncated,omitempty"` // Calls past maxTraceCallsPerTurn were dropped
+}
+
+// turnKey is the context key for the turn LLM calls are traced under
+type turnKey struct{}
+
+type turnRef struct {
+	agentID string
+	turnID  string
+}
+
+// WithTurn marks ctx as belonging to an agent turn, so calls made with it are
+// traced under turnID when debug tracing is on
+func WithTurn(ctx context.Context, agentID, turnID string) context.Context {
+	return context.WithValue(ctx, turnKey{}, turnRef{agentID: agentID, turnID: turnID})
+}
+
+// TraceStore keeps the traces of the most recent turns, dropping the oldest
+// turn when full
+type TraceStore struct {
+	mu       sync.Mutex
+	maxTurns int
+	order    []string // Keys, oldest first
+	turns    map[string]*TurnTrace
+}
+
+// NewTraceStore creates a store for up to maxTurns turns
+func NewTraceStore(maxTurns int) *TraceStore {
+	if maxTurns <= 0 {
+		maxTurns = DefaultTraceMaxTurns
+	}
+	return &TraceStore{
+		maxTurns: maxTurns,
+		turns:    make(map[string]*TurnTrace),
+	}
+}
+
+func traceKey(agentID, turnID string) string {
+	return agentID + "\x00" + turnID
+}
+
+// record appends call to its turn's trace, starting the trace if needed
+func (s *TraceStore) record(ref turnRef, call LLMCallTrace) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := traceKey(ref.agentID, ref.turnID)
+	trace, ok := s.turns[key]
+	if !ok {
+		if len(s.order) >= s.maxTurns {
+			delete(s.turns, s.order[0])
+			s.order = s.order[1:]
+		}
+		trace = &TurnTrace{AgentID: ref.agentID, TurnID: ref.turnID, StartedAt: call.Time}
+		s.turns[key] = trace
+		s.order = append(s.order, key)
+	}
+	if len(trace.Calls) >= maxTraceCallsPerTurn {
+		trace.Truncated = true
+		return
+	}
+	trace.Calls = append(trace.Calls, call)
+}
+
+// Get returns a copy of a turn's trace, if it is still kept
+func (s *TraceStore) Get(agentID, turnID string) (*TurnTrace, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trace, ok := s.turns[traceKey(agentID, turnID)]
+	if !ok {
+		return nil, false
+	}
+	copied := *trace
+	copied.Calls = append([]LLMCallTrace(nil), trace.Calls...)
+	return &copied, true
+}
+
+// secretPatterns match credentials that may end up in prompts or replies.
+// Text in the first group, such as the key name, is kept.
+var secretPatterns = []*regexp.Regexp{
+	// OpenAI and OpenRouter keys
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{16,}`),
+	// GitHub tokens
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{20,}`),
+	// Discord bot tokens
+	regexp.MustCompile(`\b[MN][A-Za-z0-9]{23,25}\.[\w-]{6}\.[\w-]{27,}`),
+	// Authorization headers
+	regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._\-]{16,}`),
+	// key=value pairs such as api_key: "..."
+	regexp.MustCompile(`(?i)\b((?:api[_-]?key|secret|password)["']?\s*[:=]\s*["']?)[^\s"',]{8,}`),
+}
+
+// redactSecrets hides API keys in text: the given secrets verbatim, and
+// anything shaped like a known credential
+func redactSecrets(text string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redactedText)
+		}
+	}
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllString(text, "${1}"+redactedText)
+	}
+	return text
+}
+
+// EnableDebugTrace logs every LLM request and response in full at debug
+// level and keeps the calls of the last maxTurns turns for Trace. Call before
+// the adapter is shared.
+func (a *LLMAdapter) EnableDebugTrace(maxTurns int) {
+	a.traces = NewTraceStore(maxTurns)
+}
+
+// DebugTraceEnabled reports whether LLM calls are being traced
+func (a *LLMAdapter) DebugTraceEnabled() bool {
+	return a.traces != nil
+}
+
+// Trace returns the LLM calls made during a turn, if tracing is on and the
+// turn is still kept
+func (a *LLMAdapter) Trace(agentID, turnID string) (*TurnTrace, bool) {
+	if a.traces == nil {
+		return nil, false
+	}
+	return a.traces.Get(agentID, turnID)
+}
+
+// startCallTrace begins tracing a call, or returns nil when tracing is off
+func (a *LLMAdapter) startCallTrace(params GenerateParams, systemPrompt, userMsg string, tools []Tool) *LLMCallTrace {
+	if a.traces == nil {
+		return nil
+	}
+	return &LLMCallTrace{
+		Time:         time.Now().UTC(),
+		Provider:     params.Provider,
+		Model:        params.Model,
+		SystemPrompt: systemPrompt,
+		UserMessage:  userMsg,
+		ImageURLs:    params.ImageURLs,
+		Tools:        tools,
+	}
+}
+
+// recordResponse adds the provider's raw reply to a call's trace
+func (call *LLMCallTrace) recordResponse(completion *Completion) {
+	if call == nil {
+		return
+	}
+	if completion.Usage.TotalTokens > 0 {
+		usage := completion.Usage
+		call.Usage = &usage
+	}
+	call.Response = &TraceResponse{
+		Content:      completion.Content,
+		FinishReason: completion.FinishReason,
+	}
+	for _, tc := range completion.ToolCalls {
+		call.Response.ToolCalls = append(call.Response.ToolCalls, TraceToolCall{
+			ID:        tc.ID,
+			Name:      tc.Name,
+			Arguments: tc.Arguments,
+		})
+	}
+}
+
+// finishCallTrace redacts a traced call, logs it and stores it under the
+// turn in ctx, if any
+func (a *LLMAdapter) finishCallTrace(ctx context.Context, call *LLMCallTrace, err error) {
+	if call == nil {
+		return
+	}
+	call.DurationMs = time.Since(call.Time).Milliseconds()
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	call.SystemPrompt = redactSecrets(call.SystemPrompt, a.secrets)
+	call.UserMessage = redactSecrets(call.UserMessage, a.secrets)
+	call.Error = redactSecrets(call.Error, a.secrets)
+	if call.Response != nil {
+		call.Response.Content = redactSecrets(call.Response.Content, a.secrets)
+		for i := range call.Response.ToolCalls {
+			call.Response.ToolCalls[i].Arguments = redactSecrets(call.Response.ToolCalls[i].Arguments, a.secrets)
+		}
+	}
+
+	ref, inTurn := ctx.Value(turnKey{}).(turnRef)
+	a.logger.Debug("LLM call trace",
+		zap.String("agent_id", ref.agentID),
+		zap.String("turn_id", ref.turnID),
+		zap.String("provider", call.Provider),
+		zap.String("model", call.Model),
+		zap.String("system_prompt", call.SystemPrompt),
+		zap.String("user_message", call.UserMessage),
+		zap.Any("tools", call.Tools),
+		zap.Any("response", call.Response),
+		zap.Any("usage", call.Usage),
+		zap.String("error", call.Error),
+		zap.Int64("duration_ms", call.DurationMs),
+	)
+	if inTurn {
+		a.traces.record(ref, *call)
+	}
+}
@@ -0,0 +1,102 @@
+package adapter
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// TokenUsage is the token count the provider reported for a call
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// tokenUsage converts the provider's usage, filling in a missing total
+func tokenUsage(usage openai.Usage) TokenUsage {
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
+	return TokenUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      total,
+	}
+}
+
+// add sums other into u
+func (u *TokenUsage) add(other TokenUsage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+}
+
+// ModelUsage is the tokens used with one model and how many calls used them
+type ModelUsage struct {
+	Model string `json:"model"`
+	TokenUsage
+	Calls int `json:"calls"`
+}
+
+// UsageMeter adds up the tokens of every LLM call made with a context,
+// by model. It is safe for concurrent calls.
+type UsageMeter struct {
+	mu      sync.Mutex
+	byModel map[string]*ModelUsage
+}
+
+// usageKey is the context key for the meter LLM calls are counted on
+type usageKey struct{}
+
+// WithUsageMeter returns a context whose LLM calls are counted on the
+// returned meter
+func WithUsageMeter(ctx context.Context) (context.Context, *UsageMeter) {
+	meter := &UsageMeter{byModel: make(map[string]*ModelUsage)}
+	return context.WithValue(ctx, usageKey{}, meter), meter
+}
+
+// meterUsage counts a call's usage on ctx's meter, if it has one
+func meterUsage(ctx context.Context, model string, usage TokenUsage) {
+	meter, ok := ctx.Value(usageKey{}).(*UsageMeter)
+	if !ok {
+		return
+	}
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+
+	counted, ok := meter.byModel[model]
+	if !ok {
+		counted = &ModelUsage{Model: model}
+		meter.byModel[model] = counted
+	}
+	counted.add(usage)
+	counted.Calls++
+}
+
+// ByModel returns the usage per model, sorted by model name
+func (m *UsageMeter) ByModel() []ModelUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage := make([]ModelUsage, 0, len(m.byModel))
+	for _, counted := range m.byModel {
+		usage = append(usage, *counted)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Model < usage[j].Model })
+	return usage
+}
+
+// Total returns the usage across all models and the number of calls
+func (m *UsageMeter) Total() (TokenUsage, int) {
+	var total TokenUsage
+	calls := 0
+	for _, usage := range m.ByModel() {
+		total.add(usage.TokenUsage)
+		calls += usage.Calls
+	}
+	return total, calls
+}
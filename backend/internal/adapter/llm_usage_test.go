@@ -0,0 +1,29 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUsageMeter_CountsByModel(t *testing.T) {
+	ctx, meter := WithUsageMeter(context.Background())
+	meterUsage(ctx, "b-model", TokenUsage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120})
+	meterUsage(ctx, "a-model", TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15})
+	meterUsage(ctx, "b-model", TokenUsage{PromptTokens: 50, CompletionTokens: 10, TotalTokens: 60})
+
+	// Calls outside a metered context are ignored
+	meterUsage(context.Background(), "b-model", TokenUsage{PromptTokens: 1000, TotalTokens: 1000})
+
+	byModel := meter.ByModel()
+	if len(byModel) != 2 || byModel[0].Model != "a-model" || byModel[1].Model != "b-model" {
+		t.Fatalf("Expected usage for two models sorted by name, got %+v", byModel)
+	}
+	if byModel[1].PromptTokens != 150 || byModel[1].CompletionTokens != 30 || byModel[1].Calls != 2 {
+		t.Errorf("Expected b-model calls to be summed, got %+v", byModel[1])
+	}
+
+	total, calls := meter.Total()
+	if total.TotalTokens != 195 || calls != 3 {
+		t.Errorf("Expected 195 tokens over 3 calls, got %d over %d", total.TotalTokens, calls)
+	}
+}
@@ -0,0 +1,48 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"ezra-clone/backend/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultModerationModel is the moderation model used when none is configured
+const DefaultModerationModel = "omni-moderation-latest"
+
+// ModerationResult is a moderation model's verdict on a text
+type ModerationResult struct {
+	Flagged    bool
+	Categories []string // Categories the text was flagged for, sorted
+}
+
+// Moderator is implemented by providers that serve moderation models
+type Moderator interface {
+	Moderate(ctx context.Context, model, text string) (*ModerationResult, error)
+}
+
+// Moderate checks text with a moderation model served by the named provider,
+// or the default provider when providerName is empty
+func (a *LLMAdapter) Moderate(ctx context.Context, providerName, model, text string) (*ModerationResult, error) {
+	ctx, span := tracing.Start(ctx, "llm.moderate",
+		attribute.String("llm.provider", providerName),
+		attribute.String("llm.model", model),
+	)
+	result, err := a.moderate(ctx, providerName, model, text)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (a *LLMAdapter) moderate(ctx context.Context, providerName, model, text string) (*ModerationResult, error) {
+	provider, err := a.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	moderator, ok := provider.(Moderator)
+	if !ok {
+		return nil, fmt.Errorf("LLM provider %q does not serve moderation models", provider.Name())
+	}
+	return moderator.Moderate(ctx, model, text)
+}
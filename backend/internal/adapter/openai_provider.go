@@ -0,0 +1,286 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// placeholderAPIKey is sent to OpenAI-compatible servers that don't need a
+// key, such as LiteLLM without auth or Ollama
+const placeholderAPIKey = "dummy-key"
+
+// DefaultOpenAIBaseURL is the OpenAI API used when no base URL is configured
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// DefaultOllamaURL is the Ollama server used when no URL is configured
+const DefaultOllamaURL = "http://localhost:11434"
+
+// openAIProvider talks to any OpenAI-compatible chat completions API
+type openAIProvider struct {
+	name    string
+	client  *openai.Client
+	baseURL string
+	apiKey  string // Empty when the server needs no key
+	http    *http.Client
+}
+
+// NewOpenAIProvider returns a provider for an OpenAI-compatible API at
+// baseURL, which includes the version path (for example
+// https://api.openai.com/v1). An empty apiKey is allowed for servers without
+// auth.
+func NewOpenAIProvider(name, baseURL, apiKey string) Provider {
+	key := apiKey
+	if key == "" {
+		key = placeholderAPIKey
+	}
+	config := openai.DefaultConfig(key)
+	config.BaseURL = strings.TrimRight(baseURL, "/")
+	return &openAIProvider{
+		name:    name,
+		client:  openai.NewClientWithConfig(config),
+		baseURL: config.BaseURL,
+		apiKey:  apiKey,
+		http:    &http.Client{},
+	}
+}
+
+// NewLiteLLMProvider returns a provider for the LiteLLM gateway at baseURL
+func NewLiteLLMProvider(baseURL, apiKey string) Provider {
+	return NewOpenAIProvider(ProviderLiteLLM, strings.TrimRight(baseURL, "/")+"/v1", apiKey)
+}
+
+// NewOllamaProvider returns a provider for the Ollama server at baseURL,
+// through its OpenAI-compatible API
+func NewOllamaProvider(baseURL string) Provider {
+	if baseURL == "" {
+		baseURL = DefaultOllamaURL
+	}
+	return NewOpenAIProvider(ProviderOllama, strings.TrimRight(baseURL, "/")+"/v1", "")
+}
+
+func (p *openAIProvider) Name() string {
+	return p.name
+}
+
+func (p *openAIProvider) secrets() []string {
+	if p.apiKey == "" || p.apiKey == placeholderAPIKey {
+		return nil
+	}
+	return []string{p.apiKey}
+}
+
+// chatRequest translates req to the chat completions format
+func (p *openAIProvider) chatRequest(req ChatRequest) openai.ChatCompletionRequest {
+	user := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: req.UserMessage}
+	if len(req.ImageURLs) > 0 {
+		parts := []openai.ChatMessagePart{{Type: openai.ChatMessagePartTypeText, Text: req.UserMessage}}
+		for _, url := range req.ImageURLs {
+			parts = append(parts, openai.ChatMessagePart{
+				Type:     openai.ChatMessagePartTypeImageURL,
+				ImageURL: &openai.ChatMessageImageURL{URL: url, Detail: openai.ImageURLDetailAuto},
+			})
+		}
+		user = openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, MultiContent: parts}
+	}
+
+	tools := make([]openai.Tool, 0, len(req.Tools))
+	for _, tool := range req.Tools {
+		tools = append(tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  tool.Function.Parameters,
+			},
+		})
+	}
+
+	return openai.ChatCompletionRequest{
+		Model: req.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: req.SystemPrompt},
+			user,
+		},
+		Tools: tools,
+		// ToolChoice defaults to "auto" when tools are provided
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+}
+
+func (p *openAIProvider) Complete(ctx context.Context, req ChatRequest) (*Completion, error) {
+	resp, err := p.client.CreateChatCompletion(ctx, p.chatRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in LLM response")
+	}
+
+	choice := resp.Choices[0]
+	completion := &Completion{
+		Content:      choice.Message.Content,
+		FinishReason: string(choice.FinishReason),
+		Usage:        tokenUsage(resp.Usage),
+	}
+	for _, tc := range choice.Message.ToolCalls {
+		completion.ToolCalls = append(completion.ToolCalls, CompletionToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+	return completion, nil
+}
+
+func (p *openAIProvider) Stream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (*Completion, error) {
+	chatReq := p.chatRequest(req)
+	chatReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := p.client.CreateChatCompletionStream(ctx, chatReq)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	// Tool calls arrive in pieces, matched up by their index
+	var content strings.Builder
+	completion := &Completion{}
+	toolCalls := make(map[int]*CompletionToolCall)
+	var order []int
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if chunk.Usage != nil {
+			completion.Usage = tokenUsage(*chunk.Usage)
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		choice := chunk.Choices[0]
+		if choice.FinishReason != "" {
+			completion.FinishReason = string(choice.FinishReason)
+		}
+		for _, tc := range choice.Delta.ToolCalls {
+			index := len(order)
+			if tc.Index != nil {
+				index = *tc.Index
+			}
+			call, ok := toolCalls[index]
+			if !ok {
+				call = &CompletionToolCall{}
+				toolCalls[index] = call
+				order = append(order, index)
+			}
+			if tc.ID != "" {
+				call.ID = tc.ID
+			}
+			if tc.Function.Name != "" {
+				call.Name = tc.Function.Name
+			}
+			call.Arguments += tc.Function.Arguments
+		}
+		if choice.Delta.Content != "" {
+			content.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	completion.Content = content.String()
+	for _, index := range order {
+		completion.ToolCalls = append(completion.ToolCalls, *toolCalls[index])
+	}
+	return completion, nil
+}
+
+func (p *openAIProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	resp, err := p.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: texts,
+		Model: openai.EmbeddingModel(model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding response has out-of-range index %d", data.Index)
+		}
+		vectors[data.Index] = data.Embedding
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("embedding response is missing input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// Moderate calls the /moderations endpoint directly: the client library only
+// accepts the legacy text-moderation models
+func (p *openAIProvider) Moderate(ctx context.Context, model, text string) (*ModerationResult, error) {
+	body, err := json.Marshal(map[string]string{"model": model, "input": text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode moderation request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/moderations", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation API error: %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	var parsed struct {
+		Results []struct {
+			Flagged    bool            `json:"flagged"`
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+	if len(parsed.Results) == 0 {
+		return nil, fmt.Errorf("moderation response has no results")
+	}
+
+	result := &ModerationResult{Flagged: parsed.Results[0].Flagged}
+	for category, flagged := range parsed.Results[0].Categories {
+		if flagged {
+			result.Categories = append(result.Categories, category)
+		}
+	}
+	sort.Strings(result.Categories)
+	return result, nil
+}
@@ -0,0 +1,125 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Built-in provider names
+const (
+	ProviderLiteLLM   = "litellm"   // LiteLLM gateway (default)
+	ProviderOpenAI    = "openai"    // OpenAI or any OpenAI-compatible API
+	ProviderAnthropic = "anthropic" // Anthropic Messages API
+	ProviderOllama    = "ollama"    // Local Ollama server
+)
+
+// ChatRequest is one chat completion call in provider-neutral form
+type ChatRequest struct {
+	Model        string
+	SystemPrompt string
+	UserMessage  string
+	ImageURLs    []string // Only set for models that accept images
+	Tools        []Tool
+	Temperature  float32
+	MaxTokens    int // Reply length limit; 0 uses the provider's default
+}
+
+// Completion is a provider's reply to a ChatRequest
+type Completion struct {
+	Content      string
+	ToolCalls    []CompletionToolCall
+	FinishReason string
+	Usage        TokenUsage // Zero when the provider doesn't report usage
+}
+
+// CompletionToolCall is a tool call as the model sent it, with its arguments
+// still JSON-encoded
+type CompletionToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// Provider sends chat requests to one LLM API, translating messages, images
+// and tool calls to and from its wire format. Implementations must be safe
+// for concurrent use.
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req ChatRequest) (*Completion, error)
+}
+
+// Embedder is implemented by providers that serve embedding models
+type Embedder interface {
+	Embed(ctx context.Context, model string, texts []string) ([][]float32, error)
+}
+
+// Streamer is implemented by providers that can stream a reply's text as it
+// is generated. Stream calls onDelta with each piece of text in order and
+// returns the whole reply once it ends; an error from onDelta stops it.
+type Streamer interface {
+	Stream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (*Completion, error)
+}
+
+// secretHolder is implemented by providers with credentials that must be
+// redacted from traces and debug logs
+type secretHolder interface {
+	secrets() []string
+}
+
+// AddProvider registers a provider under its name, replacing any provider of
+// the same name. Call before the adapter is shared.
+func (a *LLMAdapter) AddProvider(p Provider) {
+	a.providers[p.Name()] = p
+	if holder, ok := p.(secretHolder); ok {
+		a.secrets = append(a.secrets, holder.secrets()...)
+	}
+}
+
+// SetDefaultProvider picks the registered provider used by calls that don't
+// name one. Call before the adapter is shared.
+func (a *LLMAdapter) SetDefaultProvider(name string) error {
+	if name == "" {
+		return nil
+	}
+	if !a.HasProvider(name) {
+		return fmt.Errorf("LLM provider %q is not configured (configured: %v)", name, a.Providers())
+	}
+	a.defaultProvider = name
+	return nil
+}
+
+// DefaultProvider returns the name of the provider used when a call doesn't
+// name one
+func (a *LLMAdapter) DefaultProvider() string {
+	return a.defaultProvider
+}
+
+// HasProvider reports whether a provider is registered under name
+func (a *LLMAdapter) HasProvider(name string) bool {
+	_, ok := a.providers[name]
+	return ok
+}
+
+// Providers returns the names of the registered providers, sorted
+func (a *LLMAdapter) Providers() []string {
+	names := make([]string, 0, len(a.providers))
+	for name := range a.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// provider returns the provider called name, or the default one for an
+// empty name
+func (a *LLMAdapter) provider(name string) (Provider, error) {
+	if name == "" {
+		name = a.defaultProvider
+	}
+	p, ok := a.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("LLM provider %q is not configured", name)
+	}
+	return p, nil
+}
@@ -0,0 +1,258 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeProvider answers every request with its name
+type fakeProvider struct {
+	name     string
+	requests []ChatRequest
+}
+
+func (f *fakeProvider) Name() string {
+	return f.name
+}
+
+func (f *fakeProvider) Complete(ctx context.Context, req ChatRequest) (*Completion, error) {
+	f.requests = append(f.requests, req)
+	return &Completion{Content: f.name}, nil
+}
+
+func TestLLMAdapter_RoutesCallsToProviders(t *testing.T) {
+	llm := NewLLMAdapter("http://localhost", "", "default-model")
+	local := &fakeProvider{name: ProviderOllama}
+	llm.AddProvider(local)
+
+	resp, err := llm.Generate(context.Background(), GenerateParams{Provider: ProviderOllama, Model: "llama3"}, "system", "hi", nil)
+	if err != nil || resp.Content != ProviderOllama {
+		t.Fatalf("Expected the call to go to ollama, got %+v, %v", resp, err)
+	}
+	if local.requests[0].Model != "llama3" || local.requests[0].Temperature != defaultTemperature {
+		t.Errorf("Expected the model and default temperature to be passed on, got %+v", local.requests[0])
+	}
+
+	if _, err := llm.Generate(context.Background(), GenerateParams{Provider: "missing"}, "system", "hi", nil); err == nil {
+		t.Error("Expected an unknown provider to fail")
+	}
+	if err := llm.SetDefaultProvider("missing"); err == nil {
+		t.Error("Expected an unknown default provider to be rejected")
+	}
+	if err := llm.SetDefaultProvider(ProviderOllama); err != nil {
+		t.Fatalf("SetDefaultProvider failed: %v", err)
+	}
+	if resp, err := llm.Generate(context.Background(), GenerateParams{}, "system", "hi", nil); err != nil || resp.Content != ProviderOllama {
+		t.Errorf("Expected calls without a provider to use the new default, got %+v, %v", resp, err)
+	}
+	if _, err := llm.Embed(context.Background(), "", "embed-model", []string{"hi"}); err == nil {
+		t.Error("Expected embeddings to fail for a provider that doesn't serve them")
+	}
+}
+
+func TestAnthropicProvider_TranslatesToolCalls(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "sk-ant-test" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("Unexpected request %s with headers %v", r.URL.Path, r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"content": [
+				{"type": "text", "text": "Let me look."},
+				{"type": "tool_use", "id": "toolu_1", "name": "web_search", "input": {"query": "go 1.30"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 12, "output_tokens": 8}
+		}`)
+	}))
+	defer server.Close()
+
+	llm := NewLLMAdapter("http://localhost", "", "claude-sonnet-4")
+	llm.AddProvider(NewAnthropicProvider(server.URL, "sk-ant-test", 0))
+	tools := []Tool{{Type: "function", Function: FunctionDefinition{
+		Name:       "web_search",
+		Parameters: map[string]interface{}{"type": "object"},
+	}}}
+	params := GenerateParams{Provider: ProviderAnthropic, ImageURLs: []string{"https://cdn.example.com/cat.png"}}
+	resp, err := llm.Generate(context.Background(), params, "be brief", "what's new in go?", tools)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if body["system"] != "be brief" || body["model"] != "claude-sonnet-4" {
+		t.Errorf("Expected the system prompt and model in the request, got %v", body)
+	}
+	if body["max_tokens"] != float64(DefaultAnthropicMaxTokens) {
+		t.Errorf("Expected the default reply limit, got %v", body["max_tokens"])
+	}
+	encoded, _ := json.Marshal(body)
+	if !strings.Contains(string(encoded), `"input_schema":{"type":"object"}`) || !strings.Contains(string(encoded), `"url":"https://cdn.example.com/cat.png"`) {
+		t.Errorf("Expected the tool schema and image in Anthropic's format, got %s", encoded)
+	}
+	if resp.Content != "Let me look." || len(resp.ToolCalls) != 1 {
+		t.Fatalf("Expected text and one tool call, got %+v", resp)
+	}
+	if call := resp.ToolCalls[0]; call.ID != "toolu_1" || call.Name != "web_search" || call.Arguments["query"] != "go 1.30" {
+		t.Errorf("Unexpected tool call %+v", call)
+	}
+	if resp.Usage.TotalTokens != 20 {
+		t.Errorf("Expected usage to be summed, got %+v", resp.Usage)
+	}
+}
+
+func TestLLMAdapter_AuxiliaryCallsUseTheAgentModel(t *testing.T) {
+	llm := NewLLMAdapter("http://localhost", "", "default-model")
+	local := &fakeProvider{name: ProviderOllama}
+	llm.AddProvider(local)
+	llm.AddProvider(&fakeProvider{name: ProviderLiteLLM})
+	ctx := WithAuxiliaryModel(context.Background(), AuxiliaryModel{Provider: ProviderOllama, Model: "llama3", MaxTokens: 256})
+
+	resp, err := llm.Generate(ctx, GenerateParams{Auxiliary: true}, "system", "hi", nil)
+	if err != nil || resp.Content != ProviderOllama {
+		t.Fatalf("Expected the auxiliary call to go to ollama, got %+v, %v", resp, err)
+	}
+	if req := local.requests[0]; req.Model != "llama3" || req.MaxTokens != 256 {
+		t.Errorf("Expected the agent's auxiliary model and limit, got %+v", req)
+	}
+
+	if _, err := llm.Generate(ctx, GenerateParams{Provider: ProviderOllama, Model: "qwen3", Auxiliary: true}, "system", "hi", nil); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if req := local.requests[1]; req.Model != "qwen3" || req.MaxTokens != 256 {
+		t.Errorf("Expected a model set on the call to win, got %+v", req)
+	}
+
+	if resp, err := llm.Generate(ctx, GenerateParams{}, "system", "hi", nil); err != nil || resp.Content != ProviderLiteLLM {
+		t.Errorf("Expected a reply call to ignore the auxiliary model, got %+v, %v", resp, err)
+	}
+}
+
+func TestOpenAIProvider_StreamsReply(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"web_search","arguments":"{\"query\":"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"go\"}"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{},"finish_reason":"length"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":5,"total_tokens":12}}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	llm := NewLLMAdapter("http://localhost", "", "gpt-4o")
+	llm.AddProvider(NewOpenAIProvider(ProviderOpenAI, server.URL, "sk-test"))
+	var deltas []string
+	resp, err := llm.GenerateStream(context.Background(), GenerateParams{Provider: ProviderOpenAI}, "be brief", "hi", nil, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream failed: %v", err)
+	}
+
+	if body["stream"] != true {
+		t.Errorf("Expected a streamed request, got %v", body)
+	}
+	if strings.Join(deltas, "|") != "Hel|lo" || resp.Content != "Hello" {
+		t.Errorf("Expected the text in two pieces, got %q and %q", deltas, resp.Content)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "call_1" || resp.ToolCalls[0].Arguments["query"] != "go" {
+		t.Errorf("Expected the tool call to be put back together, got %+v", resp.ToolCalls)
+	}
+	if !resp.Truncated || resp.Usage.TotalTokens != 12 {
+		t.Errorf("Expected the finish reason and usage, got %+v", resp)
+	}
+}
+
+func TestAnthropicProvider_StreamsReply(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`{"type":"message_start","message":{"usage":{"input_tokens":12}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"ping"}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me "}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"look."}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"web_search","input":{}}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"query\": "}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"go 1.30\"}"}}`,
+			`{"type":"content_block_stop","index":1}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":8}}`,
+			`{"type":"message_stop"}`,
+		} {
+			var parsed struct{ Type string }
+			_ = json.Unmarshal([]byte(event), &parsed)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", parsed.Type, event)
+		}
+	}))
+	defer server.Close()
+
+	llm := NewLLMAdapter("http://localhost", "", "claude-sonnet-4")
+	llm.AddProvider(NewAnthropicProvider(server.URL, "sk-ant-test", 0))
+	var deltas []string
+	resp, err := llm.GenerateStream(context.Background(), GenerateParams{Provider: ProviderAnthropic}, "be brief", "what's new in go?", nil, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream failed: %v", err)
+	}
+
+	if body["stream"] != true {
+		t.Errorf("Expected a streamed request, got %v", body)
+	}
+	if strings.Join(deltas, "|") != "Let me |look." || resp.Content != "Let me look." {
+		t.Errorf("Expected the text in two pieces, got %q and %q", deltas, resp.Content)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "toolu_1" || resp.ToolCalls[0].Arguments["query"] != "go 1.30" {
+		t.Errorf("Expected the tool call to be put back together, got %+v", resp.ToolCalls)
+	}
+	if resp.Usage.TotalTokens != 20 {
+		t.Errorf("Expected usage to be summed, got %+v", resp.Usage)
+	}
+}
+
+func TestLLMAdapter_GenerateStream(t *testing.T) {
+	llm := NewLLMAdapter("http://localhost", "", "default-model")
+	llm.AddProvider(&fakeProvider{name: ProviderLiteLLM})
+
+	var deltas []string
+	resp, err := llm.GenerateStream(context.Background(), GenerateParams{}, "system", "hi", nil, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil || resp.Content != ProviderLiteLLM || len(deltas) != 1 || deltas[0] != ProviderLiteLLM {
+		t.Errorf("Expected a provider that can't stream to deliver the whole reply at once, got %q, %+v, %v", deltas, resp, err)
+	}
+
+	stop := fmt.Errorf("reader went away")
+	if _, err := llm.GenerateStream(context.Background(), GenerateParams{}, "system", "hi", nil, func(delta string) error {
+		return stop
+	}); !errors.Is(err, stop) {
+		t.Errorf("Expected an error from onDelta to stop the call, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ezra-clone/backend/internal/cache"
+	"go.uber.org/zap"
+)
+
+// defaultVisionModels are model name patterns known to accept image inputs
+var defaultVisionModels = []string{
+	"gpt-4o", "gpt-4.1", "gpt-4-turbo", "gpt-4-vision",
+	"claude-3", "claude-sonnet-4", "claude-opus-4",
+	"gemini", "llava", "pixtral", "qwen-vl", "vision",
+}
+
+// captionPrompt asks the caption model for a description a text-only model can use
+const captionPrompt = "Describe this image in two or three sentences. Mention any visible text verbatim."
+
+// maxCachedCaptions bounds the caption cache, which remembers image
+// descriptions so recursive rounds of a turn don't caption the same image again
+const maxCachedCaptions = 200
+
+// CaptionCache returns the cache of image descriptions
+func (a *LLMAdapter) CaptionCache() cache.Clearable {
+	return a.captions
+}
+
+// SetVisionModels replaces the model name patterns that accept image inputs.
+// An empty list keeps the defaults. Call before the adapter is shared.
+func (a *LLMAdapter) SetVisionModels(patterns []string) {
+	if len(patterns) > 0 {
+		a.visionModels = patterns
+	}
+}
+
+// SetCaptionModel sets the vision model used to describe images for
+// text-only models. Call before the adapter is shared.
+func (a *LLMAdapter) SetCaptionModel(model string) {
+	a.captionModel = model
+}
+
+// SupportsVision reports whether model accepts image inputs. The caption
+// model is always treated as vision-capable.
+func (a *LLMAdapter) SupportsVision(model string) bool {
+	if model == "" {
+		model = a.defaultModel
+	}
+	if a.captionModel != "" && model == a.captionModel {
+		return true
+	}
+	lower := strings.ToLower(model)
+	for _, pattern := range a.visionModels {
+		if strings.Contains(lower, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// DescribeImage returns a short description of the image at imageURL using
+// the caption model
+func (a *LLMAdapter) DescribeImage(ctx context.Context, imageURL string) (string, error) {
+	if a.captionModel == "" {
+		return "", fmt.Errorf("no caption model configured")
+	}
+	if caption, ok := a.captions.Get(imageURL); ok {
+		return caption, nil
+	}
+
+	params := GenerateParams{Model: a.captionModel, Temperature: 0.2, ImageURLs: []string{imageURL}}
+	resp, err := a.Generate(ctx, params, "You describe images for an assistant that cannot see them.", captionPrompt, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe image: %w", err)
+	}
+	caption := strings.TrimSpace(resp.Content)
+	if caption == "" {
+		return "", fmt.Errorf("caption model returned an empty description")
+	}
+	a.captions.Put(imageURL, caption)
+	return caption, nil
+}
+
+// userContent builds the user message for a call. Vision models get the
+// images as they are; text-only models get a description of each image
+// appended to the text instead, and no images.
+func (a *LLMAdapter) userContent(ctx context.Context, params GenerateParams, userMsg string) (string, []string) {
+	if len(params.ImageURLs) == 0 {
+		return userMsg, nil
+	}
+
+	if a.SupportsVision(params.Model) {
+		return userMsg, params.ImageURLs
+	}
+
+	var b strings.Builder
+	b.WriteString(userMsg)
+	for _, url := range params.ImageURLs {
+		caption, err := a.DescribeImage(ctx, url)
+		if err != nil {
+			a.logger.Debug("Image not described for text-only model",
+				zap.String("model", params.Model),
+				zap.String("url", url),
+				zap.Error(err),
+			)
+			fmt.Fprintf(&b, "\n\n[The user attached an image you cannot see: %s]", url)
+			continue
+		}
+		fmt.Fprintf(&b, "\n\n[The user attached an image: %s]", caption)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,100 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingServer answers chat completions with fixed content and keeps the
+// raw request bodies
+type recordingServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies []string
+}
+
+func newRecordingServer(t *testing.T, reply string) *recordingServer {
+	rs := &recordingServer{}
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		rs.mu.Lock()
+		rs.bodies = append(rs.bodies, string(body))
+		rs.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":%q}}]}`, reply)
+	}))
+	return rs
+}
+
+func TestLLMAdapter_Generate_VisionModelGetsImageParts(t *testing.T) {
+	server := newRecordingServer(t, "a cat")
+	defer server.Close()
+
+	llm := NewLLMAdapter(server.URL, "", "openrouter/openai/gpt-4o")
+	params := GenerateParams{ImageURLs: []string{"https://cdn.example.com/cat.png"}}
+	if _, err := llm.Generate(context.Background(), params, "system", "what is this?", nil); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if len(server.bodies) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(server.bodies))
+	}
+	body := server.bodies[0]
+	if !strings.Contains(body, `"type":"image_url"`) || !strings.Contains(body, "https://cdn.example.com/cat.png") {
+		t.Errorf("Expected image_url part in request, got %s", body)
+	}
+}
+
+func TestLLMAdapter_Generate_TextModelGetsCaption(t *testing.T) {
+	server := newRecordingServer(t, "A tabby cat asleep on a keyboard.")
+	defer server.Close()
+
+	llm := NewLLMAdapter(server.URL, "", "text-only-model")
+	llm.SetCaptionModel("caption-model")
+	params := GenerateParams{ImageURLs: []string{"https://cdn.example.com/cat.png"}}
+	for i := 0; i < 2; i++ {
+		if _, err := llm.Generate(context.Background(), params, "system", "what is this?", nil); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+	}
+
+	// One caption call, cached for the second turn, plus two text-only calls
+	if len(server.bodies) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(server.bodies))
+	}
+	if !strings.Contains(server.bodies[0], `"model":"caption-model"`) || !strings.Contains(server.bodies[0], `"type":"image_url"`) {
+		t.Errorf("Expected caption request with the image, got %s", server.bodies[0])
+	}
+	for _, body := range server.bodies[1:] {
+		if strings.Contains(body, "image_url") {
+			t.Errorf("Text-only model should not receive image parts, got %s", body)
+		}
+		if !strings.Contains(body, "A tabby cat asleep on a keyboard.") {
+			t.Errorf("Expected caption in user message, got %s", body)
+		}
+	}
+}
+
+func TestLLMAdapter_SupportsVision(t *testing.T) {
+	llm := NewLLMAdapter("http://localhost", "", "openrouter/anthropic/claude-3.5-sonnet")
+	if !llm.SupportsVision("") {
+		t.Error("Expected default claude-3 model to support vision")
+	}
+	if llm.SupportsVision("meta-llama/llama-3-8b") {
+		t.Error("Expected llama-3 to be text-only")
+	}
+
+	llm.SetVisionModels([]string{"llama-3"})
+	if !llm.SupportsVision("meta-llama/llama-3-8b") {
+		t.Error("Expected configured pattern to enable vision")
+	}
+}
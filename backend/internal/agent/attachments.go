@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/tools"
+)
+
+// attachmentNote lists the non-image attachments for the LLM; images are
+// sent to the model directly or described by the adapter
+func attachmentNote(attachments []tools.Attachment) string {
+	var lines []string
+	for _, attachment := range attachments {
+		if attachment.IsImage() {
+			continue
+		}
+		name := attachment.Filename
+		if name == "" {
+			name = "file"
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s", name, attachment.URL))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n\n[The user attached these files]:\n" + strings.Join(lines, "\n")
+}
+
+// attachmentURLs returns the URLs of every attachment, for the message log
+func attachmentURLs(attachments []tools.Attachment) []string {
+	urls := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		urls = append(urls, attachment.URL)
+	}
+	return urls
+}
+
+// historyAttachmentNote describes the files shared with a past message
+func historyAttachmentNote(msg graph.Message) string {
+	if len(msg.Attachments) == 0 {
+		return ""
+	}
+	images := tools.ImageURLs(attachmentsFromURLs(msg.Attachments))
+	switch {
+	case len(images) == len(msg.Attachments) && len(images) == 1:
+		return " [shared an image]"
+	case len(images) == len(msg.Attachments):
+		return fmt.Sprintf(" [shared %d images]", len(images))
+	case len(msg.Attachments) == 1:
+		return " [shared a file]"
+	default:
+		return fmt.Sprintf(" [shared %d attachments]", len(msg.Attachments))
+	}
+}
+
+// attachmentsFromURLs rebuilds attachments from stored URLs
+func attachmentsFromURLs(urls []string) []tools.Attachment {
+	attachments := make([]tools.Attachment, 0, len(urls))
+	for _, url := range urls {
+		attachments = append(attachments, tools.Attachment{URL: url})
+	}
+	return attachments
+}
@@ -0,0 +1,211 @@
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/moderation"
+	"ezra-clone/backend/internal/tools"
+	apperrors "ezra-clone/backend/pkg/errors"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ContinuationTTL is how long a cut-off reply can be continued
+	ContinuationTTL = 30 * time.Minute
+	// continuationTailChars is how much of the cut-off reply the model is
+	// shown to pick up from
+	continuationTailChars = 2000
+)
+
+// ErrNoContinuation is returned by ContinueTurn when the channel has no
+// cut-off reply to resume
+var ErrNoContinuation = apperrors.NewBaseError(apperrors.ErrorTypeAgent, "no reply to continue", nil)
+
+// continueRequests are the messages that ask for the rest of a cut-off reply
+var continueRequests = map[string]bool{
+	"continue":        true,
+	"continue please": true,
+	"please continue": true,
+	"go on":           true,
+	"keep going":      true,
+	"more":            true,
+}
+
+// IsContinueRequest reports whether message asks the agent to go on with
+// its last reply, e.g. "continue" or "keep going"
+func IsContinueRequest(message string) bool {
+	message = strings.ToLower(strings.Trim(strings.TrimSpace(message), ".!…"))
+	return continueRequests[message]
+}
+
+// continuation is what's needed to resume a reply that hit the length limit
+type continuation struct {
+	id            string // Set by put, new for each cut-off reply
+	request       string // The user's message the reply answers
+	tail          string // The end of what was sent so far
+	params        adapter.GenerateParams
+	systemPrompt  string
+	contentFilter moderation.Settings
+	expires       time.Time
+}
+
+// continuationStore holds the latest cut-off reply per agent and channel
+type continuationStore struct {
+	mu      sync.Mutex
+	entries map[string]continuation
+	now     func() time.Time
+}
+
+func newContinuationStore() *continuationStore {
+	return &continuationStore{entries: make(map[string]continuation), now: time.Now}
+}
+
+func continuationKey(agentID, channelID string) string {
+	return agentID + "|" + channelID
+}
+
+// put stores c for the channel, replacing any earlier one, and returns the
+// ID it was given
+func (s *continuationStore) put(agentID, channelID string, c continuation) string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	c.id = hex.EncodeToString(id[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.expires = s.now().Add(ContinuationTTL)
+	s.entries[continuationKey(agentID, channelID)] = c
+	return c.id
+}
+
+// take removes and returns the channel's continuation, if it hasn't expired
+func (s *continuationStore) take(agentID, channelID string) (continuation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := continuationKey(agentID, channelID)
+	c, ok := s.entries[key]
+	delete(s.entries, key)
+	return c, ok && s.now().Before(c.expires)
+}
+
+// has reports whether the channel has a continuation that hasn't expired
+// and, unless id is empty, has that ID
+func (s *continuationStore) has(agentID, channelID, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.entries[continuationKey(agentID, channelID)]
+	return ok && s.now().Before(c.expires) && (id == "" || c.id == id)
+}
+
+// drop forgets the channel's continuation
+func (s *continuationStore) drop(agentID, channelID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, continuationKey(agentID, channelID))
+}
+
+// replyTail returns the last continuationTailChars of reply, cut at a rune
+// boundary
+func replyTail(reply string) string {
+	if len(reply) <= continuationTailChars {
+		return reply
+	}
+	start := len(reply) - continuationTailChars
+	for start < len(reply) && !utf8.RuneStart(reply[start]) {
+		start++
+	}
+	return reply[start:]
+}
+
+// buildContinuationMessage asks the model for the rest of its reply to request
+func buildContinuationMessage(request, tail string) string {
+	return fmt.Sprintf("%s\n\n[Your reply to this message was cut off by the length limit. It ended with:]\n%s\n\n[Continue the reply exactly where it stopped. Don't repeat what you already wrote, and don't add an introduction.]", request, tail)
+}
+
+// CanContinue reports whether the channel has a cut-off reply ContinueTurn
+// can resume. A non-empty continuationID (TurnResult.ContinuationID) must be
+// the channel's latest cut-off reply.
+func (o *Orchestrator) CanContinue(agentID, channelID, continuationID string) bool {
+	return channelID != "" && o.continuations.has(agentID, channelID, continuationID)
+}
+
+// ContinueTurn resumes the channel's last reply that was cut off by the
+// model's length limit, as if the user had said "continue". With a
+// continuationID it only resumes that reply, so a stale Continue button
+// doesn't resume a later one. It returns ErrNoContinuation when there is
+// nothing to resume.
+func (o *Orchestrator) ContinueTurn(ctx context.Context, execCtx *tools.ExecutionContext, continuationID string) (*TurnResult, error) {
+	if !o.CanContinue(execCtx.AgentID, execCtx.ChannelID, continuationID) {
+		return nil, ErrNoContinuation
+	}
+	return o.RunTurnWithExecutionContext(ctx, execCtx, "continue")
+}
+
+// takeContinuation returns the channel's continuation when message asks for
+// it. Any other message starts a new exchange, so the continuation is dropped.
+func (o *Orchestrator) takeContinuation(execCtx *tools.ExecutionContext, message string) (continuation, bool) {
+	if execCtx.ChannelID == "" {
+		return continuation{}, false
+	}
+	if !IsContinueRequest(message) {
+		o.continuations.drop(execCtx.AgentID, execCtx.ChannelID)
+		return continuation{}, false
+	}
+	return o.continuations.take(execCtx.AgentID, execCtx.ChannelID)
+}
+
+// rememberContinuation keeps what's needed to resume a reply that was cut
+// off and returns its ID, or "" when it can't be continued
+func (o *Orchestrator) rememberContinuation(execCtx *tools.ExecutionContext, request, reply string, round *turnRound) string {
+	if execCtx.ChannelID == "" {
+		return ""
+	}
+	id := o.continuations.put(execCtx.AgentID, execCtx.ChannelID, continuation{
+		request:       request,
+		tail:          replyTail(reply),
+		params:        round.params,
+		systemPrompt:  round.systemPrompt,
+		contentFilter: round.contentFilter,
+	})
+	o.logger.Debug("Reply hit the length limit; it can be continued",
+		zap.String("agent_id", execCtx.AgentID),
+		zap.String("channel_id", execCtx.ChannelID),
+	)
+	return id
+}
+
+// runContinuation asks the model for the rest of a cut-off reply, with the
+// prompt the reply was generated from. Tools aren't offered: the reply
+// already had its tool results.
+func (o *Orchestrator) runContinuation(ctx context.Context, execCtx *tools.ExecutionContext, message string, c continuation) (*TurnResult, error) {
+	llmResponse, err := o.llm.Generate(ctx, c.params, c.systemPrompt, buildContinuationMessage(c.request, c.tail), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to continue the reply: %w", err)
+	}
+
+	filtered := false
+	if c.contentFilter.Enabled() {
+		llmResponse.Content, filtered = o.contentFilter.Apply(ctx, c.contentFilter, execCtx.AgentID, execCtx.ChannelID, llmResponse.Content)
+	}
+	o.finishTurn(ctx, execCtx, message, llmResponse)
+	if filtered && llmResponse.Content == "" {
+		return &TurnResult{}, ErrIgnored
+	}
+
+	result := BuildTurnResult(llmResponse, nil, nil)
+	if llmResponse.Truncated && !filtered {
+		c.tail = replyTail(llmResponse.Content)
+		result.ContinuationID = o.continuations.put(execCtx.AgentID, execCtx.ChannelID, c)
+		result.Truncated = true
+	}
+	return result, nil
+}
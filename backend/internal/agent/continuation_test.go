@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestIsContinueRequest(t *testing.T) {
+	for _, message := range []string{"continue", "Continue.", "  keep going! ", "Go on", "more"} {
+		if !IsContinueRequest(message) {
+			t.Errorf("Expected %q to ask for the rest of the reply", message)
+		}
+	}
+	for _, message := range []string{"", "continue with the next topic", "tell me more about cats"} {
+		if IsContinueRequest(message) {
+			t.Errorf("Expected %q to be a new message", message)
+		}
+	}
+}
+
+func TestContinuationStore_ExpiresAndTakesOnce(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := newContinuationStore()
+	store.now = func() time.Time { return now }
+
+	id := store.put("Ezra", "c1", continuation{request: "write an essay", tail: "and so"})
+	if store.has("Ezra", "c2", "") || store.has("Other", "c1", "") {
+		t.Error("Expected continuations to be kept per agent and channel")
+	}
+	if !store.has("Ezra", "c1", id) || store.has("Ezra", "c1", "stale") {
+		t.Error("Expected a continuation to be found by its own ID only")
+	}
+	c, ok := store.take("Ezra", "c1")
+	if !ok || c.tail != "and so" {
+		t.Fatalf("Expected the continuation back, got %+v, %v", c, ok)
+	}
+	if _, ok := store.take("Ezra", "c1"); ok {
+		t.Error("Expected a continuation to be used only once")
+	}
+
+	if next := store.put("Ezra", "c1", continuation{request: "write an essay"}); next == id {
+		t.Error("Expected each cut-off reply to get a new ID")
+	}
+	store.now = func() time.Time { return now.Add(ContinuationTTL) }
+	if store.has("Ezra", "c1", "") {
+		t.Error("Expected the continuation to expire")
+	}
+	if _, ok := store.take("Ezra", "c1"); ok {
+		t.Error("Expected an expired continuation not to be taken")
+	}
+}
+
+func TestReplyTail_CutsAtRuneBoundary(t *testing.T) {
+	reply := strings.Repeat("é", continuationTailChars)
+	tail := replyTail(reply)
+	if !utf8.ValidString(tail) || len(tail) > continuationTailChars {
+		t.Errorf("Expected a valid tail of at most %d bytes, got %d bytes", continuationTailChars, len(tail))
+	}
+	if !strings.HasSuffix(reply, tail) {
+		t.Error("Expected the tail to be the end of the reply")
+	}
+}
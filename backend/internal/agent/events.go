@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"time"
+
+	"ezra-clone/backend/internal/utils"
+	"ezra-clone/backend/internal/webhook"
+)
+
+const (
+	// DefaultErrorAlertThreshold is how many failed turns within
+	// DefaultErrorAlertWindow raise an errors.threshold event
+	DefaultErrorAlertThreshold = 5
+	// DefaultErrorAlertWindow is the window failed turns are counted over
+	DefaultErrorAlertWindow = 5 * time.Minute
+	// highImportance is the lowest importance that raises a memory.important event
+	highImportance = 8
+)
+
+// EventNotifier delivers agent events to integrations. Notify is called on
+// the turn's goroutine, so it must not block.
+type EventNotifier interface {
+	Notify(event webhook.Event)
+}
+
+// SetEventNotifier registers a notifier for important memories and error
+// alerts. Pass nil to disable.
+func (o *Orchestrator) SetEventNotifier(notifier EventNotifier) {
+	o.events = notifier
+	o.memoryEvaluator.events = notifier
+}
+
+// SetErrorAlertThreshold sets how many failed turns an agent may have within
+// window before an errors.threshold event is sent. A threshold of 0 disables
+// the alert.
+func (o *Orchestrator) SetErrorAlertThreshold(threshold int, window time.Duration) {
+	o.errorTracker = utils.NewErrorRateTracker(threshold, window)
+}
+
+// recordTurnError counts a failed turn and sends an alert when the agent
+// reaches the error threshold
+func (o *Orchestrator) recordTurnError(agentID string, turnErr error) {
+	if o.events == nil {
+		return
+	}
+	count := o.errorTracker.Record(agentID, time.Now())
+	if count == 0 {
+		return
+	}
+	o.events.Notify(webhook.Event{
+		Type:    webhook.EventErrorThreshold,
+		AgentID: agentID,
+		ID:      agentID,
+		Content: turnErr.Error(),
+		Data: map[string]interface{}{
+			"errors":         count,
+			"window_seconds": int(o.errorTracker.Window().Seconds()),
+		},
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// notifyImportant sends a memory.important event for a saved fact when the
+// decision rated it highly
+func (m *MemoryEvaluator) notifyImportant(agentID, userID, factID string, decision *MemoryDecision) {
+	if m.events == nil || decision.Importance < highImportance {
+		return
+	}
+	m.events.Notify(webhook.Event{
+		Type:    webhook.EventMemoryImportant,
+		AgentID: agentID,
+		UserID:  userID,
+		ID:      factID,
+		Content: decision.Content,
+		Data: map[string]interface{}{
+			"importance":  decision.Importance,
+			"memory_type": decision.MemoryType,
+		},
+		Timestamp: time.Now().UTC(),
+	})
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/state"
+	"ezra-clone/backend/internal/tools"
+)
+
+// maxTemplateValueLength caps substituted user-controlled values so a long
+// username can't swamp the instructions
+const maxTemplateValueLength = 100
+
+// instructionFuncs are the only helper functions available to system
+// instruction templates
+var instructionFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// instructionVars builds the variables available to system instruction
+// templates. User-controlled values are escaped before substitution.
+func instructionVars(ctxWindow *state.ContextWindow, userCtx *graph.UserContext, execCtx *tools.ExecutionContext, now time.Time) map[string]string {
+	vars := map[string]string{
+		"agent_id":   execCtx.AgentID,
+		"user_id":    execCtx.UserID,
+		"user_name":  escapeTemplateValue(execCtx.UserID),
+		"platform":   execCtx.Platform,
+		"channel_id": execCtx.ChannelID,
+		"date":       now.Format("Monday, January 2, 2006"),
+		"time":       now.Format("15:04 MST"),
+		"year":       fmt.Sprintf("%d", now.Year()),
+		"language":   "",
+	}
+	if ctxWindow != nil {
+		vars["agent_name"] = ctxWindow.Identity.Name
+	}
+	if userCtx != nil {
+		if userCtx.User.DiscordUsername != "" {
+			vars["user_name"] = escapeTemplateValue(userCtx.User.DiscordUsername)
+		}
+		vars["language"] = escapeTemplateValue(userCtx.User.PreferredLanguage)
+	}
+	return vars
+}
+
+// escapeTemplateValue neutralises template delimiters and line breaks in a
+// user-controlled value so it can't inject directives or prompt sections
+func escapeTemplateValue(value string) string {
+	value = strings.ReplaceAll(value, "{{", "{ {")
+	value = strings.ReplaceAll(value, "}}", "} }")
+	value = strings.Join(strings.Fields(value), " ")
+	if runes := []rune(value); len(runes) > maxTemplateValueLength {
+		value = string(runes[:maxTemplateValueLength])
+	}
+	return value
+}
+
+// renderInstructions substitutes variables into stored system instructions.
+// Variables can be written as {{user_name}} or {{.user_name}}.
+func renderInstructions(raw string, vars map[string]string) (string, error) {
+	if !strings.Contains(raw, "{{") {
+		return raw, nil
+	}
+
+	funcs := template.FuncMap{}
+	for name, fn := range instructionFuncs {
+		funcs[name] = fn
+	}
+	for name, value := range vars {
+		value := value
+		funcs[name] = func() string { return value }
+	}
+
+	tmpl, err := template.New("system_instructions").Funcs(funcs).Option("missingkey=zero").Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse system instructions template: %w", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return "", fmt.Errorf("failed to render system instructions template: %w", err)
+	}
+	return out.String(), nil
+}
@@ -6,18 +6,46 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"ezra-clone/backend/internal/adapter"
 	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/tools"
+	"ezra-clone/backend/internal/utils"
 	"ezra-clone/backend/pkg/logger"
 	"go.uber.org/zap"
 )
 
 // MemoryEvaluator automatically evaluates messages to determine if they should be saved to memory
 type MemoryEvaluator struct {
-	llm       *adapter.LLMAdapter
-	graphRepo *graph.Repository
-	logger    *zap.Logger
+	llm             *adapter.LLMAdapter
+	graphRepo       *graph.Repository
+	logger          *zap.Logger
+	languageTracker *utils.LanguageMismatchTracker
+	coalescer       *utils.MessageCoalescer // Batches a user's messages within the cooldown
+	minLength       int                     // Shortest (coalesced) text worth evaluating
+	events          EventNotifier           // Told about highly important saved memories; nil disables
+}
+
+const (
+	// DefaultMemoryEvalMinLength is the shortest text evaluated for memories
+	DefaultMemoryEvalMinLength = 10
+	// DefaultMemoryEvalCooldown is how long a user's messages are collected
+	// before they are evaluated together
+	DefaultMemoryEvalCooldown = 30 * time.Second
+	// memoryEvalTimeout bounds one evaluation, including saving the decision
+	memoryEvalTimeout = 30 * time.Second
+	// minSaveImportance is the lowest importance ApplyDecision saves
+	minSaveImportance = 3
+)
+
+// MemoryEvaluation is what the evaluator would do with a message, without
+// having done it
+type MemoryEvaluation struct {
+	Decision     *MemoryDecision `json:"decision"`
+	WouldSave    bool            `json:"would_save"`
+	Action       string          `json:"action"` // "create", "update" or "none"
+	SimilarFacts []graph.Fact    `json:"similar_facts"`
 }
 
 // MemoryDecision represents the evaluator's decision about what to save
@@ -35,22 +63,151 @@ type MemoryDecision struct {
 // NewMemoryEvaluator creates a new memory evaluator
 func NewMemoryEvaluator(llm *adapter.LLMAdapter, repo *graph.Repository) *MemoryEvaluator {
 	return &MemoryEvaluator{
-		llm:       llm,
-		graphRepo: repo,
-		logger:    logger.Get(),
+		llm:             llm,
+		graphRepo:       repo,
+		logger:          logger.Get(),
+		languageTracker: utils.NewLanguageMismatchTracker(utils.LanguageMismatchThreshold),
+		coalescer:       utils.NewMessageCoalescer(DefaultMemoryEvalCooldown),
+		minLength:       DefaultMemoryEvalMinLength,
+	}
+}
+
+// SetLimits sets the minimum text length worth evaluating and the per-user
+// cooldown during which messages are collected into one evaluation. A zero
+// cooldown evaluates every message on its own; negative values are ignored.
+func (m *MemoryEvaluator) SetLimits(minLength int, cooldown time.Duration) {
+	if minLength >= 0 {
+		m.minLength = minLength
+	}
+	m.coalescer.SetCooldown(cooldown)
+}
+
+// QueueMessage schedules a user message for memory evaluation. Messages that
+// are clearly greetings, questions or commands are dropped; the rest are
+// joined with the user's other messages from the cooldown window and
+// evaluated once, in the background.
+func (m *MemoryEvaluator) QueueMessage(agentID, userID, message string) {
+	if strings.TrimSpace(message) == "" || m.isNonMemoryMessage(message) {
+		return
+	}
+	m.coalescer.Add(agentID+"/"+userID, message, func(text string) {
+		m.evaluateAndApply(agentID, userID, text)
+	})
+}
+
+// evaluateAndApply evaluates coalesced text and saves the resulting memory
+func (m *MemoryEvaluator) evaluateAndApply(agentID, userID, text string) {
+	ctx, cancel := context.WithTimeout(context.Background(), memoryEvalTimeout)
+	defer cancel()
+	ctx = m.withAgentModels(ctx, agentID)
+
+	decision, err := m.EvaluateMessage(ctx, agentID, userID, text)
+	if err != nil {
+		m.logger.Debug("Memory evaluation failed (non-critical)",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if decision != nil && decision.ShouldSave {
+		if err := m.ApplyDecision(ctx, agentID, userID, decision); err != nil {
+			m.logger.Warn("Failed to auto-save memory",
+				zap.String("user_id", userID),
+				zap.String("memory_type", decision.MemoryType),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// withAgentModels returns ctx with the agent's auxiliary model, which the
+// evaluation calls use. If the config can't be loaded they use the defaults.
+func (m *MemoryEvaluator) withAgentModels(ctx context.Context, agentID string) context.Context {
+	if m.graphRepo == nil {
+		return ctx
+	}
+	agentConfig, err := m.graphRepo.GetAgentConfig(ctx, agentID)
+	if err != nil {
+		m.logger.Debug("Failed to load the agent's models; using the defaults",
+			zap.String("agent_id", agentID),
+			zap.Error(err),
+		)
+		return ctx
 	}
+	return adapter.WithAuxiliaryModel(ctx, tools.AgentAuxiliaryModel(agentConfig))
+}
+
+// EvaluateLanguage detects the language of a user message and proposes a new
+// preferred_language once several messages in a row disagree with the stored
+// one. Returns the proposed language code, or an empty string.
+func (m *MemoryEvaluator) EvaluateLanguage(ctx context.Context, userID, message string) (string, error) {
+	detected := utils.DetectLanguage(message)
+	if detected == "" {
+		return "", nil
+	}
+
+	preferred, err := m.graphRepo.GetUserLanguagePreference(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	proposed := m.languageTracker.Observe(userID, preferred, detected)
+	if proposed != "" {
+		m.logger.Info("Proposing preferred language update",
+			zap.String("user_id", userID),
+			zap.String("current", preferred),
+			zap.String("proposed", proposed),
+		)
+	}
+	return proposed, nil
+}
+
+// DryRun evaluates a message the way automatic evaluation would, including
+// the duplicate check ApplyDecision does, but saves nothing
+func (m *MemoryEvaluator) DryRun(ctx context.Context, agentID, userID, message string) (*MemoryEvaluation, error) {
+	ctx = m.withAgentModels(ctx, agentID)
+	decision, err := m.EvaluateMessage(ctx, agentID, userID, message)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluation := &MemoryEvaluation{
+		Decision:     decision,
+		WouldSave:    decision.ShouldSave && decision.Importance >= minSaveImportance,
+		Action:       "none",
+		SimilarFacts: []graph.Fact{},
+	}
+	if !evaluation.WouldSave {
+		return evaluation, nil
+	}
+
+	evaluation.Action = "create"
+	if decision.UpdatesExisting && decision.ExistingID != "" {
+		evaluation.Action = "update"
+		return evaluation, nil
+	}
+
+	similar, err := m.findSimilarFacts(ctx, userID, decision.Content)
+	if err != nil {
+		m.logger.Warn("Failed to check for similar facts", zap.Error(err))
+	} else if len(similar) > 0 {
+		evaluation.SimilarFacts = similar
+		evaluation.Action = "update"
+	}
+	return evaluation, nil
 }
 
 // EvaluateMessage analyzes a user message and determines if anything should be saved to memory
 func (m *MemoryEvaluator) EvaluateMessage(ctx context.Context, agentID, userID, message string) (*MemoryDecision, error) {
 	// Skip very short messages or obvious non-memory messages
-	if len(strings.TrimSpace(message)) < 10 {
-		return &MemoryDecision{ShouldSave: false}, nil
+	if len(strings.TrimSpace(message)) < m.minLength {
+		return &MemoryDecision{ShouldSave: false, Reasoning: "message is too short to evaluate"}, nil
 	}
 
 	// Quick filter: skip greetings, questions, and commands
 	if m.isNonMemoryMessage(message) {
-		return &MemoryDecision{ShouldSave: false}, nil
+		return &MemoryDecision{ShouldSave: false, Reasoning: "message looks like a greeting, question or command"}, nil
 	}
 
 	// Get existing facts about this user for contradiction detection
@@ -110,7 +267,7 @@ Guidelines:
 - Be aggressive about detecting duplicates - if you see "User prefers X" and "User prefers to communicate in X", they are duplicates`, message, existingJSON)
 
 	// Call LLM for evaluation
-	response, err := m.llm.Generate(ctx, prompt, "Analyze and respond with JSON only. No markdown, no explanation, just the JSON object.", nil)
+	response, err := m.llm.Generate(ctx, adapter.GenerateParams{Auxiliary: true}, prompt, "Analyze and respond with JSON only. No markdown, no explanation, just the JSON object.", nil)
 	if err != nil {
 		m.logger.Warn("Memory evaluation LLM call failed",
 			zap.String("user_id", userID),
@@ -176,7 +333,7 @@ Guidelines:
 
 // ApplyDecision saves the memory based on the evaluation decision
 func (m *MemoryEvaluator) ApplyDecision(ctx context.Context, agentID, userID string, decision *MemoryDecision) error {
-	if !decision.ShouldSave || decision.Importance < 3 {
+	if !decision.ShouldSave || decision.Importance < minSaveImportance {
 		return nil // Not important enough
 	}
 
@@ -194,6 +351,7 @@ func (m *MemoryEvaluator) ApplyDecision(ctx context.Context, agentID, userID str
 				zap.String("fact_id", decision.ExistingID),
 				zap.String("user_id", userID),
 			)
+			m.notifyImportant(agentID, userID, decision.ExistingID, decision)
 			return nil
 		}
 	}
@@ -219,6 +377,7 @@ func (m *MemoryEvaluator) ApplyDecision(ctx context.Context, agentID, userID str
 				zap.String("old_content", mostRecent.Content),
 				zap.String("new_content", decision.Content),
 			)
+			m.notifyImportant(agentID, userID, mostRecent.ID, decision)
 			return nil
 		}
 	}
@@ -262,6 +421,7 @@ func (m *MemoryEvaluator) ApplyDecision(ctx context.Context, agentID, userID str
 		zap.Strings("topics", topics),
 		zap.String("reasoning", decision.Reasoning),
 	)
+	m.notifyImportant(agentID, userID, fact.ID, decision)
 
 	return nil
 }
@@ -270,10 +430,8 @@ func (m *MemoryEvaluator) ApplyDecision(ctx context.Context, agentID, userID str
 func (m *MemoryEvaluator) isNonMemoryMessage(message string) bool {
 	lower := strings.ToLower(strings.TrimSpace(message))
 	
-	// Very short messages
-	if len(lower) < 10 {
-		return true
-	}
+	// Length is checked by EvaluateMessage on the coalesced text, so short
+	// messages can still add up to something worth remembering
 
 	// Greetings
 	greetingPatterns := []string{
@@ -352,7 +510,7 @@ Only include facts where relationship is NOT "none" and confidence >= 0.7. Retur
 		content, 
 		formatFactsForLLM(userCtx.Facts))
 
-	response, err := m.llm.Generate(ctx, prompt, "Respond with JSON array only. No markdown, no explanation.", nil)
+	response, err := m.llm.Generate(ctx, adapter.GenerateParams{Auxiliary: true}, prompt, "Respond with JSON array only. No markdown, no explanation.", nil)
 	if err != nil {
 		m.logger.Warn("Failed to check for similar facts with LLM", zap.Error(err))
 		return nil, err
@@ -446,27 +604,18 @@ func (m *MemoryEvaluator) CleanupUserMemories(ctx context.Context, userID string
 	// Group facts by similarity using LLM
 	duplicateGroups := m.findDuplicateGroups(ctx, userCtx.Facts)
 	
-	// Process each group - keep the most recent, delete others
-	for _, group := range duplicateGroups {
-		if len(group) < 2 {
-			continue
-		}
-		
-		// Keep the first fact (should be most recent), delete the rest
-		keepID := group[0]
-		for i := 1; i < len(group); i++ {
-			if err := m.graphRepo.DeleteFact(ctx, group[i]); err != nil {
-				m.logger.Warn("Failed to delete duplicate fact",
-					zap.String("fact_id", group[i]),
-					zap.Error(err),
-				)
-			} else {
-				m.logger.Info("Deleted duplicate fact",
-					zap.String("fact_id", group[i]),
-					zap.String("kept_id", keepID),
-					zap.String("user_id", userID),
-				)
-			}
+	// Keep the most recent (or pinned) fact of each group, delete the others
+	for _, factID := range graph.FactsToPrune(duplicateGroups, userCtx.Facts) {
+		if err := m.graphRepo.DeleteFact(ctx, factID); err != nil {
+			m.logger.Warn("Failed to delete duplicate fact",
+				zap.String("fact_id", factID),
+				zap.Error(err),
+			)
+		} else {
+			m.logger.Info("Deleted duplicate fact",
+				zap.String("fact_id", factID),
+				zap.String("user_id", userID),
+			)
 		}
 	}
 
@@ -502,7 +651,7 @@ Guidelines:
 - Only create groups with 2+ facts
 - Return empty array if no duplicates/conflicts found`, strings.Join(factList, "\n"))
 
-	response, err := m.llm.Generate(ctx, prompt, "Respond with JSON array only. No markdown, no explanation.", nil)
+	response, err := m.llm.Generate(ctx, adapter.GenerateParams{Auxiliary: true}, prompt, "Respond with JSON array only. No markdown, no explanation.", nil)
 	if err != nil {
 		m.logger.Warn("Failed to analyze duplicates with LLM", zap.Error(err))
 		return nil
@@ -2,17 +2,25 @@ package agent
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/cache"
 	"ezra-clone/backend/internal/constants"
 	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/moderation"
 	"ezra-clone/backend/internal/tools"
+	"ezra-clone/backend/internal/utils"
 	apperrors "ezra-clone/backend/pkg/errors"
 	"ezra-clone/backend/pkg/logger"
+	"ezra-clone/backend/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -30,7 +38,15 @@ type Orchestrator struct {
 	toolExecutor      *tools.Executor
 	memoryEvaluator   *MemoryEvaluator
 	toolResultProc    *ToolResultProcessor
+	personaChecker    *PersonaChecker
+	contentFilter     *moderation.Filter // nil leaves replies unfiltered
 	logger            *zap.Logger
+	requireAgentModel bool                    // Fail turns for agents without a configured model instead of using the default
+	events            EventNotifier           // Receives error alerts; nil disables them
+	errorTracker      *utils.ErrorRateTracker // Counts failed turns per agent for error alerts
+	recursionStrategy RecursionStrategy       // Decides whether a turn takes another LLM round
+	promptBudget      PromptBudget            // Limits the system prompt's size; the zero value is unlimited
+	continuations     *continuationStore      // Replies cut off by the length limit, per channel
 }
 
 // NewOrchestrator creates a new agent orchestrator
@@ -42,7 +58,11 @@ func NewOrchestrator(graphRepo *graph.Repository, llm *adapter.LLMAdapter) *Orch
 		toolExecutor:    tools.NewExecutor(graphRepo),
 		memoryEvaluator: NewMemoryEvaluator(llm, graphRepo),
 		toolResultProc:  NewToolResultProcessor(log),
+		personaChecker:  NewPersonaChecker(llm, log),
 		logger:          log,
+		errorTracker:    utils.NewErrorRateTracker(DefaultErrorAlertThreshold, DefaultErrorAlertWindow),
+		recursionStrategy: DefaultRecursionStrategy,
+		continuations:     newContinuationStore(),
 	}
 }
 
@@ -56,6 +76,19 @@ func (o *Orchestrator) SetComfyExecutor(ce *tools.ComfyExecutor) {
 	o.toolExecutor.SetComfyExecutor(ce)
 }
 
+// SetRequireAgentModel controls what happens when an agent has no model
+// configured (or its config can't be loaded): false falls back to the
+// adapter's default model, true fails the turn.
+func (o *Orchestrator) SetRequireAgentModel(require bool) {
+	o.requireAgentModel = require
+}
+
+// SetMemoryEvaluationLimits sets the minimum message length worth
+// evaluating for memories and the per-user cooldown between evaluations
+func (o *Orchestrator) SetMemoryEvaluationLimits(minLength int, cooldown time.Duration) {
+	o.memoryEvaluator.SetLimits(minLength, cooldown)
+}
+
 // SetMusicExecutor sets the music executor for music playback tools
 func (o *Orchestrator) SetMusicExecutor(me *tools.MusicExecutor) {
 	o.toolExecutor.SetMusicExecutor(me)
@@ -71,25 +104,128 @@ func (o *Orchestrator) SetSystemExecutor(se *tools.SystemExecutor) {
 	o.toolExecutor.SetSystemExecutor(se)
 }
 
+// SetVoiceExecutor sets the voice executor for voice reference tools
+func (o *Orchestrator) SetVoiceExecutor(ve *tools.VoiceExecutor) {
+	o.toolExecutor.SetVoiceExecutor(ve)
+}
+
+// SetContentFilter sets the filter agents' replies go through when they
+// enable content_filter, for turns and for posts made outside them
+func (o *Orchestrator) SetContentFilter(f *moderation.Filter) {
+	o.contentFilter = f
+	o.toolExecutor.SetContentFilter(f)
+}
+
+// SetMessageScheduler sets the scheduler behind the scheduled message tools
+func (o *Orchestrator) SetMessageScheduler(s *tools.MessageScheduler) {
+	o.toolExecutor.SetMessageScheduler(s)
+}
+
 // SetLLMAdapterForTools sets the LLM adapter for tools that need it (like website summarization)
 func (o *Orchestrator) SetLLMAdapterForTools(llmAdapter *adapter.LLMAdapter) {
 	o.toolExecutor.SetLLMAdapter(llmAdapter)
 }
 
+// SetWebFetchLimits sets the fetch_webpage size cap and timeout on the tool executor
+func (o *Orchestrator) SetWebFetchLimits(maxBytes int64, timeout time.Duration) {
+	o.toolExecutor.SetWebFetchLimits(maxBytes, timeout)
+}
+
+// SetWebSearchFallback sets query relaxation and fallback providers for web_search
+func (o *Orchestrator) SetWebSearchFallback(relaxQueries bool, fallbackURLs []string) {
+	o.toolExecutor.SetWebSearchFallback(relaxQueries, fallbackURLs)
+}
+
+// SetWebCache sizes the web_search and fetch_webpage result caches
+func (o *Orchestrator) SetWebCache(maxEntries int, ttl time.Duration) {
+	o.toolExecutor.SetWebCache(maxEntries, ttl)
+}
+
+// Caches returns the tool executor's caches by name
+func (o *Orchestrator) Caches() map[string]cache.Clearable {
+	return o.toolExecutor.Caches()
+}
+
+// SetToolTimeouts bounds how long each tool may run. defaultTimeout applies
+// to tools without a built-in timeout; overrides are keyed by tool name.
+func (o *Orchestrator) SetToolTimeouts(defaultTimeout time.Duration, overrides map[string]time.Duration) {
+	o.toolResultProc.SetTimeouts(tools.NewToolTimeouts(defaultTimeout, overrides))
+}
+
+// SetArticleLimits sets how much of each fetched article is injected into
+// the final round and how long the extractive digest injected on earlier
+// rounds is. digestChars of 0 injects the full text every round.
+func (o *Orchestrator) SetArticleLimits(contentChars, digestChars int) {
+	o.toolResultProc.SetArticleLimits(contentChars, digestChars)
+}
+
+// SetMaxToolCallsPerTurn limits how many tool calls one turn may run across
+// all its rounds. Once the limit is reached, later rounds are offered no
+// tools. 0 removes the limit.
+func (o *Orchestrator) SetMaxToolCallsPerTurn(max int) {
+	o.toolResultProc.SetMaxToolCalls(max)
+}
+
+// SetRecursionStrategy replaces the decision of whether a turn takes another
+// LLM round after running tools. nil restores DefaultRecursionStrategy.
+func (o *Orchestrator) SetRecursionStrategy(strategy RecursionStrategy) {
+	if strategy == nil {
+		strategy = DefaultRecursionStrategy
+	}
+	o.recursionStrategy = strategy
+}
+
+// SetPromptBudget limits the system prompt to each model's context size
+// minus a reply reservation, trimming lower-priority context to fit. Models
+// without their own size are assumed to have budget.ContextTokens, which is
+// logged since a default smaller than the real window trims needlessly.
+func (o *Orchestrator) SetPromptBudget(budget PromptBudget) {
+	o.promptBudget = budget
+	if budget.ContextTokens > 0 {
+		models := make([]string, 0, len(budget.ModelContextTokens))
+		for model := range budget.ModelContextTokens {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+		o.logger.Info("Prompt budget assumes a default context size for unlisted models",
+			zap.Int("default_context_tokens", budget.ContextTokens),
+			zap.Strings("models_with_own_size", models),
+		)
+	}
+}
+
 // GetToolExecutor returns the tool executor (for background tasks)
 func (o *Orchestrator) GetToolExecutor() *tools.Executor {
 	return o.toolExecutor
 }
 
+// GetMemoryEvaluator returns the memory evaluator (for dry runs)
+func (o *Orchestrator) GetMemoryEvaluator() *MemoryEvaluator {
+	return o.memoryEvaluator
+}
+
 // TurnResult represents the result of a single agent turn
 type TurnResult struct {
-	Content   string
-	ToolCalls []adapter.ToolCall
-	Ignored   bool
-	Embeds    []Embed // Optional embeds for rich content
-	ImageData []byte  // Optional image data for Discord attachment
-	ImageName string  // Optional image filename for Discord attachment
-	ImageMeta map[string]interface{} // Optional image metadata (seed, dimensions, etc.)
+	Content         string
+	ToolCalls       []adapter.ToolCall
+	Ignored         bool
+	Depth           int                    // Deepest recursive round the turn ran, 0 when the first LLM call answered
+	MaxDepthReached bool                   // The turn was cut off with ErrMaxRecursion
+	Embeds          []Embed                // Optional embeds for rich content
+	ImageData       []byte                 // Optional image data for Discord attachment
+	ImageName       string                 // Optional image filename for Discord attachment
+	ImageMeta       map[string]interface{} // Optional image metadata (seed, dimensions, etc.)
+	Variations      []TurnImage            // Further images from a batch generation, after ImageData
+	Usage           adapter.TokenUsage     // Tokens used by the LLM calls made while the turn ran
+	Truncated       bool                   // The reply hit the model's length limit; "continue" or ContinueTurn resumes it
+	ContinuationID  string                 // Identifies the cut-off reply to ContinueTurn; empty when it can't be continued
+}
+
+// TurnImage is an image produced during a turn
+type TurnImage struct {
+	Data []byte
+	Name string
+	Meta map[string]interface{} // Seed, dimensions, etc.
 }
 
 // Embed represents a Discord-style embed
@@ -122,20 +258,236 @@ func (o *Orchestrator) RunTurnWithContext(ctx context.Context, agentID, userID,
 		ChannelID: channelID,
 		Platform:  platform,
 	}
-	return o.runTurnRecursive(ctx, execCtx, message, 0)
+	return o.RunTurnWithExecutionContext(ctx, execCtx, message)
+}
+
+// RunTurnWithExecutionContext executes a turn using a caller-built execution context.
+// If execCtx has no IdempotencyKey, one is derived from the channel, user, message and
+// turn start time so that recursive calls within the turn log the message only once.
+func (o *Orchestrator) RunTurnWithExecutionContext(ctx context.Context, execCtx *tools.ExecutionContext, message string) (*TurnResult, error) {
+	if execCtx.IdempotencyKey == "" {
+		execCtx.IdempotencyKey = deriveIdempotencyKey(execCtx, message, time.Now())
+	}
+	execCtx.ToolCallsMade = 0
+
+	ctx, span := tracing.Start(ctx, "agent.turn",
+		attribute.String("agent.id", execCtx.AgentID),
+		attribute.String("user.id", execCtx.UserID),
+		attribute.String("channel.id", execCtx.ChannelID),
+		attribute.String("platform", execCtx.Platform),
+	)
+	// The idempotency key doubles as the turn ID for LLM debug traces
+	ctx = adapter.WithTurn(ctx, execCtx.AgentID, execCtx.IdempotencyKey)
+	ctx, usage := adapter.WithUsageMeter(ctx)
+	var result *TurnResult
+	var err error
+	if c, ok := o.takeContinuation(execCtx, message); ok {
+		result, err = o.runContinuation(ctx, execCtx, message, c)
+	} else {
+		result, err = o.runTurnLoop(ctx, execCtx, message)
+	}
+	total := o.recordTurnUsage(execCtx, usage)
+	if result != nil {
+		result.Usage = total
+	}
+	turnErr := err
+	if err == ErrIgnored {
+		turnErr = nil // choosing not to reply isn't a failure
+	}
+	if turnErr != nil {
+		o.recordTurnError(execCtx.AgentID, turnErr)
+	}
+	tracing.End(span, turnErr)
+	return result, err
 }
 
-// runTurnRecursive executes a turn with recursion tracking
-func (o *Orchestrator) runTurnRecursive(ctx context.Context, execCtx *tools.ExecutionContext, message string, depth int) (*TurnResult, error) {
-	return o.runTurnRecursiveWithImage(ctx, execCtx, message, depth, nil, "", nil, nil)
+// recordTurnUsage logs a turn's token usage and adds it to the agent's
+// stored totals, returning the turn's total
+func (o *Orchestrator) recordTurnUsage(execCtx *tools.ExecutionContext, usage *adapter.UsageMeter) adapter.TokenUsage {
+	total, calls := usage.Total()
+	if calls == 0 {
+		return total
+	}
+	o.logger.Info("Turn token usage",
+		zap.String("agent_id", execCtx.AgentID),
+		zap.String("turn_id", execCtx.IdempotencyKey),
+		zap.Int("llm_calls", calls),
+		zap.Int("prompt_tokens", total.PromptTokens),
+		zap.Int("completion_tokens", total.CompletionTokens),
+		zap.Int("total_tokens", total.TotalTokens),
+	)
+
+	byModel := usage.ByModel()
+	records := make([]graph.ModelTokenUsage, 0, len(byModel))
+	for _, u := range byModel {
+		records = append(records, graph.ModelTokenUsage{
+			Model:            u.Model,
+			PromptTokens:     int64(u.PromptTokens),
+			CompletionTokens: int64(u.CompletionTokens),
+			Calls:            int64(u.Calls),
+		})
+	}
+	// The turn's context may already be cancelled, e.g. by a disconnected client
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := o.graphRepo.RecordTokenUsage(ctx, execCtx.AgentID, records, time.Now()); err != nil {
+		o.logger.Warn("Failed to record token usage", zap.String("agent_id", execCtx.AgentID), zap.Error(err))
+	}
+	return total
 }
 
-// runTurnRecursiveWithImage executes a turn with recursion tracking and preserves image data
-func (o *Orchestrator) runTurnRecursiveWithImage(ctx context.Context, execCtx *tools.ExecutionContext, message string, depth int, preservedImageData []byte, preservedImageName string, preservedImageMeta map[string]interface{}, preservedFetchedURLs []string) (*TurnResult, error) {
-	if depth >= constants.MaxRecursionDepth {
-		return nil, ErrMaxRecursion
+// RunTool executes a single tool call without consulting the LLM, for
+// explicit commands. The result is formatted like a turn's tool output.
+func (o *Orchestrator) RunTool(ctx context.Context, execCtx *tools.ExecutionContext, toolCall adapter.ToolCall) *TurnResult {
+	execCtx.ToolAccess = tools.AgentToolAccess(o.graphRepo.GetAgentConfig(ctx, execCtx.AgentID))
+	llmResponse := &adapter.Response{ToolCalls: []adapter.ToolCall{toolCall}}
+	toolResults, images, _, embeds, _ := o.toolResultProc.ProcessToolResults(
+		ctx,
+		llmResponse.ToolCalls,
+		execCtx,
+		o.toolExecutor,
+		llmResponse,
+		nil,
+		nil,
+	)
+	if llmResponse.Content == "" {
+		llmResponse.Content = toolOutputsText(toolResults)
 	}
+	return BuildTurnResult(llmResponse, embeds, images)
+}
+
+// deriveIdempotencyKey hashes the message identity into a stable key
+func deriveIdempotencyKey(execCtx *tools.ExecutionContext, message string, startedAt time.Time) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%s|%s|%d", execCtx.AgentID, execCtx.ChannelID, execCtx.UserID, message, startedAt.UnixNano())
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
 
+// turnMessageID returns the stored message ID for a role within the current turn.
+// Message and interaction IDs are global, so the idempotency key is hashed with the
+// agent, channel and user; the same client key sent by two users or to two agents
+// then stores two messages. Roles other than the user get a suffix.
+func turnMessageID(execCtx *tools.ExecutionContext, role string) string {
+	if execCtx.IdempotencyKey == "" {
+		return ""
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%s|%s", execCtx.AgentID, execCtx.ChannelID, execCtx.UserID, execCtx.IdempotencyKey)
+	id := hex.EncodeToString(h.Sum(nil))[:32]
+	if role == "user" {
+		return id
+	}
+	return id + ":" + role
+}
+
+// turnRound is the outcome of one LLM round within a turn
+type turnRound struct {
+	llmResponse       *adapter.Response
+	maxDepth          int
+	toolResults       []ToolOutput
+	embeds            []Embed
+	fetchWebpageCount int
+	images            []TurnImage
+	fetchedURLs       []string
+
+	// What the round asked the LLM, kept so the persona check can regenerate
+	params       adapter.GenerateParams
+	systemPrompt string
+	userMsg      string
+	persona      string // Persona the reply is checked against; empty when the agent hasn't enabled persona_check
+
+	contentFilter moderation.Settings // The agent's output filter
+}
+
+// runTurnLoop runs LLM rounds until the recursion strategy stops or the
+// agent's max depth is reached. Each round after the first sees the earlier
+// tool results appended to its message; images and fetched URLs carry over.
+func (o *Orchestrator) runTurnLoop(ctx context.Context, execCtx *tools.ExecutionContext, message string) (*TurnResult, error) {
+	userMessage := message
+	maxDepth := constants.MaxRecursionDepth
+	var previous *turnRound
+	var heldArticles []ToolOutput // Articles injected as digests, sent in full on the final pass
+
+	for depth := 0; ; depth++ {
+		if depth >= maxDepth {
+			o.logger.Warn("Agent turn hit max recursion depth",
+				zap.String("agent_id", execCtx.AgentID),
+				zap.Int("max_depth", maxDepth),
+			)
+			return &TurnResult{Depth: depth - 1, MaxDepthReached: true}, ErrMaxRecursion
+		}
+
+		round, err := o.runRound(ctx, execCtx, message, depth, previous)
+		if err != nil {
+			return nil, err
+		}
+		maxDepth = round.maxDepth
+		llmResponse := round.llmResponse
+
+		if len(llmResponse.ToolCalls) > 0 {
+			decision := o.recursionStrategy(RecursionState{
+				UserMessage:       userMessage,
+				Depth:             depth,
+				MaxDepth:          maxDepth,
+				Content:           llmResponse.Content,
+				ToolResults:       formatToolOutputs(round.toolResults),
+				FetchWebpageCount: round.fetchWebpageCount,
+				FetchedURLs:       round.fetchedURLs,
+			})
+			if decision.Continue {
+				o.logger.Debug("Recursing with tool context",
+					zap.Int("new_depth", depth+1),
+					zap.Int("tool_results", len(round.toolResults)),
+				)
+				var injected []ToolOutput
+				injected, heldArticles = toolContextOutputs(round.toolResults, heldArticles, decision)
+				message = buildToolContextMessage(message, formatToolOutputs(injected), round.fetchedURLs, decision)
+				previous = round
+				continue
+			}
+
+			// Default response if the LLM ran tools but never answered
+			if llmResponse.Content == "" {
+				// Use the tool results as the response
+				llmResponse.Content = toolOutputsText(round.toolResults)
+				if llmResponse.Content == "" {
+					llmResponse.Content = "I've completed the requested actions."
+				}
+			}
+		}
+
+		if round.persona != "" {
+			llmResponse.Content, _ = o.personaChecker.Enforce(ctx, round.params, round.systemPrompt, round.userMsg, round.persona, llmResponse.Content)
+		}
+
+		filtered := false
+		if round.contentFilter.Enabled() {
+			var content string
+			content, filtered = o.contentFilter.Apply(ctx, round.contentFilter, execCtx.AgentID, execCtx.ChannelID, llmResponse.Content)
+			llmResponse.Content = content
+			if filtered && content == "" {
+				// Blocked: the user's message is still logged, but nothing is sent
+				o.finishTurn(ctx, execCtx, message, llmResponse)
+				return &TurnResult{Depth: depth}, ErrIgnored
+			}
+		}
+
+		o.finishTurn(ctx, execCtx, message, llmResponse)
+
+		// Build result with any embeds
+		turnResult := BuildTurnResult(llmResponse, round.embeds, round.images)
+		turnResult.Depth = depth
+		if llmResponse.Truncated && !filtered {
+			turnResult.ContinuationID = o.rememberContinuation(execCtx, userMessage, llmResponse.Content, round)
+			turnResult.Truncated = true
+		}
+		return turnResult, nil
+	}
+}
+
+// runRound loads the agent's state, asks the LLM once and runs any tools it
+// calls. previous is the earlier round of the same turn, if any.
+func (o *Orchestrator) runRound(ctx context.Context, execCtx *tools.ExecutionContext, message string, depth int, previous *turnRound) (*turnRound, error) {
 	o.logger.Debug("Starting agent turn",
 		zap.String("agent_id", execCtx.AgentID),
 		zap.String("user_id", execCtx.UserID),
@@ -149,24 +501,30 @@ func (o *Orchestrator) runTurnRecursiveWithImage(ctx context.Context, execCtx *t
 	}
 
 	// 2. Get agent config to use the correct model
-	agentConfig, err := o.graphRepo.GetAgentConfig(ctx, execCtx.AgentID)
-	if err == nil && agentConfig.Model != "" {
-		// Temporarily set the model for this agent's turn
-		originalModel := o.llm.GetModel()
-		o.llm.SetModel(agentConfig.Model)
-		defer func() {
-			// Restore original model after the turn
-			o.llm.SetModel(originalModel)
-		}()
-	}
-
-	// 3. Get user context if available
+	agentConfig, configErr := o.graphRepo.GetAgentConfig(ctx, execCtx.AgentID)
+	params, err := o.turnParams(execCtx.AgentID, agentConfig, configErr)
+	if err != nil {
+		return nil, err
+	}
+
+	factLimit, historyLimit := resolvePromptLimits(agentConfig)
+	execCtx.ToolAccess = tools.AgentToolAccess(agentConfig, configErr)
+	if agentConfig != nil {
+		// Tools the LLM calls this round make their own calls with the agent's auxiliary model
+		ctx = adapter.WithAuxiliaryModel(ctx, tools.AgentAuxiliaryModel(agentConfig))
+	}
+
+	// 3. Get user context if available, keeping the facts most relevant to the user's message
 	userCtx, _ := o.graphRepo.GetUserContext(ctx, execCtx.UserID)
+	if userCtx != nil {
+		userMessage, _, _ := strings.Cut(message, toolResultsMarker)
+		userCtx.Facts = graph.SelectRelevantFacts(userCtx.Facts, userMessage, factLimit)
+	}
 
 	// 4. Get recent conversation history for context (if channel ID is available)
 	var conversationHistory []graph.Message
-	if execCtx.ChannelID != "" {
-		history, err := o.graphRepo.GetConversationHistory(ctx, execCtx.ChannelID, 15)
+	if execCtx.ChannelID != "" && historyLimit > 0 {
+		history, err := o.graphRepo.GetConversationHistory(ctx, execCtx.ChannelID, historyLimit)
 		if err == nil {
 			conversationHistory = history
 		} else {
@@ -175,13 +533,17 @@ func (o *Orchestrator) runTurnRecursiveWithImage(ctx context.Context, execCtx *t
 	}
 
 	// 5. Build System Prompt
-	systemPrompt, err := o.buildSystemPrompt(ctxWindow, userCtx, execCtx, conversationHistory)
+	systemInstructions := ""
+	if agentConfig != nil {
+		systemInstructions = agentConfig.SystemInstructions
+	}
+	systemPrompt, err := o.buildBudgetedSystemPrompt(ctxWindow, userCtx, execCtx, conversationHistory, systemInstructions, params.Model)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build system prompt: %w", err)
 	}
 
-	// 6. Get all tools, but filter out mimic_personality if already mimicking
-	allTools := tools.GetAllTools()
+	// 6. Get the agent's tools, but filter out mimic_personality if already mimicking
+	allTools := tools.FilterTools(tools.GetAllTools(), execCtx.ToolAccess)
 	
 	// If already mimicking, remove mimic_personality tool unless user explicitly wants to mimic someone
 	if o.toolExecutor.IsMimicking(execCtx.AgentID) {
@@ -206,200 +568,105 @@ func (o *Orchestrator) runTurnRecursiveWithImage(ctx context.Context, execCtx *t
 		}
 	}
 
+	// With the turn's tool calls used up, the LLM has to answer from what it has
+	if o.toolResultProc.toolCallLimitReached(execCtx) {
+		allTools = nil
+		o.logger.Debug("Tool-call limit reached, offering no tools",
+			zap.String("agent_id", execCtx.AgentID),
+			zap.Int("depth", depth),
+		)
+	}
+
 	// 7. Think - Call LLM
-	llmResponse, err := o.llm.Generate(ctx, systemPrompt, message, allTools)
+	params.ImageURLs = tools.ImageURLs(execCtx.Attachments)
+	userMsg := message + attachmentNote(execCtx.Attachments)
+	llmResponse, err := o.llm.Generate(ctx, params, systemPrompt, userMsg, allTools)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate LLM response: %w", err)
 	}
 
-	// 6. Act - Execute tool calls
-	var toolResults []string
-	var embeds []Embed
-	var fetchWebpageCount int
-	var imageData []byte
-	var imageName string
-	var imageMeta map[string]interface{}
-	var fetchedURLs []string
+	round := &turnRound{
+		llmResponse:  llmResponse,
+		maxDepth:     resolveMaxRecursionDepth(agentConfig),
+		params:       params,
+		systemPrompt: systemPrompt,
+		userMsg:      userMsg,
+	}
+	if agentConfig != nil && agentConfig.PersonaCheck {
+		round.persona = personaText(ctxWindow)
+	}
+	if agentConfig != nil {
+		round.contentFilter = moderation.Settings{
+			Mode:     agentConfig.ContentFilter,
+			Action:   agentConfig.ContentFilterAction,
+			Provider: agentConfig.ModerationProvider,
+			Model:    agentConfig.ModerationModel,
+		}
+	}
+	if previous != nil {
+		round.images, round.fetchedURLs = previous.images, previous.fetchedURLs
+	}
 
+	// 8. Act - Execute tool calls
 	if len(llmResponse.ToolCalls) > 0 {
-		toolResults, imageData, imageName, imageMeta, fetchedURLs, embeds, fetchWebpageCount = o.toolResultProc.ProcessToolResults(
+		round.toolResults, round.images, round.fetchedURLs, round.embeds, round.fetchWebpageCount = o.toolResultProc.ProcessToolResults(
 			ctx,
 			llmResponse.ToolCalls,
 			execCtx,
 			o.toolExecutor,
 			llmResponse,
-			preservedImageData,
-			preservedImageName,
-			preservedImageMeta,
-			preservedFetchedURLs,
+			round.images,
+			round.fetchedURLs,
 		)
-
-		// Check if user asked for multiple articles but we only fetched one
-		messageLower := strings.ToLower(message)
-		requestedMultipleArticles := strings.Contains(messageLower, "summarize") && 
-			(strings.Contains(messageLower, "article") || strings.Contains(messageLower, "result") || strings.Contains(messageLower, "first") || strings.Contains(messageLower, "most interesting"))
-		
-		numArticlesRequested := 2 // default
-		// Detect number of articles requested
-		if strings.Contains(messageLower, "first 2") || strings.Contains(messageLower, "2 articles") || strings.Contains(messageLower, "2 most") {
-			numArticlesRequested = 2
-		} else if strings.Contains(messageLower, "first 3") || strings.Contains(messageLower, "3 articles") || strings.Contains(messageLower, "3 most") {
-			numArticlesRequested = 3
-		} else if strings.Contains(messageLower, "first 4") || strings.Contains(messageLower, "4 articles") || strings.Contains(messageLower, "4 most") {
-			numArticlesRequested = 4
-		} else if strings.Contains(messageLower, "first") || strings.Contains(messageLower, "most interesting") {
-			numArticlesRequested = 2
-		}
-		
-		// If we have tool results but no content, and haven't hit max depth, recurse WITH tool context
-		shouldRecurse := llmResponse.Content == "" && depth < constants.MaxRecursionDepth-1 && len(toolResults) > 0
-		
-		// Also recurse if user asked for multiple articles but we haven't fetched enough yet
-		// BUT: if we have enough articles, STOP recursing and force summarization
-		if requestedMultipleArticles {
-			if fetchWebpageCount < numArticlesRequested && depth < constants.MaxRecursionDepth-1 {
-				// Need more articles - force recursion
-				shouldRecurse = true
-				// Add instruction about needing more articles
-				if len(toolResults) == 0 {
-					toolResults = append(toolResults, "[Status]: Need to fetch more articles")
-				}
-			} else if fetchWebpageCount >= numArticlesRequested {
-				// We have enough articles - STOP fetching, only recurse if we need to summarize
-				// Don't recurse if we already have content (LLM already responded)
-				if llmResponse.Content == "" {
-					// No content yet, recurse once more to get summary
-					shouldRecurse = true
-				} else {
-					// We have content, don't recurse
-					shouldRecurse = false
-				}
-			}
-		}
-		
-		if shouldRecurse {
-			// Include tool results in the next message so LLM knows what happened
-			// Add a summary of fetched URLs at the top for clarity
-			toolResultsWithSummary := make([]string, 0)
-			if len(fetchedURLs) > 0 {
-				toolResultsWithSummary = append(toolResultsWithSummary, fmt.Sprintf("[SUMMARY]: You have already fetched %d article(s):", len(fetchedURLs)))
-				for i, url := range fetchedURLs {
-					toolResultsWithSummary = append(toolResultsWithSummary, fmt.Sprintf("  Article %d: %s", i+1, url))
-				}
-				toolResultsWithSummary = append(toolResultsWithSummary, "")
-			}
-			toolResultsWithSummary = append(toolResultsWithSummary, toolResults...)
-			
-			contextMessage := fmt.Sprintf("%s\n\n[Tool Results]:\n%s\n\nNow provide a helpful response to the user based on these results.",
-				message, strings.Join(toolResultsWithSummary, "\n"))
-			
-			// If user asked to summarize articles, add explicit instruction
-			if requestedMultipleArticles {
-				if fetchWebpageCount < numArticlesRequested {
-					contextMessage += fmt.Sprintf("\n\nCRITICAL: You have only fetched %d article(s), but the user asked for %d articles. ", fetchWebpageCount, numArticlesRequested)
-					contextMessage += fmt.Sprintf("You MUST call fetch_webpage %d more time(s) to fetch DIFFERENT articles.\n", numArticlesRequested-fetchWebpageCount)
-					
-					// List already fetched URLs to prevent duplicates - make it VERY explicit
-					if len(fetchedURLs) > 0 {
-						contextMessage += fmt.Sprintf("\n🚫 ALREADY FETCHED URLs - DO NOT FETCH THESE AGAIN:\n")
-						for i, url := range fetchedURLs {
-							contextMessage += fmt.Sprintf("  %d. %s\n", i+1, url)
-						}
-						contextMessage += fmt.Sprintf("\n⚠️ WARNING: If you fetch any of these URLs again, you will waste tokens and not get new information!\n")
-					}
-					
-					contextMessage += fmt.Sprintf("\nCRITICAL INSTRUCTIONS - READ CAREFULLY:\n")
-					contextMessage += fmt.Sprintf("- The user asked to summarize %d ARTICLES\n", numArticlesRequested)
-					contextMessage += fmt.Sprintf("- You have already fetched %d article(s)\n", fetchWebpageCount)
-					contextMessage += fmt.Sprintf("- You need to fetch %d MORE DIFFERENT article(s)\n", numArticlesRequested-fetchWebpageCount)
-					contextMessage += fmt.Sprintf("- Use the ARTICLE URLs from the search results above (the URLs listed under 'ARTICLE 1', 'ARTICLE 2', etc.)\n")
-					contextMessage += fmt.Sprintf("- BEFORE calling fetch_webpage, check the 'ALREADY FETCHED URLs' list above\n")
-					contextMessage += fmt.Sprintf("- The URL you fetch MUST be DIFFERENT from all URLs in that list\n")
-					contextMessage += "- DO NOT fetch the search results page URL (html.duckduckgo.com)\n"
-					contextMessage += "- DO NOT fetch URLs that contain 'duckduckgo.com', 'search', or look like article list pages\n"
-					contextMessage += "- DO NOT fetch URLs ending in '/ai-news-december-2025', '/monthly-digest', '/in-depth-and-concise', or similar list/digest pages\n"
-					contextMessage += "- DO NOT fetch URLs that are article collections, digests, or roundups\n"
-					contextMessage += fmt.Sprintf("- You need to fetch ARTICLE %d URL (use fetch_webpage with that URL - make sure it's DIFFERENT from URLs already fetched)\n", fetchWebpageCount+1)
-					if numArticlesRequested > fetchWebpageCount+1 {
-						contextMessage += fmt.Sprintf("- Then fetch ARTICLE %d URL (use fetch_webpage again with that URL - also DIFFERENT)\n", fetchWebpageCount+2)
-					}
-				} else {
-					// We have enough articles - STRONGLY instruct to summarize and STOP fetching
-					contextMessage += fmt.Sprintf("\n\n✅ SUCCESS: You have fetched %d article(s) as requested. ", fetchWebpageCount)
-					contextMessage += fmt.Sprintf("You MUST NOW PROVIDE A SUMMARY - DO NOT FETCH ANY MORE ARTICLES.\n\n")
-					contextMessage += fmt.Sprintf("CRITICAL INSTRUCTIONS:\n")
-					contextMessage += fmt.Sprintf("1. You have all the article content you need in the tool results above\n")
-					contextMessage += fmt.Sprintf("2. DO NOT call fetch_webpage again - you already have %d articles\n", fetchWebpageCount)
-					contextMessage += fmt.Sprintf("3. DO NOT call web_search again\n")
-					contextMessage += fmt.Sprintf("4. You MUST provide a well-formatted summary that:\n")
-					contextMessage += fmt.Sprintf("   - Summarizes each of the %d articles you fetched\n", numArticlesRequested)
-					contextMessage += fmt.Sprintf("   - Highlights the key points from each article\n")
-					contextMessage += fmt.Sprintf("   - Formats the summary nicely with clear sections\n")
-					contextMessage += fmt.Sprintf("   - Uses the send_message tool to send the summary to the user\n")
-					contextMessage += fmt.Sprintf("5. Your response should be a complete, formatted summary - not just raw content\n")
-				}
-			}
-			o.logger.Debug("Recursing with tool context",
-				zap.Int("new_depth", depth+1),
-				zap.Int("tool_results", len(toolResults)),
-			)
-			// Preserve image data through recursive call
-			return o.runTurnRecursiveWithImage(ctx, execCtx, contextMessage, depth+1, imageData, imageName, imageMeta, fetchedURLs)
-		}
-
-		// Default response if we hit max depth without content
-		if llmResponse.Content == "" {
-			if len(toolResults) > 0 {
-				// Use the tool results as the response
-				llmResponse.Content = strings.Join(toolResults, "\n")
-			} else {
-				llmResponse.Content = "I've completed the requested actions."
-			}
-		}
 	}
 
-	// 7. Log Interaction
-	if err := o.graphRepo.LogInteraction(ctx, execCtx.AgentID, execCtx.UserID, message, time.Now()); err != nil {
+	return round, nil
+}
+
+// finishTurn logs the final exchange and queues the message for memory evaluation
+func (o *Orchestrator) finishTurn(ctx context.Context, execCtx *tools.ExecutionContext, message string, llmResponse *adapter.Response) {
+	// Log Interaction
+	if err := o.graphRepo.LogInteraction(ctx, execCtx.AgentID, execCtx.UserID, turnMessageID(execCtx, "user"), message, time.Now()); err != nil {
 		o.logger.Warn("Failed to log interaction", zap.Error(err))
 	}
 
-	// 8. Log message to conversation
+	// Log message to conversation (idempotent on the turn's message IDs)
 	if execCtx.ChannelID != "" {
-		_ = o.graphRepo.LogMessage(ctx, execCtx.AgentID, execCtx.UserID, execCtx.ChannelID, message, "user", execCtx.Platform)
+		_ = o.graphRepo.LogMessage(ctx, execCtx.AgentID, execCtx.UserID, execCtx.ChannelID, turnMessageID(execCtx, "user"), execCtx.PlatformMessageID, message, "user", execCtx.Platform)
+		if len(execCtx.Attachments) > 0 {
+			if err := o.graphRepo.SetMessageAttachments(ctx, turnMessageID(execCtx, "user"), attachmentURLs(execCtx.Attachments)); err != nil {
+				o.logger.Warn("Failed to record message attachments", zap.Error(err))
+			}
+		}
 		if llmResponse.Content != "" {
-			_ = o.graphRepo.LogMessage(ctx, execCtx.AgentID, execCtx.UserID, execCtx.ChannelID, llmResponse.Content, "agent", execCtx.Platform)
+			_ = o.graphRepo.LogMessage(ctx, execCtx.AgentID, execCtx.UserID, execCtx.ChannelID, turnMessageID(execCtx, "agent"), "", llmResponse.Content, "agent", execCtx.Platform)
 		}
 	}
 
-	// 9. Auto-evaluate and save memory (async, non-blocking)
+	// Auto-evaluate and save memory (async, non-blocking)
 	go func() {
 		evalCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		decision, err := o.memoryEvaluator.EvaluateMessage(evalCtx, execCtx.AgentID, execCtx.UserID, message)
-		if err != nil {
-			o.logger.Debug("Memory evaluation failed (non-critical)",
+		// Follow the user when they keep writing in another language
+		if lang, err := o.memoryEvaluator.EvaluateLanguage(evalCtx, execCtx.UserID, message); err != nil {
+			o.logger.Debug("Language evaluation failed (non-critical)",
 				zap.String("user_id", execCtx.UserID),
 				zap.Error(err),
 			)
-			return
-		}
-
-		if decision != nil && decision.ShouldSave {
-			if err := o.memoryEvaluator.ApplyDecision(evalCtx, execCtx.AgentID, execCtx.UserID, decision); err != nil {
-				o.logger.Warn("Failed to auto-save memory",
+		} else if lang != "" {
+			if err := o.graphRepo.SetUserLanguagePreference(evalCtx, execCtx.UserID, lang); err != nil {
+				o.logger.Warn("Failed to update preferred language",
 					zap.String("user_id", execCtx.UserID),
-					zap.String("memory_type", decision.MemoryType),
+					zap.String("language", lang),
 					zap.Error(err),
 				)
 			}
 		}
-	}()
 
-	// Build result with any embeds
-	turnResult := BuildTurnResult(llmResponse, embeds, imageData, imageName, imageMeta)
-
-	return turnResult, nil
+		// Rapid messages from the same user are evaluated together once the cooldown ends
+		o.memoryEvaluator.QueueMessage(execCtx.AgentID, execCtx.UserID, message)
+	}()
 }
 
 // smartChunkContent intelligently splits content into chunks at natural boundaries
@@ -470,3 +737,41 @@ func smartChunkContent(content string, maxChunkSize int) []string {
 // formatToolResponseWithEmbeds is defined in response_formatter.go
 // isInformationalTool is defined in response_formatter.go
 // formatTimestamp is defined in response_formatter.go
+
+// turnParams are the provider, model and reply limit of a turn's LLM calls,
+// from the agent's config
+func (o *Orchestrator) turnParams(agentID string, agentConfig *graph.AgentConfig, configErr error) (adapter.GenerateParams, error) {
+	model, err := o.resolveTurnModel(agentID, agentConfig, configErr)
+	if err != nil {
+		return adapter.GenerateParams{}, err
+	}
+	params := adapter.GenerateParams{Model: model}
+	if agentConfig != nil {
+		params.Provider = agentConfig.Provider
+		params.MaxTokens = agentConfig.MaxTokens
+	}
+	return params, nil
+}
+
+// resolveTurnModel picks the model for a turn from the agent's config. An
+// empty result means the adapter's default model. The model is passed to each
+// LLM call rather than set on the shared adapter.
+func (o *Orchestrator) resolveTurnModel(agentID string, agentConfig *graph.AgentConfig, configErr error) (string, error) {
+	if configErr == nil && agentConfig.Model != "" {
+		return agentConfig.Model, nil
+	}
+
+	if o.requireAgentModel {
+		if configErr != nil {
+			return "", fmt.Errorf("failed to load agent config for model selection: %w", configErr)
+		}
+		return "", fmt.Errorf("agent %s has no model configured", agentID)
+	}
+
+	o.logger.Debug("Agent has no model configured, using default",
+		zap.String("agent_id", agentID),
+		zap.String("model", o.llm.DefaultModel()),
+		zap.NamedError("config_error", configErr),
+	)
+	return "", nil
+}
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/state"
+
+	"go.uber.org/zap"
+)
+
+// Persona check severities
+const (
+	PersonaSeverityNone   = "none"
+	PersonaSeverityMinor  = "minor"
+	PersonaSeverityStrong = "strong"
+)
+
+// personaBlockName is the core memory block that holds persona rules
+const personaBlockName = "persona"
+
+const personaJudgePrompt = `You check whether an AI agent's reply is consistent with its persona.
+
+Compare the reply against the persona's traits and rules and respond with a JSON object:
+{"consistent": true|false, "severity": "none"|"minor"|"strong", "reason": "one sentence"}
+
+Use "strong" only when the reply clearly breaks a rule the persona states, claims a different identity, or takes on a voice or attitude the persona rules out. Small drifts in tone are "minor". A reply that fits is "none".`
+
+// replyGenerator makes a single LLM call; *adapter.LLMAdapter implements it
+type replyGenerator interface {
+	Generate(ctx context.Context, params adapter.GenerateParams, systemPrompt, userMsg string, tools []adapter.Tool) (*adapter.Response, error)
+}
+
+// PersonaVerdict is the outcome of checking a reply against the persona
+type PersonaVerdict struct {
+	Consistent bool   `json:"consistent"`
+	Severity   string `json:"severity"`
+	Reason     string `json:"reason"`
+}
+
+// PersonaChecker compares replies with the agent's persona after they are
+// generated and regenerates ones that strongly contradict it. Each check costs
+// an extra LLM call, so agents opt in with persona_check.
+type PersonaChecker struct {
+	llm    replyGenerator
+	logger *zap.Logger
+}
+
+// NewPersonaChecker creates a persona checker
+func NewPersonaChecker(llm replyGenerator, logger *zap.Logger) *PersonaChecker {
+	return &PersonaChecker{llm: llm, logger: logger}
+}
+
+// personaText collects the agent's personality and persona block, the text
+// replies are checked against
+func personaText(ctxWindow *state.ContextWindow) string {
+	var parts []string
+	if personality := strings.TrimSpace(ctxWindow.Identity.Personality); personality != "" {
+		parts = append(parts, personality)
+	}
+	for _, block := range ctxWindow.CoreMemory {
+		if strings.EqualFold(block.Name, personaBlockName) && strings.TrimSpace(block.Content) != "" {
+			parts = append(parts, strings.TrimSpace(block.Content))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Check asks the provider and model in params whether reply is consistent
+// with persona
+func (p *PersonaChecker) Check(ctx context.Context, params adapter.GenerateParams, persona, reply string) (PersonaVerdict, error) {
+	userMsg := fmt.Sprintf("Persona:\n%s\n\nReply:\n%s\n\nRespond with the JSON object only.", persona, reply)
+	judge := adapter.GenerateParams{Provider: params.Provider, Model: params.Model}
+	response, err := p.llm.Generate(ctx, judge, personaJudgePrompt, userMsg, nil)
+	if err != nil {
+		return PersonaVerdict{}, fmt.Errorf("failed to check persona: %w", err)
+	}
+
+	jsonStr := response.Content
+	if start := strings.Index(jsonStr, "{"); start != -1 {
+		if end := strings.LastIndex(jsonStr, "}"); end > start {
+			jsonStr = jsonStr[start : end+1]
+		}
+	}
+	var verdict PersonaVerdict
+	if err := json.Unmarshal([]byte(jsonStr), &verdict); err != nil {
+		return PersonaVerdict{}, fmt.Errorf("failed to parse persona verdict: %w", err)
+	}
+	verdict.Severity = strings.ToLower(strings.TrimSpace(verdict.Severity))
+	return verdict, nil
+}
+
+// Enforce checks reply against persona and, on a strong inconsistency,
+// regenerates it once with the check's reason added to the system prompt. It
+// returns the reply to send and whether it was regenerated. Tools aren't
+// offered to the regeneration, so none run twice, and a failed check or
+// regeneration keeps the original reply.
+func (p *PersonaChecker) Enforce(ctx context.Context, params adapter.GenerateParams, systemPrompt, userMsg, persona, reply string) (string, bool) {
+	if persona == "" || strings.TrimSpace(reply) == "" {
+		return reply, false
+	}
+
+	verdict, err := p.Check(ctx, params, persona, reply)
+	if err != nil {
+		p.logger.Warn("Persona check failed; keeping reply", zap.Error(err))
+		return reply, false
+	}
+	if verdict.Severity != PersonaSeverityStrong {
+		if !verdict.Consistent {
+			p.logger.Debug("Reply drifts from persona",
+				zap.String("severity", verdict.Severity),
+				zap.String("reason", verdict.Reason),
+			)
+		}
+		return reply, false
+	}
+
+	p.logger.Info("Reply contradicts persona; regenerating", zap.String("reason", verdict.Reason))
+	correction := fmt.Sprintf(`
+## Persona Correction
+
+Your previous draft reply contradicted your persona: %s
+Draft: %q
+
+Write the reply again, staying fully in line with your persona.
+`, verdict.Reason, reply)
+	response, err := p.llm.Generate(ctx, params, systemPrompt+correction, userMsg, nil)
+	if err != nil || strings.TrimSpace(response.Content) == "" {
+		p.logger.Warn("Persona regeneration failed; keeping reply", zap.Error(err))
+		return reply, false
+	}
+	return response.Content, true
+}
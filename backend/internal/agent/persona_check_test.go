@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ezra-clone/backend/internal/adapter"
+
+	"go.uber.org/zap"
+)
+
+// scriptedGenerator returns its replies in order and records the prompts
+type scriptedGenerator struct {
+	replies       []string
+	systemPrompts []string
+}
+
+func (g *scriptedGenerator) Generate(ctx context.Context, params adapter.GenerateParams, systemPrompt, userMsg string, tools []adapter.Tool) (*adapter.Response, error) {
+	g.systemPrompts = append(g.systemPrompts, systemPrompt)
+	reply := g.replies[0]
+	g.replies = g.replies[1:]
+	return &adapter.Response{Content: reply}, nil
+}
+
+const piratePersona = "You are a pirate. Always talk like a pirate and never use corporate jargon."
+
+func TestPersonaChecker_RegeneratesStrongViolation(t *testing.T) {
+	llm := &scriptedGenerator{replies: []string{
+		`{"consistent": false, "severity": "strong", "reason": "Uses corporate jargon instead of pirate speech"}`,
+		"Arr, we be settin' sail at dawn, matey!",
+	}}
+	checker := NewPersonaChecker(llm, zap.NewNop())
+
+	reply, regenerated := checker.Enforce(context.Background(), adapter.GenerateParams{Model: "test-model"},
+		"SYSTEM", "When do we leave?", piratePersona, "Let's circle back and leverage synergies at 9am.")
+
+	if !regenerated {
+		t.Fatal("Expected a reply violating the persona to be regenerated")
+	}
+	if reply != "Arr, we be settin' sail at dawn, matey!" {
+		t.Errorf("Expected the regenerated reply, got %q", reply)
+	}
+	if len(llm.systemPrompts) != 2 {
+		t.Fatalf("Expected a check and a regeneration, got %d calls", len(llm.systemPrompts))
+	}
+	regeneration := llm.systemPrompts[1]
+	if !strings.HasPrefix(regeneration, "SYSTEM") || !strings.Contains(regeneration, "Uses corporate jargon") {
+		t.Errorf("Expected the regeneration to keep the system prompt and explain the violation, got %q", regeneration)
+	}
+}
+
+func TestPersonaChecker_KeepsConsistentAndMinorReplies(t *testing.T) {
+	for _, verdict := range []string{
+		`{"consistent": true, "severity": "none", "reason": "Speaks like a pirate"}`,
+		"```json\n{\"consistent\": false, \"severity\": \"minor\", \"reason\": \"A bit formal\"}\n```",
+	} {
+		llm := &scriptedGenerator{replies: []string{verdict}}
+		checker := NewPersonaChecker(llm, zap.NewNop())
+
+		reply, regenerated := checker.Enforce(context.Background(), adapter.GenerateParams{},
+			"SYSTEM", "Hi", piratePersona, "Ahoy there!")
+		if regenerated || reply != "Ahoy there!" {
+			t.Errorf("Expected the reply to be kept for verdict %s, got %q", verdict, reply)
+		}
+		if len(llm.systemPrompts) != 1 {
+			t.Errorf("Expected only the check call, got %d", len(llm.systemPrompts))
+		}
+	}
+}
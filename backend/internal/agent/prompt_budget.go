@@ -0,0 +1,227 @@
+package agent
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/state"
+	"ezra-clone/backend/internal/tools"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// minTrimmedBlockChars is the shortest a memory block is truncated to
+	minTrimmedBlockChars = 200
+	// trimmedBlockMarker ends a memory block cut to fit the prompt budget
+	trimmedBlockMarker = " [truncated]"
+)
+
+// protectedMemoryBlocks are core memory blocks that are never truncated
+var protectedMemoryBlocks = map[string]bool{
+	"identity":     true,
+	"persona":      true,
+	"instructions": true,
+}
+
+// PromptBudget limits the size of the system prompt so it fits the model's
+// context window with room left for the reply
+type PromptBudget struct {
+	ContextTokens      int            // Context size of models without an override (0 disables the budget)
+	ModelContextTokens map[string]int // Per-model context sizes, keyed by model name
+	ReplyReserveTokens int            // Tokens kept free for the reply and tool definitions
+}
+
+// Limit returns the most tokens the system prompt may use with model, or 0
+// when it is unlimited
+func (b PromptBudget) Limit(model string) int {
+	contextTokens := b.ContextTokens
+	if tokens, ok := b.ModelContextTokens[model]; ok {
+		contextTokens = tokens
+	}
+	if contextTokens <= 0 {
+		return 0
+	}
+	limit := contextTokens - b.ReplyReserveTokens
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// estimateTokens approximates a text's token count at ~4 characters per token
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// promptTrim records what was removed from a prompt to fit its budget
+type promptTrim struct {
+	messages     int
+	archivalRefs int
+	blocks       []string
+}
+
+func (t promptTrim) empty() bool {
+	return t.messages == 0 && t.archivalRefs == 0 && len(t.blocks) == 0
+}
+
+// promptTrimmer removes lower-priority context from copies of a prompt's
+// context window and history. Token counts of the messages and archival refs
+// are estimated once up front, so each step drops as many as cover the excess
+// instead of one at a time.
+type promptTrimmer struct {
+	window        state.ContextWindow
+	history       []graph.Message
+	historyTokens []int // Estimated tokens of each history message's line
+	trim          promptTrim
+}
+
+// newPromptTrimmer copies the context so the caller's state is left alone
+func newPromptTrimmer(ctxWindow *state.ContextWindow, history []graph.Message) *promptTrimmer {
+	t := &promptTrimmer{
+		window:        *ctxWindow,
+		history:       append([]graph.Message(nil), history...),
+		historyTokens: make([]int, len(history)),
+	}
+	t.window.CoreMemory = append([]state.MemoryBlock(nil), ctxWindow.CoreMemory...)
+	t.window.ArchivalRefs = append([]state.ArchivalPointer(nil), ctxWindow.ArchivalRefs...)
+	for i, msg := range history {
+		t.historyTokens[i] = estimateTokens(historyLine(msg) + "\n")
+	}
+	return t
+}
+
+// step removes lower-priority context worth about excessTokens: the oldest
+// conversation messages, then the least relevant archival refs, then part of
+// the least recently updated unprotected memory block. It returns false once
+// nothing more can be trimmed; the identity and agent instructions are
+// always kept.
+func (t *promptTrimmer) step(excessTokens int) bool {
+	if len(t.history) > 0 {
+		drop, dropped := 0, 0
+		for drop < len(t.history) && (drop == 0 || dropped < excessTokens) {
+			dropped += t.historyTokens[drop]
+			drop++
+		}
+		t.history, t.historyTokens = t.history[drop:], t.historyTokens[drop:]
+		t.trim.messages += drop
+		return true
+	}
+
+	if refs := t.window.ArchivalRefs; len(refs) > 0 {
+		order := make([]int, len(refs))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return refs[order[a]].RelevanceScore < refs[order[b]].RelevanceScore
+		})
+		remove := make(map[int]bool)
+		dropped := 0
+		for _, i := range order {
+			if len(remove) > 0 && dropped >= excessTokens {
+				break
+			}
+			remove[i] = true
+			dropped += archivalRefTokens(refs[i])
+		}
+		kept := make([]state.ArchivalPointer, 0, len(refs)-len(remove))
+		for i, ref := range refs {
+			if !remove[i] {
+				kept = append(kept, ref)
+			}
+		}
+		t.window.ArchivalRefs = kept
+		t.trim.archivalRefs += len(remove)
+		return true
+	}
+
+	// Oldest blocks go first; they are the least likely to matter right now
+	candidates := make([]int, 0, len(t.window.CoreMemory))
+	for i, block := range t.window.CoreMemory {
+		if !protectedMemoryBlocks[strings.ToLower(block.Name)] && utf8.RuneCountInString(block.Content) > minTrimmedBlockChars+len(trimmedBlockMarker) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return false
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return t.window.CoreMemory[candidates[a]].UpdatedAt.Before(t.window.CoreMemory[candidates[b]].UpdatedAt)
+	})
+
+	block := &t.window.CoreMemory[candidates[0]]
+	content := []rune(strings.TrimSuffix(block.Content, trimmedBlockMarker))
+	keep := len(content) - excessTokens*4
+	if keep < minTrimmedBlockChars {
+		keep = minTrimmedBlockChars
+	}
+	block.Content = string(content[:keep]) + trimmedBlockMarker
+	if n := len(t.trim.blocks); n == 0 || t.trim.blocks[n-1] != block.Name {
+		t.trim.blocks = append(t.trim.blocks, block.Name)
+	}
+	return true
+}
+
+// archivalRefTokens estimates the tokens an archival ref adds to the
+// serialized context window
+func archivalRefTokens(ref state.ArchivalPointer) int {
+	encoded, err := json.MarshalIndent(ref, "    ", "  ")
+	if err != nil {
+		return estimateTokens(ref.Summary)
+	}
+	return estimateTokens(string(encoded))
+}
+
+// buildBudgetedSystemPrompt builds the system prompt, trimming lower-priority
+// context until it fits model's prompt budget. A prompt that still doesn't fit
+// once nothing more can be trimmed is sent as is.
+func (o *Orchestrator) buildBudgetedSystemPrompt(ctxWindow *state.ContextWindow, userCtx *graph.UserContext, execCtx *tools.ExecutionContext, conversationHistory []graph.Message, systemInstructions, model string) (string, error) {
+	prompt, err := o.buildSystemPrompt(ctxWindow, userCtx, execCtx, conversationHistory, systemInstructions)
+	if err != nil {
+		return "", err
+	}
+	tokens := estimateTokens(prompt)
+
+	limit := o.promptBudget.Limit(model)
+	if limit > 0 && tokens > limit {
+		trimmer := newPromptTrimmer(ctxWindow, conversationHistory)
+		for tokens > limit && trimmer.step(tokens-limit) {
+			prompt, err = o.buildSystemPrompt(&trimmer.window, userCtx, execCtx, trimmer.history, systemInstructions)
+			if err != nil {
+				return "", err
+			}
+			tokens = estimateTokens(prompt)
+		}
+
+		if trim := trimmer.trim; !trim.empty() {
+			o.logger.Info("Trimmed system prompt to fit the token budget",
+				zap.String("agent_id", execCtx.AgentID),
+				zap.String("model", model),
+				zap.Int("budget_tokens", limit),
+				zap.Int("dropped_messages", trim.messages),
+				zap.Int("dropped_archival_refs", trim.archivalRefs),
+				zap.Strings("truncated_blocks", trim.blocks),
+			)
+		}
+		if tokens > limit {
+			o.logger.Warn("System prompt is over the token budget after trimming",
+				zap.String("agent_id", execCtx.AgentID),
+				zap.String("model", model),
+				zap.Int("prompt_tokens", tokens),
+				zap.Int("budget_tokens", limit),
+			)
+		}
+	}
+
+	o.logger.Debug("Built system prompt",
+		zap.String("agent_id", execCtx.AgentID),
+		zap.String("model", model),
+		zap.Int("prompt_tokens", tokens),
+		zap.Int("budget_tokens", limit),
+	)
+	return prompt, nil
+}
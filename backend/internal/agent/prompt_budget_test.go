@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/state"
+	"ezra-clone/backend/internal/tools"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestPromptBudget_Limit(t *testing.T) {
+	budget := PromptBudget{
+		ContextTokens:      16384,
+		ModelContextTokens: map[string]int{"big-model": 200000, "tiny-model": 100},
+		ReplyReserveTokens: 4096,
+	}
+	assert.Equal(t, 16384-4096, budget.Limit("unlisted-model"))
+	assert.Equal(t, 200000-4096, budget.Limit("big-model"))
+	assert.Equal(t, 1, budget.Limit("tiny-model"), "a reserve larger than the context still leaves a budget")
+	assert.Equal(t, 0, PromptBudget{}.Limit("any-model"), "no context size means no budget")
+}
+
+// budgetHistory returns n messages of roughly 100 tokens each
+func budgetHistory(n int) []graph.Message {
+	history := make([]graph.Message, n)
+	for i := range history {
+		content := fmt.Sprintf("message %03d ", i)
+		history[i] = graph.Message{ID: fmt.Sprint(i), Role: "user", Content: content + strings.Repeat("x", 390-len(content))}
+	}
+	return history
+}
+
+func TestPromptTrimmer_DropsMessagesInBulk(t *testing.T) {
+	history := budgetHistory(10)
+	window := &state.ContextWindow{}
+	trimmer := newPromptTrimmer(window, history)
+
+	// About 350 tokens over: four messages of ~100 tokens go in one step
+	assert.True(t, trimmer.step(350))
+	assert.Equal(t, 4, trimmer.trim.messages)
+	assert.Equal(t, "4", trimmer.history[0].ID, "the oldest messages go first")
+	assert.Len(t, history, 10, "the caller's history is left alone")
+
+	// However little over, at least one message goes
+	assert.True(t, trimmer.step(1))
+	assert.Equal(t, 5, trimmer.trim.messages)
+}
+
+func TestPromptTrimmer_DropsLeastRelevantArchivalRefs(t *testing.T) {
+	window := &state.ContextWindow{ArchivalRefs: []state.ArchivalPointer{
+		{ID: "high", Summary: "launch plan", RelevanceScore: 0.9},
+		{ID: "low", Summary: "small talk", RelevanceScore: 0.1},
+		{ID: "mid", Summary: "deploy notes", RelevanceScore: 0.5},
+	}}
+	trimmer := newPromptTrimmer(window, nil)
+
+	assert.True(t, trimmer.step(1))
+	assert.Equal(t, 1, trimmer.trim.archivalRefs)
+	assert.Equal(t, []string{"high", "mid"}, []string{trimmer.window.ArchivalRefs[0].ID, trimmer.window.ArchivalRefs[1].ID})
+	assert.Len(t, window.ArchivalRefs, 3, "the caller's window is left alone")
+
+	assert.True(t, trimmer.step(1000))
+	assert.Empty(t, trimmer.window.ArchivalRefs)
+}
+
+func TestPromptTrimmer_TruncatesOldestUnprotectedBlock(t *testing.T) {
+	now := time.Now()
+	long := strings.Repeat("y", 2000)
+	window := &state.ContextWindow{CoreMemory: []state.MemoryBlock{
+		{Name: "persona", Content: long, UpdatedAt: now.Add(-48 * time.Hour)},
+		{Name: "notes", Content: long, UpdatedAt: now.Add(-24 * time.Hour)},
+		{Name: "projects", Content: long, UpdatedAt: now},
+	}}
+	trimmer := newPromptTrimmer(window, nil)
+
+	assert.True(t, trimmer.step(100))
+	assert.Equal(t, []string{"notes"}, trimmer.trim.blocks, "the oldest unprotected block is cut first")
+	assert.Equal(t, long, trimmer.window.CoreMemory[0].Content, "protected blocks are never cut")
+	assert.Equal(t, 2000-400+len(trimmedBlockMarker), len(trimmer.window.CoreMemory[1].Content))
+	assert.Equal(t, long, window.CoreMemory[1].Content, "the caller's blocks are left alone")
+
+	// Blocks cut down to the minimum can't be trimmed further
+	for trimmer.step(100000) {
+	}
+	assert.Equal(t, minTrimmedBlockChars+len(trimmedBlockMarker), len(trimmer.window.CoreMemory[1].Content))
+	assert.Equal(t, minTrimmedBlockChars+len(trimmedBlockMarker), len(trimmer.window.CoreMemory[2].Content))
+}
+
+func TestBuildBudgetedSystemPrompt_FitsTheBudget(t *testing.T) {
+	o := &Orchestrator{toolExecutor: tools.NewExecutor(nil), logger: zap.NewNop()}
+	window := &state.ContextWindow{Identity: state.AgentIdentity{Name: "Ezra", Personality: "Helpful"}}
+	execCtx := &tools.ExecutionContext{AgentID: "Ezra"}
+	history := budgetHistory(60)
+
+	unbudgeted, err := o.buildBudgetedSystemPrompt(window, nil, execCtx, history, "", "model")
+	assert.NoError(t, err)
+	limit := estimateTokens(unbudgeted) - 2000
+
+	o.promptBudget = PromptBudget{ModelContextTokens: map[string]int{"model": limit}}
+	prompt, err := o.buildBudgetedSystemPrompt(window, nil, execCtx, history, "", "model")
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, estimateTokens(prompt), limit)
+	assert.Contains(t, prompt, historyLine(history[59]), "the newest messages are kept")
+	assert.NotContains(t, prompt, historyLine(history[0]), "the oldest messages are dropped")
+	assert.Greater(t, estimateTokens(prompt), limit-300, "no more is dropped than needed")
+}
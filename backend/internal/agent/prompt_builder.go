@@ -11,10 +11,28 @@ import (
 	"ezra-clone/backend/internal/state"
 	"ezra-clone/backend/internal/tools"
 	"ezra-clone/backend/internal/utils"
+
+	"go.uber.org/zap"
 )
 
-// buildSystemPrompt creates a comprehensive system prompt with all context
-func (o *Orchestrator) buildSystemPrompt(ctxWindow *state.ContextWindow, userCtx *graph.UserContext, execCtx *tools.ExecutionContext, conversationHistory []graph.Message) (string, error) {
+// historyLine renders a message of the conversation history section
+func historyLine(msg graph.Message) string {
+	roleLabel := "User"
+	if msg.Role == "agent" {
+		roleLabel = "Assistant"
+	}
+	// Truncate very long messages to keep context manageable
+	content := msg.Content
+	if len(content) > 500 {
+		content = content[:500] + "..."
+	}
+	return fmt.Sprintf("- %s: %s%s", roleLabel, content, historyAttachmentNote(msg))
+}
+
+// buildSystemPrompt creates a comprehensive system prompt with all context.
+// systemInstructions is the agent's stored instruction template; it is
+// rendered here so templates stay unexpanded in the graph.
+func (o *Orchestrator) buildSystemPrompt(ctxWindow *state.ContextWindow, userCtx *graph.UserContext, execCtx *tools.ExecutionContext, conversationHistory []graph.Message, systemInstructions string) (string, error) {
 	// Serialize agent state
 	agentStateJSON, err := json.MarshalIndent(ctxWindow, "", "  ")
 	if err != nil {
@@ -127,6 +145,8 @@ You MUST respond in %s unless:
 - The user explicitly asks you to respond in a different language
 - The user says "don't speak %s", "speak english", or similar override requests
 
+This only sets the language you reply in. Keep code blocks, commands, URLs, usernames and other proper nouns exactly as written, and don't translate text you are quoting.
+
 This is a persistent preference that should be remembered for all future conversations with this user.
 `, langName, langCodeSuffix, langName, strings.ToLower(langName))
 		}
@@ -136,26 +156,10 @@ This is a persistent preference that should be remembered for all future convers
 	// Build conversation history section
 	conversationSection := ""
 	if len(conversationHistory) > 0 {
-		// Format the last few messages for context (show up to 10 most recent)
-		// Exclude the current message (it will be in the user message)
-		startIdx := 0
-		if len(conversationHistory) > 10 {
-			startIdx = len(conversationHistory) - 10
-		}
-		
+		// History is already limited to the agent's prompt history cap
 		var historyLines []string
-		for i := startIdx; i < len(conversationHistory); i++ {
-			msg := conversationHistory[i]
-			roleLabel := "User"
-			if msg.Role == "agent" {
-				roleLabel = "Assistant"
-			}
-			// Truncate very long messages to keep context manageable
-			content := msg.Content
-			if len(content) > 500 {
-				content = content[:500] + "..."
-			}
-			historyLines = append(historyLines, fmt.Sprintf("- %s: %s", roleLabel, content))
+		for _, msg := range conversationHistory {
+			historyLines = append(historyLines, historyLine(msg))
 		}
 		if len(historyLines) > 0 {
 			conversationSection = fmt.Sprintf(`
@@ -170,9 +174,29 @@ The following are recent messages in this conversation (in chronological order):
 	}
 
 	// Get current date for context
-	currentDate := time.Now().Format("Monday, January 2, 2006")
-	currentYear := time.Now().Year()
-	currentMonth := time.Now().Format("January")
+	now := time.Now()
+	currentDate := now.Format("Monday, January 2, 2006")
+	currentYear := now.Year()
+	currentMonth := now.Format("January")
+
+	// Render agent instructions. The personality fallback is already part of
+	// the core state, so only distinct instructions get their own section.
+	instructionsSection := ""
+	if systemInstructions != "" && systemInstructions != ctxWindow.Identity.Personality {
+		rendered, err := renderInstructions(systemInstructions, instructionVars(ctxWindow, userCtx, execCtx, now))
+		if err != nil {
+			// A bad template must never break a turn
+			o.logger.Warn("Using raw system instructions", zap.String("agent_id", execCtx.AgentID), zap.Error(err))
+			rendered = systemInstructions
+		}
+		instructionsSection = fmt.Sprintf(`
+## Agent Instructions
+%s
+`, rendered)
+	}
+
+	// Surface the agent's configured capabilities so it describes itself accurately
+	capabilitiesSection := tools.CapabilitiesPromptSection(ctxWindow.Identity.Capabilities) + tools.DisabledToolsPromptSection(execCtx.ToolAccess)
 
 	prompt := fmt.Sprintf(`# %s - AI Agent System
 
@@ -180,14 +204,14 @@ You are %s, an intelligent AI agent with persistent memory and the ability to le
 
 ## Current Date
 Today is %s. When searching for current events or news, use "%s %d" or similar date context in your queries.
-%s%s%s
+%s%s%s%s
 ## Your Core State
 %s
 %s
 ## Platform Information
 - Platform: %s
 - Channel ID: %s
-
+%s
 ## Your Capabilities
 
 You have access to a comprehensive set of tools:
@@ -203,16 +227,21 @@ You have access to a comprehensive set of tools:
 - **create_fact**: Store facts and link them to topics and users
 - **search_facts**: Search for facts about specific topics
 - **get_user_context**: Get comprehensive information about a user
+- **pin_fact**: Pin critical facts (allergies, names) so they are never cleaned up
+- **get_fact**, **update_fact**, **delete_fact**: Read, correct or delete a fact by ID (from memory_search or get_user_context) when a user says something you remember is wrong
 
 ### Topic Management
 - **create_topic**: Create topics to organize knowledge
 - **link_topics**: Create relationships between topics
 - **find_related_topics**: Find topics related to a given topic
 - **link_user_to_topic**: Record a user's interest in a topic
+- **compare_users**: Compare two users' shared and divergent interests
 
 ### Conversation Tools
 - **get_conversation_history**: Retrieve recent messages
 - **send_message**: Send a response to the user
+- **reset_conversation**: Clear this channel's history when the user asks to start over
+- **summarize_conversation**: Catch a user up on what was discussed in this channel, with key points and decisions
 
 ### Discord Tools (when on Discord)
 - **discord_read_history**: Read message history from a Discord channel
@@ -283,7 +312,7 @@ If you can make a reasonable guess about what they want, JUST DO IT.
 ## Response Format
 
 USE TOOLS FIRST. Then provide a direct, helpful response with the information you found.
-`, constants.DefaultAgentID, constants.DefaultAgentID, currentDate, currentMonth, currentYear, mimicSection, languageSection, conversationSection, string(agentStateJSON), userSection, execCtx.Platform, execCtx.ChannelID)
+`, constants.DefaultAgentID, constants.DefaultAgentID, currentDate, currentMonth, currentYear, instructionsSection, mimicSection, languageSection, conversationSection, string(agentStateJSON), userSection, execCtx.Platform, execCtx.ChannelID, capabilitiesSection)
 
 	return prompt, nil
 }
@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"ezra-clone/backend/internal/constants"
+	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/utils"
+)
+
+// toolResultsMarker separates the user's message from the tool results
+// appended to it for a recursive turn
+const toolResultsMarker = "\n\n[Tool Results]:"
+
+// RecursionState is what a recursion strategy sees after an LLM round that
+// called tools
+type RecursionState struct {
+	UserMessage       string   // The user's message, without injected tool results
+	Depth             int      // Depth of the round that just ran, starting at 0
+	MaxDepth          int      // Rounds allowed for this turn
+	Content           string   // Text the LLM returned this round
+	ToolResults       []string // Formatted results of this round's tools
+	FetchWebpageCount int      // fetch_webpage calls made this round
+	FetchedURLs       []string // URLs fetched during the turn
+}
+
+// RecursionDecision says whether a turn takes another LLM round
+type RecursionDecision struct {
+	Continue     bool
+	Status       string // Added to the tool results when there are none to show
+	Instructions string // Appended to the next round's message after the tool results
+	Intermediate bool   // More tools will run before the answer, so fetched articles are injected as digests
+}
+
+// RecursionStrategy decides whether a turn continues after a round that ran tools
+type RecursionStrategy func(state RecursionState) RecursionDecision
+
+// DefaultRecursionStrategy continues when tools ran but the LLM has not
+// answered yet, and keeps a multi-article summary going until the requested
+// number of articles has been fetched.
+func DefaultRecursionStrategy(state RecursionState) RecursionDecision {
+	canRecurse := state.Depth < state.MaxDepth-1
+
+	intent := utils.ExtractArticleIntent(state.UserMessage)
+	if intent.Action != utils.ArticleActionSummarize {
+		return RecursionDecision{Continue: state.Content == "" && canRecurse && len(state.ToolResults) > 0}
+	}
+
+	if state.FetchWebpageCount < intent.Count {
+		if !canRecurse {
+			return RecursionDecision{}
+		}
+		return RecursionDecision{
+			Continue:     true,
+			Intermediate: true,
+			Status:       "[Status]: Need to fetch more articles",
+			Instructions: fetchMoreArticlesInstructions(intent.Count, state.FetchWebpageCount, state.FetchedURLs),
+		}
+	}
+
+	// Enough articles: one more round to summarize, unless the LLM already answered
+	return RecursionDecision{
+		Continue:     state.Content == "",
+		Instructions: summarizeArticlesInstructions(intent.Count, state.FetchWebpageCount),
+	}
+}
+
+// resolveMaxRecursionDepth returns the agent's configured depth, or the default
+func resolveMaxRecursionDepth(agentConfig *graph.AgentConfig) int {
+	if agentConfig != nil && agentConfig.MaxRecursionDepth > 0 {
+		return agentConfig.MaxRecursionDepth
+	}
+	return constants.MaxRecursionDepth
+}
+
+// resolvePromptLimits returns how many facts and history messages the agent
+// injects into its prompt, falling back to the defaults. A history of 0 means
+// no inline history; the agent relies on its memory alone.
+func resolvePromptLimits(agentConfig *graph.AgentConfig) (facts, history int) {
+	facts, history = constants.DefaultPromptFactLimit, constants.DefaultPromptHistoryLimit
+	if agentConfig != nil && agentConfig.MaxPromptFacts > 0 {
+		facts = agentConfig.MaxPromptFacts
+	}
+	if agentConfig != nil && agentConfig.MaxPromptHistory != nil {
+		history = *agentConfig.MaxPromptHistory
+	}
+	return facts, history
+}
+
+// toolContextOutputs picks what a round's tool outputs inject into the next
+// round. Before the final pass, fetched articles are injected as digests and
+// held; on the final pass the held articles are injected again in full,
+// ahead of this round's outputs. Returns the outputs to inject and the
+// articles still held.
+func toolContextOutputs(outputs, held []ToolOutput, decision RecursionDecision) (injected, stillHeld []ToolOutput) {
+	if decision.Intermediate {
+		digested, shortened := digestToolOutputs(outputs)
+		return digested, append(held, shortened...)
+	}
+	injected = make([]ToolOutput, 0, len(held)+len(outputs))
+	injected = append(injected, held...)
+	return append(injected, outputs...), nil
+}
+
+// buildToolContextMessage appends the turn's tool results, and any strategy
+// instructions, to message so the next round knows what already happened
+func buildToolContextMessage(message string, toolResults, fetchedURLs []string, decision RecursionDecision) string {
+	if len(toolResults) == 0 && decision.Status != "" {
+		toolResults = []string{decision.Status}
+	}
+
+	// Add a summary of fetched URLs at the top for clarity
+	toolResultsWithSummary := make([]string, 0)
+	if len(fetchedURLs) > 0 {
+		toolResultsWithSummary = append(toolResultsWithSummary, fmt.Sprintf("[SUMMARY]: You have already fetched %d article(s):", len(fetchedURLs)))
+		for i, url := range fetchedURLs {
+			toolResultsWithSummary = append(toolResultsWithSummary, fmt.Sprintf("  Article %d: %s", i+1, url))
+		}
+		toolResultsWithSummary = append(toolResultsWithSummary, "")
+	}
+	toolResultsWithSummary = append(toolResultsWithSummary, toolResults...)
+
+	contextMessage := fmt.Sprintf("%s%s\n%s\n\nNow provide a helpful response to the user based on these results.",
+		message, toolResultsMarker, strings.Join(toolResultsWithSummary, "\n"))
+	if decision.Instructions != "" {
+		contextMessage += "\n\n" + decision.Instructions
+	}
+	return contextMessage
+}
+
+// fetchMoreArticlesInstructions tells the LLM to fetch the remaining articles
+// without repeating ones it already has
+func fetchMoreArticlesInstructions(requested, fetched int, fetchedURLs []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CRITICAL: You have only fetched %d article(s), but the user asked for %d articles. ", fetched, requested)
+	fmt.Fprintf(&b, "You MUST call fetch_webpage %d more time(s) to fetch DIFFERENT articles.\n", requested-fetched)
+
+	// List already fetched URLs to prevent duplicates - make it VERY explicit
+	if len(fetchedURLs) > 0 {
+		b.WriteString("\n🚫 ALREADY FETCHED URLs - DO NOT FETCH THESE AGAIN:\n")
+		for i, url := range fetchedURLs {
+			fmt.Fprintf(&b, "  %d. %s\n", i+1, url)
+		}
+		b.WriteString("\n⚠️ WARNING: If you fetch any of these URLs again, you will waste tokens and not get new information!\n")
+	}
+
+	b.WriteString("\nCRITICAL INSTRUCTIONS - READ CAREFULLY:\n")
+	fmt.Fprintf(&b, "- The user asked to summarize %d ARTICLES\n", requested)
+	fmt.Fprintf(&b, "- You have already fetched %d article(s)\n", fetched)
+	fmt.Fprintf(&b, "- You need to fetch %d MORE DIFFERENT article(s)\n", requested-fetched)
+	b.WriteString("- Use the ARTICLE URLs from the search results above (the URLs listed under 'ARTICLE 1', 'ARTICLE 2', etc.)\n")
+	b.WriteString("- BEFORE calling fetch_webpage, check the 'ALREADY FETCHED URLs' list above\n")
+	b.WriteString("- The URL you fetch MUST be DIFFERENT from all URLs in that list\n")
+	b.WriteString("- DO NOT fetch the search results page URL (html.duckduckgo.com)\n")
+	b.WriteString("- DO NOT fetch URLs that contain 'duckduckgo.com', 'search', or look like article list pages\n")
+	b.WriteString("- DO NOT fetch URLs ending in '/ai-news-december-2025', '/monthly-digest', '/in-depth-and-concise', or similar list/digest pages\n")
+	b.WriteString("- DO NOT fetch URLs that are article collections, digests, or roundups\n")
+	fmt.Fprintf(&b, "- You need to fetch ARTICLE %d URL (use fetch_webpage with that URL - make sure it's DIFFERENT from URLs already fetched)\n", fetched+1)
+	if requested > fetched+1 {
+		fmt.Fprintf(&b, "- Then fetch ARTICLE %d URL (use fetch_webpage again with that URL - also DIFFERENT)\n", fetched+2)
+	}
+	return b.String()
+}
+
+// summarizeArticlesInstructions tells the LLM to stop fetching and summarize
+func summarizeArticlesInstructions(requested, fetched int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "✅ SUCCESS: You have fetched %d article(s) as requested. ", fetched)
+	b.WriteString("You MUST NOW PROVIDE A SUMMARY - DO NOT FETCH ANY MORE ARTICLES.\n\n")
+	b.WriteString("CRITICAL INSTRUCTIONS:\n")
+	b.WriteString("1. You have all the article content you need in the tool results above\n")
+	fmt.Fprintf(&b, "2. DO NOT call fetch_webpage again - you already have %d articles\n", fetched)
+	b.WriteString("3. DO NOT call web_search again\n")
+	b.WriteString("4. You MUST provide a well-formatted summary that:\n")
+	fmt.Fprintf(&b, "   - Summarizes each of the %d articles you fetched\n", requested)
+	b.WriteString("   - Highlights the key points from each article\n")
+	b.WriteString("   - Formats the summary nicely with clear sections\n")
+	b.WriteString("   - Uses the send_message tool to send the summary to the user\n")
+	b.WriteString("5. Your response should be a complete, formatted summary - not just raw content\n")
+	return b.String()
+}
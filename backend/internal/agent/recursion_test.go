@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"ezra-clone/backend/internal/constants"
+	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/tools"
+
+	"go.uber.org/zap"
+)
+
+func TestToolContextOutputs_DigestsArticlesUntilFinalPass(t *testing.T) {
+	proc := NewToolResultProcessor(zap.NewNop())
+	proc.SetArticleLimits(5000, 200)
+
+	filler := strings.Repeat("Unrelated filler about the weather and the local sports results. ", 30)
+	article := "Rust 2.0 ships a new borrow checker. " + filler + "The borrow checker accepts more programs."
+	first := proc.successToolOutput(tools.ToolFetchWebpage, &tools.ToolResult{
+		Success: true,
+		Data:    map[string]interface{}{"url": "https://example.com/rust", "content": article},
+	}, 1)
+
+	// Still fetching: the next round sees the digest, and the article is held
+	fetching := RecursionDecision{Continue: true, Intermediate: true}
+	injected, held := toolContextOutputs([]ToolOutput{first}, nil, fetching)
+	message := buildToolContextMessage("summarize two articles", formatToolOutputs(injected), []string{"https://example.com/rust"}, fetching)
+
+	if strings.Contains(message, filler) {
+		t.Error("Expected the full article text not to be injected before the final pass")
+	}
+	if !strings.Contains(message, "Rust 2.0 ships a new borrow checker.") || !strings.Contains(message, "[extractive summary") {
+		t.Errorf("Expected the article's extractive summary to be injected, got %q", message)
+	}
+	if len(injected[0].Data) > 300 {
+		t.Errorf("Expected the injected digest to stay near the limit, got %d chars", len(injected[0].Data))
+	}
+	if len(held) != 1 {
+		t.Fatalf("Expected the digested article to be held, got %d", len(held))
+	}
+
+	// Final pass: held articles are injected in full
+	second := proc.successToolOutput(tools.ToolFetchWebpage, &tools.ToolResult{
+		Success: true,
+		Data:    map[string]interface{}{"url": "https://example.com/go", "content": "Go 1.30 is out."},
+	}, 1)
+	injected, held = toolContextOutputs([]ToolOutput{second}, held, RecursionDecision{Continue: true})
+	final := buildToolContextMessage(message, formatToolOutputs(injected), nil, RecursionDecision{Continue: true})
+
+	if !strings.Contains(final, article) || !strings.Contains(final, "Go 1.30 is out.") {
+		t.Error("Expected every article's full text on the final pass")
+	}
+	if len(held) != 0 {
+		t.Errorf("Expected nothing held after the final pass, got %d", len(held))
+	}
+}
+
+func TestResolvePromptLimits_HistoryWindow(t *testing.T) {
+	if _, history := resolvePromptLimits(nil); history != constants.DefaultPromptHistoryLimit {
+		t.Errorf("Expected the default history without a config, got %d", history)
+	}
+	if _, history := resolvePromptLimits(&graph.AgentConfig{}); history != constants.DefaultPromptHistoryLimit {
+		t.Errorf("Expected the default history when unset, got %d", history)
+	}
+
+	window := 3
+	if _, history := resolvePromptLimits(&graph.AgentConfig{MaxPromptHistory: &window}); history != 3 {
+		t.Errorf("Expected the configured history, got %d", history)
+	}
+	window = 0
+	if _, history := resolvePromptLimits(&graph.AgentConfig{MaxPromptHistory: &window}); history != 0 {
+		t.Errorf("Expected 0 to disable inline history, got %d", history)
+	}
+}
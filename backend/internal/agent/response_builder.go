@@ -4,22 +4,26 @@ import (
 	"ezra-clone/backend/internal/adapter"
 )
 
-// BuildTurnResult builds a TurnResult from LLM response and processed tool data
+// BuildTurnResult builds a TurnResult from LLM response and processed tool data.
+// The first image fills ImageData, ImageName and ImageMeta; the rest of a
+// batch become Variations.
 func BuildTurnResult(
 	llmResponse *adapter.Response,
 	embeds []Embed,
-	imageData []byte,
-	imageName string,
-	imageMeta map[string]interface{},
+	images []TurnImage,
 ) *TurnResult {
-	return &TurnResult{
+	result := &TurnResult{
 		Content:   llmResponse.Content,
 		ToolCalls: llmResponse.ToolCalls,
 		Ignored:   false,
 		Embeds:    embeds,
-		ImageData: imageData,
-		ImageName: imageName,
-		ImageMeta: imageMeta,
 	}
+	if len(images) > 0 {
+		result.ImageData = images[0].Data
+		result.ImageName = images[0].Name
+		result.ImageMeta = images[0].Meta
+		result.Variations = images[1:]
+	}
+	return result
 }
 
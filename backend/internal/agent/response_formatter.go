@@ -27,6 +27,7 @@ func isInformationalTool(toolName string) bool {
 		tools.ToolDiscordReadHistory: true,
 		tools.ToolAnalyzeUserStyle:   true,
 		tools.ToolSummarizeWebsite:  true,
+		tools.ToolSummarizeConversation: true,
 	}
 	return informationalTools[toolName]
 }
@@ -245,6 +246,14 @@ func formatToolResponseWithEmbeds(toolName string, result *tools.ToolResult) (st
 		}
 		return "I generated a summary but couldn't retrieve it.", nil
 
+	case tools.ToolSummarizeConversation:
+		if summaryData, ok := result.Data.(map[string]interface{}); ok {
+			if summary, ok := summaryData["summary"].(string); ok && summary != "" {
+				return summary, nil
+			}
+		}
+		return result.Message, nil
+
 	default:
 		return "", nil // Let LLM handle other tools
 	}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Tool output statuses
+const (
+	ToolStatusOK      = "ok"
+	ToolStatusError   = "error"
+	ToolStatusSkipped = "skipped" // Not run because the turn's tool-call limit was reached
+)
+
+// maxToolDataChars caps the structured data shown for one tool result
+const maxToolDataChars = 2000
+
+// ToolOutput is one tool's result in the envelope the LLM sees on the next
+// round, so every tool reads the same way regardless of what it returned
+type ToolOutput struct {
+	Tool    string
+	Status  string // ToolStatusOK, ToolStatusError or ToolStatusSkipped
+	Summary string // One line saying what happened, or the error
+	Data    string // Details the LLM may need, such as search results or article text
+	JSON    bool   // Data is the tool's raw data as JSON, shown to the LLM only
+	Digest  string // Extractive summary of Data, injected in its place before the final pass
+}
+
+// Format renders the output for the LLM:
+//
+//	[tool: web_search | status: ok]
+//	Summary: Found 5 search results
+//	Data:
+//	...
+func (t ToolOutput) Format() string {
+	var b strings.Builder
+	summary := t.Summary
+	if summary == "" {
+		summary = "Completed"
+	}
+	fmt.Fprintf(&b, "[tool: %s | status: %s]\nSummary: %s", t.Tool, t.Status, summary)
+	if t.Data != "" {
+		fmt.Fprintf(&b, "\nData:\n%s", t.Data)
+	}
+	return b.String()
+}
+
+// Text renders the output for a user, without the envelope or JSON data
+func (t ToolOutput) Text() string {
+	if t.Status == ToolStatusError {
+		return fmt.Sprintf("%s failed: %s", t.Tool, t.Summary)
+	}
+	if t.Status == ToolStatusSkipped {
+		return fmt.Sprintf("%s skipped: tool-call limit reached", t.Tool)
+	}
+	if t.Data == "" || t.JSON {
+		return t.Summary
+	}
+	return strings.TrimSpace(t.Summary + "\n" + t.Data)
+}
+
+// digestToolOutputs swaps in the digest for outputs that have one, for rounds
+// before the final pass. The outputs it shortened are returned too, so their
+// full text can be injected if a final pass comes; the LLM may answer before
+// then, so the digest doesn't promise it.
+func digestToolOutputs(outputs []ToolOutput) (digested, shortened []ToolOutput) {
+	digested = make([]ToolOutput, len(outputs))
+	for i, output := range outputs {
+		digested[i] = output
+		if output.Digest == "" {
+			continue
+		}
+		shortened = append(shortened, output)
+		digested[i].Data = output.Digest + "\n[extractive summary of the page, not its full text]"
+	}
+	return digested, shortened
+}
+
+// formatToolOutputs renders each output for the LLM
+func formatToolOutputs(outputs []ToolOutput) []string {
+	formatted := make([]string, len(outputs))
+	for i, output := range outputs {
+		formatted[i] = output.Format()
+	}
+	return formatted
+}
+
+// toolOutputsText renders the outputs as a reply to the user, for turns where
+// the LLM ran tools but never answered. Returns "" when no tool said anything.
+func toolOutputsText(outputs []ToolOutput) string {
+	var texts []string
+	for _, output := range outputs {
+		if text := output.Text(); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// renderToolData renders a tool's structured data as compact JSON, leaving
+// out binary values such as image bytes and capping the length. Returns ""
+// when there is nothing worth showing.
+func renderToolData(data interface{}) string {
+	if data == nil {
+		return ""
+	}
+	if dataMap, ok := data.(map[string]interface{}); ok {
+		filtered := make(map[string]interface{}, len(dataMap))
+		for key, value := range dataMap {
+			if _, binary := value.([]byte); !binary {
+				filtered[key] = value
+			}
+		}
+		data = filtered
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	rendered := string(encoded)
+	if rendered == "null" || rendered == "{}" || rendered == "[]" {
+		return ""
+	}
+	if len(rendered) > maxToolDataChars {
+		rendered = rendered[:maxToolDataChars] + "... [truncated]"
+	}
+	return rendered
+}
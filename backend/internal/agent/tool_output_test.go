@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"ezra-clone/backend/internal/tools"
+
+	"go.uber.org/zap"
+)
+
+func TestToolOutputs_UniformEnvelope(t *testing.T) {
+	proc := NewToolResultProcessor(zap.NewNop())
+	outputs := []ToolOutput{
+		proc.successToolOutput(tools.ToolWebSearch, &tools.ToolResult{
+			Success: true,
+			Message: "Found 1 result",
+			Data: map[string]interface{}{
+				"results": []tools.SearchResult{{Title: "Go 1.23 released", URL: "https://go.dev/blog/go1.23", Snippet: "Go 1.23 is out"}},
+			},
+		}, 0),
+		proc.successToolOutput(tools.ToolFetchWebpage, &tools.ToolResult{
+			Success: true,
+			Data:    map[string]interface{}{"url": "https://go.dev/blog/go1.23", "content": "Go 1.23 adds range-over-func."},
+		}, 1),
+		proc.successToolOutput(tools.ToolCreateFact, &tools.ToolResult{
+			Success: true,
+			Message: "Fact created",
+			Data:    map[string]interface{}{"fact_id": "f1", "raw": []byte{0x1}},
+		}, 1),
+		{Tool: tools.ToolWebSearch, Status: ToolStatusError, Summary: "search provider unavailable"},
+	}
+
+	formatted := formatToolOutputs(outputs)
+	for i, output := range outputs {
+		header := "[tool: " + output.Tool + " | status: " + output.Status + "]\nSummary: "
+		if !strings.HasPrefix(formatted[i], header) {
+			t.Errorf("Expected result %d to start with the envelope header %q, got %q", i, header, formatted[i])
+		}
+	}
+
+	if !strings.Contains(formatted[0], "Data:\n  ARTICLE 1: Go 1.23 released\n    URL: https://go.dev/blog/go1.23") {
+		t.Errorf("Expected search results listed under Data, got %q", formatted[0])
+	}
+	if !strings.Contains(formatted[1], "Summary: Fetched ARTICLE 1 from https://go.dev/blog/go1.23\nData:\nGo 1.23 adds range-over-func.") {
+		t.Errorf("Expected the article text under Data, got %q", formatted[1])
+	}
+	if !strings.HasSuffix(formatted[2], "Summary: Fact created\nData:\n{\"fact_id\":\"f1\"}") {
+		t.Errorf("Expected other tools' data as JSON without binary values, got %q", formatted[2])
+	}
+	if formatted[3] != "[tool: web_search | status: error]\nSummary: search provider unavailable" {
+		t.Errorf("Expected the error envelope, got %q", formatted[3])
+	}
+
+	// Users see the summaries without the envelope or JSON data
+	if text := toolOutputsText(outputs[2:]); text != "Fact created\nweb_search failed: search provider unavailable" {
+		t.Errorf("Unexpected user-facing text %q", text)
+	}
+}
@@ -7,61 +7,113 @@ import (
 
 	"ezra-clone/backend/internal/adapter"
 	"ezra-clone/backend/internal/tools"
+	"ezra-clone/backend/internal/utils"
 	"go.uber.org/zap"
 )
 
+// Default limits on fetched article text injected into the next round
+const (
+	defaultArticleContentChars = 5000
+	defaultArticleDigestChars  = 600
+)
+
 // ToolResultProcessor handles processing of tool execution results
 type ToolResultProcessor struct {
-	logger *zap.Logger
+	logger       *zap.Logger
+	timeouts     *tools.ToolTimeouts // Per-tool execution timeouts
+	articleChars int                 // Fetched article text kept for the final pass
+	digestChars  int                 // Length of the extractive digest injected before it; 0 always injects the full text
+	maxToolCalls int                 // Tool calls one turn may run across its rounds; 0 is unlimited
 }
 
 // NewToolResultProcessor creates a new tool result processor
 func NewToolResultProcessor(logger *zap.Logger) *ToolResultProcessor {
 	return &ToolResultProcessor{
-		logger: logger,
+		logger:       logger,
+		timeouts:     tools.NewToolTimeouts(0, nil),
+		articleChars: defaultArticleContentChars,
+		digestChars:  defaultArticleDigestChars,
+	}
+}
+
+// SetTimeouts sets how long each tool may run before its result is replaced
+// by a timeout error
+func (p *ToolResultProcessor) SetTimeouts(timeouts *tools.ToolTimeouts) {
+	p.timeouts = timeouts
+}
+
+// SetArticleLimits sets how much of each fetched article is kept and how long
+// the digest injected on rounds before the final pass is. digestChars of 0
+// injects the full text every round.
+func (p *ToolResultProcessor) SetArticleLimits(contentChars, digestChars int) {
+	if contentChars > 0 {
+		p.articleChars = contentChars
 	}
+	p.digestChars = digestChars
+}
+
+// SetMaxToolCalls limits how many tool calls one turn may run, counted across
+// its recursive rounds. Calls past the limit are skipped with a note for the
+// next round. 0 removes the limit.
+func (p *ToolResultProcessor) SetMaxToolCalls(max int) {
+	p.maxToolCalls = max
+}
+
+// toolCallLimitReached reports whether the turn has used up its tool calls
+func (p *ToolResultProcessor) toolCallLimitReached(execCtx *tools.ExecutionContext) bool {
+	return p.maxToolCalls > 0 && execCtx.ToolCallsMade >= p.maxToolCalls
 }
 
 // ProcessToolResults processes tool execution results and extracts relevant data
-// Returns: toolResults (for context), imageData, imageName, imageMeta, fetchedURLs, embeds
+// Returns: toolResults (for context), images, fetchedURLs, embeds
 func (p *ToolResultProcessor) ProcessToolResults(
 	ctx context.Context,
 	toolCalls []adapter.ToolCall,
 	execCtx *tools.ExecutionContext,
 	executor *tools.Executor,
 	llmResponse *adapter.Response,
-	preservedImageData []byte,
-	preservedImageName string,
-	preservedImageMeta map[string]interface{},
+	preservedImages []TurnImage,
 	preservedFetchedURLs []string,
 ) (
-	toolResults []string,
-	imageData []byte,
-	imageName string,
-	imageMeta map[string]interface{},
+	toolResults []ToolOutput,
+	images []TurnImage,
 	fetchedURLs []string,
 	embeds []Embed,
 	fetchWebpageCount int,
 ) {
 	// Start with preserved values
-	imageData = preservedImageData
-	imageName = preservedImageName
-	imageMeta = preservedImageMeta
-	if imageMeta == nil {
-		imageMeta = make(map[string]interface{})
-	}
+	images = preservedImages
 	fetchedURLs = preservedFetchedURLs
 	if fetchedURLs == nil {
 		fetchedURLs = make([]string, 0)
 	}
 
+	skipped := 0
 	for _, toolCall := range toolCalls {
+		if p.toolCallLimitReached(execCtx) {
+			skipped++
+			toolResults = append(toolResults, ToolOutput{
+				Tool:    toolCall.Name,
+				Status:  ToolStatusSkipped,
+				Summary: fmt.Sprintf("tool-call limit reached (%d per turn), so this call was not run. Answer with the results you already have.", p.maxToolCalls),
+			})
+			continue
+		}
+		execCtx.ToolCallsMade++
+
 		// Track fetch_webpage calls
 		if toolCall.Name == tools.ToolFetchWebpage {
 			fetchWebpageCount++
 		}
 
-		result := executor.Execute(ctx, execCtx, toolCall)
+		if execCtx.OnToolStart != nil {
+			execCtx.OnToolStart(toolCall.Name)
+		}
+
+		toolCall := toolCall
+		result := tools.ExecuteWithTimeout(ctx, toolCall.Name, p.timeouts.For(toolCall.Name), func(toolCtx context.Context) *tools.ToolResult {
+			return executor.Execute(toolCtx, execCtx, toolCall)
+		})
 
 		if result.Success {
 			p.logger.Info("Tool executed successfully",
@@ -69,146 +121,26 @@ func (p *ToolResultProcessor) ProcessToolResults(
 				zap.String("message", result.Message),
 			)
 
-			// Capture tool results for context
-			// Track fetch_webpage URLs to prevent duplicates and include content
-			if toolCall.Name == tools.ToolFetchWebpage && result.Data != nil {
+			// Track fetch_webpage URLs to prevent duplicates
+			if toolCall.Name == tools.ToolFetchWebpage {
 				if webpageData, ok := result.Data.(map[string]interface{}); ok {
-					url, _ := webpageData["url"].(string)
-					content, _ := webpageData["content"].(string)
-
-					if url != "" {
+					if url, _ := webpageData["url"].(string); url != "" {
 						fetchedURLs = append(fetchedURLs, url)
-
-						// Include article content in tool results for summarization
-						// Truncate to reasonable size (5000 chars per article) to avoid overwhelming the LLM
-						if content != "" {
-							// Truncate content to 5000 chars per article for summarization
-							maxContentLength := 5000
-							if len(content) > maxContentLength {
-								// Try to truncate at a sentence boundary
-								truncated := content[:maxContentLength]
-								if lastPeriod := strings.LastIndex(truncated, "."); lastPeriod > maxContentLength*3/4 {
-									content = truncated[:lastPeriod+1] + "... [content truncated for summarization]"
-								} else {
-									content = truncated + "... [content truncated for summarization]"
-								}
-							}
-							toolResults = append(toolResults, fmt.Sprintf("[ARTICLE %d from %s]:\n%s", fetchWebpageCount, url, content))
-						} else {
-							// No content, just URL
-							if result.Message != "" {
-								toolResults = append(toolResults, fmt.Sprintf("[%s] Fetched: %s - %s", toolCall.Name, url, result.Message))
-							} else {
-								toolResults = append(toolResults, fmt.Sprintf("[%s] Fetched: %s", toolCall.Name, url))
-							}
-						}
 					}
 				}
 			}
 
-			// For web_search, include the actual search results data so LLM can see URLs
-			if toolCall.Name == tools.ToolWebSearch && result.Data != nil {
-				if searchData, ok := result.Data.(map[string]interface{}); ok {
-					if resultsRaw, ok := searchData["results"]; ok {
-						if results, ok := resultsRaw.([]tools.SearchResult); ok && len(results) > 0 {
-							var resultLines []string
-							resultLines = append(resultLines, fmt.Sprintf("[%s]: Found %d search results (ARTICLE URLs to fetch):", toolCall.Name, len(results)))
-							for i, r := range results {
-								if i >= 5 {
-									break
-								}
-								// Make it very clear these are article URLs
-								resultLines = append(resultLines, fmt.Sprintf("  ARTICLE %d: %s", i+1, r.Title))
-								resultLines = append(resultLines, fmt.Sprintf("    URL: %s", r.URL))
-								if r.Snippet != "" {
-									// Include snippet but truncate if too long
-									snippet := r.Snippet
-									if len(snippet) > 200 {
-										snippet = snippet[:197] + "..."
-									}
-									resultLines = append(resultLines, fmt.Sprintf("    Preview: %s", snippet))
-								}
-							}
-							resultLines = append(resultLines, "IMPORTANT: These are ARTICLE URLs. Use fetch_webpage with these URLs to read the actual articles.")
-							toolResults = append(toolResults, strings.Join(resultLines, "\n"))
-						} else {
-							// Fallback to message if format is unexpected
-							if result.Message != "" {
-								toolResults = append(toolResults, fmt.Sprintf("[%s]: %s", toolCall.Name, result.Message))
-							}
-						}
-					} else {
-						// Fallback to message if no results
-						if result.Message != "" {
-							toolResults = append(toolResults, fmt.Sprintf("[%s]: %s", toolCall.Name, result.Message))
-						}
-					}
-				} else {
-					// Fallback to message if data format is unexpected
-					if result.Message != "" {
-						toolResults = append(toolResults, fmt.Sprintf("[%s]: %s", toolCall.Name, result.Message))
-					}
-				}
-			} else if toolCall.Name == tools.ToolSummarizeWebsite && result.Data != nil {
-				// Extract and include the summary in tool results
-				if summaryData, ok := result.Data.(map[string]interface{}); ok {
-					url, _ := summaryData["url"].(string)
-					summary, _ := summaryData["summary"].(string)
-					title, _ := summaryData["title"].(string)
-
-					if summary != "" {
-						var summaryLines []string
-						if title != "" {
-							summaryLines = append(summaryLines, fmt.Sprintf("[SUMMARY of %s]:", url))
-							summaryLines = append(summaryLines, fmt.Sprintf("Title: %s", title))
-						} else {
-							summaryLines = append(summaryLines, fmt.Sprintf("[SUMMARY of %s]:", url))
-						}
-						summaryLines = append(summaryLines, summary)
-						toolResults = append(toolResults, strings.Join(summaryLines, "\n"))
-					} else if result.Message != "" {
-						toolResults = append(toolResults, fmt.Sprintf("[%s]: %s", toolCall.Name, result.Message))
-					}
-				} else if result.Message != "" {
-					toolResults = append(toolResults, fmt.Sprintf("[%s]: %s", toolCall.Name, result.Message))
-				}
-			} else if toolCall.Name != tools.ToolFetchWebpage && toolCall.Name != tools.ToolSummarizeWebsite && result.Message != "" {
-				// Don't add fetch_webpage or summarize_website results here - we handle them above
-				toolResults = append(toolResults, fmt.Sprintf("[%s]: %s", toolCall.Name, result.Message))
-			}
+			// Capture tool results for context
+			toolResults = append(toolResults, p.successToolOutput(toolCall.Name, result, fetchWebpageCount))
 
 			// Check for image data from image generation tool
 			if toolCall.Name == tools.ToolGenerateImageWithRunPod && result.Data != nil {
 				if dataMap, ok := result.Data.(map[string]interface{}); ok {
-					if imgData, ok := dataMap["image_data"].([]byte); ok && len(imgData) > 0 {
-						imageData = imgData
-						if format, ok := dataMap["image_format"].(string); ok {
-							imageName = fmt.Sprintf("image.%s", format)
-						} else {
-							imageName = "image.png"
-						}
-
-						// Extract metadata for embed
-						imageMeta = make(map[string]interface{})
-						if seed, ok := dataMap["seed"]; ok {
-							imageMeta["seed"] = seed
-						}
-						if width, ok := dataMap["width"]; ok {
-							imageMeta["width"] = width
-						}
-						if height, ok := dataMap["height"]; ok {
-							imageMeta["height"] = height
-						}
-						if workflow, ok := dataMap["workflow"]; ok {
-							imageMeta["workflow"] = workflow
-						}
-						if elapsed, ok := dataMap["elapsed_seconds"]; ok {
-							imageMeta["elapsed_seconds"] = elapsed
-						}
-
+					if generated := generatedImages(dataMap); len(generated) > 0 {
+						images = generated
 						p.logger.Debug("Captured image data from tool result",
-							zap.Int("image_size", len(imageData)),
-							zap.String("image_name", imageName),
+							zap.Int("images", len(images)),
+							zap.Int("image_size", len(images[0].Data)),
 						)
 					}
 				}
@@ -253,10 +185,148 @@ func (p *ToolResultProcessor) ProcessToolResults(
 				zap.String("tool", toolCall.Name),
 				zap.String("error", result.Error),
 			)
-			toolResults = append(toolResults, fmt.Sprintf("[%s] ERROR: %s", toolCall.Name, result.Error))
+			toolResults = append(toolResults, ToolOutput{Tool: toolCall.Name, Status: ToolStatusError, Summary: result.Error})
 		}
 	}
 
-	return toolResults, imageData, imageName, imageMeta, fetchedURLs, embeds, fetchWebpageCount
+	if skipped > 0 {
+		p.logger.Warn("Tool-call limit reached, skipping calls",
+			zap.String("agent_id", execCtx.AgentID),
+			zap.Int("max_tool_calls", p.maxToolCalls),
+			zap.Int("skipped", skipped),
+		)
+	}
+
+	return toolResults, images, fetchedURLs, embeds, fetchWebpageCount
+}
+
+// successToolOutput builds the envelope for a tool that succeeded. Article
+// text, search results and summaries are laid out for reading; other tools
+// show their data as JSON. articleNumber is the count of fetch_webpage calls
+// so far this round.
+func (p *ToolResultProcessor) successToolOutput(toolName string, result *tools.ToolResult, articleNumber int) ToolOutput {
+	output := ToolOutput{Tool: toolName, Status: ToolStatusOK, Summary: result.Message}
+	dataMap, _ := result.Data.(map[string]interface{})
+
+	switch toolName {
+	case tools.ToolFetchWebpage:
+		url, _ := dataMap["url"].(string)
+		content, _ := dataMap["content"].(string)
+		if url != "" {
+			output.Summary = fmt.Sprintf("Fetched ARTICLE %d from %s", articleNumber, url)
+		}
+		if content != "" {
+			output.Data = truncateArticle(content, p.articleChars)
+			if p.digestChars > 0 && len(output.Data) > p.digestChars {
+				output.Digest = utils.ExtractiveSummary(content, p.digestChars)
+			}
+		}
+	case tools.ToolWebSearch:
+		results, _ := dataMap["results"].([]tools.SearchResult)
+		if len(results) > 0 {
+			output.Summary = fmt.Sprintf("Found %d search results (ARTICLE URLs to fetch)", len(results))
+			output.Data = formatSearchResults(results)
+		}
+	case tools.ToolSummarizeWebsite:
+		url, _ := dataMap["url"].(string)
+		summary, _ := dataMap["summary"].(string)
+		title, _ := dataMap["title"].(string)
+		if summary != "" {
+			output.Summary = fmt.Sprintf("Summary of %s", url)
+			if title != "" {
+				summary = fmt.Sprintf("Title: %s\n%s", title, summary)
+			}
+			output.Data = summary
+		}
+	case tools.ToolSummarizeConversation:
+		if summary, _ := dataMap["summary"].(string); summary != "" {
+			output.Data = summary
+		}
+	default:
+		output.Data = renderToolData(result.Data)
+		output.JSON = true
+	}
+	return output
+}
+
+// truncateArticle keeps article text to a size the LLM can summarize,
+// cutting at a sentence boundary when one is close to the limit
+func truncateArticle(content string, maxContentLength int) string {
+	if len(content) <= maxContentLength {
+		return content
+	}
+	truncated := content[:maxContentLength]
+	if lastPeriod := strings.LastIndex(truncated, "."); lastPeriod > maxContentLength*3/4 {
+		truncated = truncated[:lastPeriod+1]
+	}
+	return truncated + "... [content truncated for summarization]"
 }
 
+// formatSearchResults lists the top search results so the LLM can see which
+// URLs to fetch
+func formatSearchResults(results []tools.SearchResult) string {
+	var lines []string
+	for i, r := range results {
+		if i >= 5 {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  ARTICLE %d: %s", i+1, r.Title))
+		lines = append(lines, fmt.Sprintf("    URL: %s", r.URL))
+		if r.Snippet != "" {
+			snippet := r.Snippet
+			if len(snippet) > 200 {
+				snippet = snippet[:197] + "..."
+			}
+			lines = append(lines, fmt.Sprintf("    Preview: %s", snippet))
+		}
+	}
+	lines = append(lines, "IMPORTANT: These are ARTICLE URLs. Use fetch_webpage with these URLs to read the actual articles.")
+	return strings.Join(lines, "\n")
+}
+
+// generatedImages collects the images from an image generation result: the
+// first at the top level and any further batch variations under "variations",
+// each with the shared metadata and its own seed
+func generatedImages(dataMap map[string]interface{}) []TurnImage {
+	imgData, ok := dataMap["image_data"].([]byte)
+	if !ok || len(imgData) == 0 {
+		return nil
+	}
+	format, ok := dataMap["image_format"].(string)
+	if !ok {
+		format = "png"
+	}
+
+	// Extract metadata for embed
+	meta := make(map[string]interface{})
+	for _, key := range []string{"seed", "width", "height", "workflow", "elapsed_seconds", "batch"} {
+		if value, ok := dataMap[key]; ok {
+			meta[key] = value
+		}
+	}
+	for _, key := range []string{"style_preset", "positive_prompt", "negative_prompt"} {
+		if value, ok := dataMap[key].(string); ok && value != "" {
+			meta[key] = value
+		}
+	}
+
+	images := []TurnImage{{Data: imgData, Name: fmt.Sprintf("image.%s", format), Meta: meta}}
+	variations, _ := dataMap["variations"].([]map[string]interface{})
+	for _, variation := range variations {
+		data, ok := variation["image_data"].([]byte)
+		if !ok || len(data) == 0 {
+			continue
+		}
+		variationMeta := make(map[string]interface{}, len(meta))
+		for key, value := range meta {
+			variationMeta[key] = value
+		}
+		variationMeta["seed"] = variation["seed"]
+		images = append(images, TurnImage{
+			Data: data,
+			Name: fmt.Sprintf("image-%d.%s", len(images)+1, format),
+			Meta: variationMeta,
+		})
+	}
+	return images
+}
@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/tools"
+
+	"go.uber.org/zap"
+)
+
+func TestProcessToolResults_LimitsToolCallsPerTurn(t *testing.T) {
+	proc := NewToolResultProcessor(zap.NewNop())
+	proc.SetMaxToolCalls(2)
+
+	// The tool is disabled, so calls are refused without touching the network
+	executor := tools.NewExecutor(nil)
+	execCtx := &tools.ExecutionContext{
+		AgentID:    "Ezra",
+		ToolAccess: tools.ToolAccess{Denied: []string{tools.ToolFetchWebpage}},
+	}
+	calls := []adapter.ToolCall{
+		{Name: tools.ToolFetchWebpage},
+		{Name: tools.ToolFetchWebpage},
+		{Name: tools.ToolFetchWebpage},
+	}
+
+	outputs, _, _, _, _ := proc.ProcessToolResults(context.Background(), calls, execCtx, executor, &adapter.Response{}, nil, nil)
+	if len(outputs) != 3 || outputs[1].Status != ToolStatusError || outputs[2].Status != ToolStatusSkipped {
+		t.Fatalf("Expected two calls run and the third skipped, got %+v", outputs)
+	}
+	if execCtx.ToolCallsMade != 2 || !proc.toolCallLimitReached(execCtx) {
+		t.Errorf("Expected the turn's limit to be used up, got %d calls", execCtx.ToolCallsMade)
+	}
+
+	// A later round of the same turn can't run more
+	outputs, _, _, _, _ = proc.ProcessToolResults(context.Background(), calls[:1], execCtx, executor, &adapter.Response{}, nil, nil)
+	if len(outputs) != 1 || outputs[0].Status != ToolStatusSkipped {
+		t.Errorf("Expected calls in later rounds to be skipped, got %+v", outputs)
+	}
+}
@@ -0,0 +1,139 @@
+// Package cache provides the bounded in-memory caches used for tool results
+// and image captions, and the stats operators see for every cache
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Stats describes a cache's contents and effectiveness
+type Stats struct {
+	Entries int     `json:"entries"`
+	Bytes   int64   `json:"bytes,omitempty"` // Only for caches that track their size
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // Hits over lookups, 0 before the first lookup
+}
+
+// Clearable is a cache operators can inspect and clear
+type Clearable interface {
+	Stats() Stats
+	Clear() error
+}
+
+// HitRate returns hits over lookups, or 0 when there were none
+func HitRate(hits, misses uint64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// entry is a cached value and when it expires
+type entry[V any] struct {
+	value   V
+	expires time.Time // Zero when the entry never expires
+}
+
+// TTL is a concurrency-safe cache that holds at most maxEntries values, each
+// for ttl. When full, expired entries are dropped first, then the entry
+// closest to expiring, or an arbitrary one when entries don't expire.
+type TTL[V any] struct {
+	mu         sync.Mutex
+	entries    map[string]entry[V]
+	maxEntries int
+	ttl        time.Duration // 0 keeps entries until evicted
+	hits       uint64
+	misses     uint64
+	now        func() time.Time
+}
+
+// NewTTL creates a cache. maxEntries of 0 or less disables it: Put stores
+// nothing and every Get misses.
+func NewTTL[V any](maxEntries int, ttl time.Duration) *TTL[V] {
+	return &TTL[V]{
+		entries:    make(map[string]entry[V]),
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+	}
+}
+
+// Get returns the value cached under key, if it hasn't expired
+func (c *TTL[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if ok && !e.expires.IsZero() && !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		var zero V
+		return zero, false
+	}
+	c.hits++
+	return e.value, true
+}
+
+// Put caches value under key, evicting an entry if the cache is full
+func (c *TTL[V]) Put(key string, value V) {
+	if c.maxEntries <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	e := entry[V]{value: value}
+	if c.ttl > 0 {
+		e.expires = now.Add(c.ttl)
+	}
+	c.entries[key] = e
+}
+
+// evict drops expired entries, or the one closest to expiring when none
+// have. Callers must hold c.mu.
+func (c *TTL[V]) evict(now time.Time) {
+	oldestKey := ""
+	var oldest time.Time
+	for key, e := range c.entries {
+		if !e.expires.IsZero() && !now.Before(e.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || e.expires.Before(oldest) {
+			oldestKey, oldest = key, e.expires
+		}
+	}
+	if len(c.entries) >= c.maxEntries && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// Stats reports the entry count and hit rate since the last Clear
+func (c *TTL[V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Entries: len(c.entries),
+		Hits:    c.hits,
+		Misses:  c.misses,
+		HitRate: HitRate(c.hits, c.misses),
+	}
+}
+
+// Clear removes every entry and resets the hit counters
+func (c *TTL[V]) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]entry[V])
+	c.hits, c.misses = 0, 0
+	return nil
+}
@@ -4,12 +4,16 @@ package constants
 const (
 	// DefaultAgentID is the default agent identifier
 	DefaultAgentID = "Ezra"
+	// DefaultAgentAlias names the configured default agent in API paths
+	DefaultAgentAlias = "default"
 )
 
 // Discord constants
 const (
 	// DiscordMaxMessageLength is the maximum character limit for Discord messages
 	DiscordMaxMessageLength = 2000
+	// DiscordMaxEmbedsPerMessage is the most embeds one Discord message can carry
+	DiscordMaxEmbedsPerMessage = 10
 )
 
 // Agent execution constants
@@ -17,6 +21,34 @@ const (
 	// MaxRecursionDepth is the maximum depth for recursive agent turns
 	// This prevents infinite loops when tools trigger additional tool calls
 	MaxRecursionDepth = 5
+	// MaxRecursionDepthLimit is the highest max depth an agent can be configured with
+	MaxRecursionDepthLimit = 20
+)
+
+// Prompt context limits, overridable per agent
+const (
+	// DefaultPromptFactLimit is how many of the user's facts are injected into the system prompt
+	DefaultPromptFactLimit = 25
+	// DefaultPromptHistoryLimit is how many recent messages are injected into the system prompt
+	DefaultPromptHistoryLimit = 10
+	// MaxPromptHistoryLimit is the most recent messages an agent can be configured to inject
+	MaxPromptHistoryLimit = 100
+)
+
+// Agent listing page sizes
+const (
+	// DefaultAgentPageSize is how many agents GET /agents returns without a limit
+	DefaultAgentPageSize = 50
+	// MaxAgentPageSize is the largest limit GET /agents accepts
+	MaxAgentPageSize = 200
+)
+
+// Conversation listing page sizes
+const (
+	// DefaultConversationPageSize is how many conversations GET /agent/:id/conversations returns without a limit
+	DefaultConversationPageSize = 50
+	// MaxConversationPageSize is the largest limit GET /agent/:id/conversations accepts
+	MaxConversationPageSize = 200
 )
 
 // Language codes
@@ -0,0 +1,221 @@
+package discord
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ezra-clone/backend/internal/graph"
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// MaxAvatarBytes is the largest avatar image Discord accepts
+const MaxAvatarBytes = 10 * 1024 * 1024
+
+// presenceStatuses are the online statuses a bot can set
+var presenceStatuses = map[string]bool{
+	string(discordgo.StatusOnline):       true,
+	string(discordgo.StatusIdle):         true,
+	string(discordgo.StatusDoNotDisturb): true,
+	string(discordgo.StatusInvisible):    true,
+}
+
+// activityTypes maps presence activity names to Discord activity types
+var activityTypes = map[string]discordgo.ActivityType{
+	"custom":    discordgo.ActivityTypeCustom,
+	"playing":   discordgo.ActivityTypeGame,
+	"listening": discordgo.ActivityTypeListening,
+	"watching":  discordgo.ActivityTypeWatching,
+	"competing": discordgo.ActivityTypeCompeting,
+}
+
+// avatarContentTypes are the image types Discord accepts for avatars
+var avatarContentTypes = map[string]bool{
+	"image/png": true, "image/jpeg": true, "image/gif": true, "image/webp": true,
+}
+
+// PresenceUpdater sends a presence update over the gateway; *discordgo.Session implements it
+type PresenceUpdater interface {
+	UpdateStatusComplex(usd discordgo.UpdateStatusData) error
+}
+
+// AvatarUpdater changes the bot account's avatar; *discordgo.Session implements it
+type AvatarUpdater interface {
+	UserUpdate(username, avatar, banner string, options ...discordgo.RequestOption) (*discordgo.User, error)
+}
+
+// PresenceStore loads the desired bot presence; *graph.Repository implements it
+type PresenceStore interface {
+	GetBotPresence(ctx context.Context) (*graph.BotPresence, error)
+}
+
+// NormalizePresence fills in defaults and validates a presence
+func NormalizePresence(presence graph.BotPresence) (graph.BotPresence, error) {
+	presence.Status = strings.ToLower(strings.TrimSpace(presence.Status))
+	presence.ActivityType = strings.ToLower(strings.TrimSpace(presence.ActivityType))
+	presence.Text = strings.TrimSpace(presence.Text)
+	if presence.Status == "" {
+		presence.Status = string(discordgo.StatusOnline)
+	}
+	if presence.ActivityType == "" {
+		presence.ActivityType = "custom"
+	}
+
+	if !presenceStatuses[presence.Status] {
+		return presence, fmt.Errorf("status must be one of online, idle, dnd, invisible")
+	}
+	if _, ok := activityTypes[presence.ActivityType]; !ok {
+		return presence, fmt.Errorf("activity_type must be one of custom, playing, listening, watching, competing")
+	}
+	if len(presence.Text) > 128 {
+		return presence, fmt.Errorf("text must be at most 128 characters")
+	}
+	return presence, nil
+}
+
+// ApplyPresence sends presence to Discord
+func ApplyPresence(session PresenceUpdater, presence graph.BotPresence) error {
+	presence, err := NormalizePresence(presence)
+	if err != nil {
+		return err
+	}
+
+	data := discordgo.UpdateStatusData{Status: presence.Status, Activities: []*discordgo.Activity{}}
+	if presence.Text != "" {
+		activity := &discordgo.Activity{Name: presence.Text, Type: activityTypes[presence.ActivityType]}
+		if activity.Type == discordgo.ActivityTypeCustom {
+			// Custom statuses show State; Name is required but not displayed
+			activity.Name = "Custom Status"
+			activity.State = presence.Text
+		}
+		data.Activities = append(data.Activities, activity)
+	}
+
+	if err := session.UpdateStatusComplex(data); err != nil {
+		return fmt.Errorf("failed to update presence: %w", err)
+	}
+	return nil
+}
+
+// PresenceSync keeps the bot's presence in line with the stored one: it is
+// applied on every gateway connect and whenever it changes in the store
+type PresenceSync struct {
+	store   PresenceStore
+	session PresenceUpdater
+	logger  *zap.Logger
+
+	mu      sync.Mutex
+	applied time.Time // UpdatedAt of the last presence applied
+}
+
+// NewPresenceSync creates a presence sync for a session
+func NewPresenceSync(store PresenceStore, session PresenceUpdater, logger *zap.Logger) *PresenceSync {
+	return &PresenceSync{store: store, session: session, logger: logger}
+}
+
+// HandleReady re-applies the stored presence after the bot (re)connects
+func (p *PresenceSync) HandleReady(s *discordgo.Session, r *discordgo.Ready) {
+	if err := p.Sync(context.Background(), true); err != nil {
+		p.logger.Warn("Failed to apply bot presence on connect", zap.Error(err))
+	}
+}
+
+// Sync applies the stored presence if it changed since the last sync, or
+// always when force is set. Nothing is sent when no presence is stored.
+func (p *PresenceSync) Sync(ctx context.Context, force bool) error {
+	presence, err := p.store.GetBotPresence(ctx)
+	if err != nil || presence == nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !force && presence.UpdatedAt.Equal(p.applied) {
+		return nil
+	}
+	if err := ApplyPresence(p.session, *presence); err != nil {
+		return err
+	}
+	p.applied = presence.UpdatedAt
+	p.logger.Info("Applied bot presence",
+		zap.String("status", presence.Status),
+		zap.String("activity_type", presence.ActivityType),
+		zap.String("text", presence.Text),
+	)
+	return nil
+}
+
+// Start polls the store for presence changes made elsewhere, such as through
+// the API server. Call the returned function to stop.
+func (p *PresenceSync) Start(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := p.Sync(context.Background(), false); err != nil {
+					p.logger.Debug("Bot presence sync failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// FetchAvatar downloads an avatar image, checking its type and size
+func FetchAvatar(ctx context.Context, client *http.Client, imageURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid avatar URL: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download avatar: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to download avatar: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxAvatarBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read avatar: %w", err)
+	}
+	if len(data) > MaxAvatarBytes {
+		return nil, "", fmt.Errorf("avatar is larger than %d MB", MaxAvatarBytes/(1024*1024))
+	}
+	contentType := http.DetectContentType(data)
+	if !avatarContentTypes[contentType] {
+		return nil, "", fmt.Errorf("avatar must be a PNG, JPEG, GIF or WebP image, got %s", contentType)
+	}
+	return data, contentType, nil
+}
+
+// SetAvatar replaces the bot account's avatar with image
+func SetAvatar(session AvatarUpdater, image []byte, contentType string) (*discordgo.User, error) {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if !avatarContentTypes[contentType] {
+		return nil, fmt.Errorf("avatar must be a PNG, JPEG, GIF or WebP image, got %s", contentType)
+	}
+	if len(image) > MaxAvatarBytes {
+		return nil, fmt.Errorf("avatar is larger than %d MB", MaxAvatarBytes/(1024*1024))
+	}
+
+	dataURI := "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(image)
+	user, err := session.UserUpdate("", dataURI, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to update avatar: %w", err)
+	}
+	return user, nil
+}
@@ -0,0 +1,82 @@
+package discord
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ezra-clone/backend/internal/graph"
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// fakePresenceSession records presence updates instead of sending them
+type fakePresenceSession struct {
+	updates []discordgo.UpdateStatusData
+}
+
+func (f *fakePresenceSession) UpdateStatusComplex(usd discordgo.UpdateStatusData) error {
+	f.updates = append(f.updates, usd)
+	return nil
+}
+
+// staticPresenceStore returns a fixed presence
+type staticPresenceStore struct {
+	presence *graph.BotPresence
+}
+
+func (s *staticPresenceStore) GetBotPresence(ctx context.Context) (*graph.BotPresence, error) {
+	return s.presence, nil
+}
+
+func TestPresenceSync_AppliesConfiguredText(t *testing.T) {
+	session := &fakePresenceSession{}
+	store := &staticPresenceStore{presence: &graph.BotPresence{
+		Status:    "idle",
+		Text:      "Reading the docs",
+		UpdatedAt: time.Now(),
+	}}
+	sync := NewPresenceSync(store, session, zap.NewNop())
+
+	// A (re)connect always applies the stored presence
+	sync.HandleReady(nil, &discordgo.Ready{})
+	if len(session.updates) != 1 {
+		t.Fatalf("Expected 1 status update, got %d", len(session.updates))
+	}
+	update := session.updates[0]
+	if update.Status != "idle" {
+		t.Errorf("Expected status idle, got %s", update.Status)
+	}
+	if len(update.Activities) != 1 || update.Activities[0].State != "Reading the docs" || update.Activities[0].Type != discordgo.ActivityTypeCustom {
+		t.Fatalf("Expected custom status activity with the configured text, got %+v", update.Activities)
+	}
+
+	// Polling doesn't resend an unchanged presence
+	if err := sync.Sync(context.Background(), false); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(session.updates) != 1 {
+		t.Errorf("Expected unchanged presence not to be resent, got %d updates", len(session.updates))
+	}
+
+	store.presence = &graph.BotPresence{ActivityType: "listening", Text: "lofi beats", UpdatedAt: time.Now().Add(time.Second)}
+	if err := sync.Sync(context.Background(), false); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(session.updates) != 2 {
+		t.Fatalf("Expected changed presence to be applied, got %d updates", len(session.updates))
+	}
+	activity := session.updates[1].Activities[0]
+	if activity.Name != "lofi beats" || activity.Type != discordgo.ActivityTypeListening || session.updates[1].Status != "online" {
+		t.Errorf("Unexpected update %+v with activity %+v", session.updates[1], activity)
+	}
+}
+
+func TestNormalizePresence_RejectsUnknownStatus(t *testing.T) {
+	if _, err := NormalizePresence(graph.BotPresence{Status: "away"}); err == nil {
+		t.Error("Expected unknown status to be rejected")
+	}
+	if _, err := NormalizePresence(graph.BotPresence{ActivityType: "streaming"}); err == nil {
+		t.Error("Expected unsupported activity type to be rejected")
+	}
+}
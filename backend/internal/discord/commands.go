@@ -0,0 +1,176 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/agent"
+	"ezra-clone/backend/internal/tools"
+	"go.uber.org/zap"
+)
+
+// CommandPrefixDisabled turns prefix commands off for a guild
+const CommandPrefixDisabled = "off"
+
+// prefixCommand maps a prefix command to the tool it runs directly
+type prefixCommand struct {
+	tool    string
+	arg     string // Tool argument that receives the text after the command
+	usage   string // Shown when a required argument is missing
+	numeric bool   // arg is parsed as an integer
+	guild   bool   // Pass the guild ID, for music tools
+}
+
+// prefixCommands are the commands available as "<prefix><name>"
+var prefixCommands = map[string]prefixCommand{
+	"play":       {tool: tools.ToolMusicPlay, arg: "query", usage: "play <song or URL>", guild: true},
+	"playlist":   {tool: tools.ToolMusicPlaylist, arg: "query", usage: "playlist <playlist URL>", guild: true},
+	"queue":      {tool: tools.ToolMusicQueue, guild: true},
+	"np":         {tool: tools.ToolMusicNowPlaying, guild: true},
+	"nowplaying": {tool: tools.ToolMusicNowPlaying, guild: true},
+	"skip":       {tool: tools.ToolMusicSkip, guild: true},
+	"seek":       {tool: tools.ToolMusicSeek, arg: "position", usage: "seek <position, e.g. 1:30>", guild: true},
+	"pause":      {tool: tools.ToolMusicPause, guild: true},
+	"resume":     {tool: tools.ToolMusicResume, guild: true},
+	"stop":       {tool: tools.ToolMusicStop, guild: true},
+	"volume":     {tool: tools.ToolMusicVolume, arg: "volume", usage: "volume <0-100>", numeric: true, guild: true},
+	"leave":      {tool: tools.ToolMusicDisconnect, guild: true},
+	"image":      {tool: tools.ToolGenerateImageWithRunPod, arg: "prompt", usage: "image <description>"},
+	"search":     {tool: tools.ToolWebSearch, arg: "query", usage: "search <query>"},
+}
+
+// ToolRunner runs a tool call directly, bypassing the LLM
+type ToolRunner interface {
+	RunTool(ctx context.Context, execCtx *tools.ExecutionContext, toolCall adapter.ToolCall) *agent.TurnResult
+}
+
+// CommandPrefixes resolves the command prefix for each guild
+type CommandPrefixes struct {
+	defaultPrefix string
+	guildPrefixes map[string]string
+}
+
+// NewCommandPrefixes creates prefixes from a default and per-guild overrides.
+// An override of CommandPrefixDisabled turns commands off in that guild.
+func NewCommandPrefixes(defaultPrefix string, guildPrefixes map[string]string) *CommandPrefixes {
+	return &CommandPrefixes{
+		defaultPrefix: strings.TrimSpace(defaultPrefix),
+		guildPrefixes: guildPrefixes,
+	}
+}
+
+// Prefix returns the command prefix for a guild, or "" when commands are
+// disabled there. DMs have no guild ID and use the default.
+func (p *CommandPrefixes) Prefix(guildID string) string {
+	if p == nil {
+		return ""
+	}
+	if prefix, ok := p.guildPrefixes[guildID]; ok && guildID != "" {
+		if strings.EqualFold(prefix, CommandPrefixDisabled) {
+			return ""
+		}
+		return strings.TrimSpace(prefix)
+	}
+	return p.defaultPrefix
+}
+
+// parsePrefixCommand turns "<prefix><name> <text>" into a tool call. ok is
+// false when content isn't a known command; a non-empty usage means a
+// required argument was missing or invalid.
+func parsePrefixCommand(content, prefix, guildID string) (toolCall adapter.ToolCall, usage string, ok bool) {
+	if prefix == "" || !strings.HasPrefix(content, prefix) {
+		return adapter.ToolCall{}, "", false
+	}
+
+	name, text, _ := strings.Cut(strings.TrimPrefix(content, prefix), " ")
+	cmd, known := prefixCommands[strings.ToLower(name)]
+	if !known {
+		return adapter.ToolCall{}, "", false
+	}
+
+	args := make(map[string]interface{})
+	if cmd.guild && guildID != "" {
+		args["guild_id"] = guildID
+	}
+	if text = strings.TrimSpace(text); cmd.arg != "" {
+		if text == "" {
+			return adapter.ToolCall{}, prefix + cmd.usage, true
+		}
+		if cmd.numeric {
+			n, err := strconv.Atoi(text)
+			if err != nil {
+				return adapter.ToolCall{}, prefix + cmd.usage, true
+			}
+			args[cmd.arg] = n
+		} else {
+			args[cmd.arg] = text
+		}
+	}
+
+	return adapter.ToolCall{ID: "command-" + strings.ToLower(name), Name: cmd.tool, Arguments: args}, "", true
+}
+
+// commandList lists the available commands with a prefix, for help text
+func commandList(prefix string) string {
+	names := make([]string, 0, len(prefixCommands))
+	for name := range prefixCommands {
+		names = append(names, prefix+name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// prefixCommandFor parses content as a prefix command in a guild. ok is
+// false when it isn't one; otherwise reply is a response to send as is
+// (help or usage), or toolCall the tool to run.
+func (h *Handler) prefixCommandFor(guildID, content string) (toolCall adapter.ToolCall, reply string, ok bool) {
+	if h.toolRunner == nil {
+		return adapter.ToolCall{}, "", false
+	}
+	prefix := h.commandPrefixes.Prefix(guildID)
+	if prefix == "" {
+		return adapter.ToolCall{}, "", false
+	}
+
+	if strings.EqualFold(content, prefix+"help") {
+		return adapter.ToolCall{}, "Commands: " + commandList(prefix), true
+	}
+
+	toolCall, usage, ok := parsePrefixCommand(content, prefix, guildID)
+	if !ok {
+		return adapter.ToolCall{}, "", false
+	}
+	if usage != "" {
+		return adapter.ToolCall{}, fmt.Sprintf("Usage: `%s`", usage), true
+	}
+	return toolCall, "", true
+}
+
+// runPrefixCommand runs content as a prefix command if it is one. handled is
+// false when the message should go through the normal agent turn.
+func (h *Handler) runPrefixCommand(ctx context.Context, guildID, channelID, userID, content string) (result *agent.TurnResult, handled bool) {
+	toolCall, reply, ok := h.prefixCommandFor(guildID, content)
+	if !ok {
+		return nil, false
+	}
+	if reply != "" {
+		return &agent.TurnResult{Content: reply}, true
+	}
+
+	h.logger.Info("Running prefix command",
+		zap.String("tool", toolCall.Name),
+		zap.String("user_id", userID),
+		zap.String("channel_id", channelID),
+	)
+	execCtx := &tools.ExecutionContext{
+		AgentID:   h.agentID,
+		UserID:    userID,
+		ChannelID: channelID,
+		Platform:  "discord",
+	}
+	return h.toolRunner.RunTool(ctx, execCtx, toolCall), true
+}
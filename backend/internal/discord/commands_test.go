@@ -0,0 +1,82 @@
+package discord
+
+import (
+	"context"
+	"testing"
+
+	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/agent"
+	"ezra-clone/backend/internal/tools"
+	"go.uber.org/zap"
+)
+
+// recordingRunner records tool calls instead of executing them
+type recordingRunner struct {
+	calls []adapter.ToolCall
+}
+
+func (r *recordingRunner) RunTool(ctx context.Context, execCtx *tools.ExecutionContext, toolCall adapter.ToolCall) *agent.TurnResult {
+	r.calls = append(r.calls, toolCall)
+	return &agent.TurnResult{Content: "🎵 Now playing"}
+}
+
+func TestRunPrefixCommand_PlayRoutesToMusicTool(t *testing.T) {
+	runner := &recordingRunner{}
+	// No orchestrator: any attempt to run an LLM turn would panic
+	h := &Handler{
+		logger:          zap.NewNop(),
+		toolRunner:      runner,
+		commandPrefixes: NewCommandPrefixes("!", nil),
+	}
+
+	result, handled := h.runPrefixCommand(context.Background(), "guild-1", "channel-1", "user-1", "!play song")
+	if !handled {
+		t.Fatal("Expected !play to be handled as a command")
+	}
+	if len(runner.calls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(runner.calls))
+	}
+	call := runner.calls[0]
+	if call.Name != tools.ToolMusicPlay {
+		t.Errorf("Expected %s, got %s", tools.ToolMusicPlay, call.Name)
+	}
+	if call.Arguments["query"] != "song" || call.Arguments["guild_id"] != "guild-1" {
+		t.Errorf("Unexpected arguments %v", call.Arguments)
+	}
+	if result.Content != "🎵 Now playing" {
+		t.Errorf("Expected the tool's response, got %q", result.Content)
+	}
+}
+
+func TestRunPrefixCommand_PerGuildPrefixes(t *testing.T) {
+	runner := &recordingRunner{}
+	h := &Handler{
+		logger:     zap.NewNop(),
+		toolRunner: runner,
+		commandPrefixes: NewCommandPrefixes("!", map[string]string{
+			"custom": "?",
+			"quiet":  CommandPrefixDisabled,
+		}),
+	}
+
+	if _, handled := h.runPrefixCommand(context.Background(), "custom", "c", "u", "!skip"); handled {
+		t.Error("Expected the default prefix to be ignored in a guild with its own")
+	}
+	if _, handled := h.runPrefixCommand(context.Background(), "custom", "c", "u", "?skip"); !handled {
+		t.Error("Expected the guild's prefix to run commands")
+	}
+	if _, handled := h.runPrefixCommand(context.Background(), "quiet", "c", "u", "!skip"); handled {
+		t.Error("Expected commands to be disabled in the quiet guild")
+	}
+	if _, handled := h.runPrefixCommand(context.Background(), "other", "c", "u", "!unknown thing"); handled {
+		t.Error("Expected unknown commands to fall through to the agent")
+	}
+
+	result, handled := h.runPrefixCommand(context.Background(), "other", "c", "u", "!volume loud")
+	if !handled || result.Content != "Usage: `!volume <0-100>`" {
+		t.Errorf("Expected usage for an invalid volume, got %v", result)
+	}
+	if len(runner.calls) != 1 {
+		t.Errorf("Expected only ?skip to run a tool, got %d calls", len(runner.calls))
+	}
+}
@@ -0,0 +1,110 @@
+package discord
+
+import (
+	"context"
+	"strings"
+
+	"ezra-clone/backend/internal/agent"
+	"ezra-clone/backend/internal/tools"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// continueButtonPrefix starts the custom ID of the button under cut-off
+// replies; the rest is the reply's continuation ID
+const continueButtonPrefix = "continue_turn:"
+
+// sendContinueButton offers to resume a reply that hit the length limit
+func (h *Handler) sendContinueButton(s *discordgo.Session, channelID, continuationID string) {
+	_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: "*(The reply was cut off. Say \"continue\" or press the button for the rest.)*",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Continue", Style: discordgo.PrimaryButton, CustomID: continueButtonPrefix + continuationID},
+			}},
+		},
+	})
+	if err != nil {
+		h.logger.Warn("Failed to send continue button",
+			zap.Error(err),
+			zap.String("channel_id", channelID),
+		)
+	}
+}
+
+// HandleInteraction resumes a cut-off reply when its Continue button is
+// pressed. Only the reply the button was sent under is resumed, and a press
+// counts against the rate limit and daily quota like a message.
+func (h *Handler) HandleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+	customID := i.MessageComponentData().CustomID
+	continuationID, ok := strings.CutPrefix(customID, continueButtonPrefix)
+	if !ok {
+		return
+	}
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+	if user == nil {
+		return
+	}
+
+	// Remove the button so the reply is only continued once
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    "*(Continuing…)*",
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		h.logger.Warn("Failed to acknowledge continue button", zap.Error(err))
+	}
+
+	// Presses of the same button that arrive before it's removed run once
+	if !h.recentMessages.Add(customID) {
+		return
+	}
+
+	ctx := context.Background()
+	userID, err := h.graphRepo.ResolveUserID(ctx, user.ID)
+	if err != nil {
+		h.logger.Warn("Failed to resolve user",
+			zap.String("user_id", user.ID),
+			zap.Error(err),
+		)
+	}
+	if !h.allowMessage(ctx, s, i.ChannelID, user.ID, userID) {
+		return
+	}
+	execCtx := &tools.ExecutionContext{
+		AgentID:        h.agentID,
+		UserID:         userID,
+		ChannelID:      i.ChannelID,
+		Platform:       "discord",
+		IdempotencyKey: customID,
+	}
+
+	progress := startTurnProgress(s, i.ChannelID, realClock{})
+	result, err := h.agentOrch.ContinueTurn(ctx, execCtx, continuationID)
+	progress.Stop()
+	switch {
+	case err == agent.ErrNoContinuation:
+		_, _ = s.ChannelMessageSend(i.ChannelID, "There's nothing left to continue.")
+		return
+	case err == agent.ErrIgnored:
+		return
+	case err != nil:
+		h.logger.Error("Failed to continue reply",
+			zap.Error(err),
+			zap.String("user_id", user.ID),
+		)
+		_, _ = s.ChannelMessageSend(i.ChannelID, "Sorry, I encountered an error processing your message.")
+		return
+	}
+	h.sendResponse(s, i.ChannelID, result)
+}
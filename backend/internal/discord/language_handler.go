@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
-	"ezra-clone/backend/internal/constants"
 	"ezra-clone/backend/internal/graph"
 	"ezra-clone/backend/internal/utils"
 	"go.uber.org/zap"
@@ -15,7 +14,7 @@ import (
 
 // HandleLanguagePreferenceInstruction detects and processes language preference instructions
 // Returns (success bool, targetUsername string) - targetUsername is empty if set for requester
-func HandleLanguagePreferenceInstruction(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, content string, graphRepo *graph.Repository, log *zap.Logger) (bool, string) {
+func HandleLanguagePreferenceInstruction(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, content, agentID string, graphRepo *graph.Repository, log *zap.Logger) (bool, string) {
 	// Normalize content for pattern matching
 	_ = strings.ToLower(content) // Reserved for future pattern matching
 
@@ -201,7 +200,6 @@ func HandleLanguagePreferenceInstruction(ctx context.Context, s *discordgo.Sessi
 		langName := utils.GetLanguageName(detectedLang)
 
 		// Create a fact about the language preference
-		agentID := constants.DefaultAgentID
 		factContent := fmt.Sprintf("User prefers to communicate in %s", langName)
 		_, err = graphRepo.CreateFact(ctx, agentID, factContent, "language_preference", user.ID, []string{"Language Preferences"})
 		if err != nil {
@@ -244,7 +242,6 @@ func HandleLanguagePreferenceInstruction(ctx context.Context, s *discordgo.Sessi
 		langName := utils.GetLanguageName(detectedLang)
 
 		// Create a fact about the language preference
-		agentID := constants.DefaultAgentID
 		factContent := fmt.Sprintf("User prefers to communicate in %s", langName)
 		_, err = graphRepo.CreateFact(ctx, agentID, factContent, "language_preference", requesterUser.ID, []string{"Language Preferences"})
 		if err != nil {
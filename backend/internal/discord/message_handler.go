@@ -2,13 +2,18 @@ package discord
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"ezra-clone/backend/internal/agent"
 	"ezra-clone/backend/internal/constants"
 	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/ratelimit"
+	"ezra-clone/backend/internal/tools"
 	"ezra-clone/backend/internal/utils"
 	apperrors "ezra-clone/backend/pkg/errors"
 	"go.uber.org/zap"
@@ -16,20 +21,121 @@ import (
 
 // Handler handles Discord message processing
 type Handler struct {
-	agentOrch *agent.Orchestrator
-	graphRepo *graph.Repository
-	logger    *zap.Logger
+	agentOrch       *agent.Orchestrator
+	graphRepo       *graph.Repository
+	logger          *zap.Logger
+	agentID         string           // Agent that answers messages
+	voiceJoiner     VoiceJoiner
+	toolRunner      ToolRunner       // Runs prefix commands without the LLM
+	commandPrefixes *CommandPrefixes // nil disables prefix commands
+	typingPace      *TypingPace      // nil sends replies immediately
+	dailyQuota      int              // Messages each user may send per day (0 is unlimited)
+	hardDelete      bool             // Remove deleted messages from the graph instead of flagging them
+	rateLimiter     *ratelimit.Limiter
+	recentMessages  *ratelimit.RecentKeys // IDs of messages already handled
+}
+
+// VoiceJoiner joins the author's voice channel when a message asks for it
+type VoiceJoiner interface {
+	HandleVoiceJoinRequest(guildID, userID, content string) (string, error)
 }
 
 // NewHandler creates a new Discord message handler
 func NewHandler(agentOrch *agent.Orchestrator, graphRepo *graph.Repository, logger *zap.Logger) *Handler {
 	return &Handler{
-		agentOrch: agentOrch,
-		graphRepo: graphRepo,
-		logger:    logger,
+		agentOrch:      agentOrch,
+		graphRepo:      graphRepo,
+		logger:         logger,
+		agentID:        constants.DefaultAgentID,
+		toolRunner:     agentOrch,
+		recentMessages: ratelimit.NewRecentKeys(ratelimit.DuplicateWindow),
+	}
+}
+
+// SetAgentID sets the agent that answers messages and runs prefix commands
+func (h *Handler) SetAgentID(agentID string) {
+	if agentID != "" {
+		h.agentID = agentID
 	}
 }
 
+// SetCommandPrefixes enables prefix commands such as "!play" that run a tool
+// directly. Pass nil to disable.
+func (h *Handler) SetCommandPrefixes(prefixes *CommandPrefixes) {
+	h.commandPrefixes = prefixes
+}
+
+// SetVoiceJoiner enables auto-joining voice when a user asks the bot to
+// "join voice". Pass nil to disable.
+func (h *Handler) SetVoiceJoiner(joiner VoiceJoiner) {
+	h.voiceJoiner = joiner
+}
+
+// SetTypingPace delays agent replies by a typing time scaled to their length,
+// showing the typing indicator meanwhile. Pass nil to reply immediately.
+func (h *Handler) SetTypingPace(pace *TypingPace) {
+	h.typingPace = pace
+}
+
+// SetDailyMessageQuota limits how many messages each user can have answered
+// per UTC day. Pass 0 for no limit.
+func (h *Handler) SetDailyMessageQuota(limit int) {
+	h.dailyQuota = limit
+}
+
+// SetRateLimiter limits how fast each user can have messages answered.
+// Pass nil for no limit.
+func (h *Handler) SetRateLimiter(limiter *ratelimit.Limiter) {
+	h.rateLimiter = limiter
+}
+
+// SetHardDeleteMessages controls what happens to a stored message when its
+// Discord message is deleted: removed from the graph when true, otherwise
+// flagged deleted and hidden from history
+func (h *Handler) SetHardDeleteMessages(hard bool) {
+	h.hardDelete = hard
+}
+
+// messageAttachments converts a message's Discord attachments for the agent
+func messageAttachments(m *discordgo.Message) []tools.Attachment {
+	if m == nil {
+		return nil
+	}
+	attachments := make([]tools.Attachment, 0, len(m.Attachments))
+	for _, attachment := range m.Attachments {
+		if attachment == nil || attachment.URL == "" {
+			continue
+		}
+		attachments = append(attachments, tools.Attachment{
+			URL:         attachment.URL,
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+		})
+	}
+	return attachments
+}
+
+// cooldownNotice tells a rate-limited user when they can send again
+func cooldownNotice(retryAfter time.Duration) string {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("⏳ You're sending messages too quickly. Try again in %ds.", seconds)
+}
+
+// stripBotMention trims content and removes a leading mention of the bot,
+// reporting whether there was one
+func stripBotMention(content, botID string) (string, bool) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "<@"+botID+">") && !strings.HasPrefix(content, "<!@"+botID+">") {
+		return content, false
+	}
+	content = strings.TrimPrefix(content, "<@"+botID+">")
+	content = strings.TrimPrefix(content, "<!@"+botID+">")
+	return strings.TrimSpace(content), true
+}
+
 // HandleMessage processes a Discord message
 func (h *Handler) HandleMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Ignore messages from the bot itself
@@ -50,22 +156,29 @@ func (h *Handler) HandleMessage(s *discordgo.Session, m *discordgo.MessageCreate
 	}
 
 	// Also check if message starts with bot mention
-	content := strings.TrimSpace(m.Content)
-	if strings.HasPrefix(content, "<@"+s.State.User.ID+">") || strings.HasPrefix(content, "<!@"+s.State.User.ID+">") {
+	content, startsWithMention := stripBotMention(m.Content, s.State.User.ID)
+	if startsWithMention {
 		isMentioned = true
-		// Remove mention from content
-		content = strings.TrimPrefix(content, "<@"+s.State.User.ID+">")
-		content = strings.TrimPrefix(content, "<!@"+s.State.User.ID+">")
-		content = strings.TrimSpace(content)
 	}
 
-	// Only respond to DMs or mentions
-	if !isDM && !isMentioned {
+	// Only respond to DMs, mentions and prefix commands, which work without a mention
+	_, _, isCommand := h.prefixCommandFor(m.GuildID, content)
+	if !isDM && !isMentioned && !isCommand {
+		return
+	}
+
+	// Skip empty messages, unless they share files
+	attachments := messageAttachments(m.Message)
+	if content == "" && len(attachments) == 0 {
 		return
 	}
 
-	// Skip empty messages
-	if content == "" {
+	// Discord can deliver a message again, e.g. after a gateway resume;
+	// answer and charge it once
+	if !h.recentMessages.Add(m.ID) {
+		h.logger.Debug("Ignoring redelivered message",
+			zap.String("message_id", m.ID),
+		)
 		return
 	}
 
@@ -77,8 +190,31 @@ func (h *Handler) HandleMessage(s *discordgo.Session, m *discordgo.MessageCreate
 
 	ctx := context.Background()
 
+	// Users merged into another identity (e.g. their web user) are stored under it
+	userID, err := h.graphRepo.ResolveUserID(ctx, m.Author.ID)
+	if err != nil {
+		h.logger.Warn("Failed to resolve user",
+			zap.String("user_id", m.Author.ID),
+			zap.Error(err),
+		)
+	}
+
+	// Enforce the rate limit, then the daily quota, before running a command
+	// or spending an LLM call
+	if !h.allowMessage(ctx, s, m.ChannelID, m.Author.ID, userID) {
+		return
+	}
+
+	// Prefix commands skip the LLM
+	if isCommand {
+		if result, handled := h.runPrefixCommand(ctx, m.GuildID, m.ChannelID, m.Author.ID, content); handled {
+			h.sendResponse(s, m.ChannelID, result)
+			return
+		}
+	}
+
 	// Ensure message author exists in database before processing
-	_, err := h.graphRepo.GetOrCreateUser(ctx, m.Author.ID, m.Author.ID, m.Author.Username, "discord")
+	_, err = h.graphRepo.GetOrCreateUser(ctx, userID, m.Author.ID, m.Author.Username, "discord")
 	if err != nil {
 		h.logger.Error("Failed to get/create user",
 			zap.String("user_id", m.Author.ID),
@@ -91,7 +227,7 @@ func (h *Handler) HandleMessage(s *discordgo.Session, m *discordgo.MessageCreate
 	h.createMentionedUsers(ctx, s, m)
 
 	// Check for language preference instructions before processing
-	languagePreferenceSet, targetUserForLang := HandleLanguagePreferenceInstruction(ctx, s, m, content, h.graphRepo, h.logger)
+	languagePreferenceSet, targetUserForLang := HandleLanguagePreferenceInstruction(ctx, s, m, content, h.agentID, h.graphRepo, h.logger)
 
 	// If language preference was set, send confirmation and skip LLM processing
 	if languagePreferenceSet && targetUserForLang != "" {
@@ -109,11 +245,49 @@ func (h *Handler) HandleMessage(s *discordgo.Session, m *discordgo.MessageCreate
 		return
 	}
 
+	// Join the author's voice channel without needing an explicit channel ID,
+	// if the agent's tool access lets it use voice
+	if h.voiceJoiner != nil && !isDM && tools.IsVoiceJoinRequest(content) &&
+		tools.VoiceJoinAllowed(tools.AgentToolAccess(h.graphRepo.GetAgentConfig(ctx, h.agentID))) {
+		voiceChannelID, err := h.voiceJoiner.HandleVoiceJoinRequest(m.GuildID, m.Author.ID, content)
+		if errors.Is(err, tools.ErrUserNotInVoice) {
+			_, _ = s.ChannelMessageSend(m.ChannelID, "Join a voice channel first and I'll hop in!")
+			return
+		}
+		if err != nil {
+			h.logger.Warn("Failed to auto-join voice channel",
+				zap.String("user_id", m.Author.ID),
+				zap.Error(err),
+			)
+			_, _ = s.ChannelMessageSend(m.ChannelID, "Sorry, I couldn't join your voice channel.")
+			return
+		}
+		if voiceChannelID != "" {
+			_, _ = s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🔊 Joined <#%s>!", voiceChannelID))
+			return
+		}
+	}
+
 	// Run agent turn with full context
-	agentID := constants.DefaultAgentID // Default agent ID
+	agentID := h.agentID
 	channelID := m.ChannelID
 	platform := "discord"
-	result, err := h.agentOrch.RunTurnWithContext(ctx, agentID, m.Author.ID, channelID, platform, content)
+	execCtx := &tools.ExecutionContext{
+		AgentID:   agentID,
+		UserID:    userID,
+		ChannelID: channelID,
+		Platform:  platform,
+		// Discord message IDs are unique, so redelivered events are logged once
+		IdempotencyKey:    m.ID,
+		PlatformMessageID: m.ID,
+		Attachments:       attachments,
+	}
+
+	// Show typing, and what the agent is doing during long turns, until the reply is ready
+	progress := startTurnProgress(s, m.ChannelID, realClock{})
+	execCtx.OnToolStart = progress.ToolStarted
+	result, err := h.agentOrch.RunTurnWithExecutionContext(ctx, execCtx, content)
+	progress.Stop()
 
 	if err != nil {
 		if apperrors.IsErrorType(err, apperrors.ErrorTypeAgent) && err == agent.ErrIgnored {
@@ -146,9 +320,45 @@ func (h *Handler) HandleMessage(s *discordgo.Session, m *discordgo.MessageCreate
 		return
 	}
 
-	// Send the response
+	// Send the response, after the typing delay if one is configured
+	h.typingPace.Wait(s, m.ChannelID, result.Content, m.Timestamp)
 	h.sendResponse(s, m.ChannelID, result)
 }
 
+// allowMessage applies the per-user rate limit, then the daily quota, to a
+// message from authorID (stored as userID), telling the user when it is
+// refused
+func (h *Handler) allowMessage(ctx context.Context, s *discordgo.Session, channelID, authorID, userID string) bool {
+	if decision := h.rateLimiter.Allow(authorID); !decision.Allowed {
+		h.logger.Info("User rate limited",
+			zap.String("user_id", authorID),
+			zap.Duration("retry_after", decision.RetryAfter),
+		)
+		// One notice per cooldown, so spamming doesn't also spam the channel
+		if decision.FirstDenial {
+			_, _ = s.ChannelMessageSend(channelID, cooldownNotice(decision.RetryAfter))
+		}
+		return false
+	}
+	if h.dailyQuota > 0 {
+		allowed, usage, err := h.graphRepo.ConsumeMessageQuota(ctx, userID, h.dailyQuota, time.Now())
+		if err != nil {
+			// Don't lock users out because the count couldn't be read
+			h.logger.Warn("Failed to check message quota",
+				zap.String("user_id", authorID),
+				zap.Error(err),
+			)
+		} else if !allowed {
+			h.logger.Info("User reached daily message quota",
+				zap.String("user_id", authorID),
+				zap.Int("count", usage.Count),
+			)
+			_, _ = s.ChannelMessageSend(channelID, graph.QuotaExceededMessage(h.dailyQuota))
+			return false
+		}
+	}
+	return true
+}
+
 // Note: sendResponse, sendLongMessage, and splitMessage are now in response_sender.go
 // Note: createMentionedUsers is now in user_management.go
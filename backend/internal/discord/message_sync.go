@@ -0,0 +1,69 @@
+package discord
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// HandleMessageUpdate applies a user's edit to the stored copy of a message
+// the agent has processed, so history and personality analysis see what the
+// user actually said
+func (h *Handler) HandleMessageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	// Embed unfurls also arrive as updates; only real edits carry a timestamp
+	if m.Message == nil || m.EditedTimestamp == nil {
+		return
+	}
+	if m.Author != nil && m.Author.ID == s.State.User.ID {
+		return
+	}
+
+	content, _ := stripBotMention(m.Content, s.State.User.ID)
+	updated, err := h.graphRepo.UpdateMessageContent(context.Background(), "discord", m.ID, content)
+	if err != nil {
+		h.logger.Warn("Failed to apply message edit",
+			zap.String("message_id", m.ID),
+			zap.Error(err),
+		)
+		return
+	}
+	if updated {
+		h.logger.Debug("Applied message edit", zap.String("message_id", m.ID))
+	}
+}
+
+// HandleMessageDelete removes a deleted message from stored history
+func (h *Handler) HandleMessageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
+	if m.Message == nil {
+		return
+	}
+	h.deleteMessages([]string{m.ID})
+}
+
+// HandleMessageDeleteBulk removes messages purged together, e.g. by a moderator
+func (h *Handler) HandleMessageDeleteBulk(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+	h.deleteMessages(m.Messages)
+}
+
+// deleteMessages deletes the stored messages with the given Discord IDs;
+// IDs the agent never stored are ignored
+func (h *Handler) deleteMessages(ids []string) {
+	ctx := context.Background()
+	for _, id := range ids {
+		deleted, err := h.graphRepo.DeleteMessageByPlatformID(ctx, "discord", id, h.hardDelete)
+		if err != nil {
+			h.logger.Warn("Failed to apply message delete",
+				zap.String("message_id", id),
+				zap.Error(err),
+			)
+			continue
+		}
+		if deleted {
+			h.logger.Debug("Applied message delete",
+				zap.String("message_id", id),
+				zap.Bool("hard", h.hardDelete),
+			)
+		}
+	}
+}
@@ -46,126 +46,154 @@ func (h *Handler) sendResponse(s *discordgo.Session, channelID string, result *a
 
 		discordEmbeds = append(discordEmbeds, embed)
 	}
+	// embedFiles[i] is the attachment discordEmbeds[i] shows, if any
+	embedFiles := make([]*discordgo.File, len(discordEmbeds))
 
-	// Prepare file attachment if image data is present
-	var files []*discordgo.File
+	// Prepare file attachments if image data is present, one embed per image
 	var imageEmbed *discordgo.MessageEmbed
 	if len(result.ImageData) > 0 {
-		imageName := result.ImageName
-		if imageName == "" {
-			imageName = "image.png"
-		}
-
-		// Create a file attachment
-		files = append(files, &discordgo.File{
-			Name:   imageName,
-			Reader: bytes.NewReader(result.ImageData),
-		})
-
-		// Create a nice embed for the image
-		imageEmbed = &discordgo.MessageEmbed{
-			Title:       "🎨 Generated Image",
-			Description: messageContent,
-			Color:       0x5865F2, // Discord blurple color
-			Image: &discordgo.MessageEmbedImage{
-				URL: fmt.Sprintf("attachment://%s", imageName), // Reference the attached file
-			},
-			Footer: &discordgo.MessageEmbedFooter{
-				Text: "ComfyUI via RunPod",
-			},
-			Timestamp: time.Now().Format(time.RFC3339),
-		}
-
-		// Add metadata fields if available
-		if result.ImageMeta != nil {
-			var fields []*discordgo.MessageEmbedField
-
-			if width, ok := result.ImageMeta["width"]; ok {
-				if height, ok := result.ImageMeta["height"]; ok {
-					fields = append(fields, &discordgo.MessageEmbedField{
-						Name:   "Dimensions",
-						Value:  fmt.Sprintf("%v × %v", width, height),
-						Inline: true,
-					})
-				}
+		images := append([]agent.TurnImage{{Data: result.ImageData, Name: result.ImageName, Meta: result.ImageMeta}}, result.Variations...)
+		for i, image := range images {
+			imageName := image.Name
+			if imageName == "" {
+				imageName = fmt.Sprintf("image-%d.png", i+1)
 			}
 
-			if seed, ok := result.ImageMeta["seed"]; ok {
-				fields = append(fields, &discordgo.MessageEmbedField{
-					Name:   "Seed",
-					Value:  fmt.Sprintf("%v", seed),
-					Inline: true,
-				})
-			}
-
-			if elapsed, ok := result.ImageMeta["elapsed_seconds"]; ok {
-				if elapsedFloat, ok := elapsed.(float64); ok {
-					fields = append(fields, &discordgo.MessageEmbedField{
-						Name:   "Generation Time",
-						Value:  fmt.Sprintf("%.1fs", elapsedFloat),
-						Inline: true,
-					})
-				}
+			embed := buildImageEmbed(imageName, image.Meta)
+			if i == 0 {
+				embed.Description = messageContent
+				imageEmbed = embed
+			} else {
+				embed.Title = fmt.Sprintf("🎨 Variation %d of %d", i+1, len(images))
 			}
+			discordEmbeds = append(discordEmbeds, embed)
+			embedFiles = append(embedFiles, &discordgo.File{
+				Name:   imageName,
+				Reader: bytes.NewReader(image.Data),
+			})
 
-			if len(fields) > 0 {
-				imageEmbed.Fields = fields
-			}
+			h.logger.Debug("Attaching image to Discord message",
+				zap.String("filename", imageName),
+				zap.Int("size_bytes", len(image.Data)),
+			)
 		}
-
-		// Add the image embed to the embeds list
-		discordEmbeds = append(discordEmbeds, imageEmbed)
-
-		h.logger.Debug("Attaching image to Discord message",
-			zap.String("filename", imageName),
-			zap.Int("size_bytes", len(result.ImageData)),
-		)
 	}
 
-	// Send message with embeds and/or file attachment
-	if len(discordEmbeds) > 0 || len(files) > 0 {
+	// Send message with embeds and file attachments, split across messages
+	// when there are more embeds than one message can carry
+	if len(discordEmbeds) > 0 {
 		// If we have an image embed, don't send content separately (it's in the embed)
 		sendContent := messageContent
 		if imageEmbed != nil {
 			sendContent = "" // Image embed already has the description
 		}
 
-		// If sendContent is too long, we need to chunk it even with embeds
-		if sendContent != "" && len(sendContent) > constants.DiscordMaxMessageLength {
-			// Send embeds first, then chunk the content
-			if len(discordEmbeds) > 0 || len(files) > 0 {
-				_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
-					Content: "", // Send embeds/files first
-					Embeds:  discordEmbeds,
-					Files:   files,
-				})
-				if err != nil {
-					h.logger.Error("Failed to send message with embeds/files",
-						zap.Error(err),
-						zap.String("channel_id", channelID),
-					)
-				}
-			}
-			// Now send the content in chunks
-			h.sendLongMessage(s, channelID, sendContent)
-		} else {
-			// Content fits, send everything together
-			_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
-				Content: sendContent,
-				Embeds:  discordEmbeds,
-				Files:   files,
-			})
-			if err != nil {
+		// Content that is too long is chunked after the embeds
+		messages := embedMessages(discordEmbeds, embedFiles)
+		longContent := len(sendContent) > constants.DiscordMaxMessageLength
+		if !longContent {
+			messages[0].Content = sendContent
+		}
+		for _, message := range messages {
+			if _, err := s.ChannelMessageSendComplex(channelID, message); err != nil {
 				h.logger.Error("Failed to send message with embeds/files",
 					zap.Error(err),
 					zap.String("channel_id", channelID),
 				)
 			}
 		}
+		if longContent {
+			h.sendLongMessage(s, channelID, sendContent)
+		}
 	} else if messageContent != "" {
 		// Plain text message - split if too long
 		h.sendLongMessage(s, channelID, messageContent)
 	}
+
+	if result.Truncated && result.ContinuationID != "" {
+		h.sendContinueButton(s, channelID, result.ContinuationID)
+	}
+}
+
+// embedMessages groups embeds into messages of at most
+// DiscordMaxEmbedsPerMessage, each carrying the attachments its embeds show.
+// files[i] is the attachment embeds[i] shows, or nil.
+func embedMessages(embeds []*discordgo.MessageEmbed, files []*discordgo.File) []*discordgo.MessageSend {
+	var messages []*discordgo.MessageSend
+	for start := 0; start < len(embeds); start += constants.DiscordMaxEmbedsPerMessage {
+		end := min(start+constants.DiscordMaxEmbedsPerMessage, len(embeds))
+		message := &discordgo.MessageSend{Embeds: embeds[start:end]}
+		for _, file := range files[start:end] {
+			if file != nil {
+				message.Files = append(message.Files, file)
+			}
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// buildImageEmbed creates the embed showing an attached image, with its
+// metadata as fields
+func buildImageEmbed(imageName string, meta map[string]interface{}) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: "🎨 Generated Image",
+		Color: 0x5865F2, // Discord blurple color
+		Image: &discordgo.MessageEmbedImage{
+			URL: fmt.Sprintf("attachment://%s", imageName), // Reference the attached file
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "ComfyUI via RunPod",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if meta == nil {
+		return embed
+	}
+
+	// Add metadata fields if available
+	var fields []*discordgo.MessageEmbedField
+
+	if width, ok := meta["width"]; ok {
+		if height, ok := meta["height"]; ok {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   "Dimensions",
+				Value:  fmt.Sprintf("%v × %v", width, height),
+				Inline: true,
+			})
+		}
+	}
+
+	if seed, ok := meta["seed"]; ok {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Seed",
+			Value:  fmt.Sprintf("%v", seed),
+			Inline: true,
+		})
+	}
+
+	if style, ok := meta["style_preset"].(string); ok && style != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Style",
+			Value:  style,
+			Inline: true,
+		})
+	}
+
+	if elapsed, ok := meta["elapsed_seconds"]; ok {
+		if elapsedFloat, ok := elapsed.(float64); ok {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   "Generation Time",
+				Value:  fmt.Sprintf("%.1fs", elapsedFloat),
+				Inline: true,
+			})
+		}
+	}
+
+	if len(fields) > 0 {
+		embed.Fields = fields
+	}
+	return embed
 }
 
 // sendLongMessage splits a message into chunks if it exceeds Discord's character limit
@@ -0,0 +1,45 @@
+package discord
+
+import (
+	"fmt"
+	"testing"
+
+	"ezra-clone/backend/internal/constants"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestEmbedMessages_SplitsOverTheEmbedLimit(t *testing.T) {
+	// A link preview embed followed by twelve image embeds with their files
+	embeds := []*discordgo.MessageEmbed{{Title: "Link preview"}}
+	files := []*discordgo.File{nil}
+	for i := 1; i <= 12; i++ {
+		name := fmt.Sprintf("image-%d.png", i)
+		embeds = append(embeds, buildImageEmbed(name, nil))
+		files = append(files, &discordgo.File{Name: name})
+	}
+
+	messages := embedMessages(embeds, files)
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if len(messages[0].Embeds) != constants.DiscordMaxEmbedsPerMessage || len(messages[1].Embeds) != 3 {
+		t.Errorf("Expected 10 and 3 embeds, got %d and %d", len(messages[0].Embeds), len(messages[1].Embeds))
+	}
+
+	// Each image's file goes out with the embed that shows it
+	for _, message := range messages {
+		attached := make(map[string]bool)
+		for _, file := range message.Files {
+			attached["attachment://"+file.Name] = true
+		}
+		for _, embed := range message.Embeds {
+			if embed.Image != nil && !attached[embed.Image.URL] {
+				t.Errorf("Expected %s to be attached to the message showing it", embed.Image.URL)
+			}
+		}
+	}
+	if len(messages[0].Files) != 9 || len(messages[1].Files) != 3 {
+		t.Errorf("Expected 9 and 3 files, got %d and %d", len(messages[0].Files), len(messages[1].Files))
+	}
+}
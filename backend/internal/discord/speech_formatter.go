@@ -0,0 +1,57 @@
+package discord
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Patterns for text that reads poorly when spoken
+	speechCodeBlockPattern   = regexp.MustCompile("(?s)```.*?```")
+	speechInlineCodePattern  = regexp.MustCompile("`([^`\n]+)`")
+	speechImagePattern       = regexp.MustCompile(`!\[([^\]]*)\]\([^)]+\)`)
+	speechLinkPattern        = regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
+	speechBareURLPattern     = regexp.MustCompile(`<?https?://[^\s>)]+>?`)
+	speechHeaderPattern      = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	speechQuotePattern       = regexp.MustCompile(`(?m)^>\s?`)
+	speechListPattern        = regexp.MustCompile(`(?m)^\s*(?:[-*•]|\d+\.)\s+`)
+	speechEmphasisPattern    = regexp.MustCompile(`(\*\*\*|\*\*|\*|___|__|~~|\|\|)`)
+	speechUnderscorePattern  = regexp.MustCompile(`(^|\s)_([^_\n]+)_(\s|$|[.,!?])`)
+	speechCustomEmojiPattern = regexp.MustCompile(`<a?:(\w+):\d+>`)
+	speechSpacePattern       = regexp.MustCompile(`[ \t]+`)
+	speechNewlinesPattern    = regexp.MustCompile(`\s*\n\s*`)
+)
+
+// FormatForSpeech strips markdown from content so TTS reads only the words:
+// code blocks are replaced by a short spoken note, links keep their text,
+// bare URLs become "a link", and headers, lists and emphasis markers are
+// dropped. Lines are joined into sentences.
+func FormatForSpeech(content string) string {
+	content = speechCodeBlockPattern.ReplaceAllString(content, " (code snippet omitted) ")
+	content = speechInlineCodePattern.ReplaceAllString(content, "$1")
+	content = speechImagePattern.ReplaceAllString(content, "$1")
+	content = speechLinkPattern.ReplaceAllString(content, "$1")
+	content = speechBareURLPattern.ReplaceAllString(content, "a link")
+	content = speechCustomEmojiPattern.ReplaceAllString(content, "$1")
+	content = speechHeaderPattern.ReplaceAllString(content, "")
+	content = speechQuotePattern.ReplaceAllString(content, "")
+	content = speechListPattern.ReplaceAllString(content, "")
+	content = speechEmphasisPattern.ReplaceAllString(content, "")
+	content = speechUnderscorePattern.ReplaceAllString(content, "$1$2$3")
+
+	// Join lines into sentences so the voice doesn't run them together
+	lines := strings.Split(content, "\n")
+	var parts []string
+	for _, line := range lines {
+		line = strings.TrimSpace(speechSpacePattern.ReplaceAllString(line, " "))
+		if line == "" {
+			continue
+		}
+		if !strings.ContainsAny(line[len(line)-1:], ".!?:;,") {
+			line += "."
+		}
+		parts = append(parts, line)
+	}
+
+	return strings.TrimSpace(speechNewlinesPattern.ReplaceAllString(strings.Join(parts, " "), " "))
+}
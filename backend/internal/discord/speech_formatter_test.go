@@ -0,0 +1,28 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatForSpeech_StripsMarkdown(t *testing.T) {
+	content := "# Setup Guide\n\n" +
+		"Here's **how** to do it:\n" +
+		"- Install *Go* from [the website](https://go.dev/dl)\n" +
+		"- Run `go build`\n\n" +
+		"```go\nfunc main() {}\n```\n" +
+		"More info at https://example.com/docs ~~maybe~~ <:pog:123456>"
+
+	got := FormatForSpeech(content)
+	want := "Setup Guide. Here's how to do it: Install Go from the website. Run go build. " +
+		"(code snippet omitted). More info at a link maybe pog."
+
+	if got != want {
+		t.Errorf("FormatForSpeech()\n got: %q\nwant: %q", got, want)
+	}
+	for _, leftover := range []string{"*", "#", "`", "](", "http", "~~", "func main"} {
+		if strings.Contains(got, leftover) {
+			t.Errorf("FormatForSpeech() left %q in %q", leftover, got)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package discord
+
+import (
+	"sync"
+
+	"ezra-clone/backend/internal/tools"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ProgressSession is what a turn's progress reporting needs from Discord;
+// *discordgo.Session implements it
+type ProgressSession interface {
+	Typer
+	ChannelMessageSend(channelID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEdit(channelID, messageID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error
+}
+
+// toolStatuses are the interim messages shown while slow tools run. Quick
+// tools aren't announced; the typing indicator covers them.
+var toolStatuses = map[string]string{
+	tools.ToolWebSearch:               "🔎 Searching the web...",
+	tools.ToolFetchWebpage:            "📄 Reading a webpage...",
+	tools.ToolSummarizeWebsite:        "📄 Summarizing a website...",
+	tools.ToolGitHubSearch:            "🔎 Searching GitHub...",
+	tools.ToolReadCodebase:            "📂 Reading the codebase...",
+	tools.ToolDiscordReadHistory:      "📜 Reading channel history...",
+	tools.ToolDiscordSearchMessages:   "🔎 Searching messages...",
+	tools.ToolAnalyzeUserStyle:        "🧠 Analyzing writing style...",
+	tools.ToolEnhancePrompt:           "✨ Enhancing the prompt...",
+	tools.ToolGenerateImageWithRunPod: "🎨 Generating image...",
+}
+
+// turnProgress keeps a channel informed while a turn runs: the typing
+// indicator is refreshed until Stop, and a status message is posted when a
+// slow tool starts, edited as later tools start, and deleted at the end so
+// only the final response remains
+type turnProgress struct {
+	session   ProgressSession
+	channelID string
+	clock     Clock
+
+	mu       sync.Mutex
+	statusID string // Interim status message, once posted
+	status   string
+	stopped  bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// startTurnProgress starts showing the typing indicator in channelID
+func startTurnProgress(session ProgressSession, channelID string, clock Clock) *turnProgress {
+	p := &turnProgress{
+		session:   session,
+		channelID: channelID,
+		clock:     clock,
+		done:      make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.keepTyping()
+	return p
+}
+
+// keepTyping re-sends the typing indicator before it lapses until Stop
+func (p *turnProgress) keepTyping() {
+	defer p.wg.Done()
+	for {
+		_ = p.session.ChannelTyping(p.channelID)
+		select {
+		case <-p.done:
+			return
+		case <-p.clock.After(typingRefresh):
+		}
+	}
+}
+
+// ToolStarted shows the status for toolName, if it has one. It is the
+// turn's tools.ExecutionContext.OnToolStart.
+func (p *turnProgress) ToolStarted(toolName string) {
+	status, ok := toolStatuses[toolName]
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped || status == p.status {
+		return
+	}
+	p.status = status
+	if p.statusID == "" {
+		if msg, err := p.session.ChannelMessageSend(p.channelID, status); err == nil && msg != nil {
+			p.statusID = msg.ID
+		}
+		return
+	}
+	_, _ = p.session.ChannelMessageEdit(p.channelID, p.statusID, status)
+}
+
+// Stop ends the typing indicator refreshes and deletes the status message
+func (p *turnProgress) Stop() {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	statusID := p.statusID
+	p.mu.Unlock()
+
+	close(p.done)
+	p.wg.Wait()
+	if statusID != "" {
+		_ = p.session.ChannelMessageDelete(p.channelID, statusID)
+	}
+}
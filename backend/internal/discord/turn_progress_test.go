@@ -0,0 +1,78 @@
+package discord
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"ezra-clone/backend/internal/tools"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// recordingSession records the status messages sent, edited and deleted
+type recordingSession struct {
+	mu     sync.Mutex
+	typing int
+	calls  []string
+	nextID int
+}
+
+func (s *recordingSession) ChannelTyping(channelID string, options ...discordgo.RequestOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.typing++
+	return nil
+}
+
+func (s *recordingSession) ChannelMessageSend(channelID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, "send "+content)
+	return &discordgo.Message{ID: "status"}, nil
+}
+
+func (s *recordingSession) ChannelMessageEdit(channelID, messageID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, "edit "+messageID+" "+content)
+	return &discordgo.Message{ID: messageID}, nil
+}
+
+func (s *recordingSession) ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, "delete "+messageID)
+	return nil
+}
+
+// blockingClock never fires, so typing is only refreshed at the start
+type blockingClock struct{}
+
+func (blockingClock) Now() time.Time                       { return time.Time{} }
+func (blockingClock) After(time.Duration) <-chan time.Time { return nil }
+
+func TestTurnProgress_ShowsAndCleansUpStatus(t *testing.T) {
+	session := &recordingSession{}
+	progress := startTurnProgress(session, "channel-1", blockingClock{})
+
+	progress.ToolStarted(tools.ToolWebSearch)
+	progress.ToolStarted(tools.ToolCreateFact) // Quick tools aren't announced
+	progress.ToolStarted(tools.ToolFetchWebpage)
+	progress.ToolStarted(tools.ToolFetchWebpage) // Unchanged status isn't re-sent
+	progress.Stop()
+	progress.ToolStarted(tools.ToolGenerateImageWithRunPod) // Ignored after Stop
+
+	want := []string{
+		"send 🔎 Searching the web...",
+		"edit status 📄 Reading a webpage...",
+		"delete status",
+	}
+	if !reflect.DeepEqual(session.calls, want) {
+		t.Errorf("Expected %q, got %q", want, session.calls)
+	}
+	if session.typing != 1 {
+		t.Errorf("Expected the typing indicator once, got %d", session.typing)
+	}
+}
@@ -0,0 +1,95 @@
+package discord
+
+import (
+	"math/rand"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// typingRefresh is how often the typing indicator is re-sent; Discord shows
+// it for about ten seconds
+const typingRefresh = 8 * time.Second
+
+// Typer shows the typing indicator in a channel; *discordgo.Session implements it
+type Typer interface {
+	ChannelTyping(channelID string, options ...discordgo.RequestOption) error
+}
+
+// Clock tells time and waits; replaced in tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the wall clock
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// TypingPace delays replies in proportion to their length, showing the typing
+// indicator meanwhile, so the bot feels less instant
+type TypingPace struct {
+	charsPerSecond float64
+	jitter         float64 // Fraction the delay varies by in either direction
+	maxDelay       time.Duration
+	clock          Clock
+	random         func() float64 // Returns [0, 1); replaced in tests
+}
+
+// NewTypingPace creates a pace that "types" charsPerSecond characters a
+// second, varied by up to jitter (0-1) either way and capped at maxDelay.
+// It returns nil, which sends immediately, when charsPerSecond isn't positive.
+func NewTypingPace(charsPerSecond, jitter float64, maxDelay time.Duration) *TypingPace {
+	if charsPerSecond <= 0 || maxDelay <= 0 {
+		return nil
+	}
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	return &TypingPace{
+		charsPerSecond: charsPerSecond,
+		jitter:         jitter,
+		maxDelay:       maxDelay,
+		clock:          realClock{},
+		random:         rand.Float64,
+	}
+}
+
+// Delay returns how long typing content takes, before subtracting the time
+// already spent generating it
+func (p *TypingPace) Delay(content string) time.Duration {
+	if p == nil {
+		return 0
+	}
+	seconds := float64(utf8.RuneCountInString(content)) / p.charsPerSecond
+	seconds *= 1 + p.jitter*(2*p.random()-1)
+	delay := time.Duration(seconds * float64(time.Second))
+	if delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	return delay
+}
+
+// Wait shows the typing indicator until content's typing delay, counted from
+// started, has passed. A nil pace returns immediately.
+func (p *TypingPace) Wait(typer Typer, channelID, content string, started time.Time) {
+	if p == nil {
+		return
+	}
+	remaining := p.Delay(content) - p.clock.Now().Sub(started)
+	for remaining > 0 {
+		_ = typer.ChannelTyping(channelID)
+		step := remaining
+		if step > typingRefresh {
+			step = typingRefresh
+		}
+		<-p.clock.After(step)
+		remaining -= step
+	}
+}
@@ -0,0 +1,98 @@
+package discord
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeClock records waits and returns immediately
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *fakeClock) total() time.Duration {
+	var total time.Duration
+	for _, d := range c.waits {
+		total += d
+	}
+	return total
+}
+
+// countingTyper counts typing indicators sent
+type countingTyper struct {
+	calls int
+}
+
+func (t *countingTyper) ChannelTyping(channelID string, options ...discordgo.RequestOption) error {
+	t.calls++
+	return nil
+}
+
+func newTestPace(clock *fakeClock) *TypingPace {
+	pace := NewTypingPace(20, 0.5, 30*time.Second)
+	pace.clock = clock
+	pace.random = func() float64 { return 0.5 } // No jitter
+	return pace
+}
+
+func TestTypingPace_DelaysProportionally(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	short := &fakeClock{now: start}
+	newTestPace(short).Wait(&countingTyper{}, "channel-1", string(make([]byte, 40)), start)
+	if short.total() != 2*time.Second {
+		t.Errorf("Expected 40 chars at 20/s to wait 2s, got %v", short.total())
+	}
+
+	long := &fakeClock{now: start}
+	typer := &countingTyper{}
+	newTestPace(long).Wait(typer, "channel-1", string(make([]byte, 400)), start)
+	if long.total() != 20*time.Second {
+		t.Errorf("Expected 400 chars at 20/s to wait 20s, got %v", long.total())
+	}
+	if typer.calls != 3 {
+		t.Errorf("Expected the typing indicator to be refreshed over 20s (3 calls), got %d", typer.calls)
+	}
+
+	capped := &fakeClock{now: start}
+	newTestPace(capped).Wait(&countingTyper{}, "channel-1", string(make([]byte, 4000)), start)
+	if capped.total() != 30*time.Second {
+		t.Errorf("Expected the delay to be capped at 30s, got %v", capped.total())
+	}
+}
+
+func TestTypingPace_CountsTimeAlreadySpent(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start.Add(1500 * time.Millisecond)} // Generating the reply took 1.5s
+
+	newTestPace(clock).Wait(&countingTyper{}, "channel-1", string(make([]byte, 40)), start)
+	if clock.total() != 500*time.Millisecond {
+		t.Errorf("Expected only the remaining 500ms, got %v", clock.total())
+	}
+}
+
+func TestTypingPace_DisabledSendsImmediately(t *testing.T) {
+	pace := NewTypingPace(0, 0.5, 30*time.Second)
+	if pace != nil {
+		t.Fatal("Expected no pace when chars per second is 0")
+	}
+
+	typer := &countingTyper{}
+	pace.Wait(typer, "channel-1", "a reply that would otherwise take a while", time.Now())
+	if typer.calls != 0 {
+		t.Errorf("Expected no typing indicator, got %d calls", typer.calls)
+	}
+}
@@ -0,0 +1,154 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// ============================================================================
+// Agent Operations
+// ============================================================================
+
+// CloneOptions controls what CloneAgent copies beyond identity, config and core memory
+type CloneOptions struct {
+	Name            string // Name for the clone; defaults to the source agent's name
+	IncludeFacts    bool   // Copy facts along with their topic and user links
+	IncludeArchival bool   // Copy archival memories
+}
+
+// CloneAgent creates newID as a copy of sourceID. Every copied node gets a
+// fresh ID, so changes to the clone never touch the source. The copy is made
+// in one transaction, so a failure part way leaves no partial clone behind.
+func (r *Repository) CloneAgent(ctx context.Context, sourceID, newID string, opts CloneOptions) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return nil, cloneAgentTx(ctx, tx, sourceID, newID, opts)
+	})
+	if err != nil {
+		return err
+	}
+
+	r.logger.Info("Agent cloned",
+		zap.String("source_id", sourceID),
+		zap.String("agent_id", newID),
+		zap.Bool("facts", opts.IncludeFacts),
+		zap.Bool("archival", opts.IncludeArchival),
+	)
+	return nil
+}
+
+// cloneAgentTx runs CloneAgent's writes inside tx
+func cloneAgentTx(ctx context.Context, tx neo4j.ManagedTransaction, sourceID, newID string, opts CloneOptions) error {
+	params := map[string]interface{}{
+		"sourceID": sourceID,
+		"newID":    newID,
+		"name":     opts.Name,
+	}
+
+	// Create the agent node, copying config; fails the checks before writing anything
+	agentQuery := `
+		OPTIONAL MATCH (src:Agent {id: $sourceID})
+		OPTIONAL MATCH (existing:Agent {id: $newID})
+		WITH src, existing
+		FOREACH (_ IN CASE WHEN src IS NOT NULL AND existing IS NULL THEN [1] ELSE [] END |
+			CREATE (:Agent {
+				id: $newID,
+				name: CASE WHEN $name <> '' THEN $name ELSE src.name END,
+				model: src.model,
+				auxiliary_provider: src.auxiliary_provider,
+				auxiliary_model: src.auxiliary_model,
+				embedding_provider: src.embedding_provider,
+				embedding_model: src.embedding_model,
+				moderation_provider: src.moderation_provider,
+				moderation_model: src.moderation_model,
+				max_tokens: src.max_tokens,
+				system_instructions: src.system_instructions,
+				persona_check: src.persona_check,
+				content_filter: src.content_filter,
+				content_filter_action: src.content_filter_action,
+				allowed_tools: src.allowed_tools,
+				denied_tools: src.denied_tools,
+				image_style_preset: src.image_style_preset,
+				cloned_from: $sourceID,
+				created_at: datetime()
+			})
+		)
+		RETURN src IS NOT NULL as source_exists, existing IS NOT NULL as target_exists
+	`
+	result, err := tx.Run(ctx, agentQuery, params)
+	if err != nil {
+		return fmt.Errorf("failed to clone agent: %w", err)
+	}
+	record, err := result.Single(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to verify agent clone: %w", err)
+	}
+	if !getBoolFromRecord(record, "source_exists") {
+		return ErrAgentNotFound{AgentID: sourceID}
+	}
+	if getBoolFromRecord(record, "target_exists") {
+		return ErrAgentExists{AgentID: newID}
+	}
+
+	// Identity and core memory blocks
+	identityQuery := `
+		MATCH (src:Agent {id: $sourceID})-[:HAS_IDENTITY]->(sid:AgentIdentity)
+		MATCH (a:Agent {id: $newID})
+		CREATE (a)-[:HAS_IDENTITY]->(nid:AgentIdentity)
+		SET nid = properties(sid),
+		    nid.name = CASE WHEN $name <> '' THEN $name ELSE sid.name END
+	`
+	if _, err := tx.Run(ctx, identityQuery, params); err != nil {
+		return fmt.Errorf("failed to clone agent identity: %w", err)
+	}
+
+	memoryQuery := `
+		MATCH (src:Agent {id: $sourceID})-[:HAS_MEMORY]->(m:Memory)
+		MATCH (a:Agent {id: $newID})
+		CREATE (a)-[:HAS_MEMORY]->(nm:Memory)
+		SET nm = properties(m)
+	`
+	if _, err := tx.Run(ctx, memoryQuery, params); err != nil {
+		return fmt.Errorf("failed to clone memory blocks: %w", err)
+	}
+
+	if opts.IncludeFacts {
+		factsQuery := `
+			MATCH (src:Agent {id: $sourceID})-[:KNOWS_FACT]->(f:Fact)
+			MATCH (a:Agent {id: $newID})
+			CREATE (a)-[:KNOWS_FACT]->(nf:Fact)
+			SET nf = properties(f),
+			    nf.id = randomUUID(),
+			    nf.agent_id = $newID
+			WITH f, nf
+			OPTIONAL MATCH (f)-[:ABOUT]->(t:Topic)
+			FOREACH (_ IN CASE WHEN t IS NULL THEN [] ELSE [1] END | MERGE (nf)-[:ABOUT]->(t))
+			WITH DISTINCT f, nf
+			OPTIONAL MATCH (u:User)-[:TOLD_ME]->(f)
+			FOREACH (_ IN CASE WHEN u IS NULL THEN [] ELSE [1] END | MERGE (u)-[:TOLD_ME]->(nf))
+		`
+		if _, err := tx.Run(ctx, factsQuery, params); err != nil {
+			return fmt.Errorf("failed to clone facts: %w", err)
+		}
+	}
+
+	if opts.IncludeArchival {
+		archivalQuery := `
+			MATCH (src:Agent {id: $sourceID})-[:HAS_ARCHIVAL]->(arch:Archival)
+			MATCH (a:Agent {id: $newID})
+			CREATE (a)-[:HAS_ARCHIVAL]->(na:Archival)
+			SET na = properties(arch),
+			    na.id = randomUUID()
+		`
+		if _, err := tx.Run(ctx, archivalQuery, params); err != nil {
+			return fmt.Errorf("failed to clone archival memories: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,324 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// Archival relevance scoring. A score mixes how recent the archive is, how
+// long and specific its content is, and how often it has been accessed.
+const (
+	archivalRecencyHalfLife = 30 * 24 * time.Hour // Age at which the recency part halves
+	archivalAccessHalfLife  = 14 * 24 * time.Hour // Time since the last access at which the access part halves
+	archivalFullLength      = 1000                // Characters of content that count as fully detailed
+	archivalRescoreBatch    = 500                 // Archives rescored per page
+
+	archivalRecencyWeight = 0.35
+	archivalContentWeight = 0.45
+	archivalAccessWeight  = 0.2
+)
+
+// ArchivalScoreInputs are what an archival memory's automatic relevance
+// score is computed from, returned alongside it for transparency
+type ArchivalScoreInputs struct {
+	Auto           bool       `json:"auto"`         // False once relevance_score was set by hand; it is then never recomputed
+	AgeDays        float64    `json:"age_days"`     // Days since the archive's timestamp
+	Recency        float64    `json:"recency"`      // 0-1, halving every 30 days of age
+	Length         int        `json:"length"`       // Characters of content, or of the summary without content
+	Specificity    float64    `json:"specificity"`  // 0-1 share of names, numbers and long words
+	AccessCount    int        `json:"access_count"` // Times a memory search returned the archive
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	Access         float64    `json:"access"` // 0-1, growing with accesses and halving 14 days after the last one
+}
+
+// SetArchivalScoring sets how archival memories stored without a
+// relevance_score are scored: computed from their content, age and use
+// when auto is true, or given defaultRelevance otherwise. Values of
+// defaultRelevance outside 0-1 are ignored.
+func (r *Repository) SetArchivalScoring(auto bool, defaultRelevance float64) {
+	r.archivalAutoScore = auto
+	if defaultRelevance >= 0 && defaultRelevance <= 1 {
+		r.archivalDefaultRelevance = defaultRelevance
+	}
+}
+
+// scoreArchival computes an archival memory's relevance score at now,
+// rounded to three decimals, and the inputs it came from
+func scoreArchival(memory ArchivalMemory, accessCount int, lastAccessedAt *time.Time, now time.Time) (float64, ArchivalScoreInputs) {
+	text := memory.Content
+	if strings.TrimSpace(text) == "" {
+		text = memory.Summary
+	}
+	inputs := ArchivalScoreInputs{
+		Auto:           true,
+		Length:         len([]rune(text)),
+		Specificity:    archivalSpecificity(text),
+		AccessCount:    accessCount,
+		LastAccessedAt: lastAccessedAt,
+	}
+
+	age := now.Sub(memory.Timestamp)
+	if age < 0 {
+		age = 0
+	}
+	inputs.AgeDays = math.Round(age.Hours()/24*10) / 10
+	inputs.Recency = DecayedConfidence(1, age, archivalRecencyHalfLife)
+	if accessCount > 0 && lastAccessedAt != nil {
+		inputs.Access = math.Min(math.Log2(float64(1+accessCount))/4, 1) *
+			DecayedConfidence(1, now.Sub(*lastAccessedAt), archivalAccessHalfLife)
+	}
+
+	detail := 0.5*math.Min(float64(inputs.Length)/archivalFullLength, 1) + 0.5*math.Min(inputs.Specificity*2.5, 1)
+	score := archivalRecencyWeight*inputs.Recency + archivalContentWeight*detail + archivalAccessWeight*inputs.Access
+	return math.Round(score*1000) / 1000, inputs
+}
+
+// archivalSpecificity is the share of distinct words that carry specific
+// information: ones with digits, capitalized words after the first, and
+// words of eight or more letters
+func archivalSpecificity(text string) float64 {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := make(map[string]bool)
+	specific := 0
+	for i, word := range words {
+		key := strings.ToLower(word)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		runes := []rune(word)
+		switch {
+		case strings.IndexFunc(word, unicode.IsDigit) >= 0,
+			i > 0 && unicode.IsUpper(runes[0]),
+			len(runes) >= 8:
+			specific++
+		}
+	}
+	if len(seen) == 0 {
+		return 0
+	}
+	return float64(specific) / float64(len(seen))
+}
+
+// initialRelevance fills in the relevance score of an archival memory being
+// stored without one, and reports whether it is automatic (recomputed by
+// RescoreArchivalMemories) rather than set by the caller
+func (r *Repository) initialRelevance(memory *ArchivalMemory, now time.Time) bool {
+	if memory.RelevanceScore != 0 {
+		return false
+	}
+	if r.archivalAutoScore {
+		memory.RelevanceScore, _ = scoreArchival(*memory, 0, nil, now)
+	} else {
+		memory.RelevanceScore = r.archivalDefaultRelevance
+	}
+	return true
+}
+
+// archivalAccess is the accesses to an archive not yet written to it
+type archivalAccess struct {
+	count int
+	last  time.Time
+}
+
+// recordArchivalAccess counts an access to each of the archival memories,
+// which raises their automatic scores at the next rescore. Accesses are
+// held in memory and written by the rescore, so searches stay reads; they
+// aren't counted while automatic scoring is off.
+func (r *Repository) recordArchivalAccess(ids []string) {
+	if !r.archivalAutoScore || len(ids) == 0 {
+		return
+	}
+	now := time.Now()
+	r.archivalAccessMu.Lock()
+	defer r.archivalAccessMu.Unlock()
+	if r.archivalAccess == nil {
+		r.archivalAccess = make(map[string]archivalAccess)
+	}
+	for _, id := range ids {
+		access := r.archivalAccess[id]
+		r.archivalAccess[id] = archivalAccess{count: access.count + 1, last: now}
+	}
+}
+
+// flushArchivalAccess writes the accesses recorded since the last flush.
+// If the write fails they are kept for the next one.
+func (r *Repository) flushArchivalAccess(ctx context.Context, session neo4j.SessionWithContext) error {
+	r.archivalAccessMu.Lock()
+	pending := r.archivalAccess
+	r.archivalAccess = nil
+	r.archivalAccessMu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	accesses := make([]map[string]interface{}, 0, len(pending))
+	for id, access := range pending {
+		accesses = append(accesses, map[string]interface{}{
+			"id":    id,
+			"count": access.count,
+			"at":    access.last.UTC().Format(time.RFC3339),
+		})
+	}
+	_, err := session.Run(ctx, `
+		UNWIND $accesses as access
+		MATCH (arch:Archival {id: access.id})
+		SET arch.access_count = coalesce(arch.access_count, 0) + access.count,
+		    arch.last_accessed_at = datetime(access.at)
+	`, map[string]interface{}{"accesses": accesses})
+	if err != nil {
+		r.archivalAccessMu.Lock()
+		defer r.archivalAccessMu.Unlock()
+		if r.archivalAccess == nil {
+			r.archivalAccess = make(map[string]archivalAccess)
+		}
+		for id, access := range pending {
+			newer := r.archivalAccess[id]
+			access.count += newer.count
+			if newer.last.After(access.last) {
+				access.last = newer.last
+			}
+			r.archivalAccess[id] = access
+		}
+		return fmt.Errorf("failed to record archival memory access: %w", err)
+	}
+	return nil
+}
+
+// RescoreArchivalMemories writes the accesses recorded since the last run,
+// then recomputes the relevance score of every automatically scored
+// archival memory, so scores fade with age and rise with use. Archives from
+// before scoring existed with no score (or 0) are treated as automatic.
+// Archives are read and written in pages of archivalRescoreBatch, by ID.
+// Returns how many were rescored.
+func (r *Repository) RescoreArchivalMemories(ctx context.Context) (int, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	if err := r.flushArchivalAccess(ctx, session); err != nil {
+		return 0, err
+	}
+
+	rescored := 0
+	after := ""
+	for {
+		count, last, err := r.rescoreArchivalPage(ctx, session, after)
+		rescored += count
+		if err != nil || count < archivalRescoreBatch {
+			return rescored, err
+		}
+		after = last
+	}
+}
+
+// rescoreArchivalPage rescores the automatically scored archives with IDs
+// after the given one, up to archivalRescoreBatch of them, and returns how
+// many it rescored and the last ID
+func (r *Repository) rescoreArchivalPage(ctx context.Context, session neo4j.SessionWithContext, after string) (int, string, error) {
+	result, err := session.Run(ctx, `
+		MATCH (arch:Archival)
+		WHERE arch.id > $after
+		  AND (arch.relevance_auto = true
+		       OR (arch.relevance_auto IS NULL AND coalesce(arch.relevance_score, 0) = 0))
+		RETURN arch.id as id, arch.summary as summary, arch.content as content,
+		       arch.timestamp as timestamp, arch.access_count as access_count,
+		       arch.last_accessed_at as last_accessed_at
+		ORDER BY arch.id
+		LIMIT $limit
+	`, map[string]interface{}{
+		"after": after,
+		"limit": archivalRescoreBatch,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	now := time.Now()
+	var scores []map[string]interface{}
+	last := after
+	for result.Next(ctx) {
+		record := result.Record()
+		memory := ArchivalMemory{
+			Summary:   getString(record, "summary", ""),
+			Content:   getString(record, "content", ""),
+			Timestamp: getTimeFromRecord(record, "timestamp", now),
+		}
+		score, _ := scoreArchival(memory, getIntFromRecord(record, "access_count"), lastAccessedAt(record), now)
+		last = getString(record, "id", "")
+		scores = append(scores, map[string]interface{}{"id": last, "score": score})
+	}
+	if err := result.Err(); err != nil {
+		return 0, "", err
+	}
+	if len(scores) == 0 {
+		return 0, last, nil
+	}
+
+	_, err = session.Run(ctx, `
+		UNWIND $scores as s
+		MATCH (arch:Archival {id: s.id})
+		SET arch.relevance_score = s.score, arch.relevance_auto = true
+	`, map[string]interface{}{"scores": scores})
+	if err != nil {
+		return 0, "", err
+	}
+	return len(scores), last, nil
+}
+
+// StartArchivalRescoring runs RescoreArchivalMemories every interval until
+// the returned stop function is called, which also writes the accesses
+// recorded since the last run
+func (r *Repository) StartArchivalRescoring(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				ctx := context.Background()
+				session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+				if err := r.flushArchivalAccess(ctx, session); err != nil {
+					r.logger.Warn("Failed to write archival memory accesses", zap.Error(err))
+				}
+				session.Close(ctx)
+				return
+			case <-ticker.C:
+				rescored, err := r.RescoreArchivalMemories(context.Background())
+				if err != nil {
+					r.logger.Warn("Failed to rescore archival memories", zap.Error(err))
+					continue
+				}
+				r.logger.Debug("Rescored archival memories", zap.Int("count", rescored))
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// lastAccessedAt reads an archive's last_accessed_at, nil if never accessed
+func lastAccessedAt(record *neo4j.Record) *time.Time {
+	t := getTimeFromRecord(record, "last_accessed_at", time.Time{})
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
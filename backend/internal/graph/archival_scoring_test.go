@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreArchival(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	detailed := ArchivalMemory{
+		Content:   strings.Repeat("On 2026-03-02 Maria moved the Berlin deployment to Kubernetes after the outage. ", 15),
+		Timestamp: now,
+	}
+	vague := ArchivalMemory{Content: "we talked about some stuff", Timestamp: now}
+
+	detailedScore, inputs := scoreArchival(detailed, 0, nil, now)
+	vagueScore, _ := scoreArchival(vague, 0, nil, now)
+	assert.Greater(t, detailedScore, vagueScore, "long, specific content ranks higher")
+	assert.True(t, inputs.Auto)
+	assert.InDelta(t, 1.0, inputs.Recency, 1e-9)
+	assert.Greater(t, inputs.Specificity, 0.4)
+
+	older := detailed
+	older.Timestamp = now.Add(-archivalRecencyHalfLife)
+	olderScore, inputs := scoreArchival(older, 0, nil, now)
+	assert.InDelta(t, 0.5, inputs.Recency, 1e-9)
+	assert.InDelta(t, 30.0, inputs.AgeDays, 1e-9)
+	assert.Less(t, olderScore, detailedScore, "scores fade with age")
+
+	accessedAt := now.Add(-time.Hour)
+	accessedScore, inputs := scoreArchival(older, 15, &accessedAt, now)
+	assert.Greater(t, accessedScore, olderScore, "accesses boost the score")
+	assert.Equal(t, 15, inputs.AccessCount)
+	assert.LessOrEqual(t, accessedScore, 1.0)
+}
+
+func TestInitialRelevance(t *testing.T) {
+	now := time.Now()
+	r := &Repository{archivalDefaultRelevance: DefaultArchivalRelevance}
+
+	given := ArchivalMemory{Summary: "notes", RelevanceScore: 0.9, Timestamp: now}
+	assert.False(t, r.initialRelevance(&given, now), "a given score is kept as set by hand")
+	assert.Equal(t, 0.9, given.RelevanceScore)
+
+	unset := ArchivalMemory{Summary: "notes", Timestamp: now}
+	assert.True(t, r.initialRelevance(&unset, now))
+	assert.Equal(t, DefaultArchivalRelevance, unset.RelevanceScore)
+
+	r.SetArchivalScoring(true, 0.2)
+	unset = ArchivalMemory{Summary: "Quarterly review of the Berlin office budget", Timestamp: now}
+	assert.True(t, r.initialRelevance(&unset, now))
+	expected, _ := scoreArchival(unset, 0, nil, now)
+	assert.Equal(t, expected, unset.RelevanceScore)
+	assert.Greater(t, unset.RelevanceScore, 0.0)
+}
+
+func TestRecordArchivalAccess(t *testing.T) {
+	r := &Repository{archivalDefaultRelevance: DefaultArchivalRelevance}
+	r.recordArchivalAccess([]string{"a"})
+	assert.Empty(t, r.archivalAccess, "accesses aren't counted without automatic scoring")
+
+	r.SetArchivalScoring(true, DefaultArchivalRelevance)
+	r.recordArchivalAccess([]string{"a", "b"})
+	r.recordArchivalAccess([]string{"a"})
+	assert.Equal(t, 2, r.archivalAccess["a"].count)
+	assert.Equal(t, 1, r.archivalAccess["b"].count)
+	assert.False(t, r.archivalAccess["a"].last.Before(r.archivalAccess["b"].last))
+}
@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// DefaultCompactKeepRecent is how many of each channel's latest messages
+// compaction leaves in place when the caller doesn't say
+const DefaultCompactKeepRecent = 50
+
+// compactBatchSize is how many messages one archival summary covers
+const compactBatchSize = 100
+
+// ConversationCompaction reports what CompactConversations did
+type ConversationCompaction struct {
+	Channels          int      `json:"channels"`           // Channels that had messages compacted
+	MessagesCompacted int      `json:"messages_compacted"` // Raw messages removed
+	ArchivalSummaries int      `json:"archival_summaries"` // Archival memories created from them
+	ArchiveIDs        []string `json:"archive_ids,omitempty"`
+}
+
+// compactionBatches returns the messages older than the keepRecent latest,
+// split into batches of at most size, oldest first. messages must be in
+// chronological order.
+func compactionBatches(messages []Message, keepRecent, size int) [][]Message {
+	if keepRecent < 0 {
+		keepRecent = 0
+	}
+	if len(messages) <= keepRecent {
+		return nil
+	}
+	old := messages[:len(messages)-keepRecent]
+
+	var batches [][]Message
+	for len(old) > 0 {
+		n := min(size, len(old))
+		batches = append(batches, old[:n])
+		old = old[n:]
+	}
+	return batches
+}
+
+// compactionSummary describes a batch of compacted messages
+func compactionSummary(channelID string, batch []Message) string {
+	first, last := batch[0].Timestamp.UTC(), batch[len(batch)-1].Timestamp.UTC()
+	return fmt.Sprintf("Conversation in channel %s, %s to %s (%d messages)",
+		channelID, first.Format("2006-01-02 15:04"), last.Format("2006-01-02 15:04"), len(batch))
+}
+
+// CompactConversations moves the agent's older messages out of every channel
+// it has replied in: all but the keepRecent latest of its messages in each
+// channel are stored as archival summaries and removed. Other agents'
+// messages in a shared channel are left alone. Each channel is compacted in its
+// own transaction, so a failure leaves earlier channels compacted.
+func (r *Repository) CompactConversations(ctx context.Context, agentID string, keepRecent int) (*ConversationCompaction, error) {
+	ctx, span := startQuerySpan(ctx, "CompactConversations")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	channelsQuery := `
+		MATCH (a:Agent {id: $agentID})
+		OPTIONAL MATCH (a)-[:SENT]->(:Message)<-[:CONTAINS]-(c:Conversation)
+		RETURN DISTINCT c.channel_id as channel_id
+	`
+	result, err := session.Run(ctx, channelsQuery, map[string]interface{}{"agentID": agentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent channels: %w", err)
+	}
+	agentFound := false
+	var channelIDs []string
+	for result.Next(ctx) {
+		agentFound = true
+		if channelID := getStringFromRecord(result.Record(), "channel_id"); channelID != "" {
+			channelIDs = append(channelIDs, channelID)
+		}
+	}
+	if !agentFound {
+		return nil, ErrAgentNotFound{AgentID: agentID}
+	}
+
+	compaction := &ConversationCompaction{}
+	for _, channelID := range channelIDs {
+		archiveIDs, compacted, err := r.compactChannel(ctx, session, agentID, channelID, keepRecent)
+		if err != nil {
+			return nil, err
+		}
+		if compacted == 0 {
+			continue
+		}
+		compaction.Channels++
+		compaction.MessagesCompacted += compacted
+		compaction.ArchivalSummaries += len(archiveIDs)
+		compaction.ArchiveIDs = append(compaction.ArchiveIDs, archiveIDs...)
+		for _, id := range archiveIDs {
+			r.emitMemoryEvent(EventArchivalCreated, agentID, id, "")
+		}
+	}
+
+	r.logger.Info("Conversations compacted",
+		zap.String("agent_id", agentID),
+		zap.Int("channels", compaction.Channels),
+		zap.Int("messages_compacted", compaction.MessagesCompacted),
+		zap.Int("archival_summaries", compaction.ArchivalSummaries),
+	)
+	return compaction, nil
+}
+
+// compactChannel archives and removes the agent's older messages in one channel,
+// returning the archival memories created and how many messages were removed
+func (r *Repository) compactChannel(ctx context.Context, session neo4j.SessionWithContext, agentID, channelID string, keepRecent int) ([]string, int, error) {
+	type outcome struct {
+		archiveIDs []string
+		compacted  int
+	}
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		messagesQuery := `
+			MATCH (c:Conversation {channel_id: $channelID})-[:CONTAINS]->(m:Message)
+			WHERE m.deleted_at IS NULL AND ` + agentMessageFilter + `
+			RETURN m.id as id, m.content as content, m.role as role, m.timestamp as timestamp
+			ORDER BY m.timestamp, m.id
+		`
+		result, err := tx.Run(ctx, messagesQuery, map[string]interface{}{"agentID": agentID, "channelID": channelID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load conversation: %w", err)
+		}
+		var messages []Message
+		for result.Next(ctx) {
+			record := result.Record()
+			timestamp, _ := record.Get("timestamp")
+			messages = append(messages, Message{
+				ID:        getStringFromRecord(record, "id"),
+				Content:   getStringFromRecord(record, "content"),
+				Role:      getStringFromRecord(record, "role"),
+				Timestamp: timeFromValue("timestamp", timestamp, time.Time{}),
+			})
+		}
+
+		var out outcome
+		for _, batch := range compactionBatches(messages, keepRecent, compactBatchSize) {
+			archived, err := r.createArchivalMemoryTx(ctx, tx, agentID, ArchivalMemory{
+				Summary:   compactionSummary(channelID, batch),
+				Content:   conversationTranscript(batch),
+				Timestamp: batch[len(batch)-1].Timestamp,
+			}, true)
+			if err != nil {
+				return nil, err
+			}
+
+			ids := make([]string, len(batch))
+			for i, msg := range batch {
+				ids[i] = msg.ID
+			}
+			deleteQuery := `
+				MATCH (c:Conversation {channel_id: $channelID})-[:CONTAINS]->(m:Message)
+				WHERE m.id IN $ids
+				DETACH DELETE m
+			`
+			if _, err := tx.Run(ctx, deleteQuery, map[string]interface{}{"channelID": channelID, "ids": ids}); err != nil {
+				return nil, fmt.Errorf("failed to remove compacted messages: %w", err)
+			}
+			out.archiveIDs = append(out.archiveIDs, archived.ID)
+			out.compacted += len(batch)
+		}
+		return out, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	out := result.(outcome)
+	return out.archiveIDs, out.compacted, nil
+}
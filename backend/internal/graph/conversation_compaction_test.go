@@ -0,0 +1,34 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCompactionBatches(t *testing.T) {
+	messages := make([]Message, 7)
+	for i := range messages {
+		messages[i] = Message{ID: fmt.Sprint(i)}
+	}
+
+	batches := compactionBatches(messages, 2, 3)
+	if len(batches) != 2 || len(batches[0]) != 3 || len(batches[1]) != 2 {
+		t.Fatalf("Expected batches of 3 and 2, got %v", batches)
+	}
+	if batches[0][0].ID != "0" || batches[1][1].ID != "4" {
+		t.Errorf("Expected the oldest five messages, oldest first, got %v", batches)
+	}
+	if got := compactionBatches(messages, 7, 3); got != nil {
+		t.Errorf("Expected nothing to compact when every message is kept, got %v", got)
+	}
+}
+
+func TestCompactionSummary(t *testing.T) {
+	start := time.Date(2026, 1, 2, 9, 30, 0, 0, time.UTC)
+	got := compactionSummary("general", []Message{{Timestamp: start}, {Timestamp: start.Add(2 * time.Hour)}})
+	want := "Conversation in channel general, 2026-01-02 09:30 to 2026-01-02 11:30 (2 messages)"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -3,22 +3,33 @@ package graph
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
 )
 
 // ============================================================================
 // Conversation Operations
 // ============================================================================
 
-// LogMessage logs a message and links it to user and conversation
-func (r *Repository) LogMessage(ctx context.Context, agentID, userID, channelID, content, role, platform string) error {
+// LogMessage logs a message and links it to user and conversation.
+// msgID makes the write idempotent: re-logging a message with the same ID is a no-op.
+// If msgID is empty a new one is generated. platformMsgID is the message's ID
+// on its platform (e.g. the Discord message ID), used to apply later edits and
+// deletes; pass "" when there is none.
+func (r *Repository) LogMessage(ctx context.Context, agentID, userID, channelID, msgID, platformMsgID, content, role, platform string) error {
+	ctx, span := startQuerySpan(ctx, "LogMessage")
+	defer span.End()
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
-	msgID := uuid.New().String()
+	if msgID == "" {
+		msgID = uuid.New().String()
+	}
 	now := time.Now().UTC().Format(time.RFC3339)
 
 	query := `
@@ -27,13 +38,13 @@ func (r *Repository) LogMessage(ctx context.Context, agentID, userID, channelID,
 		MERGE (c:Conversation {channel_id: $channelID})
 		ON CREATE SET c.id = $convID, c.platform = $platform, c.started_at = datetime($now)
 		
-		CREATE (m:Message {
-			id: $msgID,
-			content: $content,
-			role: $role,
-			platform: $platform,
-			timestamp: datetime($now)
-		})
+		MERGE (m:Message {id: $msgID})
+		ON CREATE SET m.content = $content,
+		              m.role = $role,
+		              m.agent_id = $agentID,
+		              m.platform = $platform,
+		              m.platform_message_id = $platformMsgID,
+		              m.timestamp = datetime($now)
 		
 		MERGE (u)-[:PARTICIPATED_IN]->(c)
 		MERGE (c)-[:CONTAINS]->(m)
@@ -47,16 +58,22 @@ func (r *Repository) LogMessage(ctx context.Context, agentID, userID, channelID,
 		)
 	`
 
+	var platformMsgIDParam interface{}
+	if platformMsgID != "" {
+		platformMsgIDParam = platformMsgID
+	}
+
 	_, err := session.Run(ctx, query, map[string]interface{}{
-		"agentID":   agentID,
-		"userID":    userID,
-		"channelID": channelID,
-		"convID":    uuid.New().String(),
-		"msgID":     msgID,
-		"content":   content,
-		"role":      role,
-		"platform":  platform,
-		"now":       now,
+		"agentID":       agentID,
+		"userID":        userID,
+		"channelID":     channelID,
+		"convID":        uuid.New().String(),
+		"msgID":         msgID,
+		"platformMsgID": platformMsgIDParam,
+		"content":       content,
+		"role":          role,
+		"platform":      platform,
+		"now":           now,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to log message: %w", err)
@@ -65,8 +82,105 @@ func (r *Repository) LogMessage(ctx context.Context, agentID, userID, channelID,
 	return nil
 }
 
+// UpdateMessageContent replaces the content of the stored message with the
+// given platform message ID and records when it was edited. Returns false
+// when no live message matches, e.g. one the agent never processed.
+func (r *Repository) UpdateMessageContent(ctx context.Context, platform, platformMsgID, content string) (bool, error) {
+	ctx, span := startQuerySpan(ctx, "UpdateMessageContent")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (m:Message {platform_message_id: $platformMsgID, platform: $platform})
+		WHERE m.deleted_at IS NULL
+		SET m.content = $content,
+		    m.edited_at = datetime()
+		RETURN count(m) as updated
+	`
+
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"platform":      platform,
+		"platformMsgID": platformMsgID,
+		"content":       content,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update message: %w", err)
+	}
+	if !result.Next(ctx) {
+		return false, result.Err()
+	}
+	return getIntFromRecord(result.Record(), "updated") > 0, nil
+}
+
+// DeleteMessageByPlatformID removes the stored message with the given
+// platform message ID from conversation history. By default the message is
+// flagged deleted and hidden from history; hard removes the node entirely.
+// Returns false when no message matches.
+func (r *Repository) DeleteMessageByPlatformID(ctx context.Context, platform, platformMsgID string, hard bool) (bool, error) {
+	ctx, span := startQuerySpan(ctx, "DeleteMessageByPlatformID")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (m:Message {platform_message_id: $platformMsgID, platform: $platform})
+		WHERE m.deleted_at IS NULL
+		SET m.deleted_at = datetime()
+		RETURN count(m) as deleted
+	`
+	if hard {
+		query = `
+			MATCH (m:Message {platform_message_id: $platformMsgID, platform: $platform})
+			DETACH DELETE m
+			RETURN count(m) as deleted
+		`
+	}
+
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"platform":      platform,
+		"platformMsgID": platformMsgID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete message: %w", err)
+	}
+	if !result.Next(ctx) {
+		return false, result.Err()
+	}
+	return getIntFromRecord(result.Record(), "deleted") > 0, nil
+}
+
+// SetMessageAttachments records the URLs of files shared with a logged message
+func (r *Repository) SetMessageAttachments(ctx context.Context, msgID string, urls []string) error {
+	ctx, span := startQuerySpan(ctx, "SetMessageAttachments")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (m:Message {id: $msgID})
+		SET m.attachments = $urls
+	`
+
+	_, err := session.Run(ctx, query, map[string]interface{}{
+		"msgID": msgID,
+		"urls":  urls,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set message attachments: %w", err)
+	}
+
+	return nil
+}
+
 // GetConversationHistory retrieves recent messages from a conversation
 func (r *Repository) GetConversationHistory(ctx context.Context, channelID string, limit int) ([]Message, error) {
+	ctx, span := startQuerySpan(ctx, "GetConversationHistory")
+	defer span.End()
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
@@ -76,8 +190,10 @@ func (r *Repository) GetConversationHistory(ctx context.Context, channelID strin
 
 	query := `
 		MATCH (c:Conversation {channel_id: $channelID})-[:CONTAINS]->(m:Message)
+		WHERE m.deleted_at IS NULL
 		RETURN m.id as id, m.content as content, m.role as role, 
-		       m.platform as platform, m.timestamp as timestamp
+		       m.platform as platform, m.timestamp as timestamp,
+		       coalesce(m.attachments, []) as attachments
 		ORDER BY m.timestamp DESC
 		LIMIT $limit
 	`
@@ -94,10 +210,11 @@ func (r *Repository) GetConversationHistory(ctx context.Context, channelID strin
 	for result.Next(ctx) {
 		record := result.Record()
 		messages = append(messages, Message{
-			ID:       getStringFromRecord(record, "id"),
-			Content:  getStringFromRecord(record, "content"),
-			Role:     getStringFromRecord(record, "role"),
-			Platform: getStringFromRecord(record, "platform"),
+			ID:          getStringFromRecord(record, "id"),
+			Content:     getStringFromRecord(record, "content"),
+			Role:        getStringFromRecord(record, "role"),
+			Platform:    getStringFromRecord(record, "platform"),
+			Attachments: getStringSliceFromRecord(record, "attachments"),
 		})
 	}
 
@@ -109,6 +226,152 @@ func (r *Repository) GetConversationHistory(ctx context.Context, channelID strin
 	return messages, nil
 }
 
+// GetConversationRange returns the latest limit messages of a conversation
+// sent between since and until, in chronological order, with their
+// timestamps and sender names. A zero since or until leaves that end open.
+func (r *Repository) GetConversationRange(ctx context.Context, channelID string, since, until time.Time, limit int) ([]Message, error) {
+	ctx, span := startQuerySpan(ctx, "GetConversationRange")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	if limit < 1 {
+		limit = 100
+	}
+
+	query := `
+		MATCH (c:Conversation {channel_id: $channelID})-[:CONTAINS]->(m:Message)
+		WHERE m.deleted_at IS NULL
+		  AND ($since IS NULL OR m.timestamp >= $since)
+		  AND ($until IS NULL OR m.timestamp <= $until)
+		WITH m
+		ORDER BY m.timestamp DESC, m.id DESC
+		LIMIT $limit
+		OPTIONAL MATCH (sender)-[:SENT]->(m)
+		WITH m, head(collect(coalesce(sender.name, sender.discord_username, sender.id))) as sender_name
+		RETURN m.id as id, m.content as content, m.role as role,
+		       m.platform as platform, m.timestamp as timestamp, sender_name
+		ORDER BY m.timestamp, m.id
+	`
+
+	params := map[string]interface{}{
+		"channelID": channelID,
+		"since":     nil,
+		"until":     nil,
+		"limit":     limit,
+	}
+	if !since.IsZero() {
+		params["since"] = since
+	}
+	if !until.IsZero() {
+		params["until"] = until
+	}
+
+	result, err := session.Run(ctx, query, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation range: %w", err)
+	}
+
+	var messages []Message
+	for result.Next(ctx) {
+		record := result.Record()
+		timestamp, _ := record.Get("timestamp")
+		messages = append(messages, Message{
+			ID:         getStringFromRecord(record, "id"),
+			Content:    getStringFromRecord(record, "content"),
+			Role:       getStringFromRecord(record, "role"),
+			Platform:   getStringFromRecord(record, "platform"),
+			Timestamp:  timeFromValue("timestamp", timestamp, time.Time{}),
+			SenderName: getStringFromRecord(record, "sender_name"),
+		})
+	}
+	return messages, result.Err()
+}
+
+// MessageCursor marks a position in a conversation for paging
+type MessageCursor struct {
+	Timestamp time.Time
+	ID        string
+}
+
+// GetConversationPage returns up to limit of an agent's messages in a
+// conversation (see agentMessageFilter) in chronological order, starting
+// after the cursor (nil starts from the first message). Pass the last
+// message's timestamp and ID to get the next page.
+func (r *Repository) GetConversationPage(ctx context.Context, agentID, channelID string, after *MessageCursor, limit int) ([]Message, error) {
+	ctx, span := startQuerySpan(ctx, "GetConversationPage")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	if limit < 1 {
+		limit = 100
+	}
+
+	// Messages logged in the same second share a timestamp, so the ID breaks ties
+	query := `
+		MATCH (c:Conversation {channel_id: $channelID})-[:CONTAINS]->(m:Message)
+		WHERE m.deleted_at IS NULL AND ` + agentMessageFilter + `
+		  AND ($afterID IS NULL
+		   OR m.timestamp > $afterTimestamp
+		   OR (m.timestamp = $afterTimestamp AND m.id > $afterID))
+		WITH m
+		ORDER BY m.timestamp, m.id
+		LIMIT $limit
+		OPTIONAL MATCH (sender)-[:SENT]->(m)
+		WITH m, head(collect(coalesce(sender.name, sender.discord_username, sender.id))) as sender_name
+		RETURN m.id as id, m.content as content, m.role as role,
+		       m.platform as platform, m.timestamp as timestamp,
+		       coalesce(m.attachments, []) as attachments, sender_name,
+		       m.edited_at as edited_at
+		ORDER BY m.timestamp, m.id
+	`
+
+	params := map[string]interface{}{
+		"agentID":        agentID,
+		"channelID":      channelID,
+		"afterID":        nil,
+		"afterTimestamp": nil,
+		"limit":          limit,
+	}
+	if after != nil {
+		params["afterID"] = after.ID
+		params["afterTimestamp"] = after.Timestamp
+	}
+
+	result, err := session.Run(ctx, query, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation page: %w", err)
+	}
+
+	var messages []Message
+	for result.Next(ctx) {
+		record := result.Record()
+		timestamp, _ := record.Get("timestamp")
+		msg := Message{
+			ID:          getStringFromRecord(record, "id"),
+			Content:     getStringFromRecord(record, "content"),
+			Role:        getStringFromRecord(record, "role"),
+			Platform:    getStringFromRecord(record, "platform"),
+			Timestamp:   timeFromValue("timestamp", timestamp, time.Time{}),
+			Attachments: getStringSliceFromRecord(record, "attachments"),
+			SenderName:  getStringFromRecord(record, "sender_name"),
+		}
+		if editedAt, ok := record.Get("edited_at"); ok && editedAt != nil {
+			edited := timeFromValue("edited_at", editedAt, time.Time{})
+			msg.EditedAt = &edited
+		}
+		messages = append(messages, msg)
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read conversation page: %w", err)
+	}
+
+	return messages, nil
+}
+
 // LogMessageWithThreading logs a message with threading support
 func (r *Repository) LogMessageWithThreading(ctx context.Context, agentID, userID, channelID, content, role, platform string, replyToMessageID string, mentionedUserIDs []string) error {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
@@ -125,6 +388,7 @@ func (r *Repository) LogMessageWithThreading(ctx context.Context, agentID, userI
 		
 		CREATE (m:Message {
 			id: $msgID,
+			agent_id: $agentID,
 			content: $content,
 			role: $role,
 			platform: $platform,
@@ -188,3 +452,107 @@ func (r *Repository) LogMessageWithThreading(ctx context.Context, agentID, userI
 	return nil
 }
 
+// agentMessageFilter is a Cypher predicate matching the messages m of
+// conversation c that belong to agent $agentID. Conversations are shared by
+// every agent in a channel, so only messages stamped with the agent's ID
+// match, plus unstamped older ones the agent sent, or user messages in a
+// conversation no other agent has posted in.
+const agentMessageFilter = `(m.agent_id = $agentID OR (m.agent_id IS NULL AND (
+	EXISTS { MATCH (:Agent {id: $agentID})-[:SENT]->(m) } OR
+	(coalesce(m.role, '') <> 'agent' AND NOT EXISTS {
+		MATCH (c)-[:CONTAINS]->(other:Message)
+		WHERE other.agent_id <> $agentID OR EXISTS { MATCH (a:Agent)-[:SENT]->(other) WHERE a.id <> $agentID }
+	}))))`
+
+// ResetConversation clears an agent's messages from a channel's
+// conversation so the agent starts fresh there; other agents' messages in
+// the channel are kept. Facts, memory blocks and archival memories are left
+// alone. When archive is set, the cleared transcript is first stored as an
+// archival memory in the same transaction.
+func (r *Repository) ResetConversation(ctx context.Context, agentID, channelID string, archive bool) (*ConversationReset, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		reset := &ConversationReset{ChannelID: channelID}
+
+		messagesQuery := `
+			MATCH (c:Conversation {channel_id: $channelID})-[:CONTAINS]->(m:Message)
+			WHERE m.deleted_at IS NULL AND ` + agentMessageFilter + `
+			RETURN m.content as content, m.role as role
+			ORDER BY m.timestamp
+		`
+		result, err := tx.Run(ctx, messagesQuery, map[string]interface{}{
+			"agentID":   agentID,
+			"channelID": channelID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load conversation: %w", err)
+		}
+		var messages []Message
+		for result.Next(ctx) {
+			record := result.Record()
+			messages = append(messages, Message{
+				Content: getStringFromRecord(record, "content"),
+				Role:    getStringFromRecord(record, "role"),
+			})
+		}
+		if len(messages) == 0 {
+			return reset, nil
+		}
+
+		if archive {
+			outcome, err := r.createArchivalMemoryTx(ctx, tx, agentID, ArchivalMemory{
+				Summary:   fmt.Sprintf("Conversation in channel %s before it was reset", channelID),
+				Content:   conversationTranscript(messages),
+				Timestamp: time.Now(),
+			}, true)
+			if err != nil {
+				return nil, err
+			}
+			reset.ArchiveID = outcome.ID
+		}
+
+		deleteQuery := `
+			MATCH (c:Conversation {channel_id: $channelID})-[:CONTAINS]->(m:Message)
+			WHERE ` + agentMessageFilter + `
+			DETACH DELETE m
+			RETURN count(m) as cleared
+		`
+		result, err = tx.Run(ctx, deleteQuery, map[string]interface{}{
+			"agentID":   agentID,
+			"channelID": channelID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to clear conversation: %w", err)
+		}
+		if result.Next(ctx) {
+			reset.MessagesCleared = getIntFromRecord(result.Record(), "cleared")
+		}
+		return reset, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reset := result.(*ConversationReset)
+	if reset.ArchiveID != "" {
+		r.emitMemoryEvent(EventArchivalCreated, agentID, reset.ArchiveID, "")
+	}
+	r.logger.Info("Conversation reset",
+		zap.String("agent_id", agentID),
+		zap.String("channel_id", channelID),
+		zap.Int("messages_cleared", reset.MessagesCleared),
+		zap.Bool("archived", reset.ArchiveID != ""),
+	)
+	return reset, nil
+}
+
+// conversationTranscript renders messages as "role: content" lines
+func conversationTranscript(messages []Message) string {
+	lines := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		lines = append(lines, fmt.Sprintf("%s: %s", msg.Role, msg.Content))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -21,6 +21,13 @@ func deduplicateFacts(facts []Fact) []Fact {
 	for _, fact := range facts {
 		// Normalize content for comparison
 		normalized := normalizeFactContent(fact.Content)
+
+		// Pinned facts are always kept
+		if fact.Pinned {
+			seen[normalized] = true
+			unique = append(unique, fact)
+			continue
+		}
 		
 		// Check for exact duplicates
 		if seen[normalized] {
@@ -102,6 +109,88 @@ func areFactsSimilar(content1, content2 string) bool {
 	return false
 }
 
+// FactsToPrune picks which facts to delete from groups of duplicate fact IDs.
+// Pinned facts are never pruned. A group containing a pinned fact keeps only
+// its pinned facts; otherwise the first fact in the group is kept.
+func FactsToPrune(groups [][]string, facts []Fact) []string {
+	pinned := make(map[string]bool)
+	for _, fact := range facts {
+		if fact.Pinned {
+			pinned[fact.ID] = true
+		}
+	}
+
+	var prune []string
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+
+		hasPinned := false
+		for _, id := range group {
+			if pinned[id] {
+				hasPinned = true
+				break
+			}
+		}
+
+		for i, id := range group {
+			if pinned[id] || (!hasPinned && i == 0) {
+				continue
+			}
+			prune = append(prune, id)
+		}
+	}
+
+	return prune
+}
+
+// areArchivalMemoriesSimilar reports whether two archival entries describe the
+// same thing, comparing summaries and falling back to content
+func areArchivalMemoriesSimilar(a, b ArchivalMemory) bool {
+	if a.Summary != "" && b.Summary != "" {
+		s1, s2 := normalizeFactContent(a.Summary), normalizeFactContent(b.Summary)
+		if s1 == s2 || areFactsSimilar(s1, s2) {
+			return true
+		}
+	}
+	if a.Content != "" && b.Content != "" {
+		c1, c2 := normalizeFactContent(a.Content), normalizeFactContent(b.Content)
+		if c1 == c2 || areFactsSimilar(c1, c2) {
+			return true
+		}
+	}
+	return false
+}
+
+// archivalCandidateTerms are the lowercase words of an archival memory's
+// summary and content that any near duplicate of it contains: those longer
+// than three characters, as areFactsSimilar compares, or the whole text when
+// it has none
+func archivalCandidateTerms(memory ArchivalMemory) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, text := range []string{memory.Summary, memory.Content} {
+		normalized := normalizeFactContent(text)
+		words := strings.Fields(normalized)
+		found := false
+		for _, word := range words {
+			if len(word) > 3 {
+				found = true
+				if !seen[word] {
+					seen[word] = true
+					terms = append(terms, word)
+				}
+			}
+		}
+		if !found && normalized != "" && !seen[normalized] {
+			seen[normalized] = true
+			terms = append(terms, normalized)
+		}
+	}
+	return terms
+}
+
 // min returns the minimum of two integers
 func min(a, b int) int {
 	if a < b {
@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFactsToPrune_PinnedSurvives(t *testing.T) {
+	facts := []Fact{
+		{ID: "recent", Content: "Allergic to peanuts"},
+		{ID: "pinned", Content: "Is allergic to peanuts", Pinned: true},
+		{ID: "other", Content: "Likes hiking"},
+		{ID: "other-dup", Content: "Enjoys hiking"},
+	}
+	groups := [][]string{
+		{"recent", "pinned"},
+		{"other", "other-dup"},
+	}
+
+	prune := FactsToPrune(groups, facts)
+
+	pruned := make(map[string]bool)
+	for _, id := range prune {
+		pruned[id] = true
+	}
+	if pruned["pinned"] {
+		t.Error("Pinned fact must never be pruned")
+	}
+	if !pruned["recent"] {
+		t.Error("Expected unpinned duplicate of a pinned fact to be pruned")
+	}
+	if pruned["other"] || !pruned["other-dup"] {
+		t.Errorf("Expected first fact of an unpinned group to be kept, got %v", prune)
+	}
+}
+
+func TestDeduplicateFacts_KeepsPinned(t *testing.T) {
+	facts := []Fact{
+		{ID: "1", Content: "Allergic to peanuts"},
+		{ID: "2", Content: "allergic to peanuts.", Pinned: true},
+	}
+
+	unique := deduplicateFacts(facts)
+
+	found := false
+	for _, fact := range unique {
+		if fact.ID == "2" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected pinned fact to survive deduplication")
+	}
+}
+
+func TestNearDuplicateFacts(t *testing.T) {
+	existing := []string{"Loves hiking in the mountains"}
+	inputs := []FactInput{
+		{Content: "loves hiking in the mountains."}, // identical once normalized: merged, not skipped
+		{Content: "Loves hiking in the mountains a lot"},
+		{Content: "Plays the bass guitar"},
+		{Content: "Plays the bass guitar well"},
+	}
+
+	skip := nearDuplicateFacts(existing, inputs)
+
+	expected := []bool{false, true, false, true}
+	for i := range expected {
+		if skip[i] != expected[i] {
+			t.Errorf("Input %d: expected skip=%v, got %v", i, expected[i], skip[i])
+		}
+	}
+}
+
+func TestAreArchivalMemoriesSimilar(t *testing.T) {
+	existing := ArchivalMemory{
+		Summary: "Conversation summary: planning the team offsite in Lisbon",
+		Content: "We discussed dates, flights and the hotel.",
+	}
+
+	tests := []struct {
+		name     string
+		memory   ArchivalMemory
+		expected bool
+	}{
+		{"near-identical summary", ArchivalMemory{Summary: "conversation summary: Planning the team offsite in Lisbon."}, true},
+		{"same content, new summary", ArchivalMemory{Summary: "Offsite logistics", Content: "We discussed dates, flights and the hotel."}, true},
+		{"different conversation", ArchivalMemory{Summary: "Conversation summary: debugging the flaky CI pipeline"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := areArchivalMemoriesSimilar(existing, tt.memory); got != tt.expected {
+				t.Errorf("Expected similar=%v, got %v", tt.expected, got)
+			}
+			if tt.expected && !sharesCandidateTerm(existing, tt.memory) {
+				t.Errorf("Expected the duplicate to be among the candidates of %v", archivalCandidateTerms(tt.memory))
+			}
+		})
+	}
+}
+
+// sharesCandidateTerm reports whether the candidates query would load
+// existing when checking memory for a duplicate
+func sharesCandidateTerm(existing, memory ArchivalMemory) bool {
+	text := strings.ToLower(existing.Summary + " " + existing.Content)
+	for _, term := range archivalCandidateTerms(memory) {
+		if strings.Contains(text, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestArchivalCandidateTerms(t *testing.T) {
+	tests := []struct {
+		name     string
+		memory   ArchivalMemory
+		expected []string
+	}{
+		{"longer words of both", ArchivalMemory{Summary: "Team offsite in Lisbon.", Content: "The team booked the Lisbon hotel"},
+			[]string{"team", "offsite", "lisbon", "booked", "hotel"}},
+		{"no longer words", ArchivalMemory{Summary: "Go to a gig!"}, []string{"go to a gig"}},
+		{"empty", ArchivalMemory{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := archivalCandidateTerms(tt.memory); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected terms %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
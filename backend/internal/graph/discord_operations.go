@@ -176,3 +176,67 @@ func (r *Repository) LinkUserToGuild(ctx context.Context, userID, guildID string
 	return nil
 }
 
+
+// GetBotPresence returns the stored bot presence, or nil if none was set
+func (r *Repository) GetBotPresence(ctx context.Context) (*BotPresence, error) {
+	ctx, span := startQuerySpan(ctx, "GetBotPresence")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (p:BotPresence {platform: 'discord'})
+		RETURN p.status as status, p.activity_type as activity_type,
+		       p.text as text, p.updated_at as updated_at
+	`
+
+	result, err := session.Run(ctx, query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bot presence: %w", err)
+	}
+
+	if !result.Next(ctx) {
+		return nil, nil
+	}
+	record := result.Record()
+	updatedAt, _ := record.Get("updated_at")
+	return &BotPresence{
+		Status:       getStringFromRecord(record, "status"),
+		ActivityType: getStringFromRecord(record, "activity_type"),
+		Text:         getStringFromRecord(record, "text"),
+		UpdatedAt:    timeFromValue("updated_at", updatedAt, time.Time{}),
+	}, nil
+}
+
+// SetBotPresence stores the presence the bot should apply, replacing any
+// previous one
+func (r *Repository) SetBotPresence(ctx context.Context, presence BotPresence) (*BotPresence, error) {
+	ctx, span := startQuerySpan(ctx, "SetBotPresence")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	presence.UpdatedAt = time.Now().UTC()
+
+	query := `
+		MERGE (p:BotPresence {platform: 'discord'})
+		SET p.status = $status,
+		    p.activity_type = $activityType,
+		    p.text = $text,
+		    p.updated_at = datetime($updatedAt)
+	`
+
+	_, err := session.Run(ctx, query, map[string]interface{}{
+		"status":       presence.Status,
+		"activityType": presence.ActivityType,
+		"text":         presence.Text,
+		"updatedAt":    presence.UpdatedAt.Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set bot presence: %w", err)
+	}
+
+	return &presence, nil
+}
@@ -0,0 +1,296 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultEmbeddingBatchSize is how many nodes are embedded per request
+const DefaultEmbeddingBatchSize = 64
+
+// ErrReindexRunning is returned when a reindex is started while one is running
+var ErrReindexRunning = errors.New("an embedding reindex is already running")
+
+// Reindex statuses
+const (
+	ReindexIdle      = "idle"
+	ReindexRunning   = "running"
+	ReindexCompleted = "completed"
+	ReindexFailed    = "failed"
+)
+
+// Embedder turns texts into embedding vectors with a provider's model, the
+// default provider when provider is empty; *adapter.LLMAdapter implements it
+type Embedder interface {
+	Embed(ctx context.Context, provider, model string, texts []string) ([][]float32, error)
+}
+
+// EmbeddingStore reads and writes node embeddings; *Repository implements it
+type EmbeddingStore interface {
+	ListAgentEmbeddingModels(ctx context.Context) ([]AgentEmbeddingModel, error)
+	CountPendingEmbeddings(ctx context.Context, kind EmbeddingKind, scope EmbeddingScope, model string) (int, error)
+	ListPendingEmbeddings(ctx context.Context, kind EmbeddingKind, scope EmbeddingScope, model, afterID string, limit int) ([]EmbeddingItem, error)
+	SetEmbeddings(ctx context.Context, kind EmbeddingKind, model string, updates []EmbeddingUpdate) error
+	EnsureVectorIndex(ctx context.Context, kind EmbeddingKind, dimensions int) error
+}
+
+// ReindexKindProgress is how far a reindex has got through one node kind,
+// for the agents embedded with one provider and model
+type ReindexKindProgress struct {
+	Kind     EmbeddingKind `json:"kind"`
+	Provider string        `json:"provider,omitempty"` // Empty for the default provider
+	Model    string        `json:"model"`
+	Agents   []string      `json:"agents,omitempty"` // Agents with this provider and model; empty for the reindexer's own, which covers the rest
+	Total    int           `json:"total"`            // Nodes needing an embedding when the reindex started
+	Done     int           `json:"done"`             // Nodes embedded so far
+	LastID   string        `json:"last_id"`          // ID of the last node embedded
+}
+
+// embeddingGroup is a set of agents whose nodes are embedded with the same
+// provider and model
+type embeddingGroup struct {
+	provider string
+	model    string
+	scope    EmbeddingScope
+}
+
+// ReindexProgress reports the state of the current or most recent reindex
+type ReindexProgress struct {
+	Status     string                `json:"status"`
+	Model      string                `json:"model"`
+	Kinds      []ReindexKindProgress `json:"kinds"`
+	StartedAt  *time.Time            `json:"started_at,omitempty"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+	Error      string                `json:"error,omitempty"`
+}
+
+// EmbeddingReindexer recomputes the embeddings of facts and archival memories
+// in batches. Only nodes without an embedding from the current model are
+// embedded, so a reindex that was interrupted picks up where it stopped, and
+// changing the model re-embeds everything. Agents that set their own
+// embedding provider or model have their nodes embedded with it; the vector
+// indexes are sized for the reindexer's model, so their embeddings are only
+// indexed when the sizes match.
+type EmbeddingReindexer struct {
+	store     EmbeddingStore
+	embedder  Embedder
+	model     string
+	batchSize int
+	logger    *zap.Logger
+
+	mu       sync.Mutex
+	progress ReindexProgress
+}
+
+// NewEmbeddingReindexer creates a reindexer that embeds with model, batchSize
+// nodes at a time
+func NewEmbeddingReindexer(store EmbeddingStore, embedder Embedder, model string, batchSize int, logger *zap.Logger) *EmbeddingReindexer {
+	if batchSize <= 0 {
+		batchSize = DefaultEmbeddingBatchSize
+	}
+	return &EmbeddingReindexer{
+		store:     store,
+		embedder:  embedder,
+		model:     model,
+		batchSize: batchSize,
+		logger:    logger,
+		progress:  ReindexProgress{Status: ReindexIdle, Model: model},
+	}
+}
+
+// Progress returns a snapshot of the current or most recent reindex
+func (x *EmbeddingReindexer) Progress() ReindexProgress {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	progress := x.progress
+	progress.Kinds = append([]ReindexKindProgress(nil), x.progress.Kinds...)
+	return progress
+}
+
+// Start begins a reindex in the background and returns its initial progress
+func (x *EmbeddingReindexer) Start() (ReindexProgress, error) {
+	if err := x.begin(); err != nil {
+		return x.Progress(), err
+	}
+	go x.run(context.Background())
+	return x.Progress(), nil
+}
+
+// Run reindexes and waits for it to finish
+func (x *EmbeddingReindexer) Run(ctx context.Context) error {
+	if err := x.begin(); err != nil {
+		return err
+	}
+	return x.run(ctx)
+}
+
+func (x *EmbeddingReindexer) begin() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.progress.Status == ReindexRunning {
+		return ErrReindexRunning
+	}
+	now := time.Now().UTC()
+	x.progress = ReindexProgress{Status: ReindexRunning, Model: x.model, StartedAt: &now}
+	return nil
+}
+
+func (x *EmbeddingReindexer) run(ctx context.Context) error {
+	x.logger.Info("Embedding reindex started", zap.String("model", x.model))
+
+	err := x.reindexAll(ctx)
+
+	x.mu.Lock()
+	now := time.Now().UTC()
+	x.progress.FinishedAt = &now
+	x.progress.Status = ReindexCompleted
+	if err != nil {
+		x.progress.Status = ReindexFailed
+		x.progress.Error = err.Error()
+	}
+	progress := x.progress
+	x.mu.Unlock()
+
+	if err != nil {
+		x.logger.Error("Embedding reindex failed", zap.String("model", x.model), zap.Error(err))
+		return err
+	}
+	for _, kind := range progress.Kinds {
+		x.logger.Info("Embedding reindex completed",
+			zap.String("model", kind.Model),
+			zap.String("kind", string(kind.Kind)),
+			zap.Int("embedded", kind.Done),
+		)
+	}
+	return nil
+}
+
+func (x *EmbeddingReindexer) reindexAll(ctx context.Context) error {
+	groups, err := x.groups(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Count everything up front so progress has totals from the start
+	type pass struct {
+		group embeddingGroup
+		kind  EmbeddingKind
+	}
+	var passes []pass
+	for _, group := range groups {
+		for _, kind := range EmbeddingKinds {
+			total, err := x.store.CountPendingEmbeddings(ctx, kind, group.scope, group.model)
+			if err != nil {
+				return err
+			}
+			progress := ReindexKindProgress{Kind: kind, Provider: group.provider, Model: group.model, Total: total}
+			if !group.scope.Exclude {
+				progress.Agents = group.scope.AgentIDs
+			}
+			passes = append(passes, pass{group: group, kind: kind})
+			x.mu.Lock()
+			x.progress.Kinds = append(x.progress.Kinds, progress)
+			x.mu.Unlock()
+		}
+	}
+
+	for i, p := range passes {
+		if err := x.reindexKind(ctx, i, p.kind, p.group); err != nil {
+			return fmt.Errorf("failed to reindex %s embeddings with %s: %w", p.kind, p.group.model, err)
+		}
+	}
+	return nil
+}
+
+// groups splits the agents by the provider and model their nodes are
+// embedded with. The first group is the reindexer's own, covering every agent
+// that doesn't pick something else.
+func (x *EmbeddingReindexer) groups(ctx context.Context) ([]embeddingGroup, error) {
+	choices, err := x.store.ListAgentEmbeddingModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []embeddingGroup{{model: x.model, scope: EmbeddingScope{Exclude: true}}}
+	byChoice := make(map[[2]string]int)
+	for _, choice := range choices {
+		model := choice.Model
+		if model == "" {
+			model = x.model
+		}
+		if choice.Provider == "" && model == x.model {
+			continue
+		}
+		key := [2]string{choice.Provider, model}
+		i, ok := byChoice[key]
+		if !ok {
+			i = len(groups)
+			byChoice[key] = i
+			groups = append(groups, embeddingGroup{provider: choice.Provider, model: model})
+		}
+		groups[i].scope.AgentIDs = append(groups[i].scope.AgentIDs, choice.AgentID)
+		groups[0].scope.AgentIDs = append(groups[0].scope.AgentIDs, choice.AgentID)
+	}
+	return groups, nil
+}
+
+// reindexKind embeds the pending nodes of kind in group's scope batch by
+// batch. index is the pass's position in progress.Kinds.
+func (x *EmbeddingReindexer) reindexKind(ctx context.Context, index int, kind EmbeddingKind, group embeddingGroup) error {
+	// Only the reindexer's own model sizes the index
+	indexed := !group.scope.Exclude
+	afterID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		items, err := x.store.ListPendingEmbeddings(ctx, kind, group.scope, group.model, afterID, x.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		texts := make([]string, len(items))
+		for i, item := range items {
+			texts[i] = item.Text
+		}
+		vectors, err := x.embedder.Embed(ctx, group.provider, group.model, texts)
+		if err != nil {
+			return err
+		}
+		if len(vectors) != len(items) {
+			return fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(items))
+		}
+
+		// The index size comes from the model, so it's only known once
+		// something has been embedded
+		if !indexed {
+			if err := x.store.EnsureVectorIndex(ctx, kind, len(vectors[0])); err != nil {
+				return err
+			}
+			indexed = true
+		}
+
+		updates := make([]EmbeddingUpdate, len(items))
+		for i, item := range items {
+			updates[i] = EmbeddingUpdate{ID: item.ID, Vector: vectors[i]}
+		}
+		if err := x.store.SetEmbeddings(ctx, kind, group.model, updates); err != nil {
+			return err
+		}
+
+		afterID = items[len(items)-1].ID
+		x.mu.Lock()
+		x.progress.Kinds[index].Done += len(items)
+		x.progress.Kinds[index].LastID = afterID
+		x.mu.Unlock()
+	}
+}
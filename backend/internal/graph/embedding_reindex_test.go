@@ -0,0 +1,225 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// memoryEmbeddingStore keeps node texts and embeddings in memory
+type memoryEmbeddingStore struct {
+	texts       map[EmbeddingKind]map[string]string
+	vectors     map[EmbeddingKind]map[string][]float32
+	models      map[EmbeddingKind]map[string]string
+	owners      map[string]string // Agent owning each node ID, if any
+	agentModels []AgentEmbeddingModel
+	indexes     map[EmbeddingKind]int
+}
+
+func newMemoryEmbeddingStore() *memoryEmbeddingStore {
+	store := &memoryEmbeddingStore{
+		texts:   map[EmbeddingKind]map[string]string{},
+		vectors: map[EmbeddingKind]map[string][]float32{},
+		models:  map[EmbeddingKind]map[string]string{},
+		owners:  map[string]string{},
+		indexes: map[EmbeddingKind]int{},
+	}
+	for _, kind := range EmbeddingKinds {
+		store.texts[kind] = map[string]string{}
+		store.vectors[kind] = map[string][]float32{}
+		store.models[kind] = map[string]string{}
+	}
+	return store
+}
+
+func (s *memoryEmbeddingStore) pending(kind EmbeddingKind, scope EmbeddingScope, model string) []string {
+	var ids []string
+	for id := range s.texts[kind] {
+		if s.models[kind][id] != model && slices.Contains(scope.AgentIDs, s.owners[id]) != scope.Exclude {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *memoryEmbeddingStore) ListAgentEmbeddingModels(ctx context.Context) ([]AgentEmbeddingModel, error) {
+	return s.agentModels, nil
+}
+
+func (s *memoryEmbeddingStore) CountPendingEmbeddings(ctx context.Context, kind EmbeddingKind, scope EmbeddingScope, model string) (int, error) {
+	return len(s.pending(kind, scope, model)), nil
+}
+
+func (s *memoryEmbeddingStore) ListPendingEmbeddings(ctx context.Context, kind EmbeddingKind, scope EmbeddingScope, model, afterID string, limit int) ([]EmbeddingItem, error) {
+	var items []EmbeddingItem
+	for _, id := range s.pending(kind, scope, model) {
+		if id > afterID && len(items) < limit {
+			items = append(items, EmbeddingItem{ID: id, Text: s.texts[kind][id]})
+		}
+	}
+	return items, nil
+}
+
+func (s *memoryEmbeddingStore) SetEmbeddings(ctx context.Context, kind EmbeddingKind, model string, updates []EmbeddingUpdate) error {
+	for _, update := range updates {
+		s.vectors[kind][update.ID] = update.Vector
+		s.models[kind][update.ID] = model
+	}
+	return nil
+}
+
+func (s *memoryEmbeddingStore) EnsureVectorIndex(ctx context.Context, kind EmbeddingKind, dimensions int) error {
+	s.indexes[kind] = dimensions
+	return nil
+}
+
+// stubEmbedder embeds a text as its length, failing after failAfter calls
+// when failAfter is set. It records the provider and model of each call.
+type stubEmbedder struct {
+	calls     int
+	embedded  int
+	failAfter int
+	used      []string // "provider/model" per call
+}
+
+func (e *stubEmbedder) Embed(ctx context.Context, provider, model string, texts []string) ([][]float32, error) {
+	e.calls++
+	e.used = append(e.used, provider+"/"+model)
+	if e.failAfter > 0 && e.calls > e.failAfter {
+		return nil, errors.New("embedding service unavailable")
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text)), 1, 0}
+	}
+	e.embedded += len(texts)
+	return vectors, nil
+}
+
+func seedEmbeddingStore() *memoryEmbeddingStore {
+	store := newMemoryEmbeddingStore()
+	for id, text := range map[string]string{
+		"fact-1": "Likes hiking",
+		"fact-2": "Allergic to peanuts",
+		"fact-3": "Works in Go",
+		"fact-4": "Has a cat named Miso",
+		"fact-5": "Lives in Lisbon",
+	} {
+		store.texts[EmbeddingKindFact][id] = text
+	}
+	store.texts[EmbeddingKindArchival]["arch-1"] = "Planning a trip\nTalked about flights to Tokyo"
+	return store
+}
+
+func TestEmbeddingReindexer_EmbedsAllSeededFacts(t *testing.T) {
+	store := seedEmbeddingStore()
+	embedder := &stubEmbedder{}
+	reindexer := NewEmbeddingReindexer(store, embedder, "embed-v1", 2, zap.NewNop())
+
+	if err := reindexer.Run(context.Background()); err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+
+	for id, text := range store.texts[EmbeddingKindFact] {
+		vector := store.vectors[EmbeddingKindFact][id]
+		if len(vector) != 3 || vector[0] != float32(len(text)) {
+			t.Errorf("Expected %s to have its embedding updated, got %v", id, vector)
+		}
+		if store.models[EmbeddingKindFact][id] != "embed-v1" {
+			t.Errorf("Expected %s to record the embedding model, got %q", id, store.models[EmbeddingKindFact][id])
+		}
+	}
+	if _, ok := store.vectors[EmbeddingKindArchival]["arch-1"]; !ok {
+		t.Error("Expected the archival memory to be embedded")
+	}
+	if store.indexes[EmbeddingKindFact] != 3 || store.indexes[EmbeddingKindArchival] != 3 {
+		t.Errorf("Expected 3-dimensional vector indexes, got %v", store.indexes)
+	}
+
+	progress := reindexer.Progress()
+	if progress.Status != ReindexCompleted {
+		t.Errorf("Expected status completed, got %s", progress.Status)
+	}
+	if len(progress.Kinds) != 2 || progress.Kinds[0].Total != 5 || progress.Kinds[0].Done != 5 || progress.Kinds[1].Done != 1 {
+		t.Errorf("Unexpected progress: %+v", progress.Kinds)
+	}
+	if embedder.calls != 4 {
+		t.Errorf("Expected batches of 2 (3 for facts, 1 for archival), got %d calls", embedder.calls)
+	}
+}
+
+func TestEmbeddingReindexer_ResumesAfterFailure(t *testing.T) {
+	store := seedEmbeddingStore()
+	failing := &stubEmbedder{failAfter: 1}
+	reindexer := NewEmbeddingReindexer(store, failing, "embed-v1", 2, zap.NewNop())
+
+	if err := reindexer.Run(context.Background()); err == nil {
+		t.Fatal("Expected the reindex to fail")
+	}
+	if progress := reindexer.Progress(); progress.Status != ReindexFailed || progress.Error == "" {
+		t.Errorf("Expected a failed status with an error, got %+v", progress)
+	}
+
+	// The facts from the first batch are kept; only the rest are embedded again
+	embedder := &stubEmbedder{}
+	reindexer = NewEmbeddingReindexer(store, embedder, "embed-v1", 2, zap.NewNop())
+	if err := reindexer.Run(context.Background()); err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+	if embedder.embedded != 4 {
+		t.Errorf("Expected the 4 remaining nodes to be embedded, got %d", embedder.embedded)
+	}
+
+	// A new model re-embeds everything
+	embedder = &stubEmbedder{}
+	reindexer = NewEmbeddingReindexer(store, embedder, "embed-v2", 2, zap.NewNop())
+	if err := reindexer.Run(context.Background()); err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+	if embedder.embedded != 6 {
+		t.Errorf("Expected a model change to re-embed all 6 nodes, got %d", embedder.embedded)
+	}
+}
+
+func TestEmbeddingReindexer_UsesEachAgentsModel(t *testing.T) {
+	store := seedEmbeddingStore()
+	store.owners["fact-1"] = "Ezra"
+	store.owners["fact-2"] = "Ezra"
+	store.owners["fact-3"] = "Nova"
+	store.owners["arch-1"] = "Nova"
+	store.agentModels = []AgentEmbeddingModel{
+		{AgentID: "Ezra", Provider: "ollama", Model: "nomic-embed-text"},
+		{AgentID: "Nova", Model: "embed-v1"}, // The reindexer's own model
+	}
+	embedder := &stubEmbedder{}
+	reindexer := NewEmbeddingReindexer(store, embedder, "embed-v1", 10, zap.NewNop())
+
+	if err := reindexer.Run(context.Background()); err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+
+	for id, want := range map[string]string{"fact-1": "nomic-embed-text", "fact-2": "nomic-embed-text", "fact-3": "embed-v1", "fact-5": "embed-v1"} {
+		if got := store.models[EmbeddingKindFact][id]; got != want {
+			t.Errorf("Expected %s to be embedded with %s, got %q", id, want, got)
+		}
+	}
+	if store.models[EmbeddingKindArchival]["arch-1"] != "embed-v1" {
+		t.Errorf("Expected the archival memory to use the reindexer's model, got %q", store.models[EmbeddingKindArchival]["arch-1"])
+	}
+	if !slices.Contains(embedder.used, "ollama/nomic-embed-text") || !slices.Contains(embedder.used, "/embed-v1") {
+		t.Errorf("Expected calls to both providers, got %v", embedder.used)
+	}
+
+	progress := reindexer.Progress()
+	if len(progress.Kinds) != 4 || progress.Kinds[0].Total != 3 || progress.Kinds[2].Total != 2 || progress.Kinds[2].Provider != "ollama" {
+		t.Errorf("Unexpected progress: %+v", progress.Kinds)
+	}
+	if len(progress.Kinds[2].Agents) != 1 || progress.Kinds[2].Agents[0] != "Ezra" {
+		t.Errorf("Expected the ollama pass to list its agent, got %+v", progress.Kinds[2])
+	}
+}
@@ -0,0 +1,292 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// EmbeddingKind names a kind of node that carries an embedding
+type EmbeddingKind string
+
+const (
+	EmbeddingKindFact     EmbeddingKind = "fact"
+	EmbeddingKindArchival EmbeddingKind = "archival"
+)
+
+// EmbeddingKinds are the embedded node kinds, in the order they are reindexed
+var EmbeddingKinds = []EmbeddingKind{EmbeddingKindFact, EmbeddingKindArchival}
+
+// embeddingTarget describes where a kind's nodes, text and vector index live
+type embeddingTarget struct {
+	label string // Node label
+	text  string // Cypher expression for the embedded text of node n
+	agent string // Cypher expression for the ID of the agent owning node n
+	index string // Vector index name
+}
+
+var embeddingTargets = map[EmbeddingKind]embeddingTarget{
+	EmbeddingKindFact: {
+		label: "Fact",
+		text:  "trim(coalesce(n.content, ''))",
+		agent: "n.agent_id",
+		index: "fact_embedding",
+	},
+	EmbeddingKindArchival: {
+		label: "Archival",
+		text:  "trim(coalesce(n.summary, '') + '\\n' + coalesce(n.content, ''))",
+		agent: "head([(a:Agent)-[:HAS_ARCHIVAL]->(n) | a.id])",
+		index: "archival_embedding",
+	},
+}
+
+// EmbeddingScope picks the agents whose nodes a reindex pass covers: the
+// listed agents, or with Exclude every agent except them. Nodes without an
+// agent only fall in an Exclude scope.
+type EmbeddingScope struct {
+	AgentIDs []string
+	Exclude  bool
+}
+
+// AgentEmbeddingModel is an agent's own choice of embedding provider and
+// model; an empty field uses the reindexer's
+type AgentEmbeddingModel struct {
+	AgentID  string
+	Provider string
+	Model    string
+}
+
+// EmbeddingItem is a node whose text needs embedding
+type EmbeddingItem struct {
+	ID   string
+	Text string
+}
+
+// EmbeddingUpdate is a new embedding for a node
+type EmbeddingUpdate struct {
+	ID     string
+	Vector []float32
+}
+
+func lookupEmbeddingTarget(kind EmbeddingKind) (embeddingTarget, error) {
+	target, ok := embeddingTargets[kind]
+	if !ok {
+		return embeddingTarget{}, fmt.Errorf("unknown embedding kind %q", kind)
+	}
+	return target, nil
+}
+
+// pendingEmbeddingsMatch matches nodes in $agentIDs (or, with $exclude,
+// outside them) with text that have no embedding from model yet, binding n
+// and text
+func pendingEmbeddingsMatch(target embeddingTarget) string {
+	return fmt.Sprintf(`
+		MATCH (n:%s)
+		WHERE n.id IS NOT NULL AND coalesce(n.embedding_model, '') <> $model
+		WITH n, %s as text, coalesce(%s, '') as agent
+		WHERE text <> '' AND (agent IN $agentIDs) <> $exclude
+	`, target.label, target.text, target.agent)
+}
+
+// scopeParams are the query parameters pendingEmbeddingsMatch reads scope from
+func scopeParams(params map[string]interface{}, scope EmbeddingScope) map[string]interface{} {
+	agentIDs := scope.AgentIDs
+	if agentIDs == nil {
+		agentIDs = []string{}
+	}
+	params["agentIDs"] = agentIDs
+	params["exclude"] = scope.Exclude
+	return params
+}
+
+// CountPendingEmbeddings counts kind's nodes in scope that still need an
+// embedding from model
+func (r *Repository) CountPendingEmbeddings(ctx context.Context, kind EmbeddingKind, scope EmbeddingScope, model string) (int, error) {
+	ctx, span := startQuerySpan(ctx, "CountPendingEmbeddings")
+	defer span.End()
+
+	target, err := lookupEmbeddingTarget(kind)
+	if err != nil {
+		return 0, err
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	query := pendingEmbeddingsMatch(target) + `RETURN count(n) as count`
+	result, err := session.Run(ctx, query, scopeParams(map[string]interface{}{"model": model}, scope))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending embeddings: %w", err)
+	}
+	record, err := result.Single(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending embeddings: %w", err)
+	}
+	return getIntFromRecord(record, "count"), nil
+}
+
+// ListPendingEmbeddings returns up to limit of kind's nodes in scope that
+// still need an embedding from model, ordered by ID and starting after afterID
+func (r *Repository) ListPendingEmbeddings(ctx context.Context, kind EmbeddingKind, scope EmbeddingScope, model, afterID string, limit int) ([]EmbeddingItem, error) {
+	ctx, span := startQuerySpan(ctx, "ListPendingEmbeddings")
+	defer span.End()
+
+	target, err := lookupEmbeddingTarget(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	query := pendingEmbeddingsMatch(target) + `
+		AND n.id > $afterID
+		RETURN n.id as id, text
+		ORDER BY n.id
+		LIMIT $limit
+	`
+	result, err := session.Run(ctx, query, scopeParams(map[string]interface{}{
+		"model":   model,
+		"afterID": afterID,
+		"limit":   limit,
+	}, scope))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending embeddings: %w", err)
+	}
+
+	var items []EmbeddingItem
+	for result.Next(ctx) {
+		record := result.Record()
+		items = append(items, EmbeddingItem{
+			ID:   getStringFromRecord(record, "id"),
+			Text: getStringFromRecord(record, "text"),
+		})
+	}
+	return items, nil
+}
+
+// SetEmbeddings stores new embeddings on kind's nodes, recording the model
+// that produced them
+func (r *Repository) SetEmbeddings(ctx context.Context, kind EmbeddingKind, model string, updates []EmbeddingUpdate) error {
+	ctx, span := startQuerySpan(ctx, "SetEmbeddings")
+	defer span.End()
+
+	target, err := lookupEmbeddingTarget(kind)
+	if err != nil {
+		return err
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	rows := make([]map[string]interface{}, 0, len(updates))
+	for _, update := range updates {
+		vector := make([]float64, len(update.Vector))
+		for i, v := range update.Vector {
+			vector[i] = float64(v)
+		}
+		rows = append(rows, map[string]interface{}{"id": update.ID, "embedding": vector})
+	}
+
+	query := fmt.Sprintf(`
+		UNWIND $rows as row
+		MATCH (n:%s {id: row.id})
+		SET n.embedding = row.embedding, n.embedding_model = $model
+	`, target.label)
+	_, err = session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		_, err := tx.Run(ctx, query, map[string]interface{}{"rows": rows, "model": model})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store embeddings: %w", err)
+	}
+	return nil
+}
+
+// EnsureVectorIndex creates kind's cosine vector index for embeddings of the
+// given size. An index built for a different size, left by an earlier
+// embedding model, is dropped and recreated.
+func (r *Repository) EnsureVectorIndex(ctx context.Context, kind EmbeddingKind, dimensions int) error {
+	ctx, span := startQuerySpan(ctx, "EnsureVectorIndex")
+	defer span.End()
+
+	target, err := lookupEmbeddingTarget(kind)
+	if err != nil {
+		return err
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	showQuery := `
+		SHOW VECTOR INDEXES YIELD name, options
+		WHERE name = $name
+		RETURN options.indexConfig['vector.dimensions'] as dimensions
+	`
+	result, err := session.Run(ctx, showQuery, map[string]interface{}{"name": target.index})
+	if err != nil {
+		return fmt.Errorf("failed to read vector index: %w", err)
+	}
+	if result.Next(ctx) {
+		if getIntFromRecord(result.Record(), "dimensions") == dimensions {
+			return nil
+		}
+		if _, err := session.Run(ctx, fmt.Sprintf("DROP INDEX %s IF EXISTS", target.index), nil); err != nil {
+			return fmt.Errorf("failed to drop outdated vector index: %w", err)
+		}
+		r.logger.Info("Dropped vector index built for a different embedding size",
+			zap.String("index", target.index),
+			zap.Int("dimensions", dimensions),
+		)
+	}
+
+	// Index options can't be parameters, so the size is formatted in
+	createQuery := fmt.Sprintf(`
+		CREATE VECTOR INDEX %s IF NOT EXISTS
+		FOR (n:%s) ON (n.embedding)
+		OPTIONS {indexConfig: {`+"`vector.dimensions`"+`: %d, `+"`vector.similarity_function`"+`: 'cosine'}}
+	`, target.index, target.label, dimensions)
+	if _, err := session.Run(ctx, createQuery, nil); err != nil {
+		return fmt.Errorf("failed to create vector index: %w", err)
+	}
+	return nil
+}
+
+// ListAgentEmbeddingModels returns the agents that set their own embedding
+// provider or model, ordered by ID
+func (r *Repository) ListAgentEmbeddingModels(ctx context.Context) ([]AgentEmbeddingModel, error) {
+	ctx, span := startQuerySpan(ctx, "ListAgentEmbeddingModels")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, `
+		MATCH (a:Agent)
+		WHERE coalesce(a.embedding_provider, '') <> '' OR coalesce(a.embedding_model, '') <> ''
+		RETURN a.id as id, coalesce(a.embedding_provider, '') as provider, coalesce(a.embedding_model, '') as model
+		ORDER BY a.id
+	`, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent embedding models: %w", err)
+	}
+
+	var models []AgentEmbeddingModel
+	for result.Next(ctx) {
+		record := result.Record()
+		models = append(models, AgentEmbeddingModel{
+			AgentID:  getStringFromRecord(record, "id"),
+			Provider: getStringFromRecord(record, "provider"),
+			Model:    getStringFromRecord(record, "model"),
+		})
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list agent embedding models: %w", err)
+	}
+	return models, nil
+}
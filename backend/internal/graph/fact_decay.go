@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"math"
+	"time"
+)
+
+// SetFactConfidenceHalfLife sets how long it takes a fact's effective
+// confidence to halve after it was last told or re-affirmed. Zero disables
+// decay; negative values are ignored.
+func (r *Repository) SetFactConfidenceHalfLife(halfLife time.Duration) {
+	if halfLife >= 0 {
+		r.factHalfLife = halfLife
+	}
+}
+
+// DecayedConfidence returns confidence scaled by exponential decay over age:
+// confidence * 0.5^(age/halfLife). A non-positive half-life or age leaves the
+// confidence unchanged.
+func DecayedConfidence(confidence float64, age, halfLife time.Duration) float64 {
+	if halfLife <= 0 || age <= 0 {
+		return confidence
+	}
+	return confidence * math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// applyFactDecay fills in a fact's effective confidence at read time.
+// Stored values are never modified, and pinned facts don't decay.
+func (r *Repository) applyFactDecay(fact *Fact, affirmedAt, now time.Time) {
+	fact.EffectiveConfidence = fact.Confidence
+	if fact.Pinned {
+		return
+	}
+	fact.EffectiveConfidence = DecayedConfidence(fact.Confidence, now.Sub(affirmedAt), r.factHalfLife)
+}
@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecayedConfidence(t *testing.T) {
+	halfLife := 30 * 24 * time.Hour
+
+	assert.InDelta(t, 0.8, DecayedConfidence(0.8, 0, halfLife), 1e-9)
+	assert.InDelta(t, 0.4, DecayedConfidence(0.8, halfLife, halfLife), 1e-9)
+	assert.InDelta(t, 0.2, DecayedConfidence(0.8, 2*halfLife, halfLife), 1e-9)
+	assert.InDelta(t, 0.8, DecayedConfidence(0.8, 365*24*time.Hour, 0), 1e-9, "zero half-life disables decay")
+}
+
+func TestApplyFactDecay(t *testing.T) {
+	r := &Repository{}
+	r.SetFactConfidenceHalfLife(24 * time.Hour)
+	now := time.Now()
+
+	fact := &Fact{Confidence: 1.0}
+	r.applyFactDecay(fact, now.Add(-24*time.Hour), now)
+	assert.InDelta(t, 0.5, fact.EffectiveConfidence, 1e-9)
+	assert.Equal(t, 1.0, fact.Confidence, "stored confidence is not modified")
+
+	pinned := &Fact{Confidence: 1.0, Pinned: true}
+	r.applyFactDecay(pinned, now.Add(-24*time.Hour), now)
+	assert.Equal(t, 1.0, pinned.EffectiveConfidence)
+}
+
+func TestRankTopicFacts(t *testing.T) {
+	// Newest first, as the query returns them: more stale facts than the
+	// limit ahead of an old but still confident one
+	topicFacts := func() []Fact {
+		facts := make([]Fact, 0, topicFactsLimit+1)
+		for i := 0; i < topicFactsLimit; i++ {
+			facts = append(facts, Fact{ID: "stale", EffectiveConfidence: 0.1})
+		}
+		return append(facts, Fact{ID: "confident", EffectiveConfidence: 0.9})
+	}
+
+	r := &Repository{}
+	r.SetFactConfidenceHalfLife(24 * time.Hour)
+	ranked := r.rankTopicFacts(topicFacts())
+	assert.Len(t, ranked, topicFactsLimit)
+	assert.Equal(t, "confident", ranked[0].ID)
+
+	r.SetFactConfidenceHalfLife(0)
+	ranked = r.rankTopicFacts(topicFacts())
+	assert.Len(t, ranked, topicFactsLimit)
+	assert.Equal(t, "stale", ranked[0].ID, "without decay the newest facts are kept")
+}
@@ -2,7 +2,10 @@ package graph
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,37 +17,252 @@ import (
 // Fact Operations
 // ============================================================================
 
-// CreateFact creates a new fact and links it to the agent and optionally a user/topic
+// CreateFact creates a new fact and links it to the agent and optionally a user/topic.
+// Facts are merged on (agent, user, content hash), so an identical fact recorded
+// twice - even by concurrent calls - resolves to the existing node.
 func (r *Repository) CreateFact(ctx context.Context, agentID, content, source, userID string, topicNames []string) (*Fact, error) {
+	ctx, span := startQuerySpan(ctx, "CreateFact")
+	defer span.End()
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
+	input := FactInput{Content: content, Source: source, UserID: userID, Topics: topicNames}
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return r.createFactTx(ctx, tx, agentID, input)
+	})
+	if err != nil {
+		return nil, err
+	}
+	written := result.(factWrite)
+	if written.created {
+		r.emitMemoryEvent(EventFactCreated, agentID, written.fact.ID, written.fact.Content)
+	}
+	return written.fact, nil
+}
+
+// factWrite is the outcome of createFactTx
+type factWrite struct {
+	fact    *Fact
+	created bool // false when the fact merged into an existing one
+}
+
+// CreateFactsBatch creates several facts in a single transaction with one
+// UNWIND write: either all of them are stored or, if the write fails, none
+// are. Facts identical to an existing one (same agent, user and content hash)
+// are merged into it. Unless skipDedup is set, near-duplicates of existing
+// facts are skipped as well. Results are returned in input order.
+func (r *Repository) CreateFactsBatch(ctx context.Context, agentID string, inputs []FactInput, skipDedup bool) ([]FactBatchResult, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return r.createFactsBatchTx(ctx, tx, agentID, inputs, skipDedup)
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := result.([]FactBatchResult)
+	for i, res := range results {
+		if res.Status == FactBatchCreated {
+			r.emitMemoryEvent(EventFactCreated, agentID, res.ID, inputs[i].Content)
+		}
+	}
+	return results, nil
+}
+
+func (r *Repository) createFactsBatchTx(ctx context.Context, tx neo4j.ManagedTransaction, agentID string, inputs []FactInput, skipDedup bool) ([]FactBatchResult, error) {
+	results := make([]FactBatchResult, len(inputs))
+
+	skip := make([]bool, len(inputs))
+	if !skipDedup {
+		existingQuery := `
+			MATCH (:Agent {id: $agentID})-[:KNOWS_FACT]->(f:Fact)
+			RETURN f.content as content
+		`
+		existingResult, err := tx.Run(ctx, existingQuery, map[string]interface{}{
+			"agentID": agentID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load existing facts: %w", err)
+		}
+		var existing []string
+		for existingResult.Next(ctx) {
+			existing = append(existing, getStringFromRecord(existingResult.Record(), "content"))
+		}
+		skip = nearDuplicateFacts(existing, inputs)
+	}
+
+	items := make([]map[string]interface{}, 0, len(inputs))
+	for i, input := range inputs {
+		if skip[i] {
+			results[i] = FactBatchResult{Status: FactBatchSkipped}
+			continue
+		}
+		topics := make([]string, 0, len(input.Topics))
+		for _, topic := range input.Topics {
+			if topic != "" {
+				topics = append(topics, topic)
+			}
+		}
+		items = append(items, map[string]interface{}{
+			"index":        i,
+			"id":           uuid.New().String(),
+			"content":      input.Content,
+			"content_hash": factContentHash(input.Content),
+			"source":       input.Source,
+			"user_id":      input.UserID,
+			"topics":       topics,
+			"confidence":   factConfidence(input),
+		})
+	}
+	if len(items) == 0 {
+		return results, nil
+	}
+
+	query := `
+		UNWIND $items AS item
+		MATCH (a:Agent {id: $agentID})
+		MERGE (f:Fact {agent_id: $agentID, user_id: item.user_id, content_hash: item.content_hash})
+		ON CREATE SET
+			f.id = item.id,
+			f.content = item.content,
+			f.source = item.source,
+			f.confidence = item.confidence,
+			f.created_at = datetime($now)
+		ON MATCH SET f.last_affirmed_at = datetime($now)
+		MERGE (a)-[:KNOWS_FACT]->(f)
+		WITH item, f
+		OPTIONAL MATCH (u:User {id: item.user_id})
+		FOREACH (ignored IN CASE WHEN u IS NULL THEN [] ELSE [1] END |
+			MERGE (u)-[:TOLD_ME]->(f)
+		)
+		FOREACH (topicName IN item.topics |
+			MERGE (t:Topic {name: topicName})
+			ON CREATE SET t.id = randomUUID(), t.created_at = datetime($now)
+			MERGE (f)-[:ABOUT]->(t)
+		)
+		RETURN item.index as index, f.id as id, f.id = item.id as created
+	`
+
+	result, err := tx.Run(ctx, query, map[string]interface{}{
+		"agentID": agentID,
+		"items":   items,
+		"now":     time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create facts: %w", err)
+	}
+
+	written := 0
+	for result.Next(ctx) {
+		record := result.Record()
+		status := FactBatchMerged
+		if getBoolFromRecord(record, "created") {
+			status = FactBatchCreated
+		}
+		results[getIntFromRecord(record, "index")] = FactBatchResult{
+			ID:     getStringFromRecord(record, "id"),
+			Status: status,
+		}
+		written++
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("failed to create facts: %w", err)
+	}
+	if written == 0 {
+		return nil, ErrAgentNotFound{AgentID: agentID}
+	}
+
+	r.logger.Info("Fact batch stored",
+		zap.String("agent_id", agentID),
+		zap.Int("items", len(inputs)),
+		zap.Int("written", written),
+	)
+	return results, nil
+}
+
+// nearDuplicateFacts flags inputs that are similar to an existing fact or an
+// earlier input without being identical to it. Identical facts are left to
+// the content-hash merge so they resolve to the existing node.
+func nearDuplicateFacts(existing []string, inputs []FactInput) []bool {
+	seen := make([]string, 0, len(existing)+len(inputs))
+	for _, content := range existing {
+		seen = append(seen, normalizeFactContent(content))
+	}
+
+	skip := make([]bool, len(inputs))
+	for i, input := range inputs {
+		normalized := normalizeFactContent(input.Content)
+		for _, other := range seen {
+			if other != normalized && areFactsSimilar(other, normalized) {
+				skip[i] = true
+				break
+			}
+		}
+		if !skip[i] {
+			seen = append(seen, normalized)
+		}
+	}
+	return skip
+}
+
+// factConfidence returns the input's confidence, defaulting to 1.0
+func factConfidence(input FactInput) float64 {
+	if input.Confidence == nil {
+		return 1.0
+	}
+	return *input.Confidence
+}
+
+// createFactTx runs CreateFact's writes inside tx
+func (r *Repository) createFactTx(ctx context.Context, tx neo4j.ManagedTransaction, agentID string, input FactInput) (factWrite, error) {
 	factID := uuid.New().String()
 	now := time.Now().UTC().Format(time.RFC3339)
+	content, source, userID := input.Content, input.Source, input.UserID
 
-	// Create the fact and link to agent
+	// Merge the fact on its identity and link to agent
 	query := `
 		MATCH (a:Agent {id: $agentID})
-		CREATE (f:Fact {
-			id: $factID,
-			content: $content,
-			source: $source,
-			confidence: 1.0,
-			created_at: datetime($now)
-		})
-		CREATE (a)-[:KNOWS_FACT]->(f)
-		RETURN f.id as id
+		MERGE (f:Fact {agent_id: $agentID, user_id: $userID, content_hash: $contentHash})
+		ON CREATE SET
+			f.id = $factID,
+			f.content = $content,
+			f.source = $source,
+			f.confidence = $confidence,
+			f.created_at = datetime($now)
+		ON MATCH SET f.last_affirmed_at = datetime($now)
+		MERGE (a)-[:KNOWS_FACT]->(f)
+		RETURN f.id as id, f.content as content, f.source as source
 	`
 
-	_, err := session.Run(ctx, query, map[string]interface{}{
-		"agentID": agentID,
-		"factID":  factID,
-		"content": content,
-		"source":  source,
-		"now":     now,
+	result, err := tx.Run(ctx, query, map[string]interface{}{
+		"agentID":     agentID,
+		"userID":      userID,
+		"contentHash": factContentHash(content),
+		"factID":      factID,
+		"content":     content,
+		"source":      source,
+		"confidence":  factConfidence(input),
+		"now":         now,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create fact: %w", err)
+		return factWrite{}, fmt.Errorf("failed to create fact: %w", err)
+	}
+	if !result.Next(ctx) {
+		return factWrite{}, ErrAgentNotFound{AgentID: agentID}
+	}
+	record := result.Record()
+	created := true
+	if existingID := getStringFromRecord(record, "id"); existingID != factID {
+		created = false
+		r.logger.Debug("Fact already exists, reusing",
+			zap.String("fact_id", existingID),
+			zap.String("agent_id", agentID),
+		)
+		factID = existingID
+		content = getStringFromRecord(record, "content")
+		source = getStringFromRecord(record, "source")
 	}
 
 	// Link to user if provided
@@ -54,14 +272,17 @@ func (r *Repository) CreateFact(ctx context.Context, agentID, content, source, u
 			MATCH (u:User {id: $userID})
 			MERGE (u)-[:TOLD_ME]->(f)
 		`
-		_, _ = session.Run(ctx, linkQuery, map[string]interface{}{
+		_, err := tx.Run(ctx, linkQuery, map[string]interface{}{
 			"factID": factID,
 			"userID": userID,
 		})
+		if err != nil {
+			return factWrite{}, fmt.Errorf("failed to link fact to user: %w", err)
+		}
 	}
 
 	// Link to topics
-	for _, topicName := range topicNames {
+	for _, topicName := range input.Topics {
 		if topicName == "" {
 			continue
 		}
@@ -71,12 +292,15 @@ func (r *Repository) CreateFact(ctx context.Context, agentID, content, source, u
 			ON CREATE SET t.id = $topicID, t.created_at = datetime($now)
 			MERGE (f)-[:ABOUT]->(t)
 		`
-		_, _ = session.Run(ctx, topicQuery, map[string]interface{}{
+		_, err := tx.Run(ctx, topicQuery, map[string]interface{}{
 			"factID":    factID,
 			"topicName": topicName,
 			"topicID":   uuid.New().String(),
 			"now":       now,
 		})
+		if err != nil {
+			return factWrite{}, fmt.Errorf("failed to link fact to topic %s: %w", topicName, err)
+		}
 	}
 
 	r.logger.Info("Fact created",
@@ -85,16 +309,133 @@ func (r *Repository) CreateFact(ctx context.Context, agentID, content, source, u
 		zap.String("source", source),
 	)
 
-	return &Fact{
-		ID:        factID,
-		Content:   content,
-		Source:    source,
-		CreatedAt: time.Now(),
+	return factWrite{
+		fact: &Fact{
+			ID:        factID,
+			Content:   content,
+			Source:    source,
+			CreatedAt: time.Now(),
+		},
+		created: created,
 	}, nil
 }
 
+// factContentHash returns a deterministic hash of a fact's normalized content,
+// so facts differing only in case, spacing or trailing punctuation collide.
+func factContentHash(content string) string {
+	sum := sha256.Sum256([]byte(normalizeFactContent(content)))
+	return hex.EncodeToString(sum[:])
+}
+
+// factBackfillBatch is how many facts backfillFactIdentity updates per transaction
+const factBackfillBatch = 500
+
+// backfillFactIdentity gives facts stored before facts were merged on
+// (agent_id, user_id, content_hash) those properties, taken from the agent
+// that knows the fact and the user who told it. A fact that turns out
+// identical to another is merged into it, as in UpdateFact. Returns how
+// many facts were backfilled and how many of those were merged.
+func (r *Repository) backfillFactIdentity(ctx context.Context) (backfilled, merged int, err error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	for {
+		result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			return r.backfillFactIdentityTx(ctx, tx)
+		})
+		if err != nil {
+			return backfilled, merged, err
+		}
+		batch := result.(factBackfill)
+		for _, fact := range batch.merged {
+			r.emitMemoryEvent(EventFactDeleted, fact.agentID, fact.id, "")
+		}
+		if batch.facts == 0 {
+			return backfilled, merged, nil
+		}
+		backfilled += batch.facts
+		merged += len(batch.merged)
+	}
+}
+
+// factBackfill is the outcome of backfillFactIdentityTx
+type factBackfill struct {
+	facts  int        // Facts backfilled
+	merged []userFact // Those merged into an identical fact and deleted
+}
+
+// backfillFactIdentityTx backfills one batch of facts inside tx
+func (r *Repository) backfillFactIdentityTx(ctx context.Context, tx neo4j.ManagedTransaction) (factBackfill, error) {
+	result, err := tx.Run(ctx, `
+		MATCH (f:Fact)
+		WHERE f.id IS NOT NULL
+		  AND (f.agent_id IS NULL OR f.user_id IS NULL OR f.content_hash IS NULL)
+		WITH f LIMIT $limit
+		OPTIONAL MATCH (a:Agent)-[:KNOWS_FACT]->(f)
+		OPTIONAL MATCH (u:User)-[:TOLD_ME]->(f)
+		WITH f, collect(DISTINCT a.id) as agents, collect(DISTINCT u.id) as tellers
+		RETURN f.id as id, f.content as content,
+		       coalesce(f.agent_id, head(agents), '') as agent_id,
+		       coalesce(f.user_id, head(tellers), '') as user_id
+	`, map[string]interface{}{
+		"limit": factBackfillBatch,
+	})
+	if err != nil {
+		return factBackfill{}, fmt.Errorf("failed to load facts to backfill: %w", err)
+	}
+	records, err := result.Collect(ctx)
+	if err != nil {
+		return factBackfill{}, fmt.Errorf("failed to load facts to backfill: %w", err)
+	}
+
+	backfill := factBackfill{facts: len(records)}
+	for _, record := range records {
+		params := map[string]interface{}{
+			"factID":      getStringFromRecord(record, "id"),
+			"agentID":     getStringFromRecord(record, "agent_id"),
+			"userID":      getStringFromRecord(record, "user_id"),
+			"contentHash": factContentHash(getStringFromRecord(record, "content")),
+		}
+
+		// Each fact is checked after the ones before it are written, so
+		// duplicates within the batch merge too
+		kept, err := tx.Run(ctx, `
+			MATCH (kept:Fact {agent_id: $agentID, user_id: $userID, content_hash: $contentHash})
+			WHERE kept.id <> $factID
+			RETURN kept.id as id
+			LIMIT 1
+		`, params)
+		if err != nil {
+			return factBackfill{}, fmt.Errorf("failed to backfill fact: %w", err)
+		}
+		if kept.Next(ctx) {
+			fact := userFact{id: params["factID"].(string), agentID: params["agentID"].(string)}
+			if err := mergeFactIntoTx(ctx, tx, fact.id, getStringFromRecord(kept.Record(), "id")); err != nil {
+				return factBackfill{}, err
+			}
+			backfill.merged = append(backfill.merged, fact)
+			continue
+		}
+		if err := kept.Err(); err != nil {
+			return factBackfill{}, fmt.Errorf("failed to backfill fact: %w", err)
+		}
+
+		_, err = tx.Run(ctx, `
+			MATCH (f:Fact {id: $factID})
+			SET f.agent_id = $agentID, f.user_id = $userID, f.content_hash = $contentHash
+		`, params)
+		if err != nil {
+			return factBackfill{}, fmt.Errorf("failed to backfill fact: %w", err)
+		}
+	}
+	return backfill, nil
+}
+
 // GetFactsAboutTopic retrieves all facts about a topic
 func (r *Repository) GetFactsAboutTopic(ctx context.Context, topicName string) ([]Fact, error) {
+	ctx, span := startQuerySpan(ctx, "GetFactsAboutTopic")
+	defer span.End()
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
@@ -104,9 +445,9 @@ func (r *Repository) GetFactsAboutTopic(ctx context.Context, topicName string) (
 		OPTIONAL MATCH (u:User)-[:TOLD_ME]->(f)
 		RETURN f.id as id, f.content as content, f.source as source, 
 		       f.confidence as confidence, f.created_at as created_at,
-		       u.discord_username as told_by
+		       coalesce(f.last_affirmed_at, f.created_at) as affirmed_at,
+		       coalesce(f.pinned, false) as pinned, u.discord_username as told_by
 		ORDER BY f.created_at DESC
-		LIMIT 20
 	`
 
 	result, err := session.Run(ctx, query, map[string]interface{}{
@@ -116,76 +457,294 @@ func (r *Repository) GetFactsAboutTopic(ctx context.Context, topicName string) (
 		return nil, fmt.Errorf("failed to get facts: %w", err)
 	}
 
+	now := time.Now()
 	var facts []Fact
 	for result.Next(ctx) {
 		record := result.Record()
+		createdAt := getTimeFromRecord(record, "created_at", now)
 		fact := Fact{
-			ID:      getStringFromRecord(record, "id"),
-			Content: getStringFromRecord(record, "content"),
-			Source:  getStringFromRecord(record, "source"),
+			ID:         getStringFromRecord(record, "id"),
+			Content:    getStringFromRecord(record, "content"),
+			Source:     getStringFromRecord(record, "source"),
+			Confidence: getFloat64FromRecord(record, "confidence"),
+			CreatedAt:  createdAt,
+			Pinned:     getBoolFromRecord(record, "pinned"),
 		}
 		if toldBy := getStringFromRecord(record, "told_by"); toldBy != "" {
 			fact.Source = fmt.Sprintf("Told by %s", toldBy)
 		}
+		r.applyFactDecay(&fact, getTimeFromRecord(record, "affirmed_at", createdAt), now)
 		facts = append(facts, fact)
 	}
 
-	return facts, nil
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get facts: %w", err)
+	}
+
+	return r.rankTopicFacts(facts), nil
 }
 
-// UpdateFact updates the content of an existing fact
-func (r *Repository) UpdateFact(ctx context.Context, factID, newContent string) error {
-	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	defer session.Close(ctx)
+// topicFactsLimit is how many facts GetFactsAboutTopic returns
+const topicFactsLimit = 20
+
+// rankTopicFacts orders facts newest first, as given, or with decay enabled
+// by effective confidence so stale facts rank below fresh ones, and keeps
+// the first topicFactsLimit. Facts are ranked before the cut so a fresh fact
+// isn't dropped for older ones that have decayed below it.
+func (r *Repository) rankTopicFacts(facts []Fact) []Fact {
+	if r.factHalfLife > 0 {
+		sort.SliceStable(facts, func(i, j int) bool {
+			return facts[i].EffectiveConfidence > facts[j].EffectiveConfidence
+		})
+	}
+	if len(facts) > topicFactsLimit {
+		facts = facts[:topicFactsLimit]
+	}
+	return facts
+}
 
-	now := time.Now().UTC().Format(time.RFC3339)
+// GetFact returns one of an agent's facts by ID, or ErrFactNotFound if the
+// agent knows no fact with that ID
+func (r *Repository) GetFact(ctx context.Context, agentID, factID string) (*Fact, error) {
+	ctx, span := startQuerySpan(ctx, "GetFact")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
 
 	query := `
-		MATCH (f:Fact {id: $factID})
-		SET f.content = $newContent,
-		    f.updated_at = datetime($now)
-		RETURN f.id as id
+		MATCH (:Agent {id: $agentID})-[:KNOWS_FACT]->(f:Fact {id: $factID})
+		OPTIONAL MATCH (u:User)-[:TOLD_ME]->(f)
+		RETURN f.id as id, f.content as content, f.source as source,
+		       f.confidence as confidence, f.created_at as created_at,
+		       coalesce(f.last_affirmed_at, f.created_at) as affirmed_at,
+		       coalesce(f.pinned, false) as pinned, u.discord_username as told_by
+		LIMIT 1
 	`
 
 	result, err := session.Run(ctx, query, map[string]interface{}{
-		"factID":    factID,
-		"newContent": newContent,
-		"now":       now,
+		"agentID": agentID,
+		"factID":  factID,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to update fact: %w", err)
+		return nil, fmt.Errorf("failed to get fact: %w", err)
 	}
-
 	if !result.Next(ctx) {
-		return fmt.Errorf("fact not found: %s", factID)
+		if err := result.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get fact: %w", err)
+		}
+		return nil, ErrFactNotFound{FactID: factID}
+	}
+
+	now := time.Now()
+	record := result.Record()
+	createdAt := getTimeFromRecord(record, "created_at", now)
+	fact := &Fact{
+		ID:         getStringFromRecord(record, "id"),
+		Content:    getStringFromRecord(record, "content"),
+		Source:     getStringFromRecord(record, "source"),
+		Confidence: getFloat64FromRecord(record, "confidence"),
+		CreatedAt:  createdAt,
+		Pinned:     getBoolFromRecord(record, "pinned"),
+	}
+	if toldBy := getStringFromRecord(record, "told_by"); toldBy != "" {
+		fact.Source = fmt.Sprintf("Told by %s", toldBy)
+	}
+	r.applyFactDecay(fact, getTimeFromRecord(record, "affirmed_at", createdAt), now)
+	return fact, nil
+}
+
+// UpdateFact updates the content of an existing fact, keeping its content
+// hash in step. When the new content matches another fact with the same
+// identity (agent, user and content hash), the fact is merged into that one:
+// its topics, tellers and pin carry over and it is deleted.
+func (r *Repository) UpdateFact(ctx context.Context, factID, newContent string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return r.updateFactTx(ctx, tx, factID, newContent)
+	})
+	if err != nil {
+		return err
+	}
+	update := result.(factUpdate)
+
+	if update.mergedInto != "" {
+		r.logger.Info("Fact merged into an identical one on update",
+			zap.String("fact_id", factID),
+			zap.String("merged_into", update.mergedInto),
+		)
+		r.emitMemoryEvent(EventFactDeleted, update.agentID, factID, "")
+		r.emitMemoryEvent(EventFactUpdated, update.agentID, update.mergedInto, newContent)
+		return nil
 	}
 
 	r.logger.Info("Fact updated",
 		zap.String("fact_id", factID),
 	)
+	r.emitMemoryEvent(EventFactUpdated, update.agentID, factID, newContent)
+	return nil
+}
+
+// factUpdate is the outcome of updateFactTx
+type factUpdate struct {
+	agentID    string
+	mergedInto string // ID of the fact it merged into, empty if updated in place
+}
+
+// updateFactTx runs UpdateFact's writes inside tx
+func (r *Repository) updateFactTx(ctx context.Context, tx neo4j.ManagedTransaction, factID, newContent string) (factUpdate, error) {
+	params := map[string]interface{}{
+		"factID":      factID,
+		"newContent":  newContent,
+		"contentHash": factContentHash(newContent),
+		"now":         time.Now().UTC().Format(time.RFC3339),
+	}
+
+	result, err := tx.Run(ctx, `
+		MATCH (f:Fact {id: $factID})
+		OPTIONAL MATCH (kept:Fact {agent_id: f.agent_id, user_id: f.user_id, content_hash: $contentHash})
+		WHERE kept <> f
+		RETURN f.agent_id as agent_id, kept.id as kept_id
+		LIMIT 1
+	`, params)
+	if err != nil {
+		return factUpdate{}, fmt.Errorf("failed to update fact: %w", err)
+	}
+	if !result.Next(ctx) {
+		if err := result.Err(); err != nil {
+			return factUpdate{}, fmt.Errorf("failed to update fact: %w", err)
+		}
+		return factUpdate{}, ErrFactNotFound{FactID: factID}
+	}
+	record := result.Record()
+	update := factUpdate{
+		agentID:    getStringFromRecord(record, "agent_id"),
+		mergedInto: getStringFromRecord(record, "kept_id"),
+	}
+
+	if update.mergedInto == "" {
+		_, err = tx.Run(ctx, `
+			MATCH (f:Fact {id: $factID})
+			SET f.content = $newContent,
+			    f.content_hash = $contentHash,
+			    f.updated_at = datetime($now)
+		`, params)
+		if err != nil {
+			return factUpdate{}, fmt.Errorf("failed to update fact: %w", err)
+		}
+		return update, nil
+	}
+
+	params["keptID"] = update.mergedInto
+	_, err = tx.Run(ctx, `
+		MATCH (kept:Fact {id: $keptID})
+		SET kept.content = $newContent,
+		    kept.updated_at = datetime($now),
+		    kept.last_affirmed_at = datetime($now)
+	`, params)
+	if err != nil {
+		return factUpdate{}, fmt.Errorf("failed to merge fact: %w", err)
+	}
+	if err := mergeFactIntoTx(ctx, tx, factID, update.mergedInto); err != nil {
+		return factUpdate{}, err
+	}
+	return update, nil
+}
+
+// mergeFactIntoTx folds a fact into an identical one inside tx: the kept
+// fact takes over its topics, tellers and agents, stays pinned if either
+// was, and the fact is deleted
+func mergeFactIntoTx(ctx context.Context, tx neo4j.ManagedTransaction, factID, keptID string) error {
+	_, err := tx.Run(ctx, `
+		MATCH (f:Fact {id: $factID})
+		MATCH (kept:Fact {id: $keptID})
+		OPTIONAL MATCH (f)-[:ABOUT]->(t:Topic)
+		OPTIONAL MATCH (teller:User)-[:TOLD_ME]->(f)
+		OPTIONAL MATCH (knower:Agent)-[:KNOWS_FACT]->(f)
+		WITH f, kept, collect(DISTINCT t) as topics, collect(DISTINCT teller) as tellers,
+		     collect(DISTINCT knower) as knowers
+		FOREACH (t IN topics | MERGE (kept)-[:ABOUT]->(t))
+		FOREACH (u IN tellers | MERGE (u)-[:TOLD_ME]->(kept))
+		FOREACH (a IN knowers | MERGE (a)-[:KNOWS_FACT]->(kept))
+		SET kept.pinned = coalesce(kept.pinned, false) OR coalesce(f.pinned, false)
+		DETACH DELETE f
+	`, map[string]interface{}{
+		"factID": factID,
+		"keptID": keptID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to merge fact: %w", err)
+	}
 	return nil
 }
 
-// DeleteFact deletes a fact by ID
+// DeleteFact deletes a fact by ID. Pinned facts are left in place and
+// reported as an error; unpin them first to delete.
 func (r *Repository) DeleteFact(ctx context.Context, factID string) error {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
 	query := `
 		MATCH (f:Fact {id: $factID})
-		DETACH DELETE f
+		WITH f, coalesce(f.pinned, false) as pinned, f.agent_id as agent_id
+		FOREACH (_ IN CASE WHEN pinned THEN [] ELSE [1] END | DETACH DELETE f)
+		RETURN pinned, agent_id
 	`
 
-	_, err := session.Run(ctx, query, map[string]interface{}{
+	result, err := session.Run(ctx, query, map[string]interface{}{
 		"factID": factID,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to delete fact: %w", err)
 	}
+	if !result.Next(ctx) {
+		return nil
+	}
+	record := result.Record()
+	if getBoolFromRecord(record, "pinned") {
+		return fmt.Errorf("fact %s is pinned", factID)
+	}
 
 	r.logger.Info("Fact deleted",
 		zap.String("fact_id", factID),
 	)
+	r.emitMemoryEvent(EventFactDeleted, getStringFromRecord(record, "agent_id"), factID, "")
+	return nil
+}
+
+// SetFactPinned pins or unpins one of an agent's facts, returning
+// ErrFactNotFound if the agent knows no fact with that ID. Pinned facts are
+// protected from memory cleanup and deletion.
+func (r *Repository) SetFactPinned(ctx context.Context, agentID, factID string, pinned bool) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (:Agent {id: $agentID})-[:KNOWS_FACT]->(f:Fact {id: $factID})
+		SET f.pinned = $pinned
+		RETURN f.id as id
+	`
+
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"agentID": agentID,
+		"factID":  factID,
+		"pinned":  pinned,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set fact pinned: %w", err)
+	}
+
+	if !result.Next(ctx) {
+		return ErrFactNotFound{FactID: factID}
+	}
+
+	r.logger.Info("Fact pin updated",
+		zap.String("agent_id", agentID),
+		zap.String("fact_id", factID),
+		zap.Bool("pinned", pinned),
+	)
 	return nil
 }
 
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// rankingStopWords are ignored when matching facts against a message
+var rankingStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "you": true,
+	"your": true, "what": true, "how": true, "who": true, "with": true, "this": true,
+	"that": true, "about": true, "have": true, "has": true, "does": true, "did": true,
+	"can": true, "from": true, "they": true, "their": true, "its": true, "not": true,
+}
+
+// rankingTerms returns the distinct lowercase words of text worth matching on
+func rankingTerms(text string) map[string]bool {
+	terms := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		if len(word) >= 3 && !rankingStopWords[word] {
+			terms[word] = true
+		}
+	}
+	return terms
+}
+
+// SelectRelevantFacts returns at most limit facts, most relevant to query
+// first: pinned facts, then those sharing the most words with query, then by
+// effective confidence and recency. A non-positive limit keeps every fact.
+func SelectRelevantFacts(facts []Fact, query string, limit int) []Fact {
+	if limit <= 0 || len(facts) <= limit {
+		return facts
+	}
+
+	queryTerms := rankingTerms(query)
+	overlap := make([]int, len(facts))
+	for i, fact := range facts {
+		for term := range rankingTerms(fact.Content) {
+			if queryTerms[term] {
+				overlap[i]++
+			}
+		}
+	}
+
+	order := make([]int, len(facts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		fa, fb := facts[order[a]], facts[order[b]]
+		if fa.Pinned != fb.Pinned {
+			return fa.Pinned
+		}
+		if overlap[order[a]] != overlap[order[b]] {
+			return overlap[order[a]] > overlap[order[b]]
+		}
+		if ca, cb := rankingConfidence(fa), rankingConfidence(fb); ca != cb {
+			return ca > cb
+		}
+		return fa.CreatedAt.After(fb.CreatedAt)
+	})
+
+	selected := make([]Fact, 0, limit)
+	for _, i := range order[:limit] {
+		selected = append(selected, facts[i])
+	}
+	return selected
+}
+
+// rankingConfidence prefers the decayed confidence when it has been computed
+func rankingConfidence(fact Fact) float64 {
+	if fact.EffectiveConfidence > 0 {
+		return fact.EffectiveConfidence
+	}
+	return fact.Confidence
+}
@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectRelevantFacts_KeepsTopThree(t *testing.T) {
+	now := time.Now()
+	facts := []Fact{
+		{ID: "old", Content: "Went to Paris in 2019", Confidence: 1.0, CreatedAt: now.Add(-72 * time.Hour)},
+		{ID: "pizza", Content: "Loves pizza with pineapple", Confidence: 0.6, CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: "allergy", Content: "Allergic to peanuts", Confidence: 1.0, Pinned: true, CreatedAt: now.Add(-96 * time.Hour)},
+		{ID: "rust", Content: "Writes Rust at work", Confidence: 0.9, CreatedAt: now.Add(-time.Hour)},
+		{ID: "dog", Content: "Has a dog named Pizza", Confidence: 0.7, CreatedAt: now.Add(-24 * time.Hour)},
+		{ID: "cat", Content: "Used to have a cat", Confidence: 0.5, CreatedAt: now},
+	}
+
+	selected := SelectRelevantFacts(facts, "what pizza toppings should I order?", 3)
+
+	ids := make([]string, 0, len(selected))
+	for _, fact := range selected {
+		ids = append(ids, fact.ID)
+	}
+	// Pinned first, then facts mentioning pizza (higher confidence first)
+	assert.Equal(t, []string{"allergy", "dog", "pizza"}, ids)
+}
+
+func TestSelectRelevantFacts_FallsBackToConfidenceAndRecency(t *testing.T) {
+	now := time.Now()
+	facts := []Fact{
+		{ID: "a", Content: "Likes jazz", Confidence: 0.5, CreatedAt: now},
+		{ID: "b", Content: "Lives in Berlin", Confidence: 0.9, EffectiveConfidence: 0.2, CreatedAt: now},
+		{ID: "c", Content: "Studies math", Confidence: 0.8, CreatedAt: now.Add(-time.Hour)},
+		{ID: "d", Content: "Studies physics", Confidence: 0.8, CreatedAt: now},
+	}
+
+	selected := SelectRelevantFacts(facts, "hello there", 2)
+	assert.Equal(t, "d", selected[0].ID, "newer of equally confident facts first")
+	assert.Equal(t, "c", selected[1].ID)
+
+	assert.Len(t, SelectRelevantFacts(facts, "hello", 0), 4, "no limit keeps every fact")
+}
@@ -0,0 +1,370 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// Types FullTextSearch can be scoped to
+const (
+	SearchTypeFacts    = "facts"
+	SearchTypeMessages = "messages"
+	SearchTypeTopics   = "topics"
+)
+
+// SearchTypes are all the types FullTextSearch searches by default
+var SearchTypes = []string{SearchTypeFacts, SearchTypeMessages, SearchTypeTopics}
+
+// Markers wrapped around matched terms in search result snippets
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// snippetRadius is about how many characters of context a snippet keeps on
+// each side of the first match
+const snippetRadius = 80
+
+// maxSnippetShift is how far a snippet's edge moves to avoid cutting a word
+const maxSnippetShift = 20
+
+// fullTextTarget is how one type is searched: with its full-text index, or
+// with CONTAINS matching when the index isn't available. Both queries return
+// id, content, score and metadata.
+type fullTextTarget struct {
+	resultType    string
+	index         string
+	indexQuery    string // Takes $search, a Lucene query
+	fallbackQuery string // Takes $terms, lowercase search terms
+}
+
+var fullTextTargets = map[string]fullTextTarget{
+	SearchTypeFacts: {
+		resultType: "fact",
+		index:      "fact_content",
+		indexQuery: `
+			CALL db.index.fulltext.queryNodes('fact_content', $search) YIELD node, score
+			MATCH (:Agent {id: $agentID})-[:KNOWS_FACT]->(node)
+			RETURN node.id as id, node.content as content, score,
+			       {source: node.source, user_id: node.user_id} as metadata
+			ORDER BY score DESC
+			LIMIT $limit
+		`,
+		fallbackQuery: `
+			MATCH (:Agent {id: $agentID})-[:KNOWS_FACT]->(f:Fact)
+			WITH f, size([term IN $terms WHERE toLower(f.content) CONTAINS term]) as matched
+			WHERE matched > 0
+			RETURN f.id as id, f.content as content, toFloat(matched) / size($terms) as score,
+			       {source: f.source, user_id: f.user_id} as metadata
+			ORDER BY score DESC, f.created_at DESC
+			LIMIT $limit
+		`,
+	},
+	// Messages are the agent's when they are in a conversation it replied in
+	SearchTypeMessages: {
+		resultType: "message",
+		index:      "message_content",
+		indexQuery: `
+			CALL db.index.fulltext.queryNodes('message_content', $search) YIELD node, score
+			WHERE node.deleted_at IS NULL
+			MATCH (c:Conversation)-[:CONTAINS]->(node)
+			WHERE EXISTS { MATCH (c)-[:CONTAINS]->(:Message)<-[:SENT]-(:Agent {id: $agentID}) }
+			RETURN node.id as id, node.content as content, score,
+			       {role: node.role, channel_id: c.channel_id, timestamp: toString(node.timestamp)} as metadata
+			ORDER BY score DESC
+			LIMIT $limit
+		`,
+		fallbackQuery: `
+			MATCH (:Agent {id: $agentID})-[:SENT]->(:Message)<-[:CONTAINS]-(c:Conversation)
+			WITH DISTINCT c
+			MATCH (c)-[:CONTAINS]->(m:Message)
+			WHERE m.deleted_at IS NULL
+			WITH c, m, size([term IN $terms WHERE toLower(m.content) CONTAINS term]) as matched
+			WHERE matched > 0
+			RETURN m.id as id, m.content as content, toFloat(matched) / size($terms) as score,
+			       {role: m.role, channel_id: c.channel_id, timestamp: toString(m.timestamp)} as metadata
+			ORDER BY score DESC, m.timestamp DESC
+			LIMIT $limit
+		`,
+	},
+	// Topics are the agent's when it knows a fact about them
+	SearchTypeTopics: {
+		resultType: "topic",
+		index:      "topic_description",
+		indexQuery: `
+			CALL db.index.fulltext.queryNodes('topic_description', $search) YIELD node, score
+			WHERE EXISTS { MATCH (:Agent {id: $agentID})-[:KNOWS_FACT]->(:Fact)-[:ABOUT]->(node) }
+			RETURN node.id as id, node.name + coalesce(': ' + node.description, '') as content, score,
+			       {name: node.name} as metadata
+			ORDER BY score DESC
+			LIMIT $limit
+		`,
+		fallbackQuery: `
+			MATCH (:Agent {id: $agentID})-[:KNOWS_FACT]->(:Fact)-[:ABOUT]->(t:Topic)
+			WITH DISTINCT t
+			WITH t, toLower(t.name + ' ' + coalesce(t.description, '')) as text
+			WITH t, size([term IN $terms WHERE text CONTAINS term]) as matched
+			WHERE matched > 0
+			RETURN t.id as id, t.name + coalesce(': ' + t.description, '') as content,
+			       toFloat(matched) / size($terms) as score, {name: t.name} as metadata
+			ORDER BY score DESC, t.name
+			LIMIT $limit
+		`,
+	},
+}
+
+// FullTextSearch searches what an agent knows, using the full-text indexes
+// the seed scripts create: its facts, the messages of conversations it took
+// part in, and the topics of its facts. types scopes the search (all of
+// SearchTypes when empty). Results of all types are ranked together by
+// score, best first, and carry an HTML-escaped snippet with the matched
+// terms between HighlightStart and HighlightEnd.
+//
+// Scores are 0-1 so types can be compared: index scores are divided by the
+// best one of their type. When a type's index doesn't exist, that type
+// falls back to CONTAINS matching, scored by the share of search terms found.
+func (r *Repository) FullTextSearch(ctx context.Context, agentID, query string, types []string, limit int) ([]SearchResult, error) {
+	ctx, span := startQuerySpan(ctx, "FullTextSearch")
+	defer span.End()
+
+	if limit < 1 {
+		limit = 20
+	}
+	if len(types) == 0 {
+		types = SearchTypes
+	}
+	for _, t := range types {
+		if _, ok := fullTextTargets[t]; !ok {
+			return nil, fmt.Errorf("unknown search type %q", t)
+		}
+	}
+
+	terms := searchTerms(query)
+	results := []SearchResult{}
+	if len(terms) == 0 {
+		return results, nil
+	}
+	highlight := highlightPattern(terms)
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	seen := make(map[string]bool)
+	for _, t := range types {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		target := fullTextTargets[t]
+		found, err := r.runFullTextTarget(ctx, session, target, map[string]interface{}{
+			"agentID": agentID,
+			"search":  strings.Join(terms, " "),
+			"terms":   terms,
+			"limit":   limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i := range found {
+			found[i].Snippet = highlightSnippet(found[i].Content, highlight)
+		}
+		results = append(results, found...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// runFullTextTarget runs one type's full-text query, falling back to its
+// CONTAINS query when the index is missing
+func (r *Repository) runFullTextTarget(ctx context.Context, session neo4j.SessionWithContext, target fullTextTarget, params map[string]interface{}) ([]SearchResult, error) {
+	results, err := collectSearchResults(ctx, session, target.resultType, target.indexQuery, params)
+	if err == nil {
+		normalizeSearchScores(results)
+	}
+	if err != nil && fullTextUnavailable(err) {
+		r.logger.Warn("Full-text index unavailable, falling back to CONTAINS matching",
+			zap.String("index", target.index),
+			zap.Error(err),
+		)
+		results, err = collectSearchResults(ctx, session, target.resultType, target.fallbackQuery, params)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", target.index, err)
+	}
+	return results, nil
+}
+
+func collectSearchResults(ctx context.Context, session neo4j.SessionWithContext, resultType, query string, params map[string]interface{}) ([]SearchResult, error) {
+	result, err := session.Run(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+	var results []SearchResult
+	for result.Next(ctx) {
+		record := result.Record()
+		content := getStringFromRecord(record, "content")
+		if content == "" {
+			continue
+		}
+		searchResult := SearchResult{
+			Type:    resultType,
+			ID:      getStringFromRecord(record, "id"),
+			Content: content,
+			Score:   getFloat64FromRecord(record, "score"),
+		}
+		if metadata, ok := record.Get("metadata"); ok {
+			if m, ok := metadata.(map[string]interface{}); ok {
+				searchResult.Metadata = m
+			}
+		}
+		results = append(results, searchResult)
+	}
+	return results, result.Err()
+}
+
+// normalizeSearchScores scales Lucene scores, which have no upper bound,
+// to 0-1 by dividing them by the best one
+func normalizeSearchScores(results []SearchResult) {
+	best := 0.0
+	for _, result := range results {
+		best = math.Max(best, result.Score)
+	}
+	if best <= 0 {
+		return
+	}
+	for i := range results {
+		results[i].Score /= best
+	}
+}
+
+// fullTextUnavailable reports whether err means a full-text index can't be
+// queried: it doesn't exist, or the database has no full-text procedures
+func fullTextUnavailable(err error) bool {
+	var neoErr *neo4j.Neo4jError
+	if !errors.As(err, &neoErr) {
+		return false
+	}
+	switch neoErr.Code {
+	case "Neo.ClientError.Procedure.ProcedureNotFound", "Neo.ClientError.Schema.IndexNotFound":
+		return true
+	case "Neo.ClientError.Procedure.ProcedureCallFailed":
+		return strings.Contains(strings.ToLower(neoErr.Msg), "index")
+	}
+	return false
+}
+
+// searchTerms splits a query into lowercase words. Punctuation, including
+// Lucene's query syntax, is dropped, so the terms are safe to pass to a
+// full-text index as they are.
+func searchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	seen := make(map[string]bool)
+	for _, word := range words {
+		// Lucene operators are only special in upper case, but skip them anyway
+		if seen[word] || word == "and" || word == "or" || word == "not" {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// highlightPattern matches any of terms, case-insensitively
+func highlightPattern(terms []string) *regexp.Regexp {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+}
+
+// highlightSnippet cuts content down to about snippetRadius characters on
+// either side of the first match, marking cuts with "…", and wraps every
+// match in the snippet in highlight markers. The text is HTML-escaped, so
+// the markers are the only markup and the snippet is safe to render. Content
+// without a match, such as one the index matched by stemming, is only
+// shortened.
+func highlightSnippet(content string, pattern *regexp.Regexp) string {
+	start, end := 0, len(content)
+	if loc := pattern.FindStringIndex(content); loc != nil {
+		start, end = loc[0], loc[1]
+	} else {
+		end = 0
+	}
+
+	from := snippetBoundary(content, start-snippetRadius, false)
+	to := snippetBoundary(content, end+snippetRadius, true)
+	var b strings.Builder
+	text, last := content[from:to], 0
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		b.WriteString(HighlightStart + html.EscapeString(text[loc[0]:loc[1]]) + HighlightEnd)
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	snippet := b.String()
+	if from > 0 {
+		snippet = "…" + snippet
+	}
+	if to < len(content) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// snippetBoundary moves i to a word boundary in text, away from the snippet
+// (forward for its end), so words aren't cut in half. Words longer than
+// maxSnippetShift, like URLs, are cut at the nearest character instead.
+func snippetBoundary(text string, i int, forward bool) int {
+	for shift := 0; shift <= maxSnippetShift; shift++ {
+		j := i - shift
+		if forward {
+			j = i + shift
+		}
+		if j <= 0 {
+			return 0
+		}
+		if j >= len(text) {
+			return len(text)
+		}
+		if (forward && isSpaceByte(text[j])) || (!forward && isSpaceByte(text[j-1])) {
+			return j
+		}
+	}
+	if i <= 0 {
+		return 0
+	}
+	if i >= len(text) {
+		return len(text)
+	}
+	for i > 0 && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\n' || b == '\t' || b == '\r'
+}
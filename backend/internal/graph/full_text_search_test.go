@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestSearchTerms(t *testing.T) {
+	cases := map[string][]string{
+		"Green tea":                  {"green", "tea"},
+		`title:"jazz" AND (blues)~2`: {"title", "jazz", "blues", "2"},
+		"café  CAFÉ café":            {"café"},
+		"*** ???":                    {},
+	}
+	for query, want := range cases {
+		if got := searchTerms(query); !reflect.DeepEqual(got, want) {
+			t.Errorf("searchTerms(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	pattern := highlightPattern([]string{"tea", "green"})
+
+	got := highlightSnippet("Likes Green tea in the morning", pattern)
+	want := "Likes <mark>Green</mark> <mark>tea</mark> in the morning"
+	if got != want {
+		t.Errorf("Expected short content highlighted whole, got %q", got)
+	}
+
+	long := strings.Repeat("word ", 40) + "drinks green tea " + strings.Repeat("more ", 40)
+	got = highlightSnippet(long, pattern)
+	if !strings.HasPrefix(got, "…word") || !strings.HasSuffix(got, "more…") {
+		t.Errorf("Expected a snippet cut at word boundaries on both sides, got %q", got)
+	}
+	if !strings.Contains(got, "drinks <mark>green</mark> <mark>tea</mark> more") {
+		t.Errorf("Expected the match highlighted in the snippet, got %q", got)
+	}
+	if len(got) > 2*snippetRadius+2*maxSnippetShift+len("green tea")+30 {
+		t.Errorf("Expected the snippet to be shortened, got %d bytes", len(got))
+	}
+
+	// Content the index matched some other way is just shortened
+	got = highlightSnippet(strings.Repeat("ünïcödé ", 40), pattern)
+	if strings.Contains(got, HighlightStart) || !strings.HasSuffix(got, "…") {
+		t.Errorf("Expected unmatched content shortened without highlights, got %q", got)
+	}
+	if !strings.HasPrefix(got, "ünïcödé") {
+		t.Errorf("Expected the snippet to start at the beginning, got %q", got)
+	}
+
+	// Stored content is escaped, so only the highlight markers are markup
+	got = highlightSnippet(`<img src=x onerror="alert(1)"> tea & <b>green</b>`, pattern)
+	want = `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>tea</mark> &amp; &lt;b&gt;<mark>green</mark>&lt;/b&gt;`
+	if got != want {
+		t.Errorf("Expected escaped content with highlights, got %q", got)
+	}
+}
+
+func TestNormalizeSearchScores(t *testing.T) {
+	results := []SearchResult{{Score: 7.5}, {Score: 3}, {Score: 1.5}}
+	normalizeSearchScores(results)
+	if results[0].Score != 1 || results[1].Score != 0.4 || results[2].Score != 0.2 {
+		t.Errorf("Expected scores relative to the best, got %+v", results)
+	}
+	normalizeSearchScores(nil)
+}
+
+func TestFullTextUnavailable(t *testing.T) {
+	missingIndex := &neo4j.Neo4jError{
+		Code: "Neo.ClientError.Procedure.ProcedureCallFailed",
+		Msg:  "Failed to invoke procedure `db.index.fulltext.queryNodes`: There is no such fulltext schema index: fact_content",
+	}
+	if !fullTextUnavailable(fmt.Errorf("query failed: %w", missingIndex)) {
+		t.Error("Expected a missing index to trigger the fallback")
+	}
+
+	syntax := &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError", Msg: "Invalid input"}
+	if fullTextUnavailable(syntax) || fullTextUnavailable(fmt.Errorf("connection refused")) {
+		t.Error("Expected other errors not to trigger the fallback")
+	}
+}
@@ -1,7 +1,12 @@
 package graph
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"ezra-clone/backend/pkg/logger"
+	"go.uber.org/zap"
 )
 
 // ============================================================================
@@ -33,6 +38,16 @@ func getIntFromRecord(record *neo4j.Record, key string) int {
 	return 0
 }
 
+// getOptionalIntFromRecord returns nil when the value is missing or null
+func getOptionalIntFromRecord(record *neo4j.Record, key string) *int {
+	val, ok := record.Get(key)
+	if !ok || val == nil {
+		return nil
+	}
+	i := getIntFromRecord(record, key)
+	return &i
+}
+
 func getInt64FromRecord(record *neo4j.Record, key string) int64 {
 	val, ok := record.Get(key)
 	if !ok || val == nil {
@@ -114,3 +129,32 @@ func getFloat64FromMap(m map[string]interface{}, key string, defaultValue float6
 	return defaultValue
 }
 
+// timeFromValue converts a property read from Neo4j to a time. Datetimes
+// arrive as time.Time; RFC3339 strings come from write paths that stored
+// timestamps as text. Anything else points at bad data, so it is logged
+// rather than silently replaced with defaultValue.
+func timeFromValue(key string, val interface{}, defaultValue time.Time) time.Time {
+	switch v := val.(type) {
+	case nil:
+		return defaultValue
+	case time.Time:
+		return v
+	case string:
+		if v == "" {
+			return defaultValue
+		}
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t
+		}
+		logger.Get().Warn("Unparseable timestamp in graph property",
+			zap.String("key", key),
+			zap.String("value", v),
+		)
+	default:
+		logger.Get().Warn("Unexpected type for timestamp in graph property",
+			zap.String("key", key),
+			zap.String("type", fmt.Sprintf("%T", val)),
+		)
+	}
+	return defaultValue
+}
@@ -0,0 +1,43 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTimeFromRecord(t *testing.T) {
+	stored := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	fallback := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	record := &neo4j.Record{
+		Keys:   []string{"datetime", "rfc3339", "nanos", "garbage", "number", "null"},
+		Values: []any{stored, "2024-03-01T12:30:00Z", "2024-03-01T14:30:00.5+02:00", "yesterday", int64(42), nil},
+	}
+
+	assert.True(t, stored.Equal(getTimeFromRecord(record, "datetime", fallback)))
+	assert.True(t, stored.Equal(getTimeFromRecord(record, "rfc3339", fallback)))
+	assert.True(t, stored.Add(500*time.Millisecond).Equal(getTimeFromRecord(record, "nanos", fallback)))
+	assert.Equal(t, fallback, getTimeFromRecord(record, "garbage", fallback))
+	assert.Equal(t, fallback, getTimeFromRecord(record, "number", fallback))
+	assert.Equal(t, fallback, getTimeFromRecord(record, "null", fallback))
+	assert.Equal(t, fallback, getTimeFromRecord(record, "missing", fallback))
+}
+
+func TestGetTimeFromMap(t *testing.T) {
+	stored := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	fallback := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	props := map[string]interface{}{
+		"datetime": stored,
+		"rfc3339":  "2024-03-01T12:30:00Z",
+		"empty":    "",
+		"number":   3.5,
+	}
+
+	assert.True(t, stored.Equal(getTimeFromMap(props, "datetime", fallback)))
+	assert.True(t, stored.Equal(getTimeFromMap(props, "rfc3339", fallback)))
+	assert.Equal(t, fallback, getTimeFromMap(props, "empty", fallback))
+	assert.Equal(t, fallback, getTimeFromMap(props, "number", fallback))
+	assert.Equal(t, fallback, getTimeFromMap(props, "missing", fallback))
+}
@@ -0,0 +1,50 @@
+package graph
+
+import "time"
+
+// MemoryEventType names a change to an agent or its stored knowledge
+type MemoryEventType string
+
+const (
+	EventFactCreated     MemoryEventType = "fact.created"
+	EventFactUpdated     MemoryEventType = "fact.updated"
+	EventFactDeleted     MemoryEventType = "fact.deleted"
+	EventArchivalCreated MemoryEventType = "archival.created"
+	EventArchivalUpdated MemoryEventType = "archival.updated"
+	EventArchivalDeleted MemoryEventType = "archival.deleted"
+	EventAgentCreated    MemoryEventType = "agent.created"
+)
+
+// MemoryEvent describes a committed change to a fact, archival memory or agent
+type MemoryEvent struct {
+	Type      MemoryEventType `json:"event"`
+	AgentID   string          `json:"agent_id,omitempty"`
+	ID        string          `json:"id"`
+	Content   string          `json:"content,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// MemoryEventListener is told about memory changes after they are committed.
+// OnMemoryEvent is called on the writer's goroutine, so it must not block.
+type MemoryEventListener interface {
+	OnMemoryEvent(event MemoryEvent)
+}
+
+// SetMemoryEventListener registers a listener for fact and archival memory
+// changes and agent creation. Pass nil to disable.
+func (r *Repository) SetMemoryEventListener(listener MemoryEventListener) {
+	r.memoryEvents = listener
+}
+
+func (r *Repository) emitMemoryEvent(eventType MemoryEventType, agentID, id, content string) {
+	if r.memoryEvents == nil {
+		return
+	}
+	r.memoryEvents.OnMemoryEvent(MemoryEvent{
+		Type:      eventType,
+		AgentID:   agentID,
+		ID:        id,
+		Content:   content,
+		Timestamp: time.Now().UTC(),
+	})
+}
@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MemoryLimitPolicy controls what UpdateMemory does with a block that is
+// larger than the configured limit
+type MemoryLimitPolicy string
+
+const (
+	// MemoryLimitReject refuses the update with ErrMemoryBlockTooLarge
+	MemoryLimitReject MemoryLimitPolicy = "reject"
+	// MemoryLimitArchive moves the oldest part of the block into an archival
+	// memory and keeps the rest under the limit
+	MemoryLimitArchive MemoryLimitPolicy = "archive"
+)
+
+// ParseMemoryLimitPolicy converts a config value to a policy
+func ParseMemoryLimitPolicy(value string) (MemoryLimitPolicy, error) {
+	switch policy := MemoryLimitPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case MemoryLimitReject, MemoryLimitArchive:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown memory limit policy: %q", value)
+	}
+}
+
+// ErrMemoryBlockTooLarge is returned when a memory block exceeds the size limit
+type ErrMemoryBlockTooLarge struct {
+	BlockName string
+	Size      int
+	Limit     int
+}
+
+func (e ErrMemoryBlockTooLarge) Error() string {
+	return fmt.Sprintf("memory block %q is %d characters, over the %d character limit", e.BlockName, e.Size, e.Limit)
+}
+
+// SetMemoryBlockLimit sets the maximum size of a core memory block in
+// characters and what happens when an update exceeds it. Zero disables the limit.
+func (r *Repository) SetMemoryBlockLimit(maxChars int, policy MemoryLimitPolicy) {
+	if maxChars >= 0 {
+		r.memoryBlockMaxChars = maxChars
+	}
+	if policy != "" {
+		r.memoryLimitPolicy = policy
+	}
+}
+
+// MemoryBlockLimit returns the configured block size limit (0 = unlimited)
+func (r *Repository) MemoryBlockLimit() int {
+	return r.memoryBlockMaxChars
+}
+
+// SplitMemoryOverflow splits content so that keep fits within limit
+// characters. Blocks grow by appending, so the oldest lines at the start are
+// moved to overflow first. A single line longer than the limit is cut,
+// keeping its end.
+func SplitMemoryOverflow(content string, limit int) (keep, overflow string) {
+	if limit <= 0 || utf8.RuneCountInString(content) <= limit {
+		return content, ""
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	size := utf8.RuneCountInString(content)
+	cut := 0
+	for cut < len(lines)-1 && size > limit {
+		size -= utf8.RuneCountInString(lines[cut])
+		cut++
+	}
+
+	overflow = strings.Join(lines[:cut], "")
+	keep = strings.Join(lines[cut:], "")
+	if runes := []rune(keep); len(runes) > limit {
+		overflow += string(runes[:len(runes)-limit])
+		keep = string(runes[len(runes)-limit:])
+	}
+	return strings.TrimLeft(keep, "\n"), strings.TrimSpace(overflow)
+}
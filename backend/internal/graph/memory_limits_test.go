@@ -0,0 +1,29 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestSplitMemoryOverflow(t *testing.T) {
+	content := "line one\nline two\nline three"
+
+	keep, overflow := SplitMemoryOverflow(content, 20)
+	if keep != "line two\nline three" {
+		t.Errorf("Expected newest lines to be kept, got %q", keep)
+	}
+	if overflow != "line one" {
+		t.Errorf("Expected oldest line to overflow, got %q", overflow)
+	}
+
+	keep, overflow = SplitMemoryOverflow(content, 100)
+	if keep != content || overflow != "" {
+		t.Errorf("Expected content under the limit to be untouched, got %q / %q", keep, overflow)
+	}
+}
+
+func TestSplitMemoryOverflow_SingleLongLine(t *testing.T) {
+	keep, overflow := SplitMemoryOverflow("abcdefghij", 4)
+	if keep != "ghij" || overflow != "abcdef" {
+		t.Errorf("Expected the end of the line to be kept, got %q / %q", keep, overflow)
+	}
+}
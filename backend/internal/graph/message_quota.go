@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// MessageQuotaUsage is how many messages a user has sent on a quota day
+type MessageQuotaUsage struct {
+	Day   string // UTC date, e.g. "2026-01-02"
+	Count int
+}
+
+// QuotaDay returns the quota window t falls in; windows are UTC days
+func QuotaDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// countMessage applies one message to usage. The count restarts on a new
+// day, and a message over limit is rejected without being counted.
+func countMessage(usage MessageQuotaUsage, day string, limit int) (MessageQuotaUsage, bool) {
+	if usage.Day != day {
+		usage = MessageQuotaUsage{Day: day}
+	}
+	if usage.Count >= limit {
+		return usage, false
+	}
+	usage.Count++
+	return usage, true
+}
+
+// ConsumeMessageQuota counts a message against the user's daily quota of
+// limit messages and reports whether it is allowed, along with the day's
+// count. Counts are kept on the User node, which is created if needed.
+func (r *Repository) ConsumeMessageQuota(ctx context.Context, userID string, limit int, now time.Time) (bool, MessageQuotaUsage, error) {
+	ctx, span := startQuerySpan(ctx, "ConsumeMessageQuota")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	type outcome struct {
+		allowed bool
+		usage   MessageQuotaUsage
+	}
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		// Writing first locks the node, so concurrent messages from the same
+		// user are counted one after another
+		readQuery := `
+			MERGE (u:User {id: $userID})
+			SET u.quota_day = coalesce(u.quota_day, '')
+			RETURN u.quota_day as day, coalesce(u.quota_count, 0) as count
+		`
+		readResult, err := tx.Run(ctx, readQuery, map[string]interface{}{"userID": userID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message quota: %w", err)
+		}
+		record, err := readResult.Single(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message quota: %w", err)
+		}
+
+		stored := MessageQuotaUsage{
+			Day:   getStringFromRecord(record, "day"),
+			Count: getIntFromRecord(record, "count"),
+		}
+		usage, allowed := countMessage(stored, QuotaDay(now), limit)
+		if usage == stored {
+			return outcome{allowed: allowed, usage: usage}, nil
+		}
+
+		writeQuery := `
+			MATCH (u:User {id: $userID})
+			SET u.quota_day = $day, u.quota_count = $count
+		`
+		if _, err := tx.Run(ctx, writeQuery, map[string]interface{}{
+			"userID": userID,
+			"day":    usage.Day,
+			"count":  usage.Count,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to update message quota: %w", err)
+		}
+		return outcome{allowed: allowed, usage: usage}, nil
+	})
+	if err != nil {
+		return false, MessageQuotaUsage{}, err
+	}
+
+	consumed := result.(outcome)
+	return consumed.allowed, consumed.usage, nil
+}
+
+// QuotaExceededMessage is the reply sent once a user has used up their quota
+func QuotaExceededMessage(limit int) string {
+	return fmt.Sprintf("You've reached your daily limit of %d messages. It resets at midnight UTC - talk to you then!", limit)
+}
@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountMessage_RejectsOverLimitAndResetsDaily(t *testing.T) {
+	const limit = 3
+	day1 := QuotaDay(time.Date(2026, 3, 14, 23, 59, 0, 0, time.UTC))
+	day2 := QuotaDay(time.Date(2026, 3, 15, 0, 1, 0, 0, time.UTC))
+
+	var usage MessageQuotaUsage
+	for i := 1; i <= limit; i++ {
+		var allowed bool
+		usage, allowed = countMessage(usage, day1, limit)
+		if !allowed {
+			t.Fatalf("Expected message %d of %d to be allowed", i, limit)
+		}
+	}
+
+	usage, allowed := countMessage(usage, day1, limit)
+	if allowed {
+		t.Error("Expected the message after the limit to be rejected")
+	}
+	if usage.Count != limit {
+		t.Errorf("Expected a rejected message not to be counted, got %d", usage.Count)
+	}
+
+	usage, allowed = countMessage(usage, day2, limit)
+	if !allowed || usage.Count != 1 || usage.Day != day2 {
+		t.Errorf("Expected the count to reset on a new day, got allowed=%v usage=%+v", allowed, usage)
+	}
+}
+
+func TestQuotaDay_UsesUTC(t *testing.T) {
+	// 23:30 in New York on the 14th is already the 15th in UTC
+	ny := time.FixedZone("EST", -5*60*60)
+	if day := QuotaDay(time.Date(2026, 3, 14, 23, 30, 0, 0, ny)); day != "2026-03-15" {
+		t.Errorf("Expected 2026-03-15, got %s", day)
+	}
+}
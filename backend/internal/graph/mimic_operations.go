@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// MimicState is an agent's persisted mimic mode, so it survives restarts.
+// The profile is stored as the same JSON used for cached personality profiles.
+type MimicState struct {
+	AgentID             string
+	Active              bool
+	UserID              string // User being mimicked
+	ChannelID           string // Channel the user's messages were analyzed in
+	OriginalPersonality string
+	ProfileJSON         string
+}
+
+// SaveMimicState stores an agent's mimic state, replacing any earlier one.
+// Saving an inactive state keeps LoadMimicStates from restoring the agent.
+func (r *Repository) SaveMimicState(ctx context.Context, state MimicState) error {
+	ctx, span := startQuerySpan(ctx, "SaveMimicState")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (a:Agent {id: $agentID})
+		MERGE (a)-[:HAS_MIMIC_STATE]->(m:MimicState)
+		SET m.active = $active,
+		    m.user_id = $userID,
+		    m.channel_id = $channelID,
+		    m.original_personality = $originalPersonality,
+		    m.profile_data = $profileJSON,
+		    m.updated_at = datetime($now)
+		RETURN a.id as id
+	`
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"agentID":             state.AgentID,
+		"active":              state.Active,
+		"userID":              state.UserID,
+		"channelID":           state.ChannelID,
+		"originalPersonality": state.OriginalPersonality,
+		"profileJSON":         state.ProfileJSON,
+		"now":                 time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save mimic state: %w", err)
+	}
+	if !result.Next(ctx) {
+		return fmt.Errorf("agent not found: %s", state.AgentID)
+	}
+
+	r.logger.Debug("Mimic state saved",
+		zap.String("agent_id", state.AgentID),
+		zap.Bool("active", state.Active),
+	)
+	return nil
+}
+
+// LoadMimicStates returns every agent's active mimic state
+func (r *Repository) LoadMimicStates(ctx context.Context) ([]MimicState, error) {
+	ctx, span := startQuerySpan(ctx, "LoadMimicStates")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (a:Agent)-[:HAS_MIMIC_STATE]->(m:MimicState)
+		WHERE m.active = true
+		RETURN a.id as agent_id, m.user_id as user_id, m.channel_id as channel_id,
+		       m.original_personality as original_personality, m.profile_data as profile_data
+		ORDER BY a.id
+	`
+	result, err := session.Run(ctx, query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mimic states: %w", err)
+	}
+
+	var states []MimicState
+	for result.Next(ctx) {
+		record := result.Record()
+		states = append(states, MimicState{
+			AgentID:             getStringFromRecord(record, "agent_id"),
+			Active:              true,
+			UserID:              getStringFromRecord(record, "user_id"),
+			ChannelID:           getStringFromRecord(record, "channel_id"),
+			OriginalPersonality: getStringFromRecord(record, "original_personality"),
+			ProfileJSON:         getStringFromRecord(record, "profile_data"),
+		})
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load mimic states: %w", err)
+	}
+	return states, nil
+}
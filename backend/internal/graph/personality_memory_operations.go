@@ -3,6 +3,7 @@ package graph
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +15,10 @@ import (
 // Personality Memory Operations (RAG for User Facts/Opinions)
 // ============================================================================
 
+// DefaultMaxPersonalityMemories is the default per-user cap on stored
+// personality memories
+const DefaultMaxPersonalityMemories = 200
+
 // UserPersonalityMemory represents a stored memory/fact about a user
 type UserPersonalityMemory struct {
 	ID          string    `json:"id"`
@@ -24,6 +29,47 @@ type UserPersonalityMemory struct {
 	Tags        []string  `json:"tags"`       // Optional tags for categorization
 	CreatedAt   time.Time `json:"created_at"`
 	Consented   bool      `json:"consented"` // Whether user explicitly consented to this memory
+	Importance  float64   `json:"importance"`   // Grows each time the same opinion is observed again
+	LastUsedAt  time.Time `json:"last_used_at"` // Last time the memory was stored, reinforced or retrieved
+}
+
+// SetMaxPersonalityMemories sets the per-user cap on stored personality
+// memories. Zero disables the cap.
+func (r *Repository) SetMaxPersonalityMemories(limit int) {
+	if limit >= 0 {
+		r.maxPersonalityMemories = limit
+	}
+}
+
+// PersonalityMemoriesToEvict returns the IDs to delete so that at most limit
+// memories remain. The least important memories go first, and ties go to the
+// least recently used.
+func PersonalityMemoriesToEvict(memories []UserPersonalityMemory, limit int) []string {
+	if limit <= 0 || len(memories) <= limit {
+		return nil
+	}
+
+	sorted := make([]UserPersonalityMemory, len(memories))
+	copy(sorted, memories)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Importance != sorted[j].Importance {
+			return sorted[i].Importance < sorted[j].Importance
+		}
+		return lastUsed(sorted[i]).Before(lastUsed(sorted[j]))
+	})
+
+	evict := make([]string, 0, len(sorted)-limit)
+	for _, memory := range sorted[:len(sorted)-limit] {
+		evict = append(evict, memory.ID)
+	}
+	return evict
+}
+
+func lastUsed(memory UserPersonalityMemory) time.Time {
+	if memory.LastUsedAt.IsZero() {
+		return memory.CreatedAt
+	}
+	return memory.LastUsedAt
 }
 
 // StoreUserPersonalityMemory stores a consented memory/fact about a user
@@ -49,6 +95,15 @@ func (r *Repository) StoreUserPersonalityMemory(ctx context.Context, userID, con
 		return nil, fmt.Errorf("failed to ensure user exists: %w", err)
 	}
 
+	// Reinforce an existing memory instead of storing the same opinion twice
+	existing, err := r.findSimilarPersonalityMemory(ctx, session, userID, content)
+	if err != nil {
+		return nil, err
+	}
+	if existing != "" {
+		return r.reinforcePersonalityMemory(ctx, session, userID, existing, now)
+	}
+
 	// Create the personality memory node
 	query := `
 		MATCH (u:User {id: $userID})
@@ -59,12 +114,15 @@ func (r *Repository) StoreUserPersonalityMemory(ctx context.Context, userID, con
 			channel_id: $channelID,
 			tags: $tags,
 			consented: $consented,
-			created_at: datetime($now)
+			importance: 1.0,
+			created_at: datetime($now),
+			last_used_at: datetime($now)
 		})
 		CREATE (u)-[:HAS_PERSONALITY_MEMORY]->(m)
 		RETURN m.id as id, m.content as content, m.source as source,
 		       m.channel_id as channel_id, m.tags as tags,
-		       m.consented as consented, m.created_at as created_at
+		       m.consented as consented, m.created_at as created_at,
+		       m.importance as importance, m.last_used_at as last_used_at
 	`
 
 	result, err := session.Run(ctx, query, map[string]interface{}{
@@ -85,19 +143,7 @@ func (r *Repository) StoreUserPersonalityMemory(ctx context.Context, userID, con
 		return nil, fmt.Errorf("failed to retrieve created memory")
 	}
 
-	record := result.Record()
-	createdAt, _ := time.Parse(time.RFC3339, getStringFromRecord(record, "created_at"))
-
-	memory := &UserPersonalityMemory{
-		ID:        getStringFromRecord(record, "id"),
-		UserID:    userID,
-		Content:   getStringFromRecord(record, "content"),
-		Source:    getStringFromRecord(record, "source"),
-		ChannelID: getStringFromRecord(record, "channel_id"),
-		Tags:      getStringSliceFromRecord(record, "tags"),
-		Consented: getBoolFromRecord(record, "consented"),
-		CreatedAt: createdAt,
-	}
+	memory := personalityMemoryFromRecord(result.Record(), userID)
 
 	r.logger.Info("Personality memory stored",
 		zap.String("memory_id", memoryID),
@@ -105,9 +151,142 @@ func (r *Repository) StoreUserPersonalityMemory(ctx context.Context, userID, con
 		zap.Bool("consented", consented),
 	)
 
+	if err := r.enforcePersonalityMemoryCap(ctx, session, userID); err != nil {
+		r.logger.Warn("Failed to enforce personality memory cap",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+	}
+
 	return memory, nil
 }
 
+// findSimilarPersonalityMemory returns the ID of a stored memory for the user
+// that duplicates content, or "" if there is none
+func (r *Repository) findSimilarPersonalityMemory(ctx context.Context, session neo4j.SessionWithContext, userID, content string) (string, error) {
+	query := `
+		MATCH (u:User {id: $userID})-[:HAS_PERSONALITY_MEMORY]->(m:UserPersonalityMemory)
+		RETURN m.id as id, m.content as content
+	`
+
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"userID": userID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to check for duplicate personality memory: %w", err)
+	}
+
+	normalized := normalizeFactContent(content)
+	for result.Next(ctx) {
+		record := result.Record()
+		existing := normalizeFactContent(getStringFromRecord(record, "content"))
+		if existing == normalized || areFactsSimilar(existing, normalized) {
+			return getStringFromRecord(record, "id"), nil
+		}
+	}
+	return "", nil
+}
+
+// reinforcePersonalityMemory bumps the importance of a memory that was observed again
+func (r *Repository) reinforcePersonalityMemory(ctx context.Context, session neo4j.SessionWithContext, userID, memoryID, now string) (*UserPersonalityMemory, error) {
+	query := `
+		MATCH (m:UserPersonalityMemory {id: $memoryID})
+		SET m.importance = coalesce(m.importance, 1.0) + 1.0,
+		    m.last_used_at = datetime($now)
+		RETURN m.id as id, m.content as content, m.source as source,
+		       m.channel_id as channel_id, m.tags as tags,
+		       m.consented as consented, m.created_at as created_at,
+		       m.importance as importance, m.last_used_at as last_used_at
+	`
+
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"memoryID": memoryID,
+		"now":      now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reinforce personality memory: %w", err)
+	}
+	if !result.Next(ctx) {
+		return nil, fmt.Errorf("failed to retrieve reinforced memory")
+	}
+
+	r.logger.Debug("Personality memory reinforced",
+		zap.String("memory_id", memoryID),
+		zap.String("user_id", userID),
+	)
+	return personalityMemoryFromRecord(result.Record(), userID), nil
+}
+
+// enforcePersonalityMemoryCap evicts the user's least valuable memories once
+// they exceed the configured cap
+func (r *Repository) enforcePersonalityMemoryCap(ctx context.Context, session neo4j.SessionWithContext, userID string) error {
+	if r.maxPersonalityMemories <= 0 {
+		return nil
+	}
+
+	query := `
+		MATCH (u:User {id: $userID})-[:HAS_PERSONALITY_MEMORY]->(m:UserPersonalityMemory)
+		RETURN m.id as id, coalesce(m.importance, 1.0) as importance,
+		       m.created_at as created_at, m.last_used_at as last_used_at
+	`
+
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"userID": userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list personality memories: %w", err)
+	}
+
+	var memories []UserPersonalityMemory
+	for result.Next(ctx) {
+		record := result.Record()
+		memories = append(memories, UserPersonalityMemory{
+			ID:         getStringFromRecord(record, "id"),
+			Importance: getFloat64FromRecord(record, "importance"),
+			CreatedAt:  getTimeFromRecord(record, "created_at", time.Time{}),
+			LastUsedAt: getTimeFromRecord(record, "last_used_at", time.Time{}),
+		})
+	}
+
+	evict := PersonalityMemoriesToEvict(memories, r.maxPersonalityMemories)
+	if len(evict) == 0 {
+		return nil
+	}
+
+	_, err = session.Run(ctx, `
+		MATCH (m:UserPersonalityMemory)
+		WHERE m.id IN $ids
+		DETACH DELETE m
+	`, map[string]interface{}{
+		"ids": evict,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to evict personality memories: %w", err)
+	}
+
+	r.logger.Info("Evicted personality memories over cap",
+		zap.String("user_id", userID),
+		zap.Int("evicted", len(evict)),
+		zap.Int("cap", r.maxPersonalityMemories),
+	)
+	return nil
+}
+
+func personalityMemoryFromRecord(record *neo4j.Record, userID string) *UserPersonalityMemory {
+	return &UserPersonalityMemory{
+		ID:         getStringFromRecord(record, "id"),
+		UserID:     userID,
+		Content:    getStringFromRecord(record, "content"),
+		Source:     getStringFromRecord(record, "source"),
+		ChannelID:  getStringFromRecord(record, "channel_id"),
+		Tags:       getStringSliceFromRecord(record, "tags"),
+		Consented:  getBoolFromRecord(record, "consented"),
+		CreatedAt:  getTimeFromRecord(record, "created_at", time.Time{}),
+		Importance: getFloat64FromRecord(record, "importance"),
+		LastUsedAt: getTimeFromRecord(record, "last_used_at", time.Time{}),
+	}
+}
+
 // RetrieveUserPersonalityMemories retrieves relevant memories for a user based on query similarity
 // Uses text-based similarity search (can be upgraded to vector search)
 func (r *Repository) RetrieveUserPersonalityMemories(ctx context.Context, userID, query string, limit int) ([]UserPersonalityMemory, error) {
@@ -167,9 +346,39 @@ func (r *Repository) RetrieveUserPersonalityMemories(ctx context.Context, userID
 		memories = append(memories, memory)
 	}
 
+	r.touchPersonalityMemories(ctx, memories)
+
 	return memories, nil
 }
 
+// touchPersonalityMemories records that memories were just used so eviction
+// keeps recently retrieved ones. Failures are logged, not returned.
+func (r *Repository) touchPersonalityMemories(ctx context.Context, memories []UserPersonalityMemory) {
+	if len(memories) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(memories))
+	for _, memory := range memories {
+		ids = append(ids, memory.ID)
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.Run(ctx, `
+		MATCH (m:UserPersonalityMemory)
+		WHERE m.id IN $ids
+		SET m.last_used_at = datetime($now)
+	`, map[string]interface{}{
+		"ids": ids,
+		"now": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		r.logger.Debug("Failed to touch personality memories", zap.Error(err))
+	}
+}
+
 // GetAllUserPersonalityMemories retrieves all consented memories for a user
 func (r *Repository) GetAllUserPersonalityMemories(ctx context.Context, userID string) ([]UserPersonalityMemory, error) {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
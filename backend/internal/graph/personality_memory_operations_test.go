@@ -0,0 +1,34 @@
+package graph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPersonalityMemoriesToEvict_OverCap(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	memories := []UserPersonalityMemory{
+		{ID: "important-old", Importance: 3, CreatedAt: base},
+		{ID: "oldest", Importance: 1, CreatedAt: base},
+		{ID: "recently-used", Importance: 1, CreatedAt: base.Add(time.Hour), LastUsedAt: base.Add(5 * time.Hour)},
+		{ID: "newer", Importance: 1, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: "newest", Importance: 1, CreatedAt: base.Add(3 * time.Hour)},
+	}
+
+	evict := PersonalityMemoriesToEvict(memories, 3)
+
+	if len(evict) != 2 || evict[0] != "oldest" || evict[1] != "newer" {
+		t.Errorf("Expected [oldest newer] to be evicted, got %v", evict)
+	}
+}
+
+func TestPersonalityMemoriesToEvict_UnderCap(t *testing.T) {
+	memories := []UserPersonalityMemory{{ID: "a"}, {ID: "b"}}
+
+	if evict := PersonalityMemoriesToEvict(memories, 2); len(evict) != 0 {
+		t.Errorf("Expected nothing evicted at the cap, got %v", evict)
+	}
+	if evict := PersonalityMemoriesToEvict(memories, 0); len(evict) != 0 {
+		t.Errorf("Expected a zero cap to disable eviction, got %v", evict)
+	}
+}
@@ -3,7 +3,11 @@ package graph
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
@@ -16,14 +20,187 @@ import (
 type Repository struct {
 	driver neo4j.DriverWithContext
 	logger *zap.Logger
+
+	maxPersonalityMemories int                 // Per-user cap on personality memories (0 = unlimited)
+	memoryBlockMaxChars    int                 // Max core memory block size in characters (0 = unlimited)
+	memoryLimitPolicy      MemoryLimitPolicy   // What to do with blocks over memoryBlockMaxChars
+	factHalfLife           time.Duration       // Half-life of fact confidence at read time (0 = no decay)
+	memoryEvents           MemoryEventListener // Notified of fact/archival changes (nil = disabled)
+
+	archivalAutoScore        bool    // Compute relevance for archives stored without one
+	archivalDefaultRelevance float64 // Relevance for archives stored without one when not computed
+
+	archivalAccessMu sync.Mutex
+	archivalAccess   map[string]archivalAccess // Searches' accesses not yet written, by archive ID
 }
 
+// DefaultArchivalRelevance is the relevance score given to archival memories
+// stored without one, unless automatic scoring is on
+const DefaultArchivalRelevance = 0.5
+
 // NewRepository creates a new graph repository
 func NewRepository(driver neo4j.DriverWithContext) *Repository {
 	return &Repository{
-		driver: driver,
-		logger: logger.Get(),
+		driver:                 driver,
+		logger:                 logger.Get(),
+		maxPersonalityMemories: DefaultMaxPersonalityMemories,
+		memoryLimitPolicy:      MemoryLimitReject,
+
+		archivalDefaultRelevance: DefaultArchivalRelevance,
+	}
+}
+
+// EnsureConstraints creates the schema constraints the repository relies on
+// and backfills the properties they cover on nodes stored before them. It is
+// idempotent and safe to call on every startup.
+func (r *Repository) EnsureConstraints(ctx context.Context) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	// Makes the MERGE in CreateFact race-safe: concurrent merges of the same fact
+	// serialize on the constraint instead of each creating a node.
+	query := `
+		CREATE CONSTRAINT fact_identity IF NOT EXISTS
+		FOR (f:Fact) REQUIRE (f.agent_id, f.user_id, f.content_hash) IS UNIQUE
+	`
+	if _, err := session.Run(ctx, query, nil); err != nil {
+		return fmt.Errorf("failed to create fact constraint: %w", err)
+	}
+
+	// Facts stored before the constraint lack the properties it covers
+	backfilled, merged, err := r.backfillFactIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to backfill fact identity: %w", err)
+	}
+	if backfilled > 0 {
+		r.logger.Info("Backfilled fact identity",
+			zap.Int("facts", backfilled),
+			zap.Int("merged", merged),
+		)
+	}
+
+	// Makes the MERGEs in LogMessage and LogInteraction race-safe. Duplicates
+	// left by earlier races must go first, or the constraints can't be created.
+	messages, interactions, err := r.mergeDuplicateMessages(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to merge duplicate messages: %w", err)
+	}
+	if messages > 0 || interactions > 0 {
+		r.logger.Info("Merged duplicate messages",
+			zap.Int("messages", messages),
+			zap.Int("interactions", interactions),
+		)
+	}
+	query = `
+		CREATE CONSTRAINT message_id IF NOT EXISTS
+		FOR (m:Message) REQUIRE m.id IS UNIQUE
+	`
+	if _, err := session.Run(ctx, query, nil); err != nil {
+		return fmt.Errorf("failed to create message constraint: %w", err)
+	}
+	query = `
+		CREATE CONSTRAINT interaction_id IF NOT EXISTS
+		FOR (i:Interaction) REQUIRE i.id IS UNIQUE
+	`
+	if _, err := session.Run(ctx, query, nil); err != nil {
+		return fmt.Errorf("failed to create interaction constraint: %w", err)
+	}
+
+	// Edits and deletes arrive with only the platform's message ID
+	query = `
+		CREATE INDEX message_platform_id IF NOT EXISTS
+		FOR (m:Message) ON (m.platform_message_id)
+	`
+	if _, err := session.Run(ctx, query, nil); err != nil {
+		return fmt.Errorf("failed to create message index: %w", err)
+	}
+
+	// The bot reloads pending scheduled messages on every startup
+	query = `
+		CREATE INDEX scheduled_message_status IF NOT EXISTS
+		FOR (s:ScheduledMessage) ON (s.status)
+	`
+	if _, err := session.Run(ctx, query, nil); err != nil {
+		return fmt.Errorf("failed to create scheduled message index: %w", err)
 	}
+
+	return nil
+}
+
+// mergeDuplicateMessages folds messages and interactions sharing an ID, as
+// concurrent merges created them before IDs were unique, into the oldest of
+// each. Returns how many duplicate messages and interactions were deleted.
+func (r *Repository) mergeDuplicateMessages(ctx context.Context) (messages, interactions int, err error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		messagesQuery := `
+			MATCH (m:Message)
+			WHERE m.id IS NOT NULL
+			WITH m ORDER BY m.timestamp, elementId(m)
+			WITH m.id as id, collect(m) as nodes
+			WHERE size(nodes) > 1
+			WITH head(nodes) as keep, tail(nodes) as duplicates
+			UNWIND duplicates as duplicate
+			WITH keep, duplicate,
+			     [(c:Conversation)-[:CONTAINS]->(duplicate) | c] as conversations,
+			     [(sender)-[:SENT]->(duplicate) | sender] as senders,
+			     [(duplicate)-[:REPLIES_TO]->(parent) WHERE parent <> keep | parent] as parents,
+			     [(reply)-[:REPLIES_TO]->(duplicate) WHERE reply <> keep | reply] as replies,
+			     [(duplicate)-[:MENTIONS]->(u) | u] as mentioned,
+			     [(duplicate)-[:ABOUT_TOPIC]->(t) | t] as topics
+			FOREACH (c IN conversations | MERGE (c)-[:CONTAINS]->(keep))
+			FOREACH (sender IN senders | MERGE (sender)-[:SENT]->(keep))
+			FOREACH (parent IN parents | MERGE (keep)-[:REPLIES_TO]->(parent))
+			FOREACH (reply IN replies | MERGE (reply)-[:REPLIES_TO]->(keep))
+			FOREACH (u IN mentioned | MERGE (keep)-[:MENTIONS]->(u))
+			FOREACH (t IN topics | MERGE (keep)-[:ABOUT_TOPIC]->(t))
+			DETACH DELETE duplicate
+			RETURN count(*) as merged
+		`
+		result, err := tx.Run(ctx, messagesQuery, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge duplicate messages: %w", err)
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge duplicate messages: %w", err)
+		}
+		counts := []int{getIntFromRecord(record, "merged"), 0}
+
+		interactionsQuery := `
+			MATCH (i:Interaction)
+			WHERE i.id IS NOT NULL
+			WITH i ORDER BY i.timestamp, elementId(i)
+			WITH i.id as id, collect(i) as nodes
+			WHERE size(nodes) > 1
+			WITH head(nodes) as keep, tail(nodes) as duplicates
+			UNWIND duplicates as duplicate
+			WITH keep, duplicate,
+			     [(duplicate)-[:WITH_AGENT]->(a:Agent) | a] as agents,
+			     [(duplicate)-[:FROM_USER]->(u:User) | u] as users
+			FOREACH (a IN agents | MERGE (keep)-[:WITH_AGENT]->(a))
+			FOREACH (u IN users | MERGE (keep)-[:FROM_USER]->(u))
+			DETACH DELETE duplicate
+			RETURN count(*) as merged
+		`
+		result, err = tx.Run(ctx, interactionsQuery, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge duplicate interactions: %w", err)
+		}
+		record, err = result.Single(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge duplicate interactions: %w", err)
+		}
+		counts[1] = getIntFromRecord(record, "merged")
+		return counts, nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	counts := result.([]int)
+	return counts[0], counts[1], nil
 }
 
 // Close closes the Neo4j driver connection
@@ -33,6 +210,9 @@ func (r *Repository) Close() error {
 
 // FetchState retrieves the complete context window for an agent
 func (r *Repository) FetchState(ctx context.Context, agentID string) (*state.ContextWindow, error) {
+	ctx, span := startQuerySpan(ctx, "FetchState")
+	defer span.End()
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
@@ -50,9 +230,11 @@ func (r *Repository) FetchState(ctx context.Context, agentID string) (*state.Con
 			collect(DISTINCT {
 				name: m.name,
 				content: m.content,
+				order: m.order,
 				updated_at: m.updated_at
 			}) as memories,
 			collect(DISTINCT {
+				id: arch.id,
 				summary: arch.summary,
 				timestamp: arch.timestamp,
 				relevance_score: arch.relevance_score
@@ -95,11 +277,16 @@ func (r *Repository) FetchState(ctx context.Context, agentID string) (*state.Con
 				if name, ok := memMap["name"].(string); ok && name != "" {
 					content := getStringFromMap(memMap, "content", "")
 					updatedAt := getTimeFromMap(memMap, "updated_at", time.Now())
-					cw.CoreMemory = append(cw.CoreMemory, state.MemoryBlock{
+					block := state.MemoryBlock{
 						Name:      name,
 						Content:   content,
 						UpdatedAt: updatedAt,
-					})
+					}
+					if order, ok := memMap["order"].(int64); ok {
+						o := int(order)
+						block.Order = &o
+					}
+					cw.CoreMemory = append(cw.CoreMemory, block)
 				}
 			}
 		}
@@ -115,6 +302,7 @@ func (r *Repository) FetchState(ctx context.Context, agentID string) (*state.Con
 					timestamp := getTimeFromMap(archMap, "timestamp", time.Now())
 					score := getFloat64FromMap(archMap, "relevance_score", 0.0)
 					cw.ArchivalRefs = append(cw.ArchivalRefs, state.ArchivalPointer{
+						ID:             getStringFromMap(archMap, "id", ""),
 						Summary:        summary,
 						Timestamp:      timestamp,
 						RelevanceScore: score,
@@ -124,12 +312,57 @@ func (r *Repository) FetchState(ctx context.Context, agentID string) (*state.Con
 		}
 	}
 
+	// collect() returns rows in no particular order; sort them so identical
+	// state always renders the same prompt
+	sortContextWindow(cw)
+
 	return cw, nil
 }
 
+// sortContextWindow orders memory blocks by their order, then by name, with
+// unordered blocks last, and archival refs from oldest to newest
+func sortContextWindow(cw *state.ContextWindow) {
+	sort.SliceStable(cw.CoreMemory, func(i, j int) bool {
+		a, b := cw.CoreMemory[i], cw.CoreMemory[j]
+		if (a.Order == nil) != (b.Order == nil) {
+			return a.Order != nil
+		}
+		if a.Order != nil && *a.Order != *b.Order {
+			return *a.Order < *b.Order
+		}
+		return a.Name < b.Name
+	})
+	sort.SliceStable(cw.ArchivalRefs, func(i, j int) bool {
+		a, b := cw.ArchivalRefs[i], cw.ArchivalRefs[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		return a.ID < b.ID
+	})
+}
+
 // UpdateMemory updates or creates a memory block for an agent
-// If the agent doesn't exist, it will be created automatically
+// If the agent doesn't exist, it will be created automatically.
+// Content over the block size limit is rejected with ErrMemoryBlockTooLarge,
+// or with the archive policy its oldest part is moved to archival memory.
 func (r *Repository) UpdateMemory(ctx context.Context, agentID, blockName, newContent string) error {
+	return r.UpdateMemoryWithOrder(ctx, agentID, blockName, newContent, nil)
+}
+
+// UpdateMemoryWithOrder is UpdateMemory that also sets the block's position
+// in the prompt. A nil order leaves the block's current position alone.
+func (r *Repository) UpdateMemoryWithOrder(ctx context.Context, agentID, blockName, newContent string, order *int) error {
+	ctx, span := startQuerySpan(ctx, "UpdateMemory")
+	defer span.End()
+
+	overflow := ""
+	if size := utf8.RuneCountInString(newContent); r.memoryBlockMaxChars > 0 && size > r.memoryBlockMaxChars {
+		if r.memoryLimitPolicy != MemoryLimitArchive {
+			return ErrMemoryBlockTooLarge{BlockName: blockName, Size: size, Limit: r.memoryBlockMaxChars}
+		}
+		newContent, overflow = SplitMemoryOverflow(newContent, r.memoryBlockMaxChars)
+	}
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
@@ -154,17 +387,47 @@ func (r *Repository) UpdateMemory(ctx context.Context, agentID, blockName, newCo
 		MATCH (a:Agent {id: $agentID})
 		MERGE (a)-[:HAS_MEMORY]->(m:Memory {name: $blockName})
 		SET m.content = $newContent,
+		    m.order = CASE WHEN $setOrder THEN $order ELSE m.order END,
 		    m.updated_at = datetime()
 		RETURN m.name as name
 	`
-
-	_, err = session.Run(ctx, query, map[string]interface{}{
+	params := map[string]interface{}{
 		"agentID":   agentID,
 		"blockName": blockName,
 		"newContent": newContent,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to update memory: %w", err)
+		"setOrder":  order != nil,
+		"order":     nil,
+	}
+	if order != nil {
+		params["order"] = *order
+	}
+
+	if overflow == "" {
+		_, err = session.Run(ctx, query, params)
+		if err != nil {
+			return fmt.Errorf("failed to update memory: %w", err)
+		}
+	} else {
+		// Trim the block and archive the overflow together so nothing is lost
+		archived, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			if _, err := tx.Run(ctx, query, params); err != nil {
+				return nil, fmt.Errorf("failed to update memory: %w", err)
+			}
+			return r.createArchivalMemoryTx(ctx, tx, agentID, ArchivalMemory{
+				Summary:   fmt.Sprintf("Overflow archived from memory block '%s'", blockName),
+				Content:   overflow,
+				Timestamp: time.Now(),
+			}, true)
+		})
+		if err != nil {
+			return err
+		}
+		r.logger.Info("Archived memory block overflow",
+			zap.String("agent_id", agentID),
+			zap.String("block_name", blockName),
+			zap.Int("archived_chars", utf8.RuneCountInString(overflow)),
+		)
+		r.emitMemoryEvent(EventArchivalCreated, agentID, archived.(ArchivalImportResult).ID, overflow)
 	}
 
 	r.logger.Info("Memory block updated",
@@ -208,30 +471,40 @@ func (r *Repository) DeleteMemory(ctx context.Context, agentID, blockName string
 	return nil
 }
 
-// LogInteraction logs an interaction between a user and an agent
-func (r *Repository) LogInteraction(ctx context.Context, agentID, userID, message string, timestamp time.Time) error {
+// LogInteraction logs an interaction between a user and an agent.
+// interactionID makes the write idempotent: logging the same ID twice is a no-op.
+// If interactionID is empty a new one is generated.
+func (r *Repository) LogInteraction(ctx context.Context, agentID, userID, interactionID, message string, timestamp time.Time) error {
+	ctx, span := startQuerySpan(ctx, "LogInteraction")
+	defer span.End()
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
+	if interactionID == "" {
+		interactionID = uuid.New().String()
+	}
+
 	// Convert to UTC and format as ISO 8601 string for Neo4j compatibility
 	timestampStr := timestamp.UTC().Format(time.RFC3339)
 
 	query := `
 		MATCH (a:Agent {id: $agentID})
 		MERGE (u:User {id: $userID})
-		CREATE (i:Interaction {
-			message: $message,
-			timestamp: datetime($timestamp)
-		})
-		CREATE (a)<-[:WITH_AGENT]-(i)-[:FROM_USER]->(u)
+		MERGE (i:Interaction {id: $interactionID})
+		ON CREATE SET i.message = $message,
+		              i.timestamp = datetime($timestamp)
+		MERGE (a)<-[:WITH_AGENT]-(i)
+		MERGE (i)-[:FROM_USER]->(u)
 		RETURN i
 	`
 
 	_, err := session.Run(ctx, query, map[string]interface{}{
-		"agentID":   agentID,
-		"userID":    userID,
-		"message":   message,
-		"timestamp": timestampStr,
+		"agentID":       agentID,
+		"userID":        userID,
+		"interactionID": interactionID,
+		"message":       message,
+		"timestamp":     timestampStr,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to log interaction: %w", err)
@@ -246,10 +519,12 @@ func (r *Repository) CreateAgent(ctx context.Context, agentID, name string) erro
 	defer session.Close(ctx)
 
 	query := `
+		OPTIONAL MATCH (existing:Agent {id: $agentID})
+		WITH existing IS NULL as created
 		MERGE (a:Agent {id: $agentID})
 		SET a.name = $name,
 		    a.created_at = datetime()
-		RETURN a.id as id
+		RETURN a.id as id, created
 	`
 
 	result, err := session.Run(ctx, query, map[string]interface{}{
@@ -260,7 +535,7 @@ func (r *Repository) CreateAgent(ctx context.Context, agentID, name string) erro
 		return fmt.Errorf("failed to create agent: %w", err)
 	}
 
-	_, err = result.Single(ctx)
+	record, err := result.Single(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to verify agent creation: %w", err)
 	}
@@ -269,6 +544,9 @@ func (r *Repository) CreateAgent(ctx context.Context, agentID, name string) erro
 		zap.String("agent_id", agentID),
 		zap.String("name", name),
 	)
+	if getBoolFromRecord(record, "created") {
+		r.emitMemoryEvent(EventAgentCreated, agentID, agentID, name)
+	}
 	return nil
 }
 
@@ -336,43 +614,71 @@ func getTimeFromMap(m map[string]interface{}, key string, defaultValue time.Time
 	if !ok {
 		return defaultValue
 	}
-	// Neo4j datetime values come as time.Time
-	if t, ok := val.(time.Time); ok {
-		return t
-	}
-	return defaultValue
+	return timeFromValue(key, val, defaultValue)
 }
 
 // getFloat64FromMap is defined in helpers.go
 
-// ListAgents returns all agents with their metadata
-func (r *Repository) ListAgents(ctx context.Context) ([]AgentInfo, error) {
+// ListAgents returns a page of agents, newest first, with the total number
+// matching. A non-empty query keeps agents whose name contains it, ignoring case.
+func (r *Repository) ListAgents(ctx context.Context, query string, limit, offset int) (*AgentPage, error) {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
-	query := `
+	where := ""
+	if query != "" {
+		where = "WHERE toLower(a.name) CONTAINS toLower($q)"
+	}
+	params := map[string]interface{}{
+		"q":      query,
+		"limit":  limit,
+		"offset": offset,
+	}
+
+	countResult, err := session.Run(ctx, `
 		MATCH (a:Agent)
+		`+where+`
+		RETURN count(a) as total
+	`, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count agents: %w", err)
+	}
+	page := &AgentPage{Agents: []AgentInfo{}, Limit: limit, Offset: offset}
+	if countResult.Next(ctx) {
+		page.Total = getIntFromRecord(countResult.Record(), "total")
+	}
+
+	result, err := session.Run(ctx, `
+		MATCH (a:Agent)
+		`+where+`
 		RETURN a.id as id, a.name as name, a.created_at as created_at
 		ORDER BY a.created_at DESC
-	`
-
-	result, err := session.Run(ctx, query, map[string]interface{}{})
+		SKIP $offset
+		LIMIT $limit
+	`, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list agents: %w", err)
 	}
 
-	var agents []AgentInfo
 	for result.Next(ctx) {
 		record := result.Record()
 		createdAt := getTimeFromRecord(record, "created_at", time.Now())
-		agents = append(agents, AgentInfo{
+		page.Agents = append(page.Agents, AgentInfo{
 			ID:        getString(record, "id", ""),
 			Name:      getString(record, "name", ""),
 			CreatedAt: createdAt,
 		})
 	}
 
-	return agents, nil
+	return page, nil
+}
+
+// AgentPage is one page of agents from ListAgents
+type AgentPage struct {
+	Agents []AgentInfo `json:"agents"`
+	Total  int         `json:"total"` // Agents matching the query across all pages
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
 }
 
 // AgentInfo represents basic agent information
@@ -384,6 +690,20 @@ type AgentInfo struct {
 
 // GetAgentConfig retrieves agent configuration (model, system_instructions)
 func (r *Repository) GetAgentConfig(ctx context.Context, agentID string) (*AgentConfig, error) {
+	return r.getAgentConfig(ctx, agentID, true)
+}
+
+// GetStoredAgentConfig retrieves agent configuration as stored, without
+// falling back to the personality for unset system_instructions, so it can
+// be updated and written back with UpdateAgentConfig
+func (r *Repository) GetStoredAgentConfig(ctx context.Context, agentID string) (*AgentConfig, error) {
+	return r.getAgentConfig(ctx, agentID, false)
+}
+
+func (r *Repository) getAgentConfig(ctx context.Context, agentID string, personalityFallback bool) (*AgentConfig, error) {
+	ctx, span := startQuerySpan(ctx, "GetAgentConfig")
+	defer span.End()
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
@@ -392,7 +712,24 @@ func (r *Repository) GetAgentConfig(ctx context.Context, agentID string) (*Agent
 		OPTIONAL MATCH (a)-[:HAS_IDENTITY]->(id:AgentIdentity)
 		RETURN 
 			a.model as model,
+			coalesce(a.llm_provider, '') as llm_provider,
+			coalesce(a.auxiliary_provider, '') as auxiliary_provider,
+			coalesce(a.auxiliary_model, '') as auxiliary_model,
+			coalesce(a.embedding_provider, '') as embedding_provider,
+			coalesce(a.embedding_model, '') as embedding_model,
+			coalesce(a.moderation_provider, '') as moderation_provider,
+			coalesce(a.moderation_model, '') as moderation_model,
+			coalesce(a.max_tokens, 0) as max_tokens,
 			a.system_instructions as system_instructions,
+			coalesce(a.persona_check, false) as persona_check,
+			coalesce(a.content_filter, '') as content_filter,
+			coalesce(a.content_filter_action, '') as content_filter_action,
+			coalesce(a.allowed_tools, []) as allowed_tools,
+			coalesce(a.denied_tools, []) as denied_tools,
+			coalesce(a.image_style_preset, '') as image_style_preset,
+			coalesce(a.max_recursion_depth, 0) as max_recursion_depth,
+			coalesce(a.max_prompt_facts, 0) as max_prompt_facts,
+			coalesce(a.prompt_history_window, CASE WHEN a.max_prompt_history > 0 THEN a.max_prompt_history END) as max_prompt_history,
 			id.personality as personality
 	`
 
@@ -413,20 +750,54 @@ func (r *Repository) GetAgentConfig(ctx context.Context, agentID string) (*Agent
 	personality := getString(record, "personality", "")
 
 	// If system_instructions is not set, use personality as fallback
-	if systemInstructions == "" {
+	if systemInstructions == "" && personalityFallback {
 		systemInstructions = personality
 	}
 
 	return &AgentConfig{
-		Model:              model,
-		SystemInstructions: systemInstructions,
+		Model:               model,
+		Provider:            getString(record, "llm_provider", ""),
+		AuxiliaryProvider:   getString(record, "auxiliary_provider", ""),
+		AuxiliaryModel:      getString(record, "auxiliary_model", ""),
+		EmbeddingProvider:   getString(record, "embedding_provider", ""),
+		EmbeddingModel:      getString(record, "embedding_model", ""),
+		ModerationProvider:  getString(record, "moderation_provider", ""),
+		ModerationModel:     getString(record, "moderation_model", ""),
+		MaxTokens:           getIntFromRecord(record, "max_tokens"),
+		SystemInstructions:  systemInstructions,
+		PersonaCheck:        getBoolFromRecord(record, "persona_check"),
+		ContentFilter:       getString(record, "content_filter", ""),
+		ContentFilterAction: getString(record, "content_filter_action", ""),
+		AllowedTools:        getStringSliceFromRecord(record, "allowed_tools"),
+		DeniedTools:         getStringSliceFromRecord(record, "denied_tools"),
+		ImageStylePreset:    getString(record, "image_style_preset", ""),
+		MaxRecursionDepth:   getIntFromRecord(record, "max_recursion_depth"),
+		MaxPromptFacts:      getIntFromRecord(record, "max_prompt_facts"),
+		MaxPromptHistory:    getOptionalIntFromRecord(record, "max_prompt_history"),
 	}, nil
 }
 
 // AgentConfig represents agent configuration
 type AgentConfig struct {
-	Model              string `json:"model"`
-	SystemInstructions string `json:"system_instructions"`
+	Model               string   `json:"model"`
+	Provider            string   `json:"provider,omitempty"`            // LLM provider for the agent's turns; empty uses the default
+	AuxiliaryProvider   string   `json:"auxiliary_provider,omitempty"`  // Provider for background calls such as memory evaluation and summaries; empty uses the default
+	AuxiliaryModel      string   `json:"auxiliary_model,omitempty"`     // Model for background calls; empty uses the default
+	EmbeddingProvider   string   `json:"embedding_provider,omitempty"`  // Provider embedding the agent's facts and archives; empty uses the reindexer's
+	EmbeddingModel      string   `json:"embedding_model,omitempty"`     // Embedding model for the agent's facts and archives; empty uses the reindexer's
+	ModerationProvider  string   `json:"moderation_provider,omitempty"` // Provider for the "moderation" content filter; empty uses the default
+	ModerationModel     string   `json:"moderation_model,omitempty"`    // Moderation model for the content filter; empty uses the filter's
+	MaxTokens           int      `json:"max_tokens,omitempty"`          // Reply length limit sent with the agent's calls; 0 uses the provider's default
+	SystemInstructions  string   `json:"system_instructions"`
+	PersonaCheck        bool     `json:"persona_check,omitempty"`         // Check replies against the persona and regenerate strong contradictions (one extra LLM call per reply)
+	ContentFilter       string   `json:"content_filter,omitempty"`        // Output filter: "local" patterns, "moderation" model plus patterns, or empty for none
+	ContentFilterAction string   `json:"content_filter_action,omitempty"` // "replace" filtered output with the fallback (default) or "block" it
+	AllowedTools        []string `json:"allowed_tools,omitempty"`         // Tools or capabilities the agent may use; empty allows all
+	DeniedTools         []string `json:"denied_tools,omitempty"`          // Tools or capabilities the agent may never use, even if allowed
+	ImageStylePreset    string   `json:"image_style_preset,omitempty"`    // Style preset for generated images when the call names none
+	MaxRecursionDepth   int      `json:"max_recursion_depth,omitempty"`   // LLM rounds allowed per turn; 0 uses the default
+	MaxPromptFacts      int      `json:"max_prompt_facts,omitempty"`      // Most relevant user facts injected into the prompt; 0 uses the default
+	MaxPromptHistory    *int     `json:"max_prompt_history,omitempty"`    // Recent messages injected into the prompt; nil uses the default, 0 injects none
 }
 
 // UpdateAgentConfig updates agent configuration
@@ -437,15 +808,58 @@ func (r *Repository) UpdateAgentConfig(ctx context.Context, agentID string, conf
 	query := `
 		MATCH (a:Agent {id: $agentID})
 		SET a.model = $model,
+		    a.llm_provider = $llm_provider,
+		    a.auxiliary_provider = $auxiliary_provider,
+		    a.auxiliary_model = $auxiliary_model,
+		    a.embedding_provider = $embedding_provider,
+		    a.embedding_model = $embedding_model,
+		    a.moderation_provider = $moderation_provider,
+		    a.moderation_model = $moderation_model,
+		    a.max_tokens = $max_tokens,
 		    a.system_instructions = $system_instructions,
+		    a.persona_check = $persona_check,
+		    a.content_filter = $content_filter,
+		    a.content_filter_action = $content_filter_action,
+		    a.allowed_tools = $allowed_tools,
+		    a.denied_tools = $denied_tools,
+		    a.image_style_preset = $image_style_preset,
+		    a.max_recursion_depth = $max_recursion_depth,
+		    a.max_prompt_facts = $max_prompt_facts,
+		    a.prompt_history_window = $prompt_history_window,
 		    a.updated_at = datetime()
+		REMOVE a.max_prompt_history
 		RETURN a.id as id
 	`
 
+	// Agents saved before 0 meant "no history" stored 0 for the default in
+	// max_prompt_history, so the window lives in its own property; null
+	// removes it and restores the default
+	var historyWindow interface{}
+	if config.MaxPromptHistory != nil {
+		historyWindow = *config.MaxPromptHistory
+	}
+
 	_, err := session.Run(ctx, query, map[string]interface{}{
-		"agentID":            agentID,
-		"model":              config.Model,
-		"system_instructions": config.SystemInstructions,
+		"agentID":               agentID,
+		"model":                 config.Model,
+		"llm_provider":          config.Provider,
+		"auxiliary_provider":    config.AuxiliaryProvider,
+		"auxiliary_model":       config.AuxiliaryModel,
+		"embedding_provider":    config.EmbeddingProvider,
+		"embedding_model":       config.EmbeddingModel,
+		"moderation_provider":   config.ModerationProvider,
+		"moderation_model":      config.ModerationModel,
+		"max_tokens":            config.MaxTokens,
+		"system_instructions":   config.SystemInstructions,
+		"persona_check":         config.PersonaCheck,
+		"content_filter":        config.ContentFilter,
+		"content_filter_action": config.ContentFilterAction,
+		"allowed_tools":         config.AllowedTools,
+		"denied_tools":          config.DeniedTools,
+		"image_style_preset":    config.ImageStylePreset,
+		"max_recursion_depth":   config.MaxRecursionDepth,
+		"max_prompt_facts":      config.MaxPromptFacts,
+		"prompt_history_window": historyWindow,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to update agent config: %w", err)
@@ -469,7 +883,11 @@ func (r *Repository) GetArchivalMemories(ctx context.Context, agentID string) ([
 		       arch.summary as summary,
 		       arch.timestamp as timestamp,
 		       arch.relevance_score as relevance_score,
-		       arch.content as content
+		       arch.content as content,
+		       arch.updated_at as updated_at,
+		       arch.relevance_auto as relevance_auto,
+		       arch.access_count as access_count,
+		       arch.last_accessed_at as last_accessed_at
 		ORDER BY arch.timestamp DESC
 	`
 
@@ -480,6 +898,7 @@ func (r *Repository) GetArchivalMemories(ctx context.Context, agentID string) ([
 		return nil, fmt.Errorf("failed to get archival memories: %w", err)
 	}
 
+	now := time.Now()
 	var memories []ArchivalMemory
 	for result.Next(ctx) {
 		record := result.Record()
@@ -490,13 +909,27 @@ func (r *Repository) GetArchivalMemories(ctx context.Context, agentID string) ([
 		if memoryID == "" {
 			memoryID = uuid.New().String()
 		}
-		memories = append(memories, ArchivalMemory{
+		memory := ArchivalMemory{
 			ID:             memoryID,
 			Summary:        getString(record, "summary", ""),
 			Content:        getString(record, "content", ""),
 			Timestamp:      timestamp,
 			RelevanceScore: relevanceScore,
-		})
+		}
+		if updatedAt, ok := record.Get("updated_at"); ok {
+			if t, ok := updatedAt.(time.Time); ok {
+				memory.UpdatedAt = &t
+			}
+		}
+		_, inputs := scoreArchival(memory, getIntFromRecord(record, "access_count"), lastAccessedAt(record), now)
+		if auto, ok := record.Get("relevance_auto"); ok && auto != nil {
+			inputs.Auto = getBoolFromRecord(record, "relevance_auto")
+		} else {
+			// Archives from before scoring are rescored only while they have no score
+			inputs.Auto = relevanceScore == 0
+		}
+		memory.ScoreInputs = &inputs
+		memories = append(memories, memory)
 	}
 
 	return memories, nil
@@ -507,8 +940,100 @@ type ArchivalMemory struct {
 	ID             string    `json:"id"`
 	Summary        string    `json:"summary"`
 	Content        string    `json:"content"`
-	Timestamp      time.Time `json:"timestamp"`
-	RelevanceScore float64   `json:"relevance_score"`
+	Timestamp      time.Time  `json:"timestamp"`
+	RelevanceScore float64    `json:"relevance_score"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"` // Set once the entry has been edited
+
+	ScoreInputs *ArchivalScoreInputs `json:"score_inputs,omitempty"` // What the relevance score is computed from; read-only
+}
+
+// ArchivalMemoryUpdate holds the fields to change on an archival memory; nil fields are left as-is
+type ArchivalMemoryUpdate struct {
+	Summary        *string  `json:"summary"`
+	Content        *string  `json:"content"`
+	RelevanceScore *float64 `json:"relevance_score"`
+}
+
+// ArchivalImportItem is one entry of an archival import
+type ArchivalImportItem struct {
+	ArchivalMemory
+	Force bool `json:"force"` // Store as a separate entry even if a similar one exists
+}
+
+// ArchivalImportResult is the outcome of storing one archival memory
+type ArchivalImportResult struct {
+	ID     string
+	Merged bool
+}
+
+// UpdateArchivalMemory edits an archival memory in place, keeping its ID and
+// original timestamp and recording updated_at
+func (r *Repository) UpdateArchivalMemory(ctx context.Context, agentID, memoryID string, fields ArchivalMemoryUpdate) error {
+	if fields.RelevanceScore != nil && (*fields.RelevanceScore < 0 || *fields.RelevanceScore > 1) {
+		return fmt.Errorf("relevance_score must be between 0 and 1")
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	query := `
+		OPTIONAL MATCH (a:Agent {id: $agentID})
+		OPTIONAL MATCH (a)-[:HAS_ARCHIVAL]->(arch:Archival {id: $memoryID})
+		FOREACH (_ IN CASE WHEN arch IS NULL THEN [] ELSE [1] END |
+			SET arch.summary = coalesce($summary, arch.summary),
+			    arch.content = coalesce($content, arch.content),
+			    arch.relevance_score = coalesce($relevance_score, arch.relevance_score),
+			    arch.relevance_auto = CASE WHEN $relevance_score IS NULL THEN arch.relevance_auto ELSE false END,
+			    arch.updated_at = datetime($now)
+		)
+		RETURN a IS NOT NULL as agent_exists, arch IS NOT NULL as memory_exists
+	`
+
+	params := map[string]interface{}{
+		"agentID":         agentID,
+		"memoryID":        memoryID,
+		"summary":         nil,
+		"content":         nil,
+		"relevance_score": nil,
+		"now":             now,
+	}
+	if fields.Summary != nil {
+		params["summary"] = *fields.Summary
+	}
+	if fields.Content != nil {
+		params["content"] = *fields.Content
+	}
+	if fields.RelevanceScore != nil {
+		params["relevance_score"] = *fields.RelevanceScore
+	}
+
+	result, err := session.Run(ctx, query, params)
+	if err != nil {
+		return fmt.Errorf("failed to update archival memory: %w", err)
+	}
+	record, err := result.Single(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to verify archival memory update: %w", err)
+	}
+	if !getBoolFromRecord(record, "agent_exists") {
+		return ErrAgentNotFound{AgentID: agentID}
+	}
+	if !getBoolFromRecord(record, "memory_exists") {
+		return ErrArchivalMemoryNotFound{MemoryID: memoryID}
+	}
+
+	r.logger.Info("Archival memory updated",
+		zap.String("agent_id", agentID),
+		zap.String("memory_id", memoryID),
+	)
+	content := ""
+	if fields.Content != nil {
+		content = *fields.Content
+	}
+	r.emitMemoryEvent(EventArchivalUpdated, agentID, memoryID, content)
+	return nil
 }
 
 // DeleteArchivalMemory deletes an archival memory by ID
@@ -542,16 +1067,161 @@ func (r *Repository) DeleteArchivalMemory(ctx context.Context, agentID string, m
 		zap.String("agent_id", agentID),
 		zap.String("memory_id", memoryID),
 	)
+	r.emitMemoryEvent(EventArchivalDeleted, agentID, memoryID, "")
 	return nil
 }
 
-// CreateArchivalMemory creates a new archival memory
-func (r *Repository) CreateArchivalMemory(ctx context.Context, agentID string, memory ArchivalMemory) error {
+// archivalDedupCandidates is how many recent entries are compared when
+// checking a new archival memory for a near duplicate
+const archivalDedupCandidates = 200
+
+// CreateArchivalMemory stores an archival memory and returns its ID.
+// Unless force is set, an existing entry that is a near duplicate (same
+// similarity rules as fact dedup) is refreshed in place instead, and merged
+// is true. Only the most recent entries sharing a word with the memory are
+// compared, so the check stays cheap as the archive grows.
+func (r *Repository) CreateArchivalMemory(ctx context.Context, agentID string, memory ArchivalMemory, force bool) (memoryID string, merged bool, err error) {
+	ctx, span := startQuerySpan(ctx, "CreateArchivalMemory")
+	defer span.End()
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return r.createArchivalMemoryTx(ctx, tx, agentID, memory, force)
+	})
+	if err != nil {
+		return "", false, err
+	}
+	outcome := result.(ArchivalImportResult)
+	r.emitArchivalWrite(agentID, outcome, memory.Content)
+	return outcome.ID, outcome.Merged, nil
+}
+
+// ImportArchivalMemories stores several archival memories in a single
+// transaction: either all of them are stored or, if any write fails, none
+// are. Force applies per item as in CreateArchivalMemory; later items can
+// merge into earlier ones from the same import.
+func (r *Repository) ImportArchivalMemories(ctx context.Context, agentID string, items []ArchivalImportItem) ([]ArchivalImportResult, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		outcomes := make([]ArchivalImportResult, 0, len(items))
+		for _, item := range items {
+			outcome, err := r.createArchivalMemoryTx(ctx, tx, agentID, item.ArchivalMemory, item.Force)
+			if err != nil {
+				return nil, err
+			}
+			outcomes = append(outcomes, outcome)
+		}
+		return outcomes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	outcomes := result.([]ArchivalImportResult)
+	for i, outcome := range outcomes {
+		r.emitArchivalWrite(agentID, outcome, items[i].Content)
+	}
+	return outcomes, nil
+}
+
+// emitArchivalWrite reports a stored archival memory as created or, when it
+// was merged into an existing entry, updated
+func (r *Repository) emitArchivalWrite(agentID string, outcome ArchivalImportResult, content string) {
+	eventType := EventArchivalCreated
+	if outcome.Merged {
+		eventType = EventArchivalUpdated
+	}
+	r.emitMemoryEvent(eventType, agentID, outcome.ID, content)
+}
+
+// createArchivalMemoryTx runs CreateArchivalMemory's writes inside tx
+func (r *Repository) createArchivalMemoryTx(ctx context.Context, tx neo4j.ManagedTransaction, agentID string, memory ArchivalMemory, force bool) (ArchivalImportResult, error) {
 	timestampStr := memory.Timestamp.UTC().Format(time.RFC3339)
-	
+	autoRelevance := r.initialRelevance(&memory, time.Now())
+
+	// Load the entries sharing a word with this one, as any near duplicate
+	// must; also confirms the agent exists
+	terms := archivalCandidateTerms(memory)
+	if force {
+		terms = nil
+	}
+	candidatesQuery := `
+		MATCH (a:Agent {id: $agentID})
+		OPTIONAL MATCH (a)-[:HAS_ARCHIVAL]->(arch:Archival)
+		WHERE any(term IN $terms WHERE toLower(coalesce(arch.summary, '') + ' ' + coalesce(arch.content, '')) CONTAINS term)
+		RETURN arch.id as id, arch.summary as summary, arch.content as content
+		ORDER BY arch.timestamp DESC
+		LIMIT $limit
+	`
+	result, err := tx.Run(ctx, candidatesQuery, map[string]interface{}{
+		"agentID": agentID,
+		"terms":   terms,
+		"limit":   archivalDedupCandidates,
+	})
+	if err != nil {
+		return ArchivalImportResult{}, fmt.Errorf("failed to check archival memories: %w", err)
+	}
+
+	agentFound := false
+	var duplicate *ArchivalMemory
+	for result.Next(ctx) {
+		agentFound = true
+		record := result.Record()
+		existing := ArchivalMemory{
+			ID:      getStringFromRecord(record, "id"),
+			Summary: getStringFromRecord(record, "summary"),
+			Content: getStringFromRecord(record, "content"),
+		}
+		if existing.ID == "" || duplicate != nil {
+			continue
+		}
+		if areArchivalMemoriesSimilar(existing, memory) {
+			duplicate = &existing
+		}
+	}
+	if !agentFound {
+		return ArchivalImportResult{}, ErrAgentNotFound{AgentID: agentID}
+	}
+
+	if duplicate != nil {
+		// A score given with the memory replaces the entry's; otherwise a
+		// score set by hand on the entry is kept, and an automatic one is
+		// refreshed for the new content
+		mergeQuery := `
+			MATCH (a:Agent {id: $agentID})-[:HAS_ARCHIVAL]->(arch:Archival {id: $id})
+			WITH arch, $relevance_auto AND (arch.relevance_auto = false
+			     OR (arch.relevance_auto IS NULL AND coalesce(arch.relevance_score, 0) <> 0)) as keep_existing
+			SET arch.summary = $summary,
+				arch.content = $content,
+				arch.timestamp = datetime($timestamp),
+				arch.relevance_score = CASE WHEN keep_existing THEN arch.relevance_score ELSE $relevance_score END,
+				arch.relevance_auto = CASE WHEN keep_existing THEN false ELSE $relevance_auto END
+		`
+		_, err := tx.Run(ctx, mergeQuery, map[string]interface{}{
+			"agentID":         agentID,
+			"id":              duplicate.ID,
+			"summary":         memory.Summary,
+			"content":         memory.Content,
+			"timestamp":       timestampStr,
+			"relevance_score": memory.RelevanceScore,
+			"relevance_auto":  autoRelevance,
+		})
+		if err != nil {
+			return ArchivalImportResult{}, fmt.Errorf("failed to merge archival memory: %w", err)
+		}
+
+		r.logger.Info("Archival memory merged into existing entry",
+			zap.String("agent_id", agentID),
+			zap.String("memory_id", duplicate.ID),
+			zap.String("old_summary", duplicate.Summary),
+			zap.String("new_summary", memory.Summary),
+		)
+		return ArchivalImportResult{ID: duplicate.ID, Merged: true}, nil
+	}
+
 	// Generate ID if not provided
 	if memory.ID == "" {
 		memory.ID = uuid.New().String()
@@ -564,28 +1234,31 @@ func (r *Repository) CreateArchivalMemory(ctx context.Context, agentID string, m
 			summary: $summary,
 			content: $content,
 			timestamp: datetime($timestamp),
-			relevance_score: $relevance_score
+			relevance_score: $relevance_score,
+			relevance_auto: $relevance_auto
 		})
 		RETURN arch
 	`
 
-	_, err := session.Run(ctx, query, map[string]interface{}{
+	_, err = tx.Run(ctx, query, map[string]interface{}{
 		"agentID":        agentID,
 		"id":             memory.ID,
 		"summary":         memory.Summary,
 		"content":         memory.Content,
 		"timestamp":      timestampStr,
 		"relevance_score": memory.RelevanceScore,
+		"relevance_auto":  autoRelevance,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create archival memory: %w", err)
+		return ArchivalImportResult{}, fmt.Errorf("failed to create archival memory: %w", err)
 	}
 
 	r.logger.Info("Archival memory created",
 		zap.String("agent_id", agentID),
 		zap.String("summary", memory.Summary),
+		zap.Bool("forced", force),
 	)
-	return nil
+	return ArchivalImportResult{ID: memory.ID}, nil
 }
 
 // GetContextStats estimates token usage for an agent's context window
@@ -607,9 +1280,18 @@ func (r *Repository) GetContextStats(ctx context.Context, agentID string) (*Cont
 	}
 
 	// Count core memory
+	blocks := make([]MemoryBlockStats, 0, len(state.CoreMemory))
 	for _, block := range state.CoreMemory {
 		totalChars += len(block.Name)
 		totalChars += len(block.Content)
+		chars := utf8.RuneCountInString(block.Content)
+		blocks = append(blocks, MemoryBlockStats{
+			Name:            block.Name,
+			Chars:           chars,
+			EstimatedTokens: len(block.Content) / 4,
+			Limit:           r.memoryBlockMaxChars,
+			OverLimit:       r.memoryBlockMaxChars > 0 && chars > r.memoryBlockMaxChars,
+		})
 	}
 
 	// Count archival refs
@@ -627,21 +1309,28 @@ func (r *Repository) GetContextStats(ctx context.Context, agentID string) (*Cont
 	}
 
 	return &ContextStats{
-		UsedTokens:  estimatedTokens,
-		TotalTokens: totalTokens,
+		UsedTokens:       estimatedTokens,
+		TotalTokens:      totalTokens,
+		MemoryBlocks:     blocks,
+		MemoryBlockLimit: r.memoryBlockMaxChars,
 	}, nil
 }
 
 // GetAllFacts retrieves all facts known by an agent
 // Note: Fact type is defined in enhanced_repository.go
 func (r *Repository) GetAllFacts(ctx context.Context, agentID string) ([]*Fact, error) {
+	ctx, span := startQuerySpan(ctx, "GetAllFacts")
+	defer span.End()
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
 	query := `
 		MATCH (a:Agent {id: $agentID})-[:KNOWS_FACT]->(f:Fact)
 		RETURN f.id as id, f.content as content, f.source as source,
-		       f.confidence as confidence, f.created_at as created_at
+		       f.confidence as confidence, f.created_at as created_at,
+		       coalesce(f.last_affirmed_at, f.created_at) as affirmed_at,
+		       coalesce(f.pinned, false) as pinned
 		ORDER BY f.created_at DESC
 	`
 
@@ -652,18 +1341,22 @@ func (r *Repository) GetAllFacts(ctx context.Context, agentID string) ([]*Fact,
 		return nil, fmt.Errorf("failed to get facts: %w", err)
 	}
 
+	now := time.Now()
 	var facts []*Fact
 	for result.Next(ctx) {
 		record := result.Record()
 		createdAt := getTimeFromRecord(record, "created_at", time.Now())
 		confidence := getFloat64FromRecord(record, "confidence")
-		facts = append(facts, &Fact{
+		fact := &Fact{
 			ID:         getString(record, "id", ""),
 			Content:    getString(record, "content", ""),
 			Source:     getString(record, "source", ""),
 			Confidence: confidence,
 			CreatedAt:  createdAt,
-		})
+			Pinned:     getBoolFromRecord(record, "pinned"),
+		}
+		r.applyFactDecay(fact, getTimeFromRecord(record, "affirmed_at", createdAt), now)
+		facts = append(facts, fact)
 	}
 
 	return facts, nil
@@ -713,6 +1406,7 @@ func (r *Repository) GetAllMessages(ctx context.Context, agentID string, limit i
 
 	query := `
 		MATCH (a:Agent {id: $agentID})-[:SENT]->(m:Message)
+		WHERE m.deleted_at IS NULL
 		RETURN m.id as id, m.content as content, m.role as role,
 		       m.platform as platform, m.timestamp as timestamp
 		ORDER BY m.timestamp DESC
@@ -743,45 +1437,134 @@ func (r *Repository) GetAllMessages(ctx context.Context, agentID string, limit i
 	return messages, nil
 }
 
-// GetAllConversations retrieves all conversations for an agent
+// conversationPreviewLength is the maximum number of characters kept in a
+// conversation's last-message preview
+const conversationPreviewLength = 120
+
+// ConversationFilter narrows GetAllConversations. Zero values don't filter.
+type ConversationFilter struct {
+	Platform string    // discord, web
+	Since    time.Time // Keep conversations started at or after this time
+	Until    time.Time // Keep conversations started before this time
+	Limit    int       // Conversations per page; 50 when not positive
+	Offset   int       // Conversations to skip
+}
+
+// ConversationPage is one page of conversations from GetAllConversations
+type ConversationPage struct {
+	Conversations []*Conversation `json:"conversations"`
+	Total         int             `json:"total"` // Conversations matching the filter across all pages
+	Limit         int             `json:"limit"`
+	Offset        int             `json:"offset"`
+}
+
+// GetAllConversations returns a page of an agent's conversations, most
+// recently started first, along with the last message preview, message
+// count and participant count of each, and the total number matching
 // Note: Conversation type is defined in enhanced_repository.go
-func (r *Repository) GetAllConversations(ctx context.Context, agentID string, limit int) ([]*Conversation, error) {
+func (r *Repository) GetAllConversations(ctx context.Context, agentID string, filter ConversationFilter) (*ConversationPage, error) {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
-	if limit < 1 {
-		limit = 50
+	if filter.Limit < 1 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	var conditions []string
+	params := map[string]interface{}{
+		"agentID": agentID,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	}
+	if filter.Platform != "" {
+		conditions = append(conditions, "c.platform = $platform")
+		params["platform"] = filter.Platform
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "c.started_at >= datetime($since)")
+		params["since"] = filter.Since.UTC().Format(time.RFC3339Nano)
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "c.started_at < datetime($until)")
+		params["until"] = filter.Until.UTC().Format(time.RFC3339Nano)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countResult, err := session.Run(ctx, `
+		MATCH (a:Agent {id: $agentID})-[:SENT]->(:Message)<-[:CONTAINS]-(c:Conversation)
+		WITH DISTINCT c
+		`+where+`
+		RETURN count(c) as total
+	`, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count conversations: %w", err)
+	}
+	page := &ConversationPage{Conversations: []*Conversation{}, Limit: filter.Limit, Offset: filter.Offset}
+	if countResult.Next(ctx) {
+		page.Total = getIntFromRecord(countResult.Record(), "total")
 	}
 
 	query := `
-		MATCH (a:Agent {id: $agentID})-[:SENT]->(m:Message)<-[:CONTAINS]-(c:Conversation)
-		RETURN DISTINCT c.id as id, c.channel_id as channel_id,
-		       c.platform as platform, c.started_at as started_at
+		MATCH (a:Agent {id: $agentID})-[:SENT]->(:Message)<-[:CONTAINS]-(c:Conversation)
+		WITH DISTINCT c
+		` + where + `
 		ORDER BY c.started_at DESC
+		SKIP $offset
 		LIMIT $limit
+		OPTIONAL MATCH (c)-[:CONTAINS]->(m:Message)
+		WHERE m.deleted_at IS NULL
+		WITH c, m
+		ORDER BY m.timestamp DESC
+		WITH c, count(m) as message_count, collect(m)[0] as last
+		OPTIONAL MATCH (u:User)-[:PARTICIPATED_IN]->(c)
+		WITH c, message_count, last, count(DISTINCT u) as participant_count
+		RETURN c.id as id, c.channel_id as channel_id,
+		       c.platform as platform, c.started_at as started_at,
+		       last.content as last_message, last.role as last_message_role,
+		       last.timestamp as last_message_at,
+		       message_count, participant_count
+		ORDER BY c.started_at DESC
 	`
 
-	result, err := session.Run(ctx, query, map[string]interface{}{
-		"agentID": agentID,
-		"limit":   limit,
-	})
+	result, err := session.Run(ctx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get conversations: %w", err)
 	}
 
-	var conversations []*Conversation
 	for result.Next(ctx) {
 		record := result.Record()
 		startedAt := getTimeFromRecord(record, "started_at", time.Now())
-		conversations = append(conversations, &Conversation{
-			ID:        getString(record, "id", ""),
-			ChannelID: getString(record, "channel_id", ""),
-			Platform:  getString(record, "platform", ""),
-			StartedAt: startedAt,
+		page.Conversations = append(page.Conversations, &Conversation{
+			ID:               getString(record, "id", ""),
+			ChannelID:        getString(record, "channel_id", ""),
+			Platform:         getString(record, "platform", ""),
+			StartedAt:        startedAt,
+			LastMessage:      messagePreview(getString(record, "last_message", ""), conversationPreviewLength),
+			LastMessageRole:  getString(record, "last_message_role", ""),
+			LastMessageAt:    getTimeFromRecord(record, "last_message_at", startedAt),
+			MessageCount:     getIntFromRecord(record, "message_count"),
+			ParticipantCount: getIntFromRecord(record, "participant_count"),
 		})
 	}
 
-	return conversations, nil
+	return page, nil
+}
+
+// messagePreview collapses whitespace and truncates content to maxLen
+// characters, adding an ellipsis when cut
+func messagePreview(content string, maxLen int) string {
+	preview := strings.Join(strings.Fields(content), " ")
+	runes := []rune(preview)
+	if len(runes) <= maxLen {
+		return preview
+	}
+	return strings.TrimSpace(string(runes[:maxLen])) + "…"
 }
 
 // GetAllUsers retrieves all users that have interacted with an agent
@@ -827,8 +1610,19 @@ func (r *Repository) GetAllUsers(ctx context.Context, agentID string) ([]*User,
 
 // ContextStats represents context window statistics
 type ContextStats struct {
-	UsedTokens  int `json:"used_tokens"`
-	TotalTokens int `json:"total_tokens"`
+	UsedTokens       int                `json:"used_tokens"`
+	TotalTokens      int                `json:"total_tokens"`
+	MemoryBlocks     []MemoryBlockStats `json:"memory_blocks"`
+	MemoryBlockLimit int                `json:"memory_block_limit"` // Max characters per block (0 = unlimited)
+}
+
+// MemoryBlockStats is the size breakdown of a single core memory block
+type MemoryBlockStats struct {
+	Name            string `json:"name"`
+	Chars           int    `json:"chars"`
+	EstimatedTokens int    `json:"estimated_tokens"`
+	Limit           int    `json:"limit"`
+	OverLimit       bool   `json:"over_limit"` // Set for blocks written before the limit was lowered
 }
 
 // Helper functions for records
@@ -838,10 +1632,7 @@ func getTimeFromRecord(record *neo4j.Record, key string, defaultValue time.Time)
 	if !ok {
 		return defaultValue
 	}
-	if t, ok := val.(time.Time); ok {
-		return t
-	}
-	return defaultValue
+	return timeFromValue(key, val, defaultValue)
 }
 
 // Errors
@@ -854,3 +1645,42 @@ func (e ErrAgentNotFound) Error() string {
 	return fmt.Sprintf("agent not found: %s", e.AgentID)
 }
 
+type ErrUserNotFound struct {
+	UserID string
+}
+
+func (e ErrUserNotFound) Error() string {
+	return fmt.Sprintf("user not found: %s", e.UserID)
+}
+
+type ErrAgentExists struct {
+	AgentID string
+}
+
+func (e ErrAgentExists) Error() string {
+	return fmt.Sprintf("agent already exists: %s", e.AgentID)
+}
+
+type ErrArchivalMemoryNotFound struct {
+	MemoryID string
+}
+
+func (e ErrArchivalMemoryNotFound) Error() string {
+	return fmt.Sprintf("archival memory not found: %s", e.MemoryID)
+}
+
+type ErrFactNotFound struct {
+	FactID string
+}
+
+func (e ErrFactNotFound) Error() string {
+	return fmt.Sprintf("fact not found: %s", e.FactID)
+}
+
+type ErrTopicNotFound struct {
+	Name string
+}
+
+func (e ErrTopicNotFound) Error() string {
+	return fmt.Sprintf("topic not found: %s", e.Name)
+}
@@ -2,9 +2,16 @@ package graph
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"ezra-clone/backend/internal/state"
+
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
@@ -47,6 +54,69 @@ func TestRepository_CreateAgent(t *testing.T) {
 	}
 }
 
+func TestRepository_StoredAgentConfig_PartialUpdate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	agentID := "test-agent-" + time.Now().Format("20060102150405")
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $id}) OPTIONAL MATCH (a)-[:HAS_IDENTITY]->(i) DETACH DELETE i, a",
+			map[string]interface{}{"id": agentID})
+	}()
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	if err := repo.CreateAgentIdentity(ctx, agentID, state.AgentIdentity{Name: "Test Agent", Personality: "Cheerful"}); err != nil {
+		t.Fatalf("CreateAgentIdentity failed: %v", err)
+	}
+	history := 4
+	if err := repo.UpdateAgentConfig(ctx, agentID, AgentConfig{Model: "model-a", DeniedTools: []string{"music"}, MaxPromptHistory: &history}); err != nil {
+		t.Fatalf("UpdateAgentConfig failed: %v", err)
+	}
+
+	stored, err := repo.GetStoredAgentConfig(ctx, agentID)
+	if err != nil {
+		t.Fatalf("GetStoredAgentConfig failed: %v", err)
+	}
+	if stored.SystemInstructions != "" {
+		t.Errorf("Expected no personality fallback in the stored config, got %q", stored.SystemInstructions)
+	}
+
+	// A partial update decoded over the stored config keeps what it leaves out
+	if err := json.Unmarshal([]byte(`{"persona_check": true}`), stored); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := repo.UpdateAgentConfig(ctx, agentID, *stored); err != nil {
+		t.Fatalf("UpdateAgentConfig failed: %v", err)
+	}
+
+	config, err := repo.GetAgentConfig(ctx, agentID)
+	if err != nil {
+		t.Fatalf("GetAgentConfig failed: %v", err)
+	}
+	if !config.PersonaCheck || config.Model != "model-a" || len(config.DeniedTools) != 1 {
+		t.Errorf("Expected the update to keep the other fields, got %+v", config)
+	}
+	if config.MaxPromptHistory == nil || *config.MaxPromptHistory != 4 {
+		t.Errorf("Expected max_prompt_history 4 to be kept, got %v", config.MaxPromptHistory)
+	}
+	if config.SystemInstructions != "Cheerful" {
+		t.Errorf("Expected the personality fallback to still apply, got %q", config.SystemInstructions)
+	}
+}
+
 func TestRepository_UpdateMemory(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test")
@@ -120,6 +190,25 @@ func TestRepository_UpdateMemory(t *testing.T) {
 	if !found {
 		t.Error("Memory block not updated correctly")
 	}
+
+	// An order moves the block ahead of unordered ones and survives updates without one
+	if err := repo.UpdateMemory(ctx, agentID, "a_memory", "First by name"); err != nil {
+		t.Fatalf("UpdateMemory failed: %v", err)
+	}
+	order := 0
+	if err := repo.UpdateMemoryWithOrder(ctx, agentID, "test_memory", "Ordered content", &order); err != nil {
+		t.Fatalf("UpdateMemoryWithOrder failed: %v", err)
+	}
+	if err := repo.UpdateMemory(ctx, agentID, "test_memory", "Reordered? No"); err != nil {
+		t.Fatalf("UpdateMemory failed: %v", err)
+	}
+	state, err = repo.FetchState(ctx, agentID)
+	if err != nil {
+		t.Fatalf("FetchState failed: %v", err)
+	}
+	if len(state.CoreMemory) < 2 || state.CoreMemory[0].Name != "test_memory" || state.CoreMemory[0].Order == nil {
+		t.Errorf("Expected the ordered block first, got %+v", state.CoreMemory)
+	}
 }
 
 func TestRepository_FetchState_NotFound(t *testing.T) {
@@ -144,23 +233,1432 @@ func TestRepository_FetchState_NotFound(t *testing.T) {
 	}
 }
 
-func createTestDriver() (neo4j.DriverWithContext, error) {
-	uri := "bolt://localhost:7687"
-	user := "neo4j"
-	password := "password"
+func TestRepository_CreateFact_ConcurrentDuplicates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
 
-	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(user, password, ""))
+	ctx := context.Background()
+	driver, err := createTestDriver()
 	if err != nil {
-		return nil, err
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	if err := repo.EnsureConstraints(ctx); err != nil {
+		t.Fatalf("EnsureConstraints failed: %v", err)
+	}
+	agentID := "test-agent-" + time.Now().Format("20060102150405")
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+
+	// Clean up
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (f:Fact {agent_id: $id}) DETACH DELETE f", map[string]interface{}{"id": agentID})
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $id}) DETACH DELETE a", map[string]interface{}{"id": agentID})
+	}()
+
+	const workers = 10
+	ids := make([]string, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fact, err := repo.CreateFact(ctx, agentID, "Likes green tea", "test", "", nil)
+			if err != nil {
+				t.Errorf("CreateFact failed: %v", err)
+				return
+			}
+			ids[i] = fact.ID
+		}(i)
+	}
+	wg.Wait()
+
+	for _, id := range ids[1:] {
+		if id != ids[0] {
+			t.Errorf("Expected all calls to return fact %s, got %s", ids[0], id)
+		}
+	}
+
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+	result, err := session.Run(ctx, "MATCH (:Agent {id: $id})-[:KNOWS_FACT]->(f:Fact) RETURN count(f) as count", map[string]interface{}{"id": agentID})
+	if err != nil {
+		t.Fatalf("Count query failed: %v", err)
+	}
+	if !result.Next(ctx) {
+		t.Fatal("Count query returned no rows")
+	}
+	if count := getInt64FromRecord(result.Record(), "count"); count != 1 {
+		t.Errorf("Expected 1 fact node, got %d", count)
+	}
+}
+
+func TestRepository_EnsureConstraints_BackfillsFactIdentity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
 	}
 
-	// Verify connection
 	ctx := context.Background()
-	if err := driver.VerifyConnectivity(ctx); err != nil {
-		driver.Close(ctx)
-		return nil, err
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
 	}
+	defer driver.Close(ctx)
 
-	return driver, nil
+	repo := NewRepository(driver)
+	agentID := "test-agent-" + time.Now().Format("20060102150405")
+	userID := "test-user-" + time.Now().Format("20060102150405")
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+	defer func() {
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $agent}) OPTIONAL MATCH (a)-[:KNOWS_FACT]->(f) DETACH DELETE f, a",
+			map[string]interface{}{"agent": agentID})
+		_, _ = session.Run(ctx, "MATCH (u:User {id: $user}) DETACH DELETE u", map[string]interface{}{"user": userID})
+	}()
+
+	// Facts as stored before they were merged on their identity: two the
+	// same but for case, one of them pinned, and one told by nobody
+	_, err = session.Run(ctx, `
+		MATCH (a:Agent {id: $agent})
+		CREATE (u:User {id: $user})
+		CREATE (a)-[:KNOWS_FACT]->(f1:Fact {id: $agent + '-1', content: 'Likes green tea'})<-[:TOLD_ME]-(u)
+		CREATE (a)-[:KNOWS_FACT]->(f2:Fact {id: $agent + '-2', content: 'likes green tea.', pinned: true})<-[:TOLD_ME]-(u)
+		CREATE (a)-[:KNOWS_FACT]->(:Fact {id: $agent + '-3', content: 'The office is in Lisbon'})
+	`, map[string]interface{}{"agent": agentID, "user": userID})
+	if err != nil {
+		t.Fatalf("Failed to seed facts: %v", err)
+	}
+
+	if err := repo.EnsureConstraints(ctx); err != nil {
+		t.Fatalf("EnsureConstraints failed: %v", err)
+	}
+
+	result, err := session.Run(ctx, `
+		MATCH (:Agent {id: $agent})-[:KNOWS_FACT]->(f:Fact)
+		RETURN f.content as content, f.agent_id as agent_id, f.user_id as user_id,
+		       f.content_hash as content_hash, coalesce(f.pinned, false) as pinned
+		ORDER BY f.id
+	`, map[string]interface{}{"agent": agentID})
+	if err != nil {
+		t.Fatalf("Fact query failed: %v", err)
+	}
+	records, err := result.Collect(ctx)
+	if err != nil {
+		t.Fatalf("Fact query failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected the identical facts to merge into one, leaving 2 facts, got %d", len(records))
+	}
+	for i, expectedUser := range []string{userID, ""} {
+		record := records[i]
+		content := getStringFromRecord(record, "content")
+		if getStringFromRecord(record, "agent_id") != agentID || getStringFromRecord(record, "user_id") != expectedUser {
+			t.Errorf("Expected %q to be keyed to %s and user %q", content, agentID, expectedUser)
+		}
+		if getStringFromRecord(record, "content_hash") != factContentHash(content) {
+			t.Errorf("Expected %q to have its content hash", content)
+		}
+	}
+	if !getBoolFromRecord(records[0], "pinned") {
+		t.Error("Expected the merged fact to stay pinned")
+	}
+
+	// Backfilled facts merge with new ones like any other
+	fact, err := repo.CreateFact(ctx, agentID, "Likes green tea!", "test", userID, nil)
+	if err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+	if fact.Content != getStringFromRecord(records[0], "content") {
+		t.Errorf("Expected CreateFact to reuse the backfilled fact, got %q", fact.Content)
+	}
+}
+
+func TestRepository_GetChannelInterestTopics(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	channelID := "test-channel-" + suffix
+	userID := "test-user-" + suffix
+	topicName := "Test Topic " + suffix
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (c:Conversation {channel_id: $id})-[:CONTAINS]->(m) DETACH DELETE m, c", map[string]interface{}{"id": channelID})
+		_, _ = session.Run(ctx, "MATCH (n) WHERE n.id IN [$agent, $user] OR (n:Topic AND n.name = $topic) DETACH DELETE n",
+			map[string]interface{}{"agent": agentID, "user": userID, "topic": topicName})
+	}()
+
+	if err := repo.LogMessage(ctx, agentID, userID, channelID, "", "", "hello", "user", "discord"); err != nil {
+		t.Fatalf("LogMessage failed: %v", err)
+	}
+	if err := repo.LinkUserToTopic(ctx, userID, topicName); err != nil {
+		t.Fatalf("LinkUserToTopic failed: %v", err)
+	}
+
+	topics, err := repo.GetChannelInterestTopics(ctx, channelID, 10)
+	if err != nil {
+		t.Fatalf("GetChannelInterestTopics failed: %v", err)
+	}
+	if len(topics) != 1 || topics[0].Name != topicName {
+		t.Errorf("Expected [%s], got %v", topicName, topics)
+	}
+}
+
+func TestRepository_GetFact_ScopedToAgent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	otherID := "test-other-agent-" + suffix
+	for _, id := range []string{agentID, otherID} {
+		if err := repo.CreateAgent(ctx, id, "Test Agent"); err != nil {
+			t.Fatalf("CreateAgent failed: %v", err)
+		}
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (f:Fact {agent_id: $id}) DETACH DELETE f", map[string]interface{}{"id": agentID})
+		_, _ = session.Run(ctx, "MATCH (a:Agent) WHERE a.id IN [$a, $b] DETACH DELETE a", map[string]interface{}{"a": agentID, "b": otherID})
+	}()
+
+	created, err := repo.CreateFact(ctx, agentID, "Prefers tea", "test", "", nil)
+	if err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+
+	fact, err := repo.GetFact(ctx, agentID, created.ID)
+	if err != nil || fact.Content != "Prefers tea" {
+		t.Fatalf("Expected the fact back, got %+v, %v", fact, err)
+	}
+	if _, err := repo.GetFact(ctx, otherID, created.ID); !errors.As(err, &ErrFactNotFound{}) {
+		t.Errorf("Expected another agent's fact to be not found, got %v", err)
+	}
+
+	if err := repo.UpdateFact(ctx, created.ID, "Prefers coffee"); err != nil {
+		t.Fatalf("UpdateFact failed: %v", err)
+	}
+	if fact, _ := repo.GetFact(ctx, agentID, created.ID); fact == nil || fact.Content != "Prefers coffee" {
+		t.Errorf("Expected the corrected content, got %+v", fact)
+	}
+	if err := repo.UpdateFact(ctx, "missing-"+suffix, "x"); !errors.As(err, &ErrFactNotFound{}) {
+		t.Errorf("Expected ErrFactNotFound for a missing fact, got %v", err)
+	}
+
+	// The content hash follows the content, so the corrected fact is found
+	// again when the same content is stored
+	again, err := repo.CreateFact(ctx, agentID, "prefers coffee.", "test", "", nil)
+	if err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+	if again.ID != created.ID {
+		t.Errorf("Expected the updated fact %s to be reused, got %s", created.ID, again.ID)
+	}
+
+	// Updating a fact to match another one merges it into that one
+	other, err := repo.CreateFact(ctx, agentID, "Prefers juice", "test", "", []string{"drinks"})
+	if err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+	if err := repo.SetFactPinned(ctx, agentID, other.ID, true); err != nil {
+		t.Fatalf("SetFactPinned failed: %v", err)
+	}
+	if err := repo.UpdateFact(ctx, other.ID, "Prefers Coffee"); err != nil {
+		t.Fatalf("UpdateFact onto an existing fact failed: %v", err)
+	}
+	if _, err := repo.GetFact(ctx, agentID, other.ID); !errors.As(err, &ErrFactNotFound{}) {
+		t.Errorf("Expected the merged fact to be gone, got %v", err)
+	}
+	kept, err := repo.GetFact(ctx, agentID, created.ID)
+	if err != nil {
+		t.Fatalf("Expected the fact merged into to remain, got %v", err)
+	}
+	if kept.Content != "Prefers Coffee" || !kept.Pinned {
+		t.Errorf("Expected the new content and the pin to carry over, got %+v", kept)
+	}
+}
+
+func TestRepository_PinnedFact_SurvivesDecayAndCleanup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	repo.SetFactConfidenceHalfLife(24 * time.Hour)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	otherID := "test-other-agent-" + suffix
+	for _, id := range []string{agentID, otherID} {
+		if err := repo.CreateAgent(ctx, id, "Test Agent"); err != nil {
+			t.Fatalf("CreateAgent failed: %v", err)
+		}
+	}
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+	defer func() {
+		_, _ = session.Run(ctx, "MATCH (f:Fact {agent_id: $id}) DETACH DELETE f", map[string]interface{}{"id": agentID})
+		_, _ = session.Run(ctx, "MATCH (a:Agent) WHERE a.id IN [$a, $b] DETACH DELETE a", map[string]interface{}{"a": agentID, "b": otherID})
+	}()
+
+	pinned, err := repo.CreateFact(ctx, agentID, "Allergic to peanuts", "test", "", nil)
+	if err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+	duplicate, err := repo.CreateFact(ctx, agentID, "Is allergic to peanuts", "test", "", nil)
+	if err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+
+	if err := repo.SetFactPinned(ctx, otherID, pinned.ID, true); !errors.As(err, &ErrFactNotFound{}) {
+		t.Errorf("Expected another agent's pin to find nothing, got %v", err)
+	}
+	if err := repo.SetFactPinned(ctx, agentID, pinned.ID, true); err != nil {
+		t.Fatalf("SetFactPinned failed: %v", err)
+	}
+
+	// Age both facts well past the half-life
+	_, err = session.Run(ctx, `
+		MATCH (f:Fact) WHERE f.id IN $ids
+		SET f.created_at = datetime() - duration('P90D'), f.last_affirmed_at = datetime() - duration('P90D')
+	`, map[string]interface{}{"ids": []string{pinned.ID, duplicate.ID}})
+	if err != nil {
+		t.Fatalf("Failed to age facts: %v", err)
+	}
+
+	fact, err := repo.GetFact(ctx, agentID, pinned.ID)
+	if err != nil || !fact.Pinned {
+		t.Fatalf("Expected the pinned fact back, got %+v, %v", fact, err)
+	}
+	if fact.EffectiveConfidence != fact.Confidence {
+		t.Errorf("Expected a pinned fact not to decay, got %v of %v", fact.EffectiveConfidence, fact.Confidence)
+	}
+	aged, err := repo.GetFact(ctx, agentID, duplicate.ID)
+	if err != nil || aged.EffectiveConfidence >= aged.Confidence {
+		t.Errorf("Expected the unpinned fact to decay, got %+v, %v", aged, err)
+	}
+
+	prune := FactsToPrune([][]string{{duplicate.ID, pinned.ID}}, []Fact{*aged, *fact})
+	if len(prune) != 1 || prune[0] != duplicate.ID {
+		t.Errorf("Expected only the unpinned duplicate to be pruned, got %v", prune)
+	}
+	if err := repo.DeleteFact(ctx, pinned.ID); err == nil {
+		t.Error("Expected deleting a pinned fact to fail")
+	}
+	if _, err := repo.GetFact(ctx, agentID, pinned.ID); err != nil {
+		t.Errorf("Expected the pinned fact to survive cleanup, got %v", err)
+	}
 }
 
+func TestRepository_GetAllConversations_Summary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	channelID := "test-channel-" + suffix
+	userA := "test-user-a-" + suffix
+	userB := "test-user-b-" + suffix
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (c:Conversation {channel_id: $id})-[:CONTAINS]->(m) DETACH DELETE m, c", map[string]interface{}{"id": channelID})
+		_, _ = session.Run(ctx, "MATCH (n) WHERE n.id IN [$agent, $a, $b] DETACH DELETE n",
+			map[string]interface{}{"agent": agentID, "a": userA, "b": userB})
+	}()
+
+	seed := []struct{ userID, content, role string }{
+		{userA, "hello", "user"},
+		{userB, "hi there", "user"},
+		{userA, "hey both", "agent"},
+	}
+	for _, msg := range seed {
+		if err := repo.LogMessage(ctx, agentID, msg.userID, channelID, "", "", msg.content, msg.role, "discord"); err != nil {
+			t.Fatalf("LogMessage failed: %v", err)
+		}
+		// Messages are timestamped to the second, so keep them ordered
+		time.Sleep(1100 * time.Millisecond)
+	}
+
+	page, err := repo.GetAllConversations(ctx, agentID, ConversationFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("GetAllConversations failed: %v", err)
+	}
+	if len(page.Conversations) != 1 || page.Total != 1 {
+		t.Fatalf("Expected 1 conversation, got %d of %d", len(page.Conversations), page.Total)
+	}
+	conv := page.Conversations[0]
+	if conv.MessageCount != 3 {
+		t.Errorf("Expected 3 messages, got %d", conv.MessageCount)
+	}
+	if conv.ParticipantCount != 2 {
+		t.Errorf("Expected 2 participants, got %d", conv.ParticipantCount)
+	}
+	if conv.LastMessage != "hey both" || conv.LastMessageRole != "agent" {
+		t.Errorf("Expected last message 'hey both' from agent, got %q from %q", conv.LastMessage, conv.LastMessageRole)
+	}
+
+	filters := map[string]ConversationFilter{
+		"other platform":    {Platform: "web"},
+		"since tomorrow":    {Since: time.Now().Add(24 * time.Hour)},
+		"until an hour ago": {Until: time.Now().Add(-time.Hour)},
+	}
+	for name, filter := range filters {
+		page, err := repo.GetAllConversations(ctx, agentID, filter)
+		if err != nil {
+			t.Fatalf("GetAllConversations (%s) failed: %v", name, err)
+		}
+		if len(page.Conversations) != 0 || page.Total != 0 {
+			t.Errorf("Expected no conversations for %s, got %d of %d", name, len(page.Conversations), page.Total)
+		}
+	}
+}
+
+func TestRepository_MessageEditAndDelete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	channelID := "test-channel-" + suffix
+	userID := "test-user-" + suffix
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (c:Conversation {channel_id: $id})-[:CONTAINS]->(m) DETACH DELETE m, c", map[string]interface{}{"id": channelID})
+		_, _ = session.Run(ctx, "MATCH (n) WHERE n.id IN [$agent, $user] DETACH DELETE n",
+			map[string]interface{}{"agent": agentID, "user": userID})
+	}()
+
+	for _, id := range []string{"edited-" + suffix, "deleted-" + suffix} {
+		if err := repo.LogMessage(ctx, agentID, userID, channelID, id, id, "original", "user", "discord"); err != nil {
+			t.Fatalf("LogMessage failed: %v", err)
+		}
+	}
+
+	if updated, err := repo.UpdateMessageContent(ctx, "discord", "edited-"+suffix, "corrected"); err != nil || !updated {
+		t.Fatalf("UpdateMessageContent failed: updated=%v err=%v", updated, err)
+	}
+	if deleted, err := repo.DeleteMessageByPlatformID(ctx, "discord", "deleted-"+suffix, false); err != nil || !deleted {
+		t.Fatalf("DeleteMessageByPlatformID failed: deleted=%v err=%v", deleted, err)
+	}
+	if updated, err := repo.UpdateMessageContent(ctx, "discord", "deleted-"+suffix, "too late"); err != nil || updated {
+		t.Errorf("Expected edits to a deleted message to be ignored: updated=%v err=%v", updated, err)
+	}
+
+	messages, err := repo.GetConversationPage(ctx, agentID, channelID, nil, 10)
+	if err != nil {
+		t.Fatalf("GetConversationPage failed: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Expected the deleted message to be hidden, got %d messages", len(messages))
+	}
+	if messages[0].Content != "corrected" || messages[0].EditedAt == nil {
+		t.Errorf("Expected the edited content with edited_at, got %q (edited_at %v)", messages[0].Content, messages[0].EditedAt)
+	}
+
+	if other, err := repo.GetConversationPage(ctx, "other-"+agentID, channelID, nil, 10); err != nil || len(other) != 0 {
+		t.Errorf("Expected no messages for another agent, got %d (err %v)", len(other), err)
+	}
+}
+
+func TestMessagePreview(t *testing.T) {
+	if got := messagePreview("  short\n message ", 20); got != "short message" {
+		t.Errorf("Expected collapsed whitespace, got %q", got)
+	}
+	if got := messagePreview("abcdefghij", 5); got != "abcde…" {
+		t.Errorf("Expected truncated preview, got %q", got)
+	}
+}
+
+func TestRepository_GetSharedTopics(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	userA := "test-user-a-" + suffix
+	userB := "test-user-b-" + suffix
+	shared := "Shared Topic " + suffix
+	onlyA := "Only A " + suffix
+	onlyB := "Only B " + suffix
+
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (n) WHERE n.id IN [$a, $b] OR (n:Topic AND n.name IN [$shared, $onlyA, $onlyB]) DETACH DELETE n",
+			map[string]interface{}{"a": userA, "b": userB, "shared": shared, "onlyA": onlyA, "onlyB": onlyB})
+	}()
+
+	for _, userID := range []string{userA, userB} {
+		if _, err := repo.GetOrCreateUser(ctx, userID, userID, userID, "discord"); err != nil {
+			t.Fatalf("GetOrCreateUser failed: %v", err)
+		}
+	}
+	links := map[string][]string{userA: {shared, onlyA}, userB: {shared, onlyB}}
+	for userID, topics := range links {
+		for _, topic := range topics {
+			if err := repo.LinkUserToTopic(ctx, userID, topic); err != nil {
+				t.Fatalf("LinkUserToTopic failed: %v", err)
+			}
+		}
+	}
+
+	topics, err := repo.GetSharedTopics(ctx, userA, userB)
+	if err != nil {
+		t.Fatalf("GetSharedTopics failed: %v", err)
+	}
+	if len(topics) != 1 || topics[0].Name != shared {
+		t.Errorf("Expected [%s], got %v", shared, topics)
+	}
+}
+
+func TestRepository_GetUserContext_FactRankingFields(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	userID := "test-user-" + suffix
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (f:Fact {agent_id: $id}) DETACH DELETE f", map[string]interface{}{"id": agentID})
+		_, _ = session.Run(ctx, "MATCH (n) WHERE n.id IN [$a, $u] DETACH DELETE n", map[string]interface{}{"a": agentID, "u": userID})
+	}()
+
+	if _, err := repo.GetOrCreateUser(ctx, userID, userID, userID, "discord"); err != nil {
+		t.Fatalf("GetOrCreateUser failed: %v", err)
+	}
+	if _, err := repo.CreateFact(ctx, agentID, "Plays the cello", "test", userID, nil); err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+
+	uc, err := repo.GetUserContext(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetUserContext failed: %v", err)
+	}
+	if len(uc.Facts) != 1 {
+		t.Fatalf("Expected 1 fact, got %v", uc.Facts)
+	}
+	fact := uc.Facts[0]
+	if fact.Confidence != 1 || fact.EffectiveConfidence <= 0 {
+		t.Errorf("Expected the fact's confidence and its decayed value, got %+v", fact)
+	}
+	if time.Since(fact.CreatedAt) > time.Minute {
+		t.Errorf("Expected the fact's creation time, got %v", fact.CreatedAt)
+	}
+}
+
+func TestFactContentHash(t *testing.T) {
+	if factContentHash("Likes green tea.") != factContentHash("  likes   GREEN tea") {
+		t.Error("Expected normalized variants to share a hash")
+	}
+	if factContentHash("Likes green tea") == factContentHash("Likes black tea") {
+		t.Error("Expected different facts to have different hashes")
+	}
+}
+
+func createTestDriver() (neo4j.DriverWithContext, error) {
+	uri := "bolt://localhost:7687"
+	user := "neo4j"
+	password := "password"
+
+	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(user, password, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify connection
+	ctx := context.Background()
+	if err := driver.VerifyConnectivity(ctx); err != nil {
+		driver.Close(ctx)
+		return nil, err
+	}
+
+	return driver, nil
+}
+
+func TestRepository_ResetConversation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	channelID := "test-channel-" + suffix
+	userID := "test-user-" + suffix
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (c:Conversation {channel_id: $id}) OPTIONAL MATCH (c)-[:CONTAINS]->(m) DETACH DELETE m, c", map[string]interface{}{"id": channelID})
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $agent}) OPTIONAL MATCH (a)-[:KNOWS_FACT|HAS_ARCHIVAL]->(n) DETACH DELETE n, a",
+			map[string]interface{}{"agent": agentID})
+		_, _ = session.Run(ctx, "MATCH (u:User {id: $id}) DETACH DELETE u", map[string]interface{}{"id": userID})
+	}()
+
+	if _, err := repo.CreateFact(ctx, agentID, "Likes jazz", "test", userID, nil); err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+	for _, content := range []string{"hello", "hi!"} {
+		if err := repo.LogMessage(ctx, agentID, userID, channelID, "", "", content, "user", "discord"); err != nil {
+			t.Fatalf("LogMessage failed: %v", err)
+		}
+	}
+
+	reset, err := repo.ResetConversation(ctx, agentID, channelID, true)
+	if err != nil {
+		t.Fatalf("ResetConversation failed: %v", err)
+	}
+	if reset.MessagesCleared != 2 {
+		t.Errorf("Expected 2 messages cleared, got %d", reset.MessagesCleared)
+	}
+	if reset.ArchiveID == "" {
+		t.Error("Expected the transcript to be archived")
+	}
+
+	history, err := repo.GetConversationHistory(ctx, channelID, 20)
+	if err != nil {
+		t.Fatalf("GetConversationHistory failed: %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("Expected empty history after reset, got %d messages", len(history))
+	}
+
+	facts, err := repo.GetAllFacts(ctx, agentID)
+	if err != nil {
+		t.Fatalf("GetAllFacts failed: %v", err)
+	}
+	if len(facts) != 1 {
+		t.Errorf("Expected facts to survive the reset, got %d", len(facts))
+	}
+}
+
+func TestConversationTranscript(t *testing.T) {
+	got := conversationTranscript([]Message{
+		{Role: "user", Content: "hi"},
+		{Role: "agent", Content: "hello!"},
+	})
+	if got != "user: hi\nagent: hello!" {
+		t.Errorf("Unexpected transcript: %q", got)
+	}
+}
+
+func TestRepository_GetFactsByTopic(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	music := "test-music-" + suffix
+	jazz := "test-jazz-" + suffix
+	empty := "test-empty-" + suffix
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $agent}) OPTIONAL MATCH (a)-[:KNOWS_FACT]->(f) DETACH DELETE f, a",
+			map[string]interface{}{"agent": agentID})
+		_, _ = session.Run(ctx, "MATCH (t:Topic) WHERE t.name IN $names DETACH DELETE t",
+			map[string]interface{}{"names": []string{music, jazz, empty}})
+	}()
+
+	if _, err := repo.CreateFact(ctx, agentID, "Jazz was born in New Orleans", "test", "", []string{jazz}); err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+	if _, err := repo.CreateTopic(ctx, music, "All things music"); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	if _, err := repo.CreateTopic(ctx, empty, ""); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	if err := repo.LinkTopics(ctx, jazz, music, "SUBTOPIC_OF"); err != nil {
+		t.Fatalf("LinkTopics failed: %v", err)
+	}
+
+	facts, err := repo.GetFactsByTopic(ctx, agentID, jazz, 10)
+	if err != nil {
+		t.Fatalf("GetFactsByTopic failed: %v", err)
+	}
+	if len(facts) != 1 {
+		t.Errorf("Expected 1 fact about jazz, got %d", len(facts))
+	}
+
+	topic, err := repo.GetTopic(ctx, music)
+	if err != nil {
+		t.Fatalf("GetTopic failed: %v", err)
+	}
+	if topic.Description != "All things music" {
+		t.Errorf("Expected description, got %q", topic.Description)
+	}
+	if len(topic.Subtopics) != 1 || topic.Subtopics[0].Name != jazz {
+		t.Errorf("Expected jazz as subtopic, got %+v", topic.Subtopics)
+	}
+
+	facts, err = repo.GetFactsByTopic(ctx, agentID, empty, 10)
+	if err != nil {
+		t.Fatalf("GetFactsByTopic failed: %v", err)
+	}
+	if facts == nil || len(facts) != 0 {
+		t.Errorf("Expected an empty list for a topic without facts, got %v", facts)
+	}
+
+	if _, err := repo.GetTopic(ctx, "test-missing-"+suffix); err == nil {
+		t.Error("Expected an error for a missing topic")
+	} else if _, ok := err.(ErrTopicNotFound); !ok {
+		t.Errorf("Expected ErrTopicNotFound, got %T", err)
+	}
+}
+
+func TestRepository_CreateArchivalMemory_MergesNearDuplicate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	agentID := "test-agent-" + time.Now().Format("20060102150405")
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $agent}) OPTIONAL MATCH (a)-[:HAS_ARCHIVAL]->(n) DETACH DELETE n, a",
+			map[string]interface{}{"agent": agentID})
+	}()
+
+	first := ArchivalMemory{
+		Summary:        "Conversation summary: planning the team offsite in Lisbon",
+		Content:        "We discussed dates, flights and the hotel for the team offsite in Lisbon.",
+		Timestamp:      time.Now(),
+		RelevanceScore: 0.9,
+	}
+	firstID, merged, err := repo.CreateArchivalMemory(ctx, agentID, first, false)
+	if err != nil {
+		t.Fatalf("CreateArchivalMemory failed: %v", err)
+	}
+	if merged {
+		t.Fatal("Expected the first archival memory to be created")
+	}
+
+	again := first
+	again.RelevanceScore = 0
+	again.Summary = "Conversation summary: planning the team offsite in Lisbon."
+	again.Content = "We discussed dates, flights and the hotel for the team offsite in Lisbon, and the budget."
+	againID, merged, err := repo.CreateArchivalMemory(ctx, agentID, again, false)
+	if err != nil {
+		t.Fatalf("CreateArchivalMemory failed: %v", err)
+	}
+	if !merged || againID != firstID {
+		t.Errorf("Expected near-identical summary to update %s, got merged=%v id=%s", firstID, merged, againID)
+	}
+
+	memories, err := repo.GetArchivalMemories(ctx, agentID)
+	if err != nil {
+		t.Fatalf("GetArchivalMemories failed: %v", err)
+	}
+	if len(memories) != 1 {
+		t.Fatalf("Expected 1 archival memory, got %d", len(memories))
+	}
+	if memories[0].Content != again.Content {
+		t.Errorf("Expected the entry to hold the newer content, got %q", memories[0].Content)
+	}
+	if memories[0].RelevanceScore != first.RelevanceScore {
+		t.Errorf("Expected the merge to keep the score set by hand, got %v", memories[0].RelevanceScore)
+	}
+
+	// force skips the similarity check
+	if _, merged, err := repo.CreateArchivalMemory(ctx, agentID, again, true); err != nil || merged {
+		t.Errorf("Expected forced create to add a new entry, got merged=%v err=%v", merged, err)
+	}
+}
+
+func TestRepository_ArchivalRelevanceLifecycle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	repo.SetArchivalScoring(true, DefaultArchivalRelevance)
+	agentID := "test-agent-" + time.Now().Format("20060102150405")
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $agent}) OPTIONAL MATCH (a)-[:HAS_ARCHIVAL]->(n) DETACH DELETE n, a",
+			map[string]interface{}{"agent": agentID})
+	}()
+
+	load := func() ArchivalMemory {
+		t.Helper()
+		memories, err := repo.GetArchivalMemories(ctx, agentID)
+		if err != nil {
+			t.Fatalf("GetArchivalMemories failed: %v", err)
+		}
+		if len(memories) != 1 {
+			t.Fatalf("Expected 1 archival memory, got %d", len(memories))
+		}
+		return memories[0]
+	}
+	rescore := func() {
+		t.Helper()
+		if _, err := repo.RescoreArchivalMemories(ctx); err != nil {
+			t.Fatalf("RescoreArchivalMemories failed: %v", err)
+		}
+	}
+
+	// Stored without a score, it is scored automatically
+	memory := ArchivalMemory{
+		Summary:   "Quarterly review of the Berlin office budget",
+		Content:   "Maria cut the 2026 travel budget by 15% after the Q1 review.",
+		Timestamp: time.Now(),
+	}
+	if _, _, err := repo.CreateArchivalMemory(ctx, agentID, memory, false); err != nil {
+		t.Fatalf("CreateArchivalMemory failed: %v", err)
+	}
+	stored := load()
+	if !stored.ScoreInputs.Auto || stored.RelevanceScore == 0 {
+		t.Fatalf("Expected an automatic score, got %v (auto=%v)", stored.RelevanceScore, stored.ScoreInputs.Auto)
+	}
+
+	// Searches count as accesses once the rescore writes them
+	if _, err := repo.SearchMemory(ctx, agentID, "Berlin office", 10); err != nil {
+		t.Fatalf("SearchMemory failed: %v", err)
+	}
+	if load().ScoreInputs.AccessCount != 0 {
+		t.Error("Expected the search not to write the access")
+	}
+	rescore()
+	rescored := load()
+	if rescored.ScoreInputs.AccessCount != 1 || !rescored.ScoreInputs.Auto {
+		t.Errorf("Expected 1 recorded access on an automatic score, got %d (auto=%v)", rescored.ScoreInputs.AccessCount, rescored.ScoreInputs.Auto)
+	}
+	if rescored.RelevanceScore <= stored.RelevanceScore {
+		t.Errorf("Expected the access to raise the score above %v, got %v", stored.RelevanceScore, rescored.RelevanceScore)
+	}
+
+	// A score set by hand is kept by rescores and by merges without a score
+	handSet := 0.7
+	if err := repo.UpdateArchivalMemory(ctx, agentID, stored.ID, ArchivalMemoryUpdate{RelevanceScore: &handSet}); err != nil {
+		t.Fatalf("UpdateArchivalMemory failed: %v", err)
+	}
+	rescore()
+	again := memory
+	again.Content += " Flights are booked through the new portal."
+	if _, merged, err := repo.CreateArchivalMemory(ctx, agentID, again, false); err != nil || !merged {
+		t.Fatalf("Expected the memory to merge, got merged=%v err=%v", merged, err)
+	}
+	if kept := load(); kept.RelevanceScore != handSet || kept.ScoreInputs.Auto {
+		t.Errorf("Expected the score set by hand to be kept, got %v (auto=%v)", kept.RelevanceScore, kept.ScoreInputs.Auto)
+	}
+
+	// A merge that gives a score replaces it
+	again.RelevanceScore = 0.3
+	if _, _, err := repo.CreateArchivalMemory(ctx, agentID, again, false); err != nil {
+		t.Fatalf("CreateArchivalMemory failed: %v", err)
+	}
+	if replaced := load(); replaced.RelevanceScore != 0.3 || replaced.ScoreInputs.Auto {
+		t.Errorf("Expected the given score to replace it, got %v (auto=%v)", replaced.RelevanceScore, replaced.ScoreInputs.Auto)
+	}
+}
+
+func TestRepository_SaveAndLoadMimicStates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	agentID := "test-agent-" + time.Now().Format("20060102150405")
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $id}) OPTIONAL MATCH (a)-[:HAS_MIMIC_STATE]->(m) DETACH DELETE m, a",
+			map[string]interface{}{"id": agentID})
+	}()
+
+	findState := func() *MimicState {
+		states, err := repo.LoadMimicStates(ctx)
+		if err != nil {
+			t.Fatalf("LoadMimicStates failed: %v", err)
+		}
+		for i := range states {
+			if states[i].AgentID == agentID {
+				return &states[i]
+			}
+		}
+		return nil
+	}
+
+	saved := MimicState{
+		AgentID:             agentID,
+		Active:              true,
+		UserID:              "user-1",
+		ChannelID:           "channel-1",
+		OriginalPersonality: "Helpful",
+		ProfileJSON:         `{"user_id":"user-1","username":"someone"}`,
+	}
+	if err := repo.SaveMimicState(ctx, saved); err != nil {
+		t.Fatalf("SaveMimicState failed: %v", err)
+	}
+	if got := findState(); got == nil || *got != saved {
+		t.Fatalf("Expected the saved mimic state to load, got %+v", got)
+	}
+
+	if err := repo.SaveMimicState(ctx, MimicState{AgentID: agentID}); err != nil {
+		t.Fatalf("SaveMimicState failed: %v", err)
+	}
+	if got := findState(); got != nil {
+		t.Errorf("Expected an inactive mimic state not to load, got %+v", got)
+	}
+}
+
+func TestRepository_CompactConversations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	channelID := "test-channel-" + suffix
+	userID := "test-user-" + suffix
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (c:Conversation {channel_id: $id}) OPTIONAL MATCH (c)-[:CONTAINS]->(m) DETACH DELETE m, c", map[string]interface{}{"id": channelID})
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $agent}) OPTIONAL MATCH (a)-[:HAS_ARCHIVAL]->(n) DETACH DELETE n, a",
+			map[string]interface{}{"agent": agentID})
+		_, _ = session.Run(ctx, "MATCH (u:User {id: $id}) DETACH DELETE u", map[string]interface{}{"id": userID})
+	}()
+
+	for i := 0; i < 6; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "agent"
+		}
+		if err := repo.LogMessage(ctx, agentID, userID, channelID, "", "", fmt.Sprintf("message %d", i), role, "discord"); err != nil {
+			t.Fatalf("LogMessage failed: %v", err)
+		}
+	}
+
+	compaction, err := repo.CompactConversations(ctx, agentID, 2)
+	if err != nil {
+		t.Fatalf("CompactConversations failed: %v", err)
+	}
+	if compaction.Channels != 1 || compaction.MessagesCompacted != 4 || compaction.ArchivalSummaries != 1 {
+		t.Errorf("Expected 4 messages compacted into 1 summary in 1 channel, got %+v", compaction)
+	}
+
+	history, err := repo.GetConversationHistory(ctx, channelID, 20)
+	if err != nil {
+		t.Fatalf("GetConversationHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("Expected 2 raw messages left, got %d", len(history))
+	}
+
+	archival, err := repo.GetArchivalMemories(ctx, agentID)
+	if err != nil {
+		t.Fatalf("GetArchivalMemories failed: %v", err)
+	}
+	if len(archival) != 1 || strings.Count(archival[0].Content, "\n") != 3 {
+		t.Errorf("Expected one summary holding the 4 compacted messages, got %+v", archival)
+	}
+
+	if _, err := repo.CompactConversations(ctx, "missing-agent-"+suffix, 2); err == nil {
+		t.Error("Expected an unknown agent to be rejected")
+	}
+}
+
+func TestRepository_TokenUsage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	agentID := "test-agent-" + time.Now().Format("20060102150405")
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $agent}) OPTIONAL MATCH (a)-[:HAS_TOKEN_USAGE]->(u) DETACH DELETE u, a",
+			map[string]interface{}{"agent": agentID})
+	}()
+
+	day1 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	turns := []struct {
+		at    time.Time
+		usage []ModelTokenUsage
+	}{
+		{day1, []ModelTokenUsage{{Model: "model-a", PromptTokens: 100, CompletionTokens: 10, Calls: 2}}},
+		{day1, []ModelTokenUsage{{Model: "model-a", PromptTokens: 50, CompletionTokens: 5, Calls: 1}}},
+		{day2, []ModelTokenUsage{{Model: "model-b", PromptTokens: 200, CompletionTokens: 20, Calls: 1}}},
+	}
+	for _, turn := range turns {
+		if err := repo.RecordTokenUsage(ctx, agentID, turn.usage, turn.at); err != nil {
+			t.Fatalf("RecordTokenUsage failed: %v", err)
+		}
+	}
+
+	report, err := repo.GetTokenUsage(ctx, agentID, UsageDay(day1), UsageDay(day1))
+	if err != nil {
+		t.Fatalf("GetTokenUsage failed: %v", err)
+	}
+	if len(report.Models) != 1 || report.Models[0].PromptTokens != 150 || report.Models[0].Calls != 3 {
+		t.Errorf("Expected day one's model-a usage to be summed, got %+v", report.Models)
+	}
+	if report.TotalPromptTokens != 350 || report.TotalCompletionTokens != 35 || report.TotalTurns != 3 {
+		t.Errorf("Expected running totals across all days, got %+v", report)
+	}
+
+	if _, err := repo.GetTokenUsage(ctx, "missing-agent", UsageDay(day1), UsageDay(day2)); err == nil {
+		t.Error("Expected an unknown agent to be rejected")
+	}
+}
+
+func TestRepository_DeleteUserData(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	userID := "test-user-" + suffix
+	otherID := "test-other-" + suffix
+	dmChannel := "test-dm-" + suffix
+	sharedChannel := "test-shared-" + suffix
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (c:Conversation) WHERE c.channel_id IN $ids OPTIONAL MATCH (c)-[:CONTAINS]->(m) DETACH DELETE m, c",
+			map[string]interface{}{"ids": []string{dmChannel, sharedChannel}})
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $agent}) OPTIONAL MATCH (a)-[:KNOWS_FACT]->(f) DETACH DELETE f, a",
+			map[string]interface{}{"agent": agentID})
+		_, _ = session.Run(ctx, "MATCH (u:User) WHERE u.id IN $ids DETACH DELETE u", map[string]interface{}{"ids": []string{userID, otherID}})
+	}()
+
+	logs := []struct{ userID, channelID, content, role string }{
+		{userID, dmChannel, "my secret plans", "user"},
+		{userID, dmChannel, "noted", "agent"},
+		{userID, sharedChannel, "hello all", "user"},
+		{otherID, sharedChannel, "hi there", "user"},
+		{otherID, sharedChannel, "welcome", "agent"},
+	}
+	for _, l := range logs {
+		if err := repo.LogMessage(ctx, agentID, l.userID, l.channelID, "", "", l.content, l.role, "discord"); err != nil {
+			t.Fatalf("LogMessage failed: %v", err)
+		}
+	}
+
+	if _, err := repo.CreateFact(ctx, agentID, "Likes hiking", "conversation", userID, nil); err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+	shared, err := repo.CreateFact(ctx, agentID, "The office moved", "conversation", userID, nil)
+	if err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	_, err = session.Run(ctx, "MATCH (u:User {id: $userID}), (f:Fact {id: $factID}) MERGE (u)-[:TOLD_ME]->(f)",
+		map[string]interface{}{"userID": otherID, "factID": shared.ID})
+	session.Close(ctx)
+	if err != nil {
+		t.Fatalf("Failed to share fact: %v", err)
+	}
+
+	deletion, err := repo.DeleteUserData(ctx, userID)
+	if err != nil {
+		t.Fatalf("DeleteUserData failed: %v", err)
+	}
+	if len(deletion.UserNodes) != 1 || deletion.Conversations != 1 || deletion.Messages != 3 {
+		t.Errorf("Expected the user, their DM and 3 messages deleted, got %+v", deletion)
+	}
+	if deletion.FactsDeleted != 1 || deletion.FactsAnonymized != 1 {
+		t.Errorf("Expected one fact deleted and the shared one anonymized, got %+v", deletion)
+	}
+
+	history, err := repo.GetConversationHistory(ctx, sharedChannel, 20)
+	if err != nil {
+		t.Fatalf("GetConversationHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("Expected the other user's exchange to be kept, got %d messages", len(history))
+	}
+	facts, err := repo.GetAllFacts(ctx, agentID)
+	if err != nil {
+		t.Fatalf("GetAllFacts failed: %v", err)
+	}
+	if len(facts) != 1 || facts[0].ID != shared.ID {
+		t.Errorf("Expected only the shared fact to be kept, got %+v", facts)
+	}
+
+	// Deleting again finds nothing
+	again, err := repo.DeleteUserData(ctx, userID)
+	if err != nil {
+		t.Fatalf("Repeated DeleteUserData failed: %v", err)
+	}
+	if len(again.UserNodes) != 0 || again.Messages != 0 || again.FactsDeleted != 0 || again.FactsAnonymized != 0 {
+		t.Errorf("Expected a repeated deletion to find nothing, got %+v", again)
+	}
+}
+
+func TestRepository_MergeUsers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	discordID := "test-discord-" + suffix
+	webID := "test-web-" + suffix
+	discordChannel := "test-discord-channel-" + suffix
+	webChannel := "test-web-channel-" + suffix
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (c:Conversation) WHERE c.channel_id IN $ids OPTIONAL MATCH (c)-[:CONTAINS]->(m) DETACH DELETE m, c",
+			map[string]interface{}{"ids": []string{discordChannel, webChannel}})
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $agent}) OPTIONAL MATCH (a)-[:KNOWS_FACT]->(f) DETACH DELETE f, a",
+			map[string]interface{}{"agent": agentID})
+		_, _ = session.Run(ctx, "MATCH (u:User) WHERE u.id IN $ids DETACH DELETE u", map[string]interface{}{"ids": []string{discordID, webID}})
+	}()
+
+	if _, err := repo.GetOrCreateUser(ctx, discordID, discordID, "merge_tester", "discord"); err != nil {
+		t.Fatalf("GetOrCreateUser failed: %v", err)
+	}
+	if err := repo.LogMessage(ctx, agentID, discordID, discordChannel, "", "", "hi from discord", "user", "discord"); err != nil {
+		t.Fatalf("LogMessage failed: %v", err)
+	}
+	if err := repo.LogMessage(ctx, agentID, webID, webChannel, "", "", "hi from the web", "user", "web"); err != nil {
+		t.Fatalf("LogMessage failed: %v", err)
+	}
+	for _, userID := range []string{discordID, webID} {
+		if _, err := repo.CreateFact(ctx, agentID, "Likes hiking", "conversation", userID, nil); err != nil {
+			t.Fatalf("CreateFact failed: %v", err)
+		}
+	}
+	if _, err := repo.CreateFact(ctx, agentID, "Lives in Berlin", "conversation", webID, nil); err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+
+	candidates, err := repo.FindDiscordUsers(ctx, "@Merge_Tester")
+	if err != nil {
+		t.Fatalf("FindDiscordUsers failed: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].ID != discordID {
+		t.Errorf("Expected the Discord user to be found by username, got %+v", candidates)
+	}
+
+	merge, err := repo.MergeUsers(ctx, discordID, webID)
+	if err != nil {
+		t.Fatalf("MergeUsers failed: %v", err)
+	}
+	if merge.FactsMerged != 1 || merge.Relationships == 0 {
+		t.Errorf("Expected the shared fact merged and relationships moved, got %+v", merge)
+	}
+
+	resolved, err := repo.ResolveUserID(ctx, webID)
+	if err != nil {
+		t.Fatalf("ResolveUserID failed: %v", err)
+	}
+	if resolved != discordID {
+		t.Errorf("Expected the web ID to resolve to the Discord user, got %q", resolved)
+	}
+
+	userCtx, err := repo.GetUserContext(ctx, discordID)
+	if err != nil {
+		t.Fatalf("GetUserContext failed: %v", err)
+	}
+	if len(userCtx.Facts) != 2 {
+		t.Errorf("Expected both users' facts on the Discord user, got %+v", userCtx.Facts)
+	}
+
+	if _, err := repo.MergeUsers(ctx, discordID, webID); err == nil {
+		t.Error("Expected merging an already merged user to fail")
+	}
+}
+
+func TestRepository_FullTextSearch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := createTestDriver()
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close(ctx)
+
+	repo := NewRepository(driver)
+	suffix := time.Now().Format("20060102150405")
+	agentID := "test-agent-" + suffix
+	userID := "test-user-" + suffix
+	channelID := "test-channel-" + suffix
+
+	if err := repo.CreateAgent(ctx, agentID, "Test Agent"); err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (c:Conversation {channel_id: $channel}) OPTIONAL MATCH (c)-[:CONTAINS]->(m) DETACH DELETE m, c",
+			map[string]interface{}{"channel": channelID})
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $agent}) OPTIONAL MATCH (a)-[:KNOWS_FACT]->(f) DETACH DELETE f, a",
+			map[string]interface{}{"agent": agentID})
+		_, _ = session.Run(ctx, "MATCH (u:User {id: $user}) DETACH DELETE u", map[string]interface{}{"user": userID})
+	}()
+
+	if _, err := repo.CreateFact(ctx, agentID, "Drinks oolong "+suffix+" every morning", "test", userID, nil); err != nil {
+		t.Fatalf("CreateFact failed: %v", err)
+	}
+	if err := repo.LogMessage(ctx, agentID, userID, channelID, "", "", "any oolong "+suffix+" tips?", "user", "web"); err != nil {
+		t.Fatalf("LogMessage failed: %v", err)
+	}
+	if err := repo.LogMessage(ctx, agentID, agentID, channelID, "", "", "Steep it twice.", "agent", "web"); err != nil {
+		t.Fatalf("LogMessage failed: %v", err)
+	}
+
+	results, err := repo.FullTextSearch(ctx, agentID, "Oolong "+suffix, nil, 10)
+	if err != nil {
+		t.Fatalf("FullTextSearch failed: %v", err)
+	}
+	types := map[string]bool{}
+	for _, result := range results {
+		types[result.Type] = true
+		if !strings.Contains(result.Snippet, HighlightStart+suffix+HighlightEnd) {
+			t.Errorf("Expected the match highlighted, got %q", result.Snippet)
+		}
+	}
+	if !types["fact"] || !types["message"] {
+		t.Errorf("Expected the fact and the message to be found, got %+v", results)
+	}
+
+	results, err = repo.FullTextSearch(ctx, agentID, "oolong "+suffix, []string{SearchTypeFacts}, 10)
+	if err != nil {
+		t.Fatalf("FullTextSearch failed: %v", err)
+	}
+	for _, result := range results {
+		if result.Type != "fact" {
+			t.Errorf("Expected only facts when scoped to facts, got %+v", result)
+		}
+	}
+}
+
+func TestSortContextWindow(t *testing.T) {
+	first, second := 0, 1
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cw := &state.ContextWindow{
+		CoreMemory: []state.MemoryBlock{
+			{Name: "zeta"},
+			{Name: "persona", Order: &second},
+			{Name: "alpha"},
+			{Name: "identity", Order: &first},
+		},
+		ArchivalRefs: []state.ArchivalPointer{
+			{ID: "c", Timestamp: base.Add(time.Hour)},
+			{ID: "b", Timestamp: base},
+			{ID: "a", Timestamp: base},
+		},
+	}
+
+	sortContextWindow(cw)
+
+	var names, ids []string
+	for _, block := range cw.CoreMemory {
+		names = append(names, block.Name)
+	}
+	for _, ref := range cw.ArchivalRefs {
+		ids = append(ids, ref.ID)
+	}
+	if strings.Join(names, ",") != "identity,persona,alpha,zeta" {
+		t.Errorf("Expected ordered blocks first, then the rest by name, got %v", names)
+	}
+	if strings.Join(ids, ",") != "a,b,c" {
+		t.Errorf("Expected archival refs oldest first, got %v", ids)
+	}
+}
@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Scheduled message statuses. Only pending messages are waiting to be sent;
+// the others record how each one ended.
+const (
+	ScheduledMessagePending   = "pending"
+	ScheduledMessageSent      = "sent"
+	ScheduledMessageCancelled = "cancelled"
+	ScheduledMessageSkipped   = "skipped" // Came due while the bot was down
+	ScheduledMessageFailed    = "failed"
+)
+
+// ScheduledMessage is a message an agent will post at a future time, either
+// to a channel or as a DM to a user
+type ScheduledMessage struct {
+	ID        string    `json:"id"`
+	AgentID   string    `json:"agent_id"`
+	ChannelID string    `json:"channel_id,omitempty"` // Channel to post in; empty for DMs
+	UserID    string    `json:"user_id,omitempty"`    // User to DM; empty for channel posts
+	Content   string    `json:"content"`
+	FireAt    time.Time `json:"fire_at"`
+	CreatedBy string    `json:"created_by,omitempty"` // User who asked for it
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateScheduledMessage stores a pending scheduled message for its agent
+func (r *Repository) CreateScheduledMessage(ctx context.Context, msg ScheduledMessage) error {
+	ctx, span := startQuerySpan(ctx, "CreateScheduledMessage")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (a:Agent {id: $agentID})
+		CREATE (a)-[:HAS_SCHEDULED_MESSAGE]->(s:ScheduledMessage {
+			id: $id,
+			channel_id: $channelID,
+			user_id: $userID,
+			content: $content,
+			fire_at: datetime($fireAt),
+			created_by: $createdBy,
+			created_at: datetime($createdAt),
+			status: $status
+		})
+		RETURN s.id as id
+	`
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"agentID":   msg.AgentID,
+		"id":        msg.ID,
+		"channelID": msg.ChannelID,
+		"userID":    msg.UserID,
+		"content":   msg.Content,
+		"fireAt":    msg.FireAt.UTC().Format(time.RFC3339Nano),
+		"createdBy": msg.CreatedBy,
+		"createdAt": msg.CreatedAt.UTC().Format(time.RFC3339Nano),
+		"status":    ScheduledMessagePending,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create scheduled message: %w", err)
+	}
+	if !result.Next(ctx) {
+		return fmt.Errorf("agent not found: %s", msg.AgentID)
+	}
+	return nil
+}
+
+// ListPendingScheduledMessages returns an agent's pending scheduled messages,
+// or every agent's for an empty agentID, soonest first
+func (r *Repository) ListPendingScheduledMessages(ctx context.Context, agentID string) ([]ScheduledMessage, error) {
+	ctx, span := startQuerySpan(ctx, "ListPendingScheduledMessages")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (a:Agent)-[:HAS_SCHEDULED_MESSAGE]->(s:ScheduledMessage {status: $status})
+		WHERE $agentID = '' OR a.id = $agentID
+		RETURN a.id as agent_id, s.id as id, s.channel_id as channel_id, s.user_id as user_id,
+		       s.content as content, s.fire_at as fire_at, s.created_by as created_by,
+		       s.created_at as created_at
+		ORDER BY s.fire_at, s.id
+	`
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"agentID": agentID,
+		"status":  ScheduledMessagePending,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled messages: %w", err)
+	}
+
+	var messages []ScheduledMessage
+	for result.Next(ctx) {
+		record := result.Record()
+		fireAt, _ := record.Get("fire_at")
+		createdAt, _ := record.Get("created_at")
+		messages = append(messages, ScheduledMessage{
+			ID:        getStringFromRecord(record, "id"),
+			AgentID:   getStringFromRecord(record, "agent_id"),
+			ChannelID: getStringFromRecord(record, "channel_id"),
+			UserID:    getStringFromRecord(record, "user_id"),
+			Content:   getStringFromRecord(record, "content"),
+			FireAt:    timeFromValue("fire_at", fireAt, time.Time{}),
+			CreatedBy: getStringFromRecord(record, "created_by"),
+			CreatedAt: timeFromValue("created_at", createdAt, time.Time{}),
+		})
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list scheduled messages: %w", err)
+	}
+	return messages, nil
+}
+
+// FinishScheduledMessage moves a pending scheduled message to a final status
+// and reports whether it was still pending
+func (r *Repository) FinishScheduledMessage(ctx context.Context, agentID, id, status string) (bool, error) {
+	ctx, span := startQuerySpan(ctx, "FinishScheduledMessage")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (:Agent {id: $agentID})-[:HAS_SCHEDULED_MESSAGE]->(s:ScheduledMessage {id: $id, status: $pending})
+		SET s.status = $status, s.finished_at = datetime($now)
+		RETURN s.id as id
+	`
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"agentID": agentID,
+		"id":      id,
+		"pending": ScheduledMessagePending,
+		"status":  status,
+		"now":     time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update scheduled message: %w", err)
+	}
+	finished := result.Next(ctx)
+	if err := result.Err(); err != nil {
+		return false, fmt.Errorf("failed to update scheduled message: %w", err)
+	}
+	return finished, nil
+}
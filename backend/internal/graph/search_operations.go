@@ -13,6 +13,9 @@ import (
 
 // SearchMemory performs a comprehensive search across the graph
 func (r *Repository) SearchMemory(ctx context.Context, agentID, query string, limit int) ([]SearchResult, error) {
+	ctx, span := startQuerySpan(ctx, "SearchMemory")
+	defer span.End()
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
@@ -31,11 +34,17 @@ func (r *Repository) SearchMemory(ctx context.Context, agentID, query string, li
 		WHERE toLower(m.content) CONTAINS toLower($query) OR toLower(m.name) CONTAINS toLower($query)
 		WITH facts, collect({type: 'memory', id: m.name, content: m.content, score: 1.0}) as memories
 		
+		OPTIONAL MATCH (a)-[:HAS_ARCHIVAL]->(arch:Archival)
+		WHERE toLower(arch.summary) CONTAINS toLower($query) OR toLower(arch.content) CONTAINS toLower($query)
+		WITH facts, memories, arch
+		ORDER BY arch.relevance_score DESC
+		WITH facts, memories, collect({type: 'archival', id: arch.id, content: arch.summary, score: COALESCE(arch.relevance_score, 0.0)}) as archivals
+		
 		OPTIONAL MATCH (t:Topic)
 		WHERE toLower(t.name) CONTAINS toLower($query) OR toLower(t.description) CONTAINS toLower($query)
-		WITH facts, memories, collect({type: 'topic', id: t.id, content: t.name + ': ' + COALESCE(t.description, ''), score: 0.8}) as topics
+		WITH facts, memories, archivals, collect({type: 'topic', id: t.id, content: t.name + ': ' + COALESCE(t.description, ''), score: 0.8}) as topics
 		
-		RETURN facts + memories + topics as results
+		RETURN facts + memories + archivals + topics as results
 	`
 
 	result, err := session.Run(ctx, searchQuery, map[string]interface{}{
@@ -73,6 +82,15 @@ func (r *Repository) SearchMemory(ctx context.Context, agentID, query string, li
 		results = results[:limit]
 	}
 
+	// Archives that turn up in searches rank higher at the next rescore
+	var archivalIDs []string
+	for _, res := range results {
+		if res.Type == "archival" && res.ID != "" {
+			archivalIDs = append(archivalIDs, res.ID)
+		}
+	}
+	r.recordArchivalAccess(archivalIDs)
+
 	return results, nil
 }
 
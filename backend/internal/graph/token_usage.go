@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ModelTokenUsage is the tokens an agent used with one model
+type ModelTokenUsage struct {
+	Model            string `json:"model"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	Calls            int64  `json:"calls"`
+}
+
+// TokenUsageReport is an agent's token usage over a range of UTC days, and
+// its running totals since usage was first recorded
+type TokenUsageReport struct {
+	From                  string            // First day, e.g. "2026-01-02"
+	To                    string            // Last day, inclusive
+	Models                []ModelTokenUsage // Usage in the range, by model
+	TotalPromptTokens     int64
+	TotalCompletionTokens int64
+	TotalTurns            int64
+}
+
+// UsageDay returns the UTC day usage at t is counted on
+func UsageDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// RecordTokenUsage adds a turn's usage to the agent's running totals and to
+// its per-model counts for the day of now
+func (r *Repository) RecordTokenUsage(ctx context.Context, agentID string, usage []ModelTokenUsage, now time.Time) error {
+	ctx, span := startQuerySpan(ctx, "RecordTokenUsage")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	var prompt, completion int64
+	rows := make([]map[string]interface{}, 0, len(usage))
+	for _, u := range usage {
+		prompt += u.PromptTokens
+		completion += u.CompletionTokens
+		rows = append(rows, map[string]interface{}{
+			"model":             u.Model,
+			"prompt_tokens":     u.PromptTokens,
+			"completion_tokens": u.CompletionTokens,
+			"calls":             u.Calls,
+		})
+	}
+
+	query := `
+		MATCH (a:Agent {id: $agentID})
+		SET a.prompt_tokens = coalesce(a.prompt_tokens, 0) + $prompt,
+		    a.completion_tokens = coalesce(a.completion_tokens, 0) + $completion,
+		    a.usage_turns = coalesce(a.usage_turns, 0) + 1
+		WITH a
+		UNWIND $rows as row
+		MERGE (a)-[:HAS_TOKEN_USAGE]->(d:TokenUsage {day: $day, model: row.model})
+		SET d.prompt_tokens = coalesce(d.prompt_tokens, 0) + row.prompt_tokens,
+		    d.completion_tokens = coalesce(d.completion_tokens, 0) + row.completion_tokens,
+		    d.calls = coalesce(d.calls, 0) + row.calls
+	`
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		_, err := tx.Run(ctx, query, map[string]interface{}{
+			"agentID":    agentID,
+			"prompt":     prompt,
+			"completion": completion,
+			"rows":       rows,
+			"day":        UsageDay(now),
+		})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record token usage: %w", err)
+	}
+	return nil
+}
+
+// GetTokenUsage returns an agent's usage per model over the UTC days from
+// and to (inclusive, formatted like UsageDay), with its running totals
+func (r *Repository) GetTokenUsage(ctx context.Context, agentID, from, to string) (*TokenUsageReport, error) {
+	ctx, span := startQuerySpan(ctx, "GetTokenUsage")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	totalsQuery := `
+		MATCH (a:Agent {id: $agentID})
+		RETURN coalesce(a.prompt_tokens, 0) as prompt_tokens,
+		       coalesce(a.completion_tokens, 0) as completion_tokens,
+		       coalesce(a.usage_turns, 0) as turns
+	`
+	result, err := session.Run(ctx, totalsQuery, map[string]interface{}{"agentID": agentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token usage: %w", err)
+	}
+	if !result.Next(ctx) {
+		return nil, ErrAgentNotFound{AgentID: agentID}
+	}
+	record := result.Record()
+	report := &TokenUsageReport{
+		From:                  from,
+		To:                    to,
+		Models:                []ModelTokenUsage{},
+		TotalPromptTokens:     getInt64FromRecord(record, "prompt_tokens"),
+		TotalCompletionTokens: getInt64FromRecord(record, "completion_tokens"),
+		TotalTurns:            getInt64FromRecord(record, "turns"),
+	}
+
+	rangeQuery := `
+		MATCH (:Agent {id: $agentID})-[:HAS_TOKEN_USAGE]->(d:TokenUsage)
+		WHERE d.day >= $from AND d.day <= $to
+		RETURN d.model as model,
+		       sum(d.prompt_tokens) as prompt_tokens,
+		       sum(d.completion_tokens) as completion_tokens,
+		       sum(d.calls) as calls
+		ORDER BY model
+	`
+	result, err = session.Run(ctx, rangeQuery, map[string]interface{}{
+		"agentID": agentID,
+		"from":    from,
+		"to":      to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token usage: %w", err)
+	}
+	for result.Next(ctx) {
+		record := result.Record()
+		report.Models = append(report.Models, ModelTokenUsage{
+			Model:            getStringFromRecord(record, "model"),
+			PromptTokens:     getInt64FromRecord(record, "prompt_tokens"),
+			CompletionTokens: getInt64FromRecord(record, "completion_tokens"),
+			Calls:            getInt64FromRecord(record, "calls"),
+		})
+	}
+	return report, result.Err()
+}
@@ -3,6 +3,7 @@ package graph
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -195,3 +196,256 @@ func (r *Repository) LinkUserToTopicWeighted(ctx context.Context, userID, topicN
 	return nil
 }
 
+
+// GetChannelInterestTopics returns the topics that users active in a channel are
+// interested in or have shared facts about, most widely shared first
+func (r *Repository) GetChannelInterestTopics(ctx context.Context, channelID string, limit int) ([]Topic, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	if limit <= 0 {
+		limit = 10
+	}
+
+	query := `
+		MATCH (:Conversation {channel_id: $channelID})<-[:PARTICIPATED_IN]-(u:User)
+		OPTIONAL MATCH (u)-[:INTERESTED_IN]->(interest:Topic)
+		OPTIONAL MATCH (u)-[:TOLD_ME]->(:Fact)-[:ABOUT]->(factTopic:Topic)
+		WITH u, collect(DISTINCT interest) + collect(DISTINCT factTopic) as topics
+		UNWIND topics as t
+		WITH t, count(DISTINCT u) as interested
+		RETURN t.id as id, t.name as name, t.description as description, interested
+		ORDER BY interested DESC, name
+		LIMIT $limit
+	`
+
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"channelID": channelID,
+		"limit":     limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel interest topics: %w", err)
+	}
+
+	var topics []Topic
+	for result.Next(ctx) {
+		record := result.Record()
+		topics = append(topics, Topic{
+			ID:          getStringFromRecord(record, "id"),
+			Name:        getStringFromRecord(record, "name"),
+			Description: getStringFromRecord(record, "description"),
+		})
+	}
+
+	return topics, nil
+}
+
+// GetTopic returns a topic with its subtopics, parent topics and related
+// topics, or ErrTopicNotFound
+func (r *Repository) GetTopic(ctx context.Context, name string) (*TopicDetails, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (t:Topic {name: $name})
+		OPTIONAL MATCH (sub:Topic)-[:SUBTOPIC_OF]->(t)
+		WITH t, collect(DISTINCT sub {.id, .name, .description}) as subtopics
+		OPTIONAL MATCH (t)-[:SUBTOPIC_OF]->(parent:Topic)
+		WITH t, subtopics, collect(DISTINCT parent {.id, .name, .description}) as parents
+		OPTIONAL MATCH (t)-[:RELATED_TO]-(rel:Topic)
+		WHERE rel <> t
+		RETURN t.id as id, t.name as name, t.description as description,
+		       subtopics, parents, collect(DISTINCT rel {.id, .name, .description}) as related
+		LIMIT 1
+	`
+
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"name": name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topic: %w", err)
+	}
+
+	if !result.Next(ctx) {
+		return nil, ErrTopicNotFound{Name: name}
+	}
+	record := result.Record()
+	return &TopicDetails{
+		Topic: Topic{
+			ID:          getStringFromRecord(record, "id"),
+			Name:        getStringFromRecord(record, "name"),
+			Description: getStringFromRecord(record, "description"),
+		},
+		Subtopics:    topicsFromRecord(record, "subtopics"),
+		ParentTopics: topicsFromRecord(record, "parents"),
+		Related:      topicsFromRecord(record, "related"),
+	}, nil
+}
+
+// GetFactsByTopic returns the facts an agent knows about a topic, newest
+// first. A topic with no facts yields an empty slice.
+func (r *Repository) GetFactsByTopic(ctx context.Context, agentID, topicName string, limit int) ([]*Fact, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	if limit < 1 {
+		limit = 50
+	}
+
+	query := `
+		MATCH (a:Agent {id: $agentID})-[:KNOWS_FACT]->(f:Fact)-[:ABOUT]->(:Topic {name: $topicName})
+		RETURN DISTINCT f.id as id, f.content as content, f.source as source,
+		       f.confidence as confidence, f.created_at as created_at,
+		       coalesce(f.last_affirmed_at, f.created_at) as affirmed_at,
+		       coalesce(f.pinned, false) as pinned
+		ORDER BY created_at DESC
+		LIMIT $limit
+	`
+
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"agentID":   agentID,
+		"topicName": topicName,
+		"limit":     limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facts by topic: %w", err)
+	}
+
+	now := time.Now()
+	facts := []*Fact{}
+	for result.Next(ctx) {
+		record := result.Record()
+		createdAt := getTimeFromRecord(record, "created_at", now)
+		fact := &Fact{
+			ID:         getStringFromRecord(record, "id"),
+			Content:    getStringFromRecord(record, "content"),
+			Source:     getStringFromRecord(record, "source"),
+			Confidence: getFloat64FromRecord(record, "confidence"),
+			CreatedAt:  createdAt,
+			Pinned:     getBoolFromRecord(record, "pinned"),
+		}
+		r.applyFactDecay(fact, getTimeFromRecord(record, "affirmed_at", createdAt), now)
+		facts = append(facts, fact)
+	}
+
+	return facts, nil
+}
+
+// topicsFromRecord parses a collected list of topic maps
+func topicsFromRecord(record *neo4j.Record, key string) []Topic {
+	topics := []Topic{}
+	val, ok := record.Get(key)
+	if !ok {
+		return topics
+	}
+	list, ok := val.([]interface{})
+	if !ok {
+		return topics
+	}
+	for _, item := range list {
+		tm, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := getStringFromMap(tm, "name", "")
+		if name == "" {
+			continue
+		}
+		topics = append(topics, Topic{
+			ID:          getStringFromMap(tm, "id", ""),
+			Name:        name,
+			Description: getStringFromMap(tm, "description", ""),
+		})
+	}
+	return topics
+}
+
+// userTopicsQuery collects the topics a user is interested in or has shared
+// facts about. Expects $userID and yields rows of t.
+const userTopicsQuery = `
+	MATCH (u:User {id: $userID})
+	OPTIONAL MATCH (u)-[:INTERESTED_IN]->(interest:Topic)
+	OPTIONAL MATCH (u)-[:TOLD_ME]->(:Fact)-[:ABOUT]->(factTopic:Topic)
+	WITH collect(DISTINCT interest) + collect(DISTINCT factTopic) as topics
+	UNWIND topics as t
+	WITH DISTINCT t
+`
+
+// GetUserTopics returns the topics a user is interested in or has shared facts about
+func (r *Repository) GetUserTopics(ctx context.Context, userID string) ([]Topic, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	query := userTopicsQuery + `
+		RETURN t.id as id, t.name as name, t.description as description
+		ORDER BY name
+	`
+
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"userID": userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user topics: %w", err)
+	}
+
+	var topics []Topic
+	for result.Next(ctx) {
+		record := result.Record()
+		topics = append(topics, Topic{
+			ID:          getStringFromRecord(record, "id"),
+			Name:        getStringFromRecord(record, "name"),
+			Description: getStringFromRecord(record, "description"),
+		})
+	}
+
+	return topics, nil
+}
+
+// GetSharedTopics returns the topics both users are interested in or have
+// shared facts about
+func (r *Repository) GetSharedTopics(ctx context.Context, userA, userB string) ([]Topic, error) {
+	topicsA, err := r.GetUserTopics(ctx, userA)
+	if err != nil {
+		return nil, err
+	}
+	topicsB, err := r.GetUserTopics(ctx, userB)
+	if err != nil {
+		return nil, err
+	}
+	return CompareTopics(topicsA, topicsB).Shared, nil
+}
+
+// TopicComparison splits two users' topics into shared and divergent sets
+type TopicComparison struct {
+	Shared []Topic `json:"shared"`
+	OnlyA  []Topic `json:"only_a"`
+	OnlyB  []Topic `json:"only_b"`
+}
+
+// CompareTopics splits two topic lists into shared and divergent topics,
+// matching on name case-insensitively. Results keep the input order.
+func CompareTopics(a, b []Topic) TopicComparison {
+	inA := make(map[string]bool, len(a))
+	for _, t := range a {
+		inA[strings.ToLower(t.Name)] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, t := range b {
+		inB[strings.ToLower(t.Name)] = true
+	}
+
+	comparison := TopicComparison{Shared: []Topic{}, OnlyA: []Topic{}, OnlyB: []Topic{}}
+	for _, t := range a {
+		if inB[strings.ToLower(t.Name)] {
+			comparison.Shared = append(comparison.Shared, t)
+		} else {
+			comparison.OnlyA = append(comparison.OnlyA, t)
+		}
+	}
+	for _, t := range b {
+		if !inA[strings.ToLower(t.Name)] {
+			comparison.OnlyB = append(comparison.OnlyB, t)
+		}
+	}
+	return comparison
+}
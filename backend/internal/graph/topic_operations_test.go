@@ -0,0 +1,29 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestCompareTopics(t *testing.T) {
+	a := []Topic{{Name: "Gaming"}, {Name: "Cooking"}, {Name: "Jazz"}}
+	b := []Topic{{Name: "jazz"}, {Name: "Hiking"}, {Name: "gaming"}}
+
+	comparison := CompareTopics(a, b)
+
+	names := func(topics []Topic) []string {
+		out := []string{}
+		for _, t := range topics {
+			out = append(out, t.Name)
+		}
+		return out
+	}
+	if got := names(comparison.Shared); len(got) != 2 || got[0] != "Gaming" || got[1] != "Jazz" {
+		t.Errorf("Expected shared [Gaming Jazz], got %v", got)
+	}
+	if got := names(comparison.OnlyA); len(got) != 1 || got[0] != "Cooking" {
+		t.Errorf("Expected only_a [Cooking], got %v", got)
+	}
+	if got := names(comparison.OnlyB); len(got) != 1 || got[0] != "Hiking" {
+		t.Errorf("Expected only_b [Hiking], got %v", got)
+	}
+}
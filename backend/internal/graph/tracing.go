@@ -0,0 +1,18 @@
+package graph
+
+import (
+	"context"
+
+	"ezra-clone/backend/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startQuerySpan traces a repository operation under whatever turn or tool
+// span is already in ctx
+func startQuerySpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "graph."+operation,
+		attribute.String("db.system", "neo4j"),
+		attribute.String("db.operation", operation),
+	)
+}
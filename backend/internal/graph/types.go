@@ -19,13 +19,37 @@ type User struct {
 
 // Fact represents a learned fact
 type Fact struct {
-	ID         string    `json:"id"`
-	Content    string    `json:"content"`
-	Source     string    `json:"source,omitempty"`
-	Confidence float64   `json:"confidence"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID                  string    `json:"id"`
+	Content             string    `json:"content"`
+	Source              string    `json:"source,omitempty"`
+	Confidence          float64   `json:"confidence"`           // Stored confidence, as told
+	EffectiveConfidence float64   `json:"effective_confidence"` // Confidence after time decay, computed at read time
+	CreatedAt           time.Time `json:"created_at"`
+	Pinned              bool      `json:"pinned,omitempty"` // Pinned facts are never removed by cleanup
 }
 
+// FactInput describes a fact to create
+type FactInput struct {
+	Content    string   `json:"content"`
+	Source     string   `json:"source,omitempty"`
+	UserID     string   `json:"user_id,omitempty"`
+	Topics     []string `json:"topics,omitempty"`
+	Confidence *float64 `json:"confidence,omitempty"` // 0-1, defaults to 1.0
+}
+
+// FactBatchResult is the outcome of one fact in CreateFactsBatch
+type FactBatchResult struct {
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"` // created, merged, skipped
+}
+
+// Fact batch statuses
+const (
+	FactBatchCreated = "created" // Stored as a new fact
+	FactBatchMerged  = "merged"  // Identical to an existing fact, which was reused
+	FactBatchSkipped = "skipped" // Near-duplicate of an existing fact, not stored
+)
+
 // Topic represents a topic/subject
 type Topic struct {
 	ID          string `json:"id"`
@@ -33,21 +57,46 @@ type Topic struct {
 	Description string `json:"description,omitempty"`
 }
 
+// TopicDetails is a topic together with its place in the topic graph
+type TopicDetails struct {
+	Topic
+	Subtopics    []Topic `json:"subtopics"`      // Topics that are SUBTOPIC_OF this one
+	ParentTopics []Topic `json:"parent_topics"`  // Topics this one is a SUBTOPIC_OF
+	Related      []Topic `json:"related_topics"` // Topics linked by RELATED_TO in either direction
+}
+
 // Conversation represents a conversation thread
 type Conversation struct {
 	ID        string    `json:"id"`
 	ChannelID string    `json:"channel_id,omitempty"`
 	Platform  string    `json:"platform"` // discord, web
 	StartedAt time.Time `json:"started_at"`
+
+	// Summary fields, populated by GetAllConversations
+	LastMessage      string    `json:"last_message,omitempty"` // Truncated preview
+	LastMessageRole  string    `json:"last_message_role,omitempty"`
+	LastMessageAt    time.Time `json:"last_message_at"`
+	MessageCount     int       `json:"message_count"`
+	ParticipantCount int       `json:"participant_count"`
+}
+
+// ConversationReset reports what ResetConversation cleared
+type ConversationReset struct {
+	ChannelID       string `json:"channel_id"`
+	MessagesCleared int    `json:"messages_cleared"`
+	ArchiveID       string `json:"archive_id,omitempty"` // Archival memory holding the cleared transcript
 }
 
 // Message represents a single message
 type Message struct {
-	ID        string    `json:"id"`
-	Content   string    `json:"content"`
-	Role      string    `json:"role"` // user, agent
-	Platform  string    `json:"platform"`
-	Timestamp time.Time `json:"timestamp"`
+	ID          string     `json:"id"`
+	Content     string     `json:"content"`
+	Role        string     `json:"role"` // user, agent
+	Platform    string     `json:"platform"`
+	Timestamp   time.Time  `json:"timestamp"`
+	Attachments []string   `json:"attachments,omitempty"` // URLs of files shared with the message
+	SenderName  string     `json:"sender_name,omitempty"` // Display name of the user or agent who sent it, where known
+	EditedAt    *time.Time `json:"edited_at,omitempty"`   // When the sender last edited it on its platform
 }
 
 // UserContext contains aggregated information about a user
@@ -62,9 +111,10 @@ type UserContext struct {
 
 // SearchResult represents a search result
 type SearchResult struct {
-	Type       string                 `json:"type"` // fact, topic, memory, user
+	Type       string                 `json:"type"` // fact, topic, memory, user, message
 	ID         string                 `json:"id"`
 	Content    string                 `json:"content"`
+	Snippet    string                 `json:"snippet,omitempty"` // Excerpt with matches highlighted (full-text search only)
 	Score      float64                `json:"score"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 	Related    []string               `json:"related,omitempty"`
@@ -78,6 +128,14 @@ type Guild struct {
 	CreatedAt   time.Time `json:"created_at,omitempty"`
 }
 
+// BotPresence is the Discord presence the bot applies whenever it connects
+type BotPresence struct {
+	Status       string    `json:"status"`        // online, idle, dnd, invisible
+	ActivityType string    `json:"activity_type"` // custom, playing, listening, watching, competing
+	Text         string    `json:"text"`          // Status text; empty clears the activity
+	UpdatedAt    time.Time `json:"updated_at,omitempty"`
+}
+
 // Channel represents a Discord channel
 type Channel struct {
 	ID       string `json:"id"`
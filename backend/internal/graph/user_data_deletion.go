@@ -0,0 +1,225 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// UserDataDeletion reports what DeleteUserData removed. Running it again for
+// the same user finds nothing and reports zeros.
+type UserDataDeletion struct {
+	UserID              string   `json:"user_id"`
+	UserNodes           []string `json:"user_nodes"`    // IDs of the User nodes deleted, one per platform identity
+	Messages            int      `json:"messages"`      // Their messages, plus every message of the conversations below
+	Conversations       int      `json:"conversations"` // Conversations no one else took part in, deleted whole
+	Interactions        int      `json:"interactions"`
+	FactsDeleted        int      `json:"facts_deleted"`    // Facts only this user told an agent
+	FactsAnonymized     int      `json:"facts_anonymized"` // Facts other users told too, kept without the link to this user
+	PersonalityProfiles int      `json:"personality_profiles"`
+	PersonalityMemories int      `json:"personality_memories"`
+	ActivityPatterns    int      `json:"activity_patterns"`
+	MimicStates         int      `json:"mimic_states"` // Agents' persisted mimicry of this user
+}
+
+// userFact is a fact deleted with a user, kept to notify memory listeners
+type userFact struct {
+	id      string
+	agentID string
+}
+
+// DeleteUserData removes everything stored about a user, across all agents:
+// their User nodes, messages, interactions, personality profiles and
+// memories, activity patterns and mimic states. userID may be the user's ID
+// or any identifier on their User node (Discord or web ID); every User node
+// sharing one of those identifiers is deleted too.
+//
+// Facts only this user told are deleted. Facts other users told as well are
+// kept, but unlinked from the user and stripped of their user ID. Agent
+// replies are kept in shared conversations, while conversations no one else
+// took part in, such as DMs and web sessions, are deleted with all their
+// messages. Archival memories are not searched, since they don't record
+// whose messages they summarize.
+//
+// Everything is deleted in one transaction, so a failure deletes nothing.
+func (r *Repository) DeleteUserData(ctx context.Context, userID string) (*UserDataDeletion, error) {
+	ctx, span := startQuerySpan(ctx, "DeleteUserData")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	var deletedFacts []userFact
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		deletion := &UserDataDeletion{UserID: userID, UserNodes: []string{}}
+		deletedFacts = nil
+
+		usersQuery := `
+			MATCH (u:User)
+			WHERE u.id = $userID OR u.discord_id = $userID OR u.web_id = $userID
+			WITH collect(u.id) + collect(u.discord_id) + collect(u.web_id) + [$userID] as identifiers
+			MATCH (u:User)
+			WHERE u.id IN identifiers OR u.discord_id IN identifiers OR u.web_id IN identifiers
+			RETURN collect(DISTINCT u.id) as ids
+		`
+		result, err := tx.Run(ctx, usersQuery, map[string]interface{}{"userID": userID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user: %w", err)
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user: %w", err)
+		}
+		rawIDs, _ := record.Get("ids")
+		for _, id := range rawIDs.([]any) {
+			if s, ok := id.(string); ok {
+				deletion.UserNodes = append(deletion.UserNodes, s)
+			}
+		}
+		// Facts record the user ID they were told under even without a User node
+		ids := deletion.UserNodes
+		if !slices.Contains(ids, userID) {
+			ids = append(slices.Clone(ids), userID)
+		}
+		params := map[string]interface{}{"ids": ids}
+
+		// A fact is shared when another user told the agent the same thing
+		factsQuery := `
+			CALL {
+				MATCH (f:Fact) WHERE f.user_id IN $ids
+				RETURN f
+				UNION
+				MATCH (u:User)-[:TOLD_ME]->(f:Fact) WHERE u.id IN $ids
+				RETURN f
+			}
+			OPTIONAL MATCH (other:User)-[:TOLD_ME]->(f)
+			WHERE NOT other.id IN $ids
+			RETURN f.id as id, f.agent_id as agent_id, count(other) > 0 as shared
+		`
+		result, err = tx.Run(ctx, factsQuery, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user facts: %w", err)
+		}
+		var sharedIDs, ownedIDs []string
+		for result.Next(ctx) {
+			record := result.Record()
+			id := getStringFromRecord(record, "id")
+			if shared, _ := record.Get("shared"); shared == true {
+				sharedIDs = append(sharedIDs, id)
+			} else {
+				ownedIDs = append(ownedIDs, id)
+				deletedFacts = append(deletedFacts, userFact{id: id, agentID: getStringFromRecord(record, "agent_id")})
+			}
+		}
+		if err := result.Err(); err != nil {
+			return nil, fmt.Errorf("failed to find user facts: %w", err)
+		}
+
+		steps := []struct {
+			count  *int
+			query  string
+			params map[string]interface{}
+		}{
+			{&deletion.FactsAnonymized, `
+				MATCH (f:Fact) WHERE f.id IN $factIDs
+				OPTIONAL MATCH (u:User)-[told:TOLD_ME]->(f) WHERE u.id IN $ids
+				DELETE told
+				WITH DISTINCT f
+				SET f.user_id = CASE WHEN f.user_id IN $ids THEN null ELSE f.user_id END
+				RETURN count(f) as count
+			`, map[string]interface{}{"ids": ids, "factIDs": sharedIDs}},
+			{&deletion.FactsDeleted, `
+				MATCH (f:Fact) WHERE f.id IN $factIDs
+				DETACH DELETE f
+				RETURN count(f) as count
+			`, map[string]interface{}{"factIDs": ownedIDs}},
+			{&deletion.Conversations, `
+				MATCH (u:User)-[:PARTICIPATED_IN]->(c:Conversation) WHERE u.id IN $ids
+				WITH DISTINCT c
+				OPTIONAL MATCH (other:User)-[:PARTICIPATED_IN]->(c) WHERE NOT other.id IN $ids
+				WITH c, count(other) as others
+				WHERE others = 0
+				OPTIONAL MATCH (c)-[:CONTAINS]->(m:Message)
+				WITH c, collect(m) as messages
+				FOREACH (m IN messages | DETACH DELETE m)
+				DETACH DELETE c
+				RETURN count(c) as count, sum(size(messages)) as messages
+			`, params},
+			{&deletion.Messages, `
+				MATCH (u:User)-[:SENT]->(m:Message) WHERE u.id IN $ids
+				WITH DISTINCT m
+				DETACH DELETE m
+				RETURN count(m) as count
+			`, params},
+			{&deletion.Interactions, `
+				MATCH (i:Interaction)-[:FROM_USER]->(u:User) WHERE u.id IN $ids
+				WITH DISTINCT i
+				DETACH DELETE i
+				RETURN count(i) as count
+			`, params},
+			{&deletion.PersonalityProfiles, `
+				MATCH (p:UserPersonalityProfile) WHERE p.user_id IN $ids
+				DETACH DELETE p
+				RETURN count(p) as count
+			`, params},
+			{&deletion.PersonalityMemories, `
+				MATCH (u:User)-[:HAS_PERSONALITY_MEMORY]->(m:UserPersonalityMemory) WHERE u.id IN $ids
+				WITH DISTINCT m
+				DETACH DELETE m
+				RETURN count(m) as count
+			`, params},
+			{&deletion.ActivityPatterns, `
+				MATCH (u:User)-[:ACTIVE_AT]->(ap:ActivityPattern) WHERE u.id IN $ids
+				WITH DISTINCT ap
+				DETACH DELETE ap
+				RETURN count(ap) as count
+			`, params},
+			{&deletion.MimicStates, `
+				MATCH (m:MimicState) WHERE m.user_id IN $ids
+				DETACH DELETE m
+				RETURN count(m) as count
+			`, params},
+			{nil, `
+				MATCH (u:User) WHERE u.id IN $ids
+				DETACH DELETE u
+			`, params},
+		}
+		for _, step := range steps {
+			result, err := tx.Run(ctx, step.query, step.params)
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete user data: %w", err)
+			}
+			if step.count == nil {
+				continue
+			}
+			record, err := result.Single(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete user data: %w", err)
+			}
+			*step.count += getIntFromRecord(record, "count")
+			// Messages of deleted conversations count towards the user's messages
+			deletion.Messages += getIntFromRecord(record, "messages")
+		}
+		return deletion, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deletion := result.(*UserDataDeletion)
+	for _, fact := range deletedFacts {
+		r.emitMemoryEvent(EventFactDeleted, fact.agentID, fact.id, "")
+	}
+	r.logger.Info("User data deleted",
+		zap.String("user_id", userID),
+		zap.Strings("user_nodes", deletion.UserNodes),
+		zap.Int("messages", deletion.Messages),
+		zap.Int("conversations", deletion.Conversations),
+		zap.Int("facts_deleted", deletion.FactsDeleted),
+		zap.Int("facts_anonymized", deletion.FactsAnonymized),
+	)
+	return deletion, nil
+}
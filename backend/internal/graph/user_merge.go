@@ -0,0 +1,429 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// UserMerge reports what MergeUsers did
+type UserMerge struct {
+	PrimaryID     string   `json:"primary_id"`
+	SecondaryID   string   `json:"secondary_id"`
+	Relationships int      `json:"relationships"` // Relationships moved from the secondary user
+	FactsMerged   int      `json:"facts_merged"`  // Facts both users had told the same agent, kept once
+	LinkedIDs     []string `json:"linked_ids"`    // IDs that now resolve to the primary user
+}
+
+// relTypePattern matches relationship types that are safe to put in a query
+var relTypePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// isEmptyProperty reports whether a user property is unset
+func isEmptyProperty(value any) bool {
+	if value == nil {
+		return true
+	}
+	s, ok := value.(string)
+	return ok && s == ""
+}
+
+// mergeUserProperties combines two users' properties. The primary's values
+// win; the secondary fills in what the primary lacks. first_seen keeps the
+// earlier time and last_seen the later, today's message quota counts both
+// users' messages, and the secondary's IDs are added to linked_ids.
+func mergeUserProperties(primary, secondary map[string]any, secondaryID string) map[string]any {
+	merged := make(map[string]any, len(primary)+len(secondary))
+	for key, value := range primary {
+		merged[key] = value
+	}
+	for key, value := range secondary {
+		if key == "id" || key == "linked_ids" || key == "quota_day" || key == "quota_count" {
+			continue
+		}
+		if isEmptyProperty(merged[key]) {
+			merged[key] = value
+		}
+	}
+
+	if earlier, ok := secondary["first_seen"].(time.Time); ok {
+		if current, ok := merged["first_seen"].(time.Time); !ok || earlier.Before(current) {
+			merged["first_seen"] = earlier
+		}
+	}
+	if later, ok := secondary["last_seen"].(time.Time); ok {
+		if current, ok := merged["last_seen"].(time.Time); !ok || later.After(current) {
+			merged["last_seen"] = later
+		}
+	}
+
+	// Merging mustn't give anyone a fresh quota for today
+	primaryDay, _ := primary["quota_day"].(string)
+	secondaryDay, _ := secondary["quota_day"].(string)
+	primaryCount, _ := primary["quota_count"].(int64)
+	secondaryCount, _ := secondary["quota_count"].(int64)
+	switch {
+	case secondaryDay != "" && secondaryDay == primaryDay:
+		merged["quota_count"] = primaryCount + secondaryCount
+	case secondaryDay > primaryDay:
+		merged["quota_day"] = secondaryDay
+		merged["quota_count"] = secondaryCount
+	}
+
+	// A web user linked to a Discord user keeps its ID as the web ID
+	if isEmptyProperty(merged["web_id"]) && secondary["platform"] == "web" {
+		merged["web_id"] = secondaryID
+	}
+
+	linked := []any{}
+	for _, ids := range []any{primary["linked_ids"], secondary["linked_ids"], []any{secondaryID}} {
+		list, _ := ids.([]any)
+		for _, id := range list {
+			if !slices.Contains(linked, id) {
+				linked = append(linked, id)
+			}
+		}
+	}
+	merged["linked_ids"] = linked
+	return merged
+}
+
+// mergeRelationshipProperties combines a moved relationship with the one the
+// primary user already had to the same node. Counts add up, lists are
+// joined, scores keep the higher value, averages are weighted by count, and
+// first_ times keep the earlier time while other times keep the later.
+// Anything else keeps the primary's value.
+func mergeRelationshipProperties(existing, moved map[string]any) map[string]any {
+	if existing == nil {
+		return moved
+	}
+	merged := make(map[string]any, len(existing)+len(moved))
+	for key, value := range existing {
+		merged[key] = value
+	}
+	for key, value := range moved {
+		current, ok := merged[key]
+		if !ok || current == nil {
+			merged[key] = value
+			continue
+		}
+		switch v := value.(type) {
+		case int64:
+			if c, ok := current.(int64); ok && !strings.HasPrefix(key, "avg_") {
+				merged[key] = c + v
+			}
+		case float64:
+			if c, ok := current.(float64); ok && v > c {
+				merged[key] = v
+			}
+		case []any:
+			if c, ok := current.([]any); ok {
+				joined := slices.Clone(c)
+				for _, item := range v {
+					if !slices.Contains(joined, item) {
+						joined = append(joined, item)
+					}
+				}
+				merged[key] = joined
+			}
+		case time.Time:
+			c, ok := current.(time.Time)
+			if !ok {
+				continue
+			}
+			if strings.HasPrefix(key, "first_") && v.Before(c) || !strings.HasPrefix(key, "first_") && v.After(c) {
+				merged[key] = v
+			}
+		}
+	}
+
+	// Averages over both relationships' counts
+	existingCount, _ := existing["count"].(int64)
+	movedCount, _ := moved["count"].(int64)
+	if existingCount+movedCount > 0 {
+		for key, value := range moved {
+			if !strings.HasPrefix(key, "avg_") {
+				continue
+			}
+			current, currentOK := existing[key].(int64)
+			added, addedOK := value.(int64)
+			if currentOK && addedOK {
+				merged[key] = (current*existingCount + added*movedCount) / (existingCount + movedCount)
+			}
+		}
+	}
+	return merged
+}
+
+// MergeUsers folds the secondary user into the primary, so a person who
+// talks to agents from several platforms has one set of facts, history and
+// profiles. Every relationship of the secondary moves to the primary, merged
+// with any the primary already had to the same node. Per-user nodes keyed
+// by user (activity patterns, facts, personality profiles) are combined
+// where both users had one. The secondary's properties fill in what the
+// primary lacks, its ID is kept in the primary's linked_ids so
+// ResolveUserID maps it to the primary, and the secondary is deleted.
+// Everything happens in one transaction.
+func (r *Repository) MergeUsers(ctx context.Context, primaryID, secondaryID string) (*UserMerge, error) {
+	ctx, span := startQuerySpan(ctx, "MergeUsers")
+	defer span.End()
+
+	if primaryID == secondaryID {
+		return nil, fmt.Errorf("cannot merge user %s into itself", primaryID)
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	var deletedFacts []userFact
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		merge := &UserMerge{PrimaryID: primaryID, SecondaryID: secondaryID}
+		deletedFacts = nil
+		params := map[string]interface{}{"primaryID": primaryID, "secondaryID": secondaryID}
+
+		usersQuery := `
+			OPTIONAL MATCH (p:User {id: $primaryID})
+			OPTIONAL MATCH (s:User {id: $secondaryID})
+			RETURN properties(p) as primary_props, properties(s) as secondary_props
+		`
+		result, err := tx.Run(ctx, usersQuery, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load users: %w", err)
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load users: %w", err)
+		}
+		primaryProps, _ := record.Get("primary_props")
+		secondaryProps, _ := record.Get("secondary_props")
+		if primaryProps == nil {
+			return nil, ErrUserNotFound{UserID: primaryID}
+		}
+		if secondaryProps == nil {
+			return nil, ErrUserNotFound{UserID: secondaryID}
+		}
+
+		// Move the secondary's relationships, except any to the primary
+		relsQuery := `
+			MATCH (s:User {id: $secondaryID})-[rel]-(other)
+			WHERE NOT (other:User AND other.id IN [$primaryID, $secondaryID])
+			MATCH (p:User {id: $primaryID})
+			WITH p, rel, other, startNode(rel) = s as outgoing
+			OPTIONAL MATCH (p)-[existing]-(other)
+			WHERE type(existing) = type(rel) AND (startNode(existing) = p) = outgoing
+			RETURN elementId(rel) as rel_id, type(rel) as type, outgoing, elementId(other) as other_id,
+			       properties(rel) as props, properties(existing) as existing_props
+		`
+		result, err = tx.Run(ctx, relsQuery, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load user relationships: %w", err)
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load user relationships: %w", err)
+		}
+		moved := make(map[string]bool, len(records))
+		for _, record := range records {
+			relID := getStringFromRecord(record, "rel_id")
+			relType := getStringFromRecord(record, "type")
+			if moved[relID] {
+				continue
+			}
+			if !relTypePattern.MatchString(relType) {
+				return nil, fmt.Errorf("unexpected relationship type %q", relType)
+			}
+			props, _ := record.Get("props")
+			existingProps, _ := record.Get("existing_props")
+			movedProps, _ := props.(map[string]any)
+			primaryRelProps, _ := existingProps.(map[string]any)
+
+			pattern := "(p)-[r:" + relType + "]->(o)"
+			if outgoing, _ := record.Get("outgoing"); outgoing != true {
+				pattern = "(p)<-[r:" + relType + "]-(o)"
+			}
+			moveQuery := `
+				MATCH (p:User {id: $primaryID})
+				MATCH (o) WHERE elementId(o) = $otherID
+				MERGE ` + pattern + `
+				SET r = $props
+				WITH r
+				MATCH ()-[old]->() WHERE elementId(old) = $relID
+				DELETE old
+			`
+			if _, err := tx.Run(ctx, moveQuery, map[string]interface{}{
+				"primaryID": primaryID,
+				"otherID":   getStringFromRecord(record, "other_id"),
+				"relID":     relID,
+				"props":     mergeRelationshipProperties(primaryRelProps, movedProps),
+			}); err != nil {
+				return nil, fmt.Errorf("failed to move %s relationship: %w", relType, err)
+			}
+			moved[relID] = true
+		}
+		merge.Relationships = len(moved)
+
+		// Activity for the same hour of the week is counted once
+		activityQuery := `
+			MATCH (:User {id: $primaryID})-[:ACTIVE_AT]->(ap:ActivityPattern)
+			WITH ap.day_of_week as day, ap.hour_of_day as hour, collect(ap) as patterns
+			WHERE size(patterns) > 1
+			WITH head(patterns) as keep, tail(patterns) as duplicates
+			UNWIND duplicates as duplicate
+			SET keep.activity_count = keep.activity_count + duplicate.activity_count,
+			    keep.total_message_length = keep.total_message_length + duplicate.total_message_length
+			SET keep.avg_message_length = keep.total_message_length / keep.activity_count
+			DETACH DELETE duplicate
+		`
+		if _, err := tx.Run(ctx, activityQuery, params); err != nil {
+			return nil, fmt.Errorf("failed to merge activity patterns: %w", err)
+		}
+
+		// Facts are keyed by the user who told them; one both users told the
+		// same agent is kept once, with the topics and tellers of both
+		duplicateFactsQuery := `
+			MATCH (f:Fact {user_id: $secondaryID})
+			MATCH (kept:Fact {agent_id: f.agent_id, user_id: $primaryID, content_hash: f.content_hash})
+			OPTIONAL MATCH (f)-[:ABOUT]->(t:Topic)
+			OPTIONAL MATCH (teller:User)-[:TOLD_ME]->(f)
+			WITH f, kept, collect(DISTINCT t) as topics, collect(DISTINCT teller) as tellers
+			FOREACH (t IN topics | MERGE (kept)-[:ABOUT]->(t))
+			FOREACH (u IN tellers | MERGE (u)-[:TOLD_ME]->(kept))
+			WITH f, f.id as id, f.agent_id as agent_id
+			DETACH DELETE f
+			RETURN id, agent_id
+		`
+		result, err = tx.Run(ctx, duplicateFactsQuery, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge facts: %w", err)
+		}
+		for result.Next(ctx) {
+			record := result.Record()
+			deletedFacts = append(deletedFacts, userFact{
+				id:      getStringFromRecord(record, "id"),
+				agentID: getStringFromRecord(record, "agent_id"),
+			})
+		}
+		if err := result.Err(); err != nil {
+			return nil, fmt.Errorf("failed to merge facts: %w", err)
+		}
+		merge.FactsMerged = len(deletedFacts)
+
+		// The rest of the secondary's keyed nodes become the primary's. The
+		// primary's personality profile for a guild wins over the secondary's.
+		reassignQueries := []string{
+			`MATCH (f:Fact {user_id: $secondaryID}) SET f.user_id = $primaryID`,
+			`MATCH (dup:UserPersonalityProfile {user_id: $secondaryID})
+			 MATCH (:UserPersonalityProfile {user_id: $primaryID, guild_id: dup.guild_id})
+			 DETACH DELETE dup`,
+			`MATCH (p:UserPersonalityProfile {user_id: $secondaryID}) SET p.user_id = $primaryID`,
+			`MATCH (m:MimicState {user_id: $secondaryID}) SET m.user_id = $primaryID`,
+		}
+		for _, query := range reassignQueries {
+			if _, err := tx.Run(ctx, query, params); err != nil {
+				return nil, fmt.Errorf("failed to reassign user data: %w", err)
+			}
+		}
+
+		// Delete the secondary before copying its properties, so unique IDs
+		// such as discord_id aren't on two nodes at once
+		if _, err := tx.Run(ctx, `MATCH (s:User {id: $secondaryID}) DETACH DELETE s`, params); err != nil {
+			return nil, fmt.Errorf("failed to delete merged user: %w", err)
+		}
+		merged := mergeUserProperties(primaryProps.(map[string]any), secondaryProps.(map[string]any), secondaryID)
+		if _, err := tx.Run(ctx, `MATCH (p:User {id: $primaryID}) SET p = $props`, map[string]interface{}{
+			"primaryID": primaryID,
+			"props":     merged,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to update merged user: %w", err)
+		}
+		for _, id := range merged["linked_ids"].([]any) {
+			merge.LinkedIDs = append(merge.LinkedIDs, fmt.Sprint(id))
+		}
+		return merge, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merge := result.(*UserMerge)
+	for _, fact := range deletedFacts {
+		r.emitMemoryEvent(EventFactDeleted, fact.agentID, fact.id, "")
+	}
+	r.logger.Info("Users merged",
+		zap.String("primary_id", primaryID),
+		zap.String("secondary_id", secondaryID),
+		zap.Int("relationships", merge.Relationships),
+		zap.Int("facts_merged", merge.FactsMerged),
+	)
+	return merge, nil
+}
+
+// ResolveUserID returns the ID of the user userID was merged into, or
+// userID itself when it wasn't merged
+func (r *Repository) ResolveUserID(ctx context.Context, userID string) (string, error) {
+	ctx, span := startQuerySpan(ctx, "ResolveUserID")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	// Users that exist under their own ID weren't merged, which saves
+	// scanning linked IDs for every message
+	result, err := session.Run(ctx, `MATCH (u:User {id: $userID}) RETURN u.id as id`, map[string]interface{}{"userID": userID})
+	if err != nil {
+		return userID, fmt.Errorf("failed to resolve user: %w", err)
+	}
+	if result.Next(ctx) {
+		return userID, nil
+	}
+
+	result, err = session.Run(ctx, `
+		MATCH (u:User) WHERE $userID IN u.linked_ids
+		RETURN u.id as id
+		LIMIT 1
+	`, map[string]interface{}{"userID": userID})
+	if err != nil {
+		return userID, fmt.Errorf("failed to resolve user: %w", err)
+	}
+	if result.Next(ctx) {
+		return getStringFromRecord(result.Record(), "id"), nil
+	}
+	return userID, result.Err()
+}
+
+// FindDiscordUsers returns the Discord users with the given username,
+// ignoring case, for linking a web user who says who they are on Discord
+func (r *Repository) FindDiscordUsers(ctx context.Context, username string) ([]*User, error) {
+	ctx, span := startQuerySpan(ctx, "FindDiscordUsers")
+	defer span.End()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (u:User)
+		WHERE u.discord_id IS NOT NULL AND u.discord_id <> ''
+		  AND toLower(u.discord_username) = toLower($username)
+		RETURN u.id as id, u.discord_id as discord_id, u.discord_username as discord_username
+		ORDER BY u.id
+	`
+	result, err := session.Run(ctx, query, map[string]interface{}{"username": strings.TrimPrefix(username, "@")})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Discord users: %w", err)
+	}
+	var users []*User
+	for result.Next(ctx) {
+		record := result.Record()
+		users = append(users, &User{
+			ID:              getStringFromRecord(record, "id"),
+			DiscordID:       getStringFromRecord(record, "discord_id"),
+			DiscordUsername: getStringFromRecord(record, "discord_username"),
+		})
+	}
+	return users, result.Err()
+}
@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeUserProperties(t *testing.T) {
+	early := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(72 * time.Hour)
+	primary := map[string]any{
+		"id":                 "123",
+		"discord_id":         "123",
+		"discord_username":   "ezra_fan",
+		"preferred_language": "",
+		"first_seen":         late,
+		"last_seen":          late,
+		"quota_day":          "2026-01-04",
+		"quota_count":        int64(3),
+	}
+	secondary := map[string]any{
+		"id":                 "web-abc",
+		"discord_username":   "someone_else",
+		"preferred_language": "de",
+		"platform":           "web",
+		"first_seen":         early,
+		"last_seen":          early,
+		"quota_day":          "2026-01-04",
+		"quota_count":        int64(2),
+		"linked_ids":         []any{"web-old"},
+	}
+
+	merged := mergeUserProperties(primary, secondary, "web-abc")
+	want := map[string]any{
+		"id":                 "123",
+		"discord_id":         "123",
+		"discord_username":   "ezra_fan",
+		"preferred_language": "de",
+		"platform":           "web",
+		"web_id":             "web-abc",
+		"first_seen":         early,
+		"last_seen":          late,
+		"quota_day":          "2026-01-04",
+		"quota_count":        int64(5),
+		"linked_ids":         []any{"web-old", "web-abc"},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("mergeUserProperties() = %v, want %v", merged, want)
+	}
+}
+
+func TestMergeRelationshipProperties(t *testing.T) {
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := first.Add(24 * time.Hour)
+	existing := map[string]any{
+		"count":             int64(3),
+		"avg_response_time": int64(10),
+		"strength":          0.4,
+		"contexts":          []any{"a"},
+		"first_mentioned":   last,
+		"last_mentioned":    last,
+	}
+	moved := map[string]any{
+		"count":             int64(1),
+		"avg_response_time": int64(30),
+		"strength":          0.9,
+		"contexts":          []any{"a", "b"},
+		"first_mentioned":   first,
+		"last_mentioned":    first,
+		"joined_at":         first,
+	}
+
+	merged := mergeRelationshipProperties(existing, moved)
+	want := map[string]any{
+		"count":             int64(4),
+		"avg_response_time": int64(15),
+		"strength":          0.9,
+		"contexts":          []any{"a", "b"},
+		"first_mentioned":   first,
+		"last_mentioned":    last,
+		"joined_at":         first,
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("mergeRelationshipProperties() = %v, want %v", merged, want)
+	}
+
+	if got := mergeRelationshipProperties(nil, moved); !reflect.DeepEqual(got, moved) {
+		t.Errorf("Expected a relationship the primary lacked to move as is, got %v", got)
+	}
+}
@@ -104,6 +104,52 @@ func (r *Repository) GetUserLanguagePreference(ctx context.Context, userID strin
 	return "", nil // No preference set
 }
 
+// SetUserLocation stores where a user is, e.g. "Paris, Île-de-France, France",
+// used as their default for location-based tools like the weather
+func (r *Repository) SetUserLocation(ctx context.Context, userID, location string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (u:User {id: $userID})
+		SET u.location = $location
+	`
+
+	_, err := session.Run(ctx, query, map[string]interface{}{
+		"userID":   userID,
+		"location": location,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set user location: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserLocation retrieves a user's stored location, or "" when none is set
+func (r *Repository) GetUserLocation(ctx context.Context, userID string) (string, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (u:User {id: $userID})
+		RETURN u.location as location
+	`
+
+	result, err := session.Run(ctx, query, map[string]interface{}{
+		"userID": userID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get user location: %w", err)
+	}
+
+	if result.Next(ctx) {
+		return getStringFromRecord(result.Record(), "location"), nil
+	}
+
+	return "", nil
+}
+
 // FindUserByDiscordUsername finds a user by their Discord username (case-insensitive)
 func (r *Repository) FindUserByDiscordUsername(ctx context.Context, username string) (*User, error) {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
@@ -139,6 +185,9 @@ func (r *Repository) FindUserByDiscordUsername(ctx context.Context, username str
 
 // GetUserContext retrieves comprehensive context about a user
 func (r *Repository) GetUserContext(ctx context.Context, userID string) (*UserContext, error) {
+	ctx, span := startQuerySpan(ctx, "GetUserContext")
+	defer span.End()
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
@@ -147,13 +196,17 @@ func (r *Repository) GetUserContext(ctx context.Context, userID string) (*UserCo
 		OPTIONAL MATCH (u)-[:INTERESTED_IN]->(t:Topic)
 		OPTIONAL MATCH (u)-[:TOLD_ME]->(f:Fact)
 		OPTIONAL MATCH (u)-[:SENT]->(m:Message)
+		WHERE m.deleted_at IS NULL
 		OPTIONAL MATCH (u)-[:PARTICIPATED_IN]->(c:Conversation)
 		WITH u, 
 		     collect(DISTINCT {id: t.id, name: t.name}) as topics,
-		     collect(DISTINCT {id: f.id, content: f.content}) as facts,
+		     collect(DISTINCT {id: f.id, content: f.content, pinned: coalesce(f.pinned, false),
+		                       confidence: f.confidence, created_at: f.created_at,
+		                       affirmed_at: coalesce(f.last_affirmed_at, f.created_at)}) as facts,
 		     count(DISTINCT m) as msg_count,
 		     count(DISTINCT c) as conv_count
 		OPTIONAL MATCH (u)-[:SENT]->(lastMsg:Message)
+		WHERE lastMsg.deleted_at IS NULL
 		WITH u, topics, facts, msg_count, conv_count, lastMsg
 		ORDER BY lastMsg.timestamp DESC
 		LIMIT 1
@@ -217,16 +270,24 @@ func (r *Repository) GetUserContext(ctx context.Context, userID string) (*UserCo
 			}
 		}
 
-		// Parse facts
+		// Parse facts, with the confidence and dates the prompt's fact ranking breaks ties on
+		now := time.Now()
 		if facts, ok := record.Get("facts"); ok {
 			if factList, ok := facts.([]interface{}); ok {
 				for _, f := range factList {
 					if fm, ok := f.(map[string]interface{}); ok {
 						if content, ok := fm["content"].(string); ok && content != "" {
-							uc.Facts = append(uc.Facts, Fact{
-								ID:      getStringFromMap(fm, "id", ""),
-								Content: content,
-							})
+							pinned, _ := fm["pinned"].(bool)
+							createdAt := getTimeFromMap(fm, "created_at", now)
+							fact := Fact{
+								ID:         getStringFromMap(fm, "id", ""),
+								Content:    content,
+								Confidence: getFloat64FromMap(fm, "confidence", 0),
+								CreatedAt:  createdAt,
+								Pinned:     pinned,
+							}
+							r.applyFactDecay(&fact, getTimeFromMap(fm, "affirmed_at", createdAt), now)
+							uc.Facts = append(uc.Facts, fact)
 						}
 					}
 				}
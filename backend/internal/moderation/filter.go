@@ -0,0 +1,229 @@
+// Package moderation filters agent output before it is posted, using a local
+// pattern policy and, optionally, a moderation model.
+package moderation
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"ezra-clone/backend/internal/adapter"
+
+	"go.uber.org/zap"
+)
+
+// Filter modes an agent may pick
+const (
+	ModeOff        = ""           // No filtering (default)
+	ModeLocal      = "local"      // The local pattern policy only
+	ModeModeration = "moderation" // The moderation model, plus the local policy
+)
+
+// What happens to a reply that violates the policy
+const (
+	ActionReplace = "replace" // Send the fallback message instead (default)
+	ActionBlock   = "block"   // Send nothing
+)
+
+// DefaultFallback replaces filtered replies when no fallback is configured
+const DefaultFallback = "Sorry, I can't share that."
+
+// Settings are an agent's content filter choices
+type Settings struct {
+	Mode     string
+	Action   string
+	Provider string // LLM provider serving the moderation model; empty uses the default
+	Model    string // Moderation model; empty uses the filter's
+}
+
+// Enabled reports whether the agent's output is filtered
+func (s Settings) Enabled() bool {
+	return s.Mode != ModeOff
+}
+
+// Validate checks the mode and action are known
+func (s Settings) Validate() error {
+	switch s.Mode {
+	case ModeOff, ModeLocal, ModeModeration:
+	default:
+		return fmt.Errorf("content_filter must be %q, %q or empty", ModeLocal, ModeModeration)
+	}
+	switch s.Action {
+	case "", ActionReplace, ActionBlock:
+	default:
+		return fmt.Errorf("content_filter_action must be %q or %q", ActionReplace, ActionBlock)
+	}
+	return nil
+}
+
+// Policy is a local list of case-insensitive patterns output must not match
+type Policy struct {
+	patterns []*regexp.Regexp
+}
+
+// ParsePolicy compiles rules into a policy. Each rule is a regular
+// expression matched case-insensitively; plain words match anywhere in the
+// text, so anchor them with \b to match whole words.
+func ParsePolicy(rules []string) (*Policy, error) {
+	policy := &Policy{}
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		pattern, err := regexp.Compile("(?i)" + rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content filter pattern %q: %w", rule, err)
+		}
+		policy.patterns = append(policy.patterns, pattern)
+	}
+	return policy, nil
+}
+
+// LoadPolicy reads a policy file with one pattern per line. Blank lines and
+// lines starting with # are ignored.
+func LoadPolicy(path string) (*Policy, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open content filter patterns: %w", err)
+	}
+	defer file.Close()
+
+	var rules []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rules = append(rules, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read content filter patterns: %w", err)
+	}
+	return ParsePolicy(rules)
+}
+
+// Match returns the first pattern text matches, or "" when none does
+func (p *Policy) Match(text string) string {
+	if p == nil {
+		return ""
+	}
+	for _, pattern := range p.patterns {
+		if pattern.MatchString(text) {
+			return strings.TrimPrefix(pattern.String(), "(?i)")
+		}
+	}
+	return ""
+}
+
+// Moderator checks text with a moderation model served by a provider;
+// *adapter.LLMAdapter implements it
+type Moderator interface {
+	Moderate(ctx context.Context, provider, model, text string) (*adapter.ModerationResult, error)
+}
+
+// Verdict is why a text was flagged
+type Verdict struct {
+	Flagged bool
+	Source  string // "policy" or "moderation"
+	Reason  string // The matched pattern or the flagged categories
+}
+
+// Filter checks agent output against the local policy and the moderation
+// model, and swaps out what they flag
+type Filter struct {
+	policy    *Policy
+	moderator Moderator
+	model     string
+	fallback  string
+	logger    *zap.Logger
+}
+
+// NewFilter creates a filter. policy and moderator may be nil; a nil
+// moderator makes the moderation mode behave like the local one.
+func NewFilter(policy *Policy, moderator Moderator, model, fallback string, logger *zap.Logger) *Filter {
+	if model == "" {
+		model = adapter.DefaultModerationModel
+	}
+	if fallback == "" {
+		fallback = DefaultFallback
+	}
+	return &Filter{policy: policy, moderator: moderator, model: model, fallback: fallback, logger: logger}
+}
+
+// Check runs text through the checks the settings' mode calls for. The local
+// policy goes first, so the moderation model isn't called for text it
+// already flags.
+func (f *Filter) Check(ctx context.Context, settings Settings, text string) (Verdict, error) {
+	mode := settings.Mode
+	if mode == ModeOff || strings.TrimSpace(text) == "" {
+		return Verdict{}, nil
+	}
+	if rule := f.policy.Match(text); rule != "" {
+		return Verdict{Flagged: true, Source: "policy", Reason: rule}, nil
+	}
+	if mode != ModeModeration || f.moderator == nil {
+		return Verdict{}, nil
+	}
+
+	model := settings.Model
+	if model == "" {
+		model = f.model
+	}
+	result, err := f.moderator.Moderate(ctx, settings.Provider, model, text)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("moderation check failed: %w", err)
+	}
+	if !result.Flagged {
+		return Verdict{}, nil
+	}
+	reason := strings.Join(result.Categories, ", ")
+	if reason == "" {
+		reason = "flagged"
+	}
+	return Verdict{Flagged: true, Source: "moderation", Reason: reason}, nil
+}
+
+// Apply checks text under settings and returns what to send in its place:
+// the text itself, the fallback message, or "" when it is blocked. The
+// second result reports whether the text was filtered. Incidents are logged
+// with where the text was headed. A failed moderation call keeps the text,
+// so an unavailable endpoint doesn't silence the agent.
+func (f *Filter) Apply(ctx context.Context, settings Settings, agentID, channelID, text string) (string, bool) {
+	if f == nil || !settings.Enabled() {
+		return text, false
+	}
+
+	verdict, err := f.Check(ctx, settings, text)
+	if err != nil {
+		f.logger.Warn("Content filter check failed; keeping output",
+			zap.String("agent_id", agentID),
+			zap.Error(err),
+		)
+		return text, false
+	}
+	if !verdict.Flagged {
+		return text, false
+	}
+
+	action := settings.Action
+	if action == "" {
+		action = ActionReplace
+	}
+	f.logger.Warn("Agent output filtered",
+		zap.String("agent_id", agentID),
+		zap.String("channel_id", channelID),
+		zap.String("source", verdict.Source),
+		zap.String("reason", verdict.Reason),
+		zap.String("action", action),
+		zap.Int("length", len(text)),
+	)
+	if action == ActionBlock {
+		return "", true
+	}
+	return f.fallback, true
+}
@@ -0,0 +1,114 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ezra-clone/backend/internal/adapter"
+
+	"go.uber.org/zap"
+)
+
+// fakeModerator flags text in its flagged set, or fails with err. It
+// records the provider and model of the last call.
+type fakeModerator struct {
+	flagged  map[string][]string
+	err      error
+	calls    int
+	provider string
+	model    string
+}
+
+func (f *fakeModerator) Moderate(ctx context.Context, provider, model, text string) (*adapter.ModerationResult, error) {
+	f.calls++
+	f.provider, f.model = provider, model
+	if f.err != nil {
+		return nil, f.err
+	}
+	categories, ok := f.flagged[text]
+	return &adapter.ModerationResult{Flagged: ok, Categories: categories}, nil
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.txt")
+	rules := "# secrets\n\napi[_ ]key\n\\bhunter2\\b\n"
+	if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	if got := policy.Match("my API KEY is here"); got != "api[_ ]key" {
+		t.Errorf("Expected a case-insensitive match, got %q", got)
+	}
+	if got := policy.Match("hunter22"); got != "" {
+		t.Errorf("Expected the anchored word not to match, got %q", got)
+	}
+
+	if _, err := ParsePolicy([]string{"(unclosed"}); err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+}
+
+func TestFilter_Apply(t *testing.T) {
+	policy, _ := ParsePolicy([]string{"secret"})
+	moderator := &fakeModerator{flagged: map[string][]string{"rude": {"harassment"}}}
+	filter := NewFilter(policy, moderator, "", "", zap.NewNop())
+	ctx := context.Background()
+
+	if got, filtered := filter.Apply(ctx, Settings{}, "Ezra", "c1", "the secret"); filtered || got != "the secret" {
+		t.Errorf("Expected no filtering when off, got %q, %v", got, filtered)
+	}
+	if got, filtered := filter.Apply(ctx, Settings{Mode: ModeLocal}, "Ezra", "c1", "the secret"); !filtered || got != DefaultFallback {
+		t.Errorf("Expected the fallback, got %q, %v", got, filtered)
+	}
+	if got, filtered := filter.Apply(ctx, Settings{Mode: ModeLocal, Action: ActionBlock}, "Ezra", "c1", "the secret"); !filtered || got != "" {
+		t.Errorf("Expected the reply to be blocked, got %q, %v", got, filtered)
+	}
+	if _, filtered := filter.Apply(ctx, Settings{Mode: ModeLocal}, "Ezra", "c1", "rude"); filtered {
+		t.Error("Expected the local mode not to call the moderation model")
+	}
+	if moderator.calls != 0 {
+		t.Errorf("Expected no moderation calls, got %d", moderator.calls)
+	}
+
+	if _, filtered := filter.Apply(ctx, Settings{Mode: ModeModeration}, "Ezra", "c1", "rude"); !filtered {
+		t.Error("Expected the moderation model to flag the reply")
+	}
+	if moderator.provider != "" || moderator.model != adapter.DefaultModerationModel {
+		t.Errorf("Expected the default provider and model, got %q, %q", moderator.provider, moderator.model)
+	}
+	if _, filtered := filter.Apply(ctx, Settings{Mode: ModeModeration, Provider: "openai", Model: "text-moderation-stable"}, "Ezra", "c1", "rude"); !filtered {
+		t.Error("Expected the agent's moderation model to flag the reply")
+	}
+	if moderator.provider != "openai" || moderator.model != "text-moderation-stable" {
+		t.Errorf("Expected the agent's provider and model, got %q, %q", moderator.provider, moderator.model)
+	}
+	if _, filtered := filter.Apply(ctx, Settings{Mode: ModeModeration}, "Ezra", "c1", "fine"); filtered {
+		t.Error("Expected an unflagged reply to pass")
+	}
+
+	moderator.err = errors.New("unavailable")
+	if got, filtered := filter.Apply(ctx, Settings{Mode: ModeModeration}, "Ezra", "c1", "rude"); filtered || got != "rude" {
+		t.Errorf("Expected a failed moderation call to keep the reply, got %q, %v", got, filtered)
+	}
+}
+
+func TestSettings_Validate(t *testing.T) {
+	valid := []Settings{{}, {Mode: ModeLocal}, {Mode: ModeModeration, Action: ActionBlock}}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", s, err)
+		}
+	}
+	for _, s := range []Settings{{Mode: "strict"}, {Mode: ModeLocal, Action: "drop"}} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", s)
+		}
+	}
+}
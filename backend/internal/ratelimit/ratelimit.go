@@ -0,0 +1,145 @@
+// Package ratelimit limits how often each user can start an agent turn
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// SweepInterval is how often callers should sweep idle keys with Start
+const SweepInterval = time.Minute
+
+// Decision is the outcome of asking to spend a token
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration // How long until a token is available; 0 when allowed
+	// FirstDenial is set on the first denial since the user's last allowed
+	// request, so callers can send one cooldown notice instead of one per message
+	FirstDenial bool
+}
+
+// bucket is one user's tokens
+type bucket struct {
+	tokens float64
+	last   time.Time // When tokens was last refilled
+	denied bool      // Denied since the last allowed request
+}
+
+// Limiter is a per-key token bucket: each key may make burst requests at
+// once, refilled at perMinute tokens a minute. Exempt keys are never limited.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	rate    float64 // Tokens per second
+	burst   float64
+	exempt  map[string]bool
+	now     func() time.Time
+}
+
+// New creates a limiter allowing perMinute requests a minute per key, with
+// bursts of up to burst. It returns nil, which allows everything, when
+// perMinute isn't positive.
+func New(perMinute float64, burst int, exempt []string) *Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	exemptKeys := make(map[string]bool, len(exempt))
+	for _, key := range exempt {
+		exemptKeys[key] = true
+	}
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		rate:    perMinute / 60,
+		burst:   float64(burst),
+		exempt:  exemptKeys,
+		now:     time.Now,
+	}
+}
+
+// Allow spends one of key's tokens if it has one. A nil limiter allows
+// everything.
+func (l *Limiter) Allow(key string) Decision {
+	if l == nil || l.exempt[key] {
+		return Decision{Allowed: true}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	l.refill(b, now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.denied = false
+		return Decision{Allowed: true}
+	}
+	first := !b.denied
+	b.denied = true
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return Decision{RetryAfter: wait, FirstDenial: first}
+}
+
+// refill adds the tokens earned since b was last refilled. Callers must hold l.mu.
+func (l *Limiter) refill(b *bucket, now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+}
+
+// Sweep forgets keys whose buckets have refilled completely; they are
+// indistinguishable from keys never seen. Returns how many were removed.
+func (l *Limiter) Sweep() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	removed := 0
+	for key, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= l.burst {
+			delete(l.buckets, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Start sweeps idle keys every interval until the returned stop is called,
+// so the limiter doesn't grow with every user ever seen
+func (l *Limiter) Start(interval time.Duration) (stop func()) {
+	if l == nil || interval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				l.Sweep()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
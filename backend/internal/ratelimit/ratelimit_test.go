@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLimiter(perMinute float64, burst int, exempt ...string) (*Limiter, *time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := New(perMinute, burst, exempt)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestLimiter_BurstThenRefill(t *testing.T) {
+	limiter, now := newTestLimiter(6, 2) // A token every 10s
+
+	for i := 0; i < 2; i++ {
+		if !limiter.Allow("alice").Allowed {
+			t.Fatalf("Expected request %d of the burst to be allowed", i+1)
+		}
+	}
+
+	denied := limiter.Allow("alice")
+	if denied.Allowed || !denied.FirstDenial || denied.RetryAfter != 10*time.Second {
+		t.Errorf("Expected a first denial with a 10s wait, got %+v", denied)
+	}
+	if again := limiter.Allow("alice"); again.FirstDenial {
+		t.Error("Expected only the first denial to be flagged")
+	}
+	if !limiter.Allow("bob").Allowed {
+		t.Error("Expected other users to be unaffected")
+	}
+
+	*now = now.Add(10 * time.Second)
+	if !limiter.Allow("alice").Allowed {
+		t.Error("Expected a token after the refill interval")
+	}
+}
+
+func TestLimiter_ExemptAndDisabled(t *testing.T) {
+	limiter, _ := newTestLimiter(1, 1, "admin")
+	for i := 0; i < 5; i++ {
+		if !limiter.Allow("admin").Allowed {
+			t.Fatal("Expected exempt users never to be limited")
+		}
+	}
+
+	disabled := New(0, 5, nil)
+	if !disabled.Allow("anyone").Allowed {
+		t.Error("Expected a disabled limiter to allow everything")
+	}
+}
+
+func TestLimiter_SweepForgetsIdleUsers(t *testing.T) {
+	limiter, now := newTestLimiter(60, 3)
+	limiter.Allow("alice")
+	limiter.Allow("bob")
+	limiter.Allow("bob")
+
+	*now = now.Add(time.Second) // alice is full again, bob is not
+	if removed := limiter.Sweep(); removed != 1 {
+		t.Errorf("Expected 1 idle user swept, got %d", removed)
+	}
+	if _, ok := limiter.buckets["bob"]; !ok {
+		t.Error("Expected bob's partly spent bucket to be kept")
+	}
+}
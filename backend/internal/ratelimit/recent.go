@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// DuplicateWindow is how long RecentKeys remembers a key; retries and
+// redeliveries arrive well within it
+const DuplicateWindow = 10 * time.Minute
+
+// RecentKeys remembers the keys of recent requests, such as message IDs or
+// idempotency keys, so a retried or redelivered request can be told apart
+// from a new one and isn't charged against limits twice. Keys are forgotten
+// after the window; expired keys are swept as new ones are added.
+type RecentKeys struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time // When each key was added
+	window    time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRecentKeys creates a set remembering keys for window
+func NewRecentKeys(window time.Duration) *RecentKeys {
+	return &RecentKeys{
+		seen:   make(map[string]time.Time),
+		window: window,
+		now:    time.Now,
+	}
+}
+
+// Seen reports whether key was added within the window. The empty key is
+// never seen, and a nil set has seen nothing.
+func (r *RecentKeys) Seen(key string) bool {
+	if r == nil || key == "" {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	added, ok := r.seen[key]
+	return ok && r.now().Sub(added) < r.window
+}
+
+// Add records key and reports whether it is new, i.e. wasn't added within
+// the window. The empty key and a nil set always report new.
+func (r *RecentKeys) Add(key string) bool {
+	if r == nil || key == "" {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if now.Sub(r.lastSweep) >= r.window {
+		for k, added := range r.seen {
+			if now.Sub(added) >= r.window {
+				delete(r.seen, k)
+			}
+		}
+		r.lastSweep = now
+	}
+	if added, ok := r.seen[key]; ok && now.Sub(added) < r.window {
+		return false
+	}
+	r.seen[key] = now
+	return true
+}
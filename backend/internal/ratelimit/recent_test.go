@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecentKeys_RemembersForTheWindow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	recent := NewRecentKeys(time.Minute)
+	recent.now = func() time.Time { return now }
+
+	if recent.Seen("msg-1") {
+		t.Error("Expected an unknown key not to be seen")
+	}
+	if !recent.Add("msg-1") {
+		t.Error("Expected the first add to be new")
+	}
+	if !recent.Seen("msg-1") || recent.Add("msg-1") {
+		t.Error("Expected a repeated key to be seen")
+	}
+	if !recent.Add("") || !recent.Add("") || recent.Seen("") {
+		t.Error("Expected the empty key never to be remembered")
+	}
+
+	now = now.Add(time.Minute)
+	if recent.Seen("msg-1") {
+		t.Error("Expected the key to be forgotten after the window")
+	}
+	if !recent.Add("msg-2") {
+		t.Error("Expected a new key to be new")
+	}
+	if len(recent.seen) != 1 {
+		t.Errorf("Expected expired keys to be swept, got %d keys", len(recent.seen))
+	}
+
+	var none *RecentKeys
+	if none.Seen("msg-1") || !none.Add("msg-1") {
+		t.Error("Expected a nil set to remember nothing")
+	}
+}
@@ -23,13 +23,15 @@ type AgentIdentity struct {
 
 // MemoryBlock represents a single editable memory/instruction block
 type MemoryBlock struct {
-	Name      string    `json:"name"`      // Block identifier (e.g., "coding_style", "identity")
-	Content   string    `json:"content"`   // The rule/instruction text
+	Name      string    `json:"name"`            // Block identifier, unique per agent (e.g., "coding_style", "identity")
+	Content   string    `json:"content"`         // The rule/instruction text
+	Order     *int      `json:"order,omitempty"` // Position in the prompt; blocks without one follow, by name
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // ArchivalPointer represents a reference to archived conversation history
 type ArchivalPointer struct {
+	ID             string    `json:"id"`
 	Summary        string    `json:"summary"`
 	Timestamp      time.Time `json:"timestamp"`
 	RelevanceScore float64   `json:"relevance_score"`
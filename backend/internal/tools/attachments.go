@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// imageExtensions are file extensions treated as images when an attachment
+// has no content type
+var imageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".bmp": true,
+}
+
+// Attachment is a file shared alongside a chat message
+type Attachment struct {
+	URL         string `json:"url"`
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// IsImage reports whether the attachment is an image, by content type or
+// else by file extension
+func (a Attachment) IsImage() bool {
+	if a.ContentType != "" {
+		return strings.HasPrefix(strings.ToLower(a.ContentType), "image/")
+	}
+	name := a.Filename
+	if name == "" {
+		if u, err := url.Parse(a.URL); err == nil {
+			name = u.Path
+		}
+	}
+	return imageExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// ImageURLs returns the URLs of the image attachments
+func ImageURLs(attachments []Attachment) []string {
+	var urls []string
+	for _, attachment := range attachments {
+		if attachment.IsImage() {
+			urls = append(urls, attachment.URL)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// capabilityTools maps the capability names stored on an agent's identity to
+// the tools that provide them
+var capabilityTools = map[string][]string{
+	"chat": {ToolSendMessage, ToolGetHistory, ToolResetConversation, ToolSummarizeConversation},
+	"memory_management": {
+		ToolCoreMemoryInsert, ToolCoreMemoryReplace,
+		ToolArchivalInsert, ToolArchivalSearch, ToolMemorySearch,
+	},
+	"fact_tracking": {
+		ToolCreateFact, ToolSearchFacts, ToolLinkToUser, ToolGetUserContext, ToolPinFact,
+		ToolGetFact, ToolUpdateFact, ToolDeleteFact,
+	},
+	"topic_organization": {ToolCreateTopic, ToolLinkTopics, ToolFindRelated, ToolLinkUserTopic, ToolCompareUsers},
+	"web_search":         {ToolWebSearch, ToolFetchWebpage, ToolSummarizeWebsite},
+	"github_integration": {ToolGitHubRepoInfo, ToolGitHubSearch, ToolGitHubReadFile, ToolGitHubListOrgRepos},
+	"weather":            {ToolGetWeather},
+	"discord": {
+		ToolDiscordReadHistory, ToolDiscordGetUserInfo, ToolDiscordSearchMessages,
+		ToolDiscordGetChannelInfo, ToolInterestPoll,
+	},
+	"scheduling":       {ToolScheduleMessage, ToolListScheduledMessages, ToolCancelScheduledMessage},
+	"personality":      {ToolMimicPersonality, ToolRevertPersonality, ToolAnalyzeUserStyle},
+	"image_generation": {ToolGenerateImageWithRunPod, ToolEnhancePrompt, ToolSelectWorkflow, ToolListWorkflows},
+	"music": {
+		ToolMusicPlay, ToolMusicPlaylist, ToolMusicQueue, ToolMusicNowPlaying, ToolMusicSkip, ToolMusicSeek,
+		ToolMusicPause, ToolMusicResume, ToolMusicStop, ToolMusicVolume, ToolMusicRadio, ToolMusicDisconnect,
+	},
+	"voice": {ToolVoiceListReferences, ToolVoiceDeleteReference},
+}
+
+// normalizeCapability lowercases a capability and joins words with
+// underscores, so "Web Search" and "web-search" match "web_search"
+func normalizeCapability(capability string) string {
+	capability = strings.ToLower(strings.TrimSpace(capability))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(capability)
+}
+
+// CapabilityTools returns the available tools that provide a capability. A
+// capability that names a tool directly maps to that tool.
+func CapabilityTools(capability string) []string {
+	available := make(map[string]bool)
+	for _, tool := range GetAllTools() {
+		available[tool.Function.Name] = true
+	}
+
+	name := normalizeCapability(capability)
+	candidates, ok := capabilityTools[name]
+	if !ok {
+		candidates = []string{name}
+	}
+
+	toolNames := make([]string, 0, len(candidates))
+	for _, toolName := range candidates {
+		if available[toolName] {
+			toolNames = append(toolNames, toolName)
+		}
+	}
+	return toolNames
+}
+
+// CapabilitiesPromptSection renders an agent's capabilities and the tools
+// behind each for the system prompt. Returns "" when there are none.
+func CapabilitiesPromptSection(capabilities []string) string {
+	lines := make([]string, 0, len(capabilities))
+	seen := make(map[string]bool)
+	for _, capability := range capabilities {
+		name := normalizeCapability(capability)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		toolNames := CapabilityTools(name)
+		if len(toolNames) == 0 {
+			lines = append(lines, fmt.Sprintf("- **%s**", name))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- **%s**: %s", name, strings.Join(toolNames, ", ")))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(`
+## Your Enabled Capabilities
+
+These are the capabilities you are configured with and the tools that provide them. When asked what you can do, describe yourself using this list:
+%s
+`, strings.Join(lines, "\n"))
+}
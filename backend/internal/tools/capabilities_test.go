@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCapabilitiesPromptSection_ListsCapabilityTools(t *testing.T) {
+	section := CapabilitiesPromptSection([]string{"chat", "Web Search", "web_search", "telepathy"})
+
+	if !strings.Contains(section, "## Your Enabled Capabilities") {
+		t.Fatalf("Expected a capabilities heading, got %q", section)
+	}
+
+	var webLine string
+	for _, line := range strings.Split(section, "\n") {
+		if strings.HasPrefix(line, "- **web_search**") {
+			if webLine != "" {
+				t.Errorf("Expected web_search to be listed once")
+			}
+			webLine = line
+		}
+	}
+	if webLine == "" {
+		t.Fatalf("Expected web_search in the capabilities section, got %q", section)
+	}
+	for _, tool := range []string{ToolWebSearch, ToolFetchWebpage, ToolSummarizeWebsite} {
+		if !strings.Contains(webLine, tool) {
+			t.Errorf("Expected web_search to map to %s, got %q", tool, webLine)
+		}
+	}
+
+	// Unknown capabilities are still surfaced, just without tools
+	if !strings.Contains(section, "- **telepathy**\n") {
+		t.Errorf("Expected telepathy listed without tools, got %q", section)
+	}
+}
+
+func TestCapabilitiesPromptSection_Empty(t *testing.T) {
+	if section := CapabilitiesPromptSection(nil); section != "" {
+		t.Errorf("Expected no section without capabilities, got %q", section)
+	}
+}
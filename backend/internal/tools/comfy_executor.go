@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math/rand"
+	"strings"
 	"time"
 
 	"ezra-clone/backend/internal/adapter"
@@ -19,6 +19,7 @@ type ComfyExecutor struct {
 	promptEnhancer *PromptEnhancer
 	llmAdapter     *adapter.LLMAdapter
 	config         *config.Config
+	imageCache     *ImageCache // Keeps generated images; nil when COMFYUI_OUTPUT_DIR is unset
 	logger         *zap.Logger
 }
 
@@ -29,15 +30,35 @@ func NewComfyExecutor(llmAdapter *adapter.LLMAdapter, cfg *config.Config) *Comfy
 		runpodClient = NewRunPodClient(cfg.RunPodAPIKey, cfg.RunPodEndpointID)
 	}
 
+	var imageCache *ImageCache
+	if cfg.ComfyUIOutputDir != "" {
+		imageCache = NewImageCache(cfg.ComfyUIOutputDir, ImageRetention{
+			MaxCount:      cfg.ImageCacheMaxCount,
+			MaxAge:        cfg.ImageCacheMaxAge,
+			MaxTotalBytes: cfg.ImageCacheMaxBytes,
+			MaxImageBytes: cfg.ImageMaxBytes,
+		})
+	}
+
 	return &ComfyExecutor{
 		runpodClient:   runpodClient,
 		promptEnhancer: NewPromptEnhancer(llmAdapter),
 		llmAdapter:     llmAdapter,
 		config:         cfg,
+		imageCache:     imageCache,
 		logger:         logger.Get(),
 	}
 }
 
+// StartImageSweeper periodically removes expired generated images. The
+// returned function stops the sweeper; it is a no-op when images aren't stored.
+func (c *ComfyExecutor) StartImageSweeper() func() {
+	if c.imageCache == nil {
+		return func() {}
+	}
+	return c.imageCache.StartSweeper()
+}
+
 // executeEnhancePrompt enhances a user prompt using Z-Image Turbo methodology
 func (e *Executor) executeEnhancePrompt(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
 	userRequest, _ := args["user_request"].(string)
@@ -139,10 +160,22 @@ func (e *Executor) executeGenerateImageWithRunPod(ctx context.Context, execCtx *
 		}
 	}
 
+	negativePrompt, _ := args["negative_prompt"].(string)
+	stylePreset, _ := args["style_preset"].(string)
+	prompts, err := ResolveImagePrompts(prompt, negativePrompt, stylePreset, e.defaultStylePreset(ctx, execCtx))
+	if err != nil {
+		return &ToolResult{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
 	workflowName, _ := args["workflow_name"].(string)
+	if workflowName == "<nil>" {
+		workflowName = ""
+	}
 	width := 1280
 	height := 1440
-	seed := (*int)(nil)
 
 	if w, ok := args["width"].(float64); ok {
 		width = int(w)
@@ -150,133 +183,245 @@ func (e *Executor) executeGenerateImageWithRunPod(ctx context.Context, execCtx *
 	if h, ok := args["height"].(float64); ok {
 		height = int(h)
 	}
-	if s, ok := args["seed"].(float64); ok {
-		seedVal := int(s)
-		seed = &seedVal
+	seed, err := resolveImageSeed(args["seed"])
+	if err != nil {
+		return &ToolResult{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+	batch, err := resolveImageBatch(args["batch"], e.comfyExecutor.config.ImageMaxBatch)
+	if err != nil {
+		return &ToolResult{
+			Success: false,
+			Error:   err.Error(),
+		}
 	}
 
 	e.logger.Info("Starting image generation",
 		zap.String("workflow", workflowName),
+		zap.String("style_preset", prompts.Style),
 		zap.Int("width", width),
 		zap.Int("height", height),
+		zap.Int("seed", seed),
+		zap.Int("batch", batch),
 	)
 
 	startTime := time.Now()
 
-	// Load or create workflow
-	var workflowPayload map[string]interface{}
-	if workflowName == "" || workflowName == "<nil>" {
-		// Use programmatic Z-Image Turbo workflow
-		e.logger.Debug("Using programmatic Z-Image Turbo workflow")
-		workflowPayload = CreateZImageTurboWorkflow(prompt, seed, width, height, 4, 1.0)
-	} else {
-		// Load workflow from file
-		workflow, err := LoadWorkflow(e.comfyExecutor.config.ComfyUIWorkflowDir, workflowName)
+	// Submit every variation before polling, so RunPod can run them in parallel
+	jobs := make([]imageJob, 0, batch)
+	for i := 0; i < batch; i++ {
+		jobSeed := variationSeed(seed, i)
+		workflowPayload, err := e.buildImageWorkflow(workflowName, prompts, jobSeed, width, height)
 		if err != nil {
+			e.cancelImageJobs(ctx, jobs)
 			return &ToolResult{
 				Success: false,
-				Error:   fmt.Sprintf("Failed to load workflow: %v", err),
+				Error:   err.Error(),
 			}
 		}
 
-		prepared, err := PrepareWorkflowForAPI(workflow, prompt, seed, width, height)
+		// Log workflow payload for debugging (first 500 chars)
+		workflowJSON, _ := json.Marshal(workflowPayload)
+		workflowStr := string(workflowJSON)
+		if len(workflowStr) > 500 {
+			workflowStr = workflowStr[:500] + "..."
+		}
+		e.logger.Debug("Submitting workflow to RunPod",
+			zap.String("workflow_preview", workflowStr),
+		)
+
+		// Submit job to RunPod
+		jobID, err := e.comfyExecutor.runpodClient.SubmitJob(ctx, workflowPayload)
 		if err != nil {
+			e.logger.Error("Failed to submit job to RunPod",
+				zap.Error(err),
+				zap.String("endpoint_id", e.comfyExecutor.config.RunPodEndpointID),
+			)
+			e.cancelImageJobs(ctx, jobs)
 			return &ToolResult{
 				Success: false,
-				Error:   fmt.Sprintf("Failed to prepare workflow: %v", err),
+				Error:   fmt.Sprintf("Failed to submit job to RunPod: %v. Please verify your RUNPOD_ENDPOINT_ID is correct and the endpoint exists.", err),
 			}
 		}
-		workflowPayload = prepared
-	}
 
-	// Log workflow payload for debugging (first 500 chars)
-	workflowJSON, _ := json.Marshal(workflowPayload)
-	workflowStr := string(workflowJSON)
-	if len(workflowStr) > 500 {
-		workflowStr = workflowStr[:500] + "..."
+		e.logger.Info("Job submitted", zap.String("job_id", jobID), zap.Int("seed", jobSeed))
+		jobs = append(jobs, imageJob{seed: jobSeed, jobID: jobID})
 	}
-	e.logger.Debug("Submitting workflow to RunPod",
-		zap.String("workflow_preview", workflowStr),
-	)
 
-	// Submit job to RunPod
-	jobID, err := e.comfyExecutor.runpodClient.SubmitJob(ctx, workflowPayload)
-	if err != nil {
-		e.logger.Error("Failed to submit job to RunPod",
-			zap.Error(err),
-			zap.String("endpoint_id", e.comfyExecutor.config.RunPodEndpointID),
-		)
-		return &ToolResult{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to submit job to RunPod: %v. Please verify your RUNPOD_ENDPOINT_ID is correct and the endpoint exists.", err),
+	// Collect the images; a batch keeps the variations that succeeded
+	var images []map[string]interface{}
+	var firstErr string
+	for i, job := range jobs {
+		image, errMsg := e.collectImage(ctx, job)
+		if errMsg != "" {
+			e.logger.Warn("Image job failed",
+				zap.String("job_id", job.jobID),
+				zap.String("error", errMsg),
+			)
+			if firstErr == "" {
+				firstErr = errMsg
+			}
+			// Once the call is cancelled or times out, cancel the jobs still
+			// running rather than leave them to finish unseen
+			if ctx.Err() != nil {
+				e.cancelImageJobs(ctx, jobs[i:])
+				break
+			}
+			continue
 		}
+		images = append(images, image)
 	}
-
-	e.logger.Info("Job submitted", zap.String("job_id", jobID))
-
-	// Poll for completion
-	status, err := e.comfyExecutor.runpodClient.PollStatus(ctx, jobID, 120, 5*time.Second)
-	if err != nil {
+	if len(images) == 0 {
 		return &ToolResult{
 			Success: false,
-			Error:   fmt.Sprintf("Job failed or timed out: %v", err),
+			Error:   firstErr,
 			Data: map[string]interface{}{
-				"job_id": jobID,
+				"job_id": jobs[0].jobID,
 			},
 		}
 	}
 
-	if status.Status != "COMPLETED" {
-		return &ToolResult{
-			Success: false,
-			Error:   fmt.Sprintf("Job status: %s, error: %s", status.Status, status.Error),
-			Data: map[string]interface{}{
-				"job_id": jobID,
-			},
+	elapsed := time.Since(startTime).Seconds()
+
+	e.logger.Info("Image generated successfully",
+		zap.Int("images", len(images)),
+		zap.Float64("elapsed_seconds", elapsed),
+	)
+
+	// Return image data in result for Discord attachment; the first image is
+	// at the top level and further variations under "variations"
+	data := map[string]interface{}{
+		"image_format":    "png",
+		"width":           width,
+		"height":          height,
+		"workflow":        workflowName,
+		"elapsed_seconds": elapsed,
+		"style_preset":    prompts.Style,
+		"positive_prompt": prompts.Positive,
+		"negative_prompt": prompts.Negative,
+		"batch":           len(images),
+	}
+	for key, value := range images[0] {
+		data[key] = value
+	}
+	message := fmt.Sprintf("Image generated successfully in %.1fs (seed %d)", elapsed, images[0]["seed"])
+	if len(images) > 1 {
+		data["variations"] = images[1:]
+		seeds := make([]string, len(images))
+		for i, image := range images {
+			seeds[i] = fmt.Sprint(image["seed"])
 		}
+		message = fmt.Sprintf("Generated %d images in %.1fs (seeds %s)", len(images), elapsed, strings.Join(seeds, ", "))
+	}
+	if len(images) < batch {
+		message += fmt.Sprintf("; %d of %d variations failed", batch-len(images), batch)
 	}
 
-	// Extract image data
-	imageBytes, err := e.comfyExecutor.runpodClient.GetJobOutput(status)
-	if err != nil {
-		return &ToolResult{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to extract image: %v", err),
-			Data: map[string]interface{}{
-				"job_id": jobID,
-			},
+	return &ToolResult{
+		Success: true,
+		Data:    data,
+		Message: message,
+	}
+}
+
+// imageJob is a submitted RunPod generation
+type imageJob struct {
+	seed  int
+	jobID string
+}
+
+// cancelImageJobs cancels the jobs already submitted for a batch that failed
+// to submit in full, so they don't keep running unseen. Cancelling goes ahead
+// even when ctx was cancelled.
+func (e *Executor) cancelImageJobs(ctx context.Context, jobs []imageJob) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	for _, job := range jobs {
+		if err := e.comfyExecutor.runpodClient.CancelJob(ctx, job.jobID); err != nil {
+			e.logger.Warn("Failed to cancel image job",
+				zap.String("job_id", job.jobID),
+				zap.Error(err),
+			)
 		}
 	}
+}
 
-	if seed == nil {
-		rand.Seed(time.Now().UnixNano())
-		s := rand.Intn(1 << 32)
-		seed = &s
+// buildImageWorkflow loads the named workflow, or builds the programmatic
+// Z-Image Turbo one, and fills in the prompts, seed and size. Workflows are
+// loaded fresh for each call since preparing one modifies it.
+func (e *Executor) buildImageWorkflow(workflowName string, prompts ImagePrompts, seed, width, height int) (map[string]interface{}, error) {
+	if workflowName == "" {
+		// Use programmatic Z-Image Turbo workflow
+		e.logger.Debug("Using programmatic Z-Image Turbo workflow")
+		return CreateZImageTurboWorkflow(prompts, &seed, width, height), nil
 	}
 
-	elapsed := time.Since(startTime).Seconds()
+	// Load workflow from file
+	workflow, err := LoadWorkflow(e.comfyExecutor.config.ComfyUIWorkflowDir, workflowName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load workflow: %v", err)
+	}
+	prepared, err := PrepareWorkflowForAPI(workflow, prompts, &seed, width, height)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to prepare workflow: %v", err)
+	}
+	return prepared, nil
+}
 
-	e.logger.Info("Image generated successfully",
-		zap.Int("image_size_bytes", len(imageBytes)),
-		zap.Float64("elapsed_seconds", elapsed),
-	)
+// collectImage waits for a job and returns its image data, seed, job ID and
+// cached path, or an error message
+func (e *Executor) collectImage(ctx context.Context, job imageJob) (map[string]interface{}, string) {
+	// Poll for completion
+	status, err := e.comfyExecutor.runpodClient.PollStatus(ctx, job.jobID, 120, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Sprintf("Job failed or timed out: %v", err)
+	}
+	if status.Status != "COMPLETED" {
+		return nil, fmt.Sprintf("Job status: %s, error: %s", status.Status, status.Error)
+	}
 
-	// Return image data in result for Discord attachment
-	return &ToolResult{
-		Success: true,
-		Data: map[string]interface{}{
-			"image_data":     imageBytes, // Image bytes for Discord attachment
-			"image_format":   "png",
-			"seed":           *seed,
-			"width":          width,
-			"height":         height,
-			"workflow":       workflowName,
-			"job_id":         jobID,
-			"elapsed_seconds": elapsed,
-		},
-		Message: fmt.Sprintf("Image generated successfully in %.1fs", elapsed),
+	// Extract image data
+	imageBytes, err := e.comfyExecutor.runpodClient.GetJobOutput(status)
+	if err != nil {
+		return nil, fmt.Sprintf("Failed to extract image: %v", err)
 	}
-}
 
+	image := map[string]interface{}{
+		"image_data": imageBytes, // Image bytes for Discord attachment
+		"seed":       job.seed,
+		"job_id":     job.jobID,
+	}
 
+	// Keep a copy for re-sending or regeneration; failing to cache isn't fatal
+	if cache := e.comfyExecutor.imageCache; cache != nil {
+		path, err := cache.Store(job.jobID+".png", imageBytes)
+		if err != nil {
+			e.logger.Warn("Failed to cache generated image",
+				zap.String("job_id", job.jobID),
+				zap.Error(err),
+			)
+		} else {
+			image["image_path"] = path
+		}
+	}
+	return image, ""
+}
 
+// defaultStylePreset returns the agent's configured image style preset, or ""
+// when it has none or the config can't be loaded
+func (e *Executor) defaultStylePreset(ctx context.Context, execCtx *ExecutionContext) string {
+	if e.repo == nil || execCtx == nil || execCtx.AgentID == "" {
+		return ""
+	}
+	agentConfig, err := e.repo.GetAgentConfig(ctx, execCtx.AgentID)
+	if err != nil {
+		e.logger.Debug("Failed to load agent config for image style preset",
+			zap.String("agent_id", execCtx.AgentID),
+			zap.Error(err),
+		)
+		return ""
+	}
+	return agentConfig.ImageStylePreset
+}
@@ -55,8 +55,9 @@ func LoadWorkflow(workflowDir, name string) (map[string]interface{}, error) {
 }
 
 // CreateZImageTurboWorkflow creates a programmatic Z-Image Turbo workflow
-// Based on the reference implementation from img_zurbo.ipynb
-func CreateZImageTurboWorkflow(prompt string, seed *int, width, height, steps int, cfg float64) map[string]interface{} {
+// Based on the reference implementation from img_zurbo.ipynb. Without a
+// negative prompt the negative conditioning is the zeroed-out positive one.
+func CreateZImageTurboWorkflow(prompts ImagePrompts, seed *int, width, height int) map[string]interface{} {
 	if seed == nil {
 		rand.Seed(time.Now().UnixNano())
 		s := rand.Intn(1 << 32)
@@ -97,13 +98,7 @@ func CreateZImageTurboWorkflow(prompt string, seed *int, width, height, steps in
 			"class_type": "EmptySD3LatentImage",
 			"_meta":      map[string]interface{}{"title": "EmptySD3LatentImage"},
 		},
-		"42": map[string]interface{}{
-			"inputs": map[string]interface{}{
-				"conditioning": []interface{}{"45", 0},
-			},
-			"class_type": "ConditioningZeroOut",
-			"_meta":      map[string]interface{}{"title": "ConditioningZeroOut"},
-		},
+		"42": negativeConditioningNode(prompts.Negative),
 		"43": map[string]interface{}{
 			"inputs": map[string]interface{}{
 				"samples": []interface{}{"44", 0},
@@ -115,10 +110,10 @@ func CreateZImageTurboWorkflow(prompt string, seed *int, width, height, steps in
 		"44": map[string]interface{}{
 			"inputs": map[string]interface{}{
 				"seed":         *seed,
-				"steps":        steps,
-				"cfg":          cfg,
-				"sampler_name": "res_multistep",
-				"scheduler":    "simple",
+				"steps":        prompts.Sampler.Steps,
+				"cfg":          prompts.Sampler.CFG,
+				"sampler_name": prompts.Sampler.Sampler,
+				"scheduler":    prompts.Sampler.Scheduler,
 				"denoise":      1,
 				"model":        []interface{}{"48", 0},
 				"positive":     []interface{}{"45", 0},
@@ -130,7 +125,7 @@ func CreateZImageTurboWorkflow(prompt string, seed *int, width, height, steps in
 		},
 		"45": map[string]interface{}{
 			"inputs": map[string]interface{}{
-				"text": prompt,
+				"text": prompts.Positive,
 				"clip": []interface{}{"39", 0},
 			},
 			"class_type": "CLIPTextEncode",
@@ -151,9 +146,34 @@ func CreateZImageTurboWorkflow(prompt string, seed *int, width, height, steps in
 	}
 }
 
+// negativeConditioningNode builds node 42 of the Z-Image Turbo workflow: the
+// encoded negative prompt, or the zeroed-out positive prompt when there is none
+func negativeConditioningNode(negative string) map[string]interface{} {
+	if negative == "" {
+		return map[string]interface{}{
+			"inputs": map[string]interface{}{
+				"conditioning": []interface{}{"45", 0},
+			},
+			"class_type": "ConditioningZeroOut",
+			"_meta":      map[string]interface{}{"title": "ConditioningZeroOut"},
+		}
+	}
+	return map[string]interface{}{
+		"inputs": map[string]interface{}{
+			"text": negative,
+			"clip": []interface{}{"39", 0},
+		},
+		"class_type": "CLIPTextEncode",
+		"_meta":      map[string]interface{}{"title": "CLIP Text Encode (Negative Prompt)"},
+	}
+}
+
 // PrepareWorkflowForAPI prepares a workflow for RunPod API submission
-// Handles both API-format (dict) and UI-format (list) workflows
-func PrepareWorkflowForAPI(workflow map[string]interface{}, prompt string, seed *int, width, height int) (map[string]interface{}, error) {
+// Handles both API-format (dict) and UI-format (list) workflows. Text encoders
+// feeding a sampler's negative input get the negative prompt, and keep the
+// workflow's own text when there is none. Sampler settings are only
+// overridden when a style preset is applied.
+func PrepareWorkflowForAPI(workflow map[string]interface{}, prompts ImagePrompts, seed *int, width, height int) (map[string]interface{}, error) {
 	if seed == nil {
 		rand.Seed(time.Now().UnixNano())
 		s := rand.Intn(1 << 32)
@@ -166,6 +186,7 @@ func PrepareWorkflowForAPI(workflow map[string]interface{}, prompt string, seed
 	if nodes, ok := workflow["nodes"].([]interface{}); ok {
 		// Convert UI format to API format
 		workflowNodes = make(map[string]interface{})
+		linkOrigins := uiLinkOrigins(workflow)
 		for _, nodeRaw := range nodes {
 			node, ok := nodeRaw.(map[string]interface{})
 			if !ok {
@@ -190,7 +211,13 @@ func PrepareWorkflowForAPI(workflow map[string]interface{}, prompt string, seed
 					}
 					name, _ := inp["name"].(string)
 					link, ok := inp["link"].(float64)
-					if name != "" && ok {
+					if name == "" || !ok {
+						continue
+					}
+					// Inputs reference the node the link comes from
+					if origin, found := linkOrigins[int(link)]; found {
+						inputs[name] = origin
+					} else {
 						inputs[name] = []interface{}{fmt.Sprintf("%.0f", link), 0}
 					}
 				}
@@ -252,8 +279,10 @@ func PrepareWorkflowForAPI(workflow map[string]interface{}, prompt string, seed
 		workflowNodes = workflow
 	}
 
+	negativeNodes := negativeEncoderNodes(workflowNodes)
+
 	// Apply parameter overrides
-	for _, nodeDataRaw := range workflowNodes {
+	for nodeID, nodeDataRaw := range workflowNodes {
 		nodeData, ok := nodeDataRaw.(map[string]interface{})
 		if !ok {
 			continue
@@ -268,11 +297,21 @@ func PrepareWorkflowForAPI(workflow map[string]interface{}, prompt string, seed
 
 		switch nodeType {
 		case "CLIPTextEncode":
-			inputs["text"] = prompt
+			if !negativeNodes[nodeID] {
+				inputs["text"] = prompts.Positive
+			} else if prompts.Negative != "" {
+				inputs["text"] = prompts.Negative
+			}
 		case "RandomNoise":
 			inputs["noise_seed"] = *seed
 		case "KSampler":
 			inputs["seed"] = *seed
+			if prompts.Style != "" {
+				inputs["steps"] = prompts.Sampler.Steps
+				inputs["cfg"] = prompts.Sampler.CFG
+				inputs["sampler_name"] = prompts.Sampler.Sampler
+				inputs["scheduler"] = prompts.Sampler.Scheduler
+			}
 		case "EmptyLatentImage", "EmptySD3LatentImage":
 			inputs["width"] = width
 			inputs["height"] = height
@@ -284,6 +323,46 @@ func PrepareWorkflowForAPI(workflow map[string]interface{}, prompt string, seed
 	}, nil
 }
 
+// uiLinkOrigins maps the link IDs of a UI-format workflow to the node and
+// output slot each link starts from
+func uiLinkOrigins(workflow map[string]interface{}) map[int][]interface{} {
+	origins := make(map[int][]interface{})
+	links, _ := workflow["links"].([]interface{})
+	for _, linkRaw := range links {
+		// [link_id, origin_id, origin_slot, target_id, target_slot, type]
+		link, ok := linkRaw.([]interface{})
+		if !ok || len(link) < 3 {
+			continue
+		}
+		linkID, ok1 := link[0].(float64)
+		originID, ok2 := link[1].(float64)
+		originSlot, ok3 := link[2].(float64)
+		if ok1 && ok2 && ok3 {
+			origins[int(linkID)] = []interface{}{fmt.Sprintf("%.0f", originID), int(originSlot)}
+		}
+	}
+	return origins
+}
+
+// negativeEncoderNodes returns the IDs of nodes feeding a sampler's or
+// guider's negative input
+func negativeEncoderNodes(workflowNodes map[string]interface{}) map[string]bool {
+	negative := make(map[string]bool)
+	for _, nodeDataRaw := range workflowNodes {
+		nodeData, ok := nodeDataRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		inputs, _ := nodeData["inputs"].(map[string]interface{})
+		if ref, ok := inputs["negative"].([]interface{}); ok && len(ref) > 0 {
+			if id, ok := ref[0].(string); ok {
+				negative[id] = true
+			}
+		}
+	}
+	return negative
+}
+
 // GetModifiableNodes extracts key nodes that are commonly modified in workflows
 func GetModifiableNodes(workflow map[string]interface{}) map[string]map[string]interface{} {
 	modifiable := make(map[string]map[string]interface{})
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"fmt"
 )
 
 // ============================================================================
@@ -40,3 +41,41 @@ func (e *Executor) executeSendMessage(ctx context.Context, execCtx *ExecutionCon
 	}
 }
 
+func (e *Executor) executeResetConversation(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	if execCtx.ChannelID == "" {
+		return &ToolResult{Success: false, Error: "no active conversation to reset"}
+	}
+	// Anyone can talk in a server channel, but only moderators may wipe it
+	if execCtx.Platform == "discord" {
+		if e.discordExecutor == nil {
+			return &ToolResult{Success: false, Error: "Can't check your permissions to reset this conversation"}
+		}
+		allowed, err := e.discordExecutor.CanManageMessages(execCtx.ChannelID, execCtx.UserID)
+		if err != nil {
+			return &ToolResult{Success: false, Error: fmt.Sprintf("Can't check your permissions to reset this conversation: %v", err)}
+		}
+		if !allowed {
+			return &ToolResult{Success: false, Error: "Only members who can manage messages in this channel may reset the conversation"}
+		}
+	}
+
+	archive := true
+	if a, ok := args["archive"].(bool); ok {
+		archive = a
+	}
+
+	reset, err := e.repo.ResetConversation(ctx, execCtx.AgentID, execCtx.ChannelID, archive)
+	if err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+
+	message := fmt.Sprintf("Cleared %d messages from this conversation", reset.MessagesCleared)
+	if reset.ArchiveID != "" {
+		message += " (saved to archival memory)"
+	}
+	return &ToolResult{
+		Success: true,
+		Data:    reset,
+		Message: message,
+	}
+}
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/graph"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSummaryMessages = 100
+	maxSummaryMessages     = 500
+
+	// maxConversationChunkChars is how much transcript one LLM call
+	// summarizes, about 3000 tokens
+	maxConversationChunkChars = 12000
+
+	// maxSummaryReduceRounds bounds how many times partial summaries are
+	// summarized again before the rest is cut to fit the final call
+	maxSummaryReduceRounds = 3
+)
+
+// executeSummarizeConversation summarizes the current channel's latest
+// messages, optionally within a time range, and archives the summary if asked
+func (e *Executor) executeSummarizeConversation(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	if execCtx.ChannelID == "" {
+		return &ToolResult{Success: false, Error: "no current channel to summarize"}
+	}
+	if e.llmAdapter == nil {
+		return &ToolResult{Success: false, Error: "LLM adapter not configured. Cannot generate summary."}
+	}
+
+	limit := defaultSummaryMessages
+	if l, ok := args["limit"].(float64); ok && l >= 1 {
+		limit = int(l)
+	}
+	if limit > maxSummaryMessages {
+		limit = maxSummaryMessages
+	}
+
+	now := time.Now()
+	sinceArg, _ := args["since"].(string)
+	since, err := parseTimeArg(sinceArg, now)
+	if err != nil {
+		return &ToolResult{Success: false, Error: fmt.Sprintf("invalid since: %v", err)}
+	}
+	untilArg, _ := args["until"].(string)
+	until, err := parseTimeArg(untilArg, now)
+	if err != nil {
+		return &ToolResult{Success: false, Error: fmt.Sprintf("invalid until: %v", err)}
+	}
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		return &ToolResult{Success: false, Error: "until must be after since"}
+	}
+
+	messages, err := e.repo.GetConversationRange(ctx, execCtx.ChannelID, since, until, limit)
+	if err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+	if len(messages) == 0 {
+		return &ToolResult{Success: true, Message: "No messages to summarize in that range."}
+	}
+
+	summary, err := e.summarizeConversation(ctx, summaryTranscript(messages))
+	if err != nil {
+		return &ToolResult{Success: false, Error: fmt.Sprintf("Failed to generate summary: %v", err)}
+	}
+
+	first, last := messages[0].Timestamp, messages[len(messages)-1].Timestamp
+	data := map[string]interface{}{
+		"channel_id": execCtx.ChannelID,
+		"messages":   len(messages),
+		"from":       first,
+		"to":         last,
+		"summary":    summary,
+	}
+	message := fmt.Sprintf("Summarized %d messages", len(messages))
+
+	if archive, _ := args["archive"].(bool); archive {
+		archiveID, _, err := e.repo.CreateArchivalMemory(ctx, execCtx.AgentID, graph.ArchivalMemory{
+			Summary:   fmt.Sprintf("Summary of channel %s from %s to %s", execCtx.ChannelID, first.Format(time.RFC3339), last.Format(time.RFC3339)),
+			Content:   summary,
+			Timestamp: last,
+		}, false)
+		if err != nil {
+			// The summary is still worth returning
+			e.logger.Warn("Failed to archive conversation summary",
+				zap.String("channel_id", execCtx.ChannelID),
+				zap.Error(err),
+			)
+			message += " (couldn't save it to archival memory)"
+		} else {
+			data["archive_id"] = archiveID
+			message += " (saved to archival memory)"
+		}
+	}
+
+	return &ToolResult{
+		Success: true,
+		Data:    data,
+		Message: message,
+	}
+}
+
+// parseTimeArg parses a time given to a tool as an RFC 3339 timestamp, a date,
+// or how long before now, such as "90m", "6h" or "2d". Empty is the zero time.
+func parseTimeArg(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+
+	var ago time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is not a time or a duration like 6h or 2d", value)
+		}
+		ago = time.Duration(n * float64(24*time.Hour))
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is not a time or a duration like 6h or 2d", value)
+		}
+		ago = d
+	}
+	if ago < 0 {
+		return time.Time{}, fmt.Errorf("%q is in the future", value)
+	}
+	return now.Add(-ago), nil
+}
+
+// summaryTranscript renders messages as "[time] sender: content" lines
+func summaryTranscript(messages []graph.Message) string {
+	lines := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		sender := msg.SenderName
+		if sender == "" {
+			sender = msg.Role
+		}
+		line := fmt.Sprintf("%s: %s", sender, msg.Content)
+		if !msg.Timestamp.IsZero() {
+			line = fmt.Sprintf("[%s] %s", msg.Timestamp.UTC().Format("2006-01-02 15:04"), line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// summarizeConversation summarizes a transcript map-reduce style: a transcript
+// too long for one LLM call is split into chunks that are summarized
+// separately, and the partial summaries are combined, in further rounds if
+// they are still too long
+func (e *Executor) summarizeConversation(ctx context.Context, transcript string) (string, error) {
+	text := transcript
+	partial := false
+	for round := 0; ; round++ {
+		chunks := smartChunkContent(text, maxConversationChunkChars)
+		if len(chunks) == 1 {
+			break
+		}
+		if round == maxSummaryReduceRounds {
+			text = chunks[0] + "\n\n... [later parts truncated]"
+			break
+		}
+
+		e.logger.Info("Summarizing conversation in chunks",
+			zap.Int("round", round+1),
+			zap.Int("chunks", len(chunks)),
+			zap.Int("length", len(text)),
+		)
+		summaries := make([]string, 0, len(chunks))
+		for i, chunk := range chunks {
+			summary, err := e.summarizeConversationChunk(ctx, chunk, i+1, len(chunks), partial)
+			if err != nil {
+				return "", err
+			}
+			summaries = append(summaries, summary)
+		}
+		text = strings.Join(summaries, "\n\n---\n\n")
+		partial = true
+	}
+
+	systemPrompt := "Summarize this chat conversation for someone catching up on it. Start with a one or two sentence overview, then a \"Key points\" list and a \"Decisions\" list (write \"None\" if nothing was decided). Mention who said what where it matters. Be concise and leave out small talk."
+	userPrompt := fmt.Sprintf("Conversation:\n\n%s", text)
+	if partial {
+		systemPrompt = "Combine these summaries of consecutive parts of a chat conversation into one summary for someone catching up on it. Start with a one or two sentence overview, then a \"Key points\" list and a \"Decisions\" list (write \"None\" if nothing was decided). Mention who said what where it matters. Be concise and don't repeat points."
+		userPrompt = fmt.Sprintf("Summaries of the conversation's parts, in order:\n\n%s", text)
+	}
+	return e.generateSummary(ctx, systemPrompt, userPrompt)
+}
+
+// summarizeConversationChunk summarizes one part of a transcript, or of the
+// partial summaries from the previous round
+func (e *Executor) summarizeConversationChunk(ctx context.Context, chunk string, chunkNum, totalChunks int, partial bool) (string, error) {
+	systemPrompt := "Summarize this part of a chat conversation. List the topics discussed, key points, decisions made and open questions, and who said what where it matters. Be brief and leave out small talk."
+	what := "the conversation"
+	if partial {
+		systemPrompt = "Condense these summaries of consecutive parts of a chat conversation. Keep the topics, key points, decisions and open questions, and who said what where it matters. Drop repetition."
+		what = "the conversation's summaries"
+	}
+	userPrompt := fmt.Sprintf("Part %d of %d of %s:\n\n%s", chunkNum, totalChunks, what, chunk)
+	summary, err := e.generateSummary(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize part %d of %d: %w", chunkNum, totalChunks, err)
+	}
+	return summary, nil
+}
+
+func (e *Executor) generateSummary(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	response, err := e.llmAdapter.Generate(ctx, adapter.GenerateParams{Auxiliary: true}, systemPrompt, userPrompt, []adapter.Tool{})
+	if err != nil {
+		return "", err
+	}
+	if response.Content == "" {
+		return "", fmt.Errorf("empty response from LLM")
+	}
+	return strings.TrimSpace(response.Content), nil
+}
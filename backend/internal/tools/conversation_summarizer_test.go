@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/graph"
+)
+
+func TestParseTimeArg(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"":                     {},
+		"2026-03-09T08:30:00Z": time.Date(2026, 3, 9, 8, 30, 0, 0, time.UTC),
+		"2026-03-01":           time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		"90m":                  now.Add(-90 * time.Minute),
+		"2d":                   now.Add(-48 * time.Hour),
+	}
+	for value, want := range cases {
+		got, err := parseTimeArg(value, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseTimeArg(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"yesterday", "-2h"} {
+		if _, err := parseTimeArg(value, now); err == nil {
+			t.Errorf("Expected parseTimeArg(%q) to fail", value)
+		}
+	}
+}
+
+func TestSummaryTranscript(t *testing.T) {
+	messages := []graph.Message{
+		{Role: "user", SenderName: "alice", Content: "Ship on Friday?", Timestamp: time.Date(2026, 3, 9, 8, 30, 0, 0, time.UTC)},
+		{Role: "agent", Content: "Friday works."},
+	}
+	want := "[2026-03-09 08:30] alice: Ship on Friday?\nagent: Friday works."
+	if got := summaryTranscript(messages); got != want {
+		t.Errorf("summaryTranscript() = %q, want %q", got, want)
+	}
+}
+
+func TestSummarizeConversation_MapReducesLongTranscripts(t *testing.T) {
+	var mu sync.Mutex
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		prompts = append(prompts, req.Messages[len(req.Messages)-1].Content)
+		call := len(prompts)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"summary %d"}}]}`, call)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(nil)
+	executor.SetLLMAdapter(adapter.NewLLMAdapter(server.URL, "", "test-model"))
+
+	// Short transcripts take a single call
+	summary, err := executor.summarizeConversation(context.Background(), "alice: hi\nbob: hello")
+	if err != nil || summary != "summary 1" || len(prompts) != 1 {
+		t.Fatalf("Expected one call for a short transcript, got %q, %v after %d calls", summary, err, len(prompts))
+	}
+
+	// Long ones are summarized per chunk, then the chunk summaries combined
+	prompts = nil
+	line := "alice: " + strings.Repeat("we should plan the release ", 20) + "\n"
+	transcript := strings.Repeat(line, 2*maxConversationChunkChars/len(line)+5)
+	summary, err = executor.summarizeConversation(context.Background(), transcript)
+	if err != nil {
+		t.Fatalf("summarizeConversation failed: %v", err)
+	}
+	if len(prompts) != 4 || summary != "summary 4" {
+		t.Fatalf("Expected three chunk summaries and a final one, got %d calls and %q", len(prompts), summary)
+	}
+	final := prompts[3]
+	if !strings.Contains(final, "summary 1") || !strings.Contains(final, "summary 3") {
+		t.Errorf("Expected the final call to combine the chunk summaries, got %q", final)
+	}
+}
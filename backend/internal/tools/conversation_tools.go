@@ -45,6 +45,52 @@ func GetConversationTools() []adapter.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolResetConversation,
+				Description: "Clear the conversation history of the current channel so the conversation starts fresh. Long-term memory and facts are kept. Only use this when the user asks to reset or start over.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"archive": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Save the cleared conversation to archival memory first (default: true)",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolSummarizeConversation,
+				Description: "Summarize what was discussed in the current channel, with key points and decisions. Use this when a user asks to be caught up. Covers the latest messages, optionally limited to a time range.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"limit": map[string]interface{}{
+							"type":        "integer",
+							"description": "Maximum number of recent messages to summarize (default: 100, max: 500)",
+						},
+						"since": map[string]interface{}{
+							"type":        "string",
+							"description": "Only summarize messages after this time: an RFC 3339 timestamp, a date (2006-01-02), or how long ago such as \"6h\" or \"2d\"",
+						},
+						"until": map[string]interface{}{
+							"type":        "string",
+							"description": "Only summarize messages before this time, in the same formats as since",
+						},
+						"archive": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Also save the summary to archival memory (default: false)",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
 	}
 }
 
@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// maxReactionLookups bounds the reaction lists read per analysis. Discord
+// only says who reacted per emoji per message, so each is a request.
+const maxReactionLookups = 20
+
+// EmojiCount is how often a user used one emoji
+type EmojiCount struct {
+	Emoji     string `json:"emoji"`               // The emoji itself, or <:name:id> for custom emoji
+	Custom    bool   `json:"custom,omitempty"`    // A Discord custom emoji
+	Count     int    `json:"count"`               // Uses in messages
+	Reactions int    `json:"reactions,omitempty"` // Uses as a reaction
+}
+
+// emojiUse is one use of an emoji
+type emojiUse struct {
+	emoji  string
+	custom bool
+}
+
+// customEmojiRegex matches Discord custom emoji, animated ones included
+var customEmojiRegex = regexp.MustCompile(`<a?:\w+:\d+>`)
+
+// scanEmojis returns the emoji in text in order: custom emoji and Unicode
+// emoji, keeping sequences such as flags, skin tones and ZWJ families whole
+func scanEmojis(text string) []emojiUse {
+	var uses []emojiUse
+	last := 0
+	for _, loc := range customEmojiRegex.FindAllStringIndex(text, -1) {
+		uses = append(uses, scanUnicodeEmojis(text[last:loc[0]])...)
+		uses = append(uses, emojiUse{emoji: text[loc[0]:loc[1]], custom: true})
+		last = loc[1]
+	}
+	return append(uses, scanUnicodeEmojis(text[last:])...)
+}
+
+func scanUnicodeEmojis(text string) []emojiUse {
+	var uses []emojiUse
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		end := i + size
+		switch {
+		case isRegionalIndicator(r):
+			// Flags are pairs of regional indicators
+			next, nextSize := utf8.DecodeRuneInString(text[end:])
+			if !isRegionalIndicator(next) {
+				i = end
+				continue
+			}
+			end += nextSize
+		case isKeycapBase(r):
+			// Keycaps are a digit, # or * with an optional VS16 and U+20E3
+			j := end
+			if next, nextSize := utf8.DecodeRuneInString(text[j:]); next == 0xFE0F {
+				j += nextSize
+			}
+			next, nextSize := utf8.DecodeRuneInString(text[j:])
+			if next != 0x20E3 {
+				i = end
+				continue
+			}
+			end = j + nextSize
+		case isEmojiBase(r):
+			end = emojiSequenceEnd(text, end)
+		default:
+			i = end
+			continue
+		}
+		uses = append(uses, emojiUse{emoji: text[i:end]})
+		i = end
+	}
+	return uses
+}
+
+// emojiSequenceEnd extends an emoji ending at i over the modifiers and
+// zero-width-joined emoji that follow it
+func emojiSequenceEnd(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == 0xFE0F, r == 0x20E3, isSkinTone(r), r >= 0xE0020 && r <= 0xE007F:
+			i += size
+		case r == 0x200D:
+			next, nextSize := utf8.DecodeRuneInString(text[i+size:])
+			if !isEmojiBase(next) {
+				return i
+			}
+			i += size + nextSize
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func isEmojiBase(r rune) bool {
+	switch {
+	case r >= 0x1F300 && r <= 0x1FAFF:
+		return !isSkinTone(r)
+	case r >= 0x1F000 && r <= 0x1F0FF, // Mahjong and playing cards
+		r >= 0x2600 && r <= 0x27BF, // Miscellaneous symbols and dingbats
+		r >= 0x2300 && r <= 0x23FF, // Watches, hourglasses and media controls
+		r >= 0x2B00 && r <= 0x2BFF, // Stars, squares and arrows
+		r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	}
+	return false
+}
+
+func isSkinTone(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+func isKeycapBase(r rune) bool {
+	return (r >= '0' && r <= '9') || r == '#' || r == '*'
+}
+
+// emojiDistribution counts the emoji in messages and the reactions given,
+// ranked by uses in messages, then as reactions
+func emojiDistribution(messages []string, reactions []emojiUse) []EmojiCount {
+	counts := make(map[string]*EmojiCount)
+	count := func(use emojiUse) *EmojiCount {
+		c, ok := counts[use.emoji]
+		if !ok {
+			c = &EmojiCount{Emoji: use.emoji, Custom: use.custom}
+			counts[use.emoji] = c
+		}
+		return c
+	}
+	for _, msg := range messages {
+		for _, use := range scanEmojis(msg) {
+			count(use).Count++
+		}
+	}
+	for _, use := range reactions {
+		count(use).Reactions++
+	}
+
+	distribution := make([]EmojiCount, 0, len(counts))
+	for _, c := range counts {
+		distribution = append(distribution, *c)
+	}
+	sort.Slice(distribution, func(i, j int) bool {
+		a, b := distribution[i], distribution[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Reactions != b.Reactions {
+			return a.Reactions > b.Reactions
+		}
+		return a.Emoji < b.Emoji
+	})
+	return distribution
+}
+
+// topEmojis returns up to limit of the emoji most used in messages
+func topEmojis(distribution []EmojiCount, limit int) []string {
+	var top []string
+	for _, c := range distribution {
+		if len(top) == limit || c.Count == 0 {
+			break
+		}
+		top = append(top, c.Emoji)
+	}
+	return top
+}
+
+// fetchUserReactions returns the reactions userID added to the latest
+// messages of a channel. Only the first maxReactionLookups reactions are
+// checked. It is best effort: on an error, such as missing permissions, it
+// returns what it found so far.
+func (d *DiscordExecutor) fetchUserReactions(ctx context.Context, channelID, userID string) []emojiUse {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if d.session == nil && d.channelMessages == nil {
+		return nil
+	}
+	reactionUsers := d.reactionUsers
+	if reactionUsers == nil {
+		reactionUsers = func(ctx context.Context, channelID, messageID, emojiID string) ([]*discordgo.User, error) {
+			return d.session.MessageReactions(channelID, messageID, emojiID, 100, "", "", discordgo.WithContext(ctx))
+		}
+	}
+
+	page, err := d.fetchMessagePage(ctx, channelID, "")
+	if err != nil {
+		d.logger.Debug("Skipping reaction analysis", zap.String("channel_id", channelID), zap.Error(err))
+		return nil
+	}
+
+	var uses []emojiUse
+	lookups := 0
+	for _, msg := range page {
+		for _, reaction := range msg.Reactions {
+			if reaction.Emoji == nil {
+				continue
+			}
+			if lookups == maxReactionLookups {
+				return uses
+			}
+			lookups++
+
+			users, err := reactionUsers(ctx, channelID, msg.ID, reaction.Emoji.APIName())
+			if err != nil {
+				d.logger.Debug("Stopping reaction analysis",
+					zap.String("channel_id", channelID),
+					zap.Int("reactions_found", len(uses)),
+					zap.Error(err),
+				)
+				return uses
+			}
+			for _, user := range users {
+				if user.ID == userID {
+					uses = append(uses, emojiUse{emoji: reaction.Emoji.MessageFormat(), custom: reaction.Emoji.ID != ""})
+					break
+				}
+			}
+		}
+	}
+	return uses
+}
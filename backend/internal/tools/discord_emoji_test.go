@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+func TestScanEmojis(t *testing.T) {
+	text := "gg 😂😂 <:pepe:123456> 👍🏽 at 10:30: 🇫🇷 👨‍👩‍👧 1️⃣ <a:dance:987> ❤️ 42"
+	var got []string
+	var custom []string
+	for _, use := range scanEmojis(text) {
+		got = append(got, use.emoji)
+		if use.custom {
+			custom = append(custom, use.emoji)
+		}
+	}
+
+	want := []string{"😂", "😂", "<:pepe:123456>", "👍🏽", "🇫🇷", "👨‍👩‍👧", "1️⃣", "<a:dance:987>", "❤️"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scanEmojis() = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(custom, []string{"<:pepe:123456>", "<a:dance:987>"}) {
+		t.Errorf("Expected only Discord emoji to be custom, got %q", custom)
+	}
+}
+
+func TestEmojiDistribution_RanksMessagesThenReactions(t *testing.T) {
+	messages := []string{"lol 😂", "😂😂 ok", "hmm 🤔", "<:pepe:1> nice"}
+	reactions := []emojiUse{{emoji: "👍"}, {emoji: "👍"}, {emoji: "🤔"}}
+
+	distribution := emojiDistribution(messages, reactions)
+	want := []EmojiCount{
+		{Emoji: "😂", Count: 3},
+		{Emoji: "🤔", Count: 1, Reactions: 1},
+		{Emoji: "<:pepe:1>", Custom: true, Count: 1},
+		{Emoji: "👍", Reactions: 2},
+	}
+	if !reflect.DeepEqual(distribution, want) {
+		t.Errorf("emojiDistribution() = %+v, want %+v", distribution, want)
+	}
+	if top := topEmojis(distribution, 5); !reflect.DeepEqual(top, []string{"😂", "🤔", "<:pepe:1>"}) {
+		t.Errorf("Expected the top list to hold emoji used in messages, got %q", top)
+	}
+
+	prompt := generateStylePrompt(&PersonalityProfile{
+		Username:          "alice",
+		MessageCount:      len(messages),
+		EmojiDistribution: distribution,
+	})
+	if !strings.Contains(prompt, "- emoji: uses 😂 heavily, 🤔 heavily, <:pepe:1> heavily") {
+		t.Errorf("Expected relative emoji frequency in the style prompt, got:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "- reacts with: 👍 (2), 🤔 (1)") {
+		t.Errorf("Expected reactions in the style prompt, got:\n%s", prompt)
+	}
+}
+
+func TestFetchUserReactions_CountsTheUsersReactions(t *testing.T) {
+	d := &DiscordExecutor{
+		logger: zap.NewNop(),
+		channelMessages: func(ctx context.Context, channelID, beforeID string) ([]*discordgo.Message, error) {
+			return []*discordgo.Message{{
+				ID: "m1",
+				Reactions: []*discordgo.MessageReactions{
+					{Count: 2, Emoji: &discordgo.Emoji{Name: "🔥"}},
+					{Count: 1, Emoji: &discordgo.Emoji{Name: "pepe", ID: "42"}},
+				},
+			}}, nil
+		},
+		reactionUsers: func(ctx context.Context, channelID, messageID, emojiID string) ([]*discordgo.User, error) {
+			if emojiID == "pepe:42" {
+				return []*discordgo.User{{ID: "user-1"}}, nil
+			}
+			return []*discordgo.User{{ID: "someone-else"}}, nil
+		},
+	}
+
+	uses := d.fetchUserReactions(context.Background(), "general", "user-1")
+	if !reflect.DeepEqual(uses, []emojiUse{{emoji: "<:pepe:42>", custom: true}}) {
+		t.Errorf("Expected only the user's custom reaction, got %+v", uses)
+	}
+}
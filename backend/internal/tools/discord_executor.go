@@ -67,7 +67,8 @@ type PersonalityProfile struct {
 	LengthDistribution MessageLengthDistribution `json:"length_distribution"`
 	CommonWords        []string                  `json:"common_words"`
 	CommonPhrases      []string                  `json:"common_phrases"`
-	EmojiUsage         []string                  `json:"emoji_usage"`
+	EmojiUsage         []string                  `json:"emoji_usage"`                  // Top 5 emoji in messages
+	EmojiDistribution  []EmojiCount              `json:"emoji_distribution,omitempty"` // Every emoji used, most used first
 	Capitalization     string                    `json:"capitalization"`    // "normal", "lowercase", "uppercase", "mixed"
 	PunctuationStyle   string                    `json:"punctuation_style"` // "minimal", "normal", "heavy"
 	ToneIndicators     []string                  `json:"tone_indicators"`   // e.g., "casual", "formal", "enthusiastic"
@@ -81,6 +82,14 @@ type DiscordExecutor struct {
 	session *discordgo.Session
 	logger  *zap.Logger
 	repo    *graph.Repository // For RAG memory access
+
+	// channelMessages reads up to 100 messages before beforeID; nil uses
+	// session.ChannelMessages
+	channelMessages func(ctx context.Context, channelID, beforeID string) ([]*discordgo.Message, error)
+
+	// reactionUsers lists who reacted to a message with an emoji; nil uses
+	// session.MessageReactions
+	reactionUsers func(ctx context.Context, channelID, messageID, emojiID string) ([]*discordgo.User, error)
 }
 
 // NewDiscordExecutor creates a new Discord executor
@@ -185,6 +194,27 @@ func (d *DiscordExecutor) GetUserInfo(ctx context.Context, userID string) (*Disc
 	}, nil
 }
 
+// CanManageMessages reports whether a user may clear the bot's
+// conversation in a channel: anyone in a DM, and in a server channel users
+// with the Manage Messages or Administrator permission
+func (d *DiscordExecutor) CanManageMessages(channelID, userID string) (bool, error) {
+	if d.session == nil {
+		return false, apperrors.ErrDiscordSessionUnavailable
+	}
+	channel, err := d.session.Channel(channelID)
+	if err != nil {
+		return false, apperrors.NewDiscordChannelNotFound(channelID)
+	}
+	if channel.Type == discordgo.ChannelTypeDM {
+		return true, nil
+	}
+	permissions, err := d.session.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		return false, err
+	}
+	return permissions&(discordgo.PermissionManageMessages|discordgo.PermissionAdministrator) != 0, nil
+}
+
 // GetChannelInfo gets information about a Discord channel
 func (d *DiscordExecutor) GetChannelInfo(ctx context.Context, channelID string) (*DiscordChannelInfo, error) {
 	if ctx != nil {
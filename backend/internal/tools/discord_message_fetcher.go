@@ -2,9 +2,14 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	apperrors "ezra-clone/backend/pkg/errors"
 
@@ -12,6 +17,23 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// guildFetchWorkers is how many channels FetchUserMessagesFromGuild reads at once
+	guildFetchWorkers = 4
+
+	// minGuildMessages is the fewest messages a guild-wide fetch must gather
+	// for its result to be used despite channels that failed
+	minGuildMessages = 20
+
+	// messagePageAttempts is how many times a page of history is requested
+	// before the channel is given up on
+	messagePageAttempts = 3
+)
+
+// messagePageBackoff is the wait before retrying a failed page, doubled on
+// each retry; rate limits wait at least as long as Discord asks
+var messagePageBackoff = 500 * time.Millisecond
+
 // FetchUserMessages fetches messages from a user with proper pagination
 func (d *DiscordExecutor) FetchUserMessages(ctx context.Context, channelID, userID string, target int) ([]*discordgo.Message, error) {
 	if ctx != nil {
@@ -38,7 +60,7 @@ func (d *DiscordExecutor) FetchUserMessages(ctx context.Context, channelID, user
 			}
 		}
 
-		batch, err := d.session.ChannelMessages(channelID, 100, beforeID, "", "")
+		batch, err := d.fetchMessagePage(ctx, channelID, beforeID)
 		if err != nil {
 			return out, err
 		}
@@ -120,68 +142,182 @@ func (d *DiscordExecutor) FetchUserMessagesFromGuild(ctx context.Context, guildI
 		zap.Int("messages_per_channel", messagesPerChannel),
 	)
 
-	// Fetch messages from each channel
-	var allMessages []*discordgo.Message
-	for i, ch := range textChannels {
-		if ctx != nil {
-			select {
-			case <-ctx.Done():
-				return allMessages, apperrors.NewContextCancelled("FetchUserMessagesFromGuild", ctx.Err())
-			default:
-			}
+	allMessages, failed := d.fetchUserMessagesFromChannels(ctx, textChannels, userID, messagesPerChannel)
+
+	// Sort all messages by timestamp (oldest first)
+	sort.Slice(allMessages, func(i, j int) bool {
+		return allMessages[i].Timestamp.Before(allMessages[j].Timestamp)
+	})
+
+	d.logger.Info("Fetched messages from all guild channels",
+		zap.String("guild_id", guildID),
+		zap.String("user_id", userID),
+		zap.Int("channels_searched", len(textChannels)),
+		zap.Int("channels_failed", failed),
+		zap.Int("total_messages", len(allMessages)),
+	)
+
+	// Channels that failed or weren't reached before the deadline are
+	// skipped, as long as enough messages were found elsewhere
+	if len(allMessages) < minGuildMessages {
+		if ctx != nil && ctx.Err() != nil {
+			return allMessages, apperrors.NewContextCancelled("FetchUserMessagesFromGuild", ctx.Err())
+		}
+		if failed > 0 {
+			return allMessages, fmt.Errorf("only %d messages found in guild %s, %d of %d channels failed",
+				len(allMessages), guildID, failed, len(textChannels))
 		}
+	}
 
-		d.logger.Debug("Fetching messages from channel",
-			zap.String("channel_id", ch.ID),
-			zap.String("channel_name", ch.Name),
-			zap.Int("channel_index", i+1),
-			zap.Int("total_channels", len(textChannels)),
-		)
+	return allMessages, nil
+}
 
-		messages, err := d.FetchUserMessages(ctx, ch.ID, userID, messagesPerChannel)
-		if err != nil {
-			// Check if it's an unsupported channel type error
-			errStr := err.Error()
-			if strings.Contains(errStr, "unknown component type") ||
-				strings.Contains(errStr, "component type") ||
-				strings.Contains(errStr, "unsupported") {
-				d.logger.Debug("Skipping unsupported channel type",
-					zap.String("channel_id", ch.ID),
-					zap.String("channel_name", ch.Name),
-					zap.String("error", errStr),
-				)
-			} else {
-				d.logger.Warn("Failed to fetch messages from channel",
+// fetchUserMessagesFromChannels reads channels concurrently, up to
+// guildFetchWorkers at a time, and returns the messages found and how many
+// channels failed. A failed channel keeps the messages read before it failed.
+// Channels not started when ctx is done are skipped.
+func (d *DiscordExecutor) fetchUserMessagesFromChannels(ctx context.Context, channels []*discordgo.Channel, userID string, messagesPerChannel int) ([]*discordgo.Message, int) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var (
+		mu          sync.Mutex
+		allMessages []*discordgo.Message
+		failed      int
+		wg          sync.WaitGroup
+	)
+	jobs := make(chan *discordgo.Channel)
+	for w := 0; w < min(guildFetchWorkers, len(channels)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ch := range jobs {
+				messages, err := d.FetchUserMessages(ctx, ch.ID, userID, messagesPerChannel)
+				mu.Lock()
+				allMessages = append(allMessages, messages...)
+				if err != nil {
+					failed++
+				}
+				total := len(allMessages)
+				mu.Unlock()
+
+				if err != nil {
+					d.logChannelFetchError(ctx, ch, err)
+					continue
+				}
+				d.logger.Debug("Fetched messages from channel",
 					zap.String("channel_id", ch.ID),
 					zap.String("channel_name", ch.Name),
-					zap.Error(err),
+					zap.Int("message_count", len(messages)),
+					zap.Int("total_so_far", total),
 				)
 			}
-			// Continue with other channels even if one fails
-			continue
+		}()
+	}
+
+feed:
+	for _, ch := range channels {
+		select {
+		case jobs <- ch:
+		case <-ctx.Done():
+			break feed
 		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return allMessages, failed
+}
 
-		allMessages = append(allMessages, messages...)
-		d.logger.Debug("Fetched messages from channel",
+// logChannelFetchError logs why a channel was skipped, quietly for the
+// expected cases: unsupported channel types and running out of time
+func (d *DiscordExecutor) logChannelFetchError(ctx context.Context, ch *discordgo.Channel, err error) {
+	errStr := err.Error()
+	switch {
+	case ctx.Err() != nil:
+		d.logger.Debug("Stopped fetching channel at deadline",
+			zap.String("channel_id", ch.ID),
+			zap.String("channel_name", ch.Name),
+		)
+	case isUnsupportedChannelError(err):
+		d.logger.Debug("Skipping unsupported channel type",
+			zap.String("channel_id", ch.ID),
+			zap.String("channel_name", ch.Name),
+			zap.String("error", errStr),
+		)
+	default:
+		d.logger.Warn("Failed to fetch messages from channel",
 			zap.String("channel_id", ch.ID),
 			zap.String("channel_name", ch.Name),
-			zap.Int("message_count", len(messages)),
-			zap.Int("total_so_far", len(allMessages)),
+			zap.Error(err),
 		)
 	}
+}
 
-	// Sort all messages by timestamp (oldest first)
-	sort.Slice(allMessages, func(i, j int) bool {
-		return allMessages[i].Timestamp.Before(allMessages[j].Timestamp)
-	})
+// fetchMessagePage reads up to 100 messages before beforeID, retrying rate
+// limits and transient failures with backoff while ctx allows
+func (d *DiscordExecutor) fetchMessagePage(ctx context.Context, channelID, beforeID string) ([]*discordgo.Message, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fetch := d.channelMessages
+	if fetch == nil {
+		fetch = func(ctx context.Context, channelID, beforeID string) ([]*discordgo.Message, error) {
+			// Rate limits are waited out here, so the wait can respect ctx
+			return d.session.ChannelMessages(channelID, 100, beforeID, "", "",
+				discordgo.WithContext(ctx), discordgo.WithRetryOnRatelimit(false))
+		}
+	}
 
-	d.logger.Info("Fetched messages from all guild channels",
-		zap.String("guild_id", guildID),
-		zap.String("user_id", userID),
-		zap.Int("channels_searched", len(textChannels)),
-		zap.Int("total_messages", len(allMessages)),
-	)
+	backoff := messagePageBackoff
+	for attempt := 1; ; attempt++ {
+		batch, err := fetch(ctx, channelID, beforeID)
+		if err == nil || attempt == messagePageAttempts || !isRetryableDiscordError(err) {
+			return batch, err
+		}
 
-	return allMessages, nil
+		wait := backoff
+		var rateErr *discordgo.RateLimitError
+		if errors.As(err, &rateErr) && rateErr.RateLimit != nil && rateErr.TooManyRequests != nil && rateErr.RetryAfter > wait {
+			wait = rateErr.RetryAfter
+		}
+		d.logger.Debug("Retrying message fetch",
+			zap.String("channel_id", channelID),
+			zap.Int("attempt", attempt),
+			zap.Duration("wait", wait),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return nil, apperrors.NewContextCancelled("FetchUserMessages", ctx.Err())
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
+
+// isRetryableDiscordError reports whether a request may succeed if repeated:
+// rate limits, server errors and network failures. Missing permissions and
+// unknown channels won't.
+func isRetryableDiscordError(err error) bool {
+	var rateErr *discordgo.RateLimitError
+	if errors.As(err, &rateErr) {
+		return true
+	}
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		code := restErr.Response.StatusCode
+		return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// isUnsupportedChannelError reports whether a channel's messages can't be
+// decoded, such as ones with component types the library doesn't know
+func isUnsupportedChannelError(err error) bool {
+	errStr := err.Error()
+	return strings.Contains(errStr, "component type") || strings.Contains(errStr, "unsupported")
 }
 
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// fakeChannelHistory serves one page of messages from userID per channel,
+// failing the first requests of a channel with its queued errors
+type fakeChannelHistory struct {
+	mu     sync.Mutex
+	errs   map[string][]error
+	calls  map[string]int
+	userID string
+}
+
+func (f *fakeChannelHistory) channelMessages(ctx context.Context, channelID, beforeID string) ([]*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[channelID]++
+	if errs := f.errs[channelID]; len(errs) > 0 {
+		f.errs[channelID] = errs[1:]
+		return nil, errs[0]
+	}
+	if beforeID != "" {
+		return nil, nil
+	}
+	var page []*discordgo.Message
+	for i := 0; i < 10; i++ {
+		page = append(page, &discordgo.Message{
+			ID:        fmt.Sprintf("%s-%d", channelID, i),
+			Content:   fmt.Sprintf("message %d in %s", i, channelID),
+			Author:    &discordgo.User{ID: f.userID},
+			Timestamp: time.Date(2026, 1, 1, 0, i, 0, 0, time.UTC),
+		})
+	}
+	return page, nil
+}
+
+func TestFetchUserMessagesFromChannels_SkipsFailedChannels(t *testing.T) {
+	previous := messagePageBackoff
+	messagePageBackoff = time.Millisecond
+	defer func() { messagePageBackoff = previous }()
+
+	forbidden := &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusForbidden, Status: "403 Forbidden"}}
+	history := &fakeChannelHistory{
+		userID: "user-1",
+		calls:  map[string]int{},
+		errs: map[string][]error{
+			"denied": {forbidden},
+			"flaky":  {&url.Error{Op: "Get", URL: "https://discord.com", Err: errors.New("connection reset")}},
+		},
+	}
+	d := &DiscordExecutor{logger: zap.NewNop(), channelMessages: history.channelMessages}
+
+	channels := []*discordgo.Channel{{ID: "general"}, {ID: "denied"}, {ID: "flaky"}, {ID: "random"}, {ID: "memes"}}
+	messages, failed := d.fetchUserMessagesFromChannels(context.Background(), channels, "user-1", 300)
+
+	if failed != 1 {
+		t.Errorf("Expected only the denied channel to fail, got %d failures", failed)
+	}
+	if len(messages) != 40 {
+		t.Errorf("Expected 10 messages from each of the 4 readable channels, got %d", len(messages))
+	}
+	if history.calls["denied"] != 1 {
+		t.Errorf("Expected a permission error not to be retried, got %d calls", history.calls["denied"])
+	}
+	if history.calls["flaky"] != 3 {
+		t.Errorf("Expected the flaky channel to be retried and paged, got %d calls", history.calls["flaky"])
+	}
+}
+
+func TestFetchMessagePage_StopsRetryingAtDeadline(t *testing.T) {
+	rateLimited := &discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{
+		TooManyRequests: &discordgo.TooManyRequests{RetryAfter: time.Minute},
+	}}
+	d := &DiscordExecutor{
+		logger: zap.NewNop(),
+		channelMessages: func(ctx context.Context, channelID, beforeID string) ([]*discordgo.Message, error) {
+			return nil, rateLimited
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := d.fetchMessagePage(ctx, "general", ""); err == nil {
+		t.Fatal("Expected the fetch to fail")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the rate-limit wait to end at the deadline, took %v", elapsed)
+	}
+}
@@ -39,31 +39,22 @@ func (d *DiscordExecutor) AnalyzeUserPersonality(ctx context.Context, channelID,
 		return nil, fmt.Errorf("failed to get channel info: %w", err)
 	}
 
-	guildID := channelInfo.GuildID
-	if guildID == "" {
-		guildID = "dm" // Use "dm" as guild ID for DMs
-	}
+	guildID := profileGuildID(channelInfo)
 
 	// Check for cached profile if not forcing update
-	if !forceUpdate && d.repo != nil {
-		cachedProfileJSON, err := d.repo.GetUserPersonalityProfile(ctx, userID, guildID)
-		if err == nil && cachedProfileJSON != "" {
-			// Deserialize cached profile
-			var profile PersonalityProfile
-			if err := json.Unmarshal([]byte(cachedProfileJSON), &profile); err == nil {
-				d.logger.Info("Using cached personality profile",
-					zap.String("user_id", userID),
-					zap.String("guild_id", guildID),
-				)
-				// Regenerate style prompt with RAG (in case memories were updated)
-				profile.StylePrompt = d.generateStylePromptWithRAG(ctx, &profile, userID)
-				return &profile, nil
-			} else {
-				d.logger.Warn("Failed to deserialize cached profile, re-analyzing",
-					zap.String("user_id", userID),
-					zap.Error(err),
-				)
-			}
+	if !forceUpdate {
+		profile, err := d.CachedPersonalityProfile(ctx, userID, guildID)
+		if err != nil {
+			d.logger.Warn("Failed to load cached profile, re-analyzing",
+				zap.String("user_id", userID),
+				zap.Error(err),
+			)
+		} else if profile != nil {
+			d.logger.Info("Using cached personality profile",
+				zap.String("user_id", userID),
+				zap.String("guild_id", guildID),
+			)
+			return profile, nil
 		}
 	}
 
@@ -111,7 +102,8 @@ func (d *DiscordExecutor) AnalyzeUserPersonality(ctx context.Context, channelID,
 
 				messages, err = d.FetchUserMessagesFromGuild(ctx, channelInfo.GuildID, userID, messagesPerChannel)
 				if err != nil {
-					d.logger.Warn("Failed to fetch from all guild channels, falling back to single channel",
+					d.logger.Warn("Failed to fetch enough messages from guild channels, falling back to single channel",
+						zap.Int("messages_found", len(messages)),
 						zap.Error(err),
 					)
 					// Fall back to single channel
@@ -198,8 +190,9 @@ func (d *DiscordExecutor) AnalyzeUserPersonality(ctx context.Context, channelID,
 	// Extract common phrases (bigrams)
 	profile.CommonPhrases = extractCommonPhrases(userMessages, 2, 12)
 
-	// Extract emoji usage
-	profile.EmojiUsage = extractEmojis(userMessages)
+	// Count emoji in messages and, where Discord lets us see them, reactions
+	profile.EmojiDistribution = emojiDistribution(userMessages, d.fetchUserReactions(ctx, channelID, userID))
+	profile.EmojiUsage = topEmojis(profile.EmojiDistribution, 5)
 
 	// Analyze formatting habits
 	profile.FormatHabits = analyzeFormatHabits(userMessages)
@@ -247,6 +240,48 @@ func (d *DiscordExecutor) AnalyzeUserPersonality(ctx context.Context, channelID,
 	return profile, nil
 }
 
+// CachedPersonalityProfile returns the stored profile for a user in a guild
+// ("dm" for direct messages), or nil if none is cached. The style prompt is
+// regenerated with RAG in case the user's memories changed since analysis.
+func (d *DiscordExecutor) CachedPersonalityProfile(ctx context.Context, userID, guildID string) (*PersonalityProfile, error) {
+	if d.repo == nil {
+		return nil, nil
+	}
+
+	cachedProfileJSON, err := d.repo.GetUserPersonalityProfile(ctx, userID, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if cachedProfileJSON == "" {
+		return nil, nil
+	}
+
+	var profile PersonalityProfile
+	if err := json.Unmarshal([]byte(cachedProfileJSON), &profile); err != nil {
+		return nil, fmt.Errorf("failed to decode cached personality profile: %w", err)
+	}
+	profile.StylePrompt = d.generateStylePromptWithRAG(ctx, &profile, userID)
+	return &profile, nil
+}
+
+// PersonalityGuildID returns the guild a channel's personality profiles are
+// cached under
+func (d *DiscordExecutor) PersonalityGuildID(ctx context.Context, channelID string) (string, error) {
+	channelInfo, err := d.GetChannelInfo(ctx, channelID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get channel info: %w", err)
+	}
+	return profileGuildID(channelInfo), nil
+}
+
+// profileGuildID uses "dm" as the guild ID for direct messages
+func profileGuildID(channelInfo *DiscordChannelInfo) string {
+	if channelInfo.GuildID == "" {
+		return "dm"
+	}
+	return channelInfo.GuildID
+}
+
 // Helper functions for personality analysis
 
 func analyzeCapitalization(messages []string) string {
@@ -479,39 +514,6 @@ func analyzeMessageLengthDistribution(lengths []int, messages []*discordgo.Messa
 	}
 }
 
-func extractEmojis(messages []string) []string {
-	emojiCount := make(map[string]int)
-
-	// Common emoji patterns
-	emojiRegex := regexp.MustCompile(`[\x{1F600}-\x{1F64F}]|[\x{1F300}-\x{1F5FF}]|[\x{1F680}-\x{1F6FF}]|[\x{2600}-\x{26FF}]|[\x{2700}-\x{27BF}]|:\w+:|<:\w+:\d+>`)
-
-	for _, msg := range messages {
-		emojis := emojiRegex.FindAllString(msg, -1)
-		for _, emoji := range emojis {
-			emojiCount[emoji]++
-		}
-	}
-
-	// Sort by frequency
-	type emojiFreq struct {
-		emoji string
-		count int
-	}
-	var sorted []emojiFreq
-	for emoji, count := range emojiCount {
-		sorted = append(sorted, emojiFreq{emoji, count})
-	}
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].count > sorted[j].count
-	})
-
-	var result []string
-	for i := 0; i < 5 && i < len(sorted); i++ {
-		result = append(result, sorted[i].emoji)
-	}
-	return result
-}
-
 // extractPersonalityFactsFromMessages extracts potential facts/opinions from user messages
 // This is a helper that can be called during personality analysis to auto-extract memories
 func extractPersonalityFactsFromMessages(messages []string) []string {
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -63,11 +64,17 @@ func generateStylePrompt(profile *PersonalityProfile) string {
 		b.WriteString("- common phrases: " + strings.Join(profile.CommonPhrases, ", ") + "\n")
 	}
 
-	if len(profile.EmojiUsage) > 0 {
+	if emoji := describeEmojiFrequency(profile.EmojiDistribution, profile.MessageCount); emoji != "" {
+		b.WriteString("- emoji: " + emoji + "\n")
+	} else if len(profile.EmojiUsage) > 0 {
+		// Profiles cached before emoji were counted only have the top list
 		b.WriteString("- emoji set: " + strings.Join(profile.EmojiUsage, " ") + "\n")
 	} else {
 		b.WriteString("- emoji: rarely\n")
 	}
+	if reactions := describeReactions(profile.EmojiDistribution); reactions != "" {
+		b.WriteString("- reacts with: " + reactions + "\n")
+	}
 
 	// Formatting habits summary
 	b.WriteString("- formatting habits:\n")
@@ -108,3 +115,48 @@ func generateStylePrompt(profile *PersonalityProfile) string {
 	return b.String()
 }
 
+// describeEmojiFrequency says how often the most used emoji appear, such as
+// "uses 😂 heavily, 🤔 occasionally", judged per message
+func describeEmojiFrequency(distribution []EmojiCount, messageCount int) string {
+	if messageCount == 0 {
+		return ""
+	}
+	var parts []string
+	for _, c := range distribution {
+		if len(parts) == 8 || c.Count == 0 {
+			break
+		}
+		rate := float64(c.Count) / float64(messageCount)
+		switch {
+		case rate >= 0.25:
+			parts = append(parts, c.Emoji+" heavily")
+		case rate >= 0.08:
+			parts = append(parts, c.Emoji+" often")
+		default:
+			parts = append(parts, c.Emoji+" occasionally")
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "uses " + strings.Join(parts, ", ")
+}
+
+// describeReactions lists the emoji most used as reactions, with counts
+func describeReactions(distribution []EmojiCount) string {
+	reactions := make([]EmojiCount, 0, len(distribution))
+	for _, c := range distribution {
+		if c.Reactions > 0 {
+			reactions = append(reactions, c)
+		}
+	}
+	sort.SliceStable(reactions, func(i, j int) bool {
+		return reactions[i].Reactions > reactions[j].Reactions
+	})
+
+	var parts []string
+	for i := 0; i < len(reactions) && i < 5; i++ {
+		parts = append(parts, fmt.Sprintf("%s (%d)", reactions[i].Emoji, reactions[i].Reactions))
+	}
+	return strings.Join(parts, ", ")
+}
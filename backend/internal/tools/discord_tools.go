@@ -91,6 +91,23 @@ func GetDiscordTools() []adapter.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolInterestPoll,
+				Description: "Post a 'this or that' poll in the channel built from the interests of the people who chat there. Use this to spark conversation, when asked to start a poll, or when the channel is quiet. Options come from topics the channel's users care about; members vote with reactions.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"options": map[string]interface{}{
+							"type":        "integer",
+							"description": "Number of choices (2-4, default: 2 for a classic this-or-that)",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
 	}
 }
 
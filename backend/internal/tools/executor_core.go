@@ -2,14 +2,19 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/cache"
 	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/internal/moderation"
 	"ezra-clone/backend/pkg/logger"
+	"ezra-clone/backend/pkg/tracing"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -19,6 +24,28 @@ type ExecutionContext struct {
 	UserID    string
 	ChannelID string
 	Platform  string // "discord", "web"
+
+	// IdempotencyKey identifies the incoming message so retries and recursive
+	// turns persist it only once. Used as the stored message ID.
+	IdempotencyKey string
+
+	// PlatformMessageID is the incoming message's ID on its platform (e.g. the
+	// Discord message ID), stored so later edits and deletes can be applied
+	PlatformMessageID string
+
+	// Attachments are files shared with the incoming message
+	Attachments []Attachment
+
+	// ToolAccess limits the tools the agent may run; the zero value allows all
+	ToolAccess ToolAccess
+
+	// OnToolStart, when set, is called as each of the turn's tools starts,
+	// so platforms can show progress during long turns
+	OnToolStart func(toolName string)
+
+	// ToolCallsMade counts the tools run so far this turn, across recursive
+	// rounds, so the per-turn tool-call limit can't be bypassed by recursing
+	ToolCallsMade int
 }
 
 // ToolResult represents the result of a tool execution
@@ -34,6 +61,7 @@ type MimicState struct {
 	Active              bool                `json:"active"`
 	OriginalPersonality string              `json:"original_personality"`
 	MimicProfile        *PersonalityProfile `json:"mimic_profile,omitempty"`
+	ChannelID           string              `json:"channel_id,omitempty"` // Channel the profile was analyzed in
 }
 
 // Executor handles tool execution
@@ -45,20 +73,38 @@ type Executor struct {
 	comfyExecutor       *ComfyExecutor
 	musicExecutor       *MusicExecutor
 	systemExecutor      *SystemExecutor
+	voiceExecutor       *VoiceExecutor
 	mimicStates         map[string]*MimicState // key: agentID
 	mimicBackgroundTask *MimicBackgroundTask
-	llmAdapter          *adapter.LLMAdapter // LLM adapter for summarization via LiteLLM
+	llmAdapter          *adapter.LLMAdapter     // LLM adapter for summarization via LiteLLM
+	webFetchMaxBytes    int64                   // Default body size cap for fetch_webpage
+	webFetchTimeout     time.Duration           // Default per-request timeout for fetch_webpage
+	webSearchURLs       []string                // web_search providers, tried in order until one returns results
+	webSearchRelax      bool                    // Retry web_search with relaxed queries when nothing is found
+	fetchCache          *cache.TTL[*ToolResult] // Successful fetch_webpage results by URL and size cap
+	searchCache         *cache.TTL[*ToolResult] // Successful web_search results by query
+	weather             WeatherProvider         // Backs get_weather
+	scheduler           *MessageScheduler       // Backs the scheduled message tools; nil outside the Discord bot
+	contentFilter       *moderation.Filter      // Checks posts made outside a turn; nil disables filtering
 }
 
 // NewExecutor creates a new tool executor
 func NewExecutor(repo *graph.Repository) *Executor {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
 	return &Executor{
-		repo: repo,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		logger:      logger.Get(),
-		mimicStates: make(map[string]*MimicState),
+		repo:             repo,
+		httpClient:       client,
+		logger:           logger.Get(),
+		mimicStates:      make(map[string]*MimicState),
+		webFetchMaxBytes: defaultWebFetchMaxBytes,
+		webFetchTimeout:  defaultWebFetchTimeout,
+		webSearchURLs:    []string{defaultWebSearchURL},
+		webSearchRelax:   true,
+		fetchCache:       cache.NewTTL[*ToolResult](defaultWebCacheMaxEntries, defaultWebCacheTTL),
+		searchCache:      cache.NewTTL[*ToolResult](defaultWebCacheMaxEntries, defaultWebCacheTTL),
+		weather:          NewOpenMeteoProvider(client),
 	}
 }
 
@@ -87,11 +133,125 @@ func (e *Executor) SetSystemExecutor(se *SystemExecutor) {
 	e.systemExecutor = se
 }
 
+// SetVoiceExecutor sets the voice executor for voice reference tools
+func (e *Executor) SetVoiceExecutor(ve *VoiceExecutor) {
+	e.voiceExecutor = ve
+}
+
+// SetMessageScheduler sets the scheduler behind the scheduled message tools
+func (e *Executor) SetMessageScheduler(s *MessageScheduler) {
+	e.scheduler = s
+}
+
+// SetContentFilter sets the filter for posts made outside a turn, such as
+// mimic replies and scheduled messages
+func (e *Executor) SetContentFilter(f *moderation.Filter) {
+	e.contentFilter = f
+}
+
+// FilterOutput runs text an agent is about to post outside a turn through
+// the agent's content filter. It returns the text to post, which is "" when
+// blocked, and whether it was filtered.
+func (e *Executor) FilterOutput(ctx context.Context, agentID, channelID, text string) (string, bool) {
+	if e.contentFilter == nil || e.repo == nil {
+		return text, false
+	}
+	agentConfig, err := e.repo.GetAgentConfig(ctx, agentID)
+	if err != nil {
+		e.logger.Warn("Failed to load content filter settings; posting unfiltered",
+			zap.String("agent_id", agentID),
+			zap.Error(err),
+		)
+		return text, false
+	}
+	settings := moderation.Settings{
+		Mode:     agentConfig.ContentFilter,
+		Action:   agentConfig.ContentFilterAction,
+		Provider: agentConfig.ModerationProvider,
+		Model:    agentConfig.ModerationModel,
+	}
+	return e.contentFilter.Apply(ctx, settings, agentID, channelID, text)
+}
+
+// AgentAuxiliaryModel returns the provider, model and reply limit an agent's
+// config picks for the calls made for it outside its replies
+func AgentAuxiliaryModel(agentConfig *graph.AgentConfig) adapter.AuxiliaryModel {
+	return adapter.AuxiliaryModel{
+		Provider:  agentConfig.AuxiliaryProvider,
+		Model:     agentConfig.AuxiliaryModel,
+		MaxTokens: agentConfig.MaxTokens,
+	}
+}
+
+// withAgentModels returns ctx with the agent's auxiliary model, for work done
+// outside a turn. If the config can't be loaded, ctx is returned as is and
+// the calls use the defaults.
+func (e *Executor) withAgentModels(ctx context.Context, agentID string) context.Context {
+	if e.repo == nil {
+		return ctx
+	}
+	agentConfig, err := e.repo.GetAgentConfig(ctx, agentID)
+	if err != nil {
+		e.logger.Debug("Failed to load the agent's models; using the defaults",
+			zap.String("agent_id", agentID),
+			zap.Error(err),
+		)
+		return ctx
+	}
+	return adapter.WithAuxiliaryModel(ctx, AgentAuxiliaryModel(agentConfig))
+}
+
 // SetLLMAdapter sets the LLM adapter for website summarization
 func (e *Executor) SetLLMAdapter(llmAdapter *adapter.LLMAdapter) {
 	e.llmAdapter = llmAdapter
 }
 
+// SetWebFetchLimits sets the default body size cap and request timeout for
+// fetch_webpage. Non-positive values keep the current setting.
+func (e *Executor) SetWebFetchLimits(maxBytes int64, timeout time.Duration) {
+	if maxBytes > 0 {
+		e.webFetchMaxBytes = maxBytes
+	}
+	if timeout > 0 {
+		e.webFetchTimeout = timeout
+	}
+}
+
+// SetWebSearchFallback controls what web_search does when a query finds
+// nothing: whether it retries with relaxed queries, and which extra providers
+// (DuckDuckGo-compatible HTML endpoints) it tries after the default one
+func (e *Executor) SetWebSearchFallback(relaxQueries bool, fallbackURLs []string) {
+	e.webSearchRelax = relaxQueries
+	e.webSearchURLs = append([]string{defaultWebSearchURL}, fallbackURLs...)
+}
+
+// SetWeatherProvider replaces the provider behind get_weather (Open-Meteo by default)
+func (e *Executor) SetWeatherProvider(provider WeatherProvider) {
+	e.weather = provider
+}
+
+// SetWebCache replaces the web_search and fetch_webpage result caches.
+// maxEntries of 0 or a non-positive ttl disables caching.
+func (e *Executor) SetWebCache(maxEntries int, ttl time.Duration) {
+	if ttl <= 0 {
+		maxEntries = 0
+	}
+	e.fetchCache = cache.NewTTL[*ToolResult](maxEntries, ttl)
+	e.searchCache = cache.NewTTL[*ToolResult](maxEntries, ttl)
+}
+
+// Caches returns the executor's caches by name, for inspection and clearing
+func (e *Executor) Caches() map[string]cache.Clearable {
+	caches := map[string]cache.Clearable{
+		"web_fetch":  e.fetchCache,
+		"web_search": e.searchCache,
+	}
+	if e.comfyExecutor != nil && e.comfyExecutor.imageCache != nil {
+		caches["images"] = e.comfyExecutor.imageCache
+	}
+	return caches
+}
+
 // GetMimicState returns the current mimic state for an agent
 func (e *Executor) GetMimicState(agentID string) *MimicState {
 	return e.mimicStates[agentID]
@@ -114,6 +274,53 @@ func (e *Executor) GetMimicPrompt(agentID string) string {
 
 // Execute runs a tool call and returns the result
 func (e *Executor) Execute(ctx context.Context, execCtx *ExecutionContext, toolCall adapter.ToolCall) *ToolResult {
+	ctx, span := tracing.Start(ctx, "tool."+toolCall.Name,
+		attribute.String("tool.name", toolCall.Name),
+		attribute.String("agent.id", execCtx.AgentID),
+	)
+	var result *ToolResult
+	if execCtx.ToolAccess.Allows(toolCall.Name) {
+		result = e.validateAndDispatch(ctx, execCtx, toolCall)
+	} else {
+		// The LLM was never offered the tool, but may still name it
+		e.logger.Warn("Refused disabled tool",
+			zap.String("tool", toolCall.Name),
+			zap.String("agent_id", execCtx.AgentID),
+		)
+		result = &ToolResult{Success: false, Error: fmt.Sprintf("Tool %s is disabled for this agent", toolCall.Name)}
+	}
+
+	var err error
+	if result != nil && !result.Success {
+		err = errors.New(result.Error)
+	}
+	tracing.End(span, err)
+	return result
+}
+
+// validateAndDispatch checks a call's arguments against the tool's parameter
+// schema and dispatches it with the coerced arguments. Invalid arguments are
+// returned to the LLM to correct instead of reaching the handler.
+func (e *Executor) validateAndDispatch(ctx context.Context, execCtx *ExecutionContext, toolCall adapter.ToolCall) *ToolResult {
+	schema, ok := toolSchemas()[toolCall.Name]
+	if !ok {
+		return e.dispatch(ctx, execCtx, toolCall)
+	}
+	args, problems := validateToolArgs(schema, toolCall.Arguments)
+	if len(problems) > 0 {
+		e.logger.Info("Rejected tool call with invalid arguments",
+			zap.String("tool", toolCall.Name),
+			zap.String("agent_id", execCtx.AgentID),
+			zap.Strings("problems", problems),
+		)
+		return invalidArgumentsResult(toolCall.Name, problems)
+	}
+	toolCall.Arguments = args
+	return e.dispatch(ctx, execCtx, toolCall)
+}
+
+// dispatch routes a tool call to its handler
+func (e *Executor) dispatch(ctx context.Context, execCtx *ExecutionContext, toolCall adapter.ToolCall) *ToolResult {
 	e.logger.Debug("Executing tool",
 		zap.String("tool", toolCall.Name),
 		zap.String("agent_id", execCtx.AgentID),
@@ -136,6 +343,14 @@ func (e *Executor) Execute(ctx context.Context, execCtx *ExecutionContext, toolC
 		return e.executeSearchFacts(ctx, execCtx, toolCall.Arguments)
 	case ToolGetUserContext:
 		return e.executeGetUserContext(ctx, execCtx, toolCall.Arguments)
+	case ToolPinFact:
+		return e.executePinFact(ctx, execCtx, toolCall.Arguments)
+	case ToolGetFact:
+		return e.executeGetFact(ctx, execCtx, toolCall.Arguments)
+	case ToolUpdateFact:
+		return e.executeUpdateFact(ctx, execCtx, toolCall.Arguments)
+	case ToolDeleteFact:
+		return e.executeDeleteFact(ctx, execCtx, toolCall.Arguments)
 
 	// Topic Tools
 	case ToolCreateTopic:
@@ -146,12 +361,18 @@ func (e *Executor) Execute(ctx context.Context, execCtx *ExecutionContext, toolC
 		return e.executeFindRelated(ctx, execCtx, toolCall.Arguments)
 	case ToolLinkUserTopic:
 		return e.executeLinkUserTopic(ctx, execCtx, toolCall.Arguments)
+	case ToolCompareUsers:
+		return e.executeCompareUsers(ctx, execCtx, toolCall.Arguments)
 
 	// Conversation Tools
 	case ToolGetHistory:
 		return e.executeGetHistory(ctx, execCtx, toolCall.Arguments)
 	case ToolSendMessage:
 		return e.executeSendMessage(ctx, execCtx, toolCall.Arguments)
+	case ToolResetConversation:
+		return e.executeResetConversation(ctx, execCtx, toolCall.Arguments)
+	case ToolSummarizeConversation:
+		return e.executeSummarizeConversation(ctx, execCtx, toolCall.Arguments)
 
 	// Web Tools
 	case ToolWebSearch:
@@ -171,6 +392,10 @@ func (e *Executor) Execute(ctx context.Context, execCtx *ExecutionContext, toolC
 	case ToolGitHubListOrgRepos:
 		return e.executeGitHubListOrgRepos(ctx, toolCall.Arguments)
 
+	// Weather Tools
+	case ToolGetWeather:
+		return e.executeGetWeather(ctx, execCtx, toolCall.Arguments)
+
 	// Discord Tools
 	case ToolDiscordReadHistory:
 		return e.executeDiscordReadHistory(ctx, execCtx, toolCall.Arguments)
@@ -180,6 +405,16 @@ func (e *Executor) Execute(ctx context.Context, execCtx *ExecutionContext, toolC
 		return e.executeDiscordGetChannelInfo(ctx, execCtx, toolCall.Arguments)
 	case ToolReadCodebase:
 		return e.executeReadCodebase(ctx, execCtx, toolCall.Arguments)
+	case ToolInterestPoll:
+		return e.executeInterestPoll(ctx, execCtx, toolCall.Arguments)
+
+	// Scheduler Tools
+	case ToolScheduleMessage:
+		return e.executeScheduleMessage(ctx, execCtx, toolCall.Arguments)
+	case ToolListScheduledMessages:
+		return e.executeListScheduledMessages(ctx, execCtx)
+	case ToolCancelScheduledMessage:
+		return e.executeCancelScheduledMessage(ctx, execCtx, toolCall.Arguments)
 
 	// Personality/Mimic Tools
 	case ToolMimicPersonality:
@@ -200,10 +435,14 @@ func (e *Executor) Execute(ctx context.Context, execCtx *ExecutionContext, toolC
 		return e.executeListWorkflows(ctx, execCtx, toolCall.Arguments)
 
 	// Music Tools
-	case ToolMusicPlay, ToolMusicPlaylist, ToolMusicQueue, ToolMusicSkip,
+	case ToolMusicPlay, ToolMusicPlaylist, ToolMusicQueue, ToolMusicNowPlaying, ToolMusicSkip, ToolMusicSeek,
 		ToolMusicPause, ToolMusicResume, ToolMusicStop, ToolMusicVolume, ToolMusicRadio, ToolMusicDisconnect:
 		return e.executeMusicTool(ctx, execCtx, toolCall)
 
+	// Voice Tools
+	case ToolVoiceListReferences, ToolVoiceDeleteReference:
+		return e.executeVoiceTool(ctx, execCtx, toolCall)
+
 	// System Tools
 	case ToolBotShutdown:
 		return e.executeSystemTool(ctx, execCtx, toolCall)
@@ -252,3 +491,20 @@ func (e *Executor) executeSystemTool(ctx context.Context, execCtx *ExecutionCont
 
 	return e.systemExecutor.ExecuteSystemTool(ctx, execCtx, toolCall.Name, args)
 }
+
+// executeVoiceTool executes a voice reference tool
+func (e *Executor) executeVoiceTool(ctx context.Context, execCtx *ExecutionContext, toolCall adapter.ToolCall) *ToolResult {
+	if e.voiceExecutor == nil {
+		return &ToolResult{
+			Success: false,
+			Error:   "Voice executor not initialized",
+		}
+	}
+
+	args := make(map[string]interface{})
+	if toolCall.Arguments != nil {
+		args = toolCall.Arguments
+	}
+
+	return e.voiceExecutor.ExecuteVoiceTool(ctx, execCtx, toolCall.Name, args)
+}
@@ -0,0 +1,215 @@
+package tools
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Extraction strategies fetch_webpage reports in its metadata
+const (
+	ExtractionStructured  = "structured"  // Headings and paragraphs of the page's main content element
+	ExtractionReadability = "readability" // The block that scored best by text, commas and link density
+	ExtractionSimple      = "simple"      // All of the page's text, when both others fail
+)
+
+// minExtractionQuality is the score below which the readability strategy is
+// tried as well
+const minExtractionQuality = 0.5
+
+// ExtractionQuality rates how much an extraction looks like article text
+// rather than navigation or boilerplate
+type ExtractionQuality struct {
+	Score           float64 // 0 (junk) to 1 (clean article text)
+	LinkDensity     float64 // Share of the text that is link text
+	TextRatio       float64 // Text characters per character of markup
+	BoilerplateHits int     // Boilerplate phrases found in the text
+}
+
+var (
+	anchorRegex       = regexp.MustCompile(`(?is)<a\b[^>]*>(.*?)</a>`)
+	boilerplatePhrase = regexp.MustCompile(`(?i)\b(sign in|log in|sign up|subscribe|newsletter|cookies?|privacy policy|terms of (service|use)|all rights reserved|skip to (main )?content|share on|follow us|menu|advertisement|related (articles|posts)|read more)\b`)
+)
+
+// scoreExtraction rates an extraction from the HTML it came from. Dense
+// links, little text per tag, boilerplate phrases and a short result each
+// lower the score.
+func scoreExtraction(content *StructuredContent) ExtractionQuality {
+	var q ExtractionQuality
+	text := inlineText(content.contentHTML)
+	if text == "" || content.TextLength == 0 {
+		return q
+	}
+
+	linkChars := 0
+	for _, anchor := range anchorRegex.FindAllStringSubmatch(content.contentHTML, -1) {
+		linkChars += len(inlineText(anchor[1]))
+	}
+	q.LinkDensity = min(float64(linkChars)/float64(len(text)), 1)
+	q.TextRatio = float64(len(text)) / float64(len(content.contentHTML))
+	q.BoilerplateHits = len(boilerplatePhrase.FindAllStringIndex(text, -1))
+
+	// Articles run around a quarter text or more; boilerplate is judged per 1,000 characters
+	boilerplateRate := float64(q.BoilerplateHits) * 1000 / float64(len(text))
+	q.Score = 0.5*(1-q.LinkDensity) + 0.3*min(q.TextRatio/0.25, 1) + 0.2*(1-min(boilerplateRate/3, 1))
+	if content.TextLength < 500 {
+		q.Score *= float64(content.TextLength) / 500
+	}
+	return q
+}
+
+// chooseExtraction runs the structured extraction and, when its quality is
+// low, the readability one too, keeping whichever scores higher. The chosen
+// strategy and its score are recorded in the metadata.
+func chooseExtraction(htmlContent string, maxLength int) (*StructuredContent, ExtractionQuality) {
+	content := extractStructuredContent(htmlContent, maxLength)
+	quality := scoreExtraction(content)
+	strategy := ExtractionStructured
+
+	if quality.Score < minExtractionQuality {
+		readable := extractContentWith(htmlContent, maxLength, findReadableContent)
+		if readableQuality := scoreExtraction(readable); readableQuality.Score > quality.Score {
+			content, quality, strategy = readable, readableQuality, ExtractionReadability
+		}
+	}
+
+	content.Metadata["extraction_strategy"] = strategy
+	content.Metadata["extraction_score"] = strconv.FormatFloat(quality.Score, 'f', 2, 64)
+	return content, quality
+}
+
+// readabilityContainers are the elements the readability strategy scores
+var readabilityContainers = map[string]bool{
+	"article": true, "main": true, "section": true, "div": true, "td": true, "body": true,
+}
+
+var (
+	readabilityTagRegex = regexp.MustCompile(`(?is)<(/?)([a-z][a-z0-9]*)\b([^>]*)>`)
+	positiveClassRegex  = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|blog|story`)
+	negativeClassRegex  = regexp.MustCompile(`(?i)comment|footer|sidebar|sponsor|nav|menu|share|related|promo|header|widget|banner|social|\bad\b|ad-`)
+	classAttrRegex      = regexp.MustCompile(`(?is)\b(?:class|id)\s*=\s*["']([^"']*)["']`)
+)
+
+// readabilityNode is a container element being scored
+type readabilityNode struct {
+	tag       string
+	start     int // Offset of the end of its opening tag
+	end       int // Offset of its closing tag; 0 while open
+	parent    int // Index of the enclosing container, -1 at the top
+	score     float64
+	textChars int
+	linkChars int
+}
+
+// findReadableContent picks the block of the page that most looks like the
+// article, in the manner of readability tools: each paragraph scores for
+// its length and commas, which counts toward its container and, at half
+// weight, the container's parent. Class names and ids like "content" or
+// "sidebar" nudge the score, and link-heavy blocks are discounted. It
+// returns the inner HTML of the best block, or the whole page when no
+// block has paragraphs.
+func findReadableContent(htmlContent string) string {
+	var nodes []readabilityNode
+	var stack []int // Open containers, innermost last
+	linkDepth := 0
+	paragraphStart := -1
+
+	addText := func(text string) {
+		n := len(strings.TrimSpace(text))
+		for _, i := range stack {
+			nodes[i].textChars += n
+			if linkDepth > 0 {
+				nodes[i].linkChars += n
+			}
+		}
+	}
+
+	pos := 0
+	for _, m := range readabilityTagRegex.FindAllStringSubmatchIndex(htmlContent, -1) {
+		addText(decodeHTMLEntities(htmlContent[pos:m[0]]))
+		pos = m[1]
+		closing := m[3] > m[2]
+		tag := strings.ToLower(htmlContent[m[4]:m[5]])
+
+		switch {
+		case tag == "a":
+			if closing {
+				linkDepth = max(linkDepth-1, 0)
+			} else {
+				linkDepth++
+			}
+		case tag == "p":
+			if !closing {
+				paragraphStart = m[1]
+			} else if paragraphStart >= 0 && len(stack) > 0 {
+				scoreParagraph(nodes, stack, inlineText(htmlContent[paragraphStart:m[0]]))
+				paragraphStart = -1
+			}
+		case readabilityContainers[tag] && !closing:
+			parent := -1
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			nodes = append(nodes, readabilityNode{tag: tag, start: m[1], parent: parent, score: classWeight(htmlContent[m[6]:m[7]])})
+			stack = append(stack, len(nodes)-1)
+		case readabilityContainers[tag] && closing:
+			// Close the innermost open element with this tag, and any left unclosed inside it
+			for i := len(stack) - 1; i >= 0; i-- {
+				if nodes[stack[i]].tag == tag {
+					for _, open := range stack[i:] {
+						nodes[open].end = m[0]
+					}
+					stack = stack[:i]
+					break
+				}
+			}
+		}
+	}
+	for _, open := range stack {
+		nodes[open].end = len(htmlContent)
+	}
+
+	best, bestScore := -1, 0.0
+	for i, node := range nodes {
+		if node.score <= 0 || node.textChars == 0 {
+			continue
+		}
+		score := node.score * (1 - float64(node.linkChars)/float64(node.textChars))
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return htmlContent
+	}
+	return htmlContent[nodes[best].start:nodes[best].end]
+}
+
+// scoreParagraph credits a paragraph to the innermost open container and,
+// at half weight, its parent. Very short paragraphs are usually captions
+// or buttons and score nothing.
+func scoreParagraph(nodes []readabilityNode, stack []int, text string) {
+	if len(text) < 25 {
+		return
+	}
+	score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+	container := stack[len(stack)-1]
+	nodes[container].score += score
+	if parent := nodes[container].parent; parent >= 0 {
+		nodes[parent].score += score / 2
+	}
+}
+
+// classWeight is a container's starting score from its class and id
+func classWeight(attributes string) float64 {
+	weight := 0.0
+	for _, match := range classAttrRegex.FindAllStringSubmatch(attributes, -1) {
+		if negativeClassRegex.MatchString(match[1]) {
+			weight -= 25
+		}
+		if positiveClassRegex.MatchString(match[1]) {
+			weight += 25
+		}
+	}
+	return weight
+}
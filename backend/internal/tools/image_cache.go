@@ -0,0 +1,215 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ezra-clone/backend/internal/cache"
+	"ezra-clone/backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// imageSweepInterval is how often the sweeper removes expired images
+const imageSweepInterval = time.Hour
+
+// imageCacheSubdir is the directory under the output directory the cache
+// keeps its images in. Only files there are ever evicted or cleared, so
+// other files in the output directory are left alone.
+const imageCacheSubdir = "generated"
+
+// ErrImageTooLarge is returned when an image exceeds the per-image size limit
+var ErrImageTooLarge = errors.New("image exceeds the maximum stored image size")
+
+// ImageRetention limits what the generated image cache keeps. Zero values
+// disable the corresponding limit.
+type ImageRetention struct {
+	MaxCount      int           // Most images kept; the oldest are evicted first
+	MaxAge        time.Duration // Images older than this are removed by Sweep
+	MaxTotalBytes int64         // Combined size of all kept images
+	MaxImageBytes int64         // Larger images are not stored at all
+}
+
+// ImageCache stores generated images on disk so they can be re-sent or
+// regenerated from, evicting the oldest once retention limits are exceeded
+type ImageCache struct {
+	dir       string
+	retention ImageRetention
+	mu        sync.Mutex
+	logger    *zap.Logger
+}
+
+// cachedImage is a stored image file as seen during eviction
+type cachedImage struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// NewImageCache creates a cache storing images in a subdirectory of
+// outputDir that it owns
+func NewImageCache(outputDir string, retention ImageRetention) *ImageCache {
+	return &ImageCache{
+		dir:       filepath.Join(outputDir, imageCacheSubdir),
+		retention: retention,
+		logger:    logger.Get(),
+	}
+}
+
+// Store saves an image under name (e.g. "<job id>.png") and evicts old images
+// that push the cache over its count or size limit. Returns the file path.
+func (c *ImageCache) Store(name string, data []byte) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid image name: %q", name)
+	}
+	if c.retention.MaxImageBytes > 0 && int64(len(data)) > c.retention.MaxImageBytes {
+		return "", ErrImageTooLarge
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create image directory: %w", err)
+	}
+	path := filepath.Join(c.dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write image: %w", err)
+	}
+
+	if _, err := c.evict(time.Now()); err != nil {
+		return path, err
+	}
+	return path, nil
+}
+
+// Sweep removes expired images and enforces the count and size limits.
+// Returns how many images were removed.
+func (c *ImageCache) Sweep() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.evict(time.Now())
+}
+
+// StartSweeper runs Sweep periodically until the returned stop function is called
+func (c *ImageCache) StartSweeper() func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(imageSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if removed, err := c.Sweep(); err != nil {
+					c.logger.Warn("Failed to sweep image cache", zap.Error(err))
+				} else if removed > 0 {
+					c.logger.Debug("Swept image cache", zap.Int("removed", removed))
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// list returns the stored images, newest first. Callers must hold c.mu.
+func (c *ImageCache) list() ([]cachedImage, error) {
+	entries, err := os.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached images: %w", err)
+	}
+
+	images := make([]cachedImage, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		images = append(images, cachedImage{
+			path:    filepath.Join(c.dir, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].modTime.After(images[j].modTime)
+	})
+	return images, nil
+}
+
+// Stats reports how many images are stored and their combined size
+func (c *ImageCache) Stats() cache.Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	images, err := c.list()
+	if err != nil {
+		c.logger.Warn("Failed to read image cache stats", zap.Error(err))
+	}
+	stats := cache.Stats{Entries: len(images)}
+	for _, img := range images {
+		stats.Bytes += img.size
+	}
+	return stats
+}
+
+// Clear removes every image the cache stored
+func (c *ImageCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	images, err := c.list()
+	if err != nil {
+		return err
+	}
+	for _, img := range images {
+		if err := os.Remove(img.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cached image: %w", err)
+		}
+	}
+	return nil
+}
+
+// evict removes images past MaxAge, then the oldest until the count and
+// size limits hold. Callers must hold c.mu.
+func (c *ImageCache) evict(now time.Time) (int, error) {
+	// Newest first, so everything past the limits is the oldest
+	images, err := c.list()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	var kept int
+	var keptBytes int64
+	for _, img := range images {
+		expired := c.retention.MaxAge > 0 && now.Sub(img.modTime) > c.retention.MaxAge
+		overCount := c.retention.MaxCount > 0 && kept >= c.retention.MaxCount
+		overSize := c.retention.MaxTotalBytes > 0 && keptBytes+img.size > c.retention.MaxTotalBytes
+		if !expired && !overCount && !overSize {
+			kept++
+			keptBytes += img.size
+			continue
+		}
+		if err := os.Remove(img.path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to evict cached image: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
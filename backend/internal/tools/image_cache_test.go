@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// age backdates a cached image so eviction order doesn't depend on timing
+func age(t *testing.T, path string, d time.Duration) {
+	when := time.Now().Add(-d)
+	require.NoError(t, os.Chtimes(path, when, when))
+}
+
+func TestImageCache_EvictsOldestOverCount(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewImageCache(dir, ImageRetention{MaxCount: 2})
+
+	oldest, err := cache.Store("job-1.png", []byte("one"))
+	require.NoError(t, err)
+	age(t, oldest, 2*time.Hour)
+	middle, err := cache.Store("job-2.png", []byte("two"))
+	require.NoError(t, err)
+	age(t, middle, time.Hour)
+
+	_, err = cache.Store("job-3.png", []byte("three"))
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, oldest)
+	assert.FileExists(t, middle)
+	assert.FileExists(t, filepath.Join(dir, imageCacheSubdir, "job-3.png"))
+}
+
+func TestImageCache_LeavesOtherFilesAlone(t *testing.T) {
+	dir := t.TempDir()
+	foreign := filepath.Join(dir, "workflow-output.png")
+	require.NoError(t, os.WriteFile(foreign, []byte("not ours"), 0644))
+	age(t, foreign, 48*time.Hour)
+
+	cache := NewImageCache(dir, ImageRetention{MaxCount: 1, MaxAge: 24 * time.Hour})
+	stored, err := cache.Store("job-1.png", []byte("one"))
+	require.NoError(t, err)
+
+	removed, err := cache.Sweep()
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+	assert.Equal(t, 1, cache.Stats().Entries)
+
+	require.NoError(t, cache.Clear())
+	assert.NoFileExists(t, stored)
+	assert.FileExists(t, foreign)
+}
+
+func TestImageCache_SweepRemovesExpired(t *testing.T) {
+	cache := NewImageCache(t.TempDir(), ImageRetention{MaxAge: 24 * time.Hour})
+
+	stale, err := cache.Store("stale.png", []byte("old"))
+	require.NoError(t, err)
+	fresh, err := cache.Store("fresh.png", []byte("new"))
+	require.NoError(t, err)
+	age(t, stale, 48*time.Hour)
+
+	removed, err := cache.Sweep()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, fresh)
+}
+
+func TestImageCache_RejectsOversizedImage(t *testing.T) {
+	cache := NewImageCache(t.TempDir(), ImageRetention{MaxImageBytes: 4})
+
+	_, err := cache.Store("big.png", []byte("too big"))
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+	_, err = cache.Store("../escape.png", []byte("x"))
+	assert.Error(t, err)
+}
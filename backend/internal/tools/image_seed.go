@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// MaxImageSeed is the largest seed accepted for image generation
+const MaxImageSeed = 1<<32 - 1
+
+// DefaultImageMaxBatch caps how many variations one call may generate when
+// IMAGE_MAX_BATCH isn't set
+const DefaultImageMaxBatch = 4
+
+// integerArg converts a JSON number argument to an int, rejecting fractions
+func integerArg(name string, value interface{}) (int, error) {
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("%s must be a whole number", name)
+		}
+		if math.Abs(v) > 1<<53 {
+			return 0, fmt.Errorf("%s is out of range", name)
+		}
+		return int(v), nil
+	case int:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+}
+
+// resolveImageSeed returns the seed argument, or a random seed when it is
+// omitted, so the seed that produced an image can always be reported back
+func resolveImageSeed(arg interface{}) (int, error) {
+	if arg == nil {
+		return rand.Intn(MaxImageSeed + 1), nil
+	}
+	seed, err := integerArg("seed", arg)
+	if err != nil {
+		return 0, err
+	}
+	if seed < 0 || seed > MaxImageSeed {
+		return 0, fmt.Errorf("seed must be between 0 and %d", MaxImageSeed)
+	}
+	return seed, nil
+}
+
+// resolveImageBatch returns how many variations to generate, 1 when the
+// argument is omitted
+func resolveImageBatch(arg interface{}, maxBatch int) (int, error) {
+	if maxBatch <= 0 {
+		maxBatch = DefaultImageMaxBatch
+	}
+	if arg == nil {
+		return 1, nil
+	}
+	batch, err := integerArg("batch", arg)
+	if err != nil {
+		return 0, err
+	}
+	if batch < 1 || batch > maxBatch {
+		return 0, fmt.Errorf("batch must be between 1 and %d", maxBatch)
+	}
+	return batch, nil
+}
+
+// variationSeed is the seed of the i-th variation in a batch: sequential
+// from seed, wrapping past MaxImageSeed
+func variationSeed(seed, i int) int {
+	return (seed + i) % (MaxImageSeed + 1)
+}
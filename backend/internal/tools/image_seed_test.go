@@ -0,0 +1,40 @@
+package tools
+
+import "testing"
+
+func TestResolveImageSeed(t *testing.T) {
+	if seed, err := resolveImageSeed(float64(42)); err != nil || seed != 42 {
+		t.Errorf("Expected the given seed, got %d (%v)", seed, err)
+	}
+	if seed, err := resolveImageSeed(nil); err != nil || seed < 0 || seed > MaxImageSeed {
+		t.Errorf("Expected a random seed in range, got %d (%v)", seed, err)
+	}
+	for _, bad := range []interface{}{float64(-1), float64(MaxImageSeed + 1), 1.5, "42"} {
+		if _, err := resolveImageSeed(bad); err == nil {
+			t.Errorf("Expected seed %v to be rejected", bad)
+		}
+	}
+}
+
+func TestResolveImageBatch(t *testing.T) {
+	if batch, err := resolveImageBatch(nil, 4); err != nil || batch != 1 {
+		t.Errorf("Expected a single image by default, got %d (%v)", batch, err)
+	}
+	if batch, err := resolveImageBatch(float64(4), 4); err != nil || batch != 4 {
+		t.Errorf("Expected a batch at the cap to be allowed, got %d (%v)", batch, err)
+	}
+	for _, bad := range []interface{}{float64(0), float64(5)} {
+		if _, err := resolveImageBatch(bad, 4); err == nil {
+			t.Errorf("Expected batch %v to be rejected", bad)
+		}
+	}
+}
+
+func TestVariationSeed(t *testing.T) {
+	if got := variationSeed(100, 2); got != 102 {
+		t.Errorf("Expected sequential seeds, got %d", got)
+	}
+	if got := variationSeed(MaxImageSeed, 1); got != 0 {
+		t.Errorf("Expected seeds to wrap past the maximum, got %d", got)
+	}
+}
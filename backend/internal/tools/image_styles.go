@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StyleNone turns off the agent's default style preset for one generation
+const StyleNone = "none"
+
+// minNegativeCFG is the guidance used when a negative prompt is given with a
+// cfg of 1 or less, where KSampler ignores the negative conditioning
+const minNegativeCFG = 1.5
+
+// SamplerSettings are the KSampler settings a generation runs with
+type SamplerSettings struct {
+	Steps     int
+	CFG       float64
+	Sampler   string
+	Scheduler string
+}
+
+// DefaultSamplerSettings suit the programmatic Z-Image Turbo workflow
+var DefaultSamplerSettings = SamplerSettings{Steps: 4, CFG: 1.0, Sampler: "res_multistep", Scheduler: "simple"}
+
+// StylePreset is a curated look: prompt fragments added around the user's
+// prompt and the sampler settings that suit it
+type StylePreset struct {
+	Name     string
+	Positive string // Appended to the prompt
+	Negative string // Added to the negative prompt
+	Sampler  SamplerSettings
+}
+
+// stylePresets are the built-in presets, keyed by name
+var stylePresets = map[string]StylePreset{
+	"photorealistic": {
+		Name:     "photorealistic",
+		Positive: "photorealistic photograph, natural lighting, sharp focus, realistic skin and material textures, shot on a full-frame camera with a 50mm lens",
+		Negative: "illustration, painting, drawing, cartoon, anime, 3d render, cgi, plastic skin, oversaturated, blurry, deformed hands, extra fingers, watermark, text",
+		Sampler:  SamplerSettings{Steps: 8, CFG: 1.5, Sampler: "res_multistep", Scheduler: "simple"},
+	},
+	"anime": {
+		Name:     "anime",
+		Positive: "anime illustration, clean line art, cel shading, vibrant colors, expressive eyes, detailed background",
+		Negative: "photorealistic, photograph, 3d render, realistic skin, muddy colors, sketchy lines, blurry, deformed, extra limbs, watermark, text",
+		Sampler:  SamplerSettings{Steps: 6, CFG: 1.5, Sampler: "euler_ancestral", Scheduler: "simple"},
+	},
+	"oil-painting": {
+		Name:     "oil-painting",
+		Positive: "oil painting on canvas, visible brush strokes, rich impasto texture, classical composition, warm glazed colors",
+		Negative: "photograph, photorealistic, digital art, flat colors, smooth gradients, anime, cartoon, blurry, watermark, text",
+		Sampler:  SamplerSettings{Steps: 8, CFG: 2.0, Sampler: "dpmpp_2m", Scheduler: "karras"},
+	},
+	"watercolor": {
+		Name:     "watercolor",
+		Positive: "watercolor painting on textured paper, soft washes, bleeding edges, light translucent layers, loose brushwork",
+		Negative: "photograph, photorealistic, 3d render, hard edges, heavy outlines, oversaturated, digital noise, watermark, text",
+		Sampler:  SamplerSettings{Steps: 6, CFG: 1.5, Sampler: "euler", Scheduler: "simple"},
+	},
+	"pixel-art": {
+		Name:     "pixel-art",
+		Positive: "pixel art, 16-bit retro game style, limited color palette, crisp pixel edges, no anti-aliasing",
+		Negative: "photograph, photorealistic, smooth shading, gradients, blurry, anti-aliased, painterly, 3d render, watermark, text",
+		Sampler:  SamplerSettings{Steps: 6, CFG: 1.5, Sampler: "euler", Scheduler: "simple"},
+	},
+}
+
+// normalizeStyleName lowercases a preset name and joins words with hyphens,
+// so "Oil Painting" and "oil_painting" match "oil-painting"
+func normalizeStyleName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "-", "_", "-").Replace(name)
+}
+
+// LookupStylePreset returns the preset with the given name
+func LookupStylePreset(name string) (StylePreset, bool) {
+	preset, ok := stylePresets[normalizeStyleName(name)]
+	return preset, ok
+}
+
+// StylePresetNames lists the built-in presets, sorted
+func StylePresetNames() []string {
+	names := make([]string, 0, len(stylePresets))
+	for name := range stylePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateStylePreset checks that name is empty, "none" or a known preset
+func ValidateStylePreset(name string) error {
+	if name == "" || normalizeStyleName(name) == StyleNone {
+		return nil
+	}
+	if _, ok := LookupStylePreset(name); !ok {
+		return fmt.Errorf("unknown style preset %q (use %s or none)", name, strings.Join(StylePresetNames(), ", "))
+	}
+	return nil
+}
+
+// ImagePrompts are the prompts and settings a generation is sent with
+type ImagePrompts struct {
+	Style    string // Preset applied, or "" for none
+	Positive string
+	Negative string
+	Sampler  SamplerSettings
+}
+
+// ResolveImagePrompts combines the prompt and negative prompt with a style
+// preset. requested is the preset named for this generation and fallback the
+// agent's default; "none" in either skips presets.
+func ResolveImagePrompts(prompt, negativePrompt, requested, fallback string) (ImagePrompts, error) {
+	name := requested
+	if name == "" {
+		name = fallback
+	}
+
+	resolved := ImagePrompts{
+		Positive: strings.TrimSpace(prompt),
+		Negative: strings.TrimSpace(negativePrompt),
+		Sampler:  DefaultSamplerSettings,
+	}
+	if name != "" && normalizeStyleName(name) != StyleNone {
+		preset, ok := LookupStylePreset(name)
+		if !ok {
+			return ImagePrompts{}, ValidateStylePreset(name)
+		}
+		resolved.Style = preset.Name
+		resolved.Positive = joinPromptParts(resolved.Positive, preset.Positive)
+		resolved.Negative = joinPromptParts(resolved.Negative, preset.Negative)
+		resolved.Sampler = preset.Sampler
+	}
+
+	if resolved.Negative != "" && resolved.Sampler.CFG <= 1 {
+		resolved.Sampler.CFG = minNegativeCFG
+	}
+	return resolved, nil
+}
+
+// joinPromptParts joins non-empty prompt fragments with commas
+func joinPromptParts(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part = strings.Trim(strings.TrimSpace(part), ","); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveImagePrompts(t *testing.T) {
+	prompts, err := ResolveImagePrompts("a lighthouse at dusk", "people", "", "Oil Painting")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prompts.Style != "oil-painting" {
+		t.Errorf("Expected the agent default preset to apply, got %q", prompts.Style)
+	}
+	if !strings.HasPrefix(prompts.Positive, "a lighthouse at dusk, oil painting") {
+		t.Errorf("Expected the preset fragment after the prompt, got %q", prompts.Positive)
+	}
+	if !strings.HasPrefix(prompts.Negative, "people, ") || !strings.Contains(prompts.Negative, "photorealistic") {
+		t.Errorf("Expected the negative prompt combined with the preset's, got %q", prompts.Negative)
+	}
+	if prompts.Sampler.Sampler != "dpmpp_2m" {
+		t.Errorf("Expected the preset's sampler settings, got %+v", prompts.Sampler)
+	}
+
+	// "none" overrides the agent default; a negative prompt needs cfg above 1
+	prompts, err = ResolveImagePrompts("a lighthouse", "fog", StyleNone, "anime")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prompts.Style != "" || prompts.Positive != "a lighthouse" {
+		t.Errorf("Expected no preset, got %+v", prompts)
+	}
+	if prompts.Sampler.CFG <= 1 {
+		t.Errorf("Expected cfg raised for the negative prompt, got %v", prompts.Sampler.CFG)
+	}
+
+	if _, err := ResolveImagePrompts("a lighthouse", "", "vaporwave", ""); err == nil {
+		t.Error("Expected an unknown preset to be rejected")
+	}
+}
+
+func TestZImageTurboWorkflow_NegativePrompt(t *testing.T) {
+	seed := 7
+	nodes := func(prompts ImagePrompts) map[string]interface{} {
+		return CreateZImageTurboWorkflow(prompts, &seed, 512, 512)["workflow"].(map[string]interface{})
+	}
+
+	plain := nodes(ImagePrompts{Positive: "a cat", Sampler: DefaultSamplerSettings})
+	if plain["42"].(map[string]interface{})["class_type"] != "ConditioningZeroOut" {
+		t.Error("Expected zeroed-out negative conditioning without a negative prompt")
+	}
+
+	negative := nodes(ImagePrompts{Positive: "a cat", Negative: "dogs", Sampler: DefaultSamplerSettings})
+	node := negative["42"].(map[string]interface{})
+	if node["class_type"] != "CLIPTextEncode" || node["inputs"].(map[string]interface{})["text"] != "dogs" {
+		t.Errorf("Expected the negative prompt to be encoded, got %+v", node)
+	}
+}
+
+func TestPrepareWorkflowForAPI_NegativePrompt(t *testing.T) {
+	workflow := map[string]interface{}{
+		"1": map[string]interface{}{"class_type": "CLIPTextEncode", "inputs": map[string]interface{}{"text": "old positive"}},
+		"2": map[string]interface{}{"class_type": "CLIPTextEncode", "inputs": map[string]interface{}{"text": "workflow negative"}},
+		"3": map[string]interface{}{"class_type": "KSampler", "inputs": map[string]interface{}{
+			"positive": []interface{}{"1", 0},
+			"negative": []interface{}{"2", 0},
+			"steps":    20,
+		}},
+	}
+	text := func(prepared map[string]interface{}, id string) interface{} {
+		nodes := prepared["workflow"].(map[string]interface{})
+		return nodes[id].(map[string]interface{})["inputs"].(map[string]interface{})["text"]
+	}
+
+	prepared, err := PrepareWorkflowForAPI(workflow, ImagePrompts{Positive: "a cat", Sampler: DefaultSamplerSettings}, nil, 512, 512)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text(prepared, "1") != "a cat" || text(prepared, "2") != "workflow negative" {
+		t.Errorf("Expected only the positive encoder to be overwritten, got %q and %q", text(prepared, "1"), text(prepared, "2"))
+	}
+	steps := prepared["workflow"].(map[string]interface{})["3"].(map[string]interface{})["inputs"].(map[string]interface{})["steps"]
+	if steps != 20 {
+		t.Errorf("Expected the workflow's sampler settings kept without a preset, got steps %v", steps)
+	}
+
+	prepared, _ = PrepareWorkflowForAPI(workflow, ImagePrompts{Positive: "a cat", Negative: "dogs", Sampler: DefaultSamplerSettings}, nil, 512, 512)
+	if text(prepared, "2") != "dogs" {
+		t.Errorf("Expected the negative encoder to get the negative prompt, got %q", text(prepared, "2"))
+	}
+}
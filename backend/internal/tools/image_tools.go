@@ -33,7 +33,20 @@ func GetImageGenerationTools() []adapter.Tool {
 						},
 						"seed": map[string]interface{}{
 							"type":        "integer",
-							"description": "Random seed for reproducibility (optional, random if not provided)",
+							"description": "Seed from 0 to 4294967295; the same prompt and seed give the same image (optional, random if not provided; the seed used is returned)",
+						},
+						"batch": map[string]interface{}{
+							"type":        "integer",
+							"description": "Number of variations to generate from sequential seeds (optional, default 1, capped by the server)",
+						},
+						"style_preset": map[string]interface{}{
+							"type":        "string",
+							"enum":        append(StylePresetNames(), StyleNone),
+							"description": "Style preset adding prompt fragments, a negative prompt and sampler settings (optional, defaults to the agent's configured preset; \"none\" skips it)",
+						},
+						"negative_prompt": map[string]interface{}{
+							"type":        "string",
+							"description": "What the image should not contain, e.g. \"blurry, text, extra fingers\" (optional, combined with the style preset's negative prompt)",
 						},
 					},
 					"required": []string{"prompt"},
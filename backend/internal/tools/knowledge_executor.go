@@ -39,6 +39,110 @@ func (e *Executor) executeCreateFact(ctx context.Context, execCtx *ExecutionCont
 	}
 }
 
+func (e *Executor) executePinFact(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	factID, _ := args["fact_id"].(string)
+	if factID == "" {
+		return &ToolResult{Success: false, Error: "fact_id is required"}
+	}
+
+	pinned := true
+	if p, ok := args["pinned"].(bool); ok {
+		pinned = p
+	}
+
+	if err := e.repo.SetFactPinned(ctx, execCtx.AgentID, factID, pinned); err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+
+	message := fmt.Sprintf("Fact %s pinned", factID)
+	if !pinned {
+		message = fmt.Sprintf("Fact %s unpinned", factID)
+	}
+	return &ToolResult{
+		Success: true,
+		Data:    map[string]interface{}{"fact_id": factID, "pinned": pinned},
+		Message: message,
+	}
+}
+
+func (e *Executor) executeGetFact(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	factID, _ := args["fact_id"].(string)
+	if factID == "" {
+		return &ToolResult{Success: false, Error: "fact_id is required"}
+	}
+
+	fact, err := e.repo.GetFact(ctx, execCtx.AgentID, factID)
+	if err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+
+	return &ToolResult{
+		Success: true,
+		Data:    fact,
+		Message: fmt.Sprintf("Fact %s: %s", factID, fact.Content),
+	}
+}
+
+// executeUpdateFact replaces a fact's content, returning the content before
+// and after so the agent can confirm the correction
+func (e *Executor) executeUpdateFact(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	factID, _ := args["fact_id"].(string)
+	if factID == "" {
+		return &ToolResult{Success: false, Error: "fact_id is required"}
+	}
+	content, _ := args["content"].(string)
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return &ToolResult{Success: false, Error: "content is required"}
+	}
+
+	// Only the agent's own facts can be changed
+	fact, err := e.repo.GetFact(ctx, execCtx.AgentID, factID)
+	if err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+	data := map[string]interface{}{"fact_id": factID, "before": fact.Content, "after": content}
+	if fact.Content == content {
+		return &ToolResult{Success: true, Data: data, Message: "Fact already says that; nothing changed"}
+	}
+
+	if err := e.repo.UpdateFact(ctx, factID, content); err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+
+	return &ToolResult{
+		Success: true,
+		Data:    data,
+		Message: fmt.Sprintf("Fact updated from %q to %q", fact.Content, content),
+	}
+}
+
+// executeDeleteFact deletes one of the agent's facts, returning its content
+func (e *Executor) executeDeleteFact(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	factID, _ := args["fact_id"].(string)
+	if factID == "" {
+		return &ToolResult{Success: false, Error: "fact_id is required"}
+	}
+
+	fact, err := e.repo.GetFact(ctx, execCtx.AgentID, factID)
+	if err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+	if fact.Pinned {
+		return &ToolResult{Success: false, Error: fmt.Sprintf("fact %s is pinned; unpin it with pin_fact first", factID)}
+	}
+
+	if err := e.repo.DeleteFact(ctx, factID); err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+
+	return &ToolResult{
+		Success: true,
+		Data:    map[string]interface{}{"fact_id": factID, "before": fact.Content},
+		Message: fmt.Sprintf("Fact deleted: %s", fact.Content),
+	}
+}
+
 func (e *Executor) executeSearchFacts(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
 	topic, _ := args["topic"].(string)
 	if topic == "" {
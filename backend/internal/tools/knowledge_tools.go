@@ -67,6 +67,82 @@ func GetKnowledgeTools() []adapter.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolPinFact,
+				Description: "Pin a critical fact (allergies, names, medical info) so it is never removed by memory cleanup, or unpin it. Get the fact ID from get_user_context or search_facts first.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"fact_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The ID of the fact to pin",
+						},
+						"pinned": map[string]interface{}{
+							"type":        "boolean",
+							"description": "true to pin (default), false to unpin",
+						},
+					},
+					"required": []string{"fact_id"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolGetFact,
+				Description: "Read one fact by its ID, with its source, confidence and whether it is pinned. Fact IDs come from memory_search, search_facts or get_user_context results.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"fact_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The ID of the fact to read",
+						},
+					},
+					"required": []string{"fact_id"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolUpdateFact,
+				Description: "Correct a fact you have stored, e.g. when a user says \"that's wrong, I actually prefer X\". Find the fact's ID with memory_search or get_user_context first. Returns the content before and after, so you can confirm the change to the user.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"fact_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The ID of the fact to correct",
+						},
+						"content": map[string]interface{}{
+							"type":        "string",
+							"description": "The corrected fact, as a complete statement",
+						},
+					},
+					"required": []string{"fact_id", "content"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolDeleteFact,
+				Description: "Delete a fact that is wrong and has no correct version, or that a user asks you to forget. Pinned facts must be unpinned with pin_fact first. Returns the deleted content.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"fact_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The ID of the fact to delete",
+						},
+					},
+					"required": []string{"fact_id"},
+				},
+			},
+		},
 	}
 }
 
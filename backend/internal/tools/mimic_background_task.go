@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"ezra-clone/backend/internal/adapter"
@@ -21,6 +22,7 @@ type MimicBackgroundTask struct {
 	stopChan      chan struct{}
 	running       bool
 	agentID       string
+	schedule      *MimicSchedule // nil posts at any hour without a cap
 }
 
 // NewMimicBackgroundTask creates a new background task manager
@@ -36,6 +38,12 @@ func NewMimicBackgroundTask(executor *Executor, llm *adapter.LLMAdapter, discord
 	}
 }
 
+// SetSchedule limits when and how often the task posts. Pass nil to post at
+// any hour without a cap.
+func (m *MimicBackgroundTask) SetSchedule(schedule *MimicSchedule) {
+	m.schedule = schedule
+}
+
 // Start begins the background task for an agent
 func (m *MimicBackgroundTask) Start(agentID string) {
 	if m.running {
@@ -121,6 +129,15 @@ func (m *MimicBackgroundTask) handleChannelMessage(s *discordgo.Session, msg *di
 		return
 	}
 
+	// Stay quiet outside active hours or once the daily cap is spent; the
+	// handler stays registered so posting resumes on its own
+	if !m.schedule.CanPost(time.Now()) {
+		m.logger.Debug("Mimic outside active hours or over daily cap, skipping",
+			zap.String("agent_id", m.agentID),
+		)
+		return
+	}
+
 	// Get mimic state
 	mimicState := m.executor.GetMimicState(m.agentID)
 	if mimicState == nil || !mimicState.Active || mimicState.MimicProfile == nil {
@@ -134,7 +151,7 @@ func (m *MimicBackgroundTask) handleChannelMessage(s *discordgo.Session, msg *di
 	}
 
 	profile := mimicState.MimicProfile
-	ctx := context.Background()
+	ctx := m.executor.withAgentModels(context.Background(), m.agentID)
 
 	// Check if this is a direct reply to the bot
 	isDirectReply := false
@@ -194,6 +211,12 @@ func (m *MimicBackgroundTask) handleChannelMessage(s *discordgo.Session, msg *di
 		return
 	}
 
+	// Mimic replies skip the turn path, so they are filtered here
+	response, _ = m.executor.FilterOutput(ctx, m.agentID, msg.ChannelID, response)
+	if response == "" {
+		return
+	}
+
 	// Post response
 	_, err = s.ChannelMessageSend(msg.ChannelID, response)
 	if err != nil {
@@ -202,6 +225,7 @@ func (m *MimicBackgroundTask) handleChannelMessage(s *discordgo.Session, msg *di
 		)
 		return
 	}
+	m.schedule.RecordPost(time.Now())
 
 	m.logger.Info("Mimic response sent",
 		zap.String("agent_id", m.agentID),
@@ -261,7 +285,7 @@ For normal conversation, questions, or anything you might naturally engage with,
 
 	// Use the style prompt as system prompt - this makes the LLM think AS the person, not as a bot
 	// The style prompt already says "You ARE [username]" and includes all their personality traits
-	response, err := m.llm.Generate(ctx, profile.StylePrompt, prompt, []adapter.Tool{ignoreTool})
+	response, err := m.llm.Generate(ctx, adapter.GenerateParams{Auxiliary: true}, profile.StylePrompt, prompt, []adapter.Tool{ignoreTool})
 	if err != nil {
 		return false, err
 	}
@@ -348,7 +372,7 @@ Write naturally as yourself - be authentic to your own communication style and r
 	)
 
 	// Use the style prompt as system prompt and the response request as user message
-	response, err := m.llm.Generate(ctx, profile.StylePrompt, prompt, []adapter.Tool{})
+	response, err := m.llm.Generate(ctx, adapter.GenerateParams{Auxiliary: true}, profile.StylePrompt, prompt, []adapter.Tool{})
 	if err != nil {
 		return "", err
 	}
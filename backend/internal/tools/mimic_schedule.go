@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"sync"
+	"time"
+)
+
+// MimicSchedule limits mimic mode to active hours and a daily number of
+// posts, so it doesn't chat overnight or flood the channel
+type MimicSchedule struct {
+	fromHour  int // First active hour, 0-23
+	untilHour int // Hour activity ends, 1-24; at or before fromHour wraps past midnight
+	location  *time.Location
+	dailyCap  int // 0 is unlimited
+
+	mu    sync.Mutex
+	day   string // Day posts was counted on, in location
+	posts int
+}
+
+// NewMimicSchedule creates a schedule active from fromHour until untilHour in
+// location, allowing dailyCap posts a day (0 is unlimited). A nil location
+// uses UTC.
+func NewMimicSchedule(fromHour, untilHour int, location *time.Location, dailyCap int) *MimicSchedule {
+	if location == nil {
+		location = time.UTC
+	}
+	return &MimicSchedule{
+		fromHour:  fromHour,
+		untilHour: untilHour,
+		location:  location,
+		dailyCap:  dailyCap,
+	}
+}
+
+// Active reports whether now falls within the active hours. A nil schedule
+// is always active.
+func (s *MimicSchedule) Active(now time.Time) bool {
+	if s == nil {
+		return true
+	}
+	hour := now.In(s.location).Hour()
+	if s.fromHour < s.untilHour {
+		return hour >= s.fromHour && hour < s.untilHour
+	}
+	return hour >= s.fromHour || hour < s.untilHour
+}
+
+// CanPost reports whether a post is allowed at now: within active hours and
+// under the daily cap
+func (s *MimicSchedule) CanPost(now time.Time) bool {
+	if s == nil {
+		return true
+	}
+	if !s.Active(now) {
+		return false
+	}
+	if s.dailyCap <= 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollover(now)
+	return s.posts < s.dailyCap
+}
+
+// RecordPost counts a post made at now toward the daily cap
+func (s *MimicSchedule) RecordPost(now time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollover(now)
+	s.posts++
+}
+
+// rollover resets the count when now is on a new day. Callers must hold s.mu.
+func (s *MimicSchedule) rollover(now time.Time) {
+	day := now.In(s.location).Format("2006-01-02")
+	if day != s.day {
+		s.day = day
+		s.posts = 0
+	}
+}
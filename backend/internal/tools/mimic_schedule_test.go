@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMimicScheduleActiveHours(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2025, 3, 1, hour, 30, 0, 0, time.UTC) }
+
+	daytime := NewMimicSchedule(9, 23, time.UTC, 0)
+	for hour, want := range map[int]bool{8: false, 9: true, 22: true, 23: false} {
+		if got := daytime.Active(at(hour)); got != want {
+			t.Errorf("9-23 at %d:30: expected active=%v, got %v", hour, want, got)
+		}
+	}
+
+	overnight := NewMimicSchedule(22, 7, time.UTC, 0)
+	for hour, want := range map[int]bool{21: false, 22: true, 3: true, 7: false} {
+		if got := overnight.Active(at(hour)); got != want {
+			t.Errorf("22-7 at %d:30: expected active=%v, got %v", hour, want, got)
+		}
+	}
+
+	tokyo := time.FixedZone("JST", 9*3600)
+	if !NewMimicSchedule(9, 23, tokyo, 0).Active(at(1)) {
+		t.Error("Expected hours to be checked in the schedule's timezone")
+	}
+}
+
+func TestMimicScheduleDailyCap(t *testing.T) {
+	schedule := NewMimicSchedule(0, 24, time.UTC, 2)
+	morning := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if !schedule.CanPost(morning) {
+			t.Fatalf("Expected post %d to be allowed", i+1)
+		}
+		schedule.RecordPost(morning)
+	}
+	if schedule.CanPost(morning) {
+		t.Error("Expected posts over the cap to be refused")
+	}
+	if !schedule.CanPost(morning.Add(24 * time.Hour)) {
+		t.Error("Expected the cap to reset the next day")
+	}
+}
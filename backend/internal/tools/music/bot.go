@@ -93,19 +93,29 @@ type MusicBot struct {
 	SongStartTime time.Time     // When the current song started playing
 	PausedAt      time.Duration // Position when paused
 
+	// demuxer is the WebM demuxer feeding the current song, if any. Only set
+	// for fresh streams: preloaded streams are buffered ahead of playback, so
+	// their demuxer position runs early.
+	demuxer *WebMDemuxer
+
 	// Radio mode fields
 	RadioEnabled    bool
 	RadioSeed       string
 	RadioHistoryMap map[string]struct{} // URLs of songs already played (O(1) lookup)
 	RadioChannelID  string              // Channel to send radio notifications
 	RadioMu         sync.Mutex
-	RadioRefilling  bool // Prevents concurrent refills
+	RadioRefilling  bool                   // Prevents concurrent refills
+	RadioModel      adapter.AuxiliaryModel // Auxiliary model of the agent that started radio mode, for its suggestions
 
 	// Playlist generation progress tracking
 	GeneratingPlaylistMsgID     string     // Message ID for "generating playlist" message
 	GeneratingPlaylistChannelID string     // Channel ID for the generating message
 	GeneratingPlaylistMu        sync.Mutex // Mutex for generating playlist message updates
 
+	// Idle disconnect: pending timer while nobody else is in the voice channel
+	idleTimer *time.Timer
+	idleMu    sync.Mutex
+
 	// LLM adapter for playlist/radio generation
 	llmAdapter *adapter.LLMAdapter
 
@@ -129,6 +139,26 @@ func NewMusicBot(guildID string, session *discordgo.Session, logger *zap.Logger)
 	}
 }
 
+// Elapsed returns how far into the current song playback is. Time spent
+// paused is not counted. Returns 0 when nothing has started playing.
+func (b *MusicBot) Elapsed() time.Duration {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if b.demuxer != nil {
+		if pos := b.demuxer.CurrentTime(); pos > 0 {
+			return pos
+		}
+	}
+	if b.SongStartTime.IsZero() {
+		return 0
+	}
+	if b.IsPaused {
+		return b.PausedAt
+	}
+	return time.Since(b.SongStartTime)
+}
+
 // ClearRadioState disables radio mode and clears history
 func (b *MusicBot) ClearRadioState() {
 	b.RadioMu.Lock()
@@ -138,6 +168,7 @@ func (b *MusicBot) ClearRadioState() {
 	b.RadioHistoryMap = make(map[string]struct{})
 	b.RadioChannelID = ""
 	b.RadioRefilling = false
+	b.RadioModel = adapter.AuxiliaryModel{}
 }
 
 // AddToRadioHistory adds a song URL to the radio history
@@ -219,6 +250,14 @@ func (m *MusicManager) GetBot(guildID string, session *discordgo.Session) *Music
 	return bot
 }
 
+// LookupBot returns the music bot for a guild without creating one
+func (m *MusicManager) LookupBot(guildID string) (*MusicBot, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	bot, exists := m.bots[guildID]
+	return bot, exists
+}
+
 // RemoveBot removes a music bot for a guild (cleanup)
 func (m *MusicManager) RemoveBot(guildID string) {
 	m.mu.Lock()
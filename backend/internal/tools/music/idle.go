@@ -0,0 +1,153 @@
+package music
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// DefaultIdleDisconnectGrace is how long the bot stays alone in a voice
+// channel before leaving
+const DefaultIdleDisconnectGrace = 2 * time.Minute
+
+// Disconnect stops playback, clears the queue and radio state, and leaves
+// the voice channel
+func (b *MusicBot) Disconnect() {
+	b.cancelIdleTimer()
+
+	// Stop playback
+	select {
+	case b.StopChan <- true:
+	default:
+	}
+
+	// Clear queue
+	b.Playlist.Lock()
+	b.Playlist.Songs = []Song{}
+	b.Playlist.Current = -1
+	b.Playlist.Unlock()
+
+	b.ClearRadioState()
+
+	b.Mu.Lock()
+	vc := b.VoiceConn
+	if vc != nil && b.IsSpeaking {
+		vc.Speaking(false)
+		b.IsSpeaking = false
+	}
+	b.VoiceConn = nil
+	b.Mu.Unlock()
+
+	if vc == nil {
+		return
+	}
+
+	b.PreloadMu.Lock()
+	cleanupPreloadedSong(b.Preloaded)
+	b.Preloaded = nil
+	b.PreloadMu.Unlock()
+
+	vc.Disconnect()
+	b.logger.Info("Disconnected from voice channel", zap.String("guild_id", b.GuildID))
+}
+
+// CountListeners returns how many users other than bots are in a voice
+// channel, based on the guild voice states in the session state cache
+func CountListeners(s *discordgo.Session, guildID, channelID string) int {
+	guild, err := s.State.Guild(guildID)
+	if err != nil || guild == nil {
+		return 0
+	}
+
+	count := 0
+	for _, vs := range guild.VoiceStates {
+		if vs.ChannelID != channelID || isBotUser(s, guildID, vs) {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// isBotUser reports whether a voice state belongs to a bot (including us)
+func isBotUser(s *discordgo.Session, guildID string, vs *discordgo.VoiceState) bool {
+	if s.State.User != nil && vs.UserID == s.State.User.ID {
+		return true
+	}
+	if vs.Member != nil && vs.Member.User != nil {
+		return vs.Member.User.Bot
+	}
+	if member, err := s.State.Member(guildID, vs.UserID); err == nil && member.User != nil {
+		return member.User.Bot
+	}
+	return false
+}
+
+// CheckIdle starts the idle disconnect timer when nobody but bots is left in
+// the bot's voice channel, and cancels it once someone is back. When the
+// timer fires after grace and the channel is still empty, the bot disconnects.
+func (b *MusicBot) CheckIdle(s *discordgo.Session, grace time.Duration) {
+	b.Mu.Lock()
+	vc := b.VoiceConn
+	b.Mu.Unlock()
+
+	if vc == nil || grace <= 0 {
+		b.cancelIdleTimer()
+		return
+	}
+
+	channelID := vc.ChannelID
+	if CountListeners(s, b.GuildID, channelID) > 0 {
+		if b.cancelIdleTimer() {
+			b.logger.Info("Listener rejoined voice channel, staying connected",
+				zap.String("guild_id", b.GuildID),
+				zap.String("channel_id", channelID),
+			)
+		}
+		return
+	}
+
+	b.idleMu.Lock()
+	defer b.idleMu.Unlock()
+	if b.idleTimer != nil {
+		return
+	}
+
+	b.logger.Info("Voice channel is empty, will disconnect if nobody rejoins",
+		zap.String("guild_id", b.GuildID),
+		zap.String("channel_id", channelID),
+		zap.Duration("grace_period", grace),
+	)
+	b.idleTimer = time.AfterFunc(grace, func() {
+		b.idleMu.Lock()
+		b.idleTimer = nil
+		b.idleMu.Unlock()
+
+		b.Mu.Lock()
+		current := b.VoiceConn
+		b.Mu.Unlock()
+		if current == nil || current.ChannelID != channelID || CountListeners(s, b.GuildID, channelID) > 0 {
+			return
+		}
+
+		b.logger.Info("Leaving voice channel: no listeners left",
+			zap.String("guild_id", b.GuildID),
+			zap.String("channel_id", channelID),
+			zap.Duration("idle_for", grace),
+		)
+		b.Disconnect()
+	})
+}
+
+// cancelIdleTimer stops a pending idle disconnect and reports whether one was pending
+func (b *MusicBot) cancelIdleTimer() bool {
+	b.idleMu.Lock()
+	defer b.idleMu.Unlock()
+	if b.idleTimer == nil {
+		return false
+	}
+	b.idleTimer.Stop()
+	b.idleTimer = nil
+	return true
+}
@@ -2,6 +2,7 @@ package music
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os/exec"
@@ -70,6 +71,56 @@ func (e *SeekError) Error() string {
 	return fmt.Sprintf("seek requested to %v", e.Position)
 }
 
+// Seek errors
+var (
+	ErrNothingPlaying  = errors.New("nothing is playing")
+	ErrSeekLiveStream  = errors.New("can't seek in a live stream")
+	ErrSeekUnknownTime = errors.New("can't seek: the song's duration is unknown")
+	ErrSeekPastEnd     = errors.New("seek position is past the end of the song")
+)
+
+// Seek restarts the current song's stream at position, keeping its place in
+// the queue. The running PlayQueue picks the request up via SeekChan and
+// replays the song through a seeking demuxer.
+func Seek(bot *MusicBot, position time.Duration) (Song, error) {
+	bot.Mu.Lock()
+	isPlaying := bot.IsPlaying
+	bot.Mu.Unlock()
+
+	bot.Playlist.Lock()
+	current := bot.Playlist.Current
+	var song Song
+	if current >= 0 && current < len(bot.Playlist.Songs) {
+		song = bot.Playlist.Songs[current]
+	}
+	bot.Playlist.Unlock()
+
+	if !isPlaying || song.URL == "" {
+		return Song{}, ErrNothingPlaying
+	}
+	if song.Source == "twitch" {
+		return song, ErrSeekLiveStream
+	}
+	length := SongLength(song)
+	if length == 0 {
+		return song, ErrSeekUnknownTime
+	}
+	if position < 0 || position >= length {
+		return song, ErrSeekPastEnd
+	}
+
+	// Replace any seek that hasn't been picked up yet
+	select {
+	case <-bot.SeekChan:
+	default:
+	}
+	select {
+	case bot.SeekChan <- position:
+	default:
+	}
+	return song, nil
+}
+
 // PlayQueue plays the queue of songs
 func PlayQueue(bot *MusicBot, session *discordgo.Session, channelID string) {
 	bot.Mu.Lock()
@@ -231,6 +282,7 @@ func PlaySongWithSeek(bot *MusicBot, song Song, seekSeconds int) error {
 	var opusOut io.ReadCloser
 	var ytdlpCmd *exec.Cmd
 	var cancel func()
+	var demuxer *WebMDemuxer
 	usePreloaded := false
 
 	if seekSeconds == 0 {
@@ -354,7 +406,6 @@ func PlaySongWithSeek(bot *MusicBot, song Song, seekSeconds int) error {
 				return err
 			}
 			// Use WebM demuxer with seek support
-			var demuxer *WebMDemuxer
 			if seekSeconds > 0 {
 				demuxer = NewWebMDemuxerWithSeek(audioOut, seekSeconds)
 			} else {
@@ -379,11 +430,21 @@ func PlaySongWithSeek(bot *MusicBot, song Song, seekSeconds int) error {
 	} else {
 		bot.SongStartTime = time.Now()
 	}
+	bot.IsPaused = false
+	bot.PausedAt = 0
+	bot.demuxer = demuxer
 	bot.Mu.Unlock()
 
 	// Play the audio stream
 	err := playAudioStream(bot, vc, opusOut, usePreloaded, ytdlpCmd, cancel)
 
+	// Reset position tracking so the next song (or an idle bot) starts from zero
+	bot.Mu.Lock()
+	bot.demuxer = nil
+	bot.SongStartTime = time.Time{}
+	bot.CurrentPos = 0
+	bot.Mu.Unlock()
+
 	// Handle seek request
 	if seekErr, ok := err.(*SeekError); ok {
 		seekSecs := int(seekErr.Position.Seconds())
@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"ezra-clone/backend/internal/adapter"
+
 	"github.com/bwmarrin/discordgo"
 	"go.uber.org/zap"
 )
@@ -20,6 +22,7 @@ func refillRadioQueue(bot *MusicBot, session *discordgo.Session) {
 	}
 	bot.RadioRefilling = true
 	seed := bot.RadioSeed
+	model := bot.RadioModel
 	bot.RadioMu.Unlock()
 
 	defer func() {
@@ -35,7 +38,7 @@ func refillRadioQueue(bot *MusicBot, session *discordgo.Session) {
 	if bot.llmAdapter == nil {
 		return
 	}
-	ctx := context.Background()
+	ctx := adapter.WithAuxiliaryModel(context.Background(), model)
 	suggestions := GenerateRadioSuggestions(ctx, bot.llmAdapter, seed, recentSongs)
 
 	if len(suggestions) == 0 {
@@ -104,12 +107,12 @@ func updateGeneratingPlaylistMessage(bot *MusicBot, session *discordgo.Session,
 	}
 }
 
-// GenerateAndPlayPlaylist generates a playlist and starts playback (streaming mode)
-func GenerateAndPlayPlaylist(query, requester string, bot *MusicBot, session *discordgo.Session, channelID string) []Song {
+// GenerateAndPlayPlaylist generates a playlist and starts playback (streaming
+// mode). The queries are generated with ctx's auxiliary model.
+func GenerateAndPlayPlaylist(ctx context.Context, query, requester string, bot *MusicBot, session *discordgo.Session, channelID string) []Song {
 	bot.logger.Info("Generating playlist", zap.String("query", query), zap.String("mode", "streaming"))
 
 	// Generate playlist queries first to know expected count
-	ctx := context.Background()
 	var queries []string
 	if bot.llmAdapter != nil {
 		queries = GeneratePlaylistQueries(ctx, bot.llmAdapter, query)
@@ -254,16 +257,18 @@ func GenerateAndPlayPlaylist(query, requester string, bot *MusicBot, session *di
 	return []Song{}
 }
 
-// StartRadioMode starts infinite radio mode
-func StartRadioMode(bot *MusicBot, session *discordgo.Session, seed, channelID string) {
+// StartRadioMode starts infinite radio mode. Suggestions are generated with
+// model, the auxiliary model of the agent that started it.
+func StartRadioMode(bot *MusicBot, session *discordgo.Session, seed, channelID string, model adapter.AuxiliaryModel) {
 	bot.RadioMu.Lock()
 	bot.RadioEnabled = true
 	bot.RadioSeed = seed
 	bot.RadioChannelID = channelID
+	bot.RadioModel = model
 	bot.RadioMu.Unlock()
 
 	// Generate initial playlist
-	ctx := context.Background()
+	ctx := adapter.WithAuxiliaryModel(context.Background(), model)
 	var queries []string
 	if bot.llmAdapter != nil {
 		queries = GeneratePlaylistQueries(ctx, bot.llmAdapter, seed)
@@ -38,11 +38,33 @@ func SearchYouTube(query, requester string) Song {
 	return convertSong(sources.SearchYouTube(query, requester))
 }
 
+// FetchYouTubeVideoWithContext wraps sources.FetchYouTubeVideoWithContext;
+// yt-dlp is killed when ctx is cancelled
+func FetchYouTubeVideoWithContext(ctx context.Context, url, requester string) (Song, error) {
+	song, err := sources.FetchYouTubeVideoWithContext(ctx, url, requester)
+	if err != nil {
+		return Song{}, err
+	}
+	return convertSong(song), nil
+}
+
+// SearchYouTubeWithContext wraps sources.SearchYouTubeWithContext; yt-dlp is
+// killed when ctx is cancelled
+func SearchYouTubeWithContext(ctx context.Context, query, requester string) (Song, error) {
+	song, err := sources.SearchYouTubeWithContext(ctx, query, requester)
+	if err != nil {
+		return Song{}, err
+	}
+	return convertSong(song), nil
+}
+
 // FetchSpotifyPlaylist wraps sources.FetchSpotifyPlaylist
 func FetchSpotifyPlaylist(ctx context.Context, spotifyURL, requester string, songChan chan<- Song) ([]Song, error) {
 	// Create a channel for sources.Song and convert
 	sourceChan := make(chan sources.Song, 10)
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		for s := range sourceChan {
 			if songChan != nil {
 				songChan <- convertSong(s)
@@ -52,6 +74,7 @@ func FetchSpotifyPlaylist(ctx context.Context, spotifyURL, requester string, son
 
 	songs, err := sources.FetchSpotifyPlaylist(ctx, spotifyURL, requester, sourceChan)
 	close(sourceChan)
+	<-done // All songs are forwarded before returning, so callers may close songChan
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +85,9 @@ func FetchSpotifyPlaylist(ctx context.Context, spotifyURL, requester string, son
 func FetchSoundCloudPlaylist(ctx context.Context, soundcloudURL, requester string, songChan chan<- Song) ([]Song, error) {
 	// Create a channel for sources.Song and convert
 	sourceChan := make(chan sources.Song, 10)
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		for s := range sourceChan {
 			if songChan != nil {
 				songChan <- convertSong(s)
@@ -72,12 +97,31 @@ func FetchSoundCloudPlaylist(ctx context.Context, soundcloudURL, requester strin
 
 	songs, err := sources.FetchSoundCloudPlaylist(ctx, soundcloudURL, requester, sourceChan)
 	close(sourceChan)
+	<-done // All songs are forwarded before returning, so callers may close songChan
 	if err != nil {
 		return nil, err
 	}
 	return convertSongs(songs), nil
 }
 
+// FetchSpotifyTrack wraps sources.FetchSpotifyTrack
+func FetchSpotifyTrack(ctx context.Context, spotifyURL, requester string) (Song, error) {
+	song, err := sources.FetchSpotifyTrack(ctx, spotifyURL, requester)
+	if err != nil {
+		return Song{}, err
+	}
+	return convertSong(song), nil
+}
+
+// FetchSoundCloudTrack wraps sources.FetchSoundCloudTrack
+func FetchSoundCloudTrack(ctx context.Context, soundcloudURL, requester string) (Song, error) {
+	song, err := sources.FetchSoundCloudTrack(ctx, soundcloudURL, requester)
+	if err != nil {
+		return Song{}, err
+	}
+	return convertSong(song), nil
+}
+
 // GeneratePlaylistQueries wraps sources.GeneratePlaylistQueries
 func GeneratePlaylistQueries(ctx context.Context, llmAdapter *adapter.LLMAdapter, query string) []string {
 	return sources.GeneratePlaylistQueries(ctx, llmAdapter, query)
@@ -22,7 +22,7 @@ func GeneratePlaylistQueries(ctx context.Context, llmAdapter *adapter.LLMAdapter
 	systemPrompt := "You are a music playlist generator. Generate song suggestions based on similarity and songs the user may like in the format 'Artist - Song Title', one per line. Only output the song suggestions, nothing else."
 	userPrompt := fmt.Sprintf("Generate 20-25 song suggestions for a playlist based on: %s", query)
 
-	response, err := llmAdapter.Generate(reqCtx, systemPrompt, userPrompt, []adapter.Tool{})
+	response, err := llmAdapter.Generate(reqCtx, adapter.GenerateParams{Auxiliary: true}, systemPrompt, userPrompt, []adapter.Tool{})
 	if err != nil {
 		return []string{}
 	}
@@ -54,7 +54,7 @@ func GenerateRadioSuggestions(ctx context.Context, llmAdapter *adapter.LLMAdapte
 	systemPrompt := "You are a music radio DJ. Generate song suggestions that flow well together, maintaining a consistent mood and style. Format each suggestion as 'Artist - Song Title', one per line. Only output the song suggestions, nothing else."
 	userPrompt := fmt.Sprintf("The listener started a radio station based on: %s%s\n\nGenerate 8-10 new song suggestions that would fit this radio station perfectly.", seed, recentContext)
 
-	response, err := llmAdapter.Generate(reqCtx, systemPrompt, userPrompt, []adapter.Tool{})
+	response, err := llmAdapter.Generate(reqCtx, adapter.GenerateParams{Auxiliary: true}, systemPrompt, userPrompt, []adapter.Tool{})
 	if err != nil {
 		return []string{}
 	}
@@ -117,6 +117,58 @@ func FetchSoundCloudPlaylist(ctx context.Context, soundcloudURL, requester strin
 	return songs, nil
 }
 
+// FetchSoundCloudTrack resolves a single SoundCloud track to a playable YouTube
+// song, rejecting ones over MaxSongDurationSeconds
+func FetchSoundCloudTrack(ctx context.Context, soundcloudURL, requester string) (Song, error) {
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), DefaultSearchTimeout*time.Second)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, YtdlpExecutable,
+		"--dump-json",
+		"--no-playlist",
+		"--no-download",
+		"--quiet",
+		soundcloudURL)
+
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return Song{}, fmt.Errorf("%w: track fetch timed out: %v", ErrTimeout, ctx.Err())
+		}
+		// yt-dlp exits non-zero for private, deleted and geo-blocked tracks
+		return Song{}, fmt.Errorf("%w: %s", ErrTrackUnavailable, soundcloudURL)
+	}
+
+	var info map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(output))), &info); err != nil {
+		return Song{}, fmt.Errorf("%w: failed to parse track info: %v", ErrFetchFailed, err)
+	}
+
+	title, _ := info["title"].(string)
+	uploader, _ := info["uploader"].(string)
+	if title == "" {
+		return Song{}, fmt.Errorf("%w: %s", ErrTrackUnavailable, soundcloudURL)
+	}
+
+	query := title
+	if uploader != "" && !strings.Contains(title, uploader) {
+		query = fmt.Sprintf("%s - %s", uploader, title)
+	}
+
+	song, err := SearchYouTubeWithContext(ctx, query, requester)
+	if err != nil {
+		return Song{}, err
+	}
+	if !IsSongDurationUnderLimit(song.Duration, MaxSongDurationSeconds) {
+		return Song{}, fmt.Errorf("%w: %s (%s)", ErrSongTooLong, song.Title, song.Duration)
+	}
+	song.Source = "soundcloud"
+	return song, nil
+}
+
 func extractSoundCloudTrackName(ctx context.Context, url string) (string, error) {
 	if ctx == nil {
 		var cancel context.CancelFunc
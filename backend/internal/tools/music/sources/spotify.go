@@ -2,6 +2,7 @@ package sources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -27,18 +28,14 @@ func FetchSpotifyPlaylist(ctx context.Context, spotifyURL, requester string, son
 
 	if strings.Contains(spotifyURL, "track") {
 		// Single track
-		trackName, err := extractSpotifyTrackName(ctx, spotifyURL)
-		if err == nil && trackName != "" {
-			song, err := SearchYouTubeWithContext(ctx, trackName, requester)
-			if err == nil && !song.IsEmpty() {
-				if IsSongDurationUnderLimit(song.Duration, maxDurationSeconds) {
-					if songChan != nil {
-						songChan <- song
-					}
-					return []Song{song}, nil
-				}
-			}
+		song, err := FetchSpotifyTrack(ctx, spotifyURL, requester)
+		if err != nil {
+			return nil, err
+		}
+		if songChan != nil {
+			songChan <- song
 		}
+		return []Song{song}, nil
 	} else if strings.Contains(spotifyURL, "playlist") || strings.Contains(spotifyURL, "album") {
 		// Playlist/Album
 		var err error
@@ -106,6 +103,34 @@ func FetchSpotifyPlaylist(ctx context.Context, spotifyURL, requester string, son
 	return songs, nil
 }
 
+// FetchSpotifyTrack resolves a single Spotify track to a playable YouTube song,
+// rejecting ones over MaxSongDurationSeconds
+func FetchSpotifyTrack(ctx context.Context, spotifyURL, requester string) (Song, error) {
+	trackName, err := extractSpotifyTrackName(ctx, spotifyURL)
+	if err != nil {
+		if errors.Is(err, ErrSongNotFound) {
+			return Song{}, fmt.Errorf("%w: %s", ErrTrackUnavailable, spotifyURL)
+		}
+		return Song{}, err
+	}
+
+	// Private or removed tracks render the generic web player page
+	if trackName == "" || strings.EqualFold(strings.TrimSpace(trackName), "Spotify") ||
+		strings.HasPrefix(trackName, "Spotify – Web Player") || strings.HasPrefix(trackName, "Spotify - Web Player") {
+		return Song{}, fmt.Errorf("%w: %s", ErrTrackUnavailable, spotifyURL)
+	}
+
+	song, err := SearchYouTubeWithContext(ctx, trackName, requester)
+	if err != nil {
+		return Song{}, err
+	}
+	if !IsSongDurationUnderLimit(song.Duration, MaxSongDurationSeconds) {
+		return Song{}, fmt.Errorf("%w: %s (%s)", ErrSongTooLong, song.Title, song.Duration)
+	}
+	song.Source = "spotify"
+	return song, nil
+}
+
 func extractSpotifyTrackName(ctx context.Context, url string) (string, error) {
 	if ctx == nil {
 		var cancel context.CancelFunc
@@ -136,6 +161,9 @@ func extractSpotifyTrackName(ctx context.Context, url string) (string, error) {
 	html := string(body)
 	titleRegex := regexp.MustCompile(`<title>(.*?)</title>`)
 	matches := titleRegex.FindStringSubmatch(html)
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrSongNotFound
+	}
 	if len(matches) > 1 {
 		title := strings.ReplaceAll(matches[1], " | Spotify", "")
 		title = strings.ReplaceAll(title, " | ", " ")
@@ -10,7 +10,7 @@ const (
 	// MaxPlaylistTracks is the maximum number of tracks to fetch from a playlist
 	MaxPlaylistTracks = 50
 
-	// MaxSongDurationSeconds is the default maximum song duration for playlists (6 minutes)
+	// MaxSongDurationSeconds is the default maximum song duration for playlists and Spotify/SoundCloud tracks (6 minutes)
 	MaxSongDurationSeconds = 360
 
 	// DefaultSearchTimeout is the default timeout for search operations
@@ -48,6 +48,12 @@ var (
 
 	// ErrSourceUnavailable is returned when a source (YouTube, Spotify, etc.) is unavailable
 	ErrSourceUnavailable = errors.New("audio source unavailable")
+
+	// ErrTrackUnavailable is returned when a track is private, removed, or region-locked
+	ErrTrackUnavailable = errors.New("track is private or unavailable")
+
+	// ErrSongTooLong is returned when a track is over MaxSongDurationSeconds
+	ErrSongTooLong = errors.New("song is over the length limit")
 )
 
 // Song represents a track (local to sources package to avoid import cycles)
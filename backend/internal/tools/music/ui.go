@@ -1,6 +1,8 @@
 package music
 
 import (
+	"time"
+
 	"ezra-clone/backend/internal/tools/music/ui"
 	"github.com/bwmarrin/discordgo"
 )
@@ -40,9 +42,26 @@ func CreateSongAddedEmbed(song Song, position int) *discordgo.MessageEmbed {
 	return ui.CreateSongAddedEmbed(convertSongToUI(song), position)
 }
 
-// CreateQueueEmbed wraps ui.CreateQueueEmbed
-func CreateQueueEmbed(playlist *Playlist, page int) *discordgo.MessageEmbed {
+// CreateQueueEmbed wraps ui.CreateQueueEmbed. elapsed is shown against the
+// current song's duration.
+func CreateQueueEmbed(playlist *Playlist, page int, elapsed time.Duration) *discordgo.MessageEmbed {
 	uiPlaylist := convertPlaylistToUI(playlist)
+	uiPlaylist.Elapsed = elapsed
 	return ui.CreateQueueEmbed(uiPlaylist, page)
 }
 
+// CreateNowPlayingEmbed wraps ui.CreateNowPlayingEmbed
+func CreateNowPlayingEmbed(song Song, position, total int, elapsed time.Duration) *discordgo.MessageEmbed {
+	return ui.CreateNowPlayingEmbed(convertSongToUI(song), position, total, elapsed, SongLength(song))
+}
+
+// ProgressBar wraps ui.ProgressBar using the song's parsed duration
+func ProgressBar(song Song, elapsed time.Duration) string {
+	return ui.ProgressBar(elapsed, SongLength(song), ui.DefaultProgressBarWidth)
+}
+
+// FormatProgress wraps ui.FormatProgress, e.g. "2:14 / 4:30"
+func FormatProgress(song Song, elapsed time.Duration) string {
+	return ui.FormatProgress(elapsed, song.Duration)
+}
+
@@ -24,7 +24,8 @@ type Playlist struct {
 	Current int
 	Loop    bool
 	Shuffle bool
-	mu      interface{} // Placeholder for sync.Mutex
+	Elapsed time.Duration // Playback position of the current song
+	mu      interface{}   // Placeholder for sync.Mutex
 }
 
 const (
@@ -69,8 +70,9 @@ func getSourceName(source string) string {
 	}
 }
 
-// CreateNowPlayingEmbed creates a now playing embed
-func CreateNowPlayingEmbed(song Song, position, total int) *discordgo.MessageEmbed {
+// CreateNowPlayingEmbed creates a now playing embed with a progress bar.
+// songLength is the parsed song duration (0 if unknown).
+func CreateNowPlayingEmbed(song Song, position, total int, elapsed, songLength time.Duration) *discordgo.MessageEmbed {
 	sourceIcon := getSourceIcon(song.Source)
 	sourceName := getSourceName(song.Source)
 
@@ -85,9 +87,12 @@ func CreateNowPlayingEmbed(song Song, position, total int) *discordgo.MessageEmb
 		}
 	}
 
+	progress := fmt.Sprintf("%s `%s`",
+		ProgressBar(elapsed, songLength, DefaultProgressBarWidth), FormatProgress(elapsed, song.Duration))
+
 	embed := &discordgo.MessageEmbed{
 		Title:       "🎵 Now Playing",
-		Description: fmt.Sprintf("**[%s](%s)**", song.Title, song.URL),
+		Description: fmt.Sprintf("**[%s](%s)**\n\n%s", song.Title, song.URL, progress),
 		Color:       ColorSuccess,
 		Image:       image,
 		Thumbnail:   thumbnail,
@@ -206,8 +211,12 @@ func CreateQueueEmbed(playlist *Playlist, page int) *discordgo.MessageEmbed {
 		if len(title) > 50 {
 			title = title[:47] + "..."
 		}
+		duration := song.Duration
+		if i == playlist.Current {
+			duration = FormatProgress(playlist.Elapsed, song.Duration)
+		}
 		queueText.WriteString(fmt.Sprintf("%s **%d.** [%s](%s) %s *%s*\n",
-			marker, i+1, title, song.URL, sourceIcon, song.Duration))
+			marker, i+1, title, song.URL, sourceIcon, duration))
 	}
 
 	footerText := fmt.Sprintf("Page %d/%d", page+1, totalPages)
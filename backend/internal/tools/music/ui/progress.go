@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultProgressBarWidth is the number of cells in a progress bar
+const DefaultProgressBarWidth = 20
+
+// FormatElapsed formats a playback position as M:SS or H:MM:SS
+func FormatElapsed(d time.Duration) string {
+	seconds := int(d.Seconds())
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	hours := seconds / 3600
+	minutes := (seconds % 3600) / 60
+	secs := seconds % 60
+
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, secs)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, secs)
+}
+
+// FormatProgress formats elapsed time against a song's duration string, e.g. "2:14 / 4:30"
+func FormatProgress(elapsed time.Duration, duration string) string {
+	if duration == "" {
+		duration = "Unknown"
+	}
+	return fmt.Sprintf("%s / %s", FormatElapsed(elapsed), duration)
+}
+
+// ProgressBar renders a text progress bar such as "▬▬▬🔘▬▬▬▬▬▬".
+// An unknown total (0) renders the marker at the start.
+func ProgressBar(elapsed, total time.Duration, width int) string {
+	if width <= 0 {
+		width = DefaultProgressBarWidth
+	}
+
+	pos := 0
+	if total > 0 && elapsed > 0 {
+		pos = int(float64(elapsed) / float64(total) * float64(width-1))
+		if pos > width-1 {
+			pos = width - 1
+		}
+	}
+
+	return strings.Repeat("▬", pos) + "🔘" + strings.Repeat("▬", width-1-pos)
+}
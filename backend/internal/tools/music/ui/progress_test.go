@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatProgress(t *testing.T) {
+	got := FormatProgress(2*time.Minute+14*time.Second+600*time.Millisecond, "4:30")
+	if got != "2:14 / 4:30" {
+		t.Errorf("FormatProgress() = %q, want %q", got, "2:14 / 4:30")
+	}
+
+	if got := FormatElapsed(time.Hour + 2*time.Minute + 3*time.Second); got != "1:02:03" {
+		t.Errorf("FormatElapsed() = %q, want %q", got, "1:02:03")
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		total   time.Duration
+		want    string
+	}{
+		{"start", 0, time.Minute, "🔘▬▬▬▬"},
+		{"middle", 30 * time.Second, time.Minute, "▬▬🔘▬▬"},
+		{"end", time.Minute, time.Minute, "▬▬▬▬🔘"},
+		{"past end", 2 * time.Minute, time.Minute, "▬▬▬▬🔘"},
+		{"unknown total", 30 * time.Second, 0, "🔘▬▬▬▬"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProgressBar(tt.elapsed, tt.total, 5); got != tt.want {
+				t.Errorf("ProgressBar() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var YtdlpExecutable = "yt-dlp"
@@ -87,6 +88,29 @@ func IsSoundCloudURL(str string) bool {
 	return err == nil && (parsed.Host == "soundcloud.com" || parsed.Host == "www.soundcloud.com")
 }
 
+// IsSpotifyTrackURL checks if a Spotify URL points at a single track rather than a playlist/album
+func IsSpotifyTrackURL(str string) bool {
+	parsed, err := url.Parse(str)
+	return err == nil && IsSpotifyURL(str) && strings.Contains(parsed.Path, "/track/")
+}
+
+// IsSoundCloudTrackURL checks if a SoundCloud URL points at a single track (soundcloud.com/<artist>/<track>)
+func IsSoundCloudTrackURL(str string) bool {
+	parsed, err := url.Parse(str)
+	if err != nil || !IsSoundCloudURL(str) {
+		return false
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) != 2 {
+		return false
+	}
+	switch segments[1] {
+	case "sets", "likes", "tracks", "albums", "reposts", "popular-tracks":
+		return false
+	}
+	return true
+}
+
 // FormatDuration formats a duration from seconds to MM:SS or H:MM:SS
 func FormatDuration(d interface{}) string {
 	var seconds float64
@@ -238,3 +262,11 @@ func FormatDurationFromSeconds(seconds int) string {
 	return fmt.Sprintf("%d:%02d", minutes, secs)
 }
 
+// SongLength parses a song's formatted duration. Returns 0 if it is unknown.
+func SongLength(song Song) time.Duration {
+	seconds, err := ParseTimestamp(song.Duration)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -3,6 +3,8 @@ package music
 import (
 	"encoding/binary"
 	"io"
+	"sync/atomic"
+	"time"
 )
 
 // WebMDemuxer extracts Opus audio packets from WebM container
@@ -20,7 +22,7 @@ type WebMDemuxer struct {
 	// Seek support
 	seekTargetMs  int64 // Target seek position in milliseconds (-1 = no seek)
 	clusterTimeMs int64 // Current cluster timestamp in milliseconds
-	currentTimeMs int64 // Current playback position in milliseconds
+	currentTimeMs int64 // Current playback position in milliseconds (accessed atomically)
 	seeking       bool  // Are we currently skipping frames?
 	seekReady     bool  // Have we reached the seek target?
 	// Loudness normalization
@@ -74,6 +76,12 @@ func NewWebMDemuxerWithSeek(reader io.Reader, seekSeconds int) *WebMDemuxer {
 	return d
 }
 
+// CurrentTime returns the timestamp of the last block read from the stream.
+// Safe to call from another goroutine while Read is in progress.
+func (d *WebMDemuxer) CurrentTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&d.currentTimeMs)) * time.Millisecond
+}
+
 // analyzeLoudness analyzes buffered Opus packets and calculates the required gain
 // Uses heuristic estimation from packet characteristics (works with all Opus modes)
 func (d *WebMDemuxer) analyzeLoudness() {
@@ -507,7 +515,7 @@ func (d *WebMDemuxer) readSimpleBlock(size uint64) ([][]byte, bool, error) {
 	blockTimecode := int16(binary.BigEndian.Uint16(timecodeBytes))
 
 	// Calculate absolute timestamp
-	d.currentTimeMs = d.clusterTimeMs + int64(blockTimecode)
+	atomic.StoreInt64(&d.currentTimeMs, d.clusterTimeMs+int64(blockTimecode))
 
 	flags := make([]byte, 1)
 	io.ReadFull(d.reader, flags)
@@ -521,7 +529,7 @@ func (d *WebMDemuxer) readSimpleBlock(size uint64) ([][]byte, bool, error) {
 
 	// Check if we're seeking and haven't reached target yet
 	if d.seeking && d.seekTargetMs > 0 {
-		if d.currentTimeMs < d.seekTargetMs {
+		if atomic.LoadInt64(&d.currentTimeMs) < d.seekTargetMs {
 			// Skip this block - we haven't reached seek target
 			io.CopyN(io.Discard, d.reader, dataSize)
 			return nil, false, nil
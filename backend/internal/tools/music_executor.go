@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"ezra-clone/backend/internal/adapter"
 	"ezra-clone/backend/internal/tools/music"
@@ -17,6 +18,12 @@ type MusicExecutor struct {
 	session   *discordgo.Session
 	logger    *zap.Logger
 	llmAdapter *adapter.LLMAdapter
+
+	// joinVoice connects to a voice channel; nil uses session.ChannelVoiceJoin
+	joinVoice func(guildID, channelID string) (*discordgo.VoiceConnection, error)
+
+	// idleGrace is how long to stay alone in a voice channel; 0 disables auto-disconnect
+	idleGrace time.Duration
 }
 
 // NewMusicExecutor creates a new music executor
@@ -37,6 +44,7 @@ func NewMusicExecutor(session *discordgo.Session, logger *zap.Logger, llmAdapter
 		session:   session,
 		logger:    logger,
 		llmAdapter: llmAdapter,
+		idleGrace:  music.DefaultIdleDisconnectGrace,
 	}
 }
 
@@ -45,6 +53,25 @@ func (m *MusicExecutor) SetSession(session *discordgo.Session) {
 	m.session = session
 }
 
+// SetIdleDisconnectGrace sets how long the bot stays in a voice channel with
+// no listeners before disconnecting. 0 disables auto-disconnect.
+func (m *MusicExecutor) SetIdleDisconnectGrace(grace time.Duration) {
+	m.idleGrace = grace
+}
+
+// HandleVoiceStateUpdate re-checks whether the guild's music bot has been left
+// alone in its voice channel whenever someone joins, leaves or moves
+func (m *MusicExecutor) HandleVoiceStateUpdate(s *discordgo.Session, vsu *discordgo.VoiceStateUpdate) {
+	if vsu.GuildID == "" {
+		return
+	}
+	bot, ok := m.manager.LookupBot(vsu.GuildID)
+	if !ok {
+		return
+	}
+	bot.CheckIdle(s, m.idleGrace)
+}
+
 // ExecuteMusicTool executes a music tool call
 func (m *MusicExecutor) ExecuteMusicTool(ctx context.Context, execCtx *ExecutionContext, toolName string, args map[string]interface{}) *ToolResult {
 	if m.session == nil {
@@ -83,8 +110,12 @@ func (m *MusicExecutor) ExecuteMusicTool(ctx context.Context, execCtx *Execution
 		return m.handlePlaylist(ctx, execCtx, bot, args)
 	case ToolMusicQueue:
 		return m.handleQueue(ctx, execCtx, bot, args)
+	case ToolMusicNowPlaying:
+		return m.handleNowPlaying(ctx, execCtx, bot, args)
 	case ToolMusicSkip:
 		return m.handleSkip(ctx, execCtx, bot, args)
+	case ToolMusicSeek:
+		return m.handleSeek(ctx, execCtx, bot, args)
 	case ToolMusicPause:
 		return m.handlePause(ctx, execCtx, bot, args)
 	case ToolMusicResume:
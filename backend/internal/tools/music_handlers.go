@@ -2,11 +2,11 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"os/exec"
 	"time"
 
+	"ezra-clone/backend/internal/adapter"
 	"ezra-clone/backend/internal/tools/music"
 
 	"github.com/bwmarrin/discordgo"
@@ -39,69 +39,15 @@ func (m *MusicExecutor) handlePlay(ctx context.Context, execCtx *ExecutionContex
 		}
 	}
 
-	// Get voice channel ID - match original bot's simple approach with fallback
+	// Get voice channel ID - fall back to the requester's current voice channel
 	channelID, _ := args["channel_id"].(string)
 	if channelID == "" {
-		m.logger.Debug("Attempting to detect user voice channel",
-			zap.String("guild_id", guildID),
-			zap.String("user_id", execCtx.UserID),
-		)
-
-		// Check if user is in a voice channel - exactly like original bot
-		// The original bot just uses State.VoiceState directly - it works because
-		// the state cache is populated by voice state update events
-		vs, err := m.session.State.VoiceState(guildID, execCtx.UserID)
-		if err != nil || vs == nil || vs.ChannelID == "" {
-			// If state cache doesn't have it, the voice state might not be tracked yet
-			// This can happen if the user joined before the bot started or state isn't synced
-			// Try to get guild and check voice states - but note that Guild() API call
-			// doesn't return voice states, only the state cache does
-			m.logger.Debug("Voice state not in cache, checking if guild state has voice states",
-				zap.String("guild_id", guildID),
-				zap.String("user_id", execCtx.UserID),
-				zap.Error(err),
-			)
-
-			// Try to get guild from state cache (which should have voice states if populated)
-			guild, err := m.session.State.Guild(guildID)
-			if err == nil && guild != nil && len(guild.VoiceStates) > 0 {
-				m.logger.Debug("Found voice states in guild state cache",
-					zap.Int("voice_state_count", len(guild.VoiceStates)),
-				)
-				for _, voiceState := range guild.VoiceStates {
-					if voiceState.UserID == execCtx.UserID && voiceState.ChannelID != "" {
-						channelID = voiceState.ChannelID
-						m.logger.Info("Found voice channel from guild state cache",
-							zap.String("channel_id", channelID),
-							zap.String("user_id", execCtx.UserID),
-						)
-						break
-					}
-				}
-			}
-
-			if channelID == "" {
-				m.logger.Warn("Could not find user voice channel - state cache may not be populated yet",
-					zap.String("guild_id", guildID),
-					zap.String("user_id", execCtx.UserID),
-					zap.Bool("guild_in_cache", err == nil && guild != nil),
-					zap.Int("voice_states_in_guild", func() int {
-						if guild != nil {
-							return len(guild.VoiceStates)
-						}
-						return 0
-					}()),
-				)
-				return &ToolResult{
-					Success: false,
-					Error:   "You must be in a voice channel to play music. Please join a voice channel first or specify channel_id.",
-				}
+		channelID = m.detectUserVoiceChannel(guildID, execCtx.UserID)
+		if channelID == "" {
+			return &ToolResult{
+				Success: false,
+				Error:   "You must be in a voice channel to play music. Please join a voice channel first or specify channel_id.",
 			}
-		} else {
-			channelID = vs.ChannelID
-			m.logger.Debug("Found voice channel from session state",
-				zap.String("channel_id", channelID),
-			)
 		}
 	}
 
@@ -136,6 +82,8 @@ func (m *MusicExecutor) handlePlay(ctx context.Context, execCtx *ExecutionContex
 			case <-timeout:
 				m.logger.Warn("Voice connection timeout, continuing anyway...")
 				timeoutReached = true
+			case <-ctx.Done():
+				return &ToolResult{Success: false, Error: fmt.Sprintf("Joining the voice channel was cancelled: %v", ctx.Err())}
 			case <-ticker.C:
 			}
 		}
@@ -146,38 +94,39 @@ func (m *MusicExecutor) handlePlay(ctx context.Context, execCtx *ExecutionContex
 	var song music.Song
 	var err error
 	if music.IsYouTubeURL(query) {
-		song = music.FetchYouTubeVideo(query, execCtx.UserID)
-		if song.Title == "" {
+		song, err = music.FetchYouTubeVideoWithContext(ctx, query, execCtx.UserID)
+		if err != nil {
 			return &ToolResult{
 				Success: false,
-				Error:   fmt.Sprintf("Could not fetch YouTube video: %s", query),
+				Error:   fmt.Sprintf("Could not fetch YouTube video: %s (%v)", query, err),
 			}
 		}
-	} else if music.IsSpotifyURL(query) {
-		songs, fetchErr := music.FetchSpotifyPlaylist(ctx, query, execCtx.UserID, nil)
-		if fetchErr != nil {
+	} else if music.IsSpotifyTrackURL(query) {
+		song, err = music.FetchSpotifyTrack(ctx, query, execCtx.UserID)
+		if err != nil {
 			return &ToolResult{
 				Success: false,
-				Error:   fmt.Sprintf("Could not fetch Spotify playlist: %v", fetchErr),
+				Error:   fmt.Sprintf("Could not play Spotify track: %v", err),
 			}
 		}
-		if len(songs) > 0 {
-			song = songs[0]
-		}
-	} else if music.IsSoundCloudURL(query) {
-		songs, fetchErr := music.FetchSoundCloudPlaylist(ctx, query, execCtx.UserID, nil)
-		if fetchErr != nil {
+	} else if music.IsSoundCloudTrackURL(query) {
+		song, err = music.FetchSoundCloudTrack(ctx, query, execCtx.UserID)
+		if err != nil {
 			return &ToolResult{
 				Success: false,
-				Error:   fmt.Sprintf("Could not fetch SoundCloud playlist: %v", fetchErr),
+				Error:   fmt.Sprintf("Could not play SoundCloud track: %v", err),
 			}
 		}
-		if len(songs) > 0 {
-			song = songs[0]
-		}
+	} else if music.IsSpotifyURL(query) {
+		return m.playPlaylistAsync(execCtx, bot, query, "Spotify", music.FetchSpotifyPlaylist)
+	} else if music.IsSoundCloudURL(query) {
+		return m.playPlaylistAsync(execCtx, bot, query, "SoundCloud", music.FetchSoundCloudPlaylist)
 	} else {
-		// Search YouTube
-		song = music.SearchYouTube(query, execCtx.UserID)
+		// Search YouTube; a failed search leaves song empty and is reported below
+		song, err = music.SearchYouTubeWithContext(ctx, query, execCtx.UserID)
+		if err != nil {
+			m.logger.Debug("YouTube search failed", zap.String("query", query), zap.Error(err))
+		}
 	}
 
 	if song.Title == "" {
@@ -186,9 +135,38 @@ func (m *MusicExecutor) handlePlay(ctx context.Context, execCtx *ExecutionContex
 			Error:   fmt.Sprintf("Could not find song: %s", query),
 		}
 	}
-	_ = err // Suppress unused variable warning
 
-	// Add to queue
+	position := m.enqueueSong(bot, execCtx.ChannelID, song)
+
+	// Send confirmation embed
+	go func() {
+		embed := music.CreateSongAddedEmbed(song, position)
+		_, err := m.session.ChannelMessageSendEmbed(execCtx.ChannelID, embed)
+		if err != nil {
+			m.logger.Warn("Failed to send song added embed", zap.Error(err))
+		}
+	}()
+
+	return &ToolResult{
+		Success: true,
+		Message: fmt.Sprintf("Added to queue: %s (Position #%d)", song.Title, position),
+		Data: map[string]interface{}{
+			"title":    song.Title,
+			"duration": song.Duration,
+			"url":      song.URL,
+			"position": position,
+		},
+	}
+}
+
+// playlistFetcher streams a playlist's songs to songChan and returns once all are sent
+type playlistFetcher func(ctx context.Context, url, requester string, songChan chan<- music.Song) ([]music.Song, error)
+
+// playlistLoadTimeout bounds the background load of a whole playlist
+const playlistLoadTimeout = 2 * time.Minute
+
+// enqueueSong appends a song to the queue, starts playback if idle, and returns its position
+func (m *MusicExecutor) enqueueSong(bot *music.MusicBot, channelID string, song music.Song) int {
 	bot.Playlist.Lock()
 	bot.Playlist.Songs = append(bot.Playlist.Songs, song)
 	position := len(bot.Playlist.Songs)
@@ -198,29 +176,76 @@ func (m *MusicExecutor) handlePlay(ctx context.Context, execCtx *ExecutionContex
 	bot.Mu.Lock()
 	if !bot.IsPlaying {
 		bot.Mu.Unlock()
-		// Start playback in goroutine
-		go music.PlayQueue(bot, m.session, execCtx.ChannelID)
+		go music.PlayQueue(bot, m.session, channelID)
 	} else {
 		bot.Mu.Unlock()
 	}
 
-	// Send confirmation embed
+	return position
+}
+
+// playPlaylistAsync starts playing a playlist as soon as its first song resolves
+// and queues the rest in the background as they are found
+func (m *MusicExecutor) playPlaylistAsync(execCtx *ExecutionContext, bot *music.MusicBot, url, sourceName string, fetch playlistFetcher) *ToolResult {
+	songChan := make(chan music.Song, 10)
+	var fetchErr error
+
+	// The turn's context ends with the reply, so the load gets its own
 	go func() {
-		embed := music.CreateSongAddedEmbed(song, position)
-		_, err := m.session.ChannelMessageSendEmbed(execCtx.ChannelID, embed)
-		if err != nil {
+		loadCtx, cancel := context.WithTimeout(context.Background(), playlistLoadTimeout)
+		defer cancel()
+		_, fetchErr = fetch(loadCtx, url, execCtx.UserID, songChan)
+		close(songChan)
+	}()
+
+	first, ok := <-songChan
+	if !ok {
+		// Channel closed before any song arrived, so fetchErr is set and safe to read
+		if fetchErr == nil {
+			fetchErr = fmt.Errorf("no playable songs found")
+		}
+		return &ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Could not fetch %s playlist: %v", sourceName, fetchErr),
+		}
+	}
+
+	position := m.enqueueSong(bot, execCtx.ChannelID, first)
+
+	go func() {
+		added := 1
+		for song := range songChan {
+			m.enqueueSong(bot, execCtx.ChannelID, song)
+			added++
+		}
+		if fetchErr != nil {
+			m.logger.Warn("Playlist load ended early",
+				zap.String("url", url),
+				zap.Int("songs_added", added),
+				zap.Error(fetchErr),
+			)
+		}
+		if _, err := m.session.ChannelMessageSend(execCtx.ChannelID, fmt.Sprintf("📜 Finished loading %s playlist: %d songs queued", sourceName, added)); err != nil {
+			m.logger.Warn("Failed to send playlist loaded message", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		embed := music.CreateSongAddedEmbed(first, position)
+		if _, err := m.session.ChannelMessageSendEmbed(execCtx.ChannelID, embed); err != nil {
 			m.logger.Warn("Failed to send song added embed", zap.Error(err))
 		}
 	}()
 
 	return &ToolResult{
 		Success: true,
-		Message: fmt.Sprintf("Added to queue: %s (Position #%d)", song.Title, position),
+		Message: fmt.Sprintf("Playing %s playlist: started with %s (Position #%d); remaining songs are loading in the background", sourceName, first.Title, position),
 		Data: map[string]interface{}{
-			"title":    song.Title,
-			"duration": song.Duration,
-			"url":      song.URL,
+			"title":    first.Title,
+			"duration": first.Duration,
+			"url":      first.URL,
 			"position": position,
+			"loading":  true,
 		},
 	}
 }
@@ -251,69 +276,15 @@ func (m *MusicExecutor) handlePlaylist(ctx context.Context, execCtx *ExecutionCo
 		}
 	}
 
-	// Get voice channel ID - match original bot's simple approach with fallback
+	// Get voice channel ID - fall back to the requester's current voice channel
 	channelID, _ := args["channel_id"].(string)
 	if channelID == "" {
-		m.logger.Debug("Attempting to detect user voice channel",
-			zap.String("guild_id", guildID),
-			zap.String("user_id", execCtx.UserID),
-		)
-
-		// Check if user is in a voice channel - exactly like original bot
-		// The original bot just uses State.VoiceState directly - it works because
-		// the state cache is populated by voice state update events
-		vs, err := m.session.State.VoiceState(guildID, execCtx.UserID)
-		if err != nil || vs == nil || vs.ChannelID == "" {
-			// If state cache doesn't have it, the voice state might not be tracked yet
-			// This can happen if the user joined before the bot started or state isn't synced
-			// Try to get guild and check voice states - but note that Guild() API call
-			// doesn't return voice states, only the state cache does
-			m.logger.Debug("Voice state not in cache, checking if guild state has voice states",
-				zap.String("guild_id", guildID),
-				zap.String("user_id", execCtx.UserID),
-				zap.Error(err),
-			)
-
-			// Try to get guild from state cache (which should have voice states if populated)
-			guild, err := m.session.State.Guild(guildID)
-			if err == nil && guild != nil && len(guild.VoiceStates) > 0 {
-				m.logger.Debug("Found voice states in guild state cache",
-					zap.Int("voice_state_count", len(guild.VoiceStates)),
-				)
-				for _, voiceState := range guild.VoiceStates {
-					if voiceState.UserID == execCtx.UserID && voiceState.ChannelID != "" {
-						channelID = voiceState.ChannelID
-						m.logger.Info("Found voice channel from guild state cache",
-							zap.String("channel_id", channelID),
-							zap.String("user_id", execCtx.UserID),
-						)
-						break
-					}
-				}
-			}
-
-			if channelID == "" {
-				m.logger.Warn("Could not find user voice channel - state cache may not be populated yet",
-					zap.String("guild_id", guildID),
-					zap.String("user_id", execCtx.UserID),
-					zap.Bool("guild_in_cache", err == nil && guild != nil),
-					zap.Int("voice_states_in_guild", func() int {
-						if guild != nil {
-							return len(guild.VoiceStates)
-						}
-						return 0
-					}()),
-				)
-				return &ToolResult{
-					Success: false,
-					Error:   "You must be in a voice channel to play music. Please join a voice channel first or specify channel_id.",
-				}
+		channelID = m.detectUserVoiceChannel(guildID, execCtx.UserID)
+		if channelID == "" {
+			return &ToolResult{
+				Success: false,
+				Error:   "You must be in a voice channel to play music. Please join a voice channel first or specify channel_id.",
 			}
-		} else {
-			channelID = vs.ChannelID
-			m.logger.Debug("Found voice channel from session state",
-				zap.String("channel_id", channelID),
-			)
 		}
 	}
 
@@ -335,7 +306,12 @@ func (m *MusicExecutor) handlePlaylist(ctx context.Context, execCtx *ExecutionCo
 		for attempt := 0; attempt < maxRetries; attempt++ {
 			if attempt > 0 {
 				m.logger.Debug("Retrying voice channel join...", zap.Int("attempt", attempt+1))
-				time.Sleep(1 * time.Second) // Wait before retry
+				// Wait before retry
+				select {
+				case <-ctx.Done():
+					return &ToolResult{Success: false, Error: fmt.Sprintf("Joining the voice channel was cancelled: %v", ctx.Err())}
+				case <-time.After(1 * time.Second):
+				}
 			}
 
 			vc, err = m.session.ChannelVoiceJoin(guildID, channelID, false, true)
@@ -364,6 +340,8 @@ func (m *MusicExecutor) handlePlaylist(ctx context.Context, execCtx *ExecutionCo
 			case <-timeout:
 				m.logger.Warn("Voice connection timeout, continuing anyway...")
 				timeoutReached = true
+			case <-ctx.Done():
+				return &ToolResult{Success: false, Error: fmt.Sprintf("Joining the voice channel was cancelled: %v", ctx.Err())}
 			case <-ticker.C:
 			}
 		}
@@ -373,11 +351,15 @@ func (m *MusicExecutor) handlePlaylist(ctx context.Context, execCtx *ExecutionCo
 	// Generate playlist using OpenRouter (if available) - streaming mode
 	// This returns immediately, songs are added to queue as they're found
 	// GenerateAndPlayPlaylist now handles sending and updating the progress message
-	music.GenerateAndPlayPlaylist(query, execCtx.UserID, bot, m.session, execCtx.ChannelID)
+	music.GenerateAndPlayPlaylist(ctx, query, execCtx.UserID, bot, m.session, execCtx.ChannelID)
 
 	// Give it time for OpenRouter to generate queries and YouTube to find first song
-	// OpenRouter API call + YouTube search can take 3-5 seconds
-	time.Sleep(5 * time.Second)
+	// OpenRouter API call + YouTube search can take 3-5 seconds; the
+	// generation carries on if the call is cancelled first
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+	}
 	bot.Playlist.Lock()
 	songCount := len(bot.Playlist.Songs)
 	bot.Playlist.Unlock()
@@ -403,9 +385,11 @@ func (m *MusicExecutor) handleQueue(ctx context.Context, execCtx *ExecutionConte
 	page := bot.QueuePage
 	bot.Playlist.Unlock()
 
+	elapsed := bot.Elapsed()
+
 	// Send queue embed
 	go func() {
-		embed := music.CreateQueueEmbed(bot.Playlist, page)
+		embed := music.CreateQueueEmbed(bot.Playlist, page, elapsed)
 		_, err := m.session.ChannelMessageSendEmbed(execCtx.ChannelID, embed)
 		if err != nil {
 			m.logger.Warn("Failed to send queue embed", zap.Error(err))
@@ -434,6 +418,59 @@ func (m *MusicExecutor) handleQueue(ctx context.Context, execCtx *ExecutionConte
 	}
 }
 
+func (m *MusicExecutor) handleNowPlaying(ctx context.Context, execCtx *ExecutionContext, bot *music.MusicBot, args map[string]interface{}) *ToolResult {
+	bot.Mu.Lock()
+	isPlaying := bot.IsPlaying
+	isPaused := bot.IsPaused
+	bot.Mu.Unlock()
+
+	bot.Playlist.Lock()
+	current := bot.Playlist.Current
+	total := len(bot.Playlist.Songs)
+	var song music.Song
+	if current >= 0 && current < total {
+		song = bot.Playlist.Songs[current]
+	}
+	bot.Playlist.Unlock()
+
+	if !isPlaying || current < 0 || current >= total {
+		return &ToolResult{
+			Success: false,
+			Error:   "Nothing is playing right now",
+		}
+	}
+
+	elapsed := bot.Elapsed()
+	progress := music.FormatProgress(song, elapsed)
+	progressBar := music.ProgressBar(song, elapsed)
+
+	go func() {
+		embed := music.CreateNowPlayingEmbed(song, current+1, total, elapsed)
+		_, err := m.session.ChannelMessageSendEmbed(execCtx.ChannelID, embed)
+		if err != nil {
+			m.logger.Warn("Failed to send now playing embed", zap.Error(err))
+		}
+	}()
+
+	return &ToolResult{
+		Success: true,
+		Message: fmt.Sprintf("Now playing: %s (%s)", song.Title, progress),
+		Data: map[string]interface{}{
+			"title":           song.Title,
+			"url":             song.URL,
+			"duration":        song.Duration,
+			"elapsed":         music.FormatDurationFromSeconds(int(elapsed.Seconds())),
+			"elapsed_seconds": int(elapsed.Seconds()),
+			"source":          song.Source,
+			"progress":        progress,
+			"progress_bar":    progressBar,
+			"paused":          isPaused,
+			"position":        current + 1,
+			"total":           total,
+		},
+	}
+}
+
 func (m *MusicExecutor) handleSkip(ctx context.Context, execCtx *ExecutionContext, bot *music.MusicBot, args map[string]interface{}) *ToolResult {
 	select {
 	case bot.SkipChan <- true:
@@ -446,6 +483,54 @@ func (m *MusicExecutor) handleSkip(ctx context.Context, execCtx *ExecutionContex
 	}
 }
 
+func (m *MusicExecutor) handleSeek(ctx context.Context, execCtx *ExecutionContext, bot *music.MusicBot, args map[string]interface{}) *ToolResult {
+	var position string
+	switch v := args["position"].(type) {
+	case string:
+		position = v
+	case float64:
+		position = fmt.Sprintf("%d", int(v))
+	}
+	if position == "" {
+		return &ToolResult{
+			Success: false,
+			Error:   "Position is required (seconds or M:SS)",
+		}
+	}
+
+	seconds, err := music.ParseTimestamp(position)
+	if err != nil {
+		return &ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid position %q: %v", position, err),
+		}
+	}
+
+	song, err := music.Seek(bot, time.Duration(seconds)*time.Second)
+	if err != nil {
+		if errors.Is(err, music.ErrSeekPastEnd) {
+			return &ToolResult{
+				Success: false,
+				Error:   fmt.Sprintf("Can't seek to %s: %s is only %s long", music.FormatDurationFromSeconds(seconds), song.Title, song.Duration),
+			}
+		}
+		return &ToolResult{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
+	return &ToolResult{
+		Success: true,
+		Message: fmt.Sprintf("Seeking to %s in %s", music.FormatDurationFromSeconds(seconds), song.Title),
+		Data: map[string]interface{}{
+			"title":    song.Title,
+			"position": music.FormatDurationFromSeconds(seconds),
+			"duration": song.Duration,
+		},
+	}
+}
+
 func (m *MusicExecutor) handlePause(ctx context.Context, execCtx *ExecutionContext, bot *music.MusicBot, args map[string]interface{}) *ToolResult {
 	select {
 	case bot.PauseChan <- true:
@@ -489,24 +574,8 @@ func (m *MusicExecutor) handleStop(ctx context.Context, execCtx *ExecutionContex
 }
 
 func (m *MusicExecutor) handleVolume(ctx context.Context, execCtx *ExecutionContext, bot *music.MusicBot, args map[string]interface{}) *ToolResult {
-	volume, ok := args["volume"].(float64)
-	if !ok {
-		volInt, ok := args["volume"].(int)
-		if !ok {
-			return &ToolResult{
-				Success: false,
-				Error:   "Volume must be a number",
-			}
-		}
-		volume = float64(volInt)
-	}
-
-	if volume < 0 || volume > 100 {
-		return &ToolResult{
-			Success: false,
-			Error:   "Volume must be between 0 and 100",
-		}
-	}
+	// The executor has checked volume against the tool's schema
+	volume, _ := args["volume"].(float64)
 
 	// Note: Discord voice connections don't support volume control directly
 	// This would need to be implemented at the audio processing level
@@ -546,10 +615,11 @@ func (m *MusicExecutor) handleRadio(ctx context.Context, execCtx *ExecutionConte
 	bot.RadioEnabled = true
 	bot.RadioSeed = seed
 	bot.RadioChannelID = execCtx.ChannelID
+	bot.RadioModel = adapter.AuxiliaryModelFrom(ctx)
 	bot.RadioMu.Unlock()
 
 	// Start radio playback
-	go music.StartRadioMode(bot, m.session, seed, execCtx.ChannelID)
+	go music.StartRadioMode(bot, m.session, seed, execCtx.ChannelID, adapter.AuxiliaryModelFrom(ctx))
 
 	return &ToolResult{
 		Success: true,
@@ -558,66 +628,10 @@ func (m *MusicExecutor) handleRadio(ctx context.Context, execCtx *ExecutionConte
 }
 
 func (m *MusicExecutor) handleDisconnect(ctx context.Context, execCtx *ExecutionContext, bot *music.MusicBot, args map[string]interface{}) *ToolResult {
-	// Stop playback
-	select {
-	case bot.StopChan <- true:
-	default:
-	}
-
-	// Clear queue
-	bot.Playlist.Lock()
-	bot.Playlist.Songs = []music.Song{}
-	bot.Playlist.Current = -1
-	bot.Playlist.Unlock()
-
-	// Clear radio state
-	bot.ClearRadioState()
-
-	// Disconnect from voice channel
-	if bot.VoiceConn != nil {
-		bot.Mu.Lock()
-		if bot.IsSpeaking {
-			bot.VoiceConn.Speaking(false)
-			bot.IsSpeaking = false
-		}
-		vc := bot.VoiceConn
-		bot.VoiceConn = nil
-		bot.Mu.Unlock()
-
-		// Clean up preload
-		bot.PreloadMu.Lock()
-		if bot.Preloaded != nil {
-			preloaded := bot.Preloaded
-			// Cancel the context to stop the goroutine
-			if preloaded.Cancel != nil {
-				preloaded.Cancel()
-			}
-			// Kill the process
-			if preloaded.YtdlpCmd != nil {
-				if cmd, ok := preloaded.YtdlpCmd.(*exec.Cmd); ok && cmd.Process != nil {
-					cmd.Process.Kill()
-				}
-			}
-			// Close the output stream
-			if preloaded.OpusOut != nil {
-				if closer, ok := preloaded.OpusOut.(io.ReadCloser); ok {
-					closer.Close()
-				}
-			}
-			bot.Preloaded = nil
-		}
-		bot.PreloadMu.Unlock()
-
-		// Disconnect
-		if vc != nil {
-			vc.Disconnect()
-			m.logger.Info("Disconnected from voice channel", zap.String("guild_id", bot.GuildID))
-		}
-	}
+	bot.Disconnect()
 
 	return &ToolResult{
 		Success: true,
 		Message: "Disconnected from voice channel",
 	}
 }
-
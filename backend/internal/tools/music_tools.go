@@ -74,6 +74,23 @@ func GetMusicTools() []adapter.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolMusicNowPlaying,
+				Description: "Show the song that is currently playing, with elapsed time and a progress bar.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"guild_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Discord guild ID (leave empty for current guild)",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: adapter.FunctionDefinition{
@@ -91,6 +108,27 @@ func GetMusicTools() []adapter.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolMusicSeek,
+				Description: "Jump to a position in the song that is currently playing. Not available for live streams.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"position": map[string]interface{}{
+							"type":        "string",
+							"description": "Position to jump to, in seconds (\"90\") or as M:SS / H:MM:SS (\"1:30\")",
+						},
+						"guild_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Discord guild ID (leave empty for current guild)",
+						},
+					},
+					"required": []string{"position"},
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: adapter.FunctionDefinition{
@@ -152,6 +190,8 @@ func GetMusicTools() []adapter.Tool {
 					"properties": map[string]interface{}{
 						"volume": map[string]interface{}{
 							"type":        "integer",
+							"minimum":     0,
+							"maximum":     100,
 							"description": "Volume level (0-100, default: 100)",
 						},
 						"guild_id": map[string]interface{}{
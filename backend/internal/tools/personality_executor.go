@@ -2,8 +2,11 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
+	"ezra-clone/backend/internal/graph"
+
 	"go.uber.org/zap"
 )
 
@@ -101,7 +104,9 @@ func (e *Executor) executeMimicPersonality(ctx context.Context, execCtx *Executi
 		Active:              true,
 		OriginalPersonality: originalPersonality,
 		MimicProfile:        profile,
+		ChannelID:           channelID,
 	}
+	e.saveMimicState(ctx, execCtx.AgentID)
 
 	// Start background task if available
 	if e.mimicBackgroundTask != nil {
@@ -148,6 +153,7 @@ func (e *Executor) executeRevertPersonality(ctx context.Context, execCtx *Execut
 
 	// Clear the mimic state
 	delete(e.mimicStates, execCtx.AgentID)
+	e.saveMimicState(ctx, execCtx.AgentID)
 
 	e.logger.Info("Mimic mode deactivated",
 		zap.String("agent_id", execCtx.AgentID),
@@ -160,6 +166,69 @@ func (e *Executor) executeRevertPersonality(ctx context.Context, execCtx *Execut
 	}
 }
 
+// saveMimicState persists the agent's mimic state, or that it has none, so
+// it survives restarts. Failures are logged; mimicry still works until then.
+func (e *Executor) saveMimicState(ctx context.Context, agentID string) {
+	if e.repo == nil {
+		return
+	}
+	record := graph.MimicState{AgentID: agentID}
+	if state := e.mimicStates[agentID]; state != nil && state.Active && state.MimicProfile != nil {
+		profileJSON, err := json.Marshal(state.MimicProfile)
+		if err != nil {
+			e.logger.Warn("Failed to encode mimic profile", zap.String("agent_id", agentID), zap.Error(err))
+			return
+		}
+		record.Active = true
+		record.UserID = state.MimicProfile.UserID
+		record.ChannelID = state.ChannelID
+		record.OriginalPersonality = state.OriginalPersonality
+		record.ProfileJSON = string(profileJSON)
+	}
+	if err := e.repo.SaveMimicState(ctx, record); err != nil {
+		e.logger.Warn("Failed to persist mimic state", zap.String("agent_id", agentID), zap.Error(err))
+	}
+}
+
+// RestoreMimicStates reloads the mimic sessions active before a restart and
+// restarts their background task. It returns how many were restored.
+func (e *Executor) RestoreMimicStates(ctx context.Context) (int, error) {
+	if e.repo == nil {
+		return 0, nil
+	}
+	records, err := e.repo.LoadMimicStates(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	restored := 0
+	for _, record := range records {
+		var profile PersonalityProfile
+		if err := json.Unmarshal([]byte(record.ProfileJSON), &profile); err != nil {
+			e.logger.Warn("Skipping mimic state with an unreadable profile",
+				zap.String("agent_id", record.AgentID),
+				zap.Error(err),
+			)
+			continue
+		}
+		e.mimicStates[record.AgentID] = &MimicState{
+			Active:              true,
+			OriginalPersonality: record.OriginalPersonality,
+			MimicProfile:        &profile,
+			ChannelID:           record.ChannelID,
+		}
+		if e.mimicBackgroundTask != nil {
+			e.mimicBackgroundTask.Start(record.AgentID)
+		}
+		e.logger.Info("Mimic mode restored",
+			zap.String("agent_id", record.AgentID),
+			zap.String("mimicking_user", profile.Username),
+		)
+		restored++
+	}
+	return restored, nil
+}
+
 func (e *Executor) executeAnalyzeUserStyle(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
 	if e.discordExecutor == nil {
 		return &ToolResult{Success: false, Error: "Discord not available"}
@@ -194,6 +263,7 @@ func (e *Executor) executeAnalyzeUserStyle(ctx context.Context, execCtx *Executi
 			"tone":               profile.ToneIndicators,
 			"common_words":       profile.CommonWords,
 			"emoji_usage":        profile.EmojiUsage,
+			"emoji_distribution": profile.EmojiDistribution,
 			"sample_messages":    profile.SampleMessages,
 		},
 		Message: fmt.Sprintf("Analyzed %d messages from %s", profile.MessageCount, profile.Username),
@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/graph"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// ============================================================================
+// Interest Poll Implementation
+// ============================================================================
+
+// pollReactions are the vote reactions, one per option
+var pollReactions = []string{"🇦", "🇧", "🇨", "🇩"}
+
+// InterestPoll is a reaction-voted poll built from channel interests
+type InterestPoll struct {
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+}
+
+// buildInterestPoll picks the most shared topics as options and writes a default question
+func buildInterestPoll(topics []graph.Topic, numOptions int) (*InterestPoll, error) {
+	if numOptions < 2 {
+		numOptions = 2
+	}
+	if numOptions > len(pollReactions) {
+		numOptions = len(pollReactions)
+	}
+
+	var options []string
+	seen := make(map[string]bool)
+	for _, topic := range topics {
+		key := strings.ToLower(strings.TrimSpace(topic.Name))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		options = append(options, topic.Name)
+		if len(options) == numOptions {
+			break
+		}
+	}
+
+	if len(options) < 2 {
+		return nil, fmt.Errorf("not enough known interests in this channel for a poll (found %d)", len(options))
+	}
+
+	question := fmt.Sprintf("This or that: %s?", strings.Join(options, " or "))
+	if len(options) > 2 {
+		question = "Which of these would you rather talk about?"
+	}
+
+	return &InterestPoll{Question: question, Options: options}, nil
+}
+
+func (e *Executor) executeInterestPoll(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	if e.discordExecutor == nil || e.discordExecutor.session == nil {
+		return &ToolResult{Success: false, Error: "Discord not available (only works in Discord bot context)"}
+	}
+	if execCtx.ChannelID == "" {
+		return &ToolResult{Success: false, Error: "No channel to post the poll in"}
+	}
+
+	numOptions := 2
+	if n, ok := args["options"].(float64); ok {
+		numOptions = int(n)
+	}
+
+	topics, err := e.repo.GetChannelInterestTopics(ctx, execCtx.ChannelID, 10)
+	if err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+
+	poll, err := buildInterestPoll(topics, numOptions)
+	if err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+
+	// Let the LLM make the question more fun; options stay tied to real topics
+	if e.llmAdapter != nil {
+		if question := e.generatePollQuestion(ctx, poll.Options); question != "" {
+			poll.Question = question
+		}
+	}
+
+	var lines []string
+	for i, option := range poll.Options {
+		lines = append(lines, fmt.Sprintf("%s  **%s**", pollReactions[i], option))
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "📊 " + poll.Question,
+		Description: strings.Join(lines, "\n"),
+		Color:       0x5865F2,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Vote with a reaction!"},
+	}
+
+	session := e.discordExecutor.session
+	msg, err := session.ChannelMessageSendEmbed(execCtx.ChannelID, embed)
+	if err != nil {
+		return &ToolResult{Success: false, Error: fmt.Sprintf("Failed to post poll: %v", err)}
+	}
+	for i := range poll.Options {
+		if err := session.MessageReactionAdd(execCtx.ChannelID, msg.ID, pollReactions[i]); err != nil {
+			e.logger.Warn("Failed to add poll reaction", zap.Error(err))
+		}
+	}
+
+	return &ToolResult{
+		Success: true,
+		Data:    poll,
+		Message: fmt.Sprintf("Posted poll: %s (%s). The poll is already posted, so don't repeat it.", poll.Question, strings.Join(poll.Options, ", ")),
+	}
+}
+
+// generatePollQuestion asks the LLM for a one-line poll question; returns "" on failure
+func (e *Executor) generatePollQuestion(ctx context.Context, options []string) string {
+	systemPrompt := "You write short, playful Discord poll questions. Reply with the question only, one line, no quotes, under 100 characters."
+	userPrompt := fmt.Sprintf("Write a fun 'this or that' style question where the choices are: %s", strings.Join(options, ", "))
+
+	response, err := e.llmAdapter.Generate(ctx, adapter.GenerateParams{Auxiliary: true}, systemPrompt, userPrompt, []adapter.Tool{})
+	if err != nil {
+		e.logger.Debug("Poll question generation failed, using default", zap.Error(err))
+		return ""
+	}
+
+	question := strings.Trim(strings.TrimSpace(response.Content), `"`)
+	if question == "" || strings.Contains(question, "\n") || len(question) > 200 {
+		return ""
+	}
+	return question
+}
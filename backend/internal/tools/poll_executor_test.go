@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"ezra-clone/backend/internal/graph"
+)
+
+func TestBuildInterestPoll_UsesSeededTopics(t *testing.T) {
+	// Topics as GetChannelInterestTopics returns them for a seeded channel
+	seeded := []graph.Topic{
+		{ID: "t1", Name: "Hazbin Hotel"},
+		{ID: "t2", Name: "Pizza"},
+		{ID: "t3", Name: "Rust"},
+	}
+
+	poll, err := buildInterestPoll(seeded, 2)
+	if err != nil {
+		t.Fatalf("buildInterestPoll failed: %v", err)
+	}
+
+	if len(poll.Options) != 2 {
+		t.Fatalf("Expected 2 options, got %d", len(poll.Options))
+	}
+	known := map[string]bool{"Hazbin Hotel": true, "Pizza": true, "Rust": true}
+	for _, option := range poll.Options {
+		if !known[option] {
+			t.Errorf("Option %q is not a seeded topic", option)
+		}
+		if !strings.Contains(poll.Question, option) {
+			t.Errorf("Expected question %q to mention %q", poll.Question, option)
+		}
+	}
+}
+
+func TestBuildInterestPoll_NotEnoughTopics(t *testing.T) {
+	if _, err := buildInterestPoll([]graph.Topic{{Name: "Pizza"}, {Name: "pizza"}}, 2); err == nil {
+		t.Error("Expected an error when fewer than two distinct topics are known")
+	}
+}
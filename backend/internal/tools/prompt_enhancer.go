@@ -39,7 +39,7 @@ func (p *PromptEnhancer) Enhance(ctx context.Context, userRequest string) (strin
 	// Call LLM to enhance the prompt
 	// We use the LLM adapter's Generate method, but we need to make a direct API call
 	// since we want a simple text completion, not tool calling
-	response, err := p.llmAdapter.Generate(ctx, systemPrompt, userRequest, []adapter.Tool{})
+	response, err := p.llmAdapter.Generate(ctx, adapter.GenerateParams{Auxiliary: true}, systemPrompt, userRequest, []adapter.Tool{})
 	if err != nil {
 		p.logger.Warn("Failed to enhance prompt, using original",
 			zap.Error(err),
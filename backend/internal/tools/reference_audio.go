@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultReferenceName is the reference TTS falls back to when a user has none
+const DefaultReferenceName = "default"
+
+// referenceAudioExt is the file extension used for stored reference clips
+const referenceAudioExt = ".wav"
+
+var (
+	// ErrReferenceNotFound is returned when no reference is stored under a name
+	ErrReferenceNotFound = errors.New("voice reference not found")
+	// ErrDefaultReferenceProtected is returned when deleting the default
+	// reference without forcing it, since every user without their own
+	// reference falls back to it
+	ErrDefaultReferenceProtected = errors.New("the default voice reference is used as the fallback for all users")
+)
+
+// ReferenceAudio describes a stored voice reference clip
+type ReferenceAudio struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size_bytes"`
+	UpdatedAt time.Time `json:"updated_at"`
+	IsDefault bool      `json:"is_default"`
+}
+
+// ReferenceAudioManager manages the voice reference clips on disk, one file per
+// user ID plus a shared default
+type ReferenceAudioManager struct {
+	dir string
+}
+
+// NewReferenceAudioManager creates a manager rooted at dir
+func NewReferenceAudioManager(dir string) *ReferenceAudioManager {
+	return &ReferenceAudioManager{dir: dir}
+}
+
+// Path returns the file path for a reference name
+func (r *ReferenceAudioManager) Path(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid reference name: %q", name)
+	}
+	return filepath.Join(r.dir, name+referenceAudioExt), nil
+}
+
+// List returns all stored references sorted by name, with the default first
+func (r *ReferenceAudioManager) List() ([]ReferenceAudio, error) {
+	entries, err := os.ReadDir(r.dir)
+	if os.IsNotExist(err) {
+		return []ReferenceAudio{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+
+	refs := []ReferenceAudio{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != referenceAudioExt {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		refs = append(refs, *referenceFromInfo(info))
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].IsDefault != refs[j].IsDefault {
+			return refs[i].IsDefault
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs, nil
+}
+
+// Delete removes the reference stored under name. The default reference is
+// only removed when force is set.
+func (r *ReferenceAudioManager) Delete(name string, force bool) error {
+	path, err := r.Path(name)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(name) == DefaultReferenceName && !force {
+		return ErrDefaultReferenceProtected
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrReferenceNotFound
+		}
+		return fmt.Errorf("failed to delete reference: %w", err)
+	}
+	return nil
+}
+
+func referenceFromInfo(info os.FileInfo) *ReferenceAudio {
+	name := strings.TrimSuffix(info.Name(), referenceAudioExt)
+	return &ReferenceAudio{
+		Name:      name,
+		Size:      info.Size(),
+		UpdatedAt: info.ModTime(),
+		IsDefault: name == DefaultReferenceName,
+	}
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferenceAudioManager_ListAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	mgr := NewReferenceAudioManager(dir)
+
+	for name, audio := range map[string]string{"user-b": "b", DefaultReferenceName: "default", "user-a": "aa"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+referenceAudioExt), []byte(audio), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a clip"), 0644))
+
+	refs, err := mgr.List()
+	require.NoError(t, err)
+	require.Len(t, refs, 3)
+	assert.Equal(t, DefaultReferenceName, refs[0].Name)
+	assert.True(t, refs[0].IsDefault)
+	assert.Equal(t, "user-a", refs[1].Name)
+	assert.Equal(t, int64(2), refs[1].Size)
+
+	assert.ErrorIs(t, mgr.Delete("missing", false), ErrReferenceNotFound)
+	assert.ErrorIs(t, mgr.Delete(DefaultReferenceName, false), ErrDefaultReferenceProtected)
+	assert.Error(t, mgr.Delete("../user-a", false))
+
+	require.NoError(t, mgr.Delete("user-a", false))
+	require.NoError(t, mgr.Delete(DefaultReferenceName, true))
+
+	refs, err = mgr.List()
+	require.NoError(t, err)
+	require.Len(t, refs, 1)
+	assert.Equal(t, "user-b", refs[0].Name)
+}
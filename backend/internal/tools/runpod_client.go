@@ -125,6 +125,31 @@ func (c *RunPodClient) SubmitJob(ctx context.Context, workflowPayload map[string
 	return jobResp.ID, nil
 }
 
+// CancelJob cancels a queued or running job
+func (c *RunPodClient) CancelJob(ctx context.Context, jobID string) error {
+	url := fmt.Sprintf("https://api.runpod.ai/v2/%s/cancel/%s", c.endpointID, jobID)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to cancel job: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("RunPod API error: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	c.logger.Info("Job cancelled", zap.String("job_id", jobID))
+	return nil
+}
+
 // PollStatus polls for job completion
 func (c *RunPodClient) PollStatus(ctx context.Context, jobID string, maxPolls int, pollInterval time.Duration) (*JobStatus, error) {
 	url := fmt.Sprintf("https://api.runpod.ai/v2/%s/status/%s", c.endpointID, jobID)
@@ -144,11 +169,16 @@ func (c *RunPodClient) PollStatus(ctx context.Context, jobID string, maxPolls in
 
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			c.logger.Warn("Poll request failed, retrying",
 				zap.Error(err),
 				zap.Int("attempt", i+1),
 			)
-			time.Sleep(pollInterval)
+			if err := waitPoll(ctx, pollInterval); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
@@ -159,7 +189,9 @@ func (c *RunPodClient) PollStatus(ctx context.Context, jobID string, maxPolls in
 				zap.Error(err),
 				zap.Int("attempt", i+1),
 			)
-			time.Sleep(pollInterval)
+			if err := waitPoll(ctx, pollInterval); err != nil {
+				return nil, err
+			}
 			continue
 		}
 		resp.Body.Close()
@@ -183,10 +215,8 @@ func (c *RunPodClient) PollStatus(ctx context.Context, jobID string, maxPolls in
 
 		// Wait before next poll
 		if i < maxPolls-1 {
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(pollInterval):
+			if err := waitPoll(ctx, pollInterval); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -194,6 +224,17 @@ func (c *RunPodClient) PollStatus(ctx context.Context, jobID string, maxPolls in
 	return nil, fmt.Errorf("job did not complete within %d polls", maxPolls)
 }
 
+// waitPoll waits for the poll interval, returning early with the context's
+// error if it is cancelled first
+func waitPoll(ctx context.Context, pollInterval time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(pollInterval):
+		return nil
+	}
+}
+
 // GetJobOutput extracts image data from a completed job
 func (c *RunPodClient) GetJobOutput(status *JobStatus) ([]byte, error) {
 	if status.Output == nil {
@@ -0,0 +1,358 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"ezra-clone/backend/internal/constants"
+	"ezra-clone/backend/internal/graph"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// ScheduledMessageTick is how often the scheduler checks for due messages
+	ScheduledMessageTick = time.Second
+	// maxPendingScheduledMessages caps the pending messages each user has scheduled with an agent
+	maxPendingScheduledMessages = 100
+	// maxScheduleAhead is the furthest ahead a message may be scheduled
+	maxScheduleAhead = 365 * 24 * time.Hour
+)
+
+// What happens to messages that came due while the bot was down
+const (
+	MissedMessagesFire = "fire" // Send them as soon as the bot is back
+	MissedMessagesSkip = "skip" // Drop them, recording them as skipped
+)
+
+// ScheduledMessageStore persists scheduled messages; *graph.Repository implements it
+type ScheduledMessageStore interface {
+	CreateScheduledMessage(ctx context.Context, msg graph.ScheduledMessage) error
+	ListPendingScheduledMessages(ctx context.Context, agentID string) ([]graph.ScheduledMessage, error)
+	FinishScheduledMessage(ctx context.Context, agentID, id, status string) (bool, error)
+}
+
+// ScheduledMessageSender posts to channels, opens DMs and looks up channels
+// and permissions to check where a message may go; *discordgo.Session implements it
+type ScheduledMessageSender interface {
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	UserChannelPermissions(userID, channelID string, fetchOptions ...discordgo.RequestOption) (int64, error)
+}
+
+// MessageScheduler sends messages at a future time. Pending messages are kept
+// in the store so they survive restarts and in memory, soonest first, where a
+// single ticker sends the ones that have come due.
+type MessageScheduler struct {
+	store      ScheduledMessageStore
+	sender     ScheduledMessageSender
+	logger     *zap.Logger
+	now        func() time.Time
+	fireMissed bool
+
+	mu       sync.Mutex
+	pending  []graph.ScheduledMessage // Sorted by FireAt, then ID
+	reserved map[scheduleOwner]int    // Cap slots held by Schedule calls still storing their message
+}
+
+// scheduleOwner is whose pending messages count toward one cap
+type scheduleOwner struct {
+	agentID   string
+	createdBy string
+}
+
+// NewMessageScheduler creates a scheduler that sends through sender. Call
+// Load to pick up messages scheduled before a restart, then Start.
+func NewMessageScheduler(store ScheduledMessageStore, sender ScheduledMessageSender, logger *zap.Logger) *MessageScheduler {
+	return &MessageScheduler{
+		store:      store,
+		sender:     sender,
+		logger:     logger,
+		now:        time.Now,
+		fireMissed: true,
+		reserved:   make(map[scheduleOwner]int),
+	}
+}
+
+// SetMissedPolicy picks what Load does with messages that came due while the
+// bot was down: MissedMessagesFire sends them right away, MissedMessagesSkip
+// drops them
+func (s *MessageScheduler) SetMissedPolicy(policy string) error {
+	switch policy {
+	case MissedMessagesFire:
+		s.fireMissed = true
+	case MissedMessagesSkip:
+		s.fireMissed = false
+	default:
+		return fmt.Errorf("unknown missed message policy %q (use %s or %s)", policy, MissedMessagesFire, MissedMessagesSkip)
+	}
+	return nil
+}
+
+// Load replaces the in-memory queue with the pending messages in the store
+// and returns how many were queued. Messages already due are sent on the
+// first tick or skipped, per the missed message policy.
+func (s *MessageScheduler) Load(ctx context.Context) (int, error) {
+	messages, err := s.store.ListPendingScheduledMessages(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	pending := make([]graph.ScheduledMessage, 0, len(messages))
+	for _, msg := range messages {
+		if !s.fireMissed && msg.FireAt.Before(now) {
+			if _, err := s.store.FinishScheduledMessage(ctx, msg.AgentID, msg.ID, graph.ScheduledMessageSkipped); err != nil {
+				s.logger.Warn("Failed to skip missed scheduled message",
+					zap.String("id", msg.ID),
+					zap.Error(err),
+				)
+			}
+			s.logger.Info("Skipped scheduled message that came due while offline",
+				zap.String("id", msg.ID),
+				zap.String("agent_id", msg.AgentID),
+				zap.Time("fire_at", msg.FireAt),
+			)
+			continue
+		}
+		pending = append(pending, msg)
+	}
+	sortScheduledMessages(pending)
+
+	s.mu.Lock()
+	s.pending = pending
+	s.mu.Unlock()
+	return len(pending), nil
+}
+
+// Start sends due messages every interval until the returned stop function is called
+func (s *MessageScheduler) Start(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				s.sendDue(context.Background())
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// Schedule validates msg, fills in its ID and creation time, stores it and
+// queues it. Exactly one of ChannelID and UserID must be set. Each user
+// (msg.CreatedBy) may have maxPendingScheduledMessages pending per agent.
+func (s *MessageScheduler) Schedule(ctx context.Context, msg graph.ScheduledMessage) (graph.ScheduledMessage, error) {
+	now := s.now()
+	switch {
+	case msg.Content == "":
+		return msg, fmt.Errorf("content is required")
+	case len(msg.Content) > constants.DiscordMaxMessageLength:
+		return msg, fmt.Errorf("content is %d characters; Discord allows %d", len(msg.Content), constants.DiscordMaxMessageLength)
+	case (msg.ChannelID == "") == (msg.UserID == ""):
+		return msg, fmt.Errorf("set either a channel or a user to send to")
+	case !msg.FireAt.After(now):
+		return msg, fmt.Errorf("the send time %s has already passed", msg.FireAt.UTC().Format(time.RFC3339))
+	case msg.FireAt.Sub(now) > maxScheduleAhead:
+		return msg, fmt.Errorf("messages can be scheduled at most %d days ahead", int(maxScheduleAhead.Hours()/24))
+	}
+	msg.ID = uuid.New().String()
+	msg.CreatedAt = now
+
+	// Reserve a slot under the cap so concurrent calls can't pass it together,
+	// then queue only once the message is stored: a message queued first could
+	// be sent and marked sent before it exists in the store
+	owner := scheduleOwner{agentID: msg.AgentID, createdBy: msg.CreatedBy}
+	s.mu.Lock()
+	count := s.reserved[owner]
+	for _, pending := range s.pending {
+		if pending.AgentID == msg.AgentID && pending.CreatedBy == msg.CreatedBy {
+			count++
+		}
+	}
+	if count >= maxPendingScheduledMessages {
+		s.mu.Unlock()
+		return msg, fmt.Errorf("too many scheduled messages (limit %d); cancel some first", maxPendingScheduledMessages)
+	}
+	s.reserved[owner]++
+	s.mu.Unlock()
+
+	err := s.store.CreateScheduledMessage(ctx, msg)
+
+	s.mu.Lock()
+	if s.reserved[owner]--; s.reserved[owner] == 0 {
+		delete(s.reserved, owner)
+	}
+	if err == nil {
+		s.pending = append(s.pending, msg)
+		sortScheduledMessages(s.pending)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return msg, err
+	}
+
+	s.logger.Info("Message scheduled",
+		zap.String("id", msg.ID),
+		zap.String("agent_id", msg.AgentID),
+		zap.Time("fire_at", msg.FireAt),
+	)
+	return msg, nil
+}
+
+// List returns an agent's pending messages, soonest first. A non-empty
+// createdBy only returns the messages that user scheduled.
+func (s *MessageScheduler) List(agentID, createdBy string) []graph.ScheduledMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var messages []graph.ScheduledMessage
+	for _, msg := range s.pending {
+		if msg.AgentID == agentID && (createdBy == "" || msg.CreatedBy == createdBy) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Cancel removes one of an agent's pending messages and reports whether it
+// was found. A non-empty createdBy only cancels messages that user scheduled.
+func (s *MessageScheduler) Cancel(ctx context.Context, agentID, createdBy, id string) (bool, error) {
+	s.mu.Lock()
+	index := -1
+	for i, msg := range s.pending {
+		if msg.ID == id && msg.AgentID == agentID && (createdBy == "" || msg.CreatedBy == createdBy) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		s.mu.Unlock()
+		return false, nil
+	}
+	msg := s.pending[index]
+	s.pending = append(s.pending[:index], s.pending[index+1:]...)
+	s.mu.Unlock()
+
+	cancelled, err := s.store.FinishScheduledMessage(ctx, agentID, id, graph.ScheduledMessageCancelled)
+	if err != nil {
+		// Put it back so it still goes out, as the store still has it pending
+		s.mu.Lock()
+		s.pending = append(s.pending, msg)
+		sortScheduledMessages(s.pending)
+		s.mu.Unlock()
+		return false, err
+	}
+	return cancelled, nil
+}
+
+// sendDue sends every queued message whose time has come
+func (s *MessageScheduler) sendDue(ctx context.Context) {
+	now := s.now()
+	s.mu.Lock()
+	due := 0
+	for due < len(s.pending) && !s.pending[due].FireAt.After(now) {
+		due++
+	}
+	messages := append([]graph.ScheduledMessage(nil), s.pending[:due]...)
+	s.pending = s.pending[due:]
+	s.mu.Unlock()
+
+	for _, msg := range messages {
+		status := graph.ScheduledMessageSent
+		if err := s.send(msg); err != nil {
+			status = graph.ScheduledMessageFailed
+			s.logger.Warn("Failed to send scheduled message",
+				zap.String("id", msg.ID),
+				zap.String("agent_id", msg.AgentID),
+				zap.Error(err),
+			)
+		} else {
+			s.logger.Info("Scheduled message sent",
+				zap.String("id", msg.ID),
+				zap.String("agent_id", msg.AgentID),
+				zap.Duration("late_by", now.Sub(msg.FireAt)),
+			)
+		}
+		if _, err := s.store.FinishScheduledMessage(ctx, msg.AgentID, msg.ID, status); err != nil {
+			// It stays pending in the store and would be sent again after a restart
+			s.logger.Error("Failed to record scheduled message status",
+				zap.String("id", msg.ID),
+				zap.String("status", status),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// CheckChannel reports why requesterID, talking in fromChannelID, may not
+// schedule a message into channelID, or nil if they may: it must be the
+// same channel, or a channel of the same server the requester can see and
+// post in
+func (s *MessageScheduler) CheckChannel(requesterID, fromChannelID, channelID string) error {
+	if channelID == fromChannelID {
+		return nil
+	}
+	from, err := s.sender.Channel(fromChannelID)
+	if err != nil {
+		return fmt.Errorf("failed to look up this channel: %w", err)
+	}
+	target, err := s.sender.Channel(channelID)
+	if err != nil {
+		return fmt.Errorf("unknown channel %s", channelID)
+	}
+	if from.GuildID == "" || target.GuildID != from.GuildID {
+		return fmt.Errorf("messages can only be scheduled in channels of this server")
+	}
+	permissions, err := s.sender.UserChannelPermissions(requesterID, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to check your permissions in channel %s: %w", channelID, err)
+	}
+	required := int64(discordgo.PermissionViewChannel | discordgo.PermissionSendMessages)
+	if permissions&required != required {
+		return fmt.Errorf("you can't post in channel %s, so it can't be scheduled there", channelID)
+	}
+	return nil
+}
+
+// send posts msg to its channel, or opens a DM with its user first. Mentions
+// in the content are not pinged, so a message can't reach @everyone or a role.
+func (s *MessageScheduler) send(msg graph.ScheduledMessage) error {
+	channelID := msg.ChannelID
+	if msg.UserID != "" {
+		dm, err := s.sender.UserChannelCreate(msg.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to open DM: %w", err)
+		}
+		channelID = dm.ID
+	}
+	_, err := s.sender.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:         msg.Content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+// sortScheduledMessages orders messages soonest first, breaking ties by ID
+func sortScheduledMessages(messages []graph.ScheduledMessage) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		if !messages[i].FireAt.Equal(messages[j].FireAt) {
+			return messages[i].FireAt.Before(messages[j].FireAt)
+		}
+		return messages[i].ID < messages[j].ID
+	})
+}
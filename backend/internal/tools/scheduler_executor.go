@@ -0,0 +1,203 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ezra-clone/backend/internal/graph"
+)
+
+// delayPart matches one number and unit of a schedule delay, e.g. "1.5h"
+var delayPart = regexp.MustCompile(`^(\d+(?:\.\d+)?)([smhdw])`)
+
+// delayUnits are the units a schedule delay may use
+var delayUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// parseScheduleDelay parses a delay such as "90m", "1d12h" or "2 weeks".
+// Unlike time.ParseDuration it accepts days and weeks, and spaces and unit
+// words are tolerated since the LLM writes these.
+func parseScheduleDelay(delay string) (time.Duration, error) {
+	s := strings.ToLower(strings.Join(strings.Fields(delay), ""))
+	for _, word := range []struct{ long, short string }{
+		{"weeks", "w"}, {"week", "w"}, {"days", "d"}, {"day", "d"},
+		{"hours", "h"}, {"hour", "h"}, {"hrs", "h"}, {"hr", "h"},
+		{"minutes", "m"}, {"minute", "m"}, {"mins", "m"}, {"min", "m"},
+		{"seconds", "s"}, {"second", "s"}, {"secs", "s"}, {"sec", "s"},
+	} {
+		s = strings.ReplaceAll(s, word.long, word.short)
+	}
+	if s == "" {
+		return 0, fmt.Errorf("delay is empty")
+	}
+
+	var total time.Duration
+	for s != "" {
+		match := delayPart.FindStringSubmatch(s)
+		if match == nil {
+			return 0, fmt.Errorf("invalid delay %q (use e.g. '30m', '2h' or '1d12h')", delay)
+		}
+		n, _ := strconv.ParseFloat(match[1], 64)
+		total += time.Duration(n * float64(delayUnits[match[2]]))
+		s = s[len(match[0]):]
+	}
+	if total <= 0 {
+		return 0, fmt.Errorf("delay must be positive")
+	}
+	return total, nil
+}
+
+// parseFireAt parses an RFC 3339 send time. Times without an offset are
+// taken as UTC.
+func parseFireAt(fireAt string) (time.Time, error) {
+	fireAt = strings.TrimSpace(fireAt)
+	if t, err := time.Parse(time.RFC3339, fireAt); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.Parse(layout, fireAt); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid fire_at %q (use an RFC 3339 timestamp like '2026-03-01T09:00:00Z')", fireAt)
+}
+
+// describeScheduledMessage summarizes a scheduled message for the LLM
+func describeScheduledMessage(msg graph.ScheduledMessage, now time.Time) map[string]interface{} {
+	target := "channel " + msg.ChannelID
+	if msg.UserID != "" {
+		target = "DM to user " + msg.UserID
+	}
+	return map[string]interface{}{
+		"id":      msg.ID,
+		"to":      target,
+		"content": msg.Content,
+		"fire_at": msg.FireAt.UTC().Format(time.RFC3339),
+		"in":      msg.FireAt.Sub(now).Round(time.Second).String(),
+	}
+}
+
+func (e *Executor) executeScheduleMessage(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	if e.scheduler == nil {
+		return &ToolResult{Success: false, Error: "Scheduled messages not available (only works in Discord bot context)"}
+	}
+
+	content, _ := args["content"].(string)
+	delay, _ := args["delay"].(string)
+	fireAtArg, _ := args["fire_at"].(string)
+	channelID, _ := args["channel_id"].(string)
+	userID, _ := args["user_id"].(string)
+
+	now := e.scheduler.now()
+	var fireAt time.Time
+	switch {
+	case delay != "" && fireAtArg != "":
+		return &ToolResult{Success: false, Error: "Give either delay or fire_at, not both"}
+	case delay != "":
+		d, err := parseScheduleDelay(delay)
+		if err != nil {
+			return &ToolResult{Success: false, Error: err.Error()}
+		}
+		fireAt = now.Add(d)
+	case fireAtArg != "":
+		t, err := parseFireAt(fireAtArg)
+		if err != nil {
+			return &ToolResult{Success: false, Error: err.Error()}
+		}
+		fireAt = t
+	default:
+		return &ToolResult{Success: false, Error: "Give delay or fire_at to say when to send the message"}
+	}
+
+	if userID != "" && channelID != "" {
+		return &ToolResult{Success: false, Error: "Give either channel_id or user_id, not both"}
+	}
+	if userID == "" && channelID == "" {
+		channelID = execCtx.ChannelID
+		if channelID == "" {
+			return &ToolResult{Success: false, Error: "No channel to post in; give channel_id or user_id"}
+		}
+	}
+	// The requester may only DM themselves and post where they could post anyway
+	if userID != "" && userID != execCtx.UserID {
+		return &ToolResult{Success: false, Error: "Scheduled DMs can only be sent to the user who asks for them"}
+	}
+	if channelID != "" {
+		if err := e.scheduler.CheckChannel(execCtx.UserID, execCtx.ChannelID, channelID); err != nil {
+			return &ToolResult{Success: false, Error: fmt.Sprintf("Can't schedule a message there: %v", err)}
+		}
+	}
+
+	// Scheduled messages are sent outside a turn, so they are filtered now
+	content = strings.TrimSpace(content)
+	if _, filtered := e.FilterOutput(ctx, execCtx.AgentID, channelID, content); filtered {
+		return &ToolResult{Success: false, Error: "The message was blocked by the content filter"}
+	}
+
+	msg, err := e.scheduler.Schedule(ctx, graph.ScheduledMessage{
+		AgentID:   execCtx.AgentID,
+		ChannelID: channelID,
+		UserID:    userID,
+		Content:   content,
+		FireAt:    fireAt,
+		CreatedBy: execCtx.UserID,
+	})
+	if err != nil {
+		return &ToolResult{Success: false, Error: fmt.Sprintf("Failed to schedule message: %v", err)}
+	}
+
+	return &ToolResult{
+		Success: true,
+		Data:    describeScheduledMessage(msg, now),
+		Message: fmt.Sprintf("Scheduled for %s (in %s)", msg.FireAt.UTC().Format("2006-01-02 15:04 UTC"), msg.FireAt.Sub(now).Round(time.Second)),
+	}
+}
+
+func (e *Executor) executeListScheduledMessages(ctx context.Context, execCtx *ExecutionContext) *ToolResult {
+	if e.scheduler == nil {
+		return &ToolResult{Success: false, Error: "Scheduled messages not available (only works in Discord bot context)"}
+	}
+
+	now := e.scheduler.now()
+	messages := e.scheduler.List(execCtx.AgentID, execCtx.UserID)
+	scheduled := make([]map[string]interface{}, 0, len(messages))
+	for _, msg := range messages {
+		scheduled = append(scheduled, describeScheduledMessage(msg, now))
+	}
+
+	return &ToolResult{
+		Success: true,
+		Data:    map[string]interface{}{"scheduled_messages": scheduled},
+		Message: fmt.Sprintf("%d pending scheduled messages", len(scheduled)),
+	}
+}
+
+func (e *Executor) executeCancelScheduledMessage(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	if e.scheduler == nil {
+		return &ToolResult{Success: false, Error: "Scheduled messages not available (only works in Discord bot context)"}
+	}
+
+	id, _ := args["id"].(string)
+	cancelled, err := e.scheduler.Cancel(ctx, execCtx.AgentID, execCtx.UserID, strings.TrimSpace(id))
+	if err != nil {
+		return &ToolResult{Success: false, Error: fmt.Sprintf("Failed to cancel scheduled message: %v", err)}
+	}
+	if !cancelled {
+		return &ToolResult{Success: false, Error: fmt.Sprintf("No pending scheduled message %s of yours; it may have been sent already", id)}
+	}
+
+	return &ToolResult{
+		Success: true,
+		Data:    map[string]interface{}{"id": id},
+		Message: "Scheduled message cancelled",
+	}
+}
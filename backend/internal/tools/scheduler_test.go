@@ -0,0 +1,339 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"ezra-clone/backend/internal/graph"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// fakeScheduleStore keeps scheduled messages and their statuses in memory
+type fakeScheduleStore struct {
+	messages []graph.ScheduledMessage
+	statuses map[string]string
+}
+
+func newFakeScheduleStore(messages ...graph.ScheduledMessage) *fakeScheduleStore {
+	store := &fakeScheduleStore{statuses: make(map[string]string)}
+	for _, msg := range messages {
+		store.messages = append(store.messages, msg)
+		store.statuses[msg.ID] = graph.ScheduledMessagePending
+	}
+	return store
+}
+
+func (f *fakeScheduleStore) CreateScheduledMessage(ctx context.Context, msg graph.ScheduledMessage) error {
+	f.messages = append(f.messages, msg)
+	f.statuses[msg.ID] = graph.ScheduledMessagePending
+	return nil
+}
+
+func (f *fakeScheduleStore) ListPendingScheduledMessages(ctx context.Context, agentID string) ([]graph.ScheduledMessage, error) {
+	var pending []graph.ScheduledMessage
+	for _, msg := range f.messages {
+		if f.statuses[msg.ID] == graph.ScheduledMessagePending && (agentID == "" || msg.AgentID == agentID) {
+			pending = append(pending, msg)
+		}
+	}
+	return pending, nil
+}
+
+func (f *fakeScheduleStore) FinishScheduledMessage(ctx context.Context, agentID, id, status string) (bool, error) {
+	if f.statuses[id] != graph.ScheduledMessagePending {
+		return false, nil
+	}
+	f.statuses[id] = status
+	return true, nil
+}
+
+// fakeMessageSender records what was sent to which channel; DMs go to "dm-<user>".
+// Channels belong to the guilds in guilds, and users have the permissions
+// in permissions ("user/channel").
+type fakeMessageSender struct {
+	sent        map[string][]string
+	mentions    []*discordgo.MessageAllowedMentions
+	guilds      map[string]string
+	permissions map[string]int64
+}
+
+func (f *fakeMessageSender) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.sent[channelID] = append(f.sent[channelID], data.Content)
+	f.mentions = append(f.mentions, data.AllowedMentions)
+	return &discordgo.Message{ChannelID: channelID, Content: data.Content}, nil
+}
+
+func (f *fakeMessageSender) UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	return &discordgo.Channel{ID: "dm-" + recipientID}, nil
+}
+
+func (f *fakeMessageSender) Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	guildID, ok := f.guilds[channelID]
+	if !ok {
+		return nil, fmt.Errorf("unknown channel")
+	}
+	return &discordgo.Channel{ID: channelID, GuildID: guildID}, nil
+}
+
+func (f *fakeMessageSender) UserChannelPermissions(userID, channelID string, fetchOptions ...discordgo.RequestOption) (int64, error) {
+	return f.permissions[userID+"/"+channelID], nil
+}
+
+func newTestScheduler(store *fakeScheduleStore, now time.Time) (*MessageScheduler, *fakeMessageSender) {
+	sender := &fakeMessageSender{sent: make(map[string][]string)}
+	s := NewMessageScheduler(store, sender, zap.NewNop())
+	s.now = func() time.Time { return now }
+	return s, sender
+}
+
+func TestMessageScheduler_SendsDueMessagesInOrder(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s, sender := newTestScheduler(newFakeScheduleStore(), now)
+
+	ctx := context.Background()
+	later, err := s.Schedule(ctx, graph.ScheduledMessage{AgentID: "Ezra", ChannelID: "c1", Content: "second", FireAt: now.Add(2 * time.Minute)})
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if _, err := s.Schedule(ctx, graph.ScheduledMessage{AgentID: "Ezra", ChannelID: "c1", Content: "first", FireAt: now.Add(time.Minute)}); err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if _, err := s.Schedule(ctx, graph.ScheduledMessage{AgentID: "Ezra", UserID: "u1", Content: "dm", FireAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+
+	s.now = func() time.Time { return now.Add(5 * time.Minute) }
+	s.sendDue(ctx)
+	if got := sender.sent["c1"]; len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Errorf("Expected both due channel messages in order, got %v", got)
+	}
+	if s.store.(*fakeScheduleStore).statuses[later.ID] != graph.ScheduledMessageSent {
+		t.Errorf("Expected a sent message to be recorded as sent")
+	}
+	if pending := s.List("Ezra", ""); len(pending) != 1 || pending[0].Content != "dm" {
+		t.Errorf("Expected only the DM to stay pending, got %+v", pending)
+	}
+
+	s.now = func() time.Time { return now.Add(2 * time.Hour) }
+	s.sendDue(ctx)
+	if got := sender.sent["dm-u1"]; len(got) != 1 || got[0] != "dm" {
+		t.Errorf("Expected the DM to be sent, got %v", sender.sent)
+	}
+	for _, mentions := range sender.mentions {
+		if mentions == nil || len(mentions.Parse) != 0 {
+			t.Errorf("Expected scheduled messages to be sent without pinging mentions, got %+v", mentions)
+		}
+	}
+}
+
+func TestMessageScheduler_RejectsInvalidMessages(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s, _ := newTestScheduler(newFakeScheduleStore(), now)
+
+	for name, msg := range map[string]graph.ScheduledMessage{
+		"no content":    {ChannelID: "c1", FireAt: now.Add(time.Hour)},
+		"no target":     {Content: "hi", FireAt: now.Add(time.Hour)},
+		"two targets":   {ChannelID: "c1", UserID: "u1", Content: "hi", FireAt: now.Add(time.Hour)},
+		"in the past":   {ChannelID: "c1", Content: "hi", FireAt: now.Add(-time.Minute)},
+		"too far ahead": {ChannelID: "c1", Content: "hi", FireAt: now.Add(2 * maxScheduleAhead)},
+	} {
+		msg.AgentID = "Ezra"
+		if _, err := s.Schedule(context.Background(), msg); err == nil {
+			t.Errorf("%s: expected Schedule to fail", name)
+		}
+	}
+}
+
+func TestMessageScheduler_LoadAppliesMissedPolicy(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	missed := graph.ScheduledMessage{ID: "missed", AgentID: "Ezra", ChannelID: "c1", Content: "late", FireAt: now.Add(-time.Hour)}
+	upcoming := graph.ScheduledMessage{ID: "upcoming", AgentID: "Ezra", ChannelID: "c1", Content: "soon", FireAt: now.Add(time.Hour)}
+
+	store := newFakeScheduleStore(missed, upcoming)
+	s, sender := newTestScheduler(store, now)
+	if err := s.SetMissedPolicy(MissedMessagesSkip); err != nil {
+		t.Fatalf("SetMissedPolicy failed: %v", err)
+	}
+	if queued, err := s.Load(context.Background()); err != nil || queued != 1 {
+		t.Fatalf("Expected one message queued, got %d, %v", queued, err)
+	}
+	if store.statuses["missed"] != graph.ScheduledMessageSkipped {
+		t.Errorf("Expected the missed message to be skipped, got %q", store.statuses["missed"])
+	}
+
+	store = newFakeScheduleStore(missed, upcoming)
+	s, sender = newTestScheduler(store, now)
+	if queued, err := s.Load(context.Background()); err != nil || queued != 2 {
+		t.Fatalf("Expected both messages queued, got %d, %v", queued, err)
+	}
+	s.sendDue(context.Background())
+	if got := sender.sent["c1"]; len(got) != 1 || got[0] != "late" {
+		t.Errorf("Expected the missed message to fire on the first tick, got %v", got)
+	}
+
+	if err := s.SetMissedPolicy("later"); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+}
+
+func TestMessageScheduler_CancelOnlyOwnMessages(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := newFakeScheduleStore()
+	s, _ := newTestScheduler(store, now)
+
+	msg, err := s.Schedule(context.Background(), graph.ScheduledMessage{AgentID: "Ezra", ChannelID: "c1", Content: "hi", FireAt: now.Add(time.Hour), CreatedBy: "u1"})
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+
+	if cancelled, err := s.Cancel(context.Background(), "Ezra", "u2", msg.ID); err != nil || cancelled {
+		t.Errorf("Expected another user's cancel to find nothing, got %v, %v", cancelled, err)
+	}
+	if cancelled, err := s.Cancel(context.Background(), "Ezra", "u1", msg.ID); err != nil || !cancelled {
+		t.Fatalf("Expected the cancel to succeed, got %v, %v", cancelled, err)
+	}
+	if store.statuses[msg.ID] != graph.ScheduledMessageCancelled || len(s.List("Ezra", "")) != 0 {
+		t.Errorf("Expected the message to be cancelled and dequeued")
+	}
+}
+
+func TestMessageScheduler_CapsPendingPerUser(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s, _ := newTestScheduler(newFakeScheduleStore(), now)
+
+	ctx := context.Background()
+	for i := 0; i < maxPendingScheduledMessages; i++ {
+		if _, err := s.Schedule(ctx, graph.ScheduledMessage{AgentID: "Ezra", ChannelID: "c1", Content: "hi", FireAt: now.Add(time.Hour), CreatedBy: "u1"}); err != nil {
+			t.Fatalf("Schedule %d failed: %v", i, err)
+		}
+	}
+	if _, err := s.Schedule(ctx, graph.ScheduledMessage{AgentID: "Ezra", ChannelID: "c1", Content: "hi", FireAt: now.Add(time.Hour), CreatedBy: "u1"}); err == nil {
+		t.Error("Expected a user past the cap to be refused")
+	}
+	if _, err := s.Schedule(ctx, graph.ScheduledMessage{AgentID: "Ezra", ChannelID: "c1", Content: "hi", FireAt: now.Add(time.Hour), CreatedBy: "u2"}); err != nil {
+		t.Errorf("Expected another user to be unaffected by the cap, got %v", err)
+	}
+}
+
+// blockingScheduleStore holds each CreateScheduledMessage until release
+// delivers its result, signalling entered when one starts
+type blockingScheduleStore struct {
+	*fakeScheduleStore
+	entered chan struct{}
+	release chan error
+}
+
+func (b *blockingScheduleStore) CreateScheduledMessage(ctx context.Context, msg graph.ScheduledMessage) error {
+	b.entered <- struct{}{}
+	if err := <-b.release; err != nil {
+		return err
+	}
+	return b.fakeScheduleStore.CreateScheduledMessage(ctx, msg)
+}
+
+func TestMessageScheduler_QueuesOnlyStoredMessages(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := &blockingScheduleStore{
+		fakeScheduleStore: newFakeScheduleStore(),
+		entered:           make(chan struct{}, 1),
+		release:           make(chan error, 1),
+	}
+	sender := &fakeMessageSender{sent: make(map[string][]string)}
+	s := NewMessageScheduler(store, sender, zap.NewNop())
+	s.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for i := 0; i < maxPendingScheduledMessages-1; i++ {
+		store.release <- nil
+		if _, err := s.Schedule(ctx, graph.ScheduledMessage{AgentID: "Ezra", ChannelID: "c1", Content: "later", FireAt: now.Add(time.Hour), CreatedBy: "u1"}); err != nil {
+			t.Fatalf("Schedule %d failed: %v", i, err)
+		}
+		<-store.entered
+	}
+
+	scheduled := make(chan error, 1)
+	schedule := func() {
+		_, err := s.Schedule(ctx, graph.ScheduledMessage{AgentID: "Ezra", ChannelID: "c1", Content: "soon", FireAt: now.Add(time.Minute), CreatedBy: "u1"})
+		scheduled <- err
+	}
+
+	// A store that fails gives the slot back
+	go schedule()
+	<-store.entered
+	store.release <- fmt.Errorf("store unavailable")
+	if err := <-scheduled; err == nil {
+		t.Fatal("Expected Schedule to fail when the store does")
+	}
+
+	// While the last slot's message is being stored it holds the slot but
+	// isn't sent, even once due
+	go schedule()
+	<-store.entered
+	if _, err := s.Schedule(ctx, graph.ScheduledMessage{AgentID: "Ezra", ChannelID: "c1", Content: "hi", FireAt: now.Add(time.Hour), CreatedBy: "u1"}); err == nil {
+		t.Error("Expected a message being stored to count toward the cap")
+	}
+	s.now = func() time.Time { return now.Add(2 * time.Minute) }
+	s.sendDue(ctx)
+	if len(sender.sent["c1"]) != 0 {
+		t.Fatalf("Expected nothing sent before the message is stored, got %v", sender.sent["c1"])
+	}
+
+	store.release <- nil
+	if err := <-scheduled; err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	s.sendDue(ctx)
+	if got := sender.sent["c1"]; len(got) != 1 || got[0] != "soon" {
+		t.Errorf("Expected the stored message to be sent once due, got %v", got)
+	}
+}
+
+func TestMessageScheduler_CheckChannel(t *testing.T) {
+	s, sender := newTestScheduler(newFakeScheduleStore(), time.Now())
+	sender.guilds = map[string]string{"here": "g1", "general": "g1", "staff": "g1", "elsewhere": "g2", "dm": ""}
+	sender.permissions = map[string]int64{
+		"u1/general": discordgo.PermissionViewChannel | discordgo.PermissionSendMessages,
+		"u1/staff":   discordgo.PermissionViewChannel,
+	}
+
+	if err := s.CheckChannel("u1", "here", "here"); err != nil {
+		t.Errorf("Expected the current channel to be allowed, got %v", err)
+	}
+	if err := s.CheckChannel("u1", "here", "general"); err != nil {
+		t.Errorf("Expected a channel of this server the user can post in to be allowed, got %v", err)
+	}
+	for _, channelID := range []string{"staff", "elsewhere", "missing"} {
+		if err := s.CheckChannel("u1", "here", channelID); err == nil {
+			t.Errorf("Expected channel %s to be refused", channelID)
+		}
+	}
+	if err := s.CheckChannel("u1", "dm", "general"); err == nil {
+		t.Error("Expected a DM to be unable to schedule into a server channel")
+	}
+}
+
+func TestParseScheduleDelay(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30m":             30 * time.Minute,
+		"1d12h":           36 * time.Hour,
+		"2 hours 30 mins": 150 * time.Minute,
+		"1w":              7 * 24 * time.Hour,
+		"1.5h":            90 * time.Minute,
+		"45 seconds":      45 * time.Second,
+	}
+	for input, want := range tests {
+		got, err := parseScheduleDelay(input)
+		if err != nil || got != want {
+			t.Errorf("parseScheduleDelay(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "soon", "3 months", "0m"} {
+		if _, err := parseScheduleDelay(input); err == nil {
+			t.Errorf("parseScheduleDelay(%q) should fail", input)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"ezra-clone/backend/internal/adapter"
+)
+
+// GetSchedulerTools returns tools for scheduling messages and reminders
+func GetSchedulerTools() []adapter.Tool {
+	return []adapter.Tool{
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolScheduleMessage,
+				Description: "Schedule a message to be sent later, e.g. a reminder. It is posted in this channel unless you give channel_id (another channel of this server the user can post in) or user_id (the user's own ID, to DM them). Give either delay or fire_at. Write content as the message itself, addressed to its reader (e.g. '<@123> reminder: stretch!'). Scheduled messages survive restarts.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"content": map[string]interface{}{
+							"type":        "string",
+							"description": "The message to send, up to 2000 characters",
+						},
+						"delay": map[string]interface{}{
+							"type":        "string",
+							"description": "How long from now to send it, e.g. '30m', '2h', '1d12h' or '1w' (units: s, m, h, d, w)",
+						},
+						"fire_at": map[string]interface{}{
+							"type":        "string",
+							"description": "When to send it, as an RFC 3339 timestamp with a UTC offset (e.g. '2026-03-01T09:00:00-05:00'). Use the user's timezone if known.",
+						},
+						"channel_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Channel of this server to post in (defaults to this channel); the user must be able to post there",
+						},
+						"user_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The requesting user's own Discord ID, to DM them instead of posting in a channel",
+						},
+					},
+					"required": []string{"content"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolListScheduledMessages,
+				Description: "List the pending messages and reminders the current user has scheduled, soonest first, with their IDs",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolCancelScheduledMessage,
+				Description: "Cancel a pending scheduled message the current user scheduled. Call list_scheduled_messages first to find its ID.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id": map[string]interface{}{
+							"type":        "string",
+							"description": "ID of the scheduled message to cancel",
+						},
+					},
+					"required": []string{"id"},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,239 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SectionTypeTable marks a ContentSection holding one table as Markdown
+const SectionTypeTable = "table"
+
+// Caps on tables and lists, so one data-heavy page can't crowd out the rest
+const (
+	maxTableRows      = 50  // Body rows kept per table; the rest are counted
+	maxTableColumns   = 12  // Columns kept per table
+	maxTableCellChars = 200 // Characters kept per cell
+	maxListItems      = 100 // Items kept per list, nested items included
+)
+
+var (
+	tableTagRegex     = regexp.MustCompile(`(?is)<(/?)(table)\b[^>]*>`)
+	listTagRegex      = regexp.MustCompile(`(?is)<(/?)(ul|ol)\b[^>]*>`)
+	listItemTagRegex  = regexp.MustCompile(`(?is)<(/?)(ul|ol|li)\b[^>]*>`)
+	tableCaptionRegex = regexp.MustCompile(`(?is)<caption[^>]*>(.*?)</caption>`)
+	tableRowRegex     = regexp.MustCompile(`(?is)<tr\b[^>]*>(.*?)</tr>`)
+	tableCellRegex    = regexp.MustCompile(`(?is)<t[hd]\b[^>]*>(.*?)</t[hd]>`)
+	whitespaceRegex   = regexp.MustCompile(`\s+`)
+)
+
+// outermostElements returns the [start, end) spans of the elements whose
+// tags tagRegex matches and that aren't nested in another such element.
+// An element left unclosed runs to the end of the HTML.
+func outermostElements(htmlContent string, tagRegex *regexp.Regexp) [][2]int {
+	var spans [][2]int
+	depth, start := 0, 0
+	for _, m := range tagRegex.FindAllStringSubmatchIndex(htmlContent, -1) {
+		if m[3] == m[2] { // Opening tag
+			if depth == 0 {
+				start = m[0]
+			}
+			depth++
+			continue
+		}
+		if depth == 0 {
+			continue // Stray closing tag
+		}
+		depth--
+		if depth == 0 {
+			spans = append(spans, [2]int{start, m[1]})
+		}
+	}
+	if depth > 0 {
+		spans = append(spans, [2]int{start, len(htmlContent)})
+	}
+	return spans
+}
+
+// blankSpans replaces the spans with spaces, so later matches keep their
+// positions in the original HTML
+func blankSpans(htmlContent string, spans [][2]int) string {
+	if len(spans) == 0 {
+		return htmlContent
+	}
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		b.WriteString(htmlContent[last:span[0]])
+		b.WriteString(strings.Repeat(" ", span[1]-span[0]))
+		last = span[1]
+	}
+	b.WriteString(htmlContent[last:])
+	return b.String()
+}
+
+// inlineText turns an HTML fragment into one line of plain text
+func inlineText(fragment string) string {
+	text := decodeHTMLEntities(stripHTMLTags(fragment))
+	return strings.TrimSpace(whitespaceRegex.ReplaceAllString(text, " "))
+}
+
+// extractTables renders the outermost tables in htmlContent as table
+// sections, and returns the HTML with those tables blanked out. Tables with
+// fewer than two rows or columns are usually layout, so they stay in the
+// HTML to be read as text.
+func extractTables(htmlContent string) ([]ContentSection, string) {
+	var tables []ContentSection
+	var rendered [][2]int
+	for _, span := range outermostElements(htmlContent, tableTagRegex) {
+		if table, ok := renderTable(htmlContent[span[0]:span[1]]); ok {
+			tables = append(tables, table)
+			rendered = append(rendered, span)
+		}
+	}
+	return tables, blankSpans(htmlContent, rendered)
+}
+
+// renderTable converts a <table> to a Markdown table. The first row is the
+// header, whether it uses <th> or <td>; its caption becomes the heading.
+func renderTable(tableHTML string) (ContentSection, bool) {
+	var rows [][]string
+	columns := 0
+	for _, row := range tableRowRegex.FindAllStringSubmatch(tableHTML, -1) {
+		var cells []string
+		for _, cell := range tableCellRegex.FindAllStringSubmatch(row[1], -1) {
+			cells = append(cells, tableCell(cell[1]))
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		rows = append(rows, cells)
+		columns = max(columns, len(cells))
+	}
+	if len(rows) < 2 || columns < 2 {
+		return ContentSection{}, false
+	}
+	columns = min(columns, maxTableColumns)
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for i := 0; i < columns; i++ {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(rows[0])
+	b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+	body := rows[1:]
+	for i, row := range body {
+		if i == maxTableRows {
+			fmt.Fprintf(&b, "\n_(%d more rows not shown)_\n", len(body)-maxTableRows)
+			break
+		}
+		writeRow(row)
+	}
+
+	section := ContentSection{
+		Type:    SectionTypeTable,
+		Content: []string{strings.TrimRight(b.String(), "\n")},
+	}
+	if caption := tableCaptionRegex.FindStringSubmatch(tableHTML); caption != nil {
+		section.Heading = inlineText(caption[1])
+	}
+	return section, true
+}
+
+// tableCell is a cell's text, escaped for a Markdown table and capped
+func tableCell(cellHTML string) string {
+	text := inlineText(cellHTML)
+	if len(text) > maxTableCellChars {
+		text = truncateAtRune(text, maxTableCellChars) + "…"
+	}
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// truncateAtRune cuts s to at most n bytes without splitting a character
+func truncateAtRune(s string, n int) string {
+	for n > 0 && n < len(s) && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
+
+// listFrame is a list being rendered: its kind and how many items it has had
+type listFrame struct {
+	ordered bool
+	items   int
+}
+
+// renderList converts a <ul> or <ol>, with any lists nested in it, to a
+// Markdown list: "-" for unordered items, "1." and so on for ordered ones,
+// and two spaces of indent per level of nesting
+func renderList(listHTML string) string {
+	var lines []string
+	var stack []listFrame
+	var item strings.Builder
+	itemOpen, total := false, 0
+
+	flush := func() {
+		if !itemOpen {
+			return
+		}
+		itemOpen = false
+		text := inlineText(item.String())
+		item.Reset()
+		if text == "" || len(stack) == 0 {
+			return
+		}
+		total++
+		if total > maxListItems {
+			return
+		}
+		depth := len(stack) - 1
+		marker := "-"
+		if stack[depth].ordered {
+			marker = fmt.Sprintf("%d.", stack[depth].items)
+		}
+		lines = append(lines, strings.Repeat("  ", depth)+marker+" "+text)
+	}
+
+	pos := 0
+	for _, m := range listItemTagRegex.FindAllStringSubmatchIndex(listHTML, -1) {
+		if itemOpen {
+			item.WriteString(listHTML[pos:m[0]])
+		}
+		pos = m[1]
+		closing := m[3] > m[2]
+		switch strings.ToLower(listHTML[m[4]:m[5]]) {
+		case "ul", "ol":
+			flush()
+			if closing {
+				if len(stack) > 0 {
+					stack = stack[:len(stack)-1]
+				}
+			} else {
+				stack = append(stack, listFrame{ordered: strings.EqualFold(listHTML[m[4]:m[5]], "ol")})
+			}
+		case "li":
+			flush()
+			if !closing && len(stack) > 0 {
+				stack[len(stack)-1].items++
+				itemOpen = true
+			}
+		}
+	}
+	if itemOpen {
+		item.WriteString(listHTML[pos:])
+	}
+	flush()
+
+	if total > maxListItems {
+		lines = append(lines, fmt.Sprintf("_(%d more items not shown)_", total-maxListItems))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -10,11 +11,13 @@ import (
 // Structured Content Extraction
 // ============================================================================
 
-// ContentSection represents a section of content with optional heading
+// ContentSection represents a section of content with optional heading.
+// Table sections hold one Markdown table, headed by its caption if any.
 type ContentSection struct {
+	Type    string   `json:"type,omitempty"` // SectionTypeTable, or empty for text
 	Heading string   `json:"heading,omitempty"`
 	Level   int      `json:"level,omitempty"` // 1-6 for h1-h6, 0 for no heading
-	Content []string  `json:"content"`
+	Content []string `json:"content"`
 }
 
 // StructuredContent represents extracted structured content from a webpage
@@ -24,10 +27,18 @@ type StructuredContent struct {
 	Sections  []ContentSection `json:"sections"`
 	Metadata  map[string]string `json:"metadata"`
 	TextLength int             `json:"text_length"`
+
+	contentHTML string // The part of the page the sections came from, for quality scoring
 }
 
 // extractStructuredContent extracts structured content from HTML with headings and sections
 func extractStructuredContent(htmlContent string, maxLength int) *StructuredContent {
+	return extractContentWith(htmlContent, maxLength, findMainContent)
+}
+
+// extractContentWith extracts structured content from the part of the page
+// findContent picks out of the cleaned HTML
+func extractContentWith(htmlContent string, maxLength int, findContent func(string) string) *StructuredContent {
 	result := &StructuredContent{
 		Metadata: make(map[string]string),
 		Sections: []ContentSection{},
@@ -36,6 +47,9 @@ func extractStructuredContent(htmlContent string, maxLength int) *StructuredCont
 	// Extract title first
 	result.Title = extractTitle(htmlContent)
 
+	// JSON-LD lives in <script> blocks, so it is read before they are removed
+	structured := extractJSONLD(htmlContent)
+
 	// Remove unwanted elements
 	htmlContent = removeTagContent(htmlContent, "script")
 	htmlContent = removeTagContent(htmlContent, "style")
@@ -47,10 +61,16 @@ func extractStructuredContent(htmlContent string, maxLength int) *StructuredCont
 	htmlContent = removeComments(htmlContent)
 
 	// Extract metadata
-	result.Metadata = extractMetadata(htmlContent)
+	result.Metadata = extractMetadata(htmlContent, structured)
+	if result.Title == "Untitled" {
+		if preview := NewLinkPreview(result.Metadata); preview != nil && preview.Title != "" {
+			result.Title = preview.Title
+		}
+	}
 
 	// Try to find main content area
-	contentHTML := findMainContent(htmlContent)
+	contentHTML := findContent(htmlContent)
+	result.contentHTML = contentHTML
 
 	// Extract structured sections
 	sections := extractSections(contentHTML)
@@ -89,6 +109,9 @@ func extractStructuredContent(htmlContent string, maxLength int) *StructuredCont
 		}
 	}
 
+	// Drop boilerplate and merge fragments left by poorly structured pages
+	sections = tidySections(sections)
+
 	// Build full text with markdown formatting
 	fullTextParts := []string{}
 	if result.Title != "" {
@@ -102,12 +125,19 @@ func extractStructuredContent(htmlContent string, maxLength int) *StructuredCont
 	if result.Metadata["date"] != "" {
 		fullTextParts = append(fullTextParts, fmt.Sprintf("**Date:** %s\n", result.Metadata["date"]))
 	}
-	if len(result.Metadata) > 0 {
+	if result.Metadata["author"] != "" || result.Metadata["date"] != "" {
 		fullTextParts = append(fullTextParts, "\n---\n\n")
 	}
 
 	// Add sections
 	for _, section := range sections {
+		if section.Type == SectionTypeTable {
+			if section.Heading != "" {
+				fullTextParts = append(fullTextParts, fmt.Sprintf("\n**%s**\n", section.Heading))
+			}
+			fullTextParts = append(fullTextParts, strings.Join(section.Content, "\n")+"\n")
+			continue
+		}
 		if section.Heading != "" {
 			headingPrefix := strings.Repeat("#", section.Level+1) // +1 because title is h1
 			fullTextParts = append(fullTextParts, fmt.Sprintf("\n%s %s\n", headingPrefix, section.Heading))
@@ -172,18 +202,33 @@ func extractTitle(htmlContent string) string {
 	return "Untitled"
 }
 
-// extractMetadata extracts metadata like author and date
-func extractMetadata(htmlContent string) map[string]string {
+// extractMetadata extracts metadata like author and date. structured is
+// the page's JSON-LD metadata, if any; it is trusted over OpenGraph and
+// <meta> tags, which are trusted over class names like "byline".
+func extractMetadata(htmlContent string, structured map[string]string) map[string]string {
 	metadata := make(map[string]string)
 
-	// Try to find author
+	// OpenGraph, Twitter-card and article tags, keyed by their property name
+	for key, value := range extractSocialMetaTags(htmlContent) {
+		metadata[key] = value
+	}
+	for key, value := range structured {
+		metadata[key] = value
+	}
+
+	// Try to find author; article:author is often a profile URL rather than a name
+	if author := metadata["article:author"]; metadata["author"] == "" && author != "" && !strings.HasPrefix(author, "http") {
+		metadata["author"] = author
+	}
 	authorPatterns := []*regexp.Regexp{
-		regexp.MustCompile(`(?i)<(?:span|div|p)[^>]*class="[^"]*(?:author|byline|writer)[^"]*"[^>]*>(.*?)</(?:span|div|p)>`),
 		regexp.MustCompile(`(?i)<meta[^>]*name="author"[^>]*content="([^"]*)"`),
-		regexp.MustCompile(`(?i)<meta[^>]*property="article:author"[^>]*content="([^"]*)"`),
+		regexp.MustCompile(`(?i)<(?:span|div|p)[^>]*class="[^"]*(?:author|byline|writer)[^"]*"[^>]*>(.*?)</(?:span|div|p)>`),
 	}
 
 	for _, pattern := range authorPatterns {
+		if metadata["author"] != "" {
+			break
+		}
 		if matches := pattern.FindStringSubmatch(htmlContent); len(matches) > 1 {
 			author := matches[1]
 			author = stripHTMLTags(author)
@@ -191,20 +236,24 @@ func extractMetadata(htmlContent string) map[string]string {
 			author = strings.TrimSpace(author)
 			if author != "" && len(author) < 200 {
 				metadata["author"] = author
-				break
 			}
 		}
 	}
 
 	// Try to find date
+	if metadata["date"] == "" && metadata["article:published_time"] != "" {
+		metadata["date"] = metadata["article:published_time"]
+	}
 	datePatterns := []*regexp.Regexp{
 		regexp.MustCompile(`(?i)<time[^>]*datetime="([^"]*)"`),
 		regexp.MustCompile(`(?i)<time[^>]*>(.*?)</time>`),
 		regexp.MustCompile(`(?i)<(?:span|div|p)[^>]*class="[^"]*(?:date|time|published)[^"]*"[^>]*>(.*?)</(?:span|div|p)>`),
-		regexp.MustCompile(`(?i)<meta[^>]*property="article:published_time"[^>]*content="([^"]*)"`),
 	}
 
 	for _, pattern := range datePatterns {
+		if metadata["date"] != "" {
+			break
+		}
 		if matches := pattern.FindStringSubmatch(htmlContent); len(matches) > 1 {
 			date := matches[1]
 			date = stripHTMLTags(date)
@@ -212,14 +261,100 @@ func extractMetadata(htmlContent string) map[string]string {
 			date = strings.TrimSpace(date)
 			if date != "" && len(date) < 100 {
 				metadata["date"] = date
-				break
 			}
 		}
 	}
 
+	// The page's description and lead image, wherever they were declared
+	for key, fallbacks := range map[string][]string{
+		"description": {"og:description", "twitter:description"},
+		"image":       {"og:image", "twitter:image"},
+	} {
+		for _, fallback := range fallbacks {
+			if metadata[key] == "" {
+				metadata[key] = metadata[fallback]
+			}
+		}
+		if metadata[key] == "" {
+			delete(metadata, key)
+		}
+	}
+
 	return metadata
 }
 
+// socialMetaTags are the OpenGraph, article and Twitter-card properties kept in metadata
+var socialMetaTags = map[string]bool{
+	"og:title": true, "og:description": true, "og:image": true, "og:site_name": true,
+	"og:url": true, "og:type": true,
+	"article:author": true, "article:published_time": true, "article:modified_time": true, "article:section": true,
+	"twitter:card": true, "twitter:title": true, "twitter:description": true, "twitter:image": true,
+	"twitter:site": true, "twitter:creator": true,
+}
+
+var (
+	metaTagRegex       = regexp.MustCompile(`(?is)<meta\b[^>]*>`)
+	metaAttributeRegex = regexp.MustCompile(`(?is)([a-z:_-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// extractSocialMetaTags finds OpenGraph and Twitter-card <meta> tags. Sites
+// use both property= and name=, in any attribute order; the first tag for a
+// property wins.
+func extractSocialMetaTags(htmlContent string) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range metaTagRegex.FindAllString(htmlContent, -1) {
+		attributes := make(map[string]string)
+		for _, attr := range metaAttributeRegex.FindAllStringSubmatch(tag, -1) {
+			attributes[strings.ToLower(attr[1])] = attr[2] + attr[3]
+		}
+
+		key := strings.ToLower(attributes["property"])
+		if key == "" {
+			key = strings.ToLower(attributes["name"])
+		}
+		if !socialMetaTags[key] || tags[key] != "" {
+			continue
+		}
+		value := strings.TrimSpace(decodeHTMLEntities(attributes["content"]))
+		if value != "" && len(value) < 1000 {
+			tags[key] = value
+		}
+	}
+	return tags
+}
+
+// LinkPreview is what a page says about itself for link embeds, taken from
+// its OpenGraph tags with Twitter-card tags and then JSON-LD as fallbacks
+type LinkPreview struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// NewLinkPreview builds a link preview from extracted page metadata. It
+// returns nil when the page has no OpenGraph, Twitter-card or JSON-LD details.
+func NewLinkPreview(metadata map[string]string) *LinkPreview {
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if metadata[key] != "" {
+				return metadata[key]
+			}
+		}
+		return ""
+	}
+	preview := &LinkPreview{
+		Title:       first("og:title", "twitter:title", "headline"),
+		Description: first("og:description", "twitter:description", "description"),
+		ImageURL:    first("og:image", "twitter:image", "image"),
+		SiteName:    first("og:site_name", "twitter:site", "publisher"),
+	}
+	if *preview == (LinkPreview{}) {
+		return nil
+	}
+	return preview
+}
+
 // findMainContent tries to find the main article content area
 // Uses a more robust approach that handles nested tags
 func findMainContent(htmlContent string) string {
@@ -349,10 +484,14 @@ func extractSections(htmlContent string) []ContentSection {
 	allMatches := headingRegex.FindAllStringSubmatchIndex(content, -1)
 
 	if len(allMatches) == 0 {
-		// No headings found, extract all paragraphs as a single section
-		paragraphs := extractParagraphs(content)
+		// No headings found, extract all paragraphs as a single section, followed by its tables
+		tables, text := extractTables(content)
+		paragraphs := extractParagraphs(text)
 		if len(paragraphs) > 0 {
-			return []ContentSection{{Content: paragraphs}}
+			return append([]ContentSection{{Content: paragraphs}}, tables...)
+		}
+		if len(tables) > 0 {
+			return tables
 		}
 		// If no paragraphs either, try extracting any text content
 		// This handles cases where content might be in divs without proper structure
@@ -374,7 +513,8 @@ func extractSections(htmlContent string) []ContentSection {
 		return sections
 	}
 
-	// Process content between headings
+	// Process content between headings; each section's tables follow it
+	var currentTables []ContentSection
 	for i, match := range allMatches {
 		headingLevel := int(content[match[2]+1] - '0') // Extract number from h1-h6
 		headingText := content[match[4]:match[5]]
@@ -386,6 +526,7 @@ func extractSections(htmlContent string) []ContentSection {
 		if currentSection.Heading != "" || len(currentSection.Content) > 0 {
 			sections = append(sections, currentSection)
 		}
+		sections = append(sections, currentTables...)
 
 		// Start new section
 		currentSection = ContentSection{
@@ -402,6 +543,7 @@ func extractSections(htmlContent string) []ContentSection {
 		}
 
 		sectionContent := content[contentStart:contentEnd]
+		currentTables, sectionContent = extractTables(sectionContent)
 		paragraphs := extractParagraphs(sectionContent)
 		currentSection.Content = paragraphs
 	}
@@ -410,6 +552,7 @@ func extractSections(htmlContent string) []ContentSection {
 	if currentSection.Heading != "" || len(currentSection.Content) > 0 {
 		sections = append(sections, currentSection)
 	}
+	sections = append(sections, currentTables...)
 
 	// Filter out sections with no meaningful content
 	filteredSections := []ContentSection{}
@@ -429,46 +572,113 @@ func extractSections(htmlContent string) []ContentSection {
 	return filteredSections
 }
 
-// extractParagraphs extracts paragraph text from HTML
+// minSectionChars is the size below which adjacent sections are merged
+const minSectionChars = 200
+
+// boilerplateHeadings match headings of sections that aren't part of the article
+var boilerplateHeadings = regexp.MustCompile(`(?i)^(related( articles| posts| stories| content)?|recommended( for you| reading)?|you (may|might) also (like|enjoy)|more from .+|read (more|next)|trending( now)?|popular( posts| stories)?|share( this)?( article| post)?|follow us|subscribe|sign up|newsletter|comments?|leave a (comment|reply)|advertisement|sponsored( content)?|cookies?( policy| notice| settings)?|about the author|tags)(\s*\(\d+\))?\s*[:.!]?$`)
+
+// boilerplateParagraph matches cookie and consent notices, newsletter prompts
+// and similar text that appears on every page of a site
+var boilerplateParagraph = regexp.MustCompile(`(?i)(\bwe use cookies\b|\bthis (web)?site uses cookies\b|\bcookie (policy|settings|preferences)\b|\baccept (all )?cookies\b|\bby continuing to (use|browse)\b|\bsubscribe to our newsletter\b|\bsign up for our newsletter\b|\ball rights reserved\b)`)
+
+// tidySections is a readability pass over extracted sections: it drops
+// boilerplate sections and paragraphs, then merges runs of tiny adjacent
+// sections so fragmented pages read as a few coherent sections
+func tidySections(sections []ContentSection) []ContentSection {
+	tidied := []ContentSection{}
+	for _, section := range sections {
+		if boilerplateHeadings.MatchString(strings.TrimSpace(section.Heading)) {
+			continue
+		}
+		content := []string{}
+		for _, para := range section.Content {
+			if !boilerplateParagraph.MatchString(para) {
+				content = append(content, para)
+			}
+		}
+		if len(content) == 0 && len(section.Content) > 0 {
+			continue // Nothing but boilerplate
+		}
+		section.Content = content
+
+		if n := len(tidied); n > 0 && tidied[n-1].Type == "" && section.Type == "" &&
+			sectionLength(tidied[n-1]) < minSectionChars && sectionLength(section) < minSectionChars {
+			previous := &tidied[n-1]
+			if section.Heading != "" {
+				previous.Content = append(previous.Content, "**"+section.Heading+"**")
+			}
+			previous.Content = append(previous.Content, section.Content...)
+			continue
+		}
+		tidied = append(tidied, section)
+	}
+	return tidied
+}
+
+// sectionLength is the number of characters in a section's heading and text
+func sectionLength(section ContentSection) int {
+	length := len(section.Heading)
+	for _, para := range section.Content {
+		length += len(para)
+	}
+	return length
+}
+
+// extractParagraphs extracts paragraph text from HTML. Each list becomes one
+// Markdown list, placed among the paragraphs in document order.
 func extractParagraphs(htmlContent string) []string {
 	paragraphs := []string{}
 
+	// Lists are rendered whole, then blanked so their contents aren't read twice
+	type block struct {
+		pos  int
+		text string
+	}
+	var blocks []block
+	listSpans := outermostElements(htmlContent, listTagRegex)
+	for _, span := range listSpans {
+		if list := renderList(htmlContent[span[0]:span[1]]); list != "" {
+			blocks = append(blocks, block{span[0], list})
+		}
+	}
+	withoutLists := blankSpans(htmlContent, listSpans)
+
 	// Extract <p> tags (use DOTALL mode to handle multiline)
 	pRegex := regexp.MustCompile(`(?is)<p[^>]*>(.*?)</p>`)
-	matches := pRegex.FindAllStringSubmatch(htmlContent, -1)
-	for _, match := range matches {
-		if len(match) > 1 {
-			text := match[1]
-			text = stripHTMLTags(text)
-			text = decodeHTMLEntities(text)
-			text = strings.TrimSpace(text)
-			if text != "" && len(text) > 10 {
-				paragraphs = append(paragraphs, text)
-			}
+	for _, match := range pRegex.FindAllStringSubmatchIndex(withoutLists, -1) {
+		text := withoutLists[match[2]:match[3]]
+		text = stripHTMLTags(text)
+		text = decodeHTMLEntities(text)
+		text = strings.TrimSpace(text)
+		if text != "" && len(text) > 10 {
+			blocks = append(blocks, block{match[0], text})
 		}
 	}
 
-	// Extract <li> tags (list items)
+	// Extract stray <li> tags outside any list
 	liRegex := regexp.MustCompile(`(?is)<li[^>]*>(.*?)</li>`)
-	matches = liRegex.FindAllStringSubmatch(htmlContent, -1)
-	for _, match := range matches {
-		if len(match) > 1 {
-			text := match[1]
-			text = stripHTMLTags(text)
-			text = decodeHTMLEntities(text)
-			text = strings.TrimSpace(text)
-			if text != "" && len(text) > 10 {
-				paragraphs = append(paragraphs, text)
-			}
+	for _, match := range liRegex.FindAllStringSubmatchIndex(withoutLists, -1) {
+		text := withoutLists[match[2]:match[3]]
+		text = stripHTMLTags(text)
+		text = decodeHTMLEntities(text)
+		text = strings.TrimSpace(text)
+		if text != "" && len(text) > 10 {
+			blocks = append(blocks, block{match[0], text})
 		}
 	}
 
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].pos < blocks[j].pos })
+	for _, b := range blocks {
+		paragraphs = append(paragraphs, b.text)
+	}
+
 	// If no paragraphs found, try extracting text from divs with substantial content
 	// But be more selective - only divs that look like content (not navigation, etc.)
 	if len(paragraphs) == 0 {
 		// Try to find content divs (avoid nav, header, footer, etc.)
 		divRegex := regexp.MustCompile(`(?is)<div[^>]*>(.*?)</div>`)
-		matches = divRegex.FindAllStringSubmatch(htmlContent, -1)
+		matches := divRegex.FindAllStringSubmatch(htmlContent, -1)
 		for _, match := range matches {
 			if len(match) > 1 {
 				// Check if this div is likely content (not navigation/header/footer)
@@ -0,0 +1,271 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+const openGraphFixture = `<!DOCTYPE html>
+<html>
+<head>
+	<title>Release notes | Example Blog</title>
+	<meta property="og:title" content="Example 2.0 is out">
+	<meta property="og:description" content="Faster builds, smaller binaries &amp; a new plugin API.">
+	<meta content="https://example.com/img/release.png" property="og:image" />
+	<meta property="og:site_name" content="Example Blog">
+	<meta name="twitter:card" content="summary_large_image">
+	<meta name="twitter:site" content="@example">
+	<meta name="twitter:title" content="Example 2.0 (Twitter title)">
+	<meta name="author" content="Jane Doe">
+</head>
+<body><article><h2>What's new</h2><p>Builds are now twice as fast thanks to incremental compilation.</p></article></body>
+</html>`
+
+func TestExtractStructuredContent_OpenGraphTags(t *testing.T) {
+	content := extractStructuredContent(openGraphFixture, 50000)
+
+	want := map[string]string{
+		"og:title":       "Example 2.0 is out",
+		"og:description": "Faster builds, smaller binaries & a new plugin API.",
+		"og:image":       "https://example.com/img/release.png",
+		"og:site_name":   "Example Blog",
+		"twitter:card":   "summary_large_image",
+		"twitter:site":   "@example",
+		"twitter:title":  "Example 2.0 (Twitter title)",
+		"author":         "Jane Doe",
+	}
+	for key, value := range want {
+		if content.Metadata[key] != value {
+			t.Errorf("Expected metadata %s = %q, got %q", key, value, content.Metadata[key])
+		}
+	}
+
+	preview := NewLinkPreview(content.Metadata)
+	if preview == nil {
+		t.Fatal("Expected a link preview")
+	}
+	if preview.Title != "Example 2.0 is out" || preview.ImageURL != "https://example.com/img/release.png" || preview.SiteName != "Example Blog" {
+		t.Errorf("Expected OpenGraph values to take precedence, got %+v", preview)
+	}
+}
+
+func TestNewLinkPreview_TwitterFallbackAndNone(t *testing.T) {
+	preview := NewLinkPreview(map[string]string{"twitter:title": "Only Twitter", "twitter:image": "https://example.com/a.png"})
+	if preview == nil || preview.Title != "Only Twitter" || preview.ImageURL != "https://example.com/a.png" {
+		t.Errorf("Expected Twitter-card fallback, got %+v", preview)
+	}
+
+	if preview := NewLinkPreview(map[string]string{"author": "Jane Doe"}); preview != nil {
+		t.Errorf("Expected no preview without social tags, got %+v", preview)
+	}
+}
+
+const fragmentedFixture = `<html><head><title>Fragmented page</title></head><body><main>
+<h2>Step one</h2><p>Install the toolchain first.</p>
+<h3>Step two</h3><p>Clone the repository locally.</p>
+<h3>Step three</h3><p>Run the build script once.</p>
+<h2>Background</h2>
+<p>The project started in 2019 as a weekend experiment and has since grown into a widely used build tool with hundreds of contributors, a plugin ecosystem and releases every six weeks.</p>
+<p>Most of its speed comes from aggressive caching of intermediate build artifacts between runs.</p>
+<p>We use cookies to improve your experience. Accept all cookies to continue.</p>
+<h2>Related Articles</h2><p>Ten build tools compared side by side</p><p>Why caching matters for monorepos</p>
+<h2>Comments (12)</h2><p>Great write-up, thanks for sharing this!</p>
+</main></body></html>`
+
+func TestExtractStructuredContent_TidiesFragmentedSections(t *testing.T) {
+	content := extractStructuredContent(fragmentedFixture, 50000)
+
+	for _, section := range content.Sections {
+		if section.Heading == "Related Articles" || section.Heading == "Comments (12)" {
+			t.Errorf("Expected boilerplate section %q to be dropped", section.Heading)
+		}
+		for _, para := range section.Content {
+			if strings.Contains(para, "cookies") {
+				t.Errorf("Expected cookie notice to be dropped, found %q", para)
+			}
+		}
+	}
+
+	// The three tiny steps are merged into one section; Background stays separate
+	if len(content.Sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d: %+v", len(content.Sections), content.Sections)
+	}
+	steps := content.Sections[0]
+	if steps.Heading != "Step one" {
+		t.Errorf("Expected merged section to keep the first heading, got %q", steps.Heading)
+	}
+	want := []string{"Install the toolchain first.", "**Step two**", "Clone the repository locally.", "**Step three**", "Run the build script once."}
+	if strings.Join(steps.Content, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected merged content %q, got %q", want, steps.Content)
+	}
+	if content.Sections[1].Heading != "Background" {
+		t.Errorf("Expected Background section, got %q", content.Sections[1].Heading)
+	}
+	if strings.Contains(content.FullText, "Great write-up") {
+		t.Error("Expected comments to be left out of the full text")
+	}
+}
+
+const pricingFixture = `<html><head><title>Pricing</title></head><body><main>
+<h2>Plans</h2>
+<p>Every plan includes unlimited projects.</p>
+<table><caption>Monthly prices</caption>
+<thead><tr><th>Plan</th><th>Price</th><th>Seats</th></tr></thead>
+<tbody>
+<tr><td>Free</td><td>$0</td><td>1</td></tr>
+<tr><td>Team</td><td><b>$20</b> | user</td><td>10</td></tr>
+</tbody></table>
+<h2>Setup</h2>
+<ol><li>Create an account</li><li>Pick a plan<ul><li>Free needs no card</li><li>Team needs a card</li></ul></li><li>Invite your team</li></ol>
+<table><tr><td><p>Layout only, read as text.</p></td></tr></table>
+</main></body></html>`
+
+func TestExtractStructuredContent_TablesAndLists(t *testing.T) {
+	content := extractStructuredContent(pricingFixture, 50000)
+
+	var table *ContentSection
+	for i := range content.Sections {
+		if content.Sections[i].Type == SectionTypeTable {
+			if table != nil {
+				t.Fatalf("Expected the one-column layout table not to become a table section")
+			}
+			table = &content.Sections[i]
+		}
+	}
+	if table == nil {
+		t.Fatalf("Expected a table section, got %+v", content.Sections)
+	}
+	if table.Heading != "Monthly prices" {
+		t.Errorf("Expected the caption as heading, got %q", table.Heading)
+	}
+	wantTable := "| Plan | Price | Seats |\n| --- | --- | --- |\n| Free | $0 | 1 |\n| Team | $20 \\| user | 10 |"
+	if len(table.Content) != 1 || table.Content[0] != wantTable {
+		t.Errorf("Expected table\n%s\ngot\n%q", wantTable, table.Content)
+	}
+	if !strings.Contains(content.FullText, wantTable) {
+		t.Error("Expected the table in the full text")
+	}
+
+	wantList := "1. Create an account\n2. Pick a plan\n  - Free needs no card\n  - Team needs a card\n3. Invite your team"
+	if !strings.Contains(content.FullText, wantList) {
+		t.Errorf("Expected the nested ordered list in the full text, got:\n%s", content.FullText)
+	}
+	if !strings.Contains(content.FullText, "Layout only") {
+		t.Error("Expected a layout table to be kept as text")
+	}
+}
+
+func TestRenderTable_CapsRows(t *testing.T) {
+	var rows strings.Builder
+	rows.WriteString("<table><tr><th>n</th><th>square</th></tr>")
+	for i := 0; i < maxTableRows+5; i++ {
+		rows.WriteString("<tr><td>x</td><td>y</td></tr>")
+	}
+	rows.WriteString("</table>")
+
+	table, ok := renderTable(rows.String())
+	if !ok {
+		t.Fatal("Expected the table to render")
+	}
+	if got := strings.Count(table.Content[0], "| x | y |"); got != maxTableRows {
+		t.Errorf("Expected %d rows, got %d", maxTableRows, got)
+	}
+	if !strings.Contains(table.Content[0], "5 more rows not shown") {
+		t.Error("Expected a note about the rows left out")
+	}
+}
+
+const jsonLDFixture = `<html><head>
+<title>Storm hits coast | Daily News</title>
+<meta property="article:published_time" content="2026-02-01T08:00:00Z">
+<meta property="article:author" content="https://facebook.com/daily">
+<script type="application/ld+json">{not valid json</script>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+	{"@type": "WebSite", "name": "Daily News"},
+	{"@type": ["NewsArticle"], "headline": "Storm hits the coast",
+	 "datePublished": "2026-02-01T07:30:00Z", "dateModified": "2026-02-01T09:00:00Z",
+	 "author": [{"@type": "Person", "name": "Ana Ruiz"}, {"@type": "Person", "name": "Tom Lee"}],
+	 "publisher": {"@type": "Organization", "name": "Daily News"},
+	 "image": {"@type": "ImageObject", "url": "/img/storm.jpg"}}
+]}
+</script>
+</head><body>
+<span class="byline">By Someone Else</span>
+<article><h2>Storm</h2><p>The storm reached the coast early this morning.</p></article>
+</body></html>`
+
+func TestExtractStructuredContent_PrefersJSONLD(t *testing.T) {
+	content := extractStructuredContent(jsonLDFixture, 50000)
+
+	want := map[string]string{
+		"headline":      "Storm hits the coast",
+		"author":        "Ana Ruiz, Tom Lee",
+		"date":          "2026-02-01T07:30:00Z",
+		"date_modified": "2026-02-01T09:00:00Z",
+		"publisher":     "Daily News",
+		"image":         "/img/storm.jpg",
+	}
+	for key, value := range want {
+		if content.Metadata[key] != value {
+			t.Errorf("Expected metadata %s = %q, got %q", key, value, content.Metadata[key])
+		}
+	}
+
+	resolveMetadataURLs(content.Metadata, "https://news.example.com/2026/storm")
+	if got := content.Metadata["image"]; got != "https://news.example.com/img/storm.jpg" {
+		t.Errorf("Expected the image URL to be resolved, got %q", got)
+	}
+
+	preview := NewLinkPreview(content.Metadata)
+	if preview == nil || preview.Title != "Storm hits the coast" || preview.SiteName != "Daily News" || preview.ImageURL != "https://news.example.com/img/storm.jpg" {
+		t.Errorf("Expected a preview built from JSON-LD, got %+v", preview)
+	}
+}
+
+func TestExtractMetadata_FallsBackToMetaTags(t *testing.T) {
+	html := `<meta property="article:published_time" content="2026-02-01T08:00:00Z">
+<meta property="article:author" content="Ana Ruiz">
+<meta property="og:image" content="https://example.com/a.png">
+<span class="byline">By Someone Else</span><time datetime="2020-01-01">old</time>`
+
+	metadata := extractMetadata(html, nil)
+	if metadata["author"] != "Ana Ruiz" || metadata["date"] != "2026-02-01T08:00:00Z" {
+		t.Errorf("Expected article tags over class-name scraping, got author %q, date %q", metadata["author"], metadata["date"])
+	}
+	if metadata["image"] != "https://example.com/a.png" {
+		t.Errorf("Expected og:image as the page image, got %q", metadata["image"])
+	}
+}
+
+func TestScoreExtraction_PenalizesLinksAndBoilerplate(t *testing.T) {
+	article := extractStructuredContent(`<article>`+strings.Repeat(`<p>The river rose overnight, and by morning the lower town was under water, with crews working to clear the roads.</p>`, 8)+`</article>`, 50000)
+	nav := extractStructuredContent(`<article>`+strings.Repeat(`<p><a href="/a">Sign in</a> | <a href="/b">Subscribe to our newsletter</a> | <a href="/c">Privacy policy</a> | <a href="/d">Cookie settings</a></p>`, 8)+`</article>`, 50000)
+
+	good, bad := scoreExtraction(article), scoreExtraction(nav)
+	if good.Score < minExtractionQuality {
+		t.Errorf("Expected article text to score at least %.2f, got %+v", minExtractionQuality, good)
+	}
+	if bad.Score >= minExtractionQuality || bad.LinkDensity < 0.8 || bad.BoilerplateHits == 0 {
+		t.Errorf("Expected navigation links to score low, got %+v", bad)
+	}
+}
+
+func TestChooseExtraction_FallsBackToReadability(t *testing.T) {
+	html := `<html><body>
+<div class="menu">` + strings.Repeat(`<p><a href="/x">Home</a> <a href="/y">Sign in</a> <a href="/z">Subscribe</a> <a href="/w">Related articles and more</a></p>`, 30) + `</div>
+<div class="story">` + strings.Repeat(`<p>The council met on Tuesday, and after a long debate, it voted to repair the old bridge before winter.</p>`, 6) + `</div>
+<div id="sidebar"><p><a href="/p">Follow us on social media for updates</a></p></div>
+</body></html>`
+
+	content, quality := chooseExtraction(html, 50000)
+	if content.Metadata["extraction_strategy"] != ExtractionReadability {
+		t.Fatalf("Expected the readability strategy, got %q (score %.2f)", content.Metadata["extraction_strategy"], quality.Score)
+	}
+	if !strings.Contains(content.FullText, "repair the old bridge") || strings.Contains(content.FullText, "Sign in") {
+		t.Errorf("Expected only the story text, got %q", content.FullText)
+	}
+	if content.Metadata["extraction_score"] == "" {
+		t.Error("Expected the score in the metadata")
+	}
+}
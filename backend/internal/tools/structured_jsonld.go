@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var jsonLDScriptRegex = regexp.MustCompile(`(?is)<script[^>]*type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+
+// jsonLDArticleTypes are the schema.org types read as the page's article
+var jsonLDArticleTypes = map[string]bool{
+	"Article": true, "NewsArticle": true, "BlogPosting": true, "TechArticle": true,
+	"ScholarlyArticle": true, "Report": true, "LiveBlogPosting": true,
+	"OpinionNewsArticle": true, "AnalysisNewsArticle": true, "ReportageNewsArticle": true,
+}
+
+// maxMetadataValue caps metadata values, as for the social meta tags
+const maxMetadataValue = 1000
+
+// extractJSONLD reads the first Article-like node from the page's JSON-LD
+// blocks, including nodes inside an @graph. It returns metadata under the
+// keys headline, description, author, date, date_modified, publisher and
+// image, or nil when the page has no such node. Blocks that aren't valid
+// JSON are skipped.
+func extractJSONLD(htmlContent string) map[string]string {
+	for _, script := range jsonLDScriptRegex.FindAllStringSubmatch(htmlContent, -1) {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(script[1])), &doc); err != nil {
+			continue
+		}
+		for _, node := range jsonLDNodes(doc) {
+			if isJSONLDArticle(node) {
+				return jsonLDArticleMetadata(node)
+			}
+		}
+	}
+	return nil
+}
+
+// jsonLDNodes flattens a JSON-LD document into its top-level and @graph nodes
+func jsonLDNodes(doc interface{}) []map[string]interface{} {
+	var nodes []map[string]interface{}
+	switch v := doc.(type) {
+	case []interface{}:
+		for _, item := range v {
+			nodes = append(nodes, jsonLDNodes(item)...)
+		}
+	case map[string]interface{}:
+		nodes = append(nodes, v)
+		if graph, ok := v["@graph"]; ok {
+			nodes = append(nodes, jsonLDNodes(graph)...)
+		}
+	}
+	return nodes
+}
+
+// isJSONLDArticle reports whether a node's @type, a string or a list, names
+// an article type
+func isJSONLDArticle(node map[string]interface{}) bool {
+	switch t := node["@type"].(type) {
+	case string:
+		return jsonLDArticleTypes[t]
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok && jsonLDArticleTypes[s] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonLDArticleMetadata maps an article node's properties to metadata keys
+func jsonLDArticleMetadata(node map[string]interface{}) map[string]string {
+	metadata := make(map[string]string)
+	set := func(key, value string) {
+		value = strings.TrimSpace(decodeHTMLEntities(value))
+		if value != "" && len(value) < maxMetadataValue {
+			metadata[key] = value
+		}
+	}
+	set("headline", jsonLDString(node["headline"]))
+	if metadata["headline"] == "" {
+		set("headline", jsonLDString(node["name"]))
+	}
+	set("description", jsonLDString(node["description"]))
+	set("author", jsonLDNames(node["author"]))
+	set("date", jsonLDString(node["datePublished"]))
+	set("date_modified", jsonLDString(node["dateModified"]))
+	set("publisher", jsonLDNames(node["publisher"]))
+	set("image", jsonLDURL(node["image"]))
+	return metadata
+}
+
+// jsonLDString returns v if it is a string
+func jsonLDString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// jsonLDNames returns the names of a person or organization value, which
+// may be a plain name, a node with a name, or a list of either
+func jsonLDNames(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case map[string]interface{}:
+		return jsonLDString(t["name"])
+	case []interface{}:
+		var names []string
+		for _, item := range t {
+			if name := strings.TrimSpace(jsonLDNames(item)); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// jsonLDURL returns the first URL of an image value, which may be a URL, an
+// ImageObject or a list of either
+func jsonLDURL(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case map[string]interface{}:
+		if u := jsonLDString(t["url"]); u != "" {
+			return u
+		}
+		return jsonLDString(t["contentUrl"])
+	case []interface{}:
+		for _, item := range t {
+			if u := jsonLDURL(item); u != "" {
+				return u
+			}
+		}
+	}
+	return ""
+}
+
+// metadataURLKeys are the metadata values that hold URLs
+var metadataURLKeys = []string{"image", "og:image", "og:url", "twitter:image"}
+
+// resolveMetadataURLs makes relative URLs in metadata absolute against the
+// page's URL, so images can be fetched or embedded as they are
+func resolveMetadataURLs(metadata map[string]string, pageURL string) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return
+	}
+	for _, key := range metadataURLKeys {
+		value := metadata[key]
+		if value == "" {
+			continue
+		}
+		if ref, err := url.Parse(value); err == nil && !ref.IsAbs() {
+			metadata[key] = base.ResolveReference(ref).String()
+		}
+	}
+}
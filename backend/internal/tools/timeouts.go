@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultToolTimeout bounds tools without a timeout of their own
+const DefaultToolTimeout = 60 * time.Second
+
+// defaultToolTimeouts are built-in timeouts for tools that usually run
+// longer or shorter than DefaultToolTimeout
+var defaultToolTimeouts = map[string]time.Duration{
+	// Web and GitHub calls are a handful of HTTP requests
+	ToolWebSearch:          30 * time.Second,
+	ToolFetchWebpage:       45 * time.Second,
+	ToolSummarizeWebsite:   2 * time.Minute, // Fetch plus multi-stage LLM summary
+	ToolGitHubRepoInfo:     30 * time.Second,
+	ToolGitHubSearch:       30 * time.Second,
+	ToolGitHubReadFile:     30 * time.Second,
+	ToolGitHubListOrgRepos: 30 * time.Second,
+	ToolGetWeather:         30 * time.Second,
+
+	// Image generation waits on a RunPod job
+	ToolGenerateImageWithRunPod: 5 * time.Minute,
+
+	// Personality analysis reads history and calls the LLM
+	ToolMimicPersonality: 2 * time.Minute,
+	ToolAnalyzeUserStyle: 2 * time.Minute,
+
+	// Long histories are summarized in chunks, one LLM call each
+	ToolSummarizeConversation: 3 * time.Minute,
+
+	// Music resolves songs with yt-dlp; playback itself runs in the background
+	ToolMusicPlay:     90 * time.Second,
+	ToolMusicPlaylist: 90 * time.Second,
+	ToolMusicRadio:    90 * time.Second,
+}
+
+// ToolTimeouts resolves how long each tool may run
+type ToolTimeouts struct {
+	defaultTimeout time.Duration
+	overrides      map[string]time.Duration
+}
+
+// NewToolTimeouts creates timeouts from a default for tools without a
+// built-in timeout and per-tool overrides. A non-positive default uses
+// DefaultToolTimeout.
+func NewToolTimeouts(defaultTimeout time.Duration, overrides map[string]time.Duration) *ToolTimeouts {
+	if defaultTimeout <= 0 {
+		defaultTimeout = DefaultToolTimeout
+	}
+	return &ToolTimeouts{defaultTimeout: defaultTimeout, overrides: overrides}
+}
+
+// For returns the timeout for a tool: its override, else its built-in
+// timeout, else the default
+func (t *ToolTimeouts) For(toolName string) time.Duration {
+	if t == nil {
+		t = NewToolTimeouts(0, nil)
+	}
+	if timeout, ok := t.overrides[toolName]; ok && timeout > 0 {
+		return timeout
+	}
+	if timeout, ok := defaultToolTimeouts[toolName]; ok {
+		return timeout
+	}
+	return t.defaultTimeout
+}
+
+// ExecuteWithTimeout runs a tool with a context that is cancelled after
+// timeout. If the tool hasn't returned by then, a failed result is returned
+// so the turn can continue; the tool sees the cancelled context and, for
+// tools running external processes, has them killed.
+func ExecuteWithTimeout(ctx context.Context, toolName string, timeout time.Duration, run func(ctx context.Context) *ToolResult) *ToolResult {
+	toolCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan *ToolResult, 1)
+	go func() {
+		done <- run(toolCtx)
+	}()
+
+	select {
+	case result := <-done:
+		return result
+	case <-toolCtx.Done():
+	}
+
+	// Prefer a result that arrived just as the deadline passed
+	select {
+	case result := <-done:
+		return result
+	default:
+	}
+
+	if ctx.Err() != nil {
+		return &ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("%s was cancelled: %v", toolName, ctx.Err()),
+		}
+	}
+	return &ToolResult{
+		Success: false,
+		Error:   fmt.Sprintf("%s timed out after %s. Continue without its result or try a different approach.", toolName, timeout),
+		Data:    map[string]interface{}{"timed_out": true},
+	}
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteWithTimeout_ReturnsTimeoutResult(t *testing.T) {
+	cancelled := make(chan struct{})
+	result := ExecuteWithTimeout(context.Background(), ToolFetchWebpage, 20*time.Millisecond, func(ctx context.Context) *ToolResult {
+		<-ctx.Done()
+		close(cancelled)
+		time.Sleep(50 * time.Millisecond) // A tool slow to notice cancellation doesn't hold up the turn
+		return &ToolResult{Success: true}
+	})
+
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "fetch_webpage timed out after 20ms")
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("tool context was not cancelled")
+	}
+}
+
+func TestExecuteWithTimeout_ReturnsToolResult(t *testing.T) {
+	result := ExecuteWithTimeout(context.Background(), ToolWebSearch, time.Second, func(ctx context.Context) *ToolResult {
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		return &ToolResult{Success: true, Message: "done"}
+	})
+
+	assert.True(t, result.Success)
+	assert.Equal(t, "done", result.Message)
+}
+
+func TestToolTimeouts_For(t *testing.T) {
+	timeouts := NewToolTimeouts(10*time.Second, map[string]time.Duration{ToolWebSearch: 5 * time.Second})
+
+	assert.Equal(t, 5*time.Second, timeouts.For(ToolWebSearch), "override wins")
+	assert.Equal(t, 5*time.Minute, timeouts.For(ToolGenerateImageWithRunPod), "built-in per-tool timeout")
+	assert.Equal(t, 10*time.Second, timeouts.For(ToolCreateFact), "configured default")
+	assert.Equal(t, DefaultToolTimeout, (*ToolTimeouts)(nil).For(ToolCreateFact))
+}
@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/graph"
+)
+
+// ToolAccess is an agent's tool allow and deny lists. Entries are tool names
+// or capability names such as "music", which stand for all of their tools. An
+// empty allow list allows every tool, and a denied tool stays denied even
+// when it is also allowed.
+type ToolAccess struct {
+	Allowed []string
+	Denied  []string
+	DenyAll bool // Set when the lists couldn't be loaded, so no tool runs unchecked
+}
+
+// AgentToolAccess returns the tool access of an agent from its config and
+// the error loading it. A failed load denies every tool rather than
+// allowing them all.
+func AgentToolAccess(agentConfig *graph.AgentConfig, err error) ToolAccess {
+	if err != nil {
+		return ToolAccess{DenyAll: true}
+	}
+	if agentConfig == nil {
+		return ToolAccess{}
+	}
+	return ToolAccess{Allowed: agentConfig.AllowedTools, Denied: agentConfig.DeniedTools}
+}
+
+// expandToolNames resolves capability names in entries to their tools
+func expandToolNames(entries []string) map[string]bool {
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		name := normalizeCapability(entry)
+		if capabilityNames, ok := capabilityTools[name]; ok {
+			for _, toolName := range capabilityNames {
+				names[toolName] = true
+			}
+			continue
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// Allows reports whether the agent may use the tool
+func (a ToolAccess) Allows(toolName string) bool {
+	if a.DenyAll {
+		return false
+	}
+	if expandToolNames(a.Denied)[toolName] {
+		return false
+	}
+	return len(a.Allowed) == 0 || expandToolNames(a.Allowed)[toolName]
+}
+
+// IsRestricted reports whether any tool is allowed or denied explicitly
+func (a ToolAccess) IsRestricted() bool {
+	return a.DenyAll || len(a.Allowed) > 0 || len(a.Denied) > 0
+}
+
+// FilterTools returns the tools the agent may use, in their original order
+func FilterTools(tools []adapter.Tool, access ToolAccess) []adapter.Tool {
+	if !access.IsRestricted() {
+		return tools
+	}
+	if access.DenyAll {
+		return []adapter.Tool{}
+	}
+	allowed := expandToolNames(access.Allowed)
+	denied := expandToolNames(access.Denied)
+
+	filtered := make([]adapter.Tool, 0, len(tools))
+	for _, tool := range tools {
+		name := tool.Function.Name
+		if denied[name] || (len(allowed) > 0 && !allowed[name]) {
+			continue
+		}
+		filtered = append(filtered, tool)
+	}
+	return filtered
+}
+
+// UnknownToolNames returns the entries that name neither a tool nor a capability
+func UnknownToolNames(entries []string) []string {
+	known := make(map[string]bool)
+	for _, tool := range GetAllTools() {
+		known[tool.Function.Name] = true
+	}
+
+	var unknown []string
+	for _, entry := range entries {
+		name := normalizeCapability(entry)
+		if _, ok := capabilityTools[name]; !ok && !known[name] {
+			unknown = append(unknown, entry)
+		}
+	}
+	return unknown
+}
+
+// DisabledToolsPromptSection tells the agent which tools it can't use, since
+// the tool overview in the system prompt lists all of them. Returns "" when
+// every tool is available.
+func DisabledToolsPromptSection(access ToolAccess) string {
+	if !access.IsRestricted() {
+		return ""
+	}
+	if access.DenyAll {
+		return `
+## Disabled Tools
+
+Your tools are unavailable right now. Don't call any; answer from what you already know.
+`
+	}
+	var disabled []string
+	for _, tool := range GetAllTools() {
+		if !access.Allows(tool.Function.Name) {
+			disabled = append(disabled, tool.Function.Name)
+		}
+	}
+	if len(disabled) == 0 {
+		return ""
+	}
+	sort.Strings(disabled)
+
+	return fmt.Sprintf(`
+## Disabled Tools
+
+These tools are turned off for you. Don't call them or offer what they do; if asked, say it isn't something you can do here:
+%s
+`, strings.Join(disabled, ", "))
+}
@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/internal/graph"
+)
+
+func toolNames(tools []adapter.Tool) map[string]bool {
+	names := make(map[string]bool)
+	for _, tool := range tools {
+		names[tool.Function.Name] = true
+	}
+	return names
+}
+
+func TestFilterTools(t *testing.T) {
+	all := GetAllTools()
+
+	if got := FilterTools(all, ToolAccess{}); len(got) != len(all) {
+		t.Errorf("Expected no lists to allow all %d tools, got %d", len(all), len(got))
+	}
+
+	// Capability names stand for all of their tools
+	denied := toolNames(FilterTools(all, ToolAccess{Denied: []string{"music", ToolBotShutdown}}))
+	if denied[ToolMusicPlay] || denied[ToolMusicStop] || denied[ToolBotShutdown] {
+		t.Error("Expected music tools and bot_shutdown to be removed")
+	}
+	if !denied[ToolSearchFacts] {
+		t.Error("Expected tools not denied to be kept")
+	}
+
+	allowed := toolNames(FilterTools(all, ToolAccess{
+		Allowed: []string{"fact_tracking", ToolArchivalSearch},
+		Denied:  []string{ToolPinFact},
+	}))
+	if len(allowed) != 7 || !allowed[ToolSearchFacts] || !allowed[ToolUpdateFact] || !allowed[ToolArchivalSearch] {
+		t.Errorf("Expected only the allowed fact tools and archival search, got %v", allowed)
+	}
+	if allowed[ToolPinFact] {
+		t.Error("Expected a denied tool to stay denied even when its capability is allowed")
+	}
+}
+
+func TestAgentToolAccess_FailsClosed(t *testing.T) {
+	access := AgentToolAccess(nil, errors.New("neo4j unavailable"))
+	if access.Allows(ToolSearchFacts) || len(FilterTools(GetAllTools(), access)) != 0 {
+		t.Error("Expected a config that failed to load to deny every tool")
+	}
+	if VoiceJoinAllowed(access) {
+		t.Error("Expected voice auto-join to be refused when the config failed to load")
+	}
+	if !AgentToolAccess(nil, nil).Allows(ToolSearchFacts) {
+		t.Error("Expected an agent without a config to use every tool")
+	}
+
+	musicOnly := AgentToolAccess(&graph.AgentConfig{DeniedTools: []string{"voice"}}, nil)
+	if !VoiceJoinAllowed(musicOnly) {
+		t.Error("Expected music to be enough to join voice")
+	}
+	if VoiceJoinAllowed(AgentToolAccess(&graph.AgentConfig{DeniedTools: []string{"voice", "music"}}, nil)) {
+		t.Error("Expected voice auto-join to be refused with music and voice denied")
+	}
+}
+
+func TestExecutor_RefusesDeniedTool(t *testing.T) {
+	executor := NewExecutor(nil)
+	execCtx := &ExecutionContext{
+		AgentID:    "knowledge-bot",
+		ToolAccess: ToolAccess{Denied: []string{ToolBotShutdown}},
+	}
+
+	result := executor.Execute(context.Background(), execCtx, adapter.ToolCall{Name: ToolBotShutdown})
+	if result.Success || !strings.Contains(result.Error, "disabled") {
+		t.Errorf("Expected the denied tool to be refused, got %+v", result)
+	}
+}
+
+func TestUnknownToolNames(t *testing.T) {
+	unknown := UnknownToolNames([]string{"music", "Web Search", ToolCreateFact, "launch_rockets"})
+	if len(unknown) != 1 || unknown[0] != "launch_rockets" {
+		t.Errorf("Expected only launch_rockets to be unknown, got %v", unknown)
+	}
+}
@@ -0,0 +1,233 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxExactFloat is the largest integer a float64 holds exactly; larger
+// numbers, such as Discord IDs sent unquoted, have already lost digits
+const maxExactFloat = 1 << 53
+
+// toolSchemas indexes every tool's parameter schema by tool name
+var toolSchemas = sync.OnceValue(func() map[string]map[string]interface{} {
+	schemas := make(map[string]map[string]interface{})
+	for _, tool := range GetAllTools() {
+		schemas[tool.Function.Name] = tool.Function.Parameters
+	}
+	return schemas
+})
+
+// invalidArgumentsResult describes a call's invalid arguments so the LLM can
+// correct them and call the tool again
+func invalidArgumentsResult(toolName string, problems []string) *ToolResult {
+	return &ToolResult{
+		Success: false,
+		Error:   fmt.Sprintf("Invalid arguments for %s: %s. Fix the arguments and call the tool again.", toolName, strings.Join(problems, "; ")),
+		Data:    map[string]interface{}{"invalid_arguments": problems},
+	}
+}
+
+// validateToolArgs checks args against a tool's parameter schema and returns
+// the arguments to call the tool with, along with any problems found. Values
+// in the wrong JSON type are coerced where the intent is clear: numeric
+// strings to numbers, "true" and "false" to booleans, whole numbers to
+// strings, enum values in the wrong case to the declared case and single
+// values to one-element arrays. Numbers stay float64, as JSON decoding
+// produces, so handlers read every number the same way. Null values, and
+// empty strings for enum properties, count as missing; properties the schema
+// doesn't declare are passed through.
+func validateToolArgs(schema map[string]interface{}, args map[string]interface{}) (map[string]interface{}, []string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	validated := make(map[string]interface{}, len(args))
+	var problems []string
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := args[name]
+		if value == nil {
+			continue
+		}
+		propSchema, ok := properties[name].(map[string]interface{})
+		if !ok {
+			validated[name] = value
+			continue
+		}
+		if value == "" && propSchema["enum"] != nil {
+			// An empty choice means the default
+			continue
+		}
+		coerced, problem := coerceArg(propSchema, value)
+		if problem != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", name, problem))
+			continue
+		}
+		validated[name] = coerced
+	}
+
+	for _, name := range schemaStrings(schema["required"]) {
+		if _, ok := validated[name]; !ok && (args[name] == nil || args[name] == "") {
+			problems = append(problems, fmt.Sprintf("%s: required", name))
+		}
+	}
+	return validated, problems
+}
+
+// coerceArg checks one value against its property schema, returning the
+// coerced value or what is wrong with it
+func coerceArg(schema map[string]interface{}, value interface{}) (interface{}, string) {
+	kind, _ := schema["type"].(string)
+	switch kind {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			f, isNumber := value.(float64)
+			if !isNumber || f != math.Trunc(f) || math.Abs(f) > maxExactFloat {
+				return nil, fmt.Sprintf("expected a string, got %s", describeArg(value))
+			}
+			s = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		if enum := schemaStrings(schema["enum"]); len(enum) > 0 {
+			i := slices.IndexFunc(enum, func(option string) bool { return strings.EqualFold(option, s) })
+			if i < 0 {
+				return nil, fmt.Sprintf("must be one of %s, got %q", strings.Join(enum, ", "), s)
+			}
+			s = enum[i]
+		}
+		return s, ""
+
+	case "number", "integer":
+		f, ok := value.(float64)
+		if !ok {
+			switch v := value.(type) {
+			case int:
+				f, ok = float64(v), true
+			case int64:
+				f, ok = float64(v), true
+			case string:
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+				f, ok = parsed, err == nil
+			}
+		}
+		if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Sprintf("expected a number, got %s", describeArg(value))
+		}
+		if kind == "integer" && f != math.Trunc(f) {
+			return nil, fmt.Sprintf("expected a whole number, got %v", f)
+		}
+		if minimum, ok := schemaNumber(schema["minimum"]); ok && f < minimum {
+			return nil, fmt.Sprintf("must be at least %v, got %v", minimum, f)
+		}
+		if maximum, ok := schemaNumber(schema["maximum"]); ok && f > maximum {
+			return nil, fmt.Sprintf("must be at most %v, got %v", maximum, f)
+		}
+		return f, ""
+
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, ""
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, ""
+			}
+		}
+		return nil, fmt.Sprintf("expected true or false, got %s", describeArg(value))
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			if strs, isStrings := value.([]string); isStrings {
+				for _, s := range strs {
+					items = append(items, s)
+				}
+			} else {
+				items = []interface{}{value}
+			}
+		}
+		itemSchema, _ := schema["items"].(map[string]interface{})
+		if itemSchema == nil {
+			return items, ""
+		}
+		coerced := make([]interface{}, 0, len(items))
+		for i, item := range items {
+			c, problem := coerceArg(itemSchema, item)
+			if problem != "" {
+				return nil, fmt.Sprintf("item %d: %s", i, problem)
+			}
+			coerced = append(coerced, c)
+		}
+		return coerced, ""
+
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Sprintf("expected an object, got %s", describeArg(value))
+		}
+		if _, hasProperties := schema["properties"]; !hasProperties {
+			return obj, ""
+		}
+		validated, problems := validateToolArgs(schema, obj)
+		if len(problems) > 0 {
+			return nil, strings.Join(problems, ", ")
+		}
+		return validated, ""
+	}
+	return value, ""
+}
+
+// describeArg names a JSON value's type for error messages
+func describeArg(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("the string %q", v)
+	case float64, int, int64:
+		return fmt.Sprintf("the number %v", v)
+	case bool:
+		return fmt.Sprintf("%v", v)
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// schemaStrings reads a schema list such as "required" or "enum", declared as
+// []string in Go or []interface{} when decoded from JSON
+func schemaStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		strs := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	}
+	return nil
+}
+
+// schemaNumber reads a schema bound such as "minimum", declared as an int or
+// a float64
+func schemaNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
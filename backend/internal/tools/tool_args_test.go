@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ezra-clone/backend/internal/adapter"
+)
+
+func TestValidateToolArgs_CoercesClearIntent(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"limit":    map[string]interface{}{"type": "integer", "minimum": 1},
+			"pinned":   map[string]interface{}{"type": "boolean"},
+			"guild_id": map[string]interface{}{"type": "string"},
+			"units":    map[string]interface{}{"type": "string", "enum": []string{"metric", "imperial"}},
+			"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		"required": []string{"limit"},
+	}
+	args := map[string]interface{}{
+		"limit":    "5",
+		"pinned":   "true",
+		"guild_id": float64(123456),
+		"units":    "Imperial",
+		"tags":     "music",
+		"extra":    "kept",
+		"ignored":  nil,
+	}
+
+	got, problems := validateToolArgs(schema, args)
+	if len(problems) > 0 {
+		t.Fatalf("Expected no problems, got %v", problems)
+	}
+	want := map[string]interface{}{
+		"limit":    float64(5),
+		"pinned":   true,
+		"guild_id": "123456",
+		"units":    "imperial",
+		"tags":     []interface{}{"music"},
+		"extra":    "kept",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("validateToolArgs() = %#v, want %#v", got, want)
+	}
+}
+
+func TestValidateToolArgs_ReportsEveryProblem(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query":  map[string]interface{}{"type": "string"},
+			"limit":  map[string]interface{}{"type": "integer"},
+			"volume": map[string]interface{}{"type": "integer", "maximum": 100},
+			"action": map[string]interface{}{"type": "string", "enum": []string{"start", "stop"}},
+		},
+		"required": []string{"query", "action"},
+	}
+
+	_, problems := validateToolArgs(schema, map[string]interface{}{"limit": 2.5, "volume": float64(150), "action": ""})
+	want := []string{
+		"limit: expected a whole number, got 2.5",
+		"volume: must be at most 100, got 150",
+		"query: required",
+		"action: required",
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("Expected problems %q, got %q", want, problems)
+	}
+}
+
+func TestExecute_RejectsInvalidArgumentsBeforeDispatch(t *testing.T) {
+	e := NewExecutor(nil)
+	result := e.Execute(context.Background(), &ExecutionContext{AgentID: "Ezra"}, adapter.ToolCall{
+		Name:      ToolMusicVolume,
+		Arguments: map[string]interface{}{"volume": "loud"},
+	})
+
+	if result.Success || !strings.Contains(result.Error, `volume: expected a number, got the string "loud"`) {
+		t.Errorf("Expected an invalid arguments error, got %+v", result)
+	}
+	data, _ := result.Data.(map[string]interface{})
+	if _, ok := data["invalid_arguments"]; !ok {
+		t.Errorf("Expected the problems in the result data, got %+v", result.Data)
+	}
+}
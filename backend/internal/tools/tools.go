@@ -19,6 +19,10 @@ const (
 	ToolSearchFacts    = "search_facts"
 	ToolLinkToUser     = "link_fact_to_user"
 	ToolGetUserContext = "get_user_context"
+	ToolPinFact        = "pin_fact"
+	ToolGetFact        = "get_fact"
+	ToolUpdateFact     = "update_fact"
+	ToolDeleteFact     = "delete_fact"
 )
 
 // Tool names - Topic Tools
@@ -27,12 +31,15 @@ const (
 	ToolLinkTopics     = "link_topics"
 	ToolFindRelated    = "find_related_topics"
 	ToolLinkUserTopic  = "link_user_to_topic"
+	ToolCompareUsers   = "compare_users"
 )
 
 // Tool names - Conversation Tools
 const (
-	ToolGetHistory     = "get_conversation_history"
-	ToolSendMessage    = "send_message"
+	ToolGetHistory            = "get_conversation_history"
+	ToolSendMessage           = "send_message"
+	ToolResetConversation     = "reset_conversation"
+	ToolSummarizeConversation = "summarize_conversation"
 )
 
 // Tool names - System Tools
@@ -49,6 +56,7 @@ const (
 	ToolGitHubSearch     = "github_search"
 	ToolGitHubReadFile   = "github_read_file"
 	ToolGitHubListOrgRepos = "github_list_org_repos"
+	ToolGetWeather       = "get_weather"
 )
 
 // Tool names - Discord Tools
@@ -58,6 +66,14 @@ const (
 	ToolDiscordSearchMessages = "discord_search_messages"
 	ToolDiscordGetChannelInfo = "discord_get_channel_info"
 	ToolReadCodebase = "read_codebase"
+	ToolInterestPoll = "post_interest_poll"
+)
+
+// Tool names - Scheduler Tools
+const (
+	ToolScheduleMessage        = "schedule_message"
+	ToolListScheduledMessages  = "list_scheduled_messages"
+	ToolCancelScheduledMessage = "cancel_scheduled_message"
 )
 
 // Tool names - Personality/Mimic Tools
@@ -80,7 +96,9 @@ const (
 	ToolMusicPlay      = "music_play"
 	ToolMusicPlaylist  = "music_playlist"
 	ToolMusicQueue     = "music_queue"
+	ToolMusicNowPlaying = "music_now_playing"
 	ToolMusicSkip      = "music_skip"
+	ToolMusicSeek      = "music_seek"
 	ToolMusicPause     = "music_pause"
 	ToolMusicResume    = "music_resume"
 	ToolMusicStop      = "music_stop"
@@ -89,6 +107,12 @@ const (
 	ToolMusicDisconnect = "music_disconnect"
 )
 
+// Tool names - Voice Tools
+const (
+	ToolVoiceListReferences  = "voice_list_references"
+	ToolVoiceDeleteReference = "voice_delete_reference"
+)
+
 // GetAllTools returns all available tools for the agent
 func GetAllTools() []adapter.Tool {
 	tools := []adapter.Tool{}
@@ -110,9 +134,15 @@ func GetAllTools() []adapter.Tool {
 	
 	// GitHub Tools
 	tools = append(tools, GetGitHubTools()...)
+
+	// Weather Tools
+	tools = append(tools, GetWeatherTools()...)
 	
 	// Discord Tools
 	tools = append(tools, GetDiscordTools()...)
+
+	// Scheduler Tools
+	tools = append(tools, GetSchedulerTools()...)
 	
 	// Personality/Mimic Tools
 	tools = append(tools, GetPersonalityTools()...)
@@ -123,6 +153,9 @@ func GetAllTools() []adapter.Tool {
 	// Music Tools
 	tools = append(tools, GetMusicTools()...)
 	
+	// Voice Tools
+	tools = append(tools, GetVoiceTools()...)
+	
 	// System Tools
 	tools = append(tools, GetSystemTools()...)
 	
@@ -3,6 +3,9 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strings"
+
+	"ezra-clone/backend/internal/graph"
 )
 
 // ============================================================================
@@ -98,3 +101,41 @@ func (e *Executor) executeLinkUserTopic(ctx context.Context, execCtx *ExecutionC
 	}
 }
 
+func (e *Executor) executeCompareUsers(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	userA, _ := args["user_a"].(string)
+	userB, _ := args["user_b"].(string)
+	// Accept Discord mentions as well as raw IDs
+	userA = strings.Trim(strings.TrimSpace(userA), "<@!>")
+	userB = strings.Trim(strings.TrimSpace(userB), "<@!>")
+	if userA == "" {
+		userA = execCtx.UserID
+	}
+	if userB == "" {
+		return &ToolResult{Success: false, Error: "user_b is required"}
+	}
+	if userA == userB {
+		return &ToolResult{Success: false, Error: "user_a and user_b must be different users"}
+	}
+
+	topicsA, err := e.repo.GetUserTopics(ctx, userA)
+	if err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+	topicsB, err := e.repo.GetUserTopics(ctx, userB)
+	if err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+
+	comparison := graph.CompareTopics(topicsA, topicsB)
+
+	return &ToolResult{
+		Success: true,
+		Data: map[string]interface{}{
+			"user_a":     userA,
+			"user_b":     userB,
+			"comparison": comparison,
+		},
+		Message: fmt.Sprintf("Users share %d topic(s); %d are unique to the first user and %d to the second",
+			len(comparison.Shared), len(comparison.OnlyA), len(comparison.OnlyB)),
+	}
+}
@@ -96,6 +96,27 @@ func GetTopicTools() []adapter.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolCompareUsers,
+				Description: "Compare two users' interests. Returns the topics they share and the topics only one of them is into.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"user_a": map[string]interface{}{
+							"type":        "string",
+							"description": "First user ID (leave empty for current user)",
+						},
+						"user_b": map[string]interface{}{
+							"type":        "string",
+							"description": "Second user ID to compare against",
+						},
+					},
+					"required": []string{"user_b"},
+				},
+			},
+		},
 	}
 }
 
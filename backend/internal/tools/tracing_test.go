@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ezra-clone/backend/internal/adapter"
+	"ezra-clone/backend/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTurnTracing_NestsLLMAndToolSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	// The model asks for a single tool call
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":%q,"arguments":"{}"}}]}}]}`, ToolListWorkflows)
+	}))
+	defer server.Close()
+
+	llm := adapter.NewLLMAdapter(server.URL, "", "test-model")
+	executor := NewExecutor(nil)
+	execCtx := &ExecutionContext{AgentID: "agent-1", UserID: "user-1", Platform: "web"}
+
+	// Same shape as a turn: generate, then execute each requested tool
+	ctx, turn := tracing.Start(context.Background(), "agent.turn")
+	resp, err := llm.Generate(ctx, adapter.GenerateParams{}, "system", "hi", nil)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	for _, toolCall := range resp.ToolCalls {
+		executor.Execute(ctx, execCtx, toolCall)
+	}
+	tracing.End(turn, nil)
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	root, ok := spans["agent.turn"]
+	if !ok {
+		t.Fatalf("Expected an agent.turn span, got %v", exporter.GetSpans().Snapshots())
+	}
+	for _, name := range []string{"llm.generate", "tool." + ToolListWorkflows} {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("Expected a %s span", name)
+		}
+		if span.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("Expected %s to be a child of agent.turn", name)
+		}
+		if span.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Errorf("Expected %s to share the turn's trace", name)
+		}
+	}
+
+	// The executor has no ComfyUI backend, so the tool fails and its span says so
+	if got := spans["tool."+ToolListWorkflows].Status.Code; got != codes.Error {
+		t.Errorf("Expected failed tool span to have error status, got %v", got)
+	}
+}
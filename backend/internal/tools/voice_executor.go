@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// VoiceExecutor handles voice reference tool execution
+type VoiceExecutor struct {
+	references *ReferenceAudioManager
+	logger     *zap.Logger
+}
+
+// NewVoiceExecutor creates a new voice executor
+func NewVoiceExecutor(references *ReferenceAudioManager, logger *zap.Logger) *VoiceExecutor {
+	return &VoiceExecutor{
+		references: references,
+		logger:     logger,
+	}
+}
+
+// ExecuteVoiceTool executes a voice tool call
+func (v *VoiceExecutor) ExecuteVoiceTool(ctx context.Context, execCtx *ExecutionContext, toolName string, args map[string]interface{}) *ToolResult {
+	if v.references == nil {
+		return &ToolResult{
+			Success: false,
+			Error:   "Voice references are not configured",
+		}
+	}
+
+	switch toolName {
+	case ToolVoiceListReferences:
+		return v.handleListReferences(ctx, execCtx, args)
+	case ToolVoiceDeleteReference:
+		return v.handleDeleteReference(ctx, execCtx, args)
+	default:
+		return &ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Unknown voice tool: %s", toolName),
+		}
+	}
+}
+
+func (v *VoiceExecutor) handleListReferences(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	refs, err := v.references.List()
+	if err != nil {
+		v.logger.Warn("Failed to list voice references", zap.Error(err))
+		return &ToolResult{Success: false, Error: "Failed to list voice references"}
+	}
+
+	hasDefault := false
+	for _, ref := range refs {
+		if ref.IsDefault {
+			hasDefault = true
+			break
+		}
+	}
+
+	message := fmt.Sprintf("Found %d voice reference(s).", len(refs))
+	if !hasDefault {
+		message += " No default reference is set, so users without their own reference have no fallback voice."
+	}
+
+	return &ToolResult{
+		Success: true,
+		Data: map[string]interface{}{
+			"references":  refs,
+			"count":       len(refs),
+			"has_default": hasDefault,
+		},
+		Message: message,
+	}
+}
+
+func (v *VoiceExecutor) handleDeleteReference(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	name, _ := args["name"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = execCtx.UserID
+	}
+	force, _ := args["force"].(bool)
+
+	// Users may remove their own reference; anything else is admin-only
+	if name != execCtx.UserID && execCtx.UserID != AdminUserID {
+		return &ToolResult{
+			Success: false,
+			Error:   "Unauthorized: you can only delete your own voice reference",
+		}
+	}
+
+	err := v.references.Delete(name, force)
+	if errors.Is(err, ErrReferenceNotFound) {
+		return &ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("No voice reference named '%s' exists", name),
+		}
+	}
+	if errors.Is(err, ErrDefaultReferenceProtected) {
+		return &ToolResult{
+			Success: false,
+			Error:   "The default voice reference is the fallback for every user without their own. Set force=true to delete it anyway.",
+		}
+	}
+	if err != nil {
+		v.logger.Warn("Failed to delete voice reference", zap.String("name", name), zap.Error(err))
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+
+	message := fmt.Sprintf("Deleted voice reference '%s'", name)
+	if name == DefaultReferenceName {
+		message += ". Warning: users without their own reference now have no fallback voice."
+	}
+	return &ToolResult{Success: true, Message: message}
+}
@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"ezra-clone/backend/internal/tools/music"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// ErrUserNotInVoice is returned when a voice join is requested by a user who
+// isn't connected to a voice channel
+var ErrUserNotInVoice = errors.New("user is not in a voice channel")
+
+// voiceJoinRequest matches a message that as a whole asks the bot to join
+// voice ("join vc", "hey, can you hop in the call please?"). Only a little
+// politeness around the request is allowed, so messages that merely mention
+// voice ("don't join vc yet", "I'll join the call later") don't count.
+var voiceJoinRequest = regexp.MustCompile(`^(?:(?:hey|hi|yo|ok|okay|please|pls|can you|could you|would you|will you)[\s,]+)*` +
+	`(?:join|hop in|hop into|come to|come into|get in|get into)\s+(?:the\s+|my\s+|our\s+)?(?:voice|vc|call)(?:\s+(?:channel|chat))?` +
+	`(?:[\s,]+(?:please|pls|now|thanks|thx))*[\s!.?]*$`)
+
+// IsVoiceJoinRequest reports whether a message asks the bot to join voice
+func IsVoiceJoinRequest(content string) bool {
+	return voiceJoinRequest.MatchString(strings.ToLower(strings.TrimSpace(content)))
+}
+
+// VoiceJoinAllowed reports whether access lets the agent join voice on its
+// own. Voice is joined to play music or to talk, so music_play or one of the
+// voice tools must be allowed.
+func VoiceJoinAllowed(access ToolAccess) bool {
+	if access.Allows(ToolMusicPlay) {
+		return true
+	}
+	for _, toolName := range capabilityTools["voice"] {
+		if access.Allows(toolName) {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleVoiceJoinRequest joins the author's current voice channel if content
+// asks the bot to join voice. Returns the joined channel ID, or "" with a nil
+// error if content isn't a voice request.
+func (m *MusicExecutor) HandleVoiceJoinRequest(guildID, userID, content string) (string, error) {
+	if guildID == "" || !IsVoiceJoinRequest(content) {
+		return "", nil
+	}
+
+	channelID := m.detectUserVoiceChannel(guildID, userID)
+	if channelID == "" {
+		return "", ErrUserNotInVoice
+	}
+
+	bot := m.manager.GetBot(guildID, m.session)
+	if err := m.connectVoice(bot, guildID, channelID); err != nil {
+		return "", err
+	}
+
+	m.logger.Info("Auto-joined user's voice channel",
+		zap.String("guild_id", guildID),
+		zap.String("channel_id", channelID),
+		zap.String("user_id", userID),
+	)
+	return channelID, nil
+}
+
+// detectUserVoiceChannel returns the voice channel a user is in, or "" if it
+// can't be found in the session state cache
+func (m *MusicExecutor) detectUserVoiceChannel(guildID, userID string) string {
+	m.logger.Debug("Attempting to detect user voice channel",
+		zap.String("guild_id", guildID),
+		zap.String("user_id", userID),
+	)
+
+	// The state cache is populated by voice state update events
+	vs, err := m.session.State.VoiceState(guildID, userID)
+	if err == nil && vs != nil && vs.ChannelID != "" {
+		m.logger.Debug("Found voice channel from session state",
+			zap.String("channel_id", vs.ChannelID),
+		)
+		return vs.ChannelID
+	}
+
+	// If state cache doesn't have it, the voice state might not be tracked yet
+	// This can happen if the user joined before the bot started or state isn't synced
+	// Try to get guild and check voice states - but note that Guild() API call
+	// doesn't return voice states, only the state cache does
+	m.logger.Debug("Voice state not in cache, checking if guild state has voice states",
+		zap.String("guild_id", guildID),
+		zap.String("user_id", userID),
+		zap.Error(err),
+	)
+
+	guild, err := m.session.State.Guild(guildID)
+	if err == nil && guild != nil {
+		for _, voiceState := range guild.VoiceStates {
+			if voiceState.UserID == userID && voiceState.ChannelID != "" {
+				m.logger.Info("Found voice channel from guild state cache",
+					zap.String("channel_id", voiceState.ChannelID),
+					zap.String("user_id", userID),
+				)
+				return voiceState.ChannelID
+			}
+		}
+	}
+
+	m.logger.Warn("Could not find user voice channel - state cache may not be populated yet",
+		zap.String("guild_id", guildID),
+		zap.String("user_id", userID),
+		zap.Bool("guild_in_cache", err == nil && guild != nil),
+		zap.Int("voice_states_in_guild", func() int {
+			if guild != nil {
+				return len(guild.VoiceStates)
+			}
+			return 0
+		}()),
+	)
+	return ""
+}
+
+// connectVoice connects the bot to a voice channel, moving it if it is
+// already connected elsewhere. bot.Mu guards the connection but isn't held
+// while disconnecting or joining, which can block.
+func (m *MusicExecutor) connectVoice(bot *music.MusicBot, guildID, channelID string) error {
+	bot.Mu.Lock()
+	current := bot.VoiceConn
+	if current != nil && current.ChannelID == channelID {
+		bot.Mu.Unlock()
+		return nil
+	}
+	bot.VoiceConn = nil
+	bot.Mu.Unlock()
+
+	if current != nil {
+		m.logger.Debug("Disconnecting from old voice channel", zap.String("channel_id", current.ChannelID))
+		current.Disconnect()
+		// Give it a moment to fully disconnect
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	join := m.joinVoice
+	if join == nil {
+		join = func(guildID, channelID string) (*discordgo.VoiceConnection, error) {
+			return m.session.ChannelVoiceJoin(guildID, channelID, false, true)
+		}
+	}
+
+	vc, err := join(guildID, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to join voice channel: %w", err)
+	}
+	bot.Mu.Lock()
+	bot.VoiceConn = vc
+	bot.Mu.Unlock()
+
+	// Wait for voice connection to be ready
+	timeout := time.After(5 * time.Second)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for !vc.Ready {
+		select {
+		case <-timeout:
+			m.logger.Warn("Voice connection timeout, continuing anyway...")
+			return nil
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"errors"
+	"testing"
+
+	"ezra-clone/backend/internal/tools/music"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// newTestVoiceExecutor returns a MusicExecutor whose state cache has userID
+// in voiceChannelID, recording joins instead of connecting to Discord
+func newTestVoiceExecutor(t *testing.T, guildID, userID, voiceChannelID string, joined *[]string) *MusicExecutor {
+	t.Helper()
+
+	state := discordgo.NewState()
+	err := state.GuildAdd(&discordgo.Guild{
+		ID: guildID,
+		VoiceStates: []*discordgo.VoiceState{
+			{GuildID: guildID, UserID: userID, ChannelID: voiceChannelID},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to seed state: %v", err)
+	}
+
+	logger := zap.NewNop()
+	session := &discordgo.Session{State: state}
+	return &MusicExecutor{
+		manager: music.NewMusicManager(nil, logger),
+		session: session,
+		logger:  logger,
+		joinVoice: func(guildID, channelID string) (*discordgo.VoiceConnection, error) {
+			*joined = append(*joined, channelID)
+			return &discordgo.VoiceConnection{GuildID: guildID, ChannelID: channelID, Ready: true}, nil
+		},
+	}
+}
+
+func TestHandleVoiceJoinRequest_JoinsAuthorsChannel(t *testing.T) {
+	var joined []string
+	m := newTestVoiceExecutor(t, "guild-1", "user-1", "voice-1", &joined)
+
+	channelID, err := m.HandleVoiceJoinRequest("guild-1", "user-1", "hey, can you join my voice channel please?")
+	if err != nil {
+		t.Fatalf("HandleVoiceJoinRequest failed: %v", err)
+	}
+	if channelID != "voice-1" {
+		t.Errorf("Expected to join voice-1, got %q", channelID)
+	}
+	if len(joined) != 1 || joined[0] != "voice-1" {
+		t.Errorf("Expected one join to voice-1, got %v", joined)
+	}
+
+	bot := m.manager.GetBot("guild-1", m.session)
+	bot.Mu.Lock()
+	vc := bot.VoiceConn
+	bot.Mu.Unlock()
+	if vc == nil || vc.ChannelID != "voice-1" {
+		t.Error("Expected bot voice connection to be set to voice-1")
+	}
+}
+
+func TestHandleVoiceJoinRequest_IgnoresOtherMessages(t *testing.T) {
+	var joined []string
+	m := newTestVoiceExecutor(t, "guild-1", "user-1", "voice-1", &joined)
+
+	channelID, err := m.HandleVoiceJoinRequest("guild-1", "user-1", "what's the weather like?")
+	if err != nil || channelID != "" {
+		t.Errorf("Expected no join, got channel %q, err %v", channelID, err)
+	}
+	if len(joined) != 0 {
+		t.Errorf("Expected no joins, got %v", joined)
+	}
+}
+
+func TestIsVoiceJoinRequest(t *testing.T) {
+	requests := []string{"join vc", "Join the call!", "hey, hop in vc pls", "could you come to voice", "  join my voice channel please?"}
+	for _, content := range requests {
+		if !IsVoiceJoinRequest(content) {
+			t.Errorf("Expected %q to be a voice join request", content)
+		}
+	}
+
+	others := []string{
+		"talk to me",
+		"don't join vc yet",
+		"I'll join the call later",
+		"can you talk to me about the game we played",
+		"what happens when people join voice channels?",
+	}
+	for _, content := range others {
+		if IsVoiceJoinRequest(content) {
+			t.Errorf("Expected %q not to be a voice join request", content)
+		}
+	}
+}
+
+func TestHandleVoiceJoinRequest_UserNotInVoice(t *testing.T) {
+	var joined []string
+	m := newTestVoiceExecutor(t, "guild-1", "user-1", "voice-1", &joined)
+
+	_, err := m.HandleVoiceJoinRequest("guild-1", "user-2", "join vc please")
+	if !errors.Is(err, ErrUserNotInVoice) {
+		t.Errorf("Expected ErrUserNotInVoice, got %v", err)
+	}
+	if len(joined) != 0 {
+		t.Errorf("Expected no joins, got %v", joined)
+	}
+}
@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"ezra-clone/backend/internal/adapter"
+)
+
+// GetVoiceTools returns voice reference management tools
+func GetVoiceTools() []adapter.Tool {
+	return []adapter.Tool{
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolVoiceListReferences,
+				Description: "List the stored voice reference audio clips used for text-to-speech, including whether the shared default reference is set.",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+					"required":   []string{},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolVoiceDeleteReference,
+				Description: "Delete a stored voice reference. Defaults to the requesting user's own reference. The 'default' reference is the fallback for everyone and requires force=true.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "Reference name (a user ID or 'default'). Omit to delete your own reference.",
+						},
+						"force": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Required to delete the 'default' reference",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,346 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrLocationNotFound is returned when a provider can't find a location
+var ErrLocationNotFound = errors.New("location not found")
+
+// AmbiguousLocationError is returned when a location matches several places
+// and none stands out; Candidates lets the agent ask which one was meant
+type AmbiguousLocationError struct {
+	Query      string
+	Candidates []string
+}
+
+func (e *AmbiguousLocationError) Error() string {
+	return fmt.Sprintf("%q matches several places: %s", e.Query, strings.Join(e.Candidates, "; "))
+}
+
+// WeatherUnits selects the units a report is given in
+type WeatherUnits string
+
+const (
+	WeatherUnitsMetric   WeatherUnits = "metric"   // °C, km/h, mm
+	WeatherUnitsImperial WeatherUnits = "imperial" // °F, mph, inch
+)
+
+// WeatherPlace is the resolved location of a report
+type WeatherPlace struct {
+	Name      string  `json:"name"`
+	Region    string  `json:"region,omitempty"`
+	Country   string  `json:"country,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone,omitempty"`
+}
+
+// String renders the place as "Name, Region, Country", skipping blanks
+func (p WeatherPlace) String() string {
+	parts := []string{p.Name}
+	for _, part := range []string{p.Region, p.Country} {
+		if part != "" && part != p.Name {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CurrentWeather is the conditions at the time of the report
+type CurrentWeather struct {
+	Time        string  `json:"time"`
+	Temperature float64 `json:"temperature"`
+	FeelsLike   float64 `json:"feels_like"`
+	Humidity    float64 `json:"humidity_percent"`
+	WindSpeed   float64 `json:"wind_speed"`
+	Conditions  string  `json:"conditions"`
+}
+
+// DailyForecast is one day of the forecast
+type DailyForecast struct {
+	Date                     string  `json:"date"`
+	Conditions               string  `json:"conditions"`
+	High                     float64 `json:"high"`
+	Low                      float64 `json:"low"`
+	PrecipitationProbability float64 `json:"precipitation_probability_percent"`
+}
+
+// WeatherReport is the current conditions and forecast for a place
+type WeatherReport struct {
+	Location WeatherPlace    `json:"location"`
+	Units    WeatherUnits    `json:"units"`
+	Current  CurrentWeather  `json:"current"`
+	Forecast []DailyForecast `json:"forecast"`
+}
+
+// WeatherProvider looks up the weather for a free-form location such as
+// "Paris" or "Springfield, Illinois"
+type WeatherProvider interface {
+	Weather(ctx context.Context, location string, days int, units WeatherUnits) (*WeatherReport, error)
+}
+
+// Open-Meteo endpoints; free and keyless
+const (
+	openMeteoGeocodeURL  = "https://geocoding-api.open-meteo.com/v1/search"
+	openMeteoForecastURL = "https://api.open-meteo.com/v1/forecast"
+)
+
+// OpenMeteoProvider gets weather from Open-Meteo, geocoding the location first
+type OpenMeteoProvider struct {
+	client      *http.Client
+	geocodeURL  string
+	forecastURL string
+}
+
+// NewOpenMeteoProvider creates a provider that calls Open-Meteo with client
+func NewOpenMeteoProvider(client *http.Client) *OpenMeteoProvider {
+	return &OpenMeteoProvider{
+		client:      client,
+		geocodeURL:  openMeteoGeocodeURL,
+		forecastURL: openMeteoForecastURL,
+	}
+}
+
+// geocodeResult is one match from the geocoding API
+type geocodeResult struct {
+	Name        string  `json:"name"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"`
+	Admin1      string  `json:"admin1"`
+	Timezone    string  `json:"timezone"`
+	Population  int64   `json:"population"`
+}
+
+func (g geocodeResult) place() WeatherPlace {
+	return WeatherPlace{
+		Name:      g.Name,
+		Region:    g.Admin1,
+		Country:   g.Country,
+		Latitude:  g.Latitude,
+		Longitude: g.Longitude,
+		Timezone:  g.Timezone,
+	}
+}
+
+// Weather geocodes location and fetches its current conditions and a
+// forecast of days days
+func (p *OpenMeteoProvider) Weather(ctx context.Context, location string, days int, units WeatherUnits) (*WeatherReport, error) {
+	place, err := p.geocode(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"latitude":      {fmt.Sprintf("%.4f", place.Latitude)},
+		"longitude":     {fmt.Sprintf("%.4f", place.Longitude)},
+		"current":       {"temperature_2m,apparent_temperature,relative_humidity_2m,weather_code,wind_speed_10m"},
+		"daily":         {"weather_code,temperature_2m_max,temperature_2m_min,precipitation_probability_max"},
+		"timezone":      {"auto"},
+		"forecast_days": {fmt.Sprint(days)},
+	}
+	if units == WeatherUnitsImperial {
+		params.Set("temperature_unit", "fahrenheit")
+		params.Set("wind_speed_unit", "mph")
+		params.Set("precipitation_unit", "inch")
+	}
+
+	var forecast struct {
+		Timezone string `json:"timezone"`
+		Current  struct {
+			Time                string  `json:"time"`
+			Temperature         float64 `json:"temperature_2m"`
+			ApparentTemperature float64 `json:"apparent_temperature"`
+			Humidity            float64 `json:"relative_humidity_2m"`
+			WeatherCode         int     `json:"weather_code"`
+			WindSpeed           float64 `json:"wind_speed_10m"`
+		} `json:"current"`
+		Daily struct {
+			Time                     []string  `json:"time"`
+			WeatherCode              []int     `json:"weather_code"`
+			TemperatureMax           []float64 `json:"temperature_2m_max"`
+			TemperatureMin           []float64 `json:"temperature_2m_min"`
+			PrecipitationProbability []float64 `json:"precipitation_probability_max"`
+		} `json:"daily"`
+	}
+	if err := p.getJSON(ctx, p.forecastURL, params, &forecast); err != nil {
+		return nil, fmt.Errorf("failed to get forecast: %w", err)
+	}
+
+	if place.Timezone == "" {
+		place.Timezone = forecast.Timezone
+	}
+	report := &WeatherReport{
+		Location: place,
+		Units:    units,
+		Current: CurrentWeather{
+			Time:        forecast.Current.Time,
+			Temperature: forecast.Current.Temperature,
+			FeelsLike:   forecast.Current.ApparentTemperature,
+			Humidity:    forecast.Current.Humidity,
+			WindSpeed:   forecast.Current.WindSpeed,
+			Conditions:  weatherCodeDescription(forecast.Current.WeatherCode),
+		},
+	}
+	daily := forecast.Daily
+	for i, date := range daily.Time {
+		day := DailyForecast{Date: date}
+		if i < len(daily.WeatherCode) {
+			day.Conditions = weatherCodeDescription(daily.WeatherCode[i])
+		}
+		if i < len(daily.TemperatureMax) {
+			day.High = daily.TemperatureMax[i]
+		}
+		if i < len(daily.TemperatureMin) {
+			day.Low = daily.TemperatureMin[i]
+		}
+		if i < len(daily.PrecipitationProbability) {
+			day.PrecipitationProbability = daily.PrecipitationProbability[i]
+		}
+		report.Forecast = append(report.Forecast, day)
+	}
+	return report, nil
+}
+
+// geocode resolves a location. Each comma-separated part after the name
+// ("Springfield, Illinois" or "Berlin, Land Berlin, Germany", as
+// WeatherPlace.String writes it) narrows the matches by region, country or
+// country code. Without any, the most populous match wins when it clearly
+// dominates; otherwise the location is ambiguous.
+func (p *OpenMeteoProvider) geocode(ctx context.Context, location string) (WeatherPlace, error) {
+	parts := strings.Split(location, ",")
+	name := strings.TrimSpace(parts[0])
+	var qualifiers []string
+	for _, part := range parts[1:] {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			qualifiers = append(qualifiers, part)
+		}
+	}
+	if name == "" {
+		return WeatherPlace{}, ErrLocationNotFound
+	}
+
+	var response struct {
+		Results []geocodeResult `json:"results"`
+	}
+	params := url.Values{"name": {name}, "count": {"10"}, "language": {"en"}, "format": {"json"}}
+	if err := p.getJSON(ctx, p.geocodeURL, params, &response); err != nil {
+		return WeatherPlace{}, fmt.Errorf("failed to look up location: %w", err)
+	}
+
+	matches := response.Results
+	if len(qualifiers) > 0 {
+		var narrowed []geocodeResult
+		for _, match := range matches {
+			if matchesQualifiers(match, qualifiers) {
+				narrowed = append(narrowed, match)
+			}
+		}
+		matches = narrowed
+	}
+
+	switch {
+	case len(matches) == 0:
+		return WeatherPlace{}, ErrLocationNotFound
+	case len(matches) == 1:
+		return matches[0].place(), nil
+	}
+
+	// Results come most populous first; a large city beats namesake towns
+	if len(qualifiers) > 0 || matches[0].Population >= 5*matches[1].Population {
+		return matches[0].place(), nil
+	}
+	candidates := make([]string, 0, 5)
+	for i, match := range matches {
+		if i == 5 {
+			break
+		}
+		candidates = append(candidates, match.place().String())
+	}
+	return WeatherPlace{}, &AmbiguousLocationError{Query: location, Candidates: candidates}
+}
+
+// matchesQualifiers reports whether every qualifier starts the match's
+// region, country or country code
+func matchesQualifiers(match geocodeResult, qualifiers []string) bool {
+	for _, qualifier := range qualifiers {
+		found := false
+		for _, field := range []string{match.Admin1, match.Country, match.CountryCode} {
+			if field != "" && strings.HasPrefix(strings.ToLower(field), qualifier) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// getJSON fetches endpoint with params and decodes the JSON response
+func (p *OpenMeteoProvider) getJSON(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "EzraBot/1.0")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// weatherCodeDescription describes a WMO weather interpretation code
+func weatherCodeDescription(code int) string {
+	switch code {
+	case 0:
+		return "Clear sky"
+	case 1:
+		return "Mainly clear"
+	case 2:
+		return "Partly cloudy"
+	case 3:
+		return "Overcast"
+	case 45, 48:
+		return "Fog"
+	case 51, 53, 55:
+		return "Drizzle"
+	case 56, 57:
+		return "Freezing drizzle"
+	case 61, 63, 65:
+		return "Rain"
+	case 66, 67:
+		return "Freezing rain"
+	case 71, 73, 75, 77:
+		return "Snow"
+	case 80, 81, 82:
+		return "Rain showers"
+	case 85, 86:
+		return "Snow showers"
+	case 95:
+		return "Thunderstorm"
+	case 96, 99:
+		return "Thunderstorm with hail"
+	default:
+		return "Unknown"
+	}
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// ============================================================================
+// Weather Tool Implementation
+// ============================================================================
+
+// Forecast length limits for get_weather
+const (
+	defaultWeatherDays = 3
+	maxWeatherDays     = 7
+)
+
+func (e *Executor) executeGetWeather(ctx context.Context, execCtx *ExecutionContext, args map[string]interface{}) *ToolResult {
+	location, _ := args["location"].(string)
+	remember, _ := args["remember_location"].(bool)
+
+	days := defaultWeatherDays
+	if arg, ok := args["days"]; ok {
+		n, err := integerArg("days", arg)
+		if err != nil {
+			return &ToolResult{Success: false, Error: err.Error()}
+		}
+		if n < 1 || n > maxWeatherDays {
+			return &ToolResult{Success: false, Error: fmt.Sprintf("days must be between 1 and %d", maxWeatherDays)}
+		}
+		days = n
+	}
+
+	units := WeatherUnitsMetric
+	if u, _ := args["units"].(string); u != "" {
+		units = WeatherUnits(u)
+		if units != WeatherUnitsMetric && units != WeatherUnitsImperial {
+			return &ToolResult{Success: false, Error: "units must be metric or imperial"}
+		}
+	}
+
+	// Fall back to where the user said they are
+	storedLocation := false
+	if location == "" && e.repo != nil {
+		stored, err := e.repo.GetUserLocation(ctx, execCtx.UserID)
+		if err != nil {
+			e.logger.Warn("Failed to load user location", zap.String("user_id", execCtx.UserID), zap.Error(err))
+		}
+		location, storedLocation = stored, stored != ""
+	}
+	if location == "" {
+		return &ToolResult{Success: false, Error: "No location given and none stored for this user; ask them where they are"}
+	}
+
+	report, err := e.weather.Weather(ctx, location, days, units)
+	var ambiguous *AmbiguousLocationError
+	switch {
+	case errors.As(err, &ambiguous):
+		return &ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("%s. Ask the user which one they meant.", ambiguous.Error()),
+			Data:    map[string]interface{}{"candidates": ambiguous.Candidates},
+		}
+	case errors.Is(err, ErrLocationNotFound):
+		return &ToolResult{Success: false, Error: fmt.Sprintf("Couldn't find a place called %q", location)}
+	case err != nil:
+		return &ToolResult{Success: false, Error: fmt.Sprintf("Weather lookup failed: %v", err)}
+	}
+
+	if remember && !storedLocation && e.repo != nil {
+		if err := e.repo.SetUserLocation(ctx, execCtx.UserID, report.Location.String()); err != nil {
+			e.logger.Warn("Failed to store user location", zap.String("user_id", execCtx.UserID), zap.Error(err))
+		}
+	}
+
+	return &ToolResult{
+		Success: true,
+		Data:    report,
+		Message: fmt.Sprintf("%s in %s, %.0f°", report.Current.Conditions, report.Location, report.Current.Temperature),
+	}
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// newRecordedOpenMeteo serves recorded Open-Meteo responses: geocoding
+// results by the searched name, and the Berlin forecast
+func newRecordedOpenMeteo(t *testing.T) (*OpenMeteoProvider, *[]string) {
+	t.Helper()
+	var forecastQueries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var file string
+		switch r.URL.Path {
+		case "/geocode":
+			switch r.URL.Query().Get("name") {
+			case "Berlin":
+				file = "testdata/open_meteo_geocode_berlin.json"
+			case "Springfield":
+				file = "testdata/open_meteo_geocode_springfield.json"
+			default:
+				w.Write([]byte(`{"generationtime_ms":0.5}`))
+				return
+			}
+		case "/forecast":
+			forecastQueries = append(forecastQueries, r.URL.RawQuery)
+			file = "testdata/open_meteo_forecast_berlin.json"
+		}
+		body, err := os.ReadFile(file)
+		if err != nil {
+			t.Errorf("Failed to read recording: %v", err)
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	provider := NewOpenMeteoProvider(server.Client())
+	provider.geocodeURL = server.URL + "/geocode"
+	provider.forecastURL = server.URL + "/forecast"
+	return provider, &forecastQueries
+}
+
+func TestOpenMeteoProvider_RecordedForecast(t *testing.T) {
+	provider, queries := newRecordedOpenMeteo(t)
+
+	report, err := provider.Weather(context.Background(), "Berlin", 3, WeatherUnitsMetric)
+	if err != nil {
+		t.Fatalf("Weather failed: %v", err)
+	}
+
+	// The capital dwarfs its namesake in New Jersey
+	if report.Location.String() != "Berlin, Land Berlin, Germany" {
+		t.Errorf("Expected Berlin, Germany, got %q", report.Location)
+	}
+	if report.Current.Temperature != 13.4 || report.Current.Conditions != "Overcast" {
+		t.Errorf("Unexpected current weather %+v", report.Current)
+	}
+	wantForecast := []DailyForecast{
+		{Date: "2026-10-16", Conditions: "Overcast", High: 14.1, Low: 8.3, PrecipitationProbability: 10},
+		{Date: "2026-10-17", Conditions: "Rain", High: 12.6, Low: 9.1, PrecipitationProbability: 80},
+		{Date: "2026-10-18", Conditions: "Partly cloudy", High: 15.0, Low: 7.4, PrecipitationProbability: 5},
+	}
+	if !reflect.DeepEqual(report.Forecast, wantForecast) {
+		t.Errorf("Expected forecast %+v, got %+v", wantForecast, report.Forecast)
+	}
+	if len(*queries) != 1 {
+		t.Fatalf("Expected one forecast request, got %d", len(*queries))
+	}
+
+	// A qualifier picks the namesake
+	report, err = provider.Weather(context.Background(), "Berlin, New Jersey", 3, WeatherUnitsImperial)
+	if err != nil {
+		t.Fatalf("Weather failed: %v", err)
+	}
+	if report.Location.Country != "United States" {
+		t.Errorf("Expected Berlin, New Jersey, got %q", report.Location)
+	}
+}
+
+func TestOpenMeteoProvider_RememberedLocationRoundTrip(t *testing.T) {
+	provider, _ := newRecordedOpenMeteo(t)
+
+	for _, location := range []string{"Berlin", "Berlin, New Jersey", "Springfield, Illinois"} {
+		report, err := provider.Weather(context.Background(), location, 1, WeatherUnitsMetric)
+		if err != nil {
+			t.Fatalf("Weather(%q) failed: %v", location, err)
+		}
+		// remember_location stores the resolved place as its String
+		remembered := report.Location.String()
+		again, err := provider.Weather(context.Background(), remembered, 1, WeatherUnitsMetric)
+		if err != nil {
+			t.Fatalf("Expected the remembered location %q to resolve, got %v", remembered, err)
+		}
+		if again.Location != report.Location {
+			t.Errorf("Expected %q to resolve to the same place, got %q", remembered, again.Location)
+		}
+	}
+
+	if _, err := provider.Weather(context.Background(), "Berlin, Land Berlin, United States", 1, WeatherUnitsMetric); !errors.Is(err, ErrLocationNotFound) {
+		t.Errorf("Expected every qualifier to have to match, got %v", err)
+	}
+}
+
+func TestOpenMeteoProvider_AmbiguousAndUnknownLocations(t *testing.T) {
+	provider, _ := newRecordedOpenMeteo(t)
+
+	_, err := provider.Weather(context.Background(), "Springfield", 3, WeatherUnitsMetric)
+	var ambiguous *AmbiguousLocationError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 3 {
+		t.Fatalf("Expected Springfield to be ambiguous between 3 places, got %v", err)
+	}
+	if ambiguous.Candidates[2] != "Springfield, Illinois, United States" {
+		t.Errorf("Unexpected candidate %q", ambiguous.Candidates[2])
+	}
+
+	if _, err := provider.Weather(context.Background(), "Springfield, Illinois", 3, WeatherUnitsMetric); err != nil {
+		t.Errorf("Expected a qualified location to resolve, got %v", err)
+	}
+	if _, err := provider.Weather(context.Background(), "Atlantis", 3, WeatherUnitsMetric); !errors.Is(err, ErrLocationNotFound) {
+		t.Errorf("Expected ErrLocationNotFound, got %v", err)
+	}
+}
+
+func TestExecuteGetWeather_RequiresLocation(t *testing.T) {
+	executor := NewExecutor(nil)
+	result := executor.executeGetWeather(context.Background(), &ExecutionContext{UserID: "user-1"}, map[string]interface{}{})
+	if result.Success {
+		t.Fatal("Expected an error without a location")
+	}
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"ezra-clone/backend/internal/adapter"
+)
+
+// GetWeatherTools returns weather lookup tools
+func GetWeatherTools() []adapter.Tool {
+	return []adapter.Tool{
+		{
+			Type: "function",
+			Function: adapter.FunctionDefinition{
+				Name:        ToolGetWeather,
+				Description: "Get the current weather and a daily forecast for a location. Omit location to use the location the user has stored; if they have none, ask where they are. If the result says the location is ambiguous, ask the user which of the listed places they meant, then call again with it (e.g. 'Springfield, Illinois').",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"location": map[string]interface{}{
+							"type":        "string",
+							"description": "City or place name, optionally narrowed with a region or country after a comma (e.g. 'Paris', 'Portland, Oregon', 'London, CA'). Defaults to the user's stored location.",
+						},
+						"days": map[string]interface{}{
+							"type":        "integer",
+							"description": "Days of forecast to include, 1-7 (default 3)",
+						},
+						"units": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{string(WeatherUnitsMetric), string(WeatherUnitsImperial)},
+							"description": "metric (°C, km/h) or imperial (°F, mph). Default metric.",
+						},
+						"remember_location": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Store location as the user's default, e.g. when they say where they live",
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -18,6 +19,23 @@ import (
 // Web Tool Implementations
 // ============================================================================
 
+// Defaults for fetch_webpage, overridable via config and per-call arguments
+const (
+	defaultWebFetchMaxBytes int64 = 500000 // 500KB is enough for most articles
+	defaultWebFetchTimeout        = 30 * time.Second
+)
+
+// defaultWebSearchURL is the primary web_search provider (free, no API key needed)
+const defaultWebSearchURL = "https://html.duckduckgo.com/html/"
+
+// Defaults for the web_search and fetch_webpage result caches
+const (
+	defaultWebCacheMaxEntries = 200
+	defaultWebCacheTTL        = 10 * time.Minute
+)
+
+// executeWebSearch serves repeated searches from the cache, so an agent
+// re-asking the same question within the TTL doesn't hit the provider again
 func (e *Executor) executeWebSearch(ctx context.Context, args map[string]interface{}) *ToolResult {
 	query, _ := args["query"].(string)
 	if query == "" {
@@ -27,17 +45,133 @@ func (e *Executor) executeWebSearch(ctx context.Context, args map[string]interfa
 	// Capture original question if provided (for better response context)
 	originalQuestion, _ := args["original_question"].(string)
 
+	key := query + "\x00" + originalQuestion
+	if cached, ok := e.searchCache.Get(key); ok {
+		e.logger.Debug("Web search served from cache", zap.String("query", query))
+		return cloneToolResult(cached)
+	}
+	result := e.searchWeb(ctx, query, originalQuestion)
+	if result.Success {
+		e.searchCache.Put(key, cloneToolResult(result))
+	}
+	return result
+}
+
+// cloneToolResult deep-copies a cached web result, so a caller changing the
+// result it got can't change what later callers are served
+func cloneToolResult(result *ToolResult) *ToolResult {
+	clone := *result
+	clone.Data = cloneResultValue(result.Data)
+	return &clone
+}
+
+// cloneResultValue deep-copies the values web results carry in their Data
+func cloneResultValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		clone := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			clone[key] = cloneResultValue(item)
+		}
+		return clone
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, item := range v {
+			clone[i] = cloneResultValue(item)
+		}
+		return clone
+	case map[string]string:
+		clone := make(map[string]string, len(v))
+		for key, item := range v {
+			clone[key] = item
+		}
+		return clone
+	case []string:
+		return append([]string(nil), v...)
+	case []SearchResult:
+		return append([]SearchResult(nil), v...)
+	case []ContentSection:
+		clone := make([]ContentSection, len(v))
+		for i, section := range v {
+			clone[i] = section
+			clone[i].Content = append([]string(nil), section.Content...)
+		}
+		return clone
+	case *LinkPreview:
+		if v == nil {
+			return v
+		}
+		clone := *v
+		return &clone
+	default:
+		return value
+	}
+}
+
+// searchWeb runs a web search against the configured providers
+func (e *Executor) searchWeb(ctx context.Context, query, originalQuestion string) *ToolResult {
+
 	e.logger.Debug("Web search",
 		zap.String("optimized_query", query),
 		zap.String("original_question", originalQuestion),
 	)
 
-	// Use DuckDuckGo HTML search (free, no API key needed)
-	searchURL := fmt.Sprintf("https://html.duckduckgo.com/html/?q=%s", url.QueryEscape(query))
+	// Try the query as given, then relaxed forms of it, against each provider
+	// in turn so an over-specific query doesn't leave the agent with nothing
+	queries := []string{query}
+	if e.webSearchRelax {
+		queries = append(queries, relaxSearchQuery(query)...)
+	}
+
+	var lastErr error
+	searched := false
+	for _, attempt := range queries {
+		for _, searchURL := range e.webSearchURLs {
+			results, err := e.searchOnce(ctx, searchURL, attempt)
+			if err != nil {
+				lastErr = err
+				e.logger.Debug("Web search attempt failed",
+					zap.String("query", attempt),
+					zap.String("provider", searchURL),
+					zap.Error(err),
+				)
+				continue
+			}
+			searched = true
+			if len(results) == 0 {
+				continue
+			}
+
+			data := map[string]interface{}{"results": results, "query": attempt, "original_question": originalQuestion}
+			message := fmt.Sprintf("Found %d results for: %s", len(results), attempt)
+			if attempt != query {
+				data["requested_query"] = query
+				message = fmt.Sprintf("Found %d results for: %s (no results for: %s)", len(results), attempt, query)
+			}
+			return &ToolResult{Success: true, Data: data, Message: message}
+		}
+	}
+
+	if !searched && lastErr != nil {
+		return &ToolResult{Success: false, Error: fmt.Sprintf("Search failed: %v", lastErr)}
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	return &ToolResult{
+		Success: true,
+		Data:    map[string]interface{}{"results": []string{}, "query": query, "original_question": originalQuestion},
+		Message: fmt.Sprintf("No results found for: %s", query),
+	}
+}
+
+// searchOnce runs a single query against a DuckDuckGo-style HTML search endpoint
+func (e *Executor) searchOnce(ctx context.Context, searchURL, query string) ([]SearchResult, error) {
+	separator := "?"
+	if strings.Contains(searchURL, "?") {
+		separator = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL+separator+"q="+url.QueryEscape(query), nil)
 	if err != nil {
-		return &ToolResult{Success: false, Error: fmt.Sprintf("Failed to create request: %v", err)}
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers to look like a browser
@@ -46,33 +180,85 @@ func (e *Executor) executeWebSearch(ctx context.Context, args map[string]interfa
 
 	resp, err := e.httpClient.Do(req)
 	if err != nil {
-		return &ToolResult{Success: false, Error: fmt.Sprintf("Search failed: %v", err)}
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search returned HTTP %d", resp.StatusCode)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return &ToolResult{Success: false, Error: "Failed to read response"}
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	html := string(body)
-
 	// Parse search results from HTML
-	results := parseSearchResults(html)
+	return parseSearchResults(string(body)), nil
+}
 
-	if len(results) == 0 {
-		return &ToolResult{
-			Success: true,
-			Data:    map[string]interface{}{"results": []string{}, "query": query, "original_question": originalQuestion},
-			Message: fmt.Sprintf("No results found for: %s", query),
+// searchOperatorPrefixes are search operators dropped when relaxing a query
+var searchOperatorPrefixes = []string{"site:", "intitle:", "inurl:", "intext:", "filetype:", "before:", "after:"}
+
+// searchStopWords are dropped when simplifying a relaxed query
+var searchStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "of": true, "in": true, "on": true, "for": true,
+	"to": true, "and": true, "or": true, "is": true, "are": true, "what": true, "how": true,
+	"who": true, "when": true, "where": true, "why": true, "with": true, "about": true,
+}
+
+// maxSimplifiedSearchTerms caps the words kept in the simplified query
+const maxSimplifiedSearchTerms = 4
+
+// relaxSearchQuery returns progressively looser forms of query to retry when
+// it finds nothing: first without quotes and operators, then just its first
+// few keywords. Forms identical to an earlier one are skipped.
+func relaxSearchQuery(query string) []string {
+	var terms []string
+	for _, word := range strings.Fields(strings.NewReplacer(`"`, " ", "“", " ", "”", " ").Replace(query)) {
+		upper := strings.ToUpper(word)
+		if upper == "OR" || upper == "AND" || upper == "NOT" || word == "|" {
+			continue
+		}
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+		operator := false
+		for _, prefix := range searchOperatorPrefixes {
+			if strings.HasPrefix(strings.ToLower(word), prefix) {
+				operator = true
+				break
+			}
+		}
+		if operator {
+			continue
+		}
+		if word = strings.Trim(word, "+()"); word != "" {
+			terms = append(terms, word)
 		}
 	}
 
-	return &ToolResult{
-		Success: true,
-		Data:    map[string]interface{}{"results": results, "query": query, "original_question": originalQuestion},
-		Message: fmt.Sprintf("Found %d results for: %s", len(results), query),
+	var keywords []string
+	for _, term := range terms {
+		if searchStopWords[strings.ToLower(strings.Trim(term, "?!.,"))] {
+			continue
+		}
+		keywords = append(keywords, strings.Trim(term, "?!.,"))
+		if len(keywords) == maxSimplifiedSearchTerms {
+			break
+		}
 	}
+
+	seen := map[string]bool{strings.Join(strings.Fields(query), " "): true}
+	var relaxed []string
+	for _, candidate := range []string{strings.Join(terms, " "), strings.Join(keywords, " ")} {
+		if candidate == "" || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		relaxed = append(relaxed, candidate)
+	}
+	return relaxed
 }
 
 // SearchResult represents a single search result
@@ -165,6 +351,8 @@ func parseSearchResults(html string) []SearchResult {
 	return results
 }
 
+// executeFetchWebpage serves repeated fetches of a page from the cache.
+// Only successful fetches are cached, so a flaky site is retried next time.
 func (e *Executor) executeFetchWebpage(ctx context.Context, args map[string]interface{}) *ToolResult {
 	urlStr, _ := args["url"].(string)
 	if urlStr == "" {
@@ -176,6 +364,38 @@ func (e *Executor) executeFetchWebpage(ctx context.Context, args map[string]inte
 		urlStr = "https://" + urlStr
 	}
 
+	// The configured limits are the most a call may ask for; 0 or less uses them
+	maxBytes := e.webFetchMaxBytes
+	if mb, ok := args["max_bytes"].(float64); ok && mb > 0 && mb < float64(maxBytes) {
+		maxBytes = int64(mb)
+	}
+	timeout := e.webFetchTimeout
+	if ts, ok := args["timeout_seconds"].(float64); ok && ts > 0 && ts < timeout.Seconds() {
+		timeout = time.Duration(ts * float64(time.Second))
+	}
+
+	key := fmt.Sprintf("%s\x00%d", urlStr, maxBytes)
+	if cached, ok := e.fetchCache.Get(key); ok {
+		e.logger.Debug("Webpage served from cache", zap.String("url", urlStr))
+		return cloneToolResult(cached)
+	}
+	result := e.fetchWebpage(ctx, urlStr, maxBytes, timeout)
+	if result.Success {
+		e.fetchCache.Put(key, cloneToolResult(result))
+	}
+	return result
+}
+
+// fetchWebpage downloads a page and extracts its readable text
+func (e *Executor) fetchWebpage(ctx context.Context, urlStr string, maxBytes int64, timeout time.Duration) *ToolResult {
+
+	// The deadline covers redirects and the body read, so a hung server can't block the turn.
+	// The shared client's own timeout is dropped so a longer per-call timeout is honored.
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	client := *e.httpClient
+	client.Timeout = 0
+
 	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
 		return &ToolResult{Success: false, Error: fmt.Sprintf("Invalid URL: %v", err)}
@@ -190,8 +410,11 @@ func (e *Executor) executeFetchWebpage(ctx context.Context, args map[string]inte
 	req.Header.Set("Connection", "keep-alive")
 	req.Header.Set("Upgrade-Insecure-Requests", "1")
 
-	resp, err := e.httpClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return &ToolResult{Success: false, Error: fmt.Sprintf("Timed out after %s fetching %s", timeout, urlStr)}
+		}
 		return &ToolResult{Success: false, Error: fmt.Sprintf("Failed to fetch: %v", err)}
 	}
 	defer resp.Body.Close()
@@ -228,7 +451,7 @@ func (e *Executor) executeFetchWebpage(ctx context.Context, args map[string]inte
 		req.Header.Set("Connection", "keep-alive")
 		req.Header.Set("Upgrade-Insecure-Requests", "1")
 		
-		resp, err = e.httpClient.Do(req)
+		resp, err = client.Do(req)
 		if err != nil {
 			return &ToolResult{Success: false, Error: fmt.Sprintf("Failed to follow redirect: %v", err)}
 		}
@@ -276,11 +499,22 @@ func (e *Executor) executeFetchWebpage(ctx context.Context, args map[string]inte
 		e.logger.Debug("Brotli compression detected but not supported, attempting to read anyway", zap.String("url", urlStr))
 	}
 
-	// Read content with larger limit for articles (500KB instead of 50KB)
-	body, err := io.ReadAll(io.LimitReader(reader, 500000)) // 500KB limit for articles
+	// Read one byte past the cap so we can tell a full page from a cut-off one
+	body, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
 	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return &ToolResult{Success: false, Error: fmt.Sprintf("Timed out after %s reading %s", timeout, urlStr)}
+		}
 		return &ToolResult{Success: false, Error: fmt.Sprintf("Failed to read content: %v", err)}
 	}
+	truncated := int64(len(body)) > maxBytes
+	if truncated {
+		body = body[:maxBytes]
+		e.logger.Debug("Webpage exceeded max fetch size, truncating",
+			zap.String("url", urlStr),
+			zap.Int64("max_bytes", maxBytes),
+		)
+	}
 
 	if len(body) == 0 {
 		return &ToolResult{Success: false, Error: "Empty response from server"}
@@ -292,9 +526,13 @@ func (e *Executor) executeFetchWebpage(ctx context.Context, args map[string]inte
 		// It's gzip but wasn't detected in Content-Encoding, try to decompress
 		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
 		if err == nil {
-			decompressed, err := io.ReadAll(gzipReader)
+			decompressed, err := io.ReadAll(io.LimitReader(gzipReader, maxBytes+1))
 			gzipReader.Close()
 			if err == nil && len(decompressed) > 0 {
+				if int64(len(decompressed)) > maxBytes {
+					decompressed = decompressed[:maxBytes]
+					truncated = true
+				}
 				body = decompressed
 				e.logger.Debug("Auto-detected and decompressed gzip content", zap.String("url", urlStr))
 			}
@@ -305,8 +543,10 @@ func (e *Executor) executeFetchWebpage(ctx context.Context, args map[string]inte
 	htmlContent := string(body)
 	originalLength := len(htmlContent)
 	
-	// Use structured extraction (max 50,000 chars for full text)
-	structuredContent := extractStructuredContent(htmlContent, 50000)
+	// Use structured extraction (max 50,000 chars for full text), retrying
+	// with the readability strategy when the result looks like boilerplate
+	structuredContent, quality := chooseExtraction(htmlContent, 50000)
+	resolveMetadataURLs(structuredContent.Metadata, urlStr)
 	
 	// Log extraction stats for debugging
 	e.logger.Debug("Structured HTML extraction",
@@ -315,6 +555,10 @@ func (e *Executor) executeFetchWebpage(ctx context.Context, args map[string]inte
 		zap.Int("extracted_chars", structuredContent.TextLength),
 		zap.Int("num_sections", len(structuredContent.Sections)),
 		zap.String("title", structuredContent.Title),
+		zap.String("strategy", structuredContent.Metadata["extraction_strategy"]),
+		zap.Float64("quality", quality.Score),
+		zap.Float64("link_density", quality.LinkDensity),
+		zap.Int("boilerplate_hits", quality.BoilerplateHits),
 	)
 
 	// Validate extraction - check if extraction is too small relative to original HTML
@@ -383,6 +627,11 @@ func (e *Executor) executeFetchWebpage(ctx context.Context, args map[string]inte
 				"text_length": len(formattedContent),
 				"num_sections": 0,
 				"fallback_used": true,
+				"truncated":   truncated,
+				"metadata": map[string]string{
+					"extraction_strategy": ExtractionSimple,
+					"source_url":          urlStr,
+				},
 			},
 			Message: fmt.Sprintf("Extracted %d characters using fallback extraction from %s", len(formattedContent), urlStr),
 		}
@@ -398,6 +647,12 @@ func (e *Executor) executeFetchWebpage(ctx context.Context, args map[string]inte
 		"metadata":    structuredContent.Metadata,
 		"text_length": structuredContent.TextLength,
 		"num_sections": len(structuredContent.Sections),
+		"truncated":   truncated,
+	}
+
+	// OpenGraph/Twitter-card details for building a rich link embed
+	if preview := NewLinkPreview(structuredContent.Metadata); preview != nil {
+		responseData["preview"] = preview
 	}
 
 	// Add source URL to metadata
@@ -411,6 +666,10 @@ func (e *Executor) executeFetchWebpage(ctx context.Context, args map[string]inte
 		len(structuredContent.Sections), 
 		urlStr)
 	
+	if truncated {
+		message += fmt.Sprintf(". Page exceeded %d bytes and was truncated, so content is incomplete", maxBytes)
+	}
+
 	// If content is long, suggest using summarize_website for better summarization
 	if structuredContent.TextLength > 8000 {
 		message += fmt.Sprintf(". Note: For AI-powered summarization of this long article (%d chars), consider using summarize_website tool.", structuredContent.TextLength)
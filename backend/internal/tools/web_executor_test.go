@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteWebSearch_RelaxesQueryWithNoResults(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("q")
+		queries = append(queries, query)
+		if query != "golang generics tutorial" {
+			fmt.Fprint(w, `<html><body>No results.</body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<div class="result"><a class="result__a" href="https://go.dev/doc/tutorial/generics">Tutorial: Getting started with generics</a>`+
+			`<a class="result__snippet" href="#">An introduction to generics in Go.</a></div>`)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(nil)
+	executor.webSearchURLs = []string{server.URL}
+
+	result := executor.executeWebSearch(context.Background(), map[string]interface{}{
+		"query": `"golang generics tutorial" site:example.com`,
+	})
+	if !result.Success {
+		t.Fatalf("Expected success, got error %q", result.Error)
+	}
+
+	wantQueries := []string{`"golang generics tutorial" site:example.com`, "golang generics tutorial"}
+	if !reflect.DeepEqual(queries, wantQueries) {
+		t.Errorf("Expected queries %q, got %q", wantQueries, queries)
+	}
+
+	data := result.Data.(map[string]interface{})
+	results, ok := data["results"].([]SearchResult)
+	if !ok || len(results) != 1 {
+		t.Fatalf("Expected 1 result from the relaxed query, got %v", data["results"])
+	}
+	if results[0].URL != "https://go.dev/doc/tutorial/generics" {
+		t.Errorf("Unexpected result URL %q", results[0].URL)
+	}
+	if data["query"] != "golang generics tutorial" {
+		t.Errorf("Expected the relaxed query to be reported, got %v", data["query"])
+	}
+}
+
+func TestRelaxSearchQuery(t *testing.T) {
+	got := relaxSearchQuery(`what is the "best pizza" in new york -chain site:reddit.com`)
+	want := []string{"what is the best pizza in new york", "best pizza new york"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if got := relaxSearchQuery("pizza"); len(got) != 0 {
+		t.Errorf("Expected nothing to relax in a plain query, got %q", got)
+	}
+}
+
+func TestExecuteFetchWebpage_ClearingCacheForcesRefetch(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `<html><body><p>Cached article body.</p></body></html>`)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(nil)
+	fetch := func() {
+		t.Helper()
+		result := executor.executeFetchWebpage(context.Background(), map[string]interface{}{"url": server.URL})
+		if !result.Success {
+			t.Fatalf("Expected success, got error %q", result.Error)
+		}
+	}
+
+	fetch()
+	fetch()
+	if requests != 1 {
+		t.Fatalf("Expected the second fetch to be served from cache, got %d requests", requests)
+	}
+	if stats := executor.Caches()["web_fetch"].Stats(); stats.Entries != 1 || stats.Hits != 1 {
+		t.Errorf("Expected 1 entry and 1 hit, got %+v", stats)
+	}
+
+	if err := executor.Caches()["web_fetch"].Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	fetch()
+	if requests != 2 {
+		t.Errorf("Expected a refetch after clearing the cache, got %d requests", requests)
+	}
+}
+
+func TestExecuteFetchWebpage_CachedResultsAreCopies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>Article</title></head><body><article><h1>Heading</h1><p>Cached article body.</p></article></body></html>`)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(nil)
+	fetch := func() map[string]interface{} {
+		t.Helper()
+		result := executor.executeFetchWebpage(context.Background(), map[string]interface{}{"url": server.URL})
+		if !result.Success {
+			t.Fatalf("Expected success, got error %q", result.Error)
+		}
+		return result.Data.(map[string]interface{})
+	}
+
+	// Change everything the first two callers got, down to nested values
+	for i := 0; i < 2; i++ {
+		data := fetch()
+		data["title"] = "changed"
+		data["metadata"].(map[string]string)["source_url"] = "changed"
+		if sections, ok := data["sections"].([]ContentSection); ok && len(sections) > 0 && len(sections[0].Content) > 0 {
+			sections[0].Content[0] = "changed"
+		}
+	}
+
+	data := fetch()
+	if data["title"] == "changed" || data["metadata"].(map[string]string)["source_url"] == "changed" {
+		t.Errorf("Expected the cached result to be unaffected, got %v", data)
+	}
+	if sections, ok := data["sections"].([]ContentSection); ok && len(sections) > 0 && len(sections[0].Content) > 0 && sections[0].Content[0] == "changed" {
+		t.Errorf("Expected the cached sections to be unaffected, got %v", sections)
+	}
+}
+
+func TestExecuteFetchWebpage_ClampsRequestedLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>`+strings.Repeat("Long article body. ", 100)+`</p></body></html>`)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(nil)
+	executor.SetWebFetchLimits(200, 5*time.Second)
+
+	result := executor.executeFetchWebpage(context.Background(), map[string]interface{}{
+		"url":             server.URL,
+		"max_bytes":       float64(10 << 20),
+		"timeout_seconds": float64(3600),
+	})
+	if !result.Success {
+		t.Fatalf("Expected success, got error %q", result.Error)
+	}
+	if truncated, _ := result.Data.(map[string]interface{})["truncated"].(bool); !truncated {
+		t.Error("Expected a max_bytes above the configured limit to be clamped to it")
+	}
+}
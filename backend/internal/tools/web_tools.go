@@ -44,6 +44,14 @@ func GetWebTools() []adapter.Tool {
 							"type":        "boolean",
 							"description": "Whether to extract structured text content (default: true). The tool automatically extracts structured content with headings, sections, and metadata.",
 						},
+						"max_bytes": map[string]interface{}{
+							"type":        "integer",
+							"description": "Optional lower cap on how many bytes of the page to download. Defaults to, and can't exceed, the server setting (500KB). If the page is larger, the result has truncated=true.",
+						},
+						"timeout_seconds": map[string]interface{}{
+							"type":        "integer",
+							"description": "Optional shorter request timeout in seconds. Defaults to, and can't exceed, the server setting (30s).",
+						},
 					},
 					"required": []string{"url"},
 				},
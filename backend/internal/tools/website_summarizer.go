@@ -122,7 +122,7 @@ func (e *Executor) callLLMForSummary(ctx context.Context, text string) (string,
 	systemPrompt := "Provide a concise one-paragraph summary focusing on main purpose and key offerings."
 	userPrompt := fmt.Sprintf("Summarize this website content:\n\n%s", text)
 
-	response, err := e.llmAdapter.Generate(ctx, systemPrompt, userPrompt, []adapter.Tool{})
+	response, err := e.llmAdapter.Generate(ctx, adapter.GenerateParams{Auxiliary: true}, systemPrompt, userPrompt, []adapter.Tool{})
 	if err != nil {
 		return "", fmt.Errorf("failed to generate summary: %w", err)
 	}
@@ -158,6 +158,10 @@ func (e *Executor) generateMultiStageSummary(ctx context.Context, content, title
 
 	var chunkSummaries []string
 	for i, chunk := range chunks {
+		// Failed chunks fall back to their text, but not once the call is cancelled
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
 		e.logger.Info("Summarizing chunk", zap.Int("current_chunk", i+1), zap.Int("total_chunks", len(chunks)))
 		chunkSummary, err := e.summarizeChunk(ctx, chunk, i+1, len(chunks))
 		if err != nil {
@@ -207,7 +211,7 @@ func (e *Executor) summarizeChunk(ctx context.Context, chunk string, chunkNum, t
 	systemPrompt := "Extract and summarize ONLY the most important and vital information from this content chunk. Focus on key facts, main points, significant insights, and essential details. Omit filler, repetition, and less critical information. Keep it concise but comprehensive."
 	userPrompt := fmt.Sprintf("Content chunk %d of %d:\n\n%s\n\nExtract and summarize the most important information from this chunk.", chunkNum, totalChunks, chunk)
 
-	response, err := e.llmAdapter.Generate(ctx, systemPrompt, userPrompt, []adapter.Tool{})
+	response, err := e.llmAdapter.Generate(ctx, adapter.GenerateParams{Auxiliary: true}, systemPrompt, userPrompt, []adapter.Tool{})
 	if err != nil {
 		return "", fmt.Errorf("failed to summarize chunk: %w", err)
 	}
@@ -228,7 +232,7 @@ func (e *Executor) combineChunkSummaries(ctx context.Context, chunkSummaries []s
 	
 	userPrompt := fmt.Sprintf("Title: %s\n\nSummaries from content chunks:\n\n%s\n\nCreate a comprehensive final summary that synthesizes all the important information above.", title, combinedSummaries)
 
-	response, err := e.llmAdapter.Generate(ctx, systemPrompt, userPrompt, []adapter.Tool{})
+	response, err := e.llmAdapter.Generate(ctx, adapter.GenerateParams{Auxiliary: true}, systemPrompt, userPrompt, []adapter.Tool{})
 	if err != nil {
 		return "", fmt.Errorf("failed to combine summaries: %w", err)
 	}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ArticleActionSummarize is the intent to summarize fetched articles
+const ArticleActionSummarize = "summarize"
+
+const (
+	// DefaultArticleCount is assumed when a summary of several articles is
+	// requested without saying how many
+	DefaultArticleCount = 2
+	// MaxArticleCount caps how many articles a single request can ask for
+	MaxArticleCount = 10
+)
+
+// ArticleIntent is what a message asks for in terms of articles
+type ArticleIntent struct {
+	Action string // ArticleActionSummarize, or "" when no articles are wanted
+	Count  int    // Articles to fetch before answering; 0 when Action is ""
+}
+
+// numberWords maps spelled-out counts to their values
+var numberWords = map[string]int{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	"a couple": 2, "a couple of": 2, "a few": 3,
+}
+
+const countPattern = `(\d+|one|two|three|four|five|six|seven|eight|nine|ten|a couple(?: of)?|a few)`
+
+var (
+	// A count before the noun, allowing a few words between: "top five articles",
+	// "3 most interesting results"
+	articleCountRe = regexp.MustCompile(`\b` + countPattern + `(?:\s+[a-z'-]+){0,3}?\s+(?:articles?|results?|stories|story|links?|posts?|sources?)\b`)
+	// A count after an ordering word: "the first 3", "top five"
+	orderedCountRe = regexp.MustCompile(`\b(?:first|top|latest|last)\s+` + countPattern + `\b`)
+	summarizeRe    = regexp.MustCompile(`\b(?:summari[sz]e|summary|summaries|sum up|recap|tl;?dr)\b`)
+	articleNounRe  = regexp.MustCompile(`\b(?:articles?|results?|stories|story|links?|posts?|sources?)\b`)
+	pluralNounRe   = regexp.MustCompile(`\b(?:articles|results|stories|links|posts|sources)\b`)
+)
+
+// ExtractArticleIntent works out whether a message asks for articles to be
+// summarized and how many, e.g. "summarize the top five articles" gives
+// {summarize, 5}. Without an explicit count a plural request defaults to
+// DefaultArticleCount and a singular one to 1.
+func ExtractArticleIntent(message string) ArticleIntent {
+	lower := strings.ToLower(message)
+	if !summarizeRe.MatchString(lower) {
+		return ArticleIntent{}
+	}
+
+	count := 0
+	for _, re := range []*regexp.Regexp{articleCountRe, orderedCountRe} {
+		if match := re.FindStringSubmatch(lower); match != nil {
+			count = parseCount(match[1])
+			break
+		}
+	}
+
+	if count == 0 {
+		switch {
+		case pluralNounRe.MatchString(lower),
+			strings.Contains(lower, "first"),
+			strings.Contains(lower, "most interesting"):
+			count = DefaultArticleCount
+		case articleNounRe.MatchString(lower):
+			count = 1
+		default:
+			// Summarizing something other than articles, e.g. a conversation
+			return ArticleIntent{}
+		}
+	}
+
+	if count > MaxArticleCount {
+		count = MaxArticleCount
+	}
+	return ArticleIntent{Action: ArticleActionSummarize, Count: count}
+}
+
+// parseCount converts a matched count to a number, returning 0 if it isn't one
+func parseCount(s string) int {
+	if n, ok := numberWords[s]; ok {
+		return n
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractArticleIntent(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected ArticleIntent
+	}{
+		{"spelled out count", "summarize the top five articles", ArticleIntent{ArticleActionSummarize, 5}},
+		{"digit count", "Summarize the first 2 articles please", ArticleIntent{ArticleActionSummarize, 2}},
+		{"words between count and noun", "summarize the 3 most interesting results", ArticleIntent{ArticleActionSummarize, 3}},
+		{"count after ordering word", "search AI news and summarize the first four", ArticleIntent{ArticleActionSummarize, 4}},
+		{"a couple", "give me a summary of a couple of stories about rust", ArticleIntent{ArticleActionSummarize, 2}},
+		{"plural without count", "summarize the articles you find", ArticleIntent{ArticleActionSummarize, DefaultArticleCount}},
+		{"most interesting without count", "summarize the most interesting ones", ArticleIntent{ArticleActionSummarize, DefaultArticleCount}},
+		{"singular", "summarize this article", ArticleIntent{ArticleActionSummarize, 1}},
+		{"capped", "summarize 50 articles", ArticleIntent{ArticleActionSummarize, MaxArticleCount}},
+		{"no summary requested", "find me 3 articles about go", ArticleIntent{}},
+		{"summary of something else", "summarize our conversation", ArticleIntent{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExtractArticleIntent(tt.message))
+		})
+	}
+}
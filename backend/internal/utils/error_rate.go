@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// ErrorRateTracker counts failures per key within a sliding window and
+// reports when they reach a threshold. It is safe for concurrent use.
+type ErrorRateTracker struct {
+	mu        sync.Mutex
+	threshold int
+	window    time.Duration
+	failures  map[string][]time.Time
+}
+
+// NewErrorRateTracker creates a tracker that alerts after threshold failures
+// within window. A threshold below 1 disables alerts.
+func NewErrorRateTracker(threshold int, window time.Duration) *ErrorRateTracker {
+	return &ErrorRateTracker{
+		threshold: threshold,
+		window:    window,
+		failures:  make(map[string][]time.Time),
+	}
+}
+
+// Window returns the period failures are counted over
+func (t *ErrorRateTracker) Window() time.Duration {
+	return t.window
+}
+
+// Record notes a failure for key at now. It returns the number of failures
+// in the window when that reaches the threshold, and 0 otherwise. The count
+// starts over after an alert, so one burst of errors raises one alert.
+func (t *ErrorRateTracker) Record(key string, now time.Time) int {
+	if t.threshold < 1 {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	recent := t.failures[key][:0]
+	for _, at := range t.failures[key] {
+		if now.Sub(at) < t.window {
+			recent = append(recent, at)
+		}
+	}
+	recent = append(recent, now)
+
+	if len(recent) < t.threshold {
+		t.failures[key] = recent
+		return 0
+	}
+	delete(t.failures, key)
+	return len(recent)
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestErrorRateTracker_AlertsOncePerBurst(t *testing.T) {
+	tracker := NewErrorRateTracker(3, time.Minute)
+	start := time.Now()
+
+	if got := tracker.Record("agent", start); got != 0 {
+		t.Fatalf("Expected no alert after 1 failure, got %d", got)
+	}
+	if got := tracker.Record("agent", start.Add(10*time.Second)); got != 0 {
+		t.Fatalf("Expected no alert after 2 failures, got %d", got)
+	}
+	if got := tracker.Record("agent", start.Add(20*time.Second)); got != 3 {
+		t.Fatalf("Expected an alert with 3 failures, got %d", got)
+	}
+	if got := tracker.Record("agent", start.Add(30*time.Second)); got != 0 {
+		t.Errorf("Expected the count to restart after an alert, got %d", got)
+	}
+}
+
+func TestErrorRateTracker_ForgetsOldFailures(t *testing.T) {
+	tracker := NewErrorRateTracker(2, time.Minute)
+	start := time.Now()
+
+	tracker.Record("agent", start)
+	if got := tracker.Record("agent", start.Add(2*time.Minute)); got != 0 {
+		t.Errorf("Expected failures outside the window to be ignored, got %d", got)
+	}
+	if got := tracker.Record("other", start.Add(2*time.Minute)); got != 0 {
+		t.Errorf("Expected keys to be counted separately, got %d", got)
+	}
+}
+
+func TestErrorRateTracker_Disabled(t *testing.T) {
+	tracker := NewErrorRateTracker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		if got := tracker.Record("agent", time.Now()); got != 0 {
+			t.Fatalf("Expected no alerts when disabled, got %d", got)
+		}
+	}
+}
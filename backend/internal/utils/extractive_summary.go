@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// A sentence ends at terminal punctuation followed by whitespace, so
+	// "2.0" and "example.com" stay whole
+	sentenceEndRe = regexp.MustCompile(`[.!?]+\s+|\n+`)
+	summaryWordRe = regexp.MustCompile(`[\p{L}\p{N}']+`)
+)
+
+// ExtractiveSummary shortens text to at most maxChars by keeping its most
+// representative sentences, scored by how often their words appear in the
+// whole text, in their original order. The first sentence is kept when it
+// fits, since articles usually lead with their point. Text that already fits
+// is returned unchanged.
+func ExtractiveSummary(text string, maxChars int) string {
+	text = strings.TrimSpace(text)
+	if maxChars <= 0 || len(text) <= maxChars {
+		return text
+	}
+
+	var sentences []string
+	seen := make(map[string]bool)
+	start := 0
+	for _, end := range append(sentenceEndRe.FindAllStringIndex(text, -1), []int{len(text), len(text)}) {
+		sentence := strings.TrimSpace(text[start:end[1]])
+		start = end[1]
+		// Repeated sentences such as boilerplate would crowd out the rest
+		if sentence != "" && !seen[sentence] {
+			seen[sentence] = true
+			sentences = append(sentences, sentence)
+		}
+	}
+	if len(sentences) == 0 {
+		return text[:maxChars]
+	}
+
+	// Words of four letters or more stand in for content words
+	frequency := make(map[string]int)
+	sentenceWords := make([][]string, len(sentences))
+	for i, sentence := range sentences {
+		for _, word := range summaryWordRe.FindAllString(strings.ToLower(sentence), -1) {
+			if len([]rune(word)) >= 4 {
+				sentenceWords[i] = append(sentenceWords[i], word)
+				frequency[word]++
+			}
+		}
+	}
+
+	scores := make([]float64, len(sentences))
+	for i, words := range sentenceWords {
+		if len(words) == 0 {
+			continue
+		}
+		total := 0
+		for _, word := range words {
+			total += frequency[word]
+		}
+		scores[i] = float64(total) / float64(len(words))
+	}
+
+	order := make([]int, len(sentences))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		// The lead sentence goes first, then the highest scoring
+		if order[a] == 0 || order[b] == 0 {
+			return order[a] == 0
+		}
+		return scores[order[a]] > scores[order[b]]
+	})
+
+	kept := make([]bool, len(sentences))
+	length := 0
+	for _, i := range order {
+		added := len(sentences[i])
+		if length > 0 {
+			added++ // Joining space
+		}
+		if length+added > maxChars {
+			continue
+		}
+		kept[i] = true
+		length += added
+	}
+
+	var picked []string
+	for i, sentence := range sentences {
+		if kept[i] {
+			picked = append(picked, sentence)
+		}
+	}
+	if len(picked) == 0 {
+		// Every sentence is longer than the budget
+		return strings.TrimSpace(text[:maxChars])
+	}
+	return strings.Join(picked, " ")
+}
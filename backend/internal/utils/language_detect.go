@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"ezra-clone/backend/internal/constants"
+)
+
+// LanguageMismatchThreshold is how many messages in a row have to be detected
+// in another language before a preferred language change is proposed
+const LanguageMismatchThreshold = 3
+
+// minLanguageScore is the fewest stopword hits needed to trust a detection
+const minLanguageScore = 2
+
+var (
+	codeBlockPattern = regexp.MustCompile("(?s)```.*?```|`[^`]*`")
+	nonProsePattern  = regexp.MustCompile(`https?://\S+|<[@#!&:][^>]*>|:[a-z0-9_]+:`)
+)
+
+// languageStopwords are common function words that are distinctive for each
+// Latin-script language. Words shared between languages count for both.
+var languageStopwords = map[string]map[string]bool{
+	constants.LanguageCodeEnglish:    wordSet("the and is are you to of what this that with have it my for not do can was be"),
+	constants.LanguageCodeFrench:     wordSet("le la les et est je tu vous pas une des que qui avec pour mais ça suis bonjour merci il elle"),
+	constants.LanguageCodeSpanish:    wordSet("el los las es está que por para pero una yo tú qué cómo muy hola gracias del con"),
+	constants.LanguageCodeGerman:     wordSet("der die das und ist ich nicht du ein eine mit auf wie was danke hallo sie wir"),
+	constants.LanguageCodeItalian:    wordSet("il lo gli è che non sono una per ma come ciao grazie io questo molto della"),
+	constants.LanguageCodePortuguese: wordSet("os não que uma um para com eu você obrigado olá muito está isso do da"),
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// DetectLanguage guesses the language of a message from its script and
+// common words. Code, URLs, mentions and emoji are ignored. It returns an
+// empty string when the message is too short or ambiguous to tell.
+func DetectLanguage(content string) string {
+	content = codeBlockPattern.ReplaceAllString(content, " ")
+	content = nonProsePattern.ReplaceAllString(strings.ToLower(content), " ")
+
+	if lang := detectScript(content); lang != "" {
+		return lang
+	}
+
+	scores := make(map[string]int)
+	for _, word := range strings.FieldsFunc(content, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for lang, stopwords := range languageStopwords {
+			if stopwords[word] {
+				scores[lang]++
+			}
+		}
+	}
+
+	best, bestScore, secondScore := "", 0, 0
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, secondScore = lang, score, bestScore
+		case score > secondScore:
+			secondScore = score
+		}
+	}
+	if bestScore < minLanguageScore || bestScore == secondScore {
+		return ""
+	}
+	return best
+}
+
+// detectScript identifies languages written in a non-Latin script
+func detectScript(content string) string {
+	var letters, kana, hangul, han, cyrillic int
+	for _, r := range content {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	// Japanese mixes kanji with kana, so any kana decides it
+	switch {
+	case kana > 0 && (kana+han)*2 >= letters:
+		return constants.LanguageCodeJapanese
+	case hangul*2 >= letters:
+		return constants.LanguageCodeKorean
+	case han*2 >= letters:
+		return constants.LanguageCodeChinese
+	case cyrillic*2 >= letters:
+		return constants.LanguageCodeRussian
+	}
+	return ""
+}
+
+// IsDetectableLanguage reports whether DetectLanguage can return code
+func IsDetectableLanguage(code string) bool {
+	switch code {
+	case constants.LanguageCodeJapanese, constants.LanguageCodeKorean,
+		constants.LanguageCodeChinese, constants.LanguageCodeRussian:
+		return true
+	}
+	_, ok := languageStopwords[code]
+	return ok
+}
+
+// LanguageMismatchTracker counts, per user, consecutive messages detected in
+// a language other than their preferred one. It is safe for concurrent use.
+type LanguageMismatchTracker struct {
+	mu        sync.Mutex
+	threshold int
+	streaks   map[string]languageStreak // key: user ID
+}
+
+type languageStreak struct {
+	language string
+	count    int
+}
+
+// NewLanguageMismatchTracker creates a tracker that proposes a change after
+// threshold consecutive mismatches
+func NewLanguageMismatchTracker(threshold int) *LanguageMismatchTracker {
+	if threshold < 1 {
+		threshold = LanguageMismatchThreshold
+	}
+	return &LanguageMismatchTracker{
+		threshold: threshold,
+		streaks:   make(map[string]languageStreak),
+	}
+}
+
+// Observe records the detected language of a user's message and returns the
+// language to propose as their new preference, or an empty string. An empty
+// preference means English. Undetected messages don't break a streak, and
+// preferences the detector can't recognise (e.g. Pig Latin) are never
+// challenged.
+func (t *LanguageMismatchTracker) Observe(userID, preferred, detected string) string {
+	if detected == "" {
+		return ""
+	}
+	if preferred == "" {
+		preferred = constants.LanguageCodeEnglish
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if detected == preferred || !IsDetectableLanguage(preferred) {
+		delete(t.streaks, userID)
+		return ""
+	}
+
+	streak := t.streaks[userID]
+	if streak.language != detected {
+		streak = languageStreak{language: detected}
+	}
+	streak.count++
+	if streak.count < t.threshold {
+		t.streaks[userID] = streak
+		return ""
+	}
+
+	delete(t.streaks, userID)
+	return detected
+}
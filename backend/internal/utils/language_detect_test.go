@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"english", "What do you think about this song?", "en"},
+		{"french", "Je ne sais pas, mais c'est une bonne idée pour le projet", "fr"},
+		{"spanish", "Hola, ¿qué tal? Estoy muy bien, gracias por preguntar", "es"},
+		{"german", "Ich weiß nicht, wie das funktioniert und was du meinst", "de"},
+		{"japanese", "こんにちは、元気ですか", "ja"},
+		{"korean", "안녕하세요 반갑습니다", "ko"},
+		{"chinese", "你好，今天天气很好", "zh"},
+		{"russian", "Привет, как дела?", "ru"},
+		{"too short", "ok lol", ""},
+		{"code is ignored", "```go\nfunc the() { return this and that }\n```", ""},
+		{"urls and mentions are ignored", "<@123456> https://example.com/the/and/is", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectLanguage(tt.content))
+		})
+	}
+}
+
+func TestLanguageMismatchTracker(t *testing.T) {
+	tracker := NewLanguageMismatchTracker(3)
+
+	assert.Empty(t, tracker.Observe("u1", "en", "fr"))
+	assert.Empty(t, tracker.Observe("u1", "en", ""), "undetected messages don't break the streak")
+	assert.Empty(t, tracker.Observe("u1", "en", "fr"))
+	assert.Equal(t, "fr", tracker.Observe("u1", "en", "fr"))
+	assert.Empty(t, tracker.Observe("u1", "en", "fr"), "streak restarts after a proposal")
+
+	assert.Empty(t, tracker.Observe("u2", "", "es"))
+	assert.Empty(t, tracker.Observe("u2", "", "en"), "a matching message resets the streak")
+	assert.Empty(t, tracker.Observe("u2", "", "es"))
+	assert.Empty(t, tracker.Observe("u2", "", "es"))
+
+	for i := 0; i < 5; i++ {
+		assert.Empty(t, tracker.Observe("u3", "pig_latin", "en"), "undetectable preferences are never challenged")
+	}
+}
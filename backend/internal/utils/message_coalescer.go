@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// MessageCoalescer batches messages per key so that work on them runs at
+// most once per cooldown. The first message for a key opens a window; any
+// messages added before it closes are joined with newlines and flushed
+// together. It is safe for concurrent use.
+type MessageCoalescer struct {
+	mu       sync.Mutex
+	cooldown time.Duration
+	pending  map[string]*pendingMessages
+}
+
+type pendingMessages struct {
+	parts []string
+	flush func(text string)
+}
+
+// NewMessageCoalescer creates a coalescer with the given cooldown. A zero
+// cooldown flushes every message on its own.
+func NewMessageCoalescer(cooldown time.Duration) *MessageCoalescer {
+	return &MessageCoalescer{
+		cooldown: cooldown,
+		pending:  make(map[string]*pendingMessages),
+	}
+}
+
+// SetCooldown changes the window for batches opened after the call.
+// Negative values are ignored.
+func (c *MessageCoalescer) SetCooldown(cooldown time.Duration) {
+	if cooldown < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.cooldown = cooldown
+	c.mu.Unlock()
+}
+
+// Add queues text under key. If no batch is open for key, one is opened and
+// flush is called with the joined text once the cooldown ends; otherwise the
+// text joins the open batch and flush is ignored. flush runs on its own
+// goroutine.
+func (c *MessageCoalescer) Add(key, text string, flush func(text string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if batch, ok := c.pending[key]; ok {
+		batch.parts = append(batch.parts, text)
+		return
+	}
+
+	if c.cooldown <= 0 {
+		go flush(text)
+		return
+	}
+
+	c.pending[key] = &pendingMessages{parts: []string{text}, flush: flush}
+	time.AfterFunc(c.cooldown, func() { c.flush(key) })
+}
+
+func (c *MessageCoalescer) flush(key string) {
+	c.mu.Lock()
+	batch, ok := c.pending[key]
+	delete(c.pending, key)
+	c.mu.Unlock()
+
+	if ok {
+		batch.flush(strings.Join(batch.parts, "\n"))
+	}
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageCoalescer_CoalescesRapidMessages(t *testing.T) {
+	coalescer := NewMessageCoalescer(50 * time.Millisecond)
+	flushed := make(chan string, 10)
+	flush := func(text string) { flushed <- text }
+
+	coalescer.Add("agent/user", "I moved", flush)
+	coalescer.Add("agent/user", "to Lyon", flush)
+	coalescer.Add("agent/user", "last week", flush)
+
+	select {
+	case text := <-flushed:
+		assert.Equal(t, "I moved\nto Lyon\nlast week", text)
+	case <-time.After(time.Second):
+		t.Fatal("Expected a coalesced flush")
+	}
+
+	select {
+	case text := <-flushed:
+		t.Fatalf("Expected a single evaluation, got another: %q", text)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMessageCoalescer_KeysAreIndependent(t *testing.T) {
+	coalescer := NewMessageCoalescer(20 * time.Millisecond)
+	flushed := make(chan string, 10)
+	flush := func(text string) { flushed <- text }
+
+	coalescer.Add("a", "from a", flush)
+	coalescer.Add("b", "from b", flush)
+
+	got := []string{<-flushed, <-flushed}
+	assert.ElementsMatch(t, []string{"from a", "from b"}, got)
+}
+
+func TestMessageCoalescer_ZeroCooldownFlushesImmediately(t *testing.T) {
+	coalescer := NewMessageCoalescer(0)
+	flushed := make(chan string, 10)
+	flush := func(text string) { flushed <- text }
+
+	coalescer.Add("a", "one", flush)
+	coalescer.Add("a", "two", flush)
+
+	got := []string{<-flushed, <-flushed}
+	assert.ElementsMatch(t, []string{"one", "two"}, got)
+}
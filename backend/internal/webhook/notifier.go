@@ -0,0 +1,236 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ezra-clone/backend/internal/graph"
+	"ezra-clone/backend/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// queueSize bounds the events waiting for delivery; events beyond it are dropped
+	queueSize = 256
+	// maxAttempts is how many times an event is sent before giving up
+	maxAttempts = 3
+	// defaultBackoff is the wait before the first retry; it doubles each attempt
+	defaultBackoff = time.Second
+	// requestTimeout caps a single delivery attempt
+	requestTimeout = 10 * time.Second
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the body when a secret is set
+const SignatureHeader = "X-Ezra-Signature"
+
+// EventHeader carries the event type, e.g. "fact.created"
+const EventHeader = "X-Ezra-Event"
+
+// Events raised by the agent rather than the graph layer. Memory and agent
+// lifecycle events use the graph.MemoryEventType names.
+const (
+	// EventMemoryImportant is sent when an auto-saved memory is rated highly important
+	EventMemoryImportant = "memory.important"
+	// EventErrorThreshold is sent when an agent's failed turns reach the alert threshold
+	EventErrorThreshold = "errors.threshold"
+)
+
+// Event is the JSON payload posted to the webhook
+type Event struct {
+	Type      string                 `json:"event"`
+	AgentID   string                 `json:"agent_id,omitempty"`
+	UserID    string                 `json:"user_id,omitempty"`
+	ID        string                 `json:"id"`
+	Content   string                 `json:"content,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// Notifier posts events to a webhook URL. Events are queued and delivered in
+// order by a background worker, with retries on failure, so Notify and
+// OnMemoryEvent never block the caller.
+type Notifier struct {
+	url        string
+	secret     string
+	client     *http.Client
+	backoff    time.Duration
+	logger     *zap.Logger
+	subscribed []string // Event types or "group.*" patterns to deliver; empty delivers all
+
+	events chan Event
+	done   chan struct{}
+	mu     sync.RWMutex // Held for reading while sending on events, for writing while closing it
+	closed bool
+}
+
+// NewNotifier creates a notifier for url and starts its delivery worker.
+// If secret is non-empty each request is signed with it.
+func NewNotifier(url, secret string) *Notifier {
+	return newNotifier(url, secret, defaultBackoff)
+}
+
+func newNotifier(url, secret string, backoff time.Duration) *Notifier {
+	n := &Notifier{
+		url:     url,
+		secret:  secret,
+		client:  &http.Client{Timeout: requestTimeout},
+		backoff: backoff,
+		logger:  logger.Get(),
+		events:  make(chan Event, queueSize),
+		done:    make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// SetEventFilter limits delivery to the given event types. A type may be
+// written as "fact.*" to match a whole group. An empty list delivers every
+// event. Call before events are sent.
+func (n *Notifier) SetEventFilter(types []string) {
+	n.subscribed = nil
+	for _, t := range types {
+		if t = strings.TrimSpace(t); t != "" {
+			n.subscribed = append(n.subscribed, t)
+		}
+	}
+}
+
+// Subscribed reports whether events of eventType are delivered
+func (n *Notifier) Subscribed(eventType string) bool {
+	if len(n.subscribed) == 0 {
+		return true
+	}
+	for _, pattern := range n.subscribed {
+		if pattern == eventType {
+			return true
+		}
+		if group, ok := strings.CutSuffix(pattern, ".*"); ok && strings.HasPrefix(eventType, group+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify queues an event for delivery, dropping it if nobody subscribed to
+// its type, the queue is full or the notifier is closed
+func (n *Notifier) Notify(event Event) {
+	if !n.Subscribed(event.Type) {
+		return
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return
+	}
+	select {
+	case n.events <- event:
+	default:
+		n.logger.Warn("Webhook queue full, dropping event",
+			zap.String("event", event.Type),
+			zap.String("id", event.ID),
+		)
+	}
+}
+
+// OnMemoryEvent queues a graph memory event for delivery
+func (n *Notifier) OnMemoryEvent(event graph.MemoryEvent) {
+	n.Notify(Event{
+		Type:      string(event.Type),
+		AgentID:   event.AgentID,
+		ID:        event.ID,
+		Content:   event.Content,
+		Timestamp: event.Timestamp,
+	})
+}
+
+// Close stops accepting events and waits for queued ones to be delivered.
+// Events sent after Close are dropped.
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.events)
+	}
+	n.mu.Unlock()
+	<-n.done
+}
+
+func (n *Notifier) run() {
+	defer close(n.done)
+	for event := range n.events {
+		if err := n.deliver(event); err != nil {
+			n.logger.Warn("Failed to deliver webhook",
+				zap.String("event", event.Type),
+				zap.String("id", event.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// deliver sends an event, retrying with exponential backoff on network
+// errors, 429 and 5xx responses
+func (n *Notifier) deliver(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	backoff := n.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(event.Type, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= maxAttempts {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying
+func (n *Notifier) post(eventType string, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	if n.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+}
+
+// Sign returns the signature sent in SignatureHeader for body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"ezra-clone/backend/internal/graph"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver records the events posted to it, failing the first failFirst requests
+type receiver struct {
+	mu        sync.Mutex
+	failFirst int
+	requests  int
+	events    []graph.MemoryEvent
+	headers   []http.Header
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests++
+	if rc.requests <= rc.failFirst {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	var event graph.MemoryEvent
+	_ = json.Unmarshal(body, &event)
+	rc.events = append(rc.events, event)
+	rc.headers = append(rc.headers, r.Header.Clone())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestNotifier_DeliversSignedEvent(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	notifier := newNotifier(server.URL, "s3cret", time.Millisecond)
+	notifier.OnMemoryEvent(graph.MemoryEvent{Type: graph.EventFactCreated, AgentID: "agent", ID: "fact-1", Content: "Likes jazz"})
+	notifier.Close()
+
+	require.Len(t, rc.events, 1)
+	assert.Equal(t, graph.EventFactCreated, rc.events[0].Type)
+	assert.Equal(t, "fact-1", rc.events[0].ID)
+	assert.Equal(t, "fact.created", rc.headers[0].Get(EventHeader))
+
+	body, _ := json.Marshal(graph.MemoryEvent{Type: graph.EventFactCreated, AgentID: "agent", ID: "fact-1", Content: "Likes jazz"})
+	assert.Equal(t, Sign("s3cret", body), rc.headers[0].Get(SignatureHeader))
+}
+
+func TestNotifier_RetriesServerErrors(t *testing.T) {
+	rc := &receiver{failFirst: 2}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	notifier := newNotifier(server.URL, "", time.Millisecond)
+	notifier.OnMemoryEvent(graph.MemoryEvent{Type: graph.EventFactDeleted, ID: "fact-1"})
+	notifier.Close()
+
+	assert.Equal(t, 3, rc.requests)
+	require.Len(t, rc.events, 1)
+	assert.Empty(t, rc.headers[0].Get(SignatureHeader), "unsigned without a secret")
+}
+
+func TestNotifier_EventFilter(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	notifier := newNotifier(server.URL, "", time.Millisecond)
+	notifier.SetEventFilter([]string{"archival.*", EventErrorThreshold, " "})
+	notifier.OnMemoryEvent(graph.MemoryEvent{Type: graph.EventFactCreated, ID: "fact-1"})
+	notifier.OnMemoryEvent(graph.MemoryEvent{Type: graph.EventArchivalDeleted, ID: "arch-1"})
+	notifier.Notify(Event{Type: EventMemoryImportant, ID: "fact-2"})
+	notifier.Notify(Event{Type: EventErrorThreshold, AgentID: "agent", ID: "agent", Data: map[string]interface{}{"errors": 5}})
+	notifier.Close()
+
+	require.Len(t, rc.events, 2)
+	assert.Equal(t, "arch-1", rc.events[0].ID)
+	assert.Equal(t, EventErrorThreshold, rc.headers[1].Get(EventHeader))
+	assert.False(t, notifier.Subscribed("archival"), "a group pattern doesn't match the bare group name")
+}
+
+func TestNotifier_NotifyDuringClose(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	notifier := newNotifier(server.URL, "", time.Millisecond)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				notifier.OnMemoryEvent(graph.MemoryEvent{Type: graph.EventFactCreated, ID: "fact"})
+			}
+		}()
+	}
+	notifier.Close()
+	wg.Wait()
+
+	// Sending after Close drops the event instead of panicking
+	notifier.Notify(Event{Type: EventMemoryImportant, ID: "late"})
+	notifier.Close()
+}
+
+func TestNotifier_FactCreatedOnAutoSave(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	driver, err := neo4j.NewDriverWithContext("bolt://localhost:7687", neo4j.BasicAuth("neo4j", "password", ""))
+	require.NoError(t, err)
+	defer driver.Close(ctx)
+	require.NoError(t, driver.VerifyConnectivity(ctx))
+
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	notifier := newNotifier(server.URL, "", time.Millisecond)
+
+	repo := graph.NewRepository(driver)
+	repo.SetMemoryEventListener(notifier)
+
+	agentID := "test-agent-" + time.Now().Format("20060102150405")
+	require.NoError(t, repo.CreateAgent(ctx, agentID, "Test Agent"))
+	defer func() {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+		_, _ = session.Run(ctx, "MATCH (a:Agent {id: $agent}) OPTIONAL MATCH (a)-[:KNOWS_FACT]->(f) DETACH DELETE f, a",
+			map[string]interface{}{"agent": agentID})
+	}()
+
+	// The memory evaluator auto-saves through CreateFact
+	fact, err := repo.CreateFact(ctx, agentID, "Plays the piano", "auto-extracted", "", []string{"Music"})
+	require.NoError(t, err)
+	notifier.Close()
+
+	require.Len(t, rc.events, 1)
+	assert.Equal(t, graph.EventFactCreated, rc.events[0].Type)
+	assert.Equal(t, fact.ID, rc.events[0].ID)
+	assert.Equal(t, agentID, rc.events[0].AgentID)
+}
@@ -3,6 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ezra-clone/backend/internal/constants"
 
 	"github.com/joho/godotenv"
 )
@@ -10,8 +16,9 @@ import (
 // Config holds all application configuration
 type Config struct {
 	// App
-	Port string
-	Env  string
+	Port         string
+	Env          string
+	APIAuthToken string // Bearer token for sensitive API endpoints (empty disables them)
 
 	// Neo4j
 	Neo4jURI      string
@@ -19,21 +26,141 @@ type Config struct {
 	Neo4jPassword string
 
 	// AI
+	LLMProvider      string // Provider used by agents without their own: litellm, openai, anthropic or ollama
 	LiteLLMURL      string
 	ModelID         string
 	OpenRouterAPIKey string
+	OpenAIAPIKey     string // Enables the openai provider
+	OpenAIBaseURL    string // OpenAI-compatible API for the openai provider, including the version path
+	AnthropicAPIKey  string // Enables the anthropic provider
+	AnthropicBaseURL string
+	AnthropicMaxTokens int // Reply limit sent to the Messages API when the agent sets none
+	OllamaURL        string // Ollama server for the ollama provider
+	RequireAgentModel bool // Fail turns for agents without their own model instead of using ModelID
+	VisionModels      []string // Model name patterns that accept images (empty uses the built-in list)
+	CaptionModel      string   // Vision model that describes images for text-only models (empty disables)
+	LLMDebugTrace     bool     // Log full LLM requests and responses at debug level and keep per-turn traces
+	LLMTraceMaxTurns  int      // How many turns' traces are kept in memory
+
+	// Usage accounting
+	ModelPrices map[string]ModelPrice // Price per model for usage cost estimates (unlisted models aren't priced)
+
+	// Content filtering (agents opt in via their config)
+	ContentFilterPatternsFile string // File of patterns agent output must not match, one per line
+	ContentFilterFallback     string // Message sent in place of a filtered reply
+	ModerationModel           string // Model the "moderation" filter mode checks output with
 
 	// Discord
-	DiscordBotToken string
-	MimicChannelID  string // Channel ID for mimic mode auto-posts
+	DiscordBotToken      string
+	MimicChannelID       string            // Channel ID for mimic mode auto-posts
+	MimicActiveFromHour  int               // Hour mimic mode starts posting, 0-23 in MimicTimezone
+	MimicActiveUntilHour int               // Hour mimic mode stops posting, 1-24; before MimicActiveFromHour wraps past midnight
+	MimicTimezone        string            // IANA timezone for the active hours and the daily post cap
+	MimicDailyPostCap    int               // Posts mimic mode may make per day (0 is unlimited)
+	VoiceAutoJoin        bool              // Join the author's voice channel when asked to "join voice"
+	VoiceIdleGrace       time.Duration     // How long to stay alone in a voice channel before leaving (0 disables)
+	VoiceReferenceDir    string            // Directory of per-user TTS reference clips (empty disables)
+	CommandPrefix        string            // Prefix for direct commands like "!play" (empty disables)
+	GuildCommandPrefixes map[string]string // Per-guild prefix overrides; "off" disables commands in that guild
+	PresenceSyncInterval time.Duration     // How often the bot checks for a presence set through the API (0 only applies it on connect)
+	TypingCharsPerSecond int               // Simulated typing speed for reply delays (0 replies immediately)
+	TypingJitterPercent  int               // How much each reply delay varies, in percent either way
+	TypingMaxDelay       time.Duration     // Longest a reply is delayed
+	HardDeleteMessages   bool              // Remove deleted Discord messages from the graph instead of flagging them
+	ScheduledMissed      string            // "fire" or "skip" scheduled messages that came due while the bot was down
+
+	// Memory
+	MemoryBlockMaxChars    int           // Max characters per core memory block (0 = unlimited)
+	MemoryBlockLimitPolicy string        // "reject" or "archive" when a block exceeds the limit
+	FactHalfLife           time.Duration // Half-life of fact confidence at read time (0 disables decay)
+
+	// Archival relevance
+	ArchivalAutoScore        bool          // Score archives stored without a relevance_score from their content, age and use
+	ArchivalDefaultRelevance float64       // Relevance of archives stored without one when ArchivalAutoScore is off
+	ArchivalRescoreInterval  time.Duration // How often automatic archival scores are recomputed (0 disables)
+
+	// Memory evaluation
+	MemoryEvalMinLength int           // Shortest message text evaluated for auto-saved memories
+	MemoryEvalCooldown  time.Duration // Per-user window for coalescing messages into one evaluation (0 disables)
+
+	// Personality
+	PersonalityMemoryMax int // Per-user cap on stored personality memories (0 = unlimited)
 
 	// RunPod
 	RunPodAPIKey     string
 	RunPodEndpointID string
 	ComfyUIWorkflowDir string
-	ComfyUIOutputDir   string
+	ComfyUIOutputDir   string // Generated images are kept in its "generated" subdirectory (empty disables storing them)
+
+	// Generated image retention
+	ImageCacheMaxCount int           // Most stored images (0 = unlimited)
+	ImageCacheMaxAge   time.Duration // Stored images older than this are swept (0 = keep forever)
+	ImageCacheMaxBytes int64         // Combined size of stored images (0 = unlimited)
+	ImageMaxBytes      int64         // Largest single image stored (0 = unlimited)
+	ImageMaxBatch      int           // Most variations one image generation may request
+
+	// Web tools
+	WebFetchMaxBytes      int64         // Max response body size read by fetch_webpage
+	WebFetchTimeout       time.Duration // Per-request timeout for fetch_webpage
+	WebSearchRelax        bool          // Retry web_search with relaxed queries when nothing is found
+	WebSearchFallbackURLs []string      // Extra DuckDuckGo-compatible search endpoints tried in order
+	ArticleContentChars   int           // Fetched article text injected into the answering round
+	ArticleDigestChars    int           // Extractive digest of each article injected on rounds before it (0 injects the full text)
+	WebCacheTTL           time.Duration // How long web_search and fetch_webpage results are reused (0 disables the cache)
+	WebCacheMaxEntries    int           // Most results kept in each web cache
+
+	// Embeddings
+	EmbeddingModel     string // LiteLLM embedding model for facts and archival memories (empty disables embeddings)
+	EmbeddingBatchSize int    // Nodes embedded per request when reindexing
+
+	// Prompt budget
+	PromptContextTokens      int            // Context size assumed for models without an override, 16384 by default (0 disables prompt trimming)
+	ModelContextTokens       map[string]int // Per-model context sizes, keyed by model name
+	PromptReplyReserveTokens int            // Tokens kept free for the reply and tool definitions
+
+	// Tool execution
+	ToolTimeout         time.Duration            // Timeout for tools without a built-in one
+	ToolTimeouts        map[string]time.Duration // Per-tool overrides, keyed by tool name
+	MaxToolCallsPerTurn int                      // Tool calls one turn may run across all its rounds (0 is unlimited)
+
+	// Web/API chat
+	WebChannelPattern string // Default channel for web chat; {agent_id} is replaced with the agent ID
+	DefaultAgentID    string // Agent the Discord bot runs as and API paths naming "default" resolve to
+
+	// Usage limits
+	DailyMessageQuota  int      // Messages each user may send per UTC day, across Discord and the API (0 is unlimited)
+	RateLimitPerMinute int      // Messages each user may send a minute on average, per entry point (0 is unlimited)
+	RateLimitBurst     int      // Messages a user may send at once before the per-minute rate applies
+	RateLimitExempt    []string // User IDs never rate limited, e.g. admins
+
+	// Webhooks
+	WebhookURL       string        // Receives memory, agent and error events (empty disables)
+	WebhookSecret    string        // Signs webhook requests with HMAC-SHA256 when set
+	WebhookEvents    []string      // Event types to send, e.g. "fact.*" (empty sends all)
+	ErrorAlertCount  int           // Failed turns within ErrorAlertWindow that raise errors.threshold (0 disables)
+	ErrorAlertWindow time.Duration // Window failed turns are counted over
+
+	// Tracing
+	TracingEndpoint    string // OTLP/HTTP collector URL for turn traces (empty disables tracing)
+	TracingServiceName string // service.name reported on exported spans
+
+	// Readiness
+	STTURL            string        // Speech-to-text service checked by /ready (empty skips it)
+	TTSURL            string        // Text-to-speech service checked by /ready (empty skips it)
+	ReadyTimeout      time.Duration // Per-dependency timeout for /ready checks
+	ReadyCriticalDeps []string      // Dependencies whose failure makes /ready return 503; the rest only report
 }
 
+// ReadyDependencies are the dependencies /ready can check
+var ReadyDependencies = []string{"neo4j", "litellm", "stt", "tts"}
+
+// LLMProviders are the providers LLM_PROVIDER can name
+var LLMProviders = []string{"litellm", "openai", "anthropic", "ollama"}
+
+// DefaultReadyCriticalDeps are the dependencies /ready requires when
+// READY_CRITICAL_DEPENDENCIES isn't set; voice services are optional
+var DefaultReadyCriticalDeps = []string{"neo4j", "litellm"}
+
 // Load reads configuration from environment variables
 func Load() (*Config, error) {
 	// Try to load .env file, but don't fail if it doesn't exist
@@ -42,18 +169,100 @@ func Load() (*Config, error) {
 	cfg := &Config{
 		Port:            getEnv("PORT", "8080"),
 		Env:             getEnv("ENV", "development"),
+		APIAuthToken:    getEnv("API_AUTH_TOKEN", ""),
 		Neo4jURI:        getEnv("NEO4J_URI", "bolt://localhost:7687"),
 		Neo4jUser:       getEnv("NEO4J_USER", "neo4j"),
 		Neo4jPassword:   getEnv("NEO4J_PASSWORD", "password"),
+		LLMProvider:      getEnv("LLM_PROVIDER", "litellm"),
 		LiteLLMURL:      getEnv("LITELLM_URL", "http://localhost:4000"),
 		ModelID:         getEnv("MODEL_ID", "openrouter/anthropic/claude-3.5-sonnet"),
 		OpenRouterAPIKey: getEnv("OPENROUTER_API_KEY", ""),
+		OpenAIAPIKey:     getEnv("OPENAI_API_KEY", ""),
+		OpenAIBaseURL:    getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+		AnthropicAPIKey:  getEnv("ANTHROPIC_API_KEY", ""),
+		AnthropicBaseURL: getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com"),
+		AnthropicMaxTokens: int(getEnvInt64("ANTHROPIC_MAX_TOKENS", 4096)),
+		OllamaURL:        getEnv("OLLAMA_URL", "http://localhost:11434"),
+		RequireAgentModel: getEnvBool("REQUIRE_AGENT_MODEL", false),
+		VisionModels:      getEnvList("VISION_MODELS"),
+		CaptionModel:      getEnv("CAPTION_MODEL", ""),
+		LLMDebugTrace:     getEnvBool("LLM_DEBUG_TRACE", false),
+		LLMTraceMaxTurns:  int(getEnvInt64("LLM_TRACE_MAX_TURNS", 100)),
+		ModelPrices:       getEnvPriceMap("MODEL_PRICES"),
+		ContentFilterPatternsFile: getEnv("CONTENT_FILTER_PATTERNS_FILE", ""),
+		ContentFilterFallback:     getEnv("CONTENT_FILTER_FALLBACK", "Sorry, I can't share that."),
+		ModerationModel:           getEnv("MODERATION_MODEL", "omni-moderation-latest"),
 		DiscordBotToken:  getEnv("DISCORD_BOT_TOKEN", ""),
 		MimicChannelID:   getEnv("MIMIC_CHANNEL_ID", "549646869744058378"),
+		MimicActiveFromHour:  int(getEnvInt64("MIMIC_ACTIVE_FROM_HOUR", 0)),
+		MimicActiveUntilHour: int(getEnvInt64("MIMIC_ACTIVE_UNTIL_HOUR", 24)),
+		MimicTimezone:        getEnv("MIMIC_TIMEZONE", "UTC"),
+		MimicDailyPostCap:    int(getEnvInt64("MIMIC_DAILY_POST_CAP", 0)),
+		VoiceAutoJoin:    getEnvBool("VOICE_AUTO_JOIN", false),
+		VoiceIdleGrace:   time.Duration(getEnvInt64("VOICE_IDLE_DISCONNECT_SECONDS", 120)) * time.Second,
+		VoiceReferenceDir: getEnv("VOICE_REFERENCE_DIR", ""),
+		CommandPrefix:     getEnv("DISCORD_COMMAND_PREFIX", ""),
+		GuildCommandPrefixes: getEnvMap("DISCORD_GUILD_COMMAND_PREFIXES"),
+		PresenceSyncInterval: time.Duration(getEnvInt64("DISCORD_PRESENCE_SYNC_SECONDS", 30)) * time.Second,
+		TypingCharsPerSecond: int(getEnvInt64("DISCORD_TYPING_CHARS_PER_SECOND", 0)),
+		TypingJitterPercent:  int(getEnvInt64("DISCORD_TYPING_JITTER_PERCENT", 25)),
+		TypingMaxDelay:       time.Duration(getEnvInt64("DISCORD_TYPING_MAX_DELAY_SECONDS", 8)) * time.Second,
+		HardDeleteMessages:   getEnvBool("DISCORD_HARD_DELETE_MESSAGES", false),
+		ScheduledMissed:      getEnv("SCHEDULED_MESSAGE_MISSED_POLICY", "fire"),
+		MemoryBlockMaxChars:    int(getEnvInt64("MEMORY_BLOCK_MAX_CHARS", 0)),
+		MemoryBlockLimitPolicy: getEnv("MEMORY_BLOCK_LIMIT_POLICY", "reject"),
+		FactHalfLife:           time.Duration(getEnvInt64("FACT_CONFIDENCE_HALF_LIFE_DAYS", 0)) * 24 * time.Hour,
+		ArchivalAutoScore:        getEnvBool("ARCHIVAL_AUTO_SCORE", true),
+		ArchivalDefaultRelevance: getEnvFloat64("ARCHIVAL_DEFAULT_RELEVANCE", 0.5),
+		ArchivalRescoreInterval:  time.Duration(getEnvInt64("ARCHIVAL_RESCORE_INTERVAL_MINUTES", 360)) * time.Minute,
+		MemoryEvalMinLength: int(getEnvInt64("MEMORY_EVAL_MIN_LENGTH", 10)),
+		MemoryEvalCooldown:  time.Duration(getEnvInt64("MEMORY_EVAL_COOLDOWN_SECONDS", 30)) * time.Second,
+		PersonalityMemoryMax: int(getEnvInt64("PERSONALITY_MEMORY_MAX_PER_USER", 200)),
 		RunPodAPIKey:     getEnv("RUNPOD_API_KEY", ""),
 		RunPodEndpointID: getEnv("RUNPOD_ENDPOINT_ID", ""),
 		ComfyUIWorkflowDir: getEnv("COMFYUI_WORKFLOW_DIR", ""),
 		ComfyUIOutputDir:   getEnv("COMFYUI_OUTPUT_DIR", "outputs"),
+		ImageCacheMaxCount: int(getEnvInt64("IMAGE_CACHE_MAX_COUNT", 200)),
+		ImageCacheMaxAge:   time.Duration(getEnvInt64("IMAGE_CACHE_MAX_AGE_HOURS", 168)) * time.Hour,
+		ImageCacheMaxBytes: getEnvInt64("IMAGE_CACHE_MAX_MB", 1024) * 1024 * 1024,
+		ImageMaxBytes:      getEnvInt64("IMAGE_MAX_STORED_MB", 25) * 1024 * 1024,
+		ImageMaxBatch:      int(getEnvInt64("IMAGE_MAX_BATCH", 4)),
+		WebFetchMaxBytes:   getEnvInt64("WEB_FETCH_MAX_BYTES", 500000),
+		WebFetchTimeout:    time.Duration(getEnvInt64("WEB_FETCH_TIMEOUT_SECONDS", 30)) * time.Second,
+		WebSearchRelax:     getEnvBool("WEB_SEARCH_RELAX_QUERIES", true),
+		WebSearchFallbackURLs: getEnvList("WEB_SEARCH_FALLBACK_URLS"),
+		ArticleContentChars:   int(getEnvInt64("ARTICLE_CONTENT_MAX_CHARS", 5000)),
+		ArticleDigestChars:    int(getEnvInt64("ARTICLE_DIGEST_CHARS", 600)),
+		WebCacheTTL:           time.Duration(getEnvInt64("WEB_CACHE_TTL_SECONDS", 600)) * time.Second,
+		WebCacheMaxEntries:    int(getEnvInt64("WEB_CACHE_MAX_ENTRIES", 200)),
+		EmbeddingModel:           getEnv("EMBEDDING_MODEL", ""),
+		EmbeddingBatchSize:       int(getEnvInt64("EMBEDDING_BATCH_SIZE", 64)),
+		PromptContextTokens:      int(getEnvInt64("PROMPT_CONTEXT_TOKENS", 16384)),
+		ModelContextTokens:       getEnvIntMap("MODEL_CONTEXT_TOKENS"),
+		PromptReplyReserveTokens: int(getEnvInt64("PROMPT_REPLY_RESERVE_TOKENS", 4096)),
+		ToolTimeout:        time.Duration(getEnvInt64("TOOL_TIMEOUT_SECONDS", 60)) * time.Second,
+		ToolTimeouts:       getEnvSecondsMap("TOOL_TIMEOUTS"),
+		MaxToolCallsPerTurn: int(getEnvInt64("MAX_TOOL_CALLS_PER_TURN", 10)),
+		WebChannelPattern:  getEnv("WEB_CHANNEL_PATTERN", "web-{agent_id}"),
+		DefaultAgentID:     getEnv("DEFAULT_AGENT_ID", constants.DefaultAgentID),
+		DailyMessageQuota:  int(getEnvInt64("DAILY_MESSAGE_QUOTA", 0)),
+		RateLimitPerMinute: int(getEnvInt64("RATE_LIMIT_PER_MINUTE", 0)),
+		RateLimitBurst:     int(getEnvInt64("RATE_LIMIT_BURST", 5)),
+		RateLimitExempt:    getEnvList("RATE_LIMIT_EXEMPT_USERS"),
+		WebhookURL:         getEnv("WEBHOOK_URL", getEnv("MEMORY_WEBHOOK_URL", "")),
+		WebhookSecret:      getEnv("WEBHOOK_SECRET", getEnv("MEMORY_WEBHOOK_SECRET", "")),
+		WebhookEvents:      getEnvList("WEBHOOK_EVENTS"),
+		ErrorAlertCount:    int(getEnvInt64("WEBHOOK_ERROR_THRESHOLD", 5)),
+		ErrorAlertWindow:   time.Duration(getEnvInt64("WEBHOOK_ERROR_WINDOW_SECONDS", 300)) * time.Second,
+		TracingEndpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracingServiceName: getEnv("OTEL_SERVICE_NAME", "ezra-clone"),
+		STTURL:             getEnv("STT_URL", ""),
+		TTSURL:             getEnv("TTS_URL", ""),
+		ReadyTimeout:       time.Duration(getEnvInt64("READY_TIMEOUT_SECONDS", 2)) * time.Second,
+		ReadyCriticalDeps:  getEnvList("READY_CRITICAL_DEPENDENCIES"),
+	}
+	if len(cfg.ReadyCriticalDeps) == 0 {
+		cfg.ReadyCriticalDeps = DefaultReadyCriticalDeps
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -68,18 +277,159 @@ func (c *Config) Validate() error {
 	if c.Neo4jURI == "" {
 		return fmt.Errorf("NEO4J_URI is required")
 	}
+	if c.DefaultAgentID == "" || c.DefaultAgentID == constants.DefaultAgentAlias {
+		return fmt.Errorf("DEFAULT_AGENT_ID must name an agent other than %q", constants.DefaultAgentAlias)
+	}
 	if c.Neo4jUser == "" {
 		return fmt.Errorf("NEO4J_USER is required")
 	}
 	if c.Neo4jPassword == "" {
 		return fmt.Errorf("NEO4J_PASSWORD is required")
 	}
-	if c.LiteLLMURL == "" {
+	if !slices.Contains(LLMProviders, c.LLMProvider) {
+		return fmt.Errorf("LLM_PROVIDER: unknown provider %q (use %s)", c.LLMProvider, strings.Join(LLMProviders, ", "))
+	}
+	if c.LLMProvider == "litellm" && c.LiteLLMURL == "" {
 		return fmt.Errorf("LITELLM_URL is required")
 	}
+	if c.LLMProvider == "openai" && c.OpenAIAPIKey == "" {
+		return fmt.Errorf("OPENAI_API_KEY is required when LLM_PROVIDER is openai")
+	}
+	if c.LLMProvider == "anthropic" && c.AnthropicAPIKey == "" {
+		return fmt.Errorf("ANTHROPIC_API_KEY is required when LLM_PROVIDER is anthropic")
+	}
+	if c.LLMProvider == "ollama" && c.OllamaURL == "" {
+		return fmt.Errorf("OLLAMA_URL is required when LLM_PROVIDER is ollama")
+	}
 	if c.ModelID == "" {
 		return fmt.Errorf("MODEL_ID is required")
 	}
+	if c.WebFetchMaxBytes <= 0 {
+		return fmt.Errorf("WEB_FETCH_MAX_BYTES must be positive")
+	}
+	if c.WebFetchTimeout <= 0 {
+		return fmt.Errorf("WEB_FETCH_TIMEOUT_SECONDS must be positive")
+	}
+	if c.LLMDebugTrace && c.LLMTraceMaxTurns <= 0 {
+		return fmt.Errorf("LLM_TRACE_MAX_TURNS must be positive when LLM_DEBUG_TRACE is on")
+	}
+	if c.DailyMessageQuota < 0 {
+		return fmt.Errorf("DAILY_MESSAGE_QUOTA must not be negative")
+	}
+	if c.RateLimitPerMinute < 0 {
+		return fmt.Errorf("RATE_LIMIT_PER_MINUTE must not be negative")
+	}
+	if c.RateLimitPerMinute > 0 && c.RateLimitBurst < 1 {
+		return fmt.Errorf("RATE_LIMIT_BURST must be at least 1")
+	}
+	if c.ReadyTimeout <= 0 {
+		return fmt.Errorf("READY_TIMEOUT_SECONDS must be positive")
+	}
+	for _, dep := range c.ReadyCriticalDeps {
+		known := false
+		for _, name := range ReadyDependencies {
+			known = known || dep == name
+		}
+		if !known {
+			return fmt.Errorf("READY_CRITICAL_DEPENDENCIES: unknown dependency %q (use %s)", dep, strings.Join(ReadyDependencies, ", "))
+		}
+	}
+	if c.ArticleContentChars <= 0 {
+		return fmt.Errorf("ARTICLE_CONTENT_MAX_CHARS must be positive")
+	}
+	if c.ArticleDigestChars < 0 {
+		return fmt.Errorf("ARTICLE_DIGEST_CHARS must not be negative")
+	}
+	if c.WebCacheTTL < 0 {
+		return fmt.Errorf("WEB_CACHE_TTL_SECONDS must not be negative")
+	}
+	if c.WebCacheMaxEntries < 0 {
+		return fmt.Errorf("WEB_CACHE_MAX_ENTRIES must not be negative")
+	}
+	if c.EmbeddingBatchSize <= 0 {
+		return fmt.Errorf("EMBEDDING_BATCH_SIZE must be positive")
+	}
+	if c.PromptContextTokens < 0 {
+		return fmt.Errorf("PROMPT_CONTEXT_TOKENS must not be negative")
+	}
+	if c.PromptReplyReserveTokens < 0 {
+		return fmt.Errorf("PROMPT_REPLY_RESERVE_TOKENS must not be negative")
+	}
+	if c.ToolTimeout <= 0 {
+		return fmt.Errorf("TOOL_TIMEOUT_SECONDS must be positive")
+	}
+	if c.MaxToolCallsPerTurn < 0 {
+		return fmt.Errorf("MAX_TOOL_CALLS_PER_TURN must not be negative")
+	}
+	if c.PresenceSyncInterval < 0 {
+		return fmt.Errorf("DISCORD_PRESENCE_SYNC_SECONDS must not be negative")
+	}
+	if c.TypingCharsPerSecond < 0 {
+		return fmt.Errorf("DISCORD_TYPING_CHARS_PER_SECOND must not be negative")
+	}
+	if c.MimicActiveFromHour < 0 || c.MimicActiveFromHour > 23 {
+		return fmt.Errorf("MIMIC_ACTIVE_FROM_HOUR must be between 0 and 23")
+	}
+	if c.MimicActiveUntilHour < 1 || c.MimicActiveUntilHour > 24 {
+		return fmt.Errorf("MIMIC_ACTIVE_UNTIL_HOUR must be between 1 and 24")
+	}
+	if c.MimicActiveFromHour == c.MimicActiveUntilHour {
+		return fmt.Errorf("MIMIC_ACTIVE_FROM_HOUR and MIMIC_ACTIVE_UNTIL_HOUR must differ")
+	}
+	if _, err := time.LoadLocation(c.MimicTimezone); err != nil {
+		return fmt.Errorf("MIMIC_TIMEZONE: %w", err)
+	}
+	if c.MimicDailyPostCap < 0 {
+		return fmt.Errorf("MIMIC_DAILY_POST_CAP must not be negative")
+	}
+	if c.TypingJitterPercent < 0 || c.TypingJitterPercent > 100 {
+		return fmt.Errorf("DISCORD_TYPING_JITTER_PERCENT must be between 0 and 100")
+	}
+	if c.TypingCharsPerSecond > 0 && c.TypingMaxDelay <= 0 {
+		return fmt.Errorf("DISCORD_TYPING_MAX_DELAY_SECONDS must be positive when typing delays are enabled")
+	}
+	if c.ScheduledMissed != "fire" && c.ScheduledMissed != "skip" {
+		return fmt.Errorf("SCHEDULED_MESSAGE_MISSED_POLICY must be 'fire' or 'skip'")
+	}
+	if c.VoiceIdleGrace < 0 {
+		return fmt.Errorf("VOICE_IDLE_DISCONNECT_SECONDS must not be negative")
+	}
+	if c.MemoryBlockMaxChars < 0 {
+		return fmt.Errorf("MEMORY_BLOCK_MAX_CHARS must not be negative")
+	}
+	if c.MemoryBlockLimitPolicy != "reject" && c.MemoryBlockLimitPolicy != "archive" {
+		return fmt.Errorf("MEMORY_BLOCK_LIMIT_POLICY must be 'reject' or 'archive'")
+	}
+	if c.FactHalfLife < 0 {
+		return fmt.Errorf("FACT_CONFIDENCE_HALF_LIFE_DAYS must not be negative")
+	}
+	if c.ArchivalDefaultRelevance < 0 || c.ArchivalDefaultRelevance > 1 {
+		return fmt.Errorf("ARCHIVAL_DEFAULT_RELEVANCE must be between 0 and 1")
+	}
+	if c.ArchivalRescoreInterval < 0 {
+		return fmt.Errorf("ARCHIVAL_RESCORE_INTERVAL_MINUTES must not be negative")
+	}
+	if c.MemoryEvalMinLength < 0 {
+		return fmt.Errorf("MEMORY_EVAL_MIN_LENGTH must not be negative")
+	}
+	if c.MemoryEvalCooldown < 0 {
+		return fmt.Errorf("MEMORY_EVAL_COOLDOWN_SECONDS must not be negative")
+	}
+	if c.ErrorAlertCount < 0 {
+		return fmt.Errorf("WEBHOOK_ERROR_THRESHOLD must not be negative")
+	}
+	if c.ErrorAlertWindow <= 0 {
+		return fmt.Errorf("WEBHOOK_ERROR_WINDOW_SECONDS must be positive")
+	}
+	if c.ImageMaxBatch < 1 || c.ImageMaxBatch > 10 {
+		return fmt.Errorf("IMAGE_MAX_BATCH must be between 1 and 10")
+	}
+	if c.ImageCacheMaxCount < 0 || c.ImageCacheMaxAge < 0 || c.ImageCacheMaxBytes < 0 || c.ImageMaxBytes < 0 {
+		return fmt.Errorf("IMAGE_CACHE_* and IMAGE_MAX_STORED_MB must not be negative")
+	}
+	if c.PersonalityMemoryMax < 0 {
+		return fmt.Errorf("PERSONALITY_MEMORY_MAX_PER_USER must not be negative")
+	}
 	// OpenRouter API key and Discord token are optional for development
 	return nil
 }
@@ -102,3 +452,107 @@ func getEnv(key, defaultValue string) string {
 }
 
 
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvMap parses a comma-separated list of key=value pairs, dropping
+// entries without a key
+func getEnvMap(key string) map[string]string {
+	values := make(map[string]string)
+	for _, entry := range getEnvList(key) {
+		k, v, _ := strings.Cut(entry, "=")
+		if k = strings.TrimSpace(k); k != "" {
+			values[k] = strings.TrimSpace(v)
+		}
+	}
+	return values
+}
+
+// getEnvSecondsMap parses key=seconds pairs into durations, dropping entries
+// that aren't a positive number of seconds
+func getEnvSecondsMap(key string) map[string]time.Duration {
+	durations := make(map[string]time.Duration)
+	for k, v := range getEnvMap(key) {
+		if seconds, err := strconv.ParseInt(v, 10, 64); err == nil && seconds > 0 {
+			durations[k] = time.Duration(seconds) * time.Second
+		}
+	}
+	return durations
+}
+
+// getEnvIntMap parses key=number pairs, dropping entries that aren't a
+// positive number
+func getEnvIntMap(key string) map[string]int {
+	numbers := make(map[string]int)
+	for k, v := range getEnvMap(key) {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			numbers[k] = n
+		}
+	}
+	return numbers
+}
+
+// ModelPrice is what a model costs in USD per million tokens
+type ModelPrice struct {
+	Prompt     float64
+	Completion float64
+}
+
+// Cost returns the USD cost of the given token counts
+func (p ModelPrice) Cost(promptTokens, completionTokens int64) float64 {
+	return (float64(promptTokens)*p.Prompt + float64(completionTokens)*p.Completion) / 1e6
+}
+
+// getEnvPriceMap parses model=prompt:completion pairs of USD per million
+// tokens, dropping entries that aren't two non-negative numbers
+func getEnvPriceMap(key string) map[string]ModelPrice {
+	prices := make(map[string]ModelPrice)
+	for model, v := range getEnvMap(key) {
+		promptStr, completionStr, ok := strings.Cut(v, ":")
+		if !ok {
+			continue
+		}
+		prompt, err1 := strconv.ParseFloat(strings.TrimSpace(promptStr), 64)
+		completion, err2 := strconv.ParseFloat(strings.TrimSpace(completionStr), 64)
+		if err1 == nil && err2 == nil && prompt >= 0 && completion >= 0 {
+			prices[model] = ModelPrice{Prompt: prompt, Completion: completion}
+		}
+	}
+	return prices
+}
+
+func getEnvFloat64(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
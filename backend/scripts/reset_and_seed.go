@@ -369,6 +369,13 @@ func createConstraints(ctx context.Context, driver neo4j.DriverWithContext, log
 
 		// Role constraints
 		"CREATE CONSTRAINT role_id_unique IF NOT EXISTS FOR (r:Role) REQUIRE r.id IS UNIQUE",
+
+		// Fact constraints
+		"CREATE CONSTRAINT fact_identity IF NOT EXISTS FOR (f:Fact) REQUIRE (f.agent_id, f.user_id, f.content_hash) IS UNIQUE",
+
+		// Message constraints
+		"CREATE CONSTRAINT message_id IF NOT EXISTS FOR (m:Message) REQUIRE m.id IS UNIQUE",
+		"CREATE CONSTRAINT interaction_id IF NOT EXISTS FOR (i:Interaction) REQUIRE i.id IS UNIQUE",
 	}
 
 	for _, constraint := range constraints {
@@ -32,19 +32,36 @@ const endpoints: Endpoint[] = [
   {
     method: 'GET',
     path: '/api/agents',
-    description: 'List all available agents',
-    response: [
-      {
-        id: 'string',
-        name: 'string',
-        created_at: 'string (ISO 8601)',
+    description: 'List agents, newest first, a page at a time',
+    parameters: {
+      query: {
+        q: 'string (optional, case-insensitive name substring)',
+        limit: 'number (optional, default: 50, max: 200)',
+        offset: 'number (optional, default: 0)',
       },
-    ],
+    },
+    response: {
+      agents: [
+        {
+          id: 'string',
+          name: 'string',
+          created_at: 'string (ISO 8601)',
+        },
+      ],
+      total: 'number',
+      limit: 'number',
+      offset: 'number',
+    },
     example: {
       response: JSON.stringify(
-        [
-          { id: 'Ezra', name: 'Ezra', created_at: '2024-01-01T00:00:00Z' },
-        ],
+        {
+          agents: [
+            { id: 'Ezra', name: 'Ezra', created_at: '2024-01-01T00:00:00Z' },
+          ],
+          total: 1,
+          limit: 50,
+          offset: 0,
+        },
         null,
         2
       ),
@@ -264,6 +281,16 @@ const endpoints: Endpoint[] = [
         content: 'string',
         timestamp: 'string',
         relevance_score: 'number',
+        score_inputs: {
+          auto: 'boolean',
+          age_days: 'number',
+          recency: 'number (0-1)',
+          length: 'number',
+          specificity: 'number (0-1)',
+          access_count: 'number',
+          last_accessed_at: 'string (optional)',
+          access: 'number (0-1)',
+        },
       },
     ],
   },
@@ -279,7 +306,7 @@ const endpoints: Endpoint[] = [
         summary: 'string (required)',
         content: 'string (required)',
         timestamp: 'string (optional, ISO 8601)',
-        relevance_score: 'number (optional, 0-1)',
+        relevance_score: 'number (optional, 0-1; computed when omitted or 0)',
       },
     },
     response: { status: 'created' },
@@ -405,23 +432,32 @@ const endpoints: Endpoint[] = [
   {
     method: 'GET',
     path: '/api/agent/:id/conversations',
-    description: 'Get all conversations for an agent',
+    description: 'List conversations for an agent, most recently started first, a page at a time',
     parameters: {
       path: {
         id: 'string - Agent ID',
       },
       query: {
-        limit: 'number (optional, default: 50)',
+        platform: 'string (optional, discord|web)',
+        since: 'string (optional, RFC3339; started at or after)',
+        until: 'string (optional, RFC3339; started before)',
+        limit: 'number (optional, default: 50, max: 200)',
+        offset: 'number (optional, default: 0)',
       },
     },
-    response: [
-      {
-        id: 'string',
-        channel_id: 'string',
-        platform: 'string',
-        started_at: 'string',
-      },
-    ],
+    response: {
+      conversations: [
+        {
+          id: 'string',
+          channel_id: 'string',
+          platform: 'string',
+          started_at: 'string',
+        },
+      ],
+      total: 'number',
+      limit: 'number',
+      offset: 'number',
+    },
   },
   {
     method: 'GET',
@@ -470,6 +506,34 @@ const endpoints: Endpoint[] = [
       channel_id: 'string',
     },
   },
+  {
+    method: 'GET',
+    path: '/api/agent/:id/conversation-history/export',
+    description: 'Download an entire channel conversation as JSON or Markdown (streamed)',
+    parameters: {
+      path: {
+        id: 'string - Agent ID',
+      },
+      query: {
+        channel_id: 'string (optional)',
+        format: "'json' | 'markdown' (optional, default: 'json')",
+      },
+    },
+    response: {
+      channel_id: 'string',
+      exported_at: 'string',
+      messages: [
+        {
+          id: 'string',
+          content: 'string',
+          role: 'string',
+          platform: 'string',
+          timestamp: 'string',
+          sender_name: 'string',
+        },
+      ],
+    },
+  },
 ];
 
 const methodColors = {
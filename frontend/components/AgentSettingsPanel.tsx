@@ -388,6 +388,73 @@ export default function AgentSettingsPanel({
               </div>
             </div>
 
+            {/* Conversation History */}
+            <div>
+              <div className="flex items-center space-x-2 mb-2">
+                <label className="text-xs font-semibold text-gray-400 uppercase tracking-wide">
+                  CONVERSATION HISTORY
+                </label>
+                <Tooltip content="Recent messages included in each prompt. Fewer is cheaper; 0 relies on memory alone">
+                  <Info size={12} className="text-gray-500 cursor-help" />
+                </Tooltip>
+              </div>
+              <div>
+                <label className="block text-xs text-gray-500 mb-1">Recent messages (0 - 100, empty for default)</label>
+                <input
+                  type="number"
+                  min="0"
+                  max="100"
+                  value={config.max_prompt_history ?? ''}
+                  onChange={(e) =>
+                    setConfig({
+                      ...config,
+                      max_prompt_history: e.target.value === '' ? null : Number(e.target.value),
+                    })
+                  }
+                  className="w-full px-3 py-2 bg-gray-800 border border-gray-700 rounded text-white text-sm focus:outline-none focus:ring-2 focus:ring-blue-500"
+                  placeholder="10"
+                />
+              </div>
+            </div>
+
+            {/* Content Filter */}
+            <div>
+              <div className="flex items-center space-x-2 mb-2">
+                <label className="text-xs font-semibold text-gray-400 uppercase tracking-wide">
+                  CONTENT FILTER
+                </label>
+                <Tooltip content="Check replies before they are posted. Local uses the server's pattern list; moderation also asks a moderation model">
+                  <Info size={12} className="text-gray-500 cursor-help" />
+                </Tooltip>
+              </div>
+              <div className="space-y-3">
+                <div>
+                  <label className="block text-xs text-gray-500 mb-1">Mode</label>
+                  <select
+                    value={config.content_filter || ''}
+                    onChange={(e) => setConfig({ ...config, content_filter: e.target.value })}
+                    className="w-full px-3 py-2 bg-gray-800 border border-gray-700 rounded text-white text-sm focus:outline-none focus:ring-2 focus:ring-blue-500"
+                  >
+                    <option value="">Off</option>
+                    <option value="local">Local patterns</option>
+                    <option value="moderation">Moderation model</option>
+                  </select>
+                </div>
+                <div>
+                  <label className="block text-xs text-gray-500 mb-1">Filtered replies</label>
+                  <select
+                    value={config.content_filter_action || 'replace'}
+                    onChange={(e) => setConfig({ ...config, content_filter_action: e.target.value })}
+                    disabled={!config.content_filter}
+                    className="w-full px-3 py-2 bg-gray-800 border border-gray-700 rounded text-white text-sm focus:outline-none focus:ring-2 focus:ring-blue-500 disabled:opacity-50"
+                  >
+                    <option value="replace">Replace with a fallback message</option>
+                    <option value="block">Send nothing</option>
+                  </select>
+                </div>
+              </div>
+            </div>
+
             {/* Rate Limiting */}
             <div>
               <div className="flex items-center space-x-2 mb-2">
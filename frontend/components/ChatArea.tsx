@@ -13,6 +13,7 @@ interface Message {
   toolCalls?: ToolCall[];
   thinking?: string;
   reasoning?: string;
+  truncated?: boolean; // Cut off at the model's length limit; "continue" resumes it
 }
 
 interface ExtendedToolCall extends ToolCall {
@@ -60,18 +61,20 @@ export default function ChatArea({ agentID, userID, onMessageSent }: ChatAreaPro
     scrollToBottom();
   }, [messages]);
 
-  const handleSend = async () => {
-    if (!input.trim() || loading) return;
+  const handleSend = async (text: string = input) => {
+    if (!text.trim() || loading) return;
 
     const userMessage: Message = {
       role: 'user',
-      content: input,
+      content: text,
       timestamp: new Date(),
     };
 
     setMessages((prev) => [...prev, userMessage]);
-    const messageToSend = input;
-    setInput('');
+    const messageToSend = text;
+    if (text === input) {
+      setInput('');
+    }
     setLoading(true);
 
     const startTime = Date.now();
@@ -88,6 +91,7 @@ export default function ChatArea({ agentID, userID, onMessageSent }: ChatAreaPro
           duration: parseFloat(duration),
           toolCalls: response.tool_calls || [],
           reasoning: response.content?.includes('Reasoning:') ? 'Internal reasoning process' : undefined,
+          truncated: response.truncated,
         };
         setMessages((prev) => [...prev, agentMessage]);
       }
@@ -309,6 +313,18 @@ export default function ChatArea({ agentID, userID, onMessageSent }: ChatAreaPro
                     </button>
                   </>
                 )}
+                {msg.role === 'agent' && msg.truncated && idx === messages.length - 1 && !loading && (
+                  <>
+                    <span>•</span>
+                    <button
+                      onClick={() => handleSend('continue')}
+                      className="text-blue-400 hover:text-blue-300"
+                      title="The reply hit the length limit"
+                    >
+                      Continue
+                    </button>
+                  </>
+                )}
               </div>
             </div>
           </div>
@@ -347,7 +363,7 @@ export default function ChatArea({ agentID, userID, onMessageSent }: ChatAreaPro
             <option>User</option>
           </select>
           <button
-            onClick={handleSend}
+            onClick={() => handleSend()}
             disabled={loading || !input.trim()}
             className="px-4 py-2 bg-blue-600 text-white rounded-lg hover:bg-blue-700 disabled:opacity-50 disabled:cursor-not-allowed flex items-center space-x-2 transition-colors"
           >
@@ -68,7 +68,7 @@ export default function ContextWindowPanel({
       }
     } catch (error: any) {
       console.error('Failed to save memory:', error);
-      const errorMsg = error?.response?.data?.error || error?.message || 'Failed to save memory block';
+      const errorMsg = error?.response?.data?.error?.message || error?.message || 'Failed to save memory block';
       alert(`Failed to save memory block: ${errorMsg}`);
       // Don't refresh on error - preserve user's input
     } finally {
@@ -83,7 +83,6 @@ export default function ContextWindowPanel({
       await createArchivalMemory(agentID, {
         summary: newMemoryContent.substring(0, 100),
         content: newMemoryContent,
-        relevance_score: 0.5,
       });
       setNewMemoryContent('');
       setShowAddMemory(false);
@@ -149,7 +148,7 @@ export default function ContextWindowPanel({
       }
     } catch (error: any) {
       console.error('Failed to save memory block:', error);
-      const errorMsg = error?.response?.data?.error || error?.message || 'Failed to save memory block';
+      const errorMsg = error?.response?.data?.error?.message || error?.message || 'Failed to save memory block';
       alert(`Failed to save memory block: ${errorMsg}`);
       // Don't clear editing state on error - let user try again
     } finally {
@@ -171,7 +170,7 @@ export default function ContextWindowPanel({
       }
     } catch (error: any) {
       console.error('Failed to create memory block:', error);
-      const errorMsg = error?.response?.data?.error || error?.message || 'Failed to create memory block';
+      const errorMsg = error?.response?.data?.error?.message || error?.message || 'Failed to create memory block';
       alert(`Failed to create memory block: ${errorMsg}`);
       // Don't clear form on error - let user try again
     } finally {
@@ -536,6 +535,14 @@ export default function ContextWindowPanel({
                 <div className="flex-1 min-w-0">
                   <div className="text-xs text-gray-400 mb-1">
                     {new Date(memory.timestamp).toLocaleString()}
+                    <span
+                      className="ml-2 text-gray-500"
+                      title={memory.score_inputs
+                        ? `${memory.score_inputs.auto ? 'Automatic' : 'Set by hand'} · recency ${memory.score_inputs.recency.toFixed(2)} · specificity ${memory.score_inputs.specificity.toFixed(2)} · ${memory.score_inputs.length} chars · ${memory.score_inputs.access_count} accesses`
+                        : undefined}
+                    >
+                      relevance {memory.relevance_score.toFixed(2)}
+                    </span>
                   </div>
                   <div className="text-xs text-gray-300 line-clamp-2">
                     {memory.summary || memory.content.substring(0, 100)}
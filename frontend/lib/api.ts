@@ -19,10 +19,12 @@ export interface AgentIdentity {
 export interface MemoryBlock {
   name: string;
   content: string;
+  order?: number;
   updated_at: string;
 }
 
 export interface ArchivalPointer {
+  id: string;
   summary: string;
   timestamp: string;
   relevance_score: number;
@@ -39,6 +41,8 @@ export interface ChatResponse {
   content: string;
   tool_calls: ToolCall[];
   ignored: boolean;
+  // The reply hit the model's length limit; sending "continue" resumes it
+  truncated?: boolean;
 }
 
 export interface ToolCall {
@@ -55,6 +59,7 @@ export interface ChatRequest {
 export interface MemoryUpdateRequest {
   block_name: string;
   content: string;
+  order?: number;
 }
 
 // New types for ADE features
@@ -64,9 +69,24 @@ export interface Agent {
   created_at: string;
 }
 
+export interface AgentPage {
+  agents: Agent[];
+  total: number;
+  limit: number;
+  offset: number;
+}
+
 export interface AgentConfig {
   model: string;
+  // LLM provider for the agent's turns; unset uses the server default
+  provider?: string;
   system_instructions: string;
+  // Recent messages included in the prompt; unset uses the server default, 0 includes none
+  max_prompt_history?: number | null;
+  // Output filtering: '' (off), 'local' or 'moderation'
+  content_filter?: string;
+  // What a filtered reply becomes: 'replace' (fallback message, default) or 'block'
+  content_filter_action?: string;
 }
 
 export interface ContextStats {
@@ -74,12 +94,24 @@ export interface ContextStats {
   total_tokens: number;
 }
 
+export interface ArchivalScoreInputs {
+  auto: boolean;
+  age_days: number;
+  recency: number;
+  length: number;
+  specificity: number;
+  access_count: number;
+  last_accessed_at?: string;
+  access: number;
+}
+
 export interface ArchivalMemory {
   id: string;
   summary: string;
   content: string;
   timestamp: string;
   relevance_score: number;
+  score_inputs?: ArchivalScoreInputs;
 }
 
 export interface Tool {
@@ -151,20 +183,29 @@ export async function sendChatMessage(
 export async function updateMemoryBlock(
   agentID: string,
   blockName: string,
-  content: string
+  content: string,
+  order?: number
 ): Promise<void> {
   await apiClient.post(`/api/memory/${agentID}/update`, {
     block_name: blockName,
     content,
+    order,
   } as MemoryUpdateRequest);
 }
 
 // New API functions for ADE features
-export async function listAgents(): Promise<Agent[]> {
-  const response = await apiClient.get<Agent[]>('/api/agents');
+export async function listAgentsPage(
+  params: { q?: string; limit?: number; offset?: number } = {}
+): Promise<AgentPage> {
+  const response = await apiClient.get<AgentPage>('/api/agents', { params });
   return response.data;
 }
 
+export async function listAgents(): Promise<Agent[]> {
+  const page = await listAgentsPage({ limit: 200 });
+  return page.agents;
+}
+
 export async function getAgentConfig(agentID: string): Promise<AgentConfig> {
   const response = await apiClient.get<AgentConfig>(`/api/agent/${agentID}/config`);
   return response.data;
@@ -194,7 +235,7 @@ export async function getArchivalMemories(agentID: string): Promise<ArchivalMemo
 
 export async function createArchivalMemory(
   agentID: string,
-  memory: Omit<ArchivalMemory, 'timestamp' | 'id'>
+  memory: Omit<ArchivalMemory, 'timestamp' | 'id' | 'relevance_score' | 'score_inputs'> & { relevance_score?: number }
 ): Promise<void> {
   await apiClient.post(`/api/agent/${agentID}/archival-memories`, {
     ...memory,
@@ -246,13 +287,26 @@ export async function fetchAllMessages(agentID: string, limit: number = 100): Pr
   return response.data;
 }
 
-export async function fetchAllConversations(agentID: string, limit: number = 50): Promise<Conversation[]> {
-  const response = await apiClient.get<Conversation[]>(`/api/agent/${agentID}/conversations`, {
-    params: { limit },
-  });
+export interface ConversationPage {
+  conversations: Conversation[];
+  total: number;
+  limit: number;
+  offset: number;
+}
+
+export async function fetchConversationsPage(
+  agentID: string,
+  params: { platform?: string; since?: string; until?: string; limit?: number; offset?: number } = {}
+): Promise<ConversationPage> {
+  const response = await apiClient.get<ConversationPage>(`/api/agent/${agentID}/conversations`, { params });
   return response.data;
 }
 
+export async function fetchAllConversations(agentID: string, limit: number = 50): Promise<Conversation[]> {
+  const page = await fetchConversationsPage(agentID, { limit });
+  return page.conversations;
+}
+
 export async function fetchAllUsers(agentID: string): Promise<User[]> {
   const response = await apiClient.get<User[]>(`/api/agent/${agentID}/users`);
   return response.data;